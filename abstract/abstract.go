@@ -0,0 +1,158 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package abstract computes a short abstract of every zettel, which is
+// provided as the "abstract" property of its meta data.
+//
+// Abstracts are cached in memory. A cached abstract is removed when the
+// zettel is changed.
+package abstract
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"zettelstore.de/z/ast"
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/encoder"
+	"zettelstore.de/z/parser"
+	"zettelstore.de/z/place"
+)
+
+// MaxLength is the maximum number of characters of an abstract.
+const MaxLength = 200
+
+// absPlace adds the abstract to all meta data returned by another place.
+type absPlace struct {
+	place.Place
+
+	mx    sync.Mutex // protects cache and gen
+	cache map[id.Zid]string
+	gen   uint64 // incremented on every change, to detect stale abstracts
+}
+
+// NewPlace wraps the given place, so that it returns meta data together with
+// the abstract of the zettel.
+func NewPlace(p place.Place) place.Place {
+	ap := &absPlace{Place: p, cache: make(map[id.Zid]string)}
+	p.RegisterChangeObserver(ap.observe)
+	return ap
+}
+
+func (ap *absPlace) observe(reason place.ChangeReason, zid id.Zid) {
+	ap.mx.Lock()
+	if reason == place.OnReload || !zid.IsValid() {
+		ap.cache = make(map[id.Zid]string)
+	} else {
+		delete(ap.cache, zid)
+	}
+	ap.gen++
+	ap.mx.Unlock()
+}
+
+// GetZettel retrieves a specific zettel.
+func (ap *absPlace) GetZettel(ctx context.Context, zid id.Zid) (domain.Zettel, error) {
+	zettel, err := ap.Place.GetZettel(ctx, zid)
+	if err != nil {
+		return zettel, err
+	}
+	ap.mx.Lock()
+	abstract, ok := ap.cache[zid]
+	gen := ap.gen
+	ap.mx.Unlock()
+	if !ok {
+		abstract = ap.compute(zettel, gen)
+	}
+	setAbstract(zettel.Meta, abstract)
+	return zettel, nil
+}
+
+// GetMeta retrieves just the meta data of a specific zettel.
+func (ap *absPlace) GetMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error) {
+	m, err := ap.Place.GetMeta(ctx, zid)
+	if err != nil {
+		return m, err
+	}
+	ap.addAbstract(ctx, m)
+	return m, nil
+}
+
+// SelectMeta returns all zettel meta data that match the selection
+// criteria. The result is ordered by descending zettel id.
+func (ap *absPlace) SelectMeta(
+	ctx context.Context, f *place.Filter, s *place.Sorter) ([]*meta.Meta, error) {
+	metaList, err := ap.Place.SelectMeta(ctx, f, s)
+	if err != nil {
+		return metaList, err
+	}
+	for _, m := range metaList {
+		ap.addAbstract(ctx, m)
+	}
+	return metaList, nil
+}
+
+func (ap *absPlace) addAbstract(ctx context.Context, m *meta.Meta) {
+	ap.mx.Lock()
+	abstract, ok := ap.cache[m.Zid]
+	gen := ap.gen
+	ap.mx.Unlock()
+	if !ok {
+		zettel, err := ap.Place.GetZettel(ctx, m.Zid)
+		if err != nil {
+			return
+		}
+		abstract = ap.compute(zettel, gen)
+	}
+	setAbstract(m, abstract)
+}
+
+// compute calculates the abstract of the zettel and stores it in the cache,
+// if no zettel was changed since gen was retrieved.
+func (ap *absPlace) compute(zettel domain.Zettel, gen uint64) string {
+	abstract := FromBlocks(parser.ParseZettel(zettel, "").Ast)
+	ap.mx.Lock()
+	if ap.gen == gen {
+		ap.cache[zettel.Meta.Zid] = abstract
+	}
+	ap.mx.Unlock()
+	return abstract
+}
+
+func setAbstract(m *meta.Meta, abstract string) {
+	if abstract != "" {
+		m.Set(meta.KeyAbstract, abstract)
+	}
+}
+
+// FromBlocks returns the text of the first top-level paragraph, shortened to
+// MaxLength characters.
+func FromBlocks(bs ast.BlockSlice) string {
+	for _, bn := range bs {
+		pn, ok := bn.(*ast.ParaNode)
+		if !ok {
+			continue
+		}
+		var sb strings.Builder
+		if _, err := encoder.Create("text").WriteInlines(&sb, pn.Inlines); err != nil {
+			return ""
+		}
+		text := strings.Join(strings.Fields(sb.String()), " ")
+		if utf8.RuneCountInString(text) <= MaxLength {
+			return text
+		}
+		runes := []rune(text)[:MaxLength-1]
+		return strings.TrimSpace(string(runes)) + "…"
+	}
+	return ""
+}
@@ -0,0 +1,48 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package abstract_test provides some tests for abstracts.
+package abstract_test
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"zettelstore.de/z/abstract"
+	"zettelstore.de/z/input"
+	"zettelstore.de/z/parser"
+
+	_ "zettelstore.de/z/parser/zettelmark"
+)
+
+func TestFromBlocks(t *testing.T) {
+	testcases := []struct {
+		src string
+		exp string
+	}{
+		{"", ""},
+		{"=== Heading\n", ""},
+		{"=== Heading\nFirst  //paragraph//\n\nSecond", "First paragraph"},
+		{"* item\n\nText", "Text"},
+	}
+	for _, tc := range testcases {
+		bs := parser.ParseBlocks(input.NewInput(tc.src), nil, "zmk")
+		if got := abstract.FromBlocks(bs); got != tc.exp {
+			t.Errorf("%q: expected %q, but got %q", tc.src, tc.exp, got)
+		}
+	}
+
+	long := strings.Repeat("word ", abstract.MaxLength)
+	got := abstract.FromBlocks(parser.ParseBlocks(input.NewInput(long), nil, "zmk"))
+	if utf8.RuneCountInString(got) > abstract.MaxLength || !strings.HasSuffix(got, "…") {
+		t.Errorf("Abstract not shortened: %q", got)
+	}
+}
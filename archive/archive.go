@@ -0,0 +1,222 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package archive submits external links to a web archive.
+//
+// When a zettel is saved, all its external links that were not archived
+// before are submitted to the archive, e.g. the Internet Archive with a save
+// URL like "https://web.archive.org/save/". The URL of the resulting snapshot
+// is stored in the meta key "archive" of the zettel. A link counts as
+// archived, if one of the snapshot URLs ends with the link.
+package archive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/linkcheck"
+	"zettelstore.de/z/place"
+)
+
+// Archiver submits links to a web archive.
+type Archiver struct {
+	saveURL string
+	agent   string
+	client  *http.Client
+
+	mx        sync.Mutex      // protects pending and submitted
+	pending   map[id.Zid]bool // zettel in queue
+	submitted map[string]bool // links that were archived since the start
+	queue     chan id.Zid
+}
+
+// New creates a new archiver. A link is submitted by retrieving the
+// concatenation of the save URL and the link.
+func New(saveURL, agent string) *Archiver {
+	return &Archiver{
+		saveURL:   saveURL,
+		agent:     agent,
+		client:    &http.Client{Timeout: 2 * time.Minute},
+		pending:   make(map[id.Zid]bool),
+		submitted: make(map[string]bool),
+		queue:     make(chan id.Zid, 64),
+	}
+}
+
+// Port is the interface of the place whose zettel are archived.
+type Port interface {
+	// RegisterChangeObserver registers an observer that will be notified
+	// if a zettel was found to be changed.
+	RegisterChangeObserver(place.ObserverFunc)
+
+	// GetZettel retrieves a specific zettel.
+	GetZettel(ctx context.Context, zid id.Zid) (domain.Zettel, error)
+
+	// CanUpdateZettel returns true, if place could possibly update the given zettel.
+	CanUpdateZettel(ctx context.Context, zettel domain.Zettel) bool
+
+	// UpdateZettel updates an existing zettel.
+	UpdateZettel(ctx context.Context, zettel domain.Zettel) error
+}
+
+// Start archives the links of every created or updated zettel of the given
+// place, until the context is done.
+func (a *Archiver) Start(ctx context.Context, port Port) {
+	port.RegisterChangeObserver(a.observe)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case zid := <-a.queue:
+				a.mx.Lock()
+				delete(a.pending, zid)
+				a.mx.Unlock()
+				if err := a.archiveZettel(ctx, port, zid); err != nil {
+					log.Printf("Archive %v: %v", zid, err)
+				}
+			}
+		}
+	}()
+}
+
+func (a *Archiver) observe(reason place.ChangeReason, zid id.Zid) {
+	if (reason != place.OnCreate && reason != place.OnUpdate) || !zid.IsValid() {
+		return
+	}
+	a.mx.Lock()
+	defer a.mx.Unlock()
+	if a.pending[zid] {
+		return
+	}
+	select {
+	case a.queue <- zid:
+		a.pending[zid] = true
+	default:
+		log.Printf("Archive %v: too many pending zettel", zid)
+	}
+}
+
+// archiveZettel submits all links of the zettel that were not archived
+// before and stores the snapshot URLs.
+func (a *Archiver) archiveZettel(ctx context.Context, port Port, zid id.Zid) error {
+	zettel, err := port.GetZettel(ctx, zid)
+	if err != nil {
+		return err
+	}
+	snapshots := zettel.Meta.GetListOrNil(meta.KeyArchive)
+	links := a.notSubmitted(Missing(linkcheck.ExternalLinks(zettel), snapshots))
+	if len(links) == 0 || !port.CanUpdateZettel(ctx, zettel) {
+		return nil
+	}
+	var added []string
+	for _, link := range links {
+		snapshot, err1 := a.Snapshot(ctx, link)
+		if err1 != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("Archive %v: %v", link, err1)
+			continue
+		}
+		added = append(added, snapshot)
+		a.mx.Lock()
+		a.submitted[link] = true
+		a.mx.Unlock()
+	}
+	if len(added) == 0 {
+		return nil
+	}
+
+	// The zettel may have been changed while the links were submitted.
+	zettel, err = port.GetZettel(ctx, zid)
+	if err != nil {
+		return err
+	}
+	m := zettel.Meta.Clone()
+	m.Set(meta.KeyArchive, strings.Join(append(m.GetListOrNil(meta.KeyArchive), added...), " "))
+	zettel.Meta = m
+	return port.UpdateZettel(ctx, zettel)
+}
+
+// notSubmitted removes all links that were archived since the start. An
+// archive may normalize a link, so that its snapshot URL does not end with
+// the link.
+func (a *Archiver) notSubmitted(links []string) []string {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+	result := links[:0]
+	for _, link := range links {
+		if !a.submitted[link] {
+			result = append(result, link)
+		}
+	}
+	return result
+}
+
+// Missing returns all links that do not have a snapshot.
+func Missing(links, snapshots []string) []string {
+	var result []string
+	for _, link := range links {
+		if !hasSnapshot(link, snapshots) {
+			result = append(result, link)
+		}
+	}
+	return result
+}
+
+func hasSnapshot(link string, snapshots []string) bool {
+	for _, snapshot := range snapshots {
+		if strings.HasSuffix(snapshot, link) {
+			return true
+		}
+	}
+	return false
+}
+
+// Snapshot submits the link to the archive and returns the URL of the
+// snapshot. This is the value of the header "Content-Location", or the URL
+// of the response after all redirects.
+func (a *Archiver) Snapshot(ctx context.Context, link string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.saveURL+link, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", a.agent)
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("archive returned %v", resp.Status)
+	}
+	snapshot := resp.Request.URL.String()
+	if loc := resp.Header.Get("Content-Location"); loc != "" {
+		if u, err1 := resp.Request.URL.Parse(loc); err1 == nil {
+			snapshot = u.String()
+		}
+	}
+	if snapshot == a.saveURL+link {
+		return "", fmt.Errorf("archive returned no snapshot URL")
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,68 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package archive submits external links to a web archive.
+package archive
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMissing(t *testing.T) {
+	links := []string{"https://a.example", "https://b.example/x", "https://c.example"}
+	snapshots := []string{"https://archive.example/web/1/https://b.example/x"}
+	got := Missing(links, snapshots)
+	if len(got) != 2 || got[0] != links[0] || got[1] != links[2] {
+		t.Errorf("unexpected missing links %v", got)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/save/"):
+			target := "http://" + r.Host + "/web/1/" + strings.TrimPrefix(r.URL.Path, "/save/")
+			http.Redirect(w, r, target, http.StatusFound)
+		case strings.HasPrefix(r.URL.Path, "/loc/"):
+			w.Header().Set("Content-Location", "/web/2/"+strings.TrimPrefix(r.URL.Path, "/loc/"))
+		case strings.HasPrefix(r.URL.Path, "/web/"):
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	testcases := []struct {
+		save string
+		exp  string
+	}{
+		{"/save/", "/web/1/https://a.example/"},
+		{"/loc/", "/web/2/https://a.example/"},
+		{"/web/", ""},
+		{"/fail/", ""},
+	}
+	for _, tc := range testcases {
+		a := New(srv.URL+tc.save, "test")
+		got, err := a.Snapshot(context.Background(), "https://a.example/")
+		if tc.exp == "" {
+			if err == nil {
+				t.Errorf("%s: expected an error, but got %q", tc.save, got)
+			}
+			continue
+		}
+		if err != nil || got != srv.URL+tc.exp {
+			t.Errorf("%s: expected %q, but got %q (%v)", tc.save, srv.URL+tc.exp, got, err)
+		}
+	}
+}
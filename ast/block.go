@@ -114,9 +114,42 @@ func (hn *HRuleNode) Accept(v Visitor) { v.VisitHRule(hn) }
 type NestedListNode struct {
 	Code  NestedListCode
 	Items []ItemSlice
+	Tasks []Task // Task state of the items, may be shorter than Items
 	Attrs *Attributes
 }
 
+// Task returns the task state of the item with the given index.
+func (ln *NestedListNode) Task(i int) Task {
+	if i < len(ln.Tasks) {
+		return ln.Tasks[i]
+	}
+	return Task{}
+}
+
+// SetTask sets the task state of the item with the given index.
+func (ln *NestedListNode) SetTask(i int, task Task) {
+	for len(ln.Tasks) <= i {
+		ln.Tasks = append(ln.Tasks, Task{})
+	}
+	ln.Tasks[i] = task
+}
+
+// Task describes whether an item of a list is a task, and whether it is done.
+type Task struct {
+	State TaskState
+	Pos   int // Position of the task marker in the source
+}
+
+// TaskState specifies the state of a task.
+type TaskState int
+
+// Values for TaskState
+const (
+	TaskNone TaskState = iota // Item is no task.
+	TaskOpen                  // Task is not done: "[ ]"
+	TaskDone                  // Task is done: "[x]"
+)
+
 // NestedListCode specifies the actual list type.
 type NestedListCode int
 
@@ -0,0 +1,218 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package audit records security-relevant events.
+//
+// Events are written to the log and kept in memory, so that they can be shown
+// to the owner. If an audit file is opened, they are appended to it too, one
+// JSON object per line, and the most recent entries are read from it at
+// startup. Entries are only appended, never changed. If there are too many
+// entries, the oldest are dropped from memory, but not from the file.
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+)
+
+// Event names the kind of a recorded event.
+type Event string
+
+// Values for Event
+const (
+	EventLogin       Event = "login"
+	EventLoginFailed Event = "login-failed"
+	EventDenied      Event = "denied"
+	EventUpdate      Event = "update"
+	EventRename      Event = "rename"
+	EventDelete      Event = "delete"
+)
+
+// Entry is one event of the audit trail.
+type Entry struct {
+	Time   time.Time `json:"time"`
+	Event  Event     `json:"event"`
+	User   string    `json:"user,omitempty"` // User identification, empty if not authenticated
+	Zid    id.Zid    `json:"zid,omitempty"`  // Affected zettel, if any
+	Detail string    `json:"detail,omitempty"`
+}
+
+// maxEntries is the maximum number of entries kept in memory.
+const maxEntries = 1000
+
+var trail struct {
+	mx      sync.Mutex
+	entries []Entry
+	file    *os.File // audit file, if opened
+}
+
+// Open reads the most recent entries of the given audit file and appends all
+// following entries to it. The file is created, if it does not exist.
+func Open(path string) error {
+	entries, err := readFile(path)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	trail.mx.Lock()
+	defer trail.mx.Unlock()
+	if trail.file != nil {
+		trail.file.Close()
+	}
+	trail.file = f
+	trail.entries = append(entries, trail.entries...)
+	if n := len(trail.entries); n > maxEntries {
+		trail.entries = append([]Entry(nil), trail.entries[n-maxEntries:]...)
+	}
+	return nil
+}
+
+// readFile returns the last entries of the audit file. Lines that cannot be
+// parsed are ignored.
+func readFile(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Entry
+		if json.Unmarshal(scanner.Bytes(), &e) != nil {
+			continue
+		}
+		if len(entries) >= maxEntries {
+			entries = append(entries[:0], entries[1:]...)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// Record appends a new entry to the audit trail.
+func Record(event Event, user *meta.Meta, zid id.Zid, detail string) {
+	e := Entry{
+		Time:   time.Now(),
+		Event:  event,
+		User:   userIdent(user),
+		Zid:    zid,
+		Detail: detail,
+	}
+	if zid.IsValid() {
+		log.Printf("Audit: %v user=%q zid=%v %v", e.Event, e.User, e.Zid, e.Detail)
+	} else {
+		log.Printf("Audit: %v user=%q %v", e.Event, e.User, e.Detail)
+	}
+	trail.mx.Lock()
+	if len(trail.entries) >= maxEntries {
+		trail.entries = append(trail.entries[:0], trail.entries[1:]...)
+	}
+	trail.entries = append(trail.entries, e)
+	if trail.file != nil {
+		if err := writeEntry(trail.file, e); err != nil {
+			log.Println("Audit: unable to write audit file:", err)
+		}
+	}
+	trail.mx.Unlock()
+}
+
+func writeEntry(f *os.File, e Entry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// RecordIdent appends a new entry for a user that is only known by its
+// identification, e.g. for a failed login.
+func RecordIdent(event Event, ident string, detail string) {
+	m := meta.New(id.Invalid)
+	m.Set(meta.KeyUserID, ident)
+	Record(event, m, id.Invalid, detail)
+}
+
+func userIdent(user *meta.Meta) string {
+	if user == nil {
+		return ""
+	}
+	if ident, ok := user.Get(meta.KeyUserID); ok {
+		return ident
+	}
+	return user.Zid.String()
+}
+
+// Entries returns all entries in memory, the newest entry first.
+func Entries() []Entry {
+	trail.mx.Lock()
+	defer trail.mx.Unlock()
+	result := make([]Entry, len(trail.entries))
+	for i, e := range trail.entries {
+		result[len(result)-1-i] = e
+	}
+	return result
+}
+
+// NewMiddleware creates a middleware that records all requests that were
+// denied because of missing authentication or authorization.
+func NewMiddleware(
+	getUser func(context.Context) *meta.Meta) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The path must be saved, because the router may change it.
+			path := r.URL.Path
+			sw := statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(&sw, r)
+			if sw.status == http.StatusUnauthorized || sw.status == http.StatusForbidden {
+				Record(EventDenied, getUser(r.Context()), id.Invalid,
+					r.Method+" "+path+" ("+http.StatusText(sw.status)+")")
+			}
+		})
+	}
+}
+
+// NewDeniedHandler creates a handler that records a request as denied,
+// because the user must be authenticated, before the given handler is called,
+// e.g. to redirect to the login form.
+func NewDeniedHandler(getUser func(context.Context) *meta.Meta, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Record(EventDenied, getUser(r.Context()), id.Invalid,
+			r.Method+" "+r.URL.Path+" (login required)")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// statusWriter remembers the status code of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sw *statusWriter) WriteHeader(status int) {
+	sw.status = status
+	sw.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,91 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package audit records security-relevant events.
+package audit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"zettelstore.de/z/domain/meta"
+)
+
+func TestMiddleware(t *testing.T) {
+	getUser := func(context.Context) *meta.Meta { return nil }
+	status := http.StatusOK
+	h := NewMiddleware(getUser)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.URL.Path = "/changed"
+		w.WriteHeader(status)
+	}))
+	before := len(Entries())
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/h/1", nil))
+	if got := len(Entries()); got != before {
+		t.Errorf("expected no new entry, but got %d", got-before)
+	}
+	status = http.StatusForbidden
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/h/2", nil))
+	entries := Entries()
+	if len(entries) != before+1 {
+		t.Fatalf("expected one new entry, but got %d", len(entries)-before)
+	}
+	if e := entries[0]; e.Event != EventDenied || e.Detail != "GET /h/2 (Forbidden)" {
+		t.Errorf("unexpected entry %v", e)
+	}
+}
+
+func TestDeniedHandler(t *testing.T) {
+	getUser := func(context.Context) *meta.Meta { return nil }
+	called := false
+	h := NewDeniedHandler(getUser, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		http.Redirect(w, r, "/a", http.StatusFound)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/e/3", nil))
+	if !called {
+		t.Error("handler was not called")
+	}
+	if e := Entries()[0]; e.Event != EventDenied || e.Detail != "GET /e/3 (login required)" {
+		t.Errorf("unexpected entry %v", e)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	if err := Open(path); err != nil {
+		t.Fatal(err)
+	}
+	RecordIdent(EventLoginFailed, "mallory\n|x", "unknown user")
+	trail.mx.Lock()
+	trail.file.Close()
+	trail.file = nil
+	trail.entries = nil
+	trail.mx.Unlock()
+
+	if err := Open(path); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		trail.mx.Lock()
+		trail.file.Close()
+		trail.file = nil
+		trail.mx.Unlock()
+	}()
+	entries := Entries()
+	if len(entries) != 1 {
+		t.Fatalf("expected one entry, but got %v", entries)
+	}
+	if e := entries[0]; e.Event != EventLoginFailed || e.User != "mallory\n|x" || e.Detail != "unknown user" {
+		t.Errorf("unexpected entry %v", e)
+	}
+}
@@ -20,6 +20,7 @@ type anonPolicy struct {
 	expertMode    func() bool
 	getVisibility func(*meta.Meta) meta.Visibility
 	pre           Policy
+	trace         *Trace
 }
 
 func (ap *anonPolicy) CanReload(user *meta.Meta) bool {
@@ -49,9 +50,13 @@ func (ap *anonPolicy) CanDelete(user *meta.Meta, m *meta.Meta) bool {
 func (ap *anonPolicy) checkVisibility(m *meta.Meta) bool {
 	switch ap.getVisibility(m) {
 	case meta.VisibilitySimple:
-		return ap.simpleMode || ap.expertMode()
+		if ap.simpleMode {
+			return ap.trace.record(true, RuleSimpleMode, "simple mode allows simple-expert zettel")
+		}
+		return ap.trace.record(
+			ap.expertMode(), RuleExpertMode, "simple-expert zettel needs expert mode")
 	case meta.VisibilityExpert:
-		return ap.expertMode()
+		return ap.trace.record(ap.expertMode(), RuleExpertMode, "expert zettel needs expert mode")
 	}
-	return true
+	return ap.trace.record(true, RuleVisibility, "no authentication, every visibility is allowed")
 }
@@ -8,7 +8,7 @@
 // under this license.
 //-----------------------------------------------------------------------------
 
-// Package policy provides some interfaces and implementation for authorizsation policies.
+// Package policy provides some interfaces and implementation for authorization policies.
 package policy
 
 import (
@@ -16,18 +16,20 @@ import (
 	"zettelstore.de/z/domain/meta"
 )
 
-type defaultPolicy struct{}
+type defaultPolicy struct {
+	trace *Trace
+}
 
 func (d *defaultPolicy) CanReload(user *meta.Meta) bool {
-	return true
+	return d.trace.record(true, RuleDefault, "reloading is always allowed")
 }
 
 func (d *defaultPolicy) CanCreate(user *meta.Meta, newMeta *meta.Meta) bool {
-	return true
+	return d.trace.record(true, RuleDefault, "creating is always allowed")
 }
 
 func (d *defaultPolicy) CanRead(user *meta.Meta, m *meta.Meta) bool {
-	return true
+	return d.trace.record(true, RuleDefault, "reading is always allowed")
 }
 
 func (d *defaultPolicy) CanWrite(user *meta.Meta, oldMeta, newMeta *meta.Meta) bool {
@@ -45,7 +47,7 @@ func (d *defaultPolicy) CanDelete(user *meta.Meta, m *meta.Meta) bool {
 func (d *defaultPolicy) canChange(user *meta.Meta, m *meta.Meta) bool {
 	metaRo, ok := m.Get(meta.KeyReadOnly)
 	if !ok {
-		return true
+		return d.trace.record(true, RuleReadOnlyMeta, "zettel has no read-only key")
 	}
 	if user == nil {
 		// If we are here, there is no authentication.
@@ -53,17 +55,22 @@ func (d *defaultPolicy) canChange(user *meta.Meta, m *meta.Meta) bool {
 
 		// No authentication: check for owner-like restriction, because the user
 		// acts as an owner
-		return metaRo != meta.ValueUserRoleOwner && !meta.BoolValue(metaRo)
+		return d.trace.record(
+			metaRo != meta.ValueUserRoleOwner && !meta.BoolValue(metaRo),
+			RuleReadOnlyMeta, "read-only value "+metaRo+" without authentication")
 	}
 
 	userRole := runtime.GetUserRole(user)
 	switch metaRo {
 	case meta.ValueUserRoleReader:
-		return userRole > meta.UserRoleReader
+		return d.trace.record(
+			userRole > meta.UserRoleReader, RuleReadOnlyMeta, "zettel is read-only for readers")
 	case meta.ValueUserRoleWriter:
-		return userRole > meta.UserRoleWriter
+		return d.trace.record(
+			userRole > meta.UserRoleWriter, RuleReadOnlyMeta, "zettel is read-only for writers")
 	case meta.ValueUserRoleOwner:
-		return userRole > meta.UserRoleOwner
+		return d.trace.record(
+			userRole > meta.UserRoleOwner, RuleReadOnlyMeta, "zettel is read-only for owners")
 	}
-	return !meta.BoolValue(metaRo)
+	return d.trace.record(!meta.BoolValue(metaRo), RuleReadOnlyMeta, "read-only value "+metaRo)
 }
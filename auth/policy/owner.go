@@ -8,7 +8,7 @@
 // under this license.
 //-----------------------------------------------------------------------------
 
-// Package policy provides some interfaces and implementation for authorizsation policies.
+// Package policy provides some interfaces and implementation for authorization policies.
 package policy
 
 import (
@@ -22,6 +22,7 @@ type ownerPolicy struct {
 	isOwner       func(id.Zid) bool
 	getVisibility func(*meta.Meta) meta.Visibility
 	pre           Policy
+	trace         *Trace
 }
 
 func (o *ownerPolicy) CanReload(user *meta.Meta) bool {
@@ -29,24 +30,52 @@ func (o *ownerPolicy) CanReload(user *meta.Meta) bool {
 	// Both the default and the readonly policy allow to reload a place.
 
 	// Only the owner is allowed to reload a place
-	return o.userIsOwner(user)
+	return o.trace.record(o.userIsOwner(user), RuleOwner, "only the owner may reload")
 }
 
 func (o *ownerPolicy) CanCreate(user *meta.Meta, newMeta *meta.Meta) bool {
-	if user == nil || !o.pre.CanCreate(user, newMeta) {
+	if user == nil {
+		return o.denyAnon()
+	}
+	if !o.pre.CanCreate(user, newMeta) {
 		return false
 	}
-	return o.userIsOwner(user) || o.userCanCreate(user, newMeta)
+	if o.userIsOwner(user) {
+		return o.allowOwner()
+	}
+	return o.userCanCreate(user, newMeta)
 }
 
 func (o *ownerPolicy) userCanCreate(user *meta.Meta, newMeta *meta.Meta) bool {
 	if runtime.GetUserRole(user) == meta.UserRoleReader {
-		return false
+		return o.trace.record(false, RuleUserRole, "a reader must not create zettel")
 	}
 	if role, ok := newMeta.Get(meta.KeyRole); ok && role == meta.ValueRoleUser {
-		return false
+		return o.trace.record(false, RuleUserZettel, "only the owner may create user zettel")
+	}
+	for _, key := range ownerOnlyKeys {
+		if _, ok := newMeta.Get(key); ok {
+			return o.trace.record(false, RuleOwnerKeys, "only the owner may set key "+key)
+		}
+	}
+	if isAsset(newMeta) {
+		return o.trace.record(false, RuleAsset, "only the owner may create style or script zettel")
 	}
-	return true
+	return o.trace.record(true, RuleUserRole, "user is allowed to create zettel")
+}
+
+// ownerOnlyKeys are meta keys that only the owner is allowed to set or change.
+var ownerOnlyKeys = []string{
+	meta.KeyCSSZettel,
+	meta.KeyJSZettel,
+}
+
+// isAsset returns true, if the zettel may be included as a style or as a
+// script by the keys in ownerOnlyKeys. Only the owner may create or change
+// such a zettel, because it is executed by the browser of all users.
+func isAsset(m *meta.Meta) bool {
+	syntax := runtime.GetSyntax(m)
+	return syntax == "css" || syntax == "js"
 }
 
 func (o *ownerPolicy) CanRead(user *meta.Meta, m *meta.Meta) bool {
@@ -56,35 +85,44 @@ func (o *ownerPolicy) CanRead(user *meta.Meta, m *meta.Meta) bool {
 	if res, ok := o.checkVisibility(user, vis); ok {
 		return res
 	}
-	return o.userIsOwner(user) || o.userCanRead(user, m, vis)
+	if o.userIsOwner(user) {
+		return o.allowOwner()
+	}
+	return o.userCanRead(user, m, vis)
 }
 
 func (o *ownerPolicy) userCanRead(user *meta.Meta, m *meta.Meta, vis meta.Visibility) bool {
 	switch vis {
 	case meta.VisibilityOwner, meta.VisibilitySimple, meta.VisibilityExpert:
-		return false
+		return o.trace.record(false, RuleVisibility, "zettel is visible for the owner only")
 	case meta.VisibilityPublic:
-		return true
+		return o.trace.record(true, RuleVisibility, "zettel is public")
 	}
 	if user == nil {
-		return false
+		return o.trace.record(false, RuleVisibility, "zettel is visible after login only")
 	}
 	if role, ok := m.Get(meta.KeyRole); ok && role == meta.ValueRoleUser {
 		// Only the user can read its own zettel
-		return user.Zid == m.Zid
+		return o.trace.record(
+			user.Zid == m.Zid, RuleUserZettel, "a user may only read the own user zettel")
 	}
-	return true
+	return o.trace.record(true, RuleVisibility, "zettel is visible for authenticated users")
 }
 
 var noChangeUser = []string{
 	meta.KeyID,
+	meta.KeyQuotaSize,
+	meta.KeyQuotaZettel,
 	meta.KeyRole,
 	meta.KeyUserID,
 	meta.KeyUserRole,
 }
 
 func (o *ownerPolicy) CanWrite(user *meta.Meta, oldMeta, newMeta *meta.Meta) bool {
-	if user == nil || !o.pre.CanWrite(user, oldMeta, newMeta) {
+	if user == nil {
+		return o.denyAnon()
+	}
+	if !o.pre.CanWrite(user, oldMeta, newMeta) {
 		return false
 	}
 	vis := o.getVisibility(oldMeta)
@@ -92,55 +130,83 @@ func (o *ownerPolicy) CanWrite(user *meta.Meta, oldMeta, newMeta *meta.Meta) boo
 		return res
 	}
 	if o.userIsOwner(user) {
-		return true
+		return o.allowOwner()
 	}
 	if !o.userCanRead(user, oldMeta, vis) {
 		return false
 	}
+	for _, key := range ownerOnlyKeys {
+		if oldMeta.GetDefault(key, "") != newMeta.GetDefault(key, "") {
+			return o.trace.record(false, RuleOwnerKeys, "only the owner may change key "+key)
+		}
+	}
+	if isAsset(oldMeta) || isAsset(newMeta) {
+		return o.trace.record(false, RuleAsset, "only the owner may change style or script zettel")
+	}
 	if role, ok := oldMeta.Get(meta.KeyRole); ok && role == meta.ValueRoleUser {
 		// Here we know, that user.Zid == newMeta.Zid (because of userCanRead) and
 		// user.Zid == newMeta.Zid (because oldMeta.Zid == newMeta.Zid)
 		for _, key := range noChangeUser {
 			if oldMeta.GetDefault(key, "") != newMeta.GetDefault(key, "") {
-				return false
+				return o.trace.record(false, RuleUserZettel, "a user must not change key "+key)
 			}
 		}
-		return true
+		return o.trace.record(true, RuleUserZettel, "a user may change the own user zettel")
 	}
 	if runtime.GetUserRole(user) == meta.UserRoleReader {
-		return false
+		return o.trace.record(false, RuleUserRole, "a reader must not change zettel")
 	}
-	return o.userCanCreate(user, newMeta)
+	if role, ok := newMeta.Get(meta.KeyRole); ok && role == meta.ValueRoleUser {
+		return o.trace.record(false, RuleUserZettel, "only the owner may create user zettel")
+	}
+	return o.trace.record(true, RuleUserRole, "user is allowed to change zettel")
 }
 
 func (o *ownerPolicy) CanRename(user *meta.Meta, m *meta.Meta) bool {
-	if user == nil || !o.pre.CanRename(user, m) {
+	if user == nil {
+		return o.denyAnon()
+	}
+	if !o.pre.CanRename(user, m) {
 		return false
 	}
 	if res, ok := o.checkVisibility(user, o.getVisibility(m)); ok {
 		return res
 	}
-	return o.userIsOwner(user)
+	return o.trace.record(o.userIsOwner(user), RuleOwner, "only the owner may rename zettel")
 }
 
 func (o *ownerPolicy) CanDelete(user *meta.Meta, m *meta.Meta) bool {
-	if user == nil || !o.pre.CanDelete(user, m) {
+	if user == nil {
+		return o.denyAnon()
+	}
+	if !o.pre.CanDelete(user, m) {
 		return false
 	}
 	if res, ok := o.checkVisibility(user, o.getVisibility(m)); ok {
 		return res
 	}
-	return o.userIsOwner(user)
+	return o.trace.record(o.userIsOwner(user), RuleOwner, "only the owner may delete zettel")
 }
 
 func (o *ownerPolicy) checkVisibility(user *meta.Meta, vis meta.Visibility) (bool, bool) {
 	switch vis {
 	case meta.VisibilitySimple, meta.VisibilityExpert:
-		return o.userIsOwner(user) && o.expertMode(), true
+		if !o.userIsOwner(user) {
+			return o.trace.record(false, RuleVisibility, "zettel is visible for the owner only"), true
+		}
+		return o.trace.record(o.expertMode(), RuleExpertMode, "zettel needs expert mode"), true
 	}
 	return false, false
 }
 
+func (o *ownerPolicy) denyAnon() bool {
+	return o.trace.record(false, RuleUserRole, "anonymous users must not create or change zettel")
+}
+
+func (o *ownerPolicy) allowOwner() bool {
+	return o.trace.record(true, RuleOwner, "the owner is allowed to do everything")
+}
+
 func (o *ownerPolicy) userIsOwner(user *meta.Meta) bool {
 	if user == nil {
 		return false
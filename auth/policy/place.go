@@ -8,12 +8,14 @@
 // under this license.
 //-----------------------------------------------------------------------------
 
-// Package policy provides some interfaces and implementation for authorizsation policies.
+// Package policy provides some interfaces and implementation for authorization policies.
 package policy
 
 import (
 	"context"
+	"io"
 
+	"zettelstore.de/z/audit"
 	"zettelstore.de/z/domain"
 	"zettelstore.de/z/domain/id"
 	"zettelstore.de/z/domain/meta"
@@ -30,8 +32,10 @@ func PlaceWithPolicy(
 	expertMode func() bool,
 	isOwner func(id.Zid) bool,
 	getVisibility func(*meta.Meta) meta.Visibility,
+	getRules func() string,
 ) (place.Place, Policy) {
-	pol := newPolicy(simpleMode, withAuth, isReadOnlyMode, expertMode, isOwner, getVisibility)
+	pol := newPolicy(
+		simpleMode, withAuth, isReadOnlyMode, expertMode, isOwner, getVisibility, getRules)
 	return newPlace(place, pol), pol
 }
 
@@ -77,19 +81,28 @@ func (pp *polPlace) CanCreateZettel(ctx context.Context) bool {
 func (pp *polPlace) CreateZettel(
 	ctx context.Context, zettel domain.Zettel) (id.Zid, error) {
 	user := session.GetUser(ctx)
-	if pp.policy.CanCreate(user, zettel.Meta) {
+	if ForContext(ctx, pp.policy).CanCreate(user, zettel.Meta) {
 		return pp.place.CreateZettel(ctx, zettel)
 	}
 	return id.Invalid, place.NewErrNotAllowed("Create", user, id.Invalid)
 }
 
+func (pp *polPlace) CreateZettelFrom(
+	ctx context.Context, m *meta.Meta, r io.Reader) (id.Zid, error) {
+	user := session.GetUser(ctx)
+	if ForContext(ctx, pp.policy).CanCreate(user, m) {
+		return pp.place.CreateZettelFrom(ctx, m, r)
+	}
+	return id.Invalid, place.NewErrNotAllowed("Create", user, id.Invalid)
+}
+
 func (pp *polPlace) GetZettel(ctx context.Context, zid id.Zid) (domain.Zettel, error) {
 	zettel, err := pp.place.GetZettel(ctx, zid)
 	if err != nil {
 		return domain.Zettel{}, err
 	}
 	user := session.GetUser(ctx)
-	if pp.policy.CanRead(user, zettel.Meta) {
+	if ForContext(ctx, pp.policy).CanRead(user, zettel.Meta) {
 		return zettel, nil
 	}
 	return domain.Zettel{}, place.NewErrNotAllowed("GetZettel", user, zid)
@@ -102,19 +115,31 @@ func (pp *polPlace) GetMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error)
 		return nil, err
 	}
 	user := session.GetUser(ctx)
-	if pp.policy.CanRead(user, m) {
+	if ForContext(ctx, pp.policy).CanRead(user, m) {
 		return m, nil
 	}
 	return nil, place.NewErrNotAllowed("GetMeta", user, zid)
 }
 
+func (pp *polPlace) OpenContent(ctx context.Context, zid id.Zid) (io.ReadCloser, error) {
+	m, err := pp.place.GetMeta(ctx, zid)
+	if err != nil {
+		return nil, err
+	}
+	user := session.GetUser(ctx)
+	if ForContext(ctx, pp.policy).CanRead(user, m) {
+		return pp.place.OpenContent(ctx, zid)
+	}
+	return nil, place.NewErrNotAllowed("OpenContent", user, zid)
+}
+
 // SelectMeta returns all zettel meta data that match the selection
 // criteria. The result is ordered by descending zettel id.
 func (pp *polPlace) SelectMeta(
 	ctx context.Context, f *place.Filter, s *place.Sorter) ([]*meta.Meta, error) {
 	user := session.GetUser(ctx)
 	f = place.EnsureFilter(f)
-	canRead := pp.policy.CanRead
+	canRead := ForContext(ctx, pp.policy).CanRead
 	if sel := f.Select; sel != nil {
 		f.Select = func(m *meta.Meta) bool {
 			return canRead(user, m) && sel(m)
@@ -143,8 +168,29 @@ func (pp *polPlace) UpdateZettel(ctx context.Context, zettel domain.Zettel) erro
 	if err != nil {
 		return err
 	}
-	if pp.policy.CanWrite(user, oldMeta, zettel.Meta) {
-		return pp.place.UpdateZettel(ctx, zettel)
+	if ForContext(ctx, pp.policy).CanWrite(user, oldMeta, zettel.Meta) {
+		err = pp.place.UpdateZettel(ctx, zettel)
+		if err == nil {
+			audit.Record(audit.EventUpdate, user, zid, "")
+		}
+		return err
+	}
+	return place.NewErrNotAllowed("Write", user, zid)
+}
+
+func (pp *polPlace) UpdateMeta(ctx context.Context, m *meta.Meta) error {
+	zid := m.Zid
+	user := session.GetUser(ctx)
+	oldMeta, err := pp.place.GetMeta(ctx, zid)
+	if err != nil {
+		return err
+	}
+	if ForContext(ctx, pp.policy).CanWrite(user, oldMeta, m) {
+		err = pp.place.UpdateMeta(ctx, m)
+		if err == nil {
+			audit.Record(audit.EventUpdate, user, zid, "meta data only")
+		}
+		return err
 	}
 	return place.NewErrNotAllowed("Write", user, zid)
 }
@@ -160,8 +206,12 @@ func (pp *polPlace) RenameZettel(ctx context.Context, curZid, newZid id.Zid) err
 		return err
 	}
 	user := session.GetUser(ctx)
-	if pp.policy.CanRename(user, meta) {
-		return pp.place.RenameZettel(ctx, curZid, newZid)
+	if ForContext(ctx, pp.policy).CanRename(user, meta) {
+		err = pp.place.RenameZettel(ctx, curZid, newZid)
+		if err == nil {
+			audit.Record(audit.EventRename, user, curZid, "new id "+newZid.String())
+		}
+		return err
 	}
 	return place.NewErrNotAllowed("Rename", user, curZid)
 }
@@ -177,19 +227,67 @@ func (pp *polPlace) DeleteZettel(ctx context.Context, zid id.Zid) error {
 		return err
 	}
 	user := session.GetUser(ctx)
-	if pp.policy.CanDelete(user, meta) {
-		return pp.place.DeleteZettel(ctx, zid)
+	if ForContext(ctx, pp.policy).CanDelete(user, meta) {
+		err = pp.place.DeleteZettel(ctx, zid)
+		if err == nil {
+			audit.Record(audit.EventDelete, user, zid, "")
+		}
+		return err
 	}
 	return place.NewErrNotAllowed("Delete", user, zid)
 }
 
+func (pp *polPlace) CanUndoZettel(ctx context.Context, zid id.Zid) bool {
+	return pp.place.CanUndoZettel(ctx, zid)
+}
+
+func (pp *polPlace) GetUndoMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error) {
+	m, err := pp.place.GetUndoMeta(ctx, zid)
+	if err != nil {
+		return nil, err
+	}
+	user := session.GetUser(ctx)
+	if ForContext(ctx, pp.policy).CanRead(user, m) {
+		return m, nil
+	}
+	return nil, place.NewErrNotAllowed("GetUndoMeta", user, zid)
+}
+
+// UndoZettel restores the previous version of the zettel. It is checked like
+// an update from the current version to the previous one, so that an undo
+// cannot restore keys the user is not allowed to change.
+func (pp *polPlace) UndoZettel(ctx context.Context, zid id.Zid) error {
+	curMeta, err := pp.place.GetMeta(ctx, zid)
+	if err != nil {
+		return err
+	}
+	prevMeta, err := pp.place.GetUndoMeta(ctx, zid)
+	if err != nil {
+		return err
+	}
+	user := session.GetUser(ctx)
+	if ForContext(ctx, pp.policy).CanWrite(user, curMeta, prevMeta) {
+		return pp.place.UndoZettel(ctx, zid)
+	}
+	return place.NewErrNotAllowed("Undo", user, zid)
+}
+
 func (pp *polPlace) Reload(ctx context.Context) error {
 	user := session.GetUser(ctx)
-	if pp.policy.CanReload(user) {
+	if ForContext(ctx, pp.policy).CanReload(user) {
 		return pp.place.Reload(ctx)
 	}
 	return place.NewErrNotAllowed("Reload", user, id.Invalid)
 }
-func (pp *polPlace) ReadStats(st *place.Stats) {
-	pp.place.ReadStats(st)
+
+func (pp *polPlace) ReloadZettel(ctx context.Context, zid id.Zid) error {
+	user := session.GetUser(ctx)
+	if ForContext(ctx, pp.policy).CanReload(user) {
+		return pp.place.ReloadZettel(ctx, zid)
+	}
+	return place.NewErrNotAllowed("ReloadZettel", user, zid)
+}
+
+func (pp *polPlace) Stats(ctx context.Context) place.Stats {
+	return pp.place.Stats(ctx)
 }
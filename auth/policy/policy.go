@@ -8,7 +8,7 @@
 // under this license.
 //-----------------------------------------------------------------------------
 
-// Package policy provides some interfaces and implementation for authorizsation policies.
+// Package policy provides some interfaces and implementation for authorization policies.
 package policy
 
 import (
@@ -45,33 +45,57 @@ func newPolicy(
 	expertMode func() bool,
 	isOwner func(id.Zid) bool,
 	getVisibility func(*meta.Meta) meta.Visibility,
+	getRules func() string,
 ) Policy {
-	var pol Policy
-	if isReadOnlyMode {
-		pol = &roPolicy{}
-	} else {
-		pol = &defaultPolicy{}
-	}
-	if withAuth() {
-		pol = &ownerPolicy{
-			expertMode:    expertMode,
-			isOwner:       isOwner,
-			getVisibility: getVisibility,
-			pre:           pol,
+	rules := &ruleCache{getContent: getRules}
+	build := func(trace *Trace) *prePolicy {
+		var pol Policy
+		if isReadOnlyMode {
+			pol = &roPolicy{trace: trace}
+		} else {
+			pol = &defaultPolicy{trace: trace}
 		}
-	} else {
-		pol = &anonPolicy{
-			simpleMode:    simpleMode,
-			expertMode:    expertMode,
-			getVisibility: getVisibility,
-			pre:           pol,
+		if withAuth() {
+			base := pol
+			pol = &ownerPolicy{
+				expertMode:    expertMode,
+				isOwner:       isOwner,
+				getVisibility: getVisibility,
+				pre:           base,
+				trace:         trace,
+			}
+			pol = &rulePolicy{rules: rules, base: base, post: pol, trace: trace}
+		} else {
+			pol = &anonPolicy{
+				simpleMode:    simpleMode,
+				expertMode:    expertMode,
+				getVisibility: getVisibility,
+				pre:           pol,
+				trace:         trace,
+			}
 		}
+		return &prePolicy{post: pol, trace: trace}
 	}
-	return &prePolicy{pol}
+	pp := build(nil)
+	pp.newTraced = func(trace *Trace) Policy { return build(trace) }
+	return pp
 }
 
 type prePolicy struct {
-	post Policy
+	post  Policy
+	trace *Trace
+
+	// newTraced creates the same policy chain, but records all decisions in
+	// the given trace. It is nil, if the policy cannot be explained.
+	newTraced func(*Trace) Policy
+}
+
+// check records a failed precondition.
+func (p *prePolicy) check(ok bool) bool {
+	if !ok {
+		p.trace.record(false, RulePrecondition, "meta data missing or inconsistent")
+	}
+	return ok
 }
 
 func (p *prePolicy) CanReload(user *meta.Meta) bool {
@@ -79,22 +103,22 @@ func (p *prePolicy) CanReload(user *meta.Meta) bool {
 }
 
 func (p *prePolicy) CanCreate(user *meta.Meta, newMeta *meta.Meta) bool {
-	return newMeta != nil && p.post.CanCreate(user, newMeta)
+	return p.check(newMeta != nil) && p.post.CanCreate(user, newMeta)
 }
 
 func (p *prePolicy) CanRead(user *meta.Meta, m *meta.Meta) bool {
-	return m != nil && p.post.CanRead(user, m)
+	return p.check(m != nil) && p.post.CanRead(user, m)
 }
 
 func (p *prePolicy) CanWrite(user *meta.Meta, oldMeta, newMeta *meta.Meta) bool {
-	return oldMeta != nil && newMeta != nil && oldMeta.Zid == newMeta.Zid &&
+	return p.check(oldMeta != nil && newMeta != nil && oldMeta.Zid == newMeta.Zid) &&
 		p.post.CanWrite(user, oldMeta, newMeta)
 }
 
 func (p *prePolicy) CanRename(user *meta.Meta, m *meta.Meta) bool {
-	return m != nil && p.post.CanRename(user, m)
+	return p.check(m != nil) && p.post.CanRename(user, m)
 }
 
 func (p *prePolicy) CanDelete(user *meta.Meta, m *meta.Meta) bool {
-	return m != nil && p.post.CanDelete(user, m)
+	return p.check(m != nil) && p.post.CanDelete(user, m)
 }
@@ -8,15 +8,19 @@
 // under this license.
 //-----------------------------------------------------------------------------
 
-// Package policy provides some interfaces and implementation for authorizsation policies.
+// Package policy provides some interfaces and implementation for authorization policies.
 package policy
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
+	"zettelstore.de/z/auth/token"
 	"zettelstore.de/z/domain/id"
 	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/place"
+	"zettelstore.de/z/web/session"
 )
 
 func TestPolicies(t *testing.T) {
@@ -56,7 +60,8 @@ func TestPolicies(t *testing.T) {
 		} else {
 			expertFunc = noExpertMode
 		}
-		pol := newPolicy(ts.simple, authFunc, ts.readonly, expertFunc, isOwner, getVisibility)
+		pol := newPolicy(
+			ts.simple, authFunc, ts.readonly, expertFunc, isOwner, getVisibility, noRules)
 		name := fmt.Sprintf("simple=%v/readonly=%v/withauth=%v/expert=%v",
 			ts.simple, ts.readonly, ts.withAuth, ts.expert)
 		t.Run(name, func(tt *testing.T) {
@@ -66,6 +71,7 @@ func TestPolicies(t *testing.T) {
 			testWrite(tt, pol, ts.simple, ts.withAuth, ts.readonly, ts.expert)
 			testRename(tt, pol, ts.simple, ts.withAuth, ts.readonly, ts.expert)
 			testDelete(tt, pol, ts.simple, ts.withAuth, ts.readonly, ts.expert)
+			testExplain(tt, pol, ts.readonly)
 		})
 	}
 }
@@ -75,6 +81,7 @@ func withoutAuth() bool       { return false }
 func expertMode() bool        { return true }
 func noExpertMode() bool      { return false }
 func isOwner(zid id.Zid) bool { return zid == ownerZid }
+func noRules() string         { return "" }
 func getVisibility(m *meta.Meta) meta.Visibility {
 	if vis, ok := m.Get(meta.KeyVisibility); ok {
 		switch vis {
@@ -121,6 +128,8 @@ func testCreate(t *testing.T, pol Policy, simple bool, withAuth bool, readonly b
 	owner2 := newOwner2()
 	zettel := newZettel()
 	userZettel := newUserZettel()
+	styleZettel := newStyleZettel()
+	scriptZettel := newScriptZettel()
 	testCases := []struct {
 		user *meta.Meta
 		meta *meta.Meta
@@ -144,6 +153,18 @@ func testCreate(t *testing.T, pol Policy, simple bool, withAuth bool, readonly b
 		{writer, userZettel, !withAuth && !readonly},
 		{owner, userZettel, !readonly},
 		{owner2, userZettel, !readonly},
+		// Zettel with owner-only keys
+		{anonUser, styleZettel, !withAuth && !readonly},
+		{reader, styleZettel, !withAuth && !readonly},
+		{writer, styleZettel, !withAuth && !readonly},
+		{owner, styleZettel, !readonly},
+		{owner2, styleZettel, !readonly},
+		// Script zettel
+		{anonUser, scriptZettel, !withAuth && !readonly},
+		{reader, scriptZettel, !withAuth && !readonly},
+		{writer, scriptZettel, !withAuth && !readonly},
+		{owner, scriptZettel, !readonly},
+		{owner2, scriptZettel, !readonly},
 	}
 	for _, tc := range testCases {
 		t.Run("Create", func(tt *testing.T) {
@@ -261,6 +282,8 @@ func testWrite(t *testing.T, pol Policy, simple bool, withAuth bool, readonly bo
 	roReader := newRoReaderZettel()
 	roWriter := newRoWriterZettel()
 	roOwner := newRoOwnerZettel()
+	styleZettel := newStyleZettel()
+	scriptZettel := newScriptZettel()
 	testCases := []struct {
 		user *meta.Meta
 		old  *meta.Meta
@@ -338,6 +361,19 @@ func testWrite(t *testing.T, pol Policy, simple bool, withAuth bool, readonly bo
 		{writer, writer, writer, !readonly},
 		{owner, owner, owner, !readonly},
 		{owner2, owner2, owner2, !readonly},
+		// Change owner-only keys
+		{anonUser, zettel, styleZettel, !withAuth && !readonly},
+		{reader, zettel, styleZettel, !withAuth && !readonly},
+		{writer, zettel, styleZettel, !withAuth && !readonly},
+		{owner, zettel, styleZettel, !readonly},
+		{owner2, zettel, styleZettel, !readonly},
+		{writer, styleZettel, styleZettel, !readonly},
+		{writer, writer, newStyleUser(writer), !withAuth && !readonly},
+		// Script zettel
+		{writer, scriptZettel, scriptZettel, !withAuth && !readonly},
+		{writer, zettel, scriptZettel, !withAuth && !readonly},
+		{writer, scriptZettel, zettel, !withAuth && !readonly},
+		{owner, scriptZettel, scriptZettel, !readonly},
 		// Writer cannot change importand metadata of its own user zettel
 		{writer, writer, writerNew, !withAuth && !readonly},
 		// No r/o zettel
@@ -561,6 +597,46 @@ const (
 	userZid   = id.Zid(1025)
 )
 
+func testExplain(t *testing.T, pol Policy, readonly bool) {
+	t.Helper()
+	users := []*meta.Meta{newAnon(), newReader(), newWriter(), newOwner()}
+	zettel := []*meta.Meta{
+		newZettel(), newPublicZettel(), newOwnerZettel(), newExpertZettel(),
+		newRoTrueZettel(), newRoWriterZettel(), newUserZettel(),
+	}
+	for _, user := range users {
+		for _, m := range zettel {
+			expected := map[string]bool{
+				"reload": pol.CanReload(user),
+				"create": pol.CanCreate(user, m),
+				"read":   pol.CanRead(user, m),
+				"write":  pol.CanWrite(user, m, m),
+				"rename": pol.CanRename(user, m),
+				"delete": pol.CanDelete(user, m),
+			}
+			for _, expl := range Explain(pol, user, m) {
+				if expl.Allowed != expected[expl.Operation] {
+					t.Errorf("Explain %v for %v/%v: got %v, but policy decides %v",
+						expl.Operation, user, m.Zid, expl.Allowed, expected[expl.Operation])
+				}
+				if len(expl.Steps) == 0 {
+					t.Errorf("Explain %v for %v/%v: no rules recorded", expl.Operation, user, m.Zid)
+					continue
+				}
+				last := expl.Steps[len(expl.Steps)-1]
+				if last.Allowed != expl.Allowed {
+					t.Errorf("Explain %v for %v/%v: last rule %v does not decide",
+						expl.Operation, user, m.Zid, last)
+				}
+				if readonly && expl.Operation == "delete" && user != nil &&
+					m.Zid == zettelZid && last.Rule != RuleReadOnly {
+					t.Errorf("Explain delete in read-only mode: got rule %q", last.Rule)
+				}
+			}
+		}
+	}
+}
+
 func newAnon() *meta.Meta { return nil }
 func newReader() *meta.Meta {
 	user := meta.New(readerZid)
@@ -595,6 +671,21 @@ func newZettel() *meta.Meta {
 	m.Set(meta.KeyTitle, "Any Zettel")
 	return m
 }
+func newStyleZettel() *meta.Meta {
+	m := newZettel()
+	m.Set(meta.KeyCSSZettel, "20001")
+	return m
+}
+func newScriptZettel() *meta.Meta {
+	m := newZettel()
+	m.Set(meta.KeySyntax, "js")
+	return m
+}
+func newStyleUser(user *meta.Meta) *meta.Meta {
+	m := user.Clone()
+	m.Set(meta.KeyJSZettel, "20001")
+	return m
+}
 func newPublicZettel() *meta.Meta {
 	m := meta.New(visZid)
 	m.Set(meta.KeyTitle, "Public Zettel")
@@ -661,3 +752,191 @@ func newUserZettel() *meta.Meta {
 	m.Set(meta.KeyRole, meta.ValueRoleUser)
 	return m
 }
+
+func TestPublicPolicy(t *testing.T) {
+	pol := &prePolicy{post: &publicPolicy{getVisibility: getVisibility}}
+	publicUser := newUserZettel()
+	publicUser.Set(meta.KeyVisibility, meta.ValueVisibilityPublic)
+	testCases := []struct {
+		meta *meta.Meta
+		exp  bool
+	}{
+		{nil, false},
+		{newZettel(), false},
+		{newPublicZettel(), true},
+		{newLoginZettel(), false},
+		{newOwnerZettel(), false},
+		{newExpertZettel(), false},
+		{newSimpleZettel(), false},
+		{publicUser, false},
+	}
+	for _, user := range []*meta.Meta{newAnon(), newWriter(), newOwner()} {
+		if pol.CanReload(user) {
+			t.Errorf("Reload allowed")
+		}
+		for i, tc := range testCases {
+			if got := pol.CanRead(user, tc.meta); got != tc.exp {
+				t.Errorf("%d: CanRead exp=%v, but got=%v", i, tc.exp, got)
+			}
+			if pol.CanCreate(user, tc.meta) || pol.CanWrite(user, tc.meta, tc.meta) ||
+				pol.CanRename(user, tc.meta) || pol.CanDelete(user, tc.meta) {
+				t.Errorf("%d: change allowed", i)
+			}
+		}
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	rules, errs := ParseRules(`% comment
+
+writer may delete,rename role scratch
+reader must-not read tag private
+owner may reload
+guest may read
+writer may write color red
+writer might read
+reader may read role`)
+	if len(rules) != 2 {
+		t.Fatalf("expected two rules, but got %v", rules)
+	}
+	if r := rules[0]; r.UserRole != meta.UserRoleWriter || !r.Allow ||
+		!r.Ops["delete"] || !r.Ops["rename"] || r.Ops["write"] || r.Role != "scratch" {
+		t.Errorf("wrong first rule: %v", r)
+	}
+	if r := rules[1]; r.UserRole != meta.UserRoleReader || r.Allow || r.Tag != "#private" {
+		t.Errorf("wrong second rule: %v", r)
+	}
+	if len(errs) != 5 {
+		t.Errorf("expected five errors, but got %v", errs)
+	}
+}
+
+func TestRulePolicy(t *testing.T) {
+	rules := `writer may delete role scratch
+writer may write role user
+reader must-not read tag #private
+writer may write tag #private
+writer may write role script`
+	pol := newPolicy(
+		false, withAuth, false, expertMode, isOwner, getVisibility, func() string { return rules })
+	scratch := newZettel()
+	scratch.Set(meta.KeyRole, "scratch")
+	roScratch := scratch.Clone()
+	roScratch.Set(meta.KeyReadOnly, "true")
+	private := newZettel()
+	private.Set(meta.KeyTags, "#private")
+	writer, reader := newWriter(), newReader()
+
+	if !pol.CanDelete(writer, scratch) {
+		t.Error("writer is not allowed to delete scratch zettel")
+	}
+	if pol.CanDelete(writer, newZettel()) {
+		t.Error("writer is allowed to delete other zettel")
+	}
+	if pol.CanDelete(reader, scratch) {
+		t.Error("reader is allowed to delete scratch zettel")
+	}
+	if pol.CanDelete(writer, roScratch) {
+		t.Error("writer is allowed to delete read-only scratch zettel")
+	}
+	if pol.CanWrite(writer, reader, reader) {
+		t.Error("writer is allowed to change user zettel")
+	}
+	script := newScriptZettel()
+	script.Set(meta.KeyRole, "script")
+	if pol.CanWrite(writer, script, script) {
+		t.Error("writer is allowed to change script zettel")
+	}
+	if pol.CanRead(reader, private) {
+		t.Error("reader is allowed to read private zettel")
+	}
+	if !pol.CanRead(writer, private) || !pol.CanWrite(writer, private, private) {
+		t.Error("writer is not allowed to read and write private zettel")
+	}
+	if !pol.CanRead(reader, newZettel()) {
+		t.Error("reader is not allowed to read other zettel")
+	}
+	expl := Explain(pol, writer, scratch)
+	for _, e := range expl {
+		if e.Operation == "delete" && e.Steps[0].Rule != RuleCustom {
+			t.Errorf("delete of scratch zettel not explained by custom rule: %v", e.Steps)
+		}
+	}
+}
+
+func TestScopePolicy(t *testing.T) {
+	project := newZettel()
+	project.Set(meta.KeyRole, "project")
+	publicProject := newZettel()
+	publicProject.Set(meta.KeyRole, "project")
+	publicProject.Set(meta.KeyTags, "#draft #public")
+	testCases := []struct {
+		scope     token.Scope
+		meta      *meta.Meta
+		canRead   bool
+		canChange bool
+	}{
+		{token.Scope{ReadOnly: true}, newZettel(), true, false},
+		{token.Scope{Role: "project"}, newZettel(), false, false},
+		{token.Scope{Role: "project"}, project, true, true},
+		{token.Scope{Tag: "#public"}, project, false, false},
+		{token.Scope{Tag: "#public"}, publicProject, true, true},
+		{token.Scope{ReadOnly: true, Role: "project", Tag: "#public"}, publicProject, true, false},
+	}
+	user := newOwner()
+	for i, tc := range testCases {
+		pol := &scopePolicy{scope: tc.scope, post: &defaultPolicy{}}
+		if got := pol.CanRead(user, tc.meta); got != tc.canRead {
+			t.Errorf("%d: CanRead exp=%v, but got=%v", i, tc.canRead, got)
+		}
+		if got := pol.CanWrite(user, tc.meta, tc.meta); got != tc.canChange {
+			t.Errorf("%d: CanWrite exp=%v, but got=%v", i, tc.canChange, got)
+		}
+		if got := pol.CanDelete(user, tc.meta); got != tc.canChange {
+			t.Errorf("%d: CanDelete exp=%v, but got=%v", i, tc.canChange, got)
+		}
+	}
+}
+
+// undoPlace is a place that stores the current and the previous version of
+// one zettel.
+type undoPlace struct {
+	place.Place
+	cur, prev *meta.Meta
+	undone    bool
+}
+
+func (up *undoPlace) GetMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error) {
+	return up.cur, nil
+}
+func (up *undoPlace) GetUndoMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error) {
+	return up.prev, nil
+}
+func (up *undoPlace) UndoZettel(ctx context.Context, zid id.Zid) error {
+	up.undone = true
+	return nil
+}
+
+func TestUndoPolicy(t *testing.T) {
+	demoted := newWriter()
+	demoted.Set(meta.KeyUserRole, meta.ValueUserRoleReader)
+	retitled := demoted.Clone()
+	retitled.Set(meta.KeyTitle, "Old Title")
+	testCases := []struct {
+		prev *meta.Meta
+		exp  bool
+	}{
+		{newWriter(), false},
+		{newStyleUser(demoted), false},
+		{retitled, true},
+	}
+	for i, tc := range testCases {
+		up := &undoPlace{cur: demoted, prev: tc.prev}
+		pp, _ := PlaceWithPolicy(
+			up, false, withAuth, false, expertMode, isOwner, getVisibility, noRules)
+		err := pp.UndoZettel(session.NewContext(context.Background(), demoted), demoted.Zid)
+		if got := err == nil; got != tc.exp || up.undone != tc.exp {
+			t.Errorf("TC=%d: expected %v, but got %v (undone=%v, err=%v)", i, tc.exp, got, up.undone, err)
+		}
+	}
+}
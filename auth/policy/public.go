@@ -0,0 +1,58 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package policy provides some interfaces and implementation for authorization policies.
+package policy
+
+import (
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/place"
+)
+
+// PublicPlace wraps the given place inside a policy place that allows only
+// to read public zettel. It is used for the public mirror of a Zettelstore.
+func PublicPlace(
+	place place.Place,
+	getVisibility func(*meta.Meta) meta.Visibility,
+) (place.Place, Policy) {
+	pol := &prePolicy{post: &publicPolicy{getVisibility: getVisibility}}
+	return newPlace(place, pol), pol
+}
+
+type publicPolicy struct {
+	getVisibility func(*meta.Meta) meta.Visibility
+}
+
+func (p *publicPolicy) CanReload(user *meta.Meta) bool {
+	return false
+}
+
+func (p *publicPolicy) CanCreate(user *meta.Meta, newMeta *meta.Meta) bool {
+	return false
+}
+
+func (p *publicPolicy) CanRead(user *meta.Meta, m *meta.Meta) bool {
+	if role, ok := m.Get(meta.KeyRole); ok && role == meta.ValueRoleUser {
+		return false
+	}
+	return p.getVisibility(m) == meta.VisibilityPublic
+}
+
+func (p *publicPolicy) CanWrite(user *meta.Meta, oldMeta, newMeta *meta.Meta) bool {
+	return false
+}
+
+func (p *publicPolicy) CanRename(user *meta.Meta, m *meta.Meta) bool {
+	return false
+}
+
+func (p *publicPolicy) CanDelete(user *meta.Meta, m *meta.Meta) bool {
+	return false
+}
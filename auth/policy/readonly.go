@@ -13,28 +13,34 @@ package policy
 
 import "zettelstore.de/z/domain/meta"
 
-type roPolicy struct{}
+type roPolicy struct {
+	trace *Trace
+}
 
 func (p *roPolicy) CanReload(user *meta.Meta) bool {
-	return true
+	return p.trace.record(true, RuleReadOnly, "reloading is allowed in read-only mode")
 }
 
 func (p *roPolicy) CanCreate(user *meta.Meta, newMeta *meta.Meta) bool {
-	return false
+	return p.deny()
 }
 
 func (p *roPolicy) CanRead(user *meta.Meta, m *meta.Meta) bool {
-	return true
+	return p.trace.record(true, RuleReadOnly, "reading is allowed in read-only mode")
 }
 
 func (p *roPolicy) CanWrite(user *meta.Meta, oldMeta, newMeta *meta.Meta) bool {
-	return false
+	return p.deny()
 }
 
 func (p *roPolicy) CanRename(user *meta.Meta, m *meta.Meta) bool {
-	return false
+	return p.deny()
 }
 
 func (p *roPolicy) CanDelete(user *meta.Meta, m *meta.Meta) bool {
-	return false
+	return p.deny()
+}
+
+func (p *roPolicy) deny() bool {
+	return p.trace.record(false, RuleReadOnly, "Zettelstore runs in read-only mode")
 }
@@ -0,0 +1,251 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package policy provides some interfaces and implementation for authorization policies.
+package policy
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/domain/meta"
+)
+
+// Rule is a custom policy rule, as defined in the policy rules zettel.
+type Rule struct {
+	Text     string // Text of the rule, as written in the zettel
+	UserRole meta.UserRole
+	Allow    bool
+	Ops      map[string]bool
+	Role     string // Zettel role, or empty if the rule applies to every role
+	Tag      string // Zettel tag, or empty if the rule applies to every tag
+}
+
+// Operations that can be used within a rule.
+var ruleOps = map[string]bool{
+	"create": true,
+	"read":   true,
+	"write":  true,
+	"rename": true,
+	"delete": true,
+}
+
+// ParseRules parses the content of a policy rules zettel. Each line contains
+// a rule of the form
+//
+//	<user-role> may|must-not <operation>[,<operation>...] [role <role>] [tag <tag>]
+//
+// Empty lines and lines starting with "%" are ignored. Lines that are not
+// valid rules are returned as errors.
+func ParseRules(content string) ([]Rule, []error) {
+	var rules []Rule
+	var errs []error
+	for i, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "%") {
+			continue
+		}
+		rule, err := parseRule(fields)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %v", i+1, err))
+			continue
+		}
+		rule.Text = strings.Join(fields, " ")
+		rules = append(rules, rule)
+	}
+	return rules, errs
+}
+
+func parseRule(fields []string) (Rule, error) {
+	if len(fields) < 3 {
+		return Rule{}, fmt.Errorf("rule %q is incomplete", strings.Join(fields, " "))
+	}
+	var rule Rule
+	rule.UserRole = meta.GetUserRole(fields[0])
+	if rule.UserRole == meta.UserRoleUnknown {
+		return Rule{}, fmt.Errorf("unknown user role %q", fields[0])
+	}
+	switch fields[1] {
+	case "may":
+		rule.Allow = true
+	case "must-not":
+		rule.Allow = false
+	default:
+		return Rule{}, fmt.Errorf("expected \"may\" or \"must-not\", but got %q", fields[1])
+	}
+	rule.Ops = make(map[string]bool)
+	for _, op := range strings.Split(fields[2], ",") {
+		if !ruleOps[op] {
+			return Rule{}, fmt.Errorf("unknown operation %q", op)
+		}
+		rule.Ops[op] = true
+	}
+	for rest := fields[3:]; len(rest) > 0; rest = rest[2:] {
+		if len(rest) < 2 {
+			return Rule{}, fmt.Errorf("missing value for %q", rest[0])
+		}
+		switch rest[0] {
+		case "role":
+			rule.Role = rest[1]
+		case "tag":
+			rule.Tag = rest[1]
+			if rule.Tag[0] != '#' {
+				rule.Tag = "#" + rule.Tag
+			}
+		default:
+			return Rule{}, fmt.Errorf("unknown condition %q", rest[0])
+		}
+	}
+	return rule, nil
+}
+
+// matches returns true, if the rule applies to the given zettel.
+func (r *Rule) matches(m *meta.Meta) bool {
+	if r.Role != "" && m.GetDefault(meta.KeyRole, "") != r.Role {
+		return false
+	}
+	if r.Tag != "" {
+		tags, _ := m.GetList(meta.KeyTags)
+		for _, tag := range tags {
+			if tag == r.Tag {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// ruleCache stores the parsed rules, as long as the rule text does not change.
+type ruleCache struct {
+	getContent func() string
+
+	mx      sync.Mutex
+	content string
+	rules   []Rule
+}
+
+func (rc *ruleCache) get() []Rule {
+	content := rc.getContent()
+	rc.mx.Lock()
+	defer rc.mx.Unlock()
+	if content != rc.content {
+		rules, errs := ParseRules(content)
+		for _, err := range errs {
+			log.Printf("Policy rules: %v", err)
+		}
+		rc.content, rc.rules = content, rules
+	}
+	return rc.rules
+}
+
+// rulePolicy evaluates the custom rules before the built-in policy. A rule
+// that denies an operation has precedence over a rule that allows it. An
+// allowing rule cannot override the read-only mode and the read-only meta
+// data of a zettel (checked by base), and it never applies to user zettel,
+// to style or script zettel, or to zettel with owner-only keys. If no rule applies, the built-in policy
+// (post) decides.
+type rulePolicy struct {
+	rules *ruleCache
+	base  Policy
+	post  Policy
+	trace *Trace
+}
+
+func (p *rulePolicy) CanReload(user *meta.Meta) bool {
+	return p.post.CanReload(user)
+}
+
+func (p *rulePolicy) CanCreate(user *meta.Meta, newMeta *meta.Meta) bool {
+	if res, ok := p.apply(user, "create", newMeta, newMeta); ok {
+		return res && p.base.CanCreate(user, newMeta)
+	}
+	return p.post.CanCreate(user, newMeta)
+}
+
+func (p *rulePolicy) CanRead(user *meta.Meta, m *meta.Meta) bool {
+	if res, ok := p.apply(user, "read", m, m); ok {
+		return res && p.base.CanRead(user, m)
+	}
+	return p.post.CanRead(user, m)
+}
+
+func (p *rulePolicy) CanWrite(user *meta.Meta, oldMeta, newMeta *meta.Meta) bool {
+	if res, ok := p.apply(user, "write", oldMeta, newMeta); ok {
+		return res && p.base.CanWrite(user, oldMeta, newMeta)
+	}
+	return p.post.CanWrite(user, oldMeta, newMeta)
+}
+
+func (p *rulePolicy) CanRename(user *meta.Meta, m *meta.Meta) bool {
+	if res, ok := p.apply(user, "rename", m, m); ok {
+		return res && p.base.CanRename(user, m)
+	}
+	return p.post.CanRename(user, m)
+}
+
+func (p *rulePolicy) CanDelete(user *meta.Meta, m *meta.Meta) bool {
+	if res, ok := p.apply(user, "delete", m, m); ok {
+		return res && p.base.CanDelete(user, m)
+	}
+	return p.post.CanDelete(user, m)
+}
+
+// apply evaluates all rules for the given operation. The second result is
+// false, if no rule applies. For a write operation, a denying rule applies
+// if it matches the old or the new meta data, an allowing rule only if it
+// matches both.
+func (p *rulePolicy) apply(user *meta.Meta, op string, oldMeta, newMeta *meta.Meta) (bool, bool) {
+	if user == nil {
+		return false, false
+	}
+	userRole := runtime.GetUserRole(user)
+	var allow *Rule
+	rules := p.rules.get()
+	for i := range rules {
+		r := &rules[i]
+		if r.UserRole != userRole || !r.Ops[op] {
+			continue
+		}
+		if !r.Allow {
+			if r.matches(oldMeta) || r.matches(newMeta) {
+				return p.trace.record(false, RuleCustom, r.Text), true
+			}
+			continue
+		}
+		if allow == nil && r.matches(oldMeta) && r.matches(newMeta) {
+			allow = r
+		}
+	}
+	if allow == nil || isProtected(oldMeta, newMeta) {
+		return false, false
+	}
+	return p.trace.record(true, RuleCustom, allow.Text), true
+}
+
+// isProtected returns true, if custom rules must not allow to change the
+// zettel, because it is a user zettel, a style or script zettel, or because
+// it uses owner-only keys.
+func isProtected(oldMeta, newMeta *meta.Meta) bool {
+	for _, m := range []*meta.Meta{oldMeta, newMeta} {
+		if m.GetDefault(meta.KeyRole, "") == meta.ValueRoleUser || isAsset(m) {
+			return true
+		}
+		for _, key := range ownerOnlyKeys {
+			if _, ok := m.Get(key); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
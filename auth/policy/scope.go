@@ -0,0 +1,80 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package policy provides some interfaces and implementation for authorization policies.
+package policy
+
+import (
+	"context"
+
+	"zettelstore.de/z/auth/token"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/web/session"
+)
+
+// ForContext returns the policy that applies to the request of the given
+// context. If the request was authenticated by a scoped token, the given
+// policy is restricted to this scope.
+func ForContext(ctx context.Context, pol Policy) Policy {
+	if scope := session.GetScope(ctx); scope.IsRestricted() {
+		return &scopePolicy{scope: scope, post: pol}
+	}
+	return pol
+}
+
+type scopePolicy struct {
+	scope token.Scope
+	post  Policy
+}
+
+func (p *scopePolicy) CanReload(user *meta.Meta) bool {
+	return !p.scope.ReadOnly && p.post.CanReload(user)
+}
+
+func (p *scopePolicy) CanCreate(user *meta.Meta, newMeta *meta.Meta) bool {
+	return !p.scope.ReadOnly && p.inScope(newMeta) && p.post.CanCreate(user, newMeta)
+}
+
+func (p *scopePolicy) CanRead(user *meta.Meta, m *meta.Meta) bool {
+	return p.inScope(m) && p.post.CanRead(user, m)
+}
+
+func (p *scopePolicy) CanWrite(user *meta.Meta, oldMeta, newMeta *meta.Meta) bool {
+	return !p.scope.ReadOnly && p.inScope(oldMeta) && p.inScope(newMeta) &&
+		p.post.CanWrite(user, oldMeta, newMeta)
+}
+
+func (p *scopePolicy) CanRename(user *meta.Meta, m *meta.Meta) bool {
+	return !p.scope.ReadOnly && p.inScope(m) && p.post.CanRename(user, m)
+}
+
+func (p *scopePolicy) CanDelete(user *meta.Meta, m *meta.Meta) bool {
+	return !p.scope.ReadOnly && p.inScope(m) && p.post.CanDelete(user, m)
+}
+
+// inScope returns true, if the zettel has the role and the tag of the scope.
+func (p *scopePolicy) inScope(m *meta.Meta) bool {
+	if m == nil {
+		return false
+	}
+	if role := p.scope.Role; role != "" && m.GetDefault(meta.KeyRole, "") != role {
+		return false
+	}
+	if tag := p.scope.Tag; tag != "" {
+		tags, _ := m.GetList(meta.KeyTags)
+		for _, t := range tags {
+			if t == tag {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
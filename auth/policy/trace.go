@@ -0,0 +1,91 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package policy provides some interfaces and implementation for authorization policies.
+package policy
+
+import "zettelstore.de/z/domain/meta"
+
+// Names of the rules that are recorded in a trace.
+const (
+	RulePrecondition = "precondition"
+	RuleReadOnly     = "read-only mode"
+	RuleDefault      = "default"
+	RuleSimpleMode   = "simple mode"
+	RuleExpertMode   = "expert mode"
+	RuleVisibility   = "visibility"
+	RuleOwner        = "owner"
+	RuleUserRole     = "user-role"
+	RuleUserZettel   = "user zettel"
+	RuleOwnerKeys    = "owner-only keys"
+	RuleAsset        = "style or script"
+	RuleReadOnlyMeta = "read-only meta"
+	RuleCustom       = "custom rule"
+)
+
+// Trace records the rules that were applied to reach a policy decision.
+// Methods of a nil trace record nothing.
+type Trace struct {
+	Steps []TraceStep
+}
+
+// TraceStep is one rule that was applied, together with its result.
+type TraceStep struct {
+	Rule    string
+	Allowed bool
+	Reason  string
+}
+
+// record appends a step to the trace and returns the given decision.
+func (t *Trace) record(allowed bool, rule, reason string) bool {
+	if t != nil {
+		t.Steps = append(t.Steps, TraceStep{Rule: rule, Allowed: allowed, Reason: reason})
+	}
+	return allowed
+}
+
+// Explanation describes the decision of a policy about one operation.
+type Explanation struct {
+	Operation string
+	Allowed   bool
+	Steps     []TraceStep
+}
+
+// Explain returns how the given policy decides about all operations of the
+// given user on the given zettel. A nil user denotes an anonymous user. If
+// the policy is not able to explain its decisions, nil is returned.
+func Explain(pol Policy, user, m *meta.Meta) []Explanation {
+	pp, ok := pol.(*prePolicy)
+	if !ok || pp.newTraced == nil {
+		return nil
+	}
+	ops := []struct {
+		name  string
+		check func(Policy) bool
+	}{
+		{"reload", func(p Policy) bool { return p.CanReload(user) }},
+		{"create", func(p Policy) bool { return p.CanCreate(user, m) }},
+		{"read", func(p Policy) bool { return p.CanRead(user, m) }},
+		{"write", func(p Policy) bool { return p.CanWrite(user, m, m) }},
+		{"rename", func(p Policy) bool { return p.CanRename(user, m) }},
+		{"delete", func(p Policy) bool { return p.CanDelete(user, m) }},
+	}
+	result := make([]Explanation, 0, len(ops))
+	for _, op := range ops {
+		var trace Trace
+		allowed := op.check(pp.newTraced(&trace))
+		result = append(result, Explanation{
+			Operation: op.name,
+			Allowed:   allowed,
+			Steps:     trace.Steps,
+		})
+	}
+	return result
+}
@@ -46,8 +46,33 @@ const (
 	KindHTML
 )
 
+// Scope restricts the access that is granted by a token.
+type Scope struct {
+	ReadOnly bool   // Zettel must not be changed
+	Role     string // If not empty, only zettel with this role are accessible
+	Tag      string // If not empty, only zettel with this tag are accessible
+}
+
+// IsRestricted returns true, if the scope restricts the access in any way.
+func (s Scope) IsRestricted() bool {
+	return s.ReadOnly || s.Role != "" || s.Tag != ""
+}
+
+// Claim names of a scope.
+const (
+	claimReadOnly = "_sro"
+	claimRole     = "_srl"
+	claimTag      = "_stg"
+)
+
 // GetToken returns a token to be used for authentification.
 func GetToken(ident *meta.Meta, d time.Duration, kind Kind) ([]byte, error) {
+	return GetScopedToken(ident, d, kind, Scope{})
+}
+
+// GetScopedToken returns a token that grants only access within the given
+// scope.
+func GetScopedToken(ident *meta.Meta, d time.Duration, kind Kind, scope Scope) ([]byte, error) {
 	if role, ok := ident.Get(meta.KeyRole); !ok || role != meta.ValueRoleUser {
 		return nil, ErrNoUser
 	}
@@ -68,6 +93,15 @@ func GetToken(ident *meta.Meta, d time.Duration, kind Kind) ([]byte, error) {
 			"_tk": int(kind),
 		},
 	}
+	if scope.ReadOnly {
+		claims.Set[claimReadOnly] = true
+	}
+	if scope.Role != "" {
+		claims.Set[claimRole] = scope.Role
+	}
+	if scope.Tag != "" {
+		claims.Set[claimTag] = scope.Tag
+	}
 	token, err := claims.HMACSign(reqHash, startup.Secret())
 	if err != nil {
 		return nil, err
@@ -86,6 +120,7 @@ type Data struct {
 	Expires time.Time
 	Ident   string
 	Zid     id.Zid
+	Scope   Scope
 }
 
 // CheckToken checks the validity of the token and returns relevant data.
@@ -118,6 +153,7 @@ func CheckToken(token []byte, k Kind) (Data, error) {
 						Expires: expires,
 						Ident:   ident,
 						Zid:     zid,
+						Scope:   getScope(claims.Set),
 					}, nil
 				}
 			}
@@ -126,3 +162,11 @@ func CheckToken(token []byte, k Kind) (Data, error) {
 	}
 	return Data{}, ErrNoZid
 }
+
+func getScope(set map[string]interface{}) Scope {
+	var scope Scope
+	scope.ReadOnly, _ = set[claimReadOnly].(bool)
+	scope.Role, _ = set[claimRole].(string)
+	scope.Tag, _ = set[claimTag].(string)
+	return scope
+}
@@ -0,0 +1,132 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package captcha protects forms that can be submitted anonymously.
+//
+// A challenge is a simple arithmetic question. The answer is not stored on
+// the server, it is only contained in the signature of a token that must be
+// submitted together with the answer. Every token can be used only once.
+//
+// Such a question is easily answered by a program. It only keeps away simple
+// spam bots, so the forms must limit their use by other means, too.
+package captcha
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Errors returned by Verify.
+var (
+	ErrInvalid = errors.New("captcha token is invalid")
+	ErrExpired = errors.New("captcha token is expired")
+	ErrUsed    = errors.New("captcha token was already used")
+	ErrWrong   = errors.New("captcha answer is wrong")
+)
+
+// Guard creates challenges and verifies the answers.
+type Guard struct {
+	secret []byte
+	maxAge time.Duration
+	now    func() time.Time
+
+	mx   sync.Mutex // protects used
+	used map[string]time.Time
+}
+
+// New creates a new guard. Challenges must be answered within the given
+// duration.
+func New(secret []byte, maxAge time.Duration) *Guard {
+	return &Guard{
+		secret: secret,
+		maxAge: maxAge,
+		now:    time.Now,
+		used:   make(map[string]time.Time),
+	}
+}
+
+var numberWords = []string{
+	"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine"}
+
+// Challenge returns a new question and the token that must be submitted
+// together with the answer.
+func (g *Guard) Challenge() (question, token string) {
+	a, b := randomDigit()+1, randomDigit()
+	question = fmt.Sprintf("How much is %v plus %v?", numberWords[a], b)
+	var nonce [8]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		panic(err)
+	}
+	payload := strconv.FormatInt(g.now().Unix(), 10) + "." + hex.EncodeToString(nonce[:])
+	return question, payload + "." + g.sign(payload, strconv.Itoa(a+b))
+}
+
+func randomDigit() int {
+	n, err := rand.Int(rand.Reader, big.NewInt(9))
+	if err != nil {
+		panic(err)
+	}
+	return int(n.Int64())
+}
+
+func (g *Guard) sign(payload, answer string) string {
+	mac := hmac.New(sha256.New, g.secret)
+	mac.Write([]byte(payload))
+	mac.Write([]byte{0})
+	mac.Write([]byte(answer))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the answer to the challenge of the given token.
+func (g *Guard) Verify(token, answer string) error {
+	pos := strings.LastIndexByte(token, '.')
+	if pos < 0 {
+		return ErrInvalid
+	}
+	payload, sig := token[:pos], token[pos+1:]
+	tsEnd := strings.IndexByte(payload, '.')
+	if tsEnd < 0 {
+		return ErrInvalid
+	}
+	ts, err := strconv.ParseInt(payload[:tsEnd], 10, 64)
+	if err != nil {
+		return ErrInvalid
+	}
+	now := g.now()
+	if now.Sub(time.Unix(ts, 0)) > g.maxAge {
+		return ErrExpired
+	}
+	answer = strings.TrimSpace(answer)
+	if !hmac.Equal([]byte(sig), []byte(g.sign(payload, answer))) {
+		return ErrWrong
+	}
+
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	for t, used := range g.used {
+		if now.Sub(used) > g.maxAge {
+			delete(g.used, t)
+		}
+	}
+	if _, ok := g.used[payload]; ok {
+		return ErrUsed
+	}
+	g.used[payload] = time.Unix(ts, 0)
+	return nil
+}
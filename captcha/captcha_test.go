@@ -0,0 +1,68 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package captcha protects forms that can be submitted anonymously.
+package captcha
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// answer solves the question of a challenge.
+func answer(t *testing.T, question string) string {
+	t.Helper()
+	fields := strings.Fields(strings.TrimSuffix(question, "?"))
+	a := -1
+	for i, w := range numberWords {
+		if w == fields[3] {
+			a = i
+		}
+	}
+	b, err := strconv.Atoi(fields[5])
+	if a < 0 || err != nil {
+		t.Fatalf("unable to parse question %q", question)
+	}
+	return strconv.Itoa(a + b)
+}
+
+func TestGuard(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	g := New([]byte("secret"), time.Hour)
+	g.now = func() time.Time { return now }
+
+	question, token := g.Challenge()
+	if err := g.Verify(token, "100"); err != ErrWrong {
+		t.Errorf("wrong answer accepted: %v", err)
+	}
+	if err := g.Verify(token, " "+answer(t, question)+" "); err != nil {
+		t.Errorf("right answer not accepted: %v", err)
+	}
+	if err := g.Verify(token, answer(t, question)); err != ErrUsed {
+		t.Errorf("token was used twice: %v", err)
+	}
+	if err := g.Verify(strings.Replace(token, ".", ".0", 1), answer(t, question)); err != ErrWrong {
+		t.Errorf("modified token accepted: %v", err)
+	}
+	if err := g.Verify("abc", "1"); err != ErrInvalid {
+		t.Errorf("invalid token accepted: %v", err)
+	}
+
+	question, token = g.Challenge()
+	now = now.Add(2 * time.Hour)
+	if err := g.Verify(token, answer(t, question)); err != ErrExpired {
+		t.Errorf("expired token accepted: %v", err)
+	}
+	if len(g.used) != 1 {
+		t.Errorf("expected one used token, but got %v", g.used)
+	}
+}
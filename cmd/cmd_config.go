@@ -11,10 +11,14 @@
 package cmd
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"strings"
+	"time"
 
 	"zettelstore.de/z/config/startup"
+	"zettelstore.de/z/place"
 )
 
 // ---------- Subcommand: config ---------------------------------------------
@@ -23,9 +27,25 @@ func cmdConfig(*flag.FlagSet) (int, error) {
 	fmtVersion()
 	fmt.Println("Stores")
 	fmt.Printf("  Read-only mode    = %v\n", startup.IsReadOnlyMode())
+	printPlaceStats(startup.PlaceManager())
 	fmt.Println("Web")
 	fmt.Printf("  Listen address    = %q\n", startup.ListenAddress())
+	if addr := startup.PublicListenAddress(); addr != "" {
+		fmt.Printf("  Public address    = %q\n", addr)
+	}
 	fmt.Printf("  URL prefix        = %q\n", startup.URLPrefix())
+	fmt.Printf("  Trust proxy       = %v\n", startup.TrustProxy())
+	if proxies := startup.TrustedProxies(); len(proxies) > 0 {
+		fmt.Printf("  Trusted proxies   = %v\n", proxies)
+	}
+	for _, area := range []string{startup.AreaWebUI, startup.AreaAPI, startup.AreaAdmin} {
+		if allow, deny := startup.IPRule(area); len(allow) > 0 || len(deny) > 0 {
+			fmt.Printf("  IP rule %-9s = allow %v, deny %v\n", area, allow, deny)
+		}
+	}
+	if attrs := startup.AllowAttributes(); len(attrs) > 0 {
+		fmt.Printf("  Allow attributes  = %v\n", strings.Join(attrs, " "))
+	}
 	if startup.WithAuth() {
 		fmt.Println("Auth")
 		fmt.Printf("  Owner             = %v\n", startup.Owner())
@@ -38,3 +58,26 @@ func cmdConfig(*flag.FlagSet) (int, error) {
 
 	return 0, nil
 }
+
+func printPlaceStats(mgr place.Manager) {
+	ctx := context.Background()
+	stats := mgr.Stats(ctx)
+	for stats.Scanning {
+		time.Sleep(100 * time.Millisecond)
+		stats = mgr.Stats(ctx)
+	}
+	fmt.Printf("  Zettel            = %v\n", stats.Zettel)
+	fmt.Printf("  Errors            = %v\n", stats.Errors)
+	for i, sst := range stats.Places {
+		fmt.Printf("  Place %-11d = %v\n", i+1, sst.Location)
+		fmt.Printf("    Zettel          = %v\n", sst.Zettel)
+		fmt.Printf("    Read-only       = %v\n", sst.ReadOnly)
+		if sst.Degraded {
+			fmt.Printf("    Degraded        = %v\n", sst.Degraded)
+		}
+		if !sst.LastScan.IsZero() {
+			fmt.Printf("    Last scan       = %v\n", sst.LastScan.Format("2006-01-02 15:04:05"))
+		}
+		fmt.Printf("    Errors          = %v\n", sst.Errors)
+	}
+}
@@ -0,0 +1,144 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package cmd provides command generic functions.
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"zettelstore.de/z/config/startup"
+)
+
+// ---------- Subcommand: reindex --------------------------------------------
+
+// reindexPoll is the time between two requests for the progress of a rebuild.
+const reindexPoll = time.Second
+
+func flgReindex(fs *flag.FlagSet) {
+	fs.String("c", defConfigfile, "configuration file")
+	fs.Uint("p", 23123, "port number")
+	fs.String("u", "", "URL of the running Zettelstore")
+	fs.String("user", "", "user name of the owner")
+}
+
+type reindexProgress struct {
+	Running bool   `json:"running"`
+	Indexed int    `json:"indexed"`
+	Total   int    `json:"total"`
+	Error   string `json:"error"`
+}
+
+// cmdReindex asks a running Zettelstore to rebuild its index from scratch,
+// and reports the progress until the rebuild is finished.
+func cmdReindex(fs *flag.FlagSet) (int, error) {
+	base := fs.Lookup("u").Value.String()
+	if base == "" {
+		base = reindexURL(startup.ListenAddress(), startup.URLPrefix())
+	} else if !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+	var token string
+	if ident := fs.Lookup("user").Value.String(); ident != "" {
+		password, err := getPassword("Password")
+		if err != nil {
+			return 2, err
+		}
+		if token, err = reindexLogin(base, ident, password); err != nil {
+			return 1, err
+		}
+	}
+
+	progress, err := reindexRequest(http.MethodPost, base, token)
+	for err == nil && progress.Running {
+		if progress.Total > 0 {
+			fmt.Printf("Indexed %d of %d zettel\n", progress.Indexed, progress.Total)
+		}
+		time.Sleep(reindexPoll)
+		progress, err = reindexRequest(http.MethodGet, base, token)
+	}
+	if err != nil {
+		return 1, err
+	}
+	if progress.Error != "" {
+		fmt.Fprintf(os.Stderr, "Rebuilding the index failed: %v\n", progress.Error)
+		return 1, nil
+	}
+	fmt.Printf("Index rebuilt, %d zettel indexed\n", progress.Indexed)
+	return 0, nil
+}
+
+// reindexURL returns the URL of the Zettelstore that listens on the given
+// address.
+func reindexURL(addr, prefix string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "http://" + addr + prefix
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	return "http://" + net.JoinHostPort(host, port) + prefix
+}
+
+func reindexLogin(base, ident, password string) (string, error) {
+	req, err := http.NewRequest(http.MethodPost, base+"a?_format=json", nil)
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(ident, password)
+	var result struct {
+		Token string `json:"access_token"`
+	}
+	if err = reindexDo(req, &result); err != nil {
+		return "", err
+	}
+	return result.Token, nil
+}
+
+func reindexRequest(method, base, token string) (reindexProgress, error) {
+	var result reindexProgress
+	req, err := http.NewRequest(method, base+"i", nil)
+	if err != nil {
+		return result, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	err = reindexDo(req, &result)
+	return result, err
+}
+
+func reindexDo(req *http.Request, result interface{}) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("%v: %s", resp.Status, apiErr.Message)
+		}
+		return fmt.Errorf("%v: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
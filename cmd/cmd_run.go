@@ -11,14 +11,28 @@
 package cmd
 
 import (
+	"context"
 	"flag"
 	"log"
 	"net/http"
+	"os"
+	"sync"
+	"time"
 
+	"zettelstore.de/z/abstract"
+	"zettelstore.de/z/archive"
+	"zettelstore.de/z/audit"
 	"zettelstore.de/z/auth/policy"
+	"zettelstore.de/z/captcha"
 	"zettelstore.de/z/config/runtime"
 	"zettelstore.de/z/config/startup"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/index"
+	"zettelstore.de/z/linkcheck"
+	"zettelstore.de/z/lock"
 	"zettelstore.de/z/place"
+	"zettelstore.de/z/place/constplace"
+	"zettelstore.de/z/recent"
 	"zettelstore.de/z/usecase"
 	"zettelstore.de/z/web/adapter"
 	"zettelstore.de/z/web/adapter/api"
@@ -37,6 +51,8 @@ func flgRun(fs *flag.FlagSet) {
 	fs.Bool("r", false, "system-wide read-only mode")
 	fs.Bool("v", false, "verbose mode")
 	fs.Bool("debug", false, "debug mode")
+	fs.String("l", "", "log file")
+	fs.Bool("desktop", false, "desktop mode, opens the web browser and shows a tray icon")
 }
 
 func enableDebug(fs *flag.FlagSet, srv *server.Server) {
@@ -45,14 +61,59 @@ func enableDebug(fs *flag.FlagSet, srv *server.Server) {
 	}
 }
 
+// redirectLog writes all log messages to the log file given by a flag.
+func redirectLog(fs *flag.FlagSet) error {
+	if flg := fs.Lookup("l"); flg != nil && flg.Value.String() != "" {
+		f, err := os.OpenFile(flg.Value.String(), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		log.SetOutput(f)
+	}
+	return nil
+}
+
+func isDesktop(fs *flag.FlagSet) bool {
+	dsk := fs.Lookup("desktop")
+	return dsk != nil && dsk.Value.String() == "true"
+}
+
 func runFunc(fs *flag.FlagSet) (int, error) {
+	if err := redirectLog(fs); err != nil {
+		return 1, err
+	}
+	if path := startup.AuditFile(); path != "" {
+		if err := audit.Open(path); err != nil {
+			return 1, err
+		}
+	}
 	listenAddr := startup.ListenAddress()
 	readonlyMode := startup.IsReadOnlyMode()
 	logBeforeRun(listenAddr, readonlyMode)
+	createStarterPack(startup.PlaceManager())
+	startUpdateCheck()
+	startScheduler(startup.PlaceManager())
+	startArchiver(startup.PlaceManager())
+	startExtractor(startup.PlaceManager())
 	handler := setupRouting(startup.PlaceManager(), readonlyMode)
 	srv := server.New(listenAddr, handler)
+	srv.SetRequestTimeout(startup.RequestTimeout())
 	enableDebug(fs, srv)
-	if err := srv.Run(); err != nil {
+	if publicAddr := startup.PublicListenAddress(); publicAddr != "" {
+		log.Printf("Public mirror listening on %v", publicAddr)
+		pubSrv := server.New(publicAddr, setupPublicRouting(startup.PlaceManager()))
+		pubSrv.SetRequestTimeout(startup.RequestTimeout())
+		go func() {
+			if err := pubSrv.Run(); err != nil {
+				log.Println("Public mirror:", err)
+			}
+		}()
+	}
+	run := srv.Run
+	if isDesktop(fs) {
+		run = func() error { return runDesktop(srv) }
+	}
+	if err := run(); err != nil {
 		return 1, err
 	}
 	return 0, nil
@@ -69,10 +130,94 @@ func logBeforeRun(listenAddr string, readonlyMode bool) {
 	}
 }
 
-func setupRouting(up place.Place, readonlyMode bool) http.Handler {
+// startUpdateCheck starts to check periodically for newer releases, if a
+// release feed was configured.
+func startUpdateCheck() {
+	if checker := startup.UpdateChecker(); checker != nil {
+		checker.Start(context.Background(), updateInterval)
+	}
+}
+
+// startScheduler registers all maintenance jobs and starts to run them at
+// the intervals of the jobs zettel.
+func startScheduler(mgr place.Manager) {
+	scheduler := startup.Scheduler()
+	scheduler.Register("reindex", func(context.Context) error {
+		getIndexer(mgr).Rebuild()
+		return nil
+	})
+	scheduler.Register("linkcheck", func(ctx context.Context) error {
+		return linkcheck.Default().CheckAll(ctx, mgr)
+	})
+	scheduler.Start(context.Background(), runtime.GetJobs, schedulerTick)
+}
+
+// startArchiver submits the external links of saved zettel to a web archive,
+// if an archive was configured.
+func startArchiver(mgr place.Manager) {
+	if saveURL := startup.ArchiveURL(); saveURL != "" && !startup.IsReadOnlyMode() {
+		v := startup.GetVersion()
+		archive.New(saveURL, v.Prog+"/"+v.Build).Start(context.Background(), mgr)
+	}
+}
+
+// startExtractor extracts searchable text from binary zettel.
+func startExtractor(mgr place.Manager) {
+	ex := startup.TextExtractor()
+	mgr.AddProperty(ex.SetText)
+	ex.Start(context.Background(), mgr)
+}
+
+// createStarterPack stores some tutorial zettel into the first place, if it
+// is empty and if this was enabled by the startup configuration.
+func createStarterPack(mgr place.Manager) {
+	if !startup.StarterPack() || startup.IsReadOnlyMode() {
+		return
+	}
+	ctx := context.Background()
+	st := mgr.Stats(ctx)
+	for i := 0; len(st.Places) > 0 && st.Places[0].Scanning && i < 300; i++ {
+		time.Sleep(100 * time.Millisecond)
+		st = mgr.Stats(ctx)
+	}
+	if len(st.Places) == 0 || st.Places[0].ReadOnly || st.Places[0].Scanning ||
+		st.Places[0].Zettel > 0 {
+		return
+	}
+	for _, zettel := range constplace.StarterZettel() {
+		zid := zettel.Meta.Zid
+		newZid, err := mgr.CreateZettel(ctx, zettel)
+		if err == nil {
+			err = mgr.RenameZettel(ctx, newZid, zid)
+		}
+		if err != nil {
+			log.Printf("Unable to create starter zettel %v: %v", zid, err)
+			return
+		}
+	}
+	log.Println("Created tutorial zettel in empty place")
+}
+
+// lockDuration is the time after which the advisory lock of a zettel expires.
+const lockDuration = 30 * time.Minute
+
+// captchaDuration is the time to answer the question of a suggestion form.
+const captchaDuration = time.Hour
+
+// updateInterval is the time between two checks for newer releases.
+const updateInterval = 24 * time.Hour
+
+// schedulerTick is the time between two checks for jobs to run.
+const schedulerTick = time.Minute
+
+// recentSize is the number of recently visited or modified zettel to track.
+const recentSize = 50
+
+func setupRouting(up place.Manager, readonlyMode bool) http.Handler {
 	pp, pol := policy.PlaceWithPolicy(
-		up, startup.IsSimple(), startup.WithAuth, readonlyMode, runtime.GetExpertMode,
-		startup.IsOwner, runtime.GetVisibility)
+		linkcheck.NewPlace(abstract.NewPlace(up), linkcheck.Default()),
+		startup.IsSimple(), startup.WithAuth, readonlyMode, runtime.GetExpertMode,
+		startup.IsOwner, runtime.GetVisibility, runtime.GetPolicyRules)
 	te := webui.NewTemplateEngine(up, pol)
 
 	ucAuthenticate := usecase.NewAuthenticate(up)
@@ -80,66 +225,322 @@ func setupRouting(up place.Place, readonlyMode bool) http.Handler {
 	ucGetZettel := usecase.NewGetZettel(pp)
 	ucParseZettel := usecase.NewParseZettel(ucGetZettel)
 	ucListMeta := usecase.NewListMeta(pp)
-	ucListRoles := usecase.NewListRole(pp)
-	ucListTags := usecase.NewListTags(pp)
-	listHTMLMetaHandler := webui.MakeListHTMLMetaHandler(te, ucListMeta)
-	getHTMLZettelHandler := webui.MakeGetHTMLZettelHandler(te, ucParseZettel, ucGetMeta)
+	iv := newIndexView(up, pol)
+	ucListRoles := usecase.NewListRole(iv)
+	ucListTags := usecase.NewListTags(iv)
+	ucListFacets := usecase.NewListFacets(pp)
+	locks := lock.New(lockDuration)
+	ucGetLock := usecase.NewGetLock(locks)
+	tracker := recent.New(recentSize)
+	up.RegisterChangeObserver(tracker.Observe)
+	ucListRecent := usecase.NewListRecent(tracker, ucGetMeta)
+	ucListReview := usecase.NewListReview(pp)
+	listHTMLMetaHandler := webui.MakeListHTMLMetaHandler(
+		te, ucListMeta, ucListFacets, ucParseZettel)
+	ucQuota := usecase.NewQuota(up, getIndexer(up))
+	getHTMLZettelHandler := webui.MakeGetHTMLZettelHandler(
+		te, ucParseZettel, ucGetMeta, ucListMeta, usecase.NewGetGlossary(pp), ucGetLock,
+		usecase.NewListComments(iv), usecase.NewTrackVisit(tracker), ucListRecent, ucQuota)
+
+	ucGetUserByZid := usecase.NewGetUserByZid(up)
+	ucCreateZettel := usecase.NewCreateZettel(pp, te, ucQuota)
+	ucUpdateMeta := usecase.NewUpdateMeta(pp)
 
+	describe := router.Describe
+	optWrite := router.Write()
+	optChange := router.Change()
+	optAuthUser := router.Auth(router.AuthUser)
+	optAPI := router.API()
+	optAdmin := router.Admin()
+	mwForwarded := router.ForwardedMiddleware(
+		startup.URLPrefix(), startup.TrustProxy(), startup.TrustedProxies())
+	mwLimit := router.LimitMiddleware(startup.RequestTimeout(), startup.MaxRequestBody())
 	router := router.NewRouter()
+	router.Use(mwForwarded, mwLimit, func(next http.Handler) http.Handler {
+		return session.NewHandler(next, ucGetUserByZid)
+	}, audit.NewMiddleware(session.GetUser), adapter.ErrorMiddleware(te.ReportError))
+	router.SetErrorFunc(adapter.ReportStatus)
+	router.SetReadOnly(readonlyMode)
+	router.SetAuthenticated(isAuthenticated)
+	router.SetLoginHandler(
+		audit.NewDeniedHandler(session.GetUser, webui.MakeRedirectLoginHandler()))
+	router.SetCORS(startup.CORSAllowOrigins(), startup.CORSAllowMethods())
+	router.SetIPRules(ipRules())
 	router.Handle("/", webui.MakeGetRootHandler(
-		pp, listHTMLMetaHandler, getHTMLZettelHandler))
-	router.AddListRoute('a', http.MethodGet, webui.MakeGetLoginHandler(te))
+		pp, listHTMLMetaHandler, getHTMLZettelHandler), describe("Start page"))
+	router.Handle("/"+webui.ManifestPath, webui.MakeGetManifestHandler(te),
+		describe("Web app manifest"))
+	router.Handle("/"+webui.ServiceWorkerPath, webui.MakeGetServiceWorkerHandler(te),
+		describe("Service worker of the web app"))
+	router.Handle("/"+api.VersionPath, api.MakeGetVersionHandler(),
+		describe("Build information of the software"))
+	router.AddListRoute('a', http.MethodGet, webui.MakeGetLoginHandler(te), describe("Login form"))
 	router.AddListRoute('a', http.MethodPost, adapter.MakePostLoginHandler(
 		api.MakePostLoginHandlerAPI(ucAuthenticate),
-		webui.MakePostLoginHandlerHTML(te, ucAuthenticate)))
-	router.AddListRoute('a', http.MethodPut, api.MakeRenewAuthHandler())
-	router.AddZettelRoute('a', http.MethodGet, webui.MakeGetLogoutHandler())
+		webui.MakePostLoginHandlerHTML(te, ucAuthenticate)), describe("Authenticate a user"))
+	router.AddListRoute('a', http.MethodPut, api.MakeRenewAuthHandler(), optAuthUser, optAPI,
+		describe("Renew the authentication token"))
+	router.AddZettelRoute('a', http.MethodGet, webui.MakeGetLogoutHandler(), describe("Logout"))
+	guard, ucSuggestZettel := getSuggestions(up)
+	router.AddZettelRoute('b', http.MethodGet, webui.MakeGetSuggestZettelHandler(
+		te, ucGetZettel, guard), describe("Form to suggest a change of a zettel"))
+	router.AddZettelRoute('b', http.MethodPost, webui.MakePostSuggestZettelHandler(
+		te, ucGetZettel, ucSuggestZettel, guard), optChange,
+		describe("Suggest a change of a zettel"))
 	router.AddListRoute('c', http.MethodGet, adapter.MakeReloadHandler(
-		usecase.NewReload(pp), api.ReloadHandlerAPI, webui.ReloadHandlerHTML))
-	if !readonlyMode {
-		router.AddZettelRoute('c', http.MethodGet, webui.MakeGetCopyZettelHandler(
-			te, ucGetZettel, usecase.NewCopyZettel()))
-		router.AddZettelRoute('c', http.MethodPost, webui.MakePostCreateZettelHandler(
-			usecase.NewCreateZettel(pp)))
-		router.AddZettelRoute('d', http.MethodGet, webui.MakeGetDeleteZettelHandler(
-			te, ucGetZettel))
-		router.AddZettelRoute('d', http.MethodPost, webui.MakePostDeleteZettelHandler(
-			usecase.NewDeleteZettel(pp)))
-		router.AddZettelRoute('e', http.MethodGet, webui.MakeEditGetZettelHandler(
-			te, ucGetZettel))
-		router.AddZettelRoute('e', http.MethodPost, webui.MakeEditSetZettelHandler(
-			usecase.NewUpdateZettel(pp)))
-		router.AddZettelRoute('f', http.MethodGet, webui.MakeGetFolgeZettelHandler(
-			te, ucGetZettel, usecase.NewFolgeZettel()))
-		router.AddZettelRoute('f', http.MethodPost, webui.MakePostCreateZettelHandler(
-			usecase.NewCreateZettel(pp)))
-	}
-	router.AddListRoute('h', http.MethodGet, listHTMLMetaHandler)
-	router.AddZettelRoute('h', http.MethodGet, getHTMLZettelHandler)
+		usecase.NewReload(pp), api.ReloadHandlerAPI, webui.ReloadHandlerHTML), optAdmin,
+		describe("Reload all places"))
+	router.AddZettelRoute('c', http.MethodGet, webui.MakeGetCopyZettelHandler(
+		te, ucGetZettel, usecase.NewCopyZettel()), optWrite, describe("Form to copy a zettel"))
+	router.AddZettelRoute('c', http.MethodPost, webui.MakePostCreateZettelHandler(
+		ucCreateZettel), optWrite, describe("Create a copy of a zettel"))
+	ucListBacklinks := usecase.NewListBacklinks(iv)
+	router.AddZettelRoute('d', http.MethodGet, webui.MakeGetDeleteZettelHandler(
+		te, ucGetZettel, ucListBacklinks), optWrite, describe("Confirm to delete a zettel"))
+	router.AddZettelRoute('d', http.MethodPost, webui.MakePostDeleteZettelHandler(
+		usecase.NewDeleteWithLinks(pp, ucListBacklinks)), optWrite, describe("Delete a zettel"))
+	router.AddZettelRoute('e', http.MethodGet, webui.MakeEditGetZettelHandler(
+		te, ucGetZettel, ucGetLock, usecase.NewSuggestTags(pp)), optWrite,
+		describe("Form to edit a zettel"))
+	router.AddZettelRoute('e', http.MethodPost, webui.MakeEditSetZettelHandler(
+		usecase.NewUpdateZettel(pp, te, ucQuota)), optWrite, describe("Update a zettel"))
+	router.AddZettelRoute('f', http.MethodGet, webui.MakeGetFolgeZettelHandler(
+		te, ucGetZettel, usecase.NewFolgeZettel()), optWrite,
+		describe("Form to create a folge zettel"))
+	router.AddZettelRoute('f', http.MethodPost, webui.MakePostCreateZettelHandler(
+		ucCreateZettel), optWrite, describe("Create a folge zettel"))
+	router.AddZettelRoute('g', http.MethodPost, webui.MakePostLockZettelHandler(
+		usecase.NewLockZettel(locks, ucGetMeta)), optWrite, describe("Lock or unlock a zettel"))
+	router.AddListRoute('h', http.MethodGet, listHTMLMetaHandler, describe("List zettel"))
+	router.AddListRoute('h', http.MethodPost, webui.MakePostBulkEditHandler(
+		ucUpdateMeta), optWrite, describe("Change the metadata of many zettel"))
+	router.AddZettelRoute('h', http.MethodGet, getHTMLZettelHandler, describe("Show a zettel"))
+	ucGetShadowing := usecase.NewGetShadowing(pp, up)
+	ucReindex := usecase.NewReindex(getIndexer(up), up)
+	router.AddListRoute('i', http.MethodGet, api.MakeGetReindexHandler(
+		ucReindex), optAPI, optAdmin, describe("Progress of rebuilding the index"))
+	router.AddListRoute('i', http.MethodPost, api.MakePostReindexHandler(
+		ucReindex), optAPI, optAdmin, describe("Rebuild the index"))
+	router.AddZettelRoute('i', http.MethodGet, adapter.MakeGetInfoHandler(
+		api.MakeGetShadowingHandler(ucGetShadowing),
+		webui.MakeGetInfoHandler(
+			te, ucParseZettel, ucGetMeta, ucGetShadowing, usecase.NewListRelations(iv))),
+		describe("Information about a zettel"))
+	router.AddZettelRoute('i', http.MethodPost, webui.MakePostShadowingHandler(
+		usecase.NewCustomizeZettel(pp, up), usecase.NewRevertZettel(pp, up)), optWrite, optAdmin,
+		describe("Customize or revert a built-in zettel"))
+	router.AddZettelRoute('j', http.MethodPost, webui.MakePostFlagZettelHandler(
+		usecase.NewFlagZettel(up, ucGetMeta)), optWrite,
+		describe("Flag a zettel as favorite or to read later"))
+	router.AddZettelRoute('k', http.MethodGet, webui.MakeWebUIListsHandler(
+		te, ucListMeta, ucListFacets, ucListRoles, ucListTags, usecase.NewListTasks(pp),
+		ucListRecent, usecase.NewListCustomized(pp, constplace.CustomizableZettel()),
+		usecase.NewListDuplicates(pp), usecase.NewListCitations(pp, ucParseZettel),
+		ucListReview, ucParseZettel, router.Routes),
+		describe("Special lists, e.g. roles, tags, and tasks"))
+	router.AddZettelRoute('k', http.MethodPost, webui.MakePostResetZettelHandler(
+		usecase.NewResetZettel(pp, constplace.CustomizableZettel())), optWrite, optAdmin,
+		describe("Reset a customized zettel"))
+	router.AddListRoute('l', http.MethodGet, webui.MakeGetStudyHandler(
+		te, ucListReview, ucParseZettel, ucGetMeta), describe("Study the cards that are due"))
+	router.AddZettelRoute('l', http.MethodGet, api.MakeGetLinksHandler(
+		ucParseZettel, usecase.NewListRelations(iv)), optAPI, describe("Links of a zettel"))
+	router.AddZettelRoute('m', http.MethodPost, webui.MakePostCommentZettelHandler(
+		usecase.NewCommentZettel(pp, ucCreateZettel)), optWrite, describe("Comment a zettel"))
+	router.AddZettelRoute('n', http.MethodGet, webui.MakeGetNewZettelHandler(
+		te, ucGetZettel, usecase.NewNewZettel()), optWrite,
+		describe("Form to create a zettel from a template"))
+	router.AddZettelRoute('n', http.MethodPost, webui.MakePostCreateZettelHandler(
+		ucCreateZettel), optWrite, describe("Create a zettel from a template"))
+	router.AddZettelRoute('o', http.MethodGet, webui.MakeGetUndoZettelHandler(
+		te, ucGetMeta), optWrite, describe("Confirm to undo the last change of a zettel"))
+	router.AddZettelRoute('o', http.MethodPost, webui.MakePostUndoZettelHandler(
+		usecase.NewUndoZettel(pp)), optWrite, describe("Undo the last change of a zettel"))
+	router.AddZettelRoute('p', http.MethodGet, webui.MakeGetPolicyHandler(te, ucGetMeta),
+		describe("Explain the policy decisions for a zettel"))
+	router.AddListRoute('q', http.MethodGet, api.MakeDescribeListHandler(), optAPI,
+		describe("Describe the parameters of the zettel list"))
+	router.AddListRoute('r', http.MethodGet, api.MakeListRoleHandler(ucListRoles), optAPI,
+		describe("List all roles"))
+	router.AddZettelRoute('r', http.MethodGet, webui.MakeGetRenameZettelHandler(
+		te, ucGetMeta), optWrite, describe("Form to rename a zettel"))
+	router.AddZettelRoute('r', http.MethodPost, webui.MakePostRenameZettelHandler(
+		usecase.NewRenameZettel(pp)), optWrite, describe("Rename a zettel"))
+	router.AddListRoute('t', http.MethodGet, api.MakeListTagsHandler(ucListTags), optAPI,
+		describe("List all tags"))
+	router.AddZettelRoute('t', http.MethodPost, webui.MakePostReviewZettelHandler(
+		usecase.NewReviewZettel(pp)), optWrite, describe("Mark a zettel as reviewed"))
+	router.AddListRoute('u', http.MethodGet, api.MakeListRoutesHandler(router.Routes), optAPI,
+		describe("List all routes"))
+	router.AddZettelRoute('u', http.MethodGet, adapter.MakeReloadZettelHandler(
+		usecase.NewReloadZettel(pp), api.ReloadHandlerAPI, webui.ReloadZettelHandlerHTML), optAdmin,
+		describe("Reload a zettel"))
+	router.AddListRoute('s', http.MethodGet, webui.MakeSearchHandler(
+		te, usecase.NewSearch(pp), usecase.NewMatchedPages(startup.TextExtractor()),
+		ucGetMeta, ucGetZettel), describe("Search zettel"))
+	router.AddZettelRoute('s', http.MethodPost, webui.MakePostStatusZettelHandler(
+		usecase.NewSetStatus(pp)), optWrite, describe("Set the workflow state of a zettel"))
+	router.AddZettelRoute('v', http.MethodGet, webui.MakeGetDiffZettelHandler(te, ucGetZettel),
+		describe("Compare a zettel with its previous version"))
+	router.AddZettelRoute('v', http.MethodPost, webui.MakePostDiffZettelHandler(te, ucGetZettel),
+		describe("Compare a zettel with a given text"))
+	router.AddListRoute('w', http.MethodGet, api.MakeZettelPickerHandler(ucListMeta),
+		describe("Pick zettel for a link"))
+	router.AddZettelRoute('w', http.MethodGet, webui.MakeGetPreviewHandler(ucGetMeta),
+		describe("Preview of a zettel"))
+	router.AddZettelRoute('x', http.MethodPost, api.MakeToggleTaskHandler(
+		usecase.NewToggleTask(pp, ucQuota)), optWrite, optAPI, describe("Toggle a task of a zettel"))
+	router.AddListRoute('y', http.MethodGet, api.MakeLookupURLHandler(ucListMeta), optAPI,
+		describe("Look up a zettel by URL"))
+	router.AddZettelRoute('y', http.MethodGet, api.MakeGetLinkInfoHandler(ucGetMeta), optAPI,
+		describe("Link information about a zettel"))
+	router.AddListRoute('z', http.MethodGet, api.MakeListMetaHandler(
+		usecase.NewListMeta(pp), ucGetMeta, ucParseZettel), optAPI, describe("List zettel"))
+	router.AddListRoute('z', http.MethodPost, api.MakePostImportHandler(ucCreateZettel),
+		optWrite, optAPI, describe("Import zettel"))
+	router.AddZettelRoute('z', http.MethodGet, api.MakeGetZettelHandler(
+		ucParseZettel, ucGetMeta, usecase.NewOpenContent(pp)), optAPI,
+		describe("Retrieve a zettel"))
+	router.AddZettelRoute('z', http.MethodPatch, api.MakeUpdateMetaHandler(
+		ucUpdateMeta), optWrite, optAPI, describe("Change the metadata of a zettel"))
+	return router
+}
+
+// setupPublicRouting creates the handler for the public mirror. It serves only
+// zettel with visibility "public", without login and without any route that
+// changes zettel.
+func setupPublicRouting(up place.Manager) http.Handler {
+	pp, pol := policy.PublicPlace(
+		linkcheck.NewPlace(abstract.NewPlace(up), linkcheck.Default()), runtime.GetVisibility)
+	te := webui.NewPublicTemplateEngine(up, pol)
+
+	ucGetMeta := usecase.NewGetMeta(pp)
+	ucGetZettel := usecase.NewGetZettel(pp)
+	ucParseZettel := usecase.NewParseZettel(ucGetZettel)
+	ucListMeta := usecase.NewListMeta(pp)
+	iv := newIndexView(up, pol)
+	ucListRoles := usecase.NewListRole(iv)
+	ucListTags := usecase.NewListTags(iv)
+	ucListFacets := usecase.NewListFacets(pp)
+	tracker := recent.New(recentSize)
+	up.RegisterChangeObserver(tracker.Observe)
+	ucListRecent := usecase.NewListRecent(tracker, ucGetMeta)
+	ucListReview := usecase.NewListReview(pp)
+	listHTMLMetaHandler := webui.MakeListHTMLMetaHandler(
+		te, ucListMeta, ucListFacets, ucParseZettel)
+	// Zettel of the public mirror cannot be written, so they are never locked.
+	getHTMLZettelHandler := webui.MakeGetHTMLZettelHandler(
+		te, ucParseZettel, ucGetMeta, ucListMeta, usecase.NewGetGlossary(pp),
+		usecase.NewGetLock(lock.New(lockDuration)), usecase.NewListComments(iv),
+		usecase.NewTrackVisit(tracker), ucListRecent, usecase.NewQuota(up, getIndexer(up)))
+
+	describe := router.Describe
+	optChange := router.Change()
+	optAPI := router.API()
+	mwForwarded := router.ForwardedMiddleware(
+		startup.URLPrefix(), startup.TrustProxy(), startup.TrustedProxies())
+	mwLimit := router.LimitMiddleware(startup.RequestTimeout(), startup.MaxRequestBody())
+	router := router.NewRouter()
+	router.Use(mwForwarded, mwLimit, adapter.ErrorMiddleware(te.ReportError))
+	router.SetErrorFunc(adapter.ReportStatus)
+	// Only suggestions may change the store, all other routes just read.
+	router.SetReadOnly(startup.IsReadOnlyMode())
+	router.SetIPRules(ipRules())
+	router.Handle("/", webui.MakeGetRootHandler(
+		pp, listHTMLMetaHandler, getHTMLZettelHandler), describe("Start page"))
+	router.Handle("/"+webui.ManifestPath, webui.MakeGetManifestHandler(te),
+		describe("Web app manifest"))
+	router.Handle("/"+webui.ServiceWorkerPath, webui.MakeGetServiceWorkerHandler(te),
+		describe("Service worker of the web app"))
+	// Suggestions do not change any zettel directly. They are checked by the
+	// use case, not by the policy of the public mirror.
+	guard, ucSuggestZettel := getSuggestions(up)
+	router.AddZettelRoute('b', http.MethodGet, webui.MakeGetSuggestZettelHandler(
+		te, ucGetZettel, guard), describe("Form to suggest a change of a zettel"))
+	router.AddZettelRoute('b', http.MethodPost, webui.MakePostSuggestZettelHandler(
+		te, ucGetZettel, ucSuggestZettel, guard), optChange,
+		describe("Suggest a change of a zettel"))
+	router.AddListRoute('h', http.MethodGet, listHTMLMetaHandler, describe("List zettel"))
+	router.AddZettelRoute('h', http.MethodGet, getHTMLZettelHandler, describe("Show a zettel"))
 	router.AddZettelRoute('i', http.MethodGet, webui.MakeGetInfoHandler(
-		te, ucParseZettel, ucGetMeta))
+		te, ucParseZettel, ucGetMeta, usecase.NewGetShadowing(pp, up),
+		usecase.NewListRelations(iv)), describe("Information about a zettel"))
 	router.AddZettelRoute('k', http.MethodGet, webui.MakeWebUIListsHandler(
-		te, ucListMeta, ucListRoles, ucListTags))
-	router.AddZettelRoute('l', http.MethodGet, api.MakeGetLinksHandler(ucParseZettel))
-	if !readonlyMode {
-		router.AddZettelRoute('n', http.MethodGet, webui.MakeGetNewZettelHandler(
-			te, ucGetZettel, usecase.NewNewZettel()))
-		router.AddZettelRoute('n', http.MethodPost, webui.MakePostCreateZettelHandler(
-			usecase.NewCreateZettel(pp)))
-	}
-	router.AddListRoute('r', http.MethodGet, api.MakeListRoleHandler(ucListRoles))
-	if !readonlyMode {
-		router.AddZettelRoute('r', http.MethodGet, webui.MakeGetRenameZettelHandler(
-			te, ucGetMeta))
-		router.AddZettelRoute('r', http.MethodPost, webui.MakePostRenameZettelHandler(
-			usecase.NewRenameZettel(pp)))
-	}
-	router.AddListRoute('t', http.MethodGet, api.MakeListTagsHandler(ucListTags))
+		te, ucListMeta, ucListFacets, ucListRoles, ucListTags, usecase.NewListTasks(pp),
+		ucListRecent, usecase.NewListCustomized(pp, nil), usecase.NewListDuplicates(pp),
+		usecase.NewListCitations(pp, ucParseZettel), ucListReview, ucParseZettel,
+		router.Routes),
+		describe("Special lists, e.g. roles, tags, and tasks"))
+	router.AddListRoute('l', http.MethodGet, webui.MakeGetStudyHandler(
+		te, ucListReview, ucParseZettel, ucGetMeta), describe("Study the cards that are due"))
 	router.AddListRoute('s', http.MethodGet, webui.MakeSearchHandler(
-		te, usecase.NewSearch(pp), ucGetMeta, ucGetZettel))
+		te, usecase.NewSearch(pp), usecase.NewMatchedPages(startup.TextExtractor()),
+		ucGetMeta, ucGetZettel), describe("Search zettel"))
+	router.AddZettelRoute('w', http.MethodGet, webui.MakeGetPreviewHandler(ucGetMeta),
+		describe("Preview of a zettel"))
+	router.AddListRoute('y', http.MethodGet, api.MakeLookupURLHandler(ucListMeta), optAPI,
+		describe("Look up a zettel by URL"))
+	router.AddZettelRoute('y', http.MethodGet, api.MakeGetLinkInfoHandler(ucGetMeta), optAPI,
+		describe("Link information about a zettel"))
 	router.AddListRoute('z', http.MethodGet, api.MakeListMetaHandler(
-		usecase.NewListMeta(pp), ucGetMeta, ucParseZettel))
+		ucListMeta, ucGetMeta, ucParseZettel), optAPI, describe("List zettel"))
 	router.AddZettelRoute('z', http.MethodGet, api.MakeGetZettelHandler(
-		ucParseZettel, ucGetMeta))
-	return session.NewHandler(router, usecase.NewGetUserByZid(up))
+		ucParseZettel, ucGetMeta, usecase.NewOpenContent(pp)), optAPI,
+		describe("Retrieve a zettel"))
+	return router
+}
+
+// newIndexView creates an index of the given place. Its view contains only
+// zettel that the current user is allowed to read, within the scope of the
+// user's token.
+func newIndexView(up place.Place, pol policy.Policy) *index.View {
+	return getIndexer(up).NewView(func(ctx context.Context, m *meta.Meta) bool {
+		return policy.ForContext(ctx, pol).CanRead(session.GetUser(ctx), m)
+	})
+}
+
+var (
+	indexerOnce sync.Once
+	indexer     *index.Indexer
+)
+
+// getIndexer returns the index of the given place. All routers share the same
+// index, so that it is rebuilt only once.
+func getIndexer(up place.Place) *index.Indexer {
+	indexerOnce.Do(func() { indexer = index.New(up) })
+	return indexer
+}
+
+var (
+	suggestOnce  sync.Once
+	suggestGuard *captcha.Guard
+	suggest      usecase.SuggestZettel
+)
+
+// getSuggestions returns the captcha guard and the use case to suggest a
+// change of a zettel. All routers share them, so that a captcha can only be
+// answered once and the limits of suggestions apply to all listeners.
+func getSuggestions(up place.Manager) (*captcha.Guard, usecase.SuggestZettel) {
+	suggestOnce.Do(func() {
+		suggestGuard = captcha.New(startup.Secret(), captchaDuration)
+		suggest = usecase.NewSuggestZettel(
+			up, usecase.NewCreateZettel(up, nil, usecase.NewQuota(up, getIndexer(up))))
+	})
+	return suggestGuard, suggest
+}
+
+// isAuthenticated returns true, if the request was made by an authenticated
+// user, or if authentication is not enabled.
+func isAuthenticated(r *http.Request) bool {
+	return !startup.WithAuth() || session.GetUser(r.Context()) != nil
+}
+
+// ipRules returns the rules that restrict the access to the web user
+// interface, to the API, and to the administrative routes.
+func ipRules() (router.IPRule, router.IPRule, router.IPRule) {
+	newRule := func(area string) router.IPRule {
+		allow, deny := startup.IPRule(area)
+		return router.IPRule{Allow: allow, Deny: deny}
+	}
+	return newRule(startup.AreaWebUI), newRule(startup.AreaAPI), newRule(startup.AreaAdmin)
 }
@@ -33,6 +33,11 @@ func flgSimpleRun(fs *flag.FlagSet) {
 
 func runSimpleFunc(*flag.FlagSet) (int, error) {
 	p := startup.PlaceManager()
+	createStarterPack(p)
+	startUpdateCheck()
+	startScheduler(p)
+	startArchiver(p)
+	startExtractor(p)
 	if _, err := p.GetMeta(context.Background(), id.WelcomeZid); err != nil {
 		if err == place.ErrNotFound {
 			updateWelcomeZettel(p)
@@ -42,7 +47,7 @@ func runSimpleFunc(*flag.FlagSet) (int, error) {
 	listenAddr := startup.ListenAddress()
 	readonlyMode := startup.IsReadOnlyMode()
 	logBeforeRun(listenAddr, readonlyMode)
-	if idx := strings.LastIndexByte(listenAddr, ':'); idx >= 0 {
+	if idx := strings.LastIndexByte(listenAddr, ':'); idx >= 0 && !strings.HasPrefix(listenAddr, "unix:") {
 		log.Println()
 		log.Println("--------------------------")
 		log.Printf("Open your browser and enter the following URL:")
@@ -52,6 +57,7 @@ func runSimpleFunc(*flag.FlagSet) (int, error) {
 
 	handler := setupRouting(startup.PlaceManager(), readonlyMode)
 	srv := server.New(listenAddr, handler)
+	srv.SetRequestTimeout(startup.RequestTimeout())
 	if err := srv.Run(); err != nil {
 		return 1, err
 	}
@@ -0,0 +1,116 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// ---------- Subcommand: service --------------------------------------------
+
+// serviceName is the name under which Zettelstore is registered as a service.
+const serviceName = "zettelstore"
+
+// errServiceUnsupported signals that there is no service integration for the
+// current platform.
+var errServiceUnsupported = errors.New("services are not supported on this platform")
+
+func flgService(fs *flag.FlagSet) {
+	fs.String("c", defConfigfile, "configuration file")
+	fs.Uint("p", 23123, "port number")
+	fs.String("d", "", "zettel directory")
+	fs.Bool("r", false, "system-wide read-only mode")
+}
+
+// serviceSpec describes how the service runs Zettelstore.
+type serviceSpec struct {
+	exe  string   // absolute path of the executable
+	args []string // arguments of the run command
+	dir  string   // working directory
+}
+
+func cmdService(fs *flag.FlagSet) (int, error) {
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: service [flags] install|uninstall|start|stop")
+		return 2, nil
+	}
+	var err error
+	switch action := fs.Arg(0); action {
+	case "install":
+		var spec *serviceSpec
+		if spec, err = newServiceSpec(fs); err == nil {
+			err = installService(spec)
+		}
+	case "uninstall":
+		err = uninstallService()
+	case "start":
+		err = startService()
+	case "stop":
+		err = stopService()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown service action %q\n", action)
+		return 2, nil
+	}
+	if err != nil {
+		return 1, err
+	}
+	return 0, nil
+}
+
+// newServiceSpec builds the run command from the given flags. All paths are
+// made absolute, because services are not started in the current directory.
+func newServiceSpec(fs *flag.FlagSet) (*serviceSpec, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"run"}
+	hasConfig := false
+	var fsErr error
+	fs.Visit(func(flg *flag.Flag) {
+		val := flg.Value.String()
+		switch flg.Name {
+		case "c", "d":
+			hasConfig = hasConfig || flg.Name == "c"
+			if val, err = filepath.Abs(val); err != nil {
+				fsErr = err
+			}
+		}
+		args = append(args, "-"+flg.Name+"="+val)
+	})
+	if fsErr != nil {
+		return nil, fsErr
+	}
+	if !hasConfig {
+		if _, err = os.Stat(defConfigfile); err == nil {
+			args = append(args, "-c="+filepath.Join(dir, defConfigfile))
+		}
+	}
+	return &serviceSpec{exe: exe, args: args, dir: dir}, nil
+}
+
+// runServiceTool executes an external program of the platform to manage
+// services.
+func runServiceTool(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
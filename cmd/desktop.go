@@ -0,0 +1,87 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+package cmd
+
+import (
+	"log"
+	"net"
+	"os/exec"
+	"runtime"
+	"strconv"
+
+	"zettelstore.de/z/config/startup"
+	"zettelstore.de/z/web/server"
+)
+
+// Paths of the quick actions, relative to the home page.
+const (
+	newZettelPath = "n/00000000091001" // Form to create a new zettel
+	searchPath    = "s"                // Search form
+)
+
+// runDesktop runs the web server, opens its home page in the web browser of
+// the user, and shows a tray icon with some quick actions. The tray icon is
+// only available, if the program was built with the tag "desktop".
+func runDesktop(srv *server.Server) error {
+	homeURLs := make(chan string, 1)
+	srv.SetOnStart(func(addr net.Addr) {
+		u, ok := homeURL(addr)
+		if !ok {
+			log.Printf("Unable to open web browser for address %v", addr)
+			close(homeURLs)
+			return
+		}
+		openURL(u)
+		homeURLs <- u
+	})
+	done := make(chan error, 1)
+	go func() { done <- srv.Run() }()
+	return runTray(homeURLs, srv.Stop, done)
+}
+
+// homeURL returns the URL of the home page for the given address of the
+// listener.
+func homeURL(addr net.Addr) (string, bool) {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return "", false
+	}
+	host := "localhost"
+	if !tcpAddr.IP.IsUnspecified() && !tcpAddr.IP.IsLoopback() {
+		host = tcpAddr.IP.String()
+	}
+	return "http://" + net.JoinHostPort(host, strconv.Itoa(tcpAddr.Port)) + startup.URLPrefix(), true
+}
+
+// openURL opens the given URL in the web browser of the user.
+func openURL(u string) {
+	if err := openBrowser(u); err != nil {
+		log.Printf("Unable to open web browser: %v", err)
+		log.Printf("Please open %v", u)
+	}
+}
+
+func openBrowser(u string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", u)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		cmd = exec.Command("xdg-open", u)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
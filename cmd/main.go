@@ -64,9 +64,10 @@ func init() {
 		Flags:  flgSimpleRun,
 	})
 	RegisterCommand(Command{
-		Name:  "config",
-		Func:  cmdConfig,
-		Flags: flgRun,
+		Name:   "config",
+		Func:   cmdConfig,
+		Places: true,
+		Flags:  flgRun,
 	})
 	RegisterCommand(Command{
 		Name: "file",
@@ -75,6 +76,16 @@ func init() {
 			fs.String("t", "html", "target output format")
 		},
 	})
+	RegisterCommand(Command{
+		Name:  "service",
+		Func:  cmdService,
+		Flags: flgService,
+	})
+	RegisterCommand(Command{
+		Name:  "reindex",
+		Func:  cmdReindex,
+		Flags: flgReindex,
+	})
 	RegisterCommand(Command{
 		Name: "password",
 		Func: cmdPassword,
@@ -86,6 +97,7 @@ func fmtVersion() {
 	fmt.Printf("%v (%v/%v) running on %v (%v/%v)\n",
 		version.Prog, version.Build, version.GoVersion,
 		version.Hostname, version.Os, version.Arch)
+	fmt.Printf("Commit %v, built %v\n", version.Commit, version.Date)
 }
 
 func getConfig(fs *flag.FlagSet) (cfg *meta.Meta) {
@@ -134,7 +146,7 @@ func setupOperations(cfg *meta.Meta, withPlaces bool, simple bool) error {
 
 	err := startup.SetupStartup(cfg, mgr, simple)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "Unable to connect to specified places")
+		fmt.Fprintln(os.Stderr, "Unable to use startup configuration")
 		return err
 	}
 	if withPlaces {
@@ -204,8 +216,8 @@ func executeCommand(name string, args ...string) {
 }
 
 // Main is the real entrypoint of the zettelstore.
-func Main(progName, buildVersion string) {
-	startup.SetupVersion(progName, buildVersion)
+func Main(progName, buildVersion, buildCommit, buildDate string) {
+	startup.SetupVersion(progName, buildVersion, buildCommit, buildDate)
 	if len(os.Args) <= 1 {
 		runSimple()
 	} else {
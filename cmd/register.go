@@ -19,7 +19,9 @@ import (
 	_ "zettelstore.de/z/encoder/rawenc"    // Allow to use raw encoder.
 	_ "zettelstore.de/z/encoder/textenc"   // Allow to use text encoder.
 	_ "zettelstore.de/z/encoder/zmkenc"    // Allow to use zmk encoder.
+	_ "zettelstore.de/z/parser/abc"        // Allow to use ABC music parser.
 	_ "zettelstore.de/z/parser/blob"       // Allow to use BLOB parser.
+	_ "zettelstore.de/z/parser/drawing"    // Allow to use drawing parser.
 	_ "zettelstore.de/z/parser/markdown"   // Allow to use markdown parser.
 	_ "zettelstore.de/z/parser/none"       // Allow to use none parser.
 	_ "zettelstore.de/z/parser/plain"      // Allow to use plain parser.
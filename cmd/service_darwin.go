@@ -0,0 +1,109 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+package cmd
+
+import (
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// On macOS, Zettelstore is installed as a launchd agent of the current user.
+// Log messages are written to ~/Library/Logs/Zettelstore.
+
+const launchdLabel = "de.zettelstore"
+
+func plistPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "Library", "LaunchAgents", launchdLabel+".plist"), nil
+}
+
+func installService(spec *serviceSpec) error {
+	path, err := plistPath()
+	if err != nil {
+		return err
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	logDir := filepath.Join(home, "Library", "Logs", "Zettelstore")
+	if err = os.MkdirAll(logDir, 0755); err != nil {
+		return err
+	}
+	logFile := filepath.Join(logDir, serviceName+".log")
+
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+`)
+	writePlistString(&sb, "Label", launchdLabel)
+	sb.WriteString("<key>ProgramArguments</key>\n<array>\n")
+	writePlistValue(&sb, spec.exe)
+	for _, arg := range spec.args {
+		writePlistValue(&sb, arg)
+	}
+	sb.WriteString("</array>\n")
+	writePlistString(&sb, "WorkingDirectory", spec.dir)
+	writePlistString(&sb, "StandardOutPath", logFile)
+	writePlistString(&sb, "StandardErrorPath", logFile)
+	sb.WriteString("<key>RunAtLoad</key>\n<true/>\n</dict>\n</plist>\n")
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, []byte(sb.String()), 0644)
+}
+
+func writePlistString(w io.Writer, key, value string) {
+	io.WriteString(w, "<key>")
+	xml.EscapeText(w, []byte(key))
+	io.WriteString(w, "</key>\n")
+	writePlistValue(w, value)
+}
+
+func writePlistValue(w io.Writer, value string) {
+	io.WriteString(w, "<string>")
+	xml.EscapeText(w, []byte(value))
+	io.WriteString(w, "</string>\n")
+}
+
+func uninstallService() error {
+	path, err := plistPath()
+	if err != nil {
+		return err
+	}
+	runServiceTool("launchctl", "unload", path)
+	return os.Remove(path)
+}
+
+func startService() error {
+	path, err := plistPath()
+	if err != nil {
+		return err
+	}
+	return runServiceTool("launchctl", "load", "-w", path)
+}
+
+func stopService() error {
+	path, err := plistPath()
+	if err != nil {
+		return err
+	}
+	return runServiceTool("launchctl", "unload", "-w", path)
+}
@@ -0,0 +1,75 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// On Linux, Zettelstore is installed as a systemd user service. Log messages
+// are written to the journal.
+
+func unitPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "systemd", "user", serviceName+".service"), nil
+}
+
+func installService(spec *serviceSpec) error {
+	path, err := unitPath()
+	if err != nil {
+		return err
+	}
+	cmdLine := make([]string, 0, len(spec.args)+1)
+	cmdLine = append(cmdLine, strconv.Quote(spec.exe))
+	for _, arg := range spec.args {
+		cmdLine = append(cmdLine, strconv.Quote(arg))
+	}
+	var sb strings.Builder
+	sb.WriteString("[Unit]\nDescription=Zettelstore\nAfter=network.target\n\n")
+	fmt.Fprintf(&sb, "[Service]\nExecStart=%v\n", strings.Join(cmdLine, " "))
+	fmt.Fprintf(&sb, "WorkingDirectory=%v\n", strconv.Quote(spec.dir))
+	sb.WriteString("Restart=on-failure\n\n[Install]\nWantedBy=default.target\n")
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+		return err
+	}
+	if err = runServiceTool("systemctl", "--user", "daemon-reload"); err != nil {
+		return err
+	}
+	return runServiceTool("systemctl", "--user", "enable", serviceName)
+}
+
+func uninstallService() error {
+	path, err := unitPath()
+	if err != nil {
+		return err
+	}
+	if err = runServiceTool("systemctl", "--user", "disable", "--now", serviceName); err != nil {
+		return err
+	}
+	if err = os.Remove(path); err != nil {
+		return err
+	}
+	return runServiceTool("systemctl", "--user", "daemon-reload")
+}
+
+func startService() error { return runServiceTool("systemctl", "--user", "start", serviceName) }
+func stopService() error  { return runServiceTool("systemctl", "--user", "stop", serviceName) }
@@ -0,0 +1,19 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+//go:build !darwin && !linux && !windows
+// +build !darwin,!linux,!windows
+
+package cmd
+
+func installService(*serviceSpec) error { return errServiceUnsupported }
+func uninstallService() error           { return errServiceUnsupported }
+func startService() error               { return errServiceUnsupported }
+func stopService() error                { return errServiceUnsupported }
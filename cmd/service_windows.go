@@ -0,0 +1,101 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+package cmd
+
+import (
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unicode/utf16"
+)
+
+// On Windows, Zettelstore is installed as a task of the task scheduler, that
+// starts when the current user logs in. Log messages are written to
+// %LOCALAPPDATA%\Zettelstore.
+
+const taskName = "Zettelstore"
+
+func installService(spec *serviceSpec) error {
+	u, err := user.Current()
+	if err != nil {
+		return err
+	}
+	cacheDir, err := os.UserCacheDir() // This is %LOCALAPPDATA%
+	if err != nil {
+		return err
+	}
+	logDir := filepath.Join(cacheDir, "Zettelstore")
+	if err = os.MkdirAll(logDir, 0755); err != nil {
+		return err
+	}
+	args := append(spec.args, "-l="+filepath.Join(logDir, serviceName+".log"))
+	for i, arg := range args {
+		args[i] = syscall.EscapeArg(arg)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-16"?>
+<Task version="1.2" xmlns="http://schemas.microsoft.com/windows/2004/02/mit/task">
+<RegistrationInfo><Description>Zettelstore</Description></RegistrationInfo>
+<Triggers><LogonTrigger><Enabled>true</Enabled>`)
+	writeXMLElement(&sb, "UserId", u.Username)
+	sb.WriteString(`</LogonTrigger></Triggers>
+<Settings>
+<MultipleInstancesPolicy>IgnoreNew</MultipleInstancesPolicy>
+<DisallowStartIfOnBatteries>false</DisallowStartIfOnBatteries>
+<StopIfGoingOnBatteries>false</StopIfGoingOnBatteries>
+<ExecutionTimeLimit>PT0S</ExecutionTimeLimit>
+<Hidden>true</Hidden>
+</Settings>
+<Actions Context="Author"><Exec>`)
+	writeXMLElement(&sb, "Command", spec.exe)
+	writeXMLElement(&sb, "Arguments", strings.Join(args, " "))
+	writeXMLElement(&sb, "WorkingDirectory", spec.dir)
+	sb.WriteString("</Exec></Actions>\n</Task>\n")
+
+	// The task scheduler expects the XML file to be encoded in UTF-16.
+	f, err := ioutil.TempFile("", "zettelstore-task-*.xml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	data := utf16.Encode([]rune("\ufeff" + sb.String()))
+	buf := make([]byte, 0, 2*len(data))
+	for _, c := range data {
+		buf = append(buf, byte(c), byte(c>>8))
+	}
+	_, err = f.Write(buf)
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		return err
+	}
+	return runServiceTool("schtasks", "/Create", "/TN", taskName, "/XML", f.Name(), "/F")
+}
+
+func writeXMLElement(w io.Writer, name, value string) {
+	io.WriteString(w, "<"+name+">")
+	xml.EscapeText(w, []byte(value))
+	io.WriteString(w, "</"+name+">")
+}
+
+func uninstallService() error {
+	return runServiceTool("schtasks", "/Delete", "/TN", taskName, "/F")
+}
+
+func startService() error { return runServiceTool("schtasks", "/Run", "/TN", taskName) }
+func stopService() error  { return runServiceTool("schtasks", "/End", "/TN", taskName) }
@@ -0,0 +1,125 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+//go:build desktop
+// +build desktop
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"runtime"
+
+	"fyne.io/systray"
+)
+
+// runTray shows a tray icon with some quick actions until the web server
+// stops. The quick actions open pages of the home page given by homeURLs.
+func runTray(homeURLs <-chan string, stop func(), done <-chan error) error {
+	result := make(chan error, 1)
+	systray.Run(func() {
+		systray.SetIcon(trayIcon())
+		systray.SetTitle("Zettelstore")
+		systray.SetTooltip("Zettelstore")
+		mOpen := systray.AddMenuItem("Open Zettelstore", "Open the home page")
+		mNew := systray.AddMenuItem("New zettel", "Create a new zettel")
+		mSearch := systray.AddMenuItem("Search", "Search for zettel")
+		systray.AddSeparator()
+		mQuit := systray.AddMenuItem("Quit", "Stop Zettelstore")
+		go func() {
+			home := ""
+			for {
+				select {
+				case u, ok := <-homeURLs:
+					if ok {
+						home = u
+					}
+					homeURLs = nil
+				case <-mOpen.ClickedCh:
+					openAction(home, "")
+				case <-mNew.ClickedCh:
+					openAction(home, newZettelPath)
+				case <-mSearch.ClickedCh:
+					openAction(home, searchPath)
+				case <-mQuit.ClickedCh:
+					stop()
+				case err := <-done:
+					result <- err
+					systray.Quit()
+					return
+				}
+			}
+		}()
+	}, nil)
+	return <-result
+}
+
+// openAction opens the page of a quick action, if the home page is known.
+func openAction(home, path string) {
+	if home != "" {
+		openURL(home + path)
+	}
+}
+
+// trayIcon returns the icon of the tray: a white "Z" on a blue square. On
+// Windows, the icon must be in ICO format, otherwise in PNG format.
+func trayIcon() []byte {
+	const size = 64
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	blue := color.NRGBA{R: 0x1f, G: 0x4e, B: 0x8c, A: 0xff}
+	white := color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	const margin, stroke = 14, 7
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			c := blue
+			inside := x >= margin && x < size-margin
+			top := y >= margin && y < margin+stroke
+			bottom := y >= size-margin-stroke && y < size-margin
+			// The diagonal runs from the upper right to the lower left corner.
+			diag := y > margin && y < size-margin && abs(x+y-size) < stroke/2+1
+			if inside && (top || bottom || diag) {
+				c = white
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	if runtime.GOOS != "windows" {
+		return buf.Bytes()
+	}
+	return pngToICO(buf.Bytes(), size)
+}
+
+// pngToICO wraps a quadratic PNG image into an ICO file.
+func pngToICO(data []byte, size int) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, struct {
+		Reserved, Type, Count uint16
+	}{0, 1, 1})
+	binary.Write(&buf, binary.LittleEndian, struct {
+		Width, Height, Colors, Reserved uint8
+		Planes, BitCount                uint16
+		Size, Offset                    uint32
+	}{uint8(size), uint8(size), 0, 0, 1, 32, uint32(len(data)), 6 + 16})
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
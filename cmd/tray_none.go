@@ -0,0 +1,23 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+//go:build !desktop
+// +build !desktop
+
+package cmd
+
+import "log"
+
+// runTray just waits for the web server to stop, because this program was
+// built without a tray icon.
+func runTray(homeURLs <-chan string, stop func(), done <-chan error) error {
+	log.Println("Tray icon not available, build with tag \"desktop\" to enable it")
+	return <-done
+}
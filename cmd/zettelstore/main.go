@@ -15,9 +15,13 @@ import (
 	"zettelstore.de/z/cmd"
 )
 
-// Version variable. Will be filled by build process.
-var buildVersion string = ""
+// Version variables. Will be filled by build process.
+var (
+	buildVersion string = ""
+	buildCommit  string = ""
+	buildDate    string = ""
+)
 
 func main() {
-	cmd.Main("Zettelstore", buildVersion)
+	cmd.Main("Zettelstore", buildVersion, buildCommit, buildDate)
 }
@@ -17,9 +17,21 @@ import (
 
 // Summary stores the relevant parts of the syntax tree
 type Summary struct {
-	Links  []*ast.Reference // list of all referenced links
-	Images []*ast.Reference // list of all referenced images
-	Cites  []*ast.CiteNode  // list of all referenced citations
+	Links     []*ast.Reference // list of all referenced links
+	Images    []*ast.Reference // list of all referenced images
+	Cites     []*ast.CiteNode  // list of all referenced citations
+	Relations []Relation       // list of all links with a relation type
+}
+
+// RelationKey is the attribute of a link that specifies the relation type,
+// e.g. [[Text|target]]{rel=contradicts}.
+const RelationKey = "rel"
+
+// Relation is a link that specifies the type of the relation to the
+// referenced zettel. Ref is the same reference as in Summary.Links.
+type Relation struct {
+	Ref *ast.Reference
+	Rel string
 }
 
 // References returns all references mentioned in the given zettel. This also
@@ -73,9 +85,13 @@ func (lv *linkVisitor) VisitSpace(sn *ast.SpaceNode) {}
 // VisitBreak does nothing.
 func (lv *linkVisitor) VisitBreak(bn *ast.BreakNode) {}
 
-// VisitLink collects the given link as a reference.
+// VisitLink collects the given link as a reference, together with its
+// relation type.
 func (lv *linkVisitor) VisitLink(ln *ast.LinkNode) {
 	lv.summary.Links = append(lv.summary.Links, ln.Ref)
+	if rel, ok := ln.Attrs.Get(RelationKey); ok && rel != "" {
+		lv.summary.Relations = append(lv.summary.Relations, Relation{Ref: ln.Ref, Rel: rel})
+	}
 }
 
 // VisitImage collects the image links as a reference.
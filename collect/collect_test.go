@@ -51,6 +51,20 @@ func TestLinks(t *testing.T) {
 	if cnt := len(summary.Links); cnt != 3 {
 		t.Error("Link count does not work. Expected: 3, got", summary.Links)
 	}
+	if summary.Relations != nil {
+		t.Error("No relations expected, but got:", summary.Relations)
+	}
+
+	relNode := &ast.LinkNode{
+		Ref:   parseRef("01234567890124"),
+		Attrs: &ast.Attributes{Attrs: map[string]string{"rel": "contradicts"}},
+	}
+	para.Inlines = append(para.Inlines, relNode)
+	summary = collect.References(zn)
+	if len(summary.Relations) != 1 || summary.Relations[0].Ref != relNode.Ref ||
+		summary.Relations[0].Rel != "contradicts" {
+		t.Error("Relation expected, but got:", summary.Relations)
+	}
 }
 
 func TestImage(t *testing.T) {
@@ -68,3 +82,63 @@ func TestImage(t *testing.T) {
 		t.Error("Only image expected, but got: ", summary.Images)
 	}
 }
+
+func TestTasks(t *testing.T) {
+	inner := &ast.NestedListNode{
+		Code:  ast.NestedListUnordered,
+		Items: []ast.ItemSlice{{}, {}},
+		Tasks: []ast.Task{{State: ast.TaskDone, Pos: 10}},
+	}
+	outer := &ast.NestedListNode{
+		Code:  ast.NestedListUnordered,
+		Items: []ast.ItemSlice{{inner}, {}, {}},
+	}
+	outer.SetTask(0, ast.Task{State: ast.TaskOpen, Pos: 2})
+	outer.SetTask(2, ast.Task{State: ast.TaskOpen, Pos: 30})
+	zn := &ast.ZettelNode{Ast: ast.BlockSlice{outer}}
+	tasks := collect.Tasks(zn)
+	if len(tasks) != 3 {
+		t.Fatalf("Expected 3 tasks, but got %v", tasks)
+	}
+	for i, pos := range []int{2, 10, 30} {
+		if got := tasks[i].Pos; got != pos {
+			t.Errorf("Task %d: expected position %d, but got %d", i, pos, got)
+		}
+	}
+	if tasks[1].State != ast.TaskDone {
+		t.Errorf("Task 1 should be done, but got %v", tasks[1].State)
+	}
+}
+
+func TestSection(t *testing.T) {
+	h1 := &ast.HeadingNode{Level: 1, Slug: "a"}
+	p1 := &ast.ParaNode{}
+	h2 := &ast.HeadingNode{Level: 2, Slug: "b"}
+	p2 := &ast.ParaNode{}
+	h3 := &ast.HeadingNode{Level: 1, Slug: "c"}
+	zn := &ast.ZettelNode{Ast: ast.BlockSlice{h1, p1, h2, p2, h3}}
+
+	testcases := []struct {
+		slug string
+		exp  ast.BlockSlice
+	}{
+		{"a", ast.BlockSlice{h1, p1, h2, p2}},
+		{"b", ast.BlockSlice{h2, p2}},
+		{"c", ast.BlockSlice{h3}},
+	}
+	for _, tc := range testcases {
+		got, ok := collect.Section(zn, tc.slug)
+		if !ok || len(got) != len(tc.exp) {
+			t.Errorf("Section %q: expected %v, but got %v (%v)", tc.slug, tc.exp, got, ok)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.exp[i] {
+				t.Errorf("Section %q: block %d differs", tc.slug, i)
+			}
+		}
+	}
+	if got, ok := collect.Section(zn, "d"); ok {
+		t.Errorf("Section %q should not be found, but got %v", "d", got)
+	}
+}
@@ -0,0 +1,36 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package collect provides functions to collect items from a syntax tree.
+package collect
+
+import (
+	"zettelstore.de/z/ast"
+)
+
+// Section returns the blocks of the section that starts with the top-level
+// heading with the given slug. The section includes the heading and ends
+// before the next heading of the same or a higher level.
+func Section(zn *ast.ZettelNode, slug string) (ast.BlockSlice, bool) {
+	for i, bn := range zn.Ast {
+		hn, ok := bn.(*ast.HeadingNode)
+		if !ok || hn.Slug != slug {
+			continue
+		}
+		end := i + 1
+		for ; end < len(zn.Ast); end++ {
+			if next, ok := zn.Ast[end].(*ast.HeadingNode); ok && next.Level <= hn.Level {
+				break
+			}
+		}
+		return zn.Ast[i:end], true
+	}
+	return nil, false
+}
@@ -0,0 +1,103 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package collect provides functions to collect items from a syntax tree.
+package collect
+
+import (
+	"sort"
+
+	"zettelstore.de/z/ast"
+)
+
+// TaskItem is a task of a list, together with the content of the list item.
+type TaskItem struct {
+	ast.Task
+	Item ast.ItemSlice
+}
+
+// Tasks returns all tasks of the given zettel, in the order of their
+// appearance.
+func Tasks(zn *ast.ZettelNode) []TaskItem {
+	tv := taskVisitor{}
+	ast.NewTopDownTraverser(&tv).VisitBlockSlice(zn.Ast)
+	sort.Slice(tv.tasks, func(i, j int) bool { return tv.tasks[i].Pos < tv.tasks[j].Pos })
+	return tv.tasks
+}
+
+type taskVisitor struct {
+	tasks []TaskItem
+}
+
+// VisitVerbatim does nothing.
+func (tv *taskVisitor) VisitVerbatim(vn *ast.VerbatimNode) {}
+
+// VisitRegion does nothing.
+func (tv *taskVisitor) VisitRegion(rn *ast.RegionNode) {}
+
+// VisitHeading does nothing.
+func (tv *taskVisitor) VisitHeading(hn *ast.HeadingNode) {}
+
+// VisitHRule does nothing.
+func (tv *taskVisitor) VisitHRule(hn *ast.HRuleNode) {}
+
+// VisitNestedList collects the task items of the list.
+func (tv *taskVisitor) VisitNestedList(ln *ast.NestedListNode) {
+	for i, item := range ln.Items {
+		if task := ln.Task(i); task.State != ast.TaskNone {
+			tv.tasks = append(tv.tasks, TaskItem{Task: task, Item: item})
+		}
+	}
+}
+
+// VisitDescriptionList does nothing.
+func (tv *taskVisitor) VisitDescriptionList(dn *ast.DescriptionListNode) {}
+
+// VisitPara does nothing.
+func (tv *taskVisitor) VisitPara(pn *ast.ParaNode) {}
+
+// VisitTable does nothing.
+func (tv *taskVisitor) VisitTable(tn *ast.TableNode) {}
+
+// VisitBLOB does nothing.
+func (tv *taskVisitor) VisitBLOB(bn *ast.BLOBNode) {}
+
+// VisitText does nothing.
+func (tv *taskVisitor) VisitText(tn *ast.TextNode) {}
+
+// VisitTag does nothing.
+func (tv *taskVisitor) VisitTag(tn *ast.TagNode) {}
+
+// VisitSpace does nothing.
+func (tv *taskVisitor) VisitSpace(sn *ast.SpaceNode) {}
+
+// VisitBreak does nothing.
+func (tv *taskVisitor) VisitBreak(bn *ast.BreakNode) {}
+
+// VisitLink does nothing.
+func (tv *taskVisitor) VisitLink(ln *ast.LinkNode) {}
+
+// VisitImage does nothing.
+func (tv *taskVisitor) VisitImage(in *ast.ImageNode) {}
+
+// VisitCite does nothing.
+func (tv *taskVisitor) VisitCite(cn *ast.CiteNode) {}
+
+// VisitFootnote does nothing.
+func (tv *taskVisitor) VisitFootnote(fn *ast.FootnoteNode) {}
+
+// VisitMark does nothing.
+func (tv *taskVisitor) VisitMark(mn *ast.MarkNode) {}
+
+// VisitFormat does nothing.
+func (tv *taskVisitor) VisitFormat(fn *ast.FormatNode) {}
+
+// VisitLiteral does nothing.
+func (tv *taskVisitor) VisitLiteral(ln *ast.LiteralNode) {}
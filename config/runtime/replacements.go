@@ -0,0 +1,57 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package runtime provides functions to retrieve runtime configuration data.
+package runtime
+
+import (
+	"strings"
+	"sync"
+
+	"zettelstore.de/z/domain/id"
+)
+
+var replacements struct {
+	mx      sync.Mutex
+	content string
+	repl    map[string]string
+}
+
+// GetReplacements returns the text replacements, as defined by the content
+// of the replacements zettel. It returns nil, if there is no runtime
+// configuration.
+func GetReplacements() map[string]string {
+	if configStock == nil {
+		return nil
+	}
+	content := configStock.GetZettel(id.ReplacementsZid).Content.AsString()
+	replacements.mx.Lock()
+	defer replacements.mx.Unlock()
+	if replacements.repl == nil || content != replacements.content {
+		replacements.content = content
+		replacements.repl = ParseReplacements(content)
+	}
+	return replacements.repl
+}
+
+// ParseReplacements parses the content of a replacements zettel. Each line
+// contains a text, some space, and the replacement of the text. Empty lines
+// and lines starting with "%" are ignored.
+func ParseReplacements(content string) map[string]string {
+	result := make(map[string]string)
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "%") {
+			continue
+		}
+		result[fields[0]] = fields[1]
+	}
+	return result
+}
@@ -14,6 +14,7 @@ package runtime
 import (
 	"strconv"
 
+	"zettelstore.de/z/config/startup"
 	"zettelstore.de/z/domain/id"
 	"zettelstore.de/z/domain/meta"
 	"zettelstore.de/z/place"
@@ -33,6 +34,15 @@ func SetupConfiguration(place place.Place) {
 	if err := configStock.Subscribe(id.ConfigurationZid); err != nil {
 		panic(err)
 	}
+	if err := configStock.Subscribe(id.ReplacementsZid); err != nil {
+		panic(err)
+	}
+	if err := configStock.Subscribe(id.PolicyRulesZid); err != nil {
+		panic(err)
+	}
+	if err := configStock.Subscribe(id.JobsZid); err != nil {
+		panic(err)
+	}
 }
 
 // getConfigurationMeta returns the meta data of the configuration zettel.
@@ -166,6 +176,15 @@ func GetYAMLHeader() bool {
 	return false
 }
 
+// GetSuggestions returns true, if anonymous visitors are allowed to suggest
+// changes of public zettel. This is never allowed in read-only mode.
+func GetSuggestions() bool {
+	if config := getConfigurationMeta(); config != nil {
+		return config.GetBool(meta.KeySuggestions) && !startup.IsReadOnlyMode()
+	}
+	return false
+}
+
 // GetZettelFileSyntax returns the current value of the "zettel-file-syntax" key.
 func GetZettelFileSyntax() []string {
 	if config := getConfigurationMeta(); config != nil {
@@ -174,6 +193,35 @@ func GetZettelFileSyntax() []string {
 	return nil
 }
 
+// GetListColumns returns the current value of the "list-columns" key. These
+// meta keys are shown as columns of a zettel list.
+func GetListColumns() []string {
+	if config := getConfigurationMeta(); config != nil {
+		return config.GetListOrNil(meta.KeyListColumns)
+	}
+	return nil
+}
+
+// GetStatusStates returns the current value of the "status-states" key. These
+// are the allowed values of the "status" key, in the order of the workflow.
+func GetStatusStates() []string {
+	if config := getConfigurationMeta(); config != nil {
+		if states := config.GetListOrNil(meta.KeyStatusStates); len(states) > 0 {
+			return states
+		}
+	}
+	return []string{"inbox", "processing", "done"}
+}
+
+// GetMIMETypes returns the current value of the "mime-types" key. Each value
+// has the form "syntax:mime/type".
+func GetMIMETypes() []string {
+	if config := getConfigurationMeta(); config != nil {
+		return config.GetListOrNil(meta.KeyMIMETypes)
+	}
+	return nil
+}
+
 // GetMarkerExternal returns the current value of the "marker-external" key.
 func GetMarkerExternal() string {
 	if config := getConfigurationMeta(); config != nil {
@@ -207,3 +255,21 @@ func GetListPageSize() int {
 	}
 	return 0
 }
+
+// GetPolicyRules returns the content of the policy rules zettel. It returns
+// an empty string, if there is no runtime configuration.
+func GetPolicyRules() string {
+	if configStock == nil {
+		return ""
+	}
+	return configStock.GetZettel(id.PolicyRulesZid).Content.AsString()
+}
+
+// GetJobs returns the content of the jobs zettel. It returns an empty
+// string, if there is no runtime configuration.
+func GetJobs() string {
+	if configStock == nil {
+		return ""
+	}
+	return configStock.GetZettel(id.JobsZid).Content.AsString()
+}
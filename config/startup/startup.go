@@ -12,14 +12,23 @@
 package startup
 
 import (
+	"fmt"
 	"hash/fnv"
 	"io"
+	"net"
+	"net/url"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
+	"zettelstore.de/z/diagram"
 	"zettelstore.de/z/domain/id"
 	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/extract"
 	"zettelstore.de/z/place"
+	"zettelstore.de/z/schedule"
+	"zettelstore.de/z/update"
 )
 
 var config struct {
@@ -27,7 +36,10 @@ var config struct {
 	verbose       bool
 	readonlyMode  bool
 	urlPrefix     string
+	baseURL       string
 	listenAddress string
+	publicAddress string
+	allowAttrs    map[string]bool
 	owner         id.Zid
 	withAuth      bool
 	secret        []byte
@@ -36,22 +48,80 @@ var config struct {
 	htmlLifetime  time.Duration
 	apiLifetime   time.Duration
 	manager       place.Manager
+	corsOrigins   []string
+	corsMethods   []string
+	trustProxy    bool
+	starterPack   bool
+	updateChecker *update.Checker
+	scheduler     *schedule.Scheduler
+	reqTimeout    time.Duration
+	maxReqBody    int64
+	maxZettelSize int64
+	archiveURL    string
+	auditFile     string
+	ocrCommand    string
+	extractor     *extract.Extractor
+	diagrams      *diagram.Renderer
+	quotaSize     int64
+	quotaZettel   int
+	proxies       []*net.IPNet
+	ipAllow       map[string][]*net.IPNet
+	ipDeny        map[string][]*net.IPNet
 }
 
 // Predefined keys for startup zettel
 const (
+	KeyAllowAttributes   = "allow-attributes"
+	KeyArchiveURL        = "archive-url"
+	KeyAuditFile         = "audit-file"
+	KeyBaseURL           = "base-url"
+	KeyCORSAllowMethods  = "cors-allow-methods"
+	KeyCORSAllowOrigins  = "cors-allow-origins"
+	KeyDotCommand        = "dot-command"
 	KeyInsecureCookie    = "insecure-cookie"
+	KeyIPAllowAdmin      = "ip-allow-admin"
+	KeyIPAllowAPI        = "ip-allow-api"
+	KeyIPAllowWebUI      = "ip-allow-webui"
+	KeyIPDenyAdmin       = "ip-deny-admin"
+	KeyIPDenyAPI         = "ip-deny-api"
+	KeyIPDenyWebUI       = "ip-deny-webui"
 	KeyListenAddress     = "listen-addr"
+	KeyMaxRequestBody    = "max-request-body"
+	KeyMaxZettelSize     = "max-zettel-size"
+	KeyMermaidCommand    = "mermaid-command"
+	KeyOCRCommand        = "ocr-command"
 	KeyOwner             = "owner"
 	KeyPersistentCookie  = "persistent-cookie"
 	KeyPlaceOneURI       = "place-1-uri"
+	KeyPublicListenAddr  = "public-listen-addr"
+	KeyQuotaSize         = "quota-size"
+	KeyQuotaZettel       = "quota-zettel"
 	KeyReadOnlyMode      = "read-only-mode"
+	KeyRequestTimeout    = "request-timeout"
+	KeyStarterPack       = "starter-pack"
 	KeyTokenLifetimeHTML = "token-lifetime-html"
 	KeyTokenLifetimeAPI  = "token-lifetime-api"
+	KeyTrustProxy        = "trust-proxy"
+	KeyTrustedProxies    = "trusted-proxies"
+	KeyUpdateCheckURL    = "update-check-url"
 	KeyURLPrefix         = "url-prefix"
 	KeyVerbose           = "verbose"
 )
 
+// Areas of routes, whose access can be restricted by IP addresses.
+const (
+	AreaAdmin = "admin"
+	AreaAPI   = "api"
+	AreaWebUI = "webui"
+)
+
+// ipRuleKeys maps an area to the keys of the allowed and the denied networks.
+var ipRuleKeys = map[string][2]string{
+	AreaAdmin: {KeyIPAllowAdmin, KeyIPDenyAdmin},
+	AreaAPI:   {KeyIPAllowAPI, KeyIPDenyAPI},
+	AreaWebUI: {KeyIPAllowWebUI, KeyIPDenyWebUI},
+}
+
 // SetupStartup initializes the startup data.
 func SetupStartup(cfg *meta.Meta, manager place.Manager, simple bool) error {
 	if config.urlPrefix != "" {
@@ -72,6 +142,18 @@ func SetupStartup(cfg *meta.Meta, manager place.Manager, simple bool) error {
 	} else {
 		config.listenAddress = "127.0.0.1:23123"
 	}
+	var err error
+	if config.baseURL, err = getBaseURL(cfg, config.listenAddress); err != nil {
+		return err
+	}
+	config.publicAddress = cfg.GetDefault(KeyPublicListenAddr, "")
+	config.allowAttrs = nil
+	if attrs := cfg.GetListOrNil(KeyAllowAttributes); len(attrs) > 0 {
+		config.allowAttrs = make(map[string]bool, len(attrs))
+		for _, attr := range attrs {
+			config.allowAttrs[strings.ToLower(attr)] = true
+		}
+	}
 	config.owner = id.Invalid
 	if owner, ok := cfg.Get(KeyOwner); ok {
 		if zid, err := id.Parse(owner); err == nil {
@@ -84,9 +166,58 @@ func SetupStartup(cfg *meta.Meta, manager place.Manager, simple bool) error {
 		config.persistCookie = cfg.GetBool(KeyPersistentCookie)
 		config.secret = calcSecret(cfg)
 		config.htmlLifetime = getDuration(
-			cfg, KeyTokenLifetimeHTML, 1*time.Hour, 1*time.Minute, 30*24*time.Hour)
+			cfg, KeyTokenLifetimeHTML, time.Minute, 1*time.Hour, 1*time.Minute, 30*24*time.Hour)
 		config.apiLifetime = getDuration(
-			cfg, KeyTokenLifetimeAPI, 10*time.Minute, 0, 1*time.Hour)
+			cfg, KeyTokenLifetimeAPI, time.Minute, 10*time.Minute, 0, 1*time.Hour)
+	}
+	config.corsOrigins = cfg.GetListOrNil(KeyCORSAllowOrigins)
+	config.corsMethods = cfg.GetListOrNil(KeyCORSAllowMethods)
+	config.trustProxy = cfg.GetBool(KeyTrustProxy)
+	if config.proxies, err = getNetworks(cfg, KeyTrustedProxies); err != nil {
+		return err
+	}
+	config.ipAllow = make(map[string][]*net.IPNet, len(ipRuleKeys))
+	config.ipDeny = make(map[string][]*net.IPNet, len(ipRuleKeys))
+	for area, keys := range ipRuleKeys {
+		if config.ipAllow[area], err = getNetworks(cfg, keys[0]); err != nil {
+			return err
+		}
+		if config.ipDeny[area], err = getNetworks(cfg, keys[1]); err != nil {
+			return err
+		}
+	}
+	if val, ok := cfg.Get(KeyStarterPack); ok {
+		config.starterPack = meta.BoolValue(val)
+	} else {
+		config.starterPack = simple
+	}
+	config.reqTimeout = getDuration(
+		cfg, KeyRequestTimeout, time.Second, 10*time.Second, time.Second, time.Hour)
+	config.maxReqBody = getSize(cfg, KeyMaxRequestBody, 32<<20)
+	config.maxZettelSize = getSize(cfg, KeyMaxZettelSize, 8<<20)
+	config.quotaSize = getSize(cfg, KeyQuotaSize, 0)
+	config.quotaZettel = int(getSize(cfg, KeyQuotaZettel, 0))
+	if url := cfg.GetDefault(KeyUpdateCheckURL, ""); url != "" {
+		config.updateChecker = update.New(url, version.Build)
+	}
+	config.scheduler = schedule.New()
+	config.archiveURL = cfg.GetDefault(KeyArchiveURL, "")
+	config.auditFile = cfg.GetDefault(KeyAuditFile, "")
+	config.ocrCommand = cfg.GetDefault(KeyOCRCommand, "")
+	config.extractor = extract.New()
+	config.extractor.Register("pdf", extract.PDFText)
+	if config.ocrCommand != "" {
+		ocr := extract.Command(config.ocrCommand)
+		for _, syntax := range extract.ImageSyntaxes {
+			config.extractor.Register(syntax, ocr)
+		}
+	}
+	config.diagrams = diagram.New()
+	if cmdline := cfg.GetDefault(KeyDotCommand, ""); cmdline != "" {
+		config.diagrams.Register("dot", extract.Command(cmdline))
+	}
+	if cmdline := cfg.GetDefault(KeyMermaidCommand, ""); cmdline != "" {
+		config.diagrams.Register("mermaid", extract.Command(cmdline))
 	}
 	config.simple = simple && !config.withAuth
 	config.manager = manager
@@ -107,11 +238,62 @@ func calcSecret(cfg *meta.Meta) []byte {
 	return h.Sum(nil)
 }
 
+// getBaseURL returns the scheme and the host of the service, as seen by its
+// clients. If it is not configured, the listen address is used.
+func getBaseURL(cfg *meta.Meta, listenAddress string) (string, error) {
+	if val, ok := cfg.Get(KeyBaseURL); ok {
+		u, err := url.Parse(val)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "", fmt.Errorf("invalid URL %q for key %q", val, KeyBaseURL)
+		}
+		return u.Scheme + "://" + u.Host, nil
+	}
+	host, port, err := net.SplitHostPort(listenAddress)
+	if err != nil {
+		return "http://" + listenAddress, nil
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	return "http://" + net.JoinHostPort(host, port), nil
+}
+
+// getNetworks returns the networks of the given key. A network is specified
+// in CIDR notation, like "192.168.0.0/16", or as a single IP address.
+func getNetworks(cfg *meta.Meta, key string) ([]*net.IPNet, error) {
+	specs := cfg.GetListOrNil(key)
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	result := make([]*net.IPNet, 0, len(specs))
+	for _, spec := range specs {
+		if !strings.Contains(spec, "/") {
+			ip := net.ParseIP(spec)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address %q for key %q", spec, key)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			result = append(result, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q for key %q", spec, key)
+		}
+		result = append(result, network)
+	}
+	return result, nil
+}
+
+// getDuration returns a duration, which is specified as a number of units.
 func getDuration(
-	cfg *meta.Meta, key string, defDur, minDur, maxDur time.Duration) time.Duration {
+	cfg *meta.Meta, key string, unit, defDur, minDur, maxDur time.Duration) time.Duration {
 	if s, ok := cfg.Get(key); ok && len(s) > 0 {
 		if d, err := strconv.ParseUint(s, 10, 64); err == nil {
-			secs := time.Duration(d) * time.Minute
+			secs := time.Duration(d) * unit
 			if secs < minDur {
 				return minDur
 			}
@@ -124,6 +306,41 @@ func getDuration(
 	return defDur
 }
 
+// getSize returns a number of bytes. The value may have a suffix "K", "M",
+// or "G" to specify kibibytes, mebibytes, or gibibytes. A value of zero
+// disables the limit.
+func getSize(cfg *meta.Meta, key string, defSize int64) int64 {
+	if n, ok := ParseSize(cfg.GetDefault(key, "")); ok {
+		return n
+	}
+	return defSize
+}
+
+// ParseSize parses a number of bytes, which may end with the letter "K",
+// "M", or "G" to specify kibibytes, mebibytes, or gibibytes.
+func ParseSize(s string) (int64, bool) {
+	if len(s) == 0 {
+		return 0, false
+	}
+	shift := uint(0)
+	switch s[len(s)-1] {
+	case 'k', 'K':
+		shift = 10
+	case 'm', 'M':
+		shift = 20
+	case 'g', 'G':
+		shift = 30
+	}
+	if shift > 0 {
+		s = s[:len(s)-1]
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n << shift, true
+}
+
 // IsSimple returns true if Zettelstore was not started with command "run"
 // and authentication is disabled.
 func IsSimple() bool { return config.simple }
@@ -134,14 +351,101 @@ func IsVerbose() bool { return config.verbose }
 // IsReadOnlyMode returns whether the system is in read-only mode or not.
 func IsReadOnlyMode() bool { return config.readonlyMode }
 
+// StarterPack returns true, if some tutorial zettel should be created when
+// the first place is empty. It defaults to true in simple mode.
+func StarterPack() bool { return config.starterPack }
+
+// RequestTimeout returns the maximum duration of a request.
+func RequestTimeout() time.Duration { return config.reqTimeout }
+
+// MaxRequestBody returns the maximum size of a request body in bytes. Zero
+// means no limit.
+func MaxRequestBody() int64 { return config.maxReqBody }
+
+// MaxZettelSize returns the maximum size of the content of a zettel in
+// bytes, when it is created or updated. Zero means no limit.
+func MaxZettelSize() int64 { return config.maxZettelSize }
+
+// QuotaSize returns the maximum total size of the content of all zettel
+// that a user may create, if the user zettel does not specify otherwise.
+// Zero means no limit.
+func QuotaSize() int64 { return config.quotaSize }
+
+// QuotaZettel returns the maximum number of zettel that a user may create, if
+// the user zettel does not specify otherwise. Zero means no limit.
+func QuotaZettel() int { return config.quotaZettel }
+
+// UpdateChecker returns the checker for newer releases. It is nil, if no
+// release feed was configured.
+func UpdateChecker() *update.Checker { return config.updateChecker }
+
+// ArchiveURL returns the URL that is prepended to an external link to submit
+// it to a web archive. It is empty, if links should not be archived.
+func ArchiveURL() string { return config.archiveURL }
+
+// AuditFile returns the path of the file that stores the audit trail. It is
+// empty, if the audit trail is kept in memory only.
+func AuditFile() string { return config.auditFile }
+
+// OCRCommand returns the command line of the program that extracts text
+// from images. It is empty, if no program was configured.
+func OCRCommand() string { return config.ocrCommand }
+
+// TextExtractor returns the extractor of searchable text from binary zettel.
+func TextExtractor() *extract.Extractor { return config.extractor }
+
+// Diagrams returns the renderer of diagrams.
+func Diagrams() *diagram.Renderer { return config.diagrams }
+
+// Scheduler returns the scheduler of maintenance jobs.
+func Scheduler() *schedule.Scheduler { return config.scheduler }
+
 // URLPrefix returns the configured prefix to be used when providing URL to
 // the service.
 func URLPrefix() string { return config.urlPrefix }
 
+// BaseURL returns the absolute URL of the service, as seen by its clients. It
+// ends with the URL prefix.
+func BaseURL() string { return config.baseURL + config.urlPrefix }
+
+// TrustProxy returns true, if the headers X-Forwarded-Proto, X-Forwarded-Host,
+// and X-Forwarded-Prefix of a reverse proxy should be used to build URLs.
+func TrustProxy() bool { return config.trustProxy }
+
+// TrustedProxies returns the networks of the reverse proxies, whose headers
+// are used. If empty and TrustProxy returns true, only the reverse proxy that
+// sent the request is trusted.
+func TrustedProxies() []*net.IPNet { return config.proxies }
+
+// IPRule returns the networks that are allowed and denied to access the routes
+// of the given area. If the list of allowed networks is empty, all networks
+// that are not denied may access the routes.
+func IPRule(area string) (allow, deny []*net.IPNet) {
+	return config.ipAllow[area], config.ipDeny[area]
+}
+
 // ListenAddress returns the string that specifies the the network card and the ip port
 // where the server listens for requests
 func ListenAddress() string { return config.listenAddress }
 
+// PublicListenAddress returns the address of a second listener that serves
+// only public zettel. If empty, there is no such listener.
+func PublicListenAddress() string { return config.publicAddress }
+
+// AllowAttribute returns true, if an attribute with the given key was
+// explicitly allowed to be written to encoded output.
+func AllowAttribute(key string) bool { return config.allowAttrs[key] }
+
+// AllowAttributes returns the sorted list of all explicitly allowed attributes.
+func AllowAttributes() []string {
+	result := make([]string, 0, len(config.allowAttrs))
+	for key := range config.allowAttrs {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
+
 // WithAuth returns true if user authentication is enabled.
 func WithAuth() bool { return config.withAuth }
 
@@ -172,3 +476,11 @@ func TokenLifetime() (htmlLifetime, apiLifetime time.Duration) {
 
 // PlaceManager returns the managing place.
 func PlaceManager() place.Manager { return config.manager }
+
+// CORSAllowOrigins returns the origins that are allowed to access the web
+// service via cross-origin resource sharing. The value "*" allows all origins.
+func CORSAllowOrigins() []string { return config.corsOrigins }
+
+// CORSAllowMethods returns the HTTP methods that may be used via cross-origin
+// resource sharing. If empty, all methods of a route are allowed.
+func CORSAllowMethods() []string { return config.corsMethods }
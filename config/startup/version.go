@@ -20,6 +20,8 @@ import (
 type Version struct {
 	Prog      string // Name of the software
 	Build     string // Representation of build process
+	Commit    string // Identifier of the source code commit
+	Date      string // Date of the build process
 	Hostname  string // Host name a reported by the kernel
 	GoVersion string // Version of go
 	Os        string // GOOS
@@ -30,13 +32,11 @@ type Version struct {
 var version Version
 
 // SetupVersion initializes the version data.
-func SetupVersion(progName, buildVersion string) {
+func SetupVersion(progName, buildVersion, buildCommit, buildDate string) {
 	version.Prog = progName
-	if buildVersion == "" {
-		version.Build = "unknown"
-	} else {
-		version.Build = buildVersion
-	}
+	version.Build = valueOrUnknown(buildVersion)
+	version.Commit = valueOrUnknown(buildCommit)
+	version.Date = valueOrUnknown(buildDate)
 	if hn, err := os.Hostname(); err == nil {
 		version.Hostname = hn
 	} else {
@@ -47,5 +47,12 @@ func SetupVersion(progName, buildVersion string) {
 	version.Arch = runtime.GOARCH
 }
 
+func valueOrUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
+
 // GetVersion returns the current software version data.
 func GetVersion() Version { return version }
@@ -0,0 +1,130 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package diagram renders the source of diagrams, e.g. Graphviz or Mermaid,
+// as SVG.
+//
+// A diagram is rendered by a function that is registered for its language,
+// typically an external program. Rendered diagrams are cached in memory as
+// derived content, so that a program runs only once for the same source.
+package diagram
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"zettelstore.de/z/extract"
+)
+
+// aliases maps alternative names of a language to its name.
+var aliases = map[string]string{
+	"dot":      "dot",
+	"graphviz": "dot",
+	"mermaid":  "mermaid",
+}
+
+// Language returns the name of the diagram language of the given code
+// language, or false if it is not a diagram.
+func Language(lang string) (string, bool) {
+	name, ok := aliases[strings.ToLower(lang)]
+	return name, ok
+}
+
+// maxCached is the maximum number of rendered diagrams in the cache.
+const maxCached = 256
+
+type result struct {
+	svg string
+	err error
+}
+
+// Renderer renders diagrams and caches the results.
+type Renderer struct {
+	funcs map[string]extract.Func
+
+	mx    sync.Mutex // protects all following fields
+	cache map[[sha256.Size]byte]result
+	order [][sha256.Size]byte // keys of the cache, oldest first
+}
+
+// New creates a new renderer without any rendering functions.
+func New() *Renderer {
+	return &Renderer{
+		funcs: make(map[string]extract.Func),
+		cache: make(map[[sha256.Size]byte]result),
+	}
+}
+
+// Register sets the function that renders diagrams of the given language.
+// The function must return SVG. It must be called before diagrams are
+// rendered.
+func (r *Renderer) Register(lang string, f extract.Func) { r.funcs[lang] = f }
+
+// Handles returns true, if diagrams of the given language can be rendered.
+func (r *Renderer) Handles(lang string) bool {
+	_, ok := r.funcs[lang]
+	return ok
+}
+
+// SVG returns the rendered diagram of the given language. Errors of the
+// rendering program are cached too, so that a failing program does not run on
+// every request. Other errors, e.g. a canceled request, are not cached.
+func (r *Renderer) SVG(ctx context.Context, lang, src string) (string, error) {
+	f, ok := r.funcs[lang]
+	if !ok {
+		return "", nil
+	}
+	key := sha256.Sum256([]byte(lang + "\n" + src))
+	r.mx.Lock()
+	res, ok := r.cache[key]
+	r.mx.Unlock()
+	if ok {
+		return res.svg, res.err
+	}
+
+	svg, err := f(ctx, []byte(src))
+	if err == nil {
+		svg = stripProlog(svg)
+	} else if ctx.Err() != nil || !isRenderError(err) {
+		return "", err
+	}
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	if _, ok = r.cache[key]; !ok {
+		if len(r.order) >= maxCached {
+			delete(r.cache, r.order[0])
+			r.order = r.order[1:]
+		}
+		r.cache[key] = result{svg, err}
+		r.order = append(r.order, key)
+	}
+	return svg, err
+}
+
+// isRenderError returns true, if the error was reported by the rendering
+// program, e.g. because of a syntax error in the diagram.
+func isRenderError(err error) bool {
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr)
+}
+
+// stripProlog removes everything before the svg element, e.g. the XML
+// declaration and the document type, so that the SVG can be embedded into
+// HTML.
+func stripProlog(svg string) string {
+	if pos := strings.Index(svg, "<svg"); pos > 0 {
+		return svg[pos:]
+	}
+	return svg
+}
@@ -0,0 +1,66 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package diagram renders the source of diagrams, e.g. Graphviz or Mermaid,
+// as SVG.
+package diagram
+
+import (
+	"context"
+	"testing"
+
+	"zettelstore.de/z/extract"
+)
+
+func TestRenderer(t *testing.T) {
+	calls := 0
+	r := New()
+	r.Register("dot", func(ctx context.Context, content []byte) (string, error) {
+		calls++
+		return "<?xml version=\"1.0\"?>\n<svg>" + string(content) + "</svg>", nil
+	})
+	if r.Handles("mermaid") {
+		t.Error("Mermaid must not be handled")
+	}
+	for i := 0; i < 2; i++ {
+		svg, err := r.SVG(context.Background(), "dot", "a->b")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp := "<svg>a->b</svg>"; svg != exp {
+			t.Errorf("Expected %q, but got %q", exp, svg)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Diagram should be rendered once, but was rendered %d times", calls)
+	}
+	if lang, ok := Language("Graphviz"); !ok || lang != "dot" {
+		t.Errorf("Graphviz should be dot, but got %q/%v", lang, ok)
+	}
+}
+
+func TestRendererErrors(t *testing.T) {
+	r := New()
+	r.Register("dot", extract.Command("false"))
+	r.Register("mermaid", extract.Command("sleep 1"))
+	for i := 0; i < 2; i++ {
+		if _, err := r.SVG(context.Background(), "dot", "a->b"); err == nil {
+			t.Error("Expected an error of the program")
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := r.SVG(ctx, "mermaid", "a->b"); err != context.Canceled {
+		t.Errorf("Expected a canceled context, but got %v", err)
+	}
+	if len(r.cache) != 1 {
+		t.Errorf("Only the error of the program should be cached, but got %d entries", len(r.cache))
+	}
+}
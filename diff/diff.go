@@ -0,0 +1,213 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package diff calculates the differences between two texts.
+package diff
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Op specifies how a piece of text was changed.
+type Op int
+
+// Constants for Op
+const (
+	Equal  Op = iota // Text is contained in both texts
+	Delete           // Text is only contained in the first text
+	Insert           // Text is only contained in the second text
+)
+
+// Edit is a piece of text together with its change operation.
+type Edit struct {
+	Op   Op
+	Text string
+}
+
+// Lines calculates the line-based differences between the two texts. Each
+// edit contains exactly one line, without the line ending.
+func Lines(a, b string) []Edit {
+	return diffTokens(splitLines(a), splitLines(b))
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// Words calculates the word-based differences between the two texts.
+// Consecutive edits with the same operation are merged.
+func Words(a, b string) []Edit {
+	edits := diffTokens(splitWords(a), splitWords(b))
+	if len(edits) == 0 {
+		return nil
+	}
+	result := edits[:1]
+	for _, e := range edits[1:] {
+		if last := &result[len(result)-1]; last.Op == e.Op {
+			last.Text += e.Text
+		} else {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// splitWords splits a text into words, sequences of white space, and single
+// other characters.
+func splitWords(s string) []string {
+	var result []string
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		pos := size
+		switch {
+		case isWordRune(r):
+			pos = scanWhile(s, pos, isWordRune)
+		case unicode.IsSpace(r):
+			pos = scanWhile(s, pos, unicode.IsSpace)
+		}
+		result = append(result, s[:pos])
+		s = s[pos:]
+	}
+	return result
+}
+
+func isWordRune(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+
+func scanWhile(s string, pos int, pred func(rune) bool) int {
+	for pos < len(s) {
+		r, size := utf8.DecodeRuneInString(s[pos:])
+		if !pred(r) {
+			break
+		}
+		pos += size
+	}
+	return pos
+}
+
+// maxDistance is the maximum number of inserted and deleted tokens that are
+// searched for a shortest edit script. Time and memory of the algorithm grow
+// with this number, so it must be limited for arbitrary input.
+const maxDistance = 1000
+
+// diffTokens calculates an edit script. Tokens that both sequences have in
+// common at their start and at their end are equal. For the tokens in
+// between, a shortest edit script is searched. If there is none with at most
+// maxDistance edits, all these tokens of a are deleted and those of b are
+// inserted.
+func diffTokens(a, b []string) []Edit {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-suf-1] == b[len(b)-suf-1] {
+		suf++
+	}
+	var result []Edit
+	for _, tok := range a[:pre] {
+		result = append(result, Edit{Equal, tok})
+	}
+	ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+	if edits, ok := shortestEdits(ma, mb); ok {
+		result = append(result, edits...)
+	} else {
+		for _, tok := range ma {
+			result = append(result, Edit{Delete, tok})
+		}
+		for _, tok := range mb {
+			result = append(result, Edit{Insert, tok})
+		}
+	}
+	for _, tok := range a[len(a)-suf:] {
+		result = append(result, Edit{Equal, tok})
+	}
+	return result
+}
+
+// shortestEdits calculates a shortest edit script with the algorithm of
+// Eugene W. Myers, "An O(ND) Difference Algorithm and Its Variations". It
+// returns false, if the script would contain more than maxDistance edits.
+func shortestEdits(a, b []string) ([]Edit, bool) {
+	n, m := len(a), len(b)
+	max := n + m
+	if max == 0 {
+		return nil, true
+	}
+	if max > maxDistance {
+		max = maxDistance
+	}
+	off := max + 1
+	v := make([]int, 2*max+3)
+
+	// trace[d] stores the relevant part of v before step d.
+	var trace [][]int
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v[off-d-1:off+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b), true
+			}
+		}
+	}
+	return nil, false
+}
+
+func backtrack(trace [][]int, a, b []string) []Edit {
+	x, y := len(a), len(b)
+	var result []Edit
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		get := func(k int) int { return v[k+d+1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && get(k-1) < get(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := get(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			result = append(result, Edit{Equal, a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				result = append(result, Edit{Insert, b[y-1]})
+			} else {
+				result = append(result, Edit{Delete, a[x-1]})
+			}
+			x, y = prevX, prevY
+		}
+	}
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return result
+}
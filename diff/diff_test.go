@@ -0,0 +1,97 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package diff_test provides some tests for calculating differences.
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"zettelstore.de/z/diff"
+)
+
+func editString(edits []diff.Edit) string {
+	var sb strings.Builder
+	for _, e := range edits {
+		switch e.Op {
+		case diff.Equal:
+			sb.WriteByte('=')
+		case diff.Delete:
+			sb.WriteByte('-')
+		case diff.Insert:
+			sb.WriteByte('+')
+		}
+		sb.WriteString(e.Text)
+		sb.WriteByte('|')
+	}
+	return sb.String()
+}
+
+func TestLines(t *testing.T) {
+	testcases := []struct {
+		a, b string
+		exp  string
+	}{
+		{"", "", ""},
+		{"a", "", "-a|"},
+		{"", "a\n", "+a|"},
+		{"a\nb\nc", "a\nb\nc\n", "=a|=b|=c|"},
+		{"a\nb\nc", "a\nc", "=a|-b|=c|"},
+		{"a\nc", "a\nb\nc", "=a|+b|=c|"},
+		{"a\nb\nc", "a\nx\nc", "=a|-b|+x|=c|"},
+		{"a\r\nb", "a\nb", "=a|=b|"},
+	}
+	for i, tc := range testcases {
+		if got := editString(diff.Lines(tc.a, tc.b)); got != tc.exp {
+			t.Errorf("%d: %q/%q: exp=%q, got=%q", i, tc.a, tc.b, tc.exp, got)
+		}
+	}
+}
+
+func TestWords(t *testing.T) {
+	testcases := []struct {
+		a, b string
+		exp  string
+	}{
+		{"", "", ""},
+		{"the quick fox", "the quick fox", "=the quick fox|"},
+		{"the quick fox", "the slow fox", "=the |-quick|+slow|= fox|"},
+		{"the fox", "the brown fox", "=the |+brown |=fox|"},
+		{"a, b", "a; b", "=a|-,|+;|= b|"},
+	}
+	for i, tc := range testcases {
+		if got := editString(diff.Words(tc.a, tc.b)); got != tc.exp {
+			t.Errorf("%d: %q/%q: exp=%q, got=%q", i, tc.a, tc.b, tc.exp, got)
+		}
+	}
+}
+
+func TestLargeDistance(t *testing.T) {
+	var sa, sb strings.Builder
+	for i := 0; i < 2000; i++ {
+		sa.WriteString("a\n")
+		sb.WriteString("b\n")
+	}
+	edits := diff.Lines("x\n"+sa.String()+"y", "x\n"+sb.String()+"y")
+	if len(edits) != 4002 {
+		t.Fatalf("expected 4002 edits, but got %d", len(edits))
+	}
+	first, last := edits[0], edits[len(edits)-1]
+	if first.Op != diff.Equal || first.Text != "x" || last.Op != diff.Equal || last.Text != "y" {
+		t.Errorf("unexpected first/last edit: %v/%v", first, last)
+	}
+	if e := edits[1]; e.Op != diff.Delete || e.Text != "a" {
+		t.Errorf("expected deletion, but got %v", e)
+	}
+	if e := edits[2001]; e.Op != diff.Insert || e.Text != "b" {
+		t.Errorf("expected insertion, but got %v", e)
+	}
+}
@@ -0,0 +1,69 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package id provides domain specific types, constants, and functions about
+// zettel identifier.
+package id
+
+import (
+	"sync"
+	"time"
+)
+
+const zidLayout = "20060102150405"
+
+// Allocator creates new zettel identifiers without collisions, even if many
+// zettel are created within the same second.
+type Allocator struct {
+	mx   sync.Mutex // protects last
+	last time.Time  // time of the last identifier with seconds
+	now  func() time.Time
+}
+
+// NewAllocator creates a new allocator.
+func NewAllocator() *Allocator {
+	return &Allocator{now: time.Now}
+}
+
+// New returns a new zettel identifier for which used returns false. If
+// withSeconds is false, an identifier with zero seconds is preferred.
+// Otherwise the identifier is based on the current time. If it is already
+// used, or if it was returned previously, the next free second is taken.
+// New never waits for the clock to advance.
+func (a *Allocator) New(withSeconds bool, used func(Zid) bool) Zid {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+	now := a.now().Truncate(time.Second)
+	if !withSeconds {
+		if zid := timeToZid(now.Truncate(time.Minute)); !used(zid) {
+			return zid
+		}
+	}
+	t := now
+	if !t.After(a.last) {
+		t = a.last.Add(time.Second)
+	}
+	for {
+		zid := timeToZid(t)
+		if !used(zid) {
+			a.last = t
+			return zid
+		}
+		t = t.Add(time.Second)
+	}
+}
+
+func timeToZid(t time.Time) Zid {
+	zid, err := Parse(t.Format(zidLayout))
+	if err != nil {
+		panic(err)
+	}
+	return zid
+}
@@ -0,0 +1,65 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package id provides domain specific types, constants, and functions about
+// zettel identifier.
+package id
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAllocator(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 34, 59, 0, time.Local)
+	a := NewAllocator()
+	a.now = func() time.Time { return now }
+	used := map[Zid]bool{}
+	isUsed := func(zid Zid) bool { return used[zid] }
+
+	exp := []Zid{20210301123400, 20210301123459, 20210301123500, 20210301123501}
+	for i, e := range exp {
+		got := a.New(i%2 == 1, isUsed)
+		if got != e {
+			t.Errorf("%d: expected %v, but got %v", i, e, got)
+		}
+		used[got] = true
+	}
+	used[20210301123502] = true
+	if got := a.New(true, isUsed); got != 20210301123503 {
+		t.Errorf("used zid not skipped, got %v", got)
+	}
+}
+
+func TestAllocatorConcurrent(t *testing.T) {
+	a := NewAllocator()
+	never := func(Zid) bool { return false }
+
+	const n = 50
+	zids := make(chan Zid, n)
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			zids <- a.New(true, never)
+		}()
+	}
+	wg.Wait()
+	close(zids)
+	seen := make(map[Zid]bool, n)
+	for zid := range zids {
+		if seen[zid] {
+			t.Errorf("zid %v allocated twice", zid)
+		}
+		seen[zid] = true
+	}
+}
@@ -25,19 +25,43 @@ type Zid uint64
 
 // Some important ZettelIDs
 const (
-	Invalid           = Zid(0) // Invalid is a Zid that will never be valid
-	ConfigurationZid  = Zid(100)
-	BaseTemplateZid   = Zid(10100)
-	LoginTemplateZid  = Zid(10200)
-	ListTemplateZid   = Zid(10300)
-	DetailTemplateZid = Zid(10401)
-	InfoTemplateZid   = Zid(10402)
-	FormTemplateZid   = Zid(10403)
-	RenameTemplateZid = Zid(10404)
-	DeleteTemplateZid = Zid(10405)
-	RolesTemplateZid  = Zid(10500)
-	TagsTemplateZid   = Zid(10600)
-	BaseCSSZid        = Zid(20001)
+	Invalid               = Zid(0) // Invalid is a Zid that will never be valid
+	ConfigurationZid      = Zid(100)
+	BaseTemplateZid       = Zid(10100)
+	LoginTemplateZid      = Zid(10200)
+	ListTemplateZid       = Zid(10300)
+	DetailTemplateZid     = Zid(10401)
+	InfoTemplateZid       = Zid(10402)
+	FormTemplateZid       = Zid(10403)
+	RenameTemplateZid     = Zid(10404)
+	DeleteTemplateZid     = Zid(10405)
+	DiffTemplateZid       = Zid(10406)
+	UndoTemplateZid       = Zid(10407)
+	PolicyTemplateZid     = Zid(10408)
+	SuggestTemplateZid    = Zid(10409)
+	RolesTemplateZid      = Zid(10500)
+	TagsTemplateZid       = Zid(10600)
+	TasksTemplateZid      = Zid(10700)
+	HierarchyTemplateZid  = Zid(10800)
+	CustomizeTemplateZid  = Zid(10900)
+	DuplicatesTemplateZid = Zid(11000)
+	CitationsTemplateZid  = Zid(11100)
+	ErrorTemplateZid      = Zid(11200)
+	RoutesTemplateZid     = Zid(11300)
+	ForbiddenTemplateZid  = Zid(11403)
+	NotFoundTemplateZid   = Zid(11404)
+	InternalTemplateZid   = Zid(11500)
+	ReviewTemplateZid     = Zid(11600)
+	StudyTemplateZid      = Zid(11700)
+	BaseCSSZid            = Zid(20001)
+	ManifestZid           = Zid(20002)
+	ServiceWorkerZid      = Zid(20003)
+	AppScriptZid          = Zid(20004)
+	AppIconZid            = Zid(20005)
+	ReplacementsZid       = Zid(30001)
+	MenuZid               = Zid(30002)
+	PolicyRulesZid        = Zid(30003)
+	JobsZid               = Zid(30004)
 
 	// Range 90000...99999 is reserved for zettel templates
 	TemplateNewZettelZid = Zid(91001)
@@ -89,15 +113,8 @@ func (zid Zid) IsValid() bool { return 0 < zid && zid <= maxZid }
 // New returns a new zettel id based on the current time.
 func New(withSeconds bool) Zid {
 	now := time.Now()
-	var s string
-	if withSeconds {
-		s = now.Format("20060102150405")
-	} else {
-		s = now.Format("20060102150400")
+	if !withSeconds {
+		now = now.Truncate(time.Minute)
 	}
-	res, err := Parse(s)
-	if err != nil {
-		panic(err)
-	}
-	return res
+	return timeToZid(now)
 }
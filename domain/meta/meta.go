@@ -80,29 +80,56 @@ var (
 	KeyRole              = registerKey("role", TypeWord, usageUser)
 	KeyTags              = registerKey("tags", TypeTagSet, usageUser)
 	KeySyntax            = registerKey("syntax", TypeWord, usageUser)
+	KeyAbstract          = registerKey("abstract", TypeString, usageProperty)
+	KeyArchive           = registerKey("archive", TypeWordSet, usageUser)
+	KeyAuthor            = registerKey("author", TypeString, usageUser)
+	KeyCiteKey           = registerKey("cite-key", TypeWord, usageUser)
+	KeyContentHash       = registerKey("content-hash", TypeWord, usageProperty)
 	KeyCopyright         = registerKey("copyright", TypeString, usageUser)
+	KeyCreator           = registerKey("creator", TypeID, usageComputed)
 	KeyCredential        = registerKey("credential", TypeCredential, usageUser)
+	KeyCSSZettel         = registerKey("css-zettel", TypeID, usageUser)
 	KeyDefaultCopyright  = registerKey("default-copyright", TypeString, usageUser)
 	KeyDefaultLang       = registerKey("default-lang", TypeWord, usageUser)
 	KeyDefaultLicense    = registerKey("default-license", TypeEmpty, usageUser)
 	KeyDefaultRole       = registerKey("default-role", TypeWord, usageUser)
 	KeyDefaultSyntax     = registerKey("default-syntax", TypeWord, usageUser)
 	KeyDefaultTitle      = registerKey("default-title", TypeZettelmarkup, usageUser)
+	KeyDeadLinks         = registerKey("dead-links", TypeWordSet, usageProperty)
 	KeyDefaultVisibility = registerKey("default-visibility", TypeWord, usageUser)
 	KeyDuplicates        = registerKey("duplicates", TypeBool, usageUser)
+	KeyExtractedText     = registerKey("extracted-text", TypeString, usageProperty)
 	KeyExpertMode        = registerKey("expert-mode", TypeBool, usageUser)
+	KeyFavorites         = registerKey("favorites", TypeIDSet, usageUser)
 	KeyFooterHTML        = registerKey("footer-html", TypeString, usageUser)
+	KeyGlossary          = registerKey("glossary", TypeWord, usageUser)
+	KeyJSZettel          = registerKey("js-zettel", TypeID, usageUser)
 	KeyLang              = registerKey("lang", TypeWord, usageUser)
 	KeyLicense           = registerKey("license", TypeEmpty, usageUser)
+	KeyListColumns       = registerKey("list-columns", TypeWordSet, usageUser)
 	KeyListPageSize      = registerKey("list-page-size", TypeNumber, usageUser)
 	KeyNewRole           = registerKey("new-role", TypeWord, usageUser)
 	KeyMarkerExternal    = registerKey("marker-external", TypeEmpty, usageUser)
+	KeyMIMETypes         = registerKey("mime-types", TypeWordSet, usageUser)
 	KeyModified          = registerKey("modified", TypeTimestamp, usageComputed)
+	KeyNumbering         = registerKey("numbering", TypeBool, usageUser)
+	KeyPinned            = registerKey("pinned", TypeBool, usageUser)
 	KeyPrecursor         = registerKey("precursor", TypeIDSet, usageUser)
 	KeyPublished         = registerKey("published", TypeTimestamp, usageProperty)
+	KeyQuotaSize         = registerKey("quota-size", TypeWord, usageUser)
+	KeyQuotaZettel       = registerKey("quota-zettel", TypeNumber, usageUser)
+	KeyReadLater         = registerKey("read-later", TypeIDSet, usageUser)
 	KeyReadOnly          = registerKey("read-only", TypeWord, usageUser)
+	KeyReviewDue         = registerKey("review-due", TypeTimestamp, usageUser)
+	KeyReviewInterval    = registerKey("review-interval", TypeNumber, usageUser)
 	KeySiteName          = registerKey("site-name", TypeString, usageUser)
 	KeyStart             = registerKey("start", TypeID, usageUser)
+	KeyStatus            = registerKey("status", TypeWord, usageUser)
+	KeyStatusStates      = registerKey("status-states", TypeWordSet, usageUser)
+	KeySuggestionFor     = registerKey("suggestion-for", TypeID, usageUser)
+	KeySuggestionNote    = registerKey("suggestion-note", TypeString, usageUser)
+	KeySuggestions       = registerKey("suggestions", TypeBool, usageUser)
+	KeyUp                = registerKey("up", TypeID, usageUser)
 	KeyURL               = registerKey("url", TypeURL, usageUser)
 	KeyUserID            = registerKey("user-id", TypeWord, usageUser)
 	KeyUserRole          = registerKey("user-role", TypeWord, usageUser)
@@ -113,14 +140,23 @@ var (
 
 // Important values for some keys.
 const (
+	ValueRoleCard          = "card"
+	ValueRoleComment       = "comment"
 	ValueRoleConfiguration = "configuration"
+	ValueRoleGlossary      = "glossary"
+	ValueRoleLiterature    = "literature"
 	ValueRoleUser          = "user"
 	ValueRoleNewTemplate   = "new-template"
+	ValueRoleSuggestion    = "suggestion"
+	ValueRoleTag           = "tag"
 	ValueRoleZettel        = "zettel"
+	ValueSyntaxMustache    = "mustache"
 	ValueSyntaxNone        = "none"
 	ValueSyntaxZmk         = "zmk"
 	ValueTrue              = "true"
 	ValueFalse             = "false"
+	ValueGlossaryAbbr      = "abbr"
+	ValueGlossarySection   = "section"
 	ValueUserRoleReader    = "reader"
 	ValueUserRoleWriter    = "writer"
 	ValueUserRoleOwner     = "owner"
@@ -0,0 +1,181 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package duplicate finds texts with nearly the same content.
+//
+// Every text is split into overlapping sequences of words, called shingles.
+// Two texts are similar, if they share most of their shingles. To avoid
+// comparing all pairs of texts, only texts with a similar MinHash signature
+// are compared.
+package duplicate
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"unicode"
+
+	"zettelstore.de/z/domain/id"
+)
+
+// Some constants to tune the detection.
+const (
+	shingleSize = 3  // number of words of a shingle
+	numHashes   = 64 // length of a MinHash signature
+	rowsPerBand = 4  // signature values that must be equal to compare texts
+)
+
+// seeds are used to derive numHashes hash functions from one hash value.
+var seeds = func() []uint64 {
+	result := make([]uint64, numHashes)
+	for i := range result {
+		result[i] = mix(uint64(i) + 1)
+	}
+	return result
+}()
+
+// mix scrambles the bits of a value (splitmix64 finalizer).
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+type shingleSet map[uint64]bool
+
+// Cluster returns groups of texts, where each text has a Jaccard similarity of
+// its shingles of at least threshold with another text of the group. Texts
+// without a similar text are not returned. Identifiers within a group are
+// sorted ascending, groups are sorted by descending first identifier.
+func Cluster(texts map[id.Zid]string, threshold float64) [][]id.Zid {
+	sets := make(map[id.Zid]shingleSet, len(texts))
+	buckets := make(map[string][]id.Zid)
+	for zid, text := range texts {
+		set := shingles(text)
+		if len(set) == 0 {
+			continue
+		}
+		sets[zid] = set
+		for _, key := range bandKeys(signature(set)) {
+			buckets[key] = append(buckets[key], zid)
+		}
+	}
+
+	parent := make(map[id.Zid]id.Zid)
+	var find func(id.Zid) id.Zid
+	find = func(zid id.Zid) id.Zid {
+		p, ok := parent[zid]
+		if !ok || p == zid {
+			return zid
+		}
+		root := find(p)
+		parent[zid] = root
+		return root
+	}
+	for _, zids := range buckets {
+		for i, zid1 := range zids {
+			for _, zid2 := range zids[i+1:] {
+				r1, r2 := find(zid1), find(zid2)
+				if r1 != r2 && jaccard(sets[zid1], sets[zid2]) >= threshold {
+					parent[r1] = r2
+				}
+			}
+		}
+	}
+
+	groups := make(map[id.Zid][]id.Zid)
+	for zid := range parent {
+		root := find(zid)
+		groups[root] = append(groups[root], zid)
+	}
+	result := make([][]id.Zid, 0, len(groups))
+	for root, zids := range groups {
+		if _, ok := parent[root]; !ok {
+			zids = append(zids, root)
+		}
+		sort.Slice(zids, func(i, j int) bool { return zids[i] < zids[j] })
+		result = append(result, zids)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i][0] > result[j][0] })
+	return result
+}
+
+// shingles returns the hash values of all word sequences of the text.
+func shingles(text string) shingleSet {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return nil
+	}
+	n := len(words) - shingleSize + 1
+	if n < 1 {
+		n = 1
+	}
+	result := make(shingleSet, n)
+	for i := 0; i < n; i++ {
+		end := i + shingleSize
+		if end > len(words) {
+			end = len(words)
+		}
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words[i:end], " ")))
+		result[h.Sum64()] = true
+	}
+	return result
+}
+
+// signature returns the MinHash signature of a shingle set.
+func signature(set shingleSet) []uint64 {
+	sig := make([]uint64, numHashes)
+	for i := range sig {
+		sig[i] = ^uint64(0)
+	}
+	for sh := range set {
+		for i, seed := range seeds {
+			if v := mix(sh ^ seed); v < sig[i] {
+				sig[i] = v
+			}
+		}
+	}
+	return sig
+}
+
+// bandKeys splits the signature into bands. Texts with an equal band key
+// are candidates for being similar.
+func bandKeys(sig []uint64) []string {
+	result := make([]string, 0, len(sig)/rowsPerBand)
+	buf := make([]byte, 8*(rowsPerBand+1))
+	for band := 0; band < len(sig)/rowsPerBand; band++ {
+		binary.LittleEndian.PutUint64(buf, uint64(band))
+		for row := 0; row < rowsPerBand; row++ {
+			binary.LittleEndian.PutUint64(buf[8*(row+1):], sig[band*rowsPerBand+row])
+		}
+		result = append(result, string(buf))
+	}
+	return result
+}
+
+func jaccard(s1, s2 shingleSet) float64 {
+	if len(s1) > len(s2) {
+		s1, s2 = s2, s1
+	}
+	common := 0
+	for sh := range s1 {
+		if s2[sh] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(s1)+len(s2)-common)
+}
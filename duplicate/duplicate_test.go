@@ -0,0 +1,44 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package duplicate_test provides some tests for duplicate detection.
+package duplicate_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/duplicate"
+)
+
+func TestCluster(t *testing.T) {
+	text := "A zettel contains one idea, which is linked to other ideas. " +
+		"Over time, the links form a network of knowledge, which grows with every " +
+		"new zettel. To find a zettel again, it has a title, some tags, and a role. " +
+		"But the links are the most important part of a zettel."
+	texts := map[id.Zid]string{
+		1: text,
+		2: strings.Replace(text, "most important", "MOST IMPORTANT", 1),
+		3: strings.Replace(text, "important", "useful", 1),
+		4: "Something completely different, without any relation to the other texts.",
+		5: "",
+		6: "Something completely different, without any relation.",
+	}
+	got := duplicate.Cluster(texts, 0.8)
+	exp := [][]id.Zid{{1, 2, 3}}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected %v, but got %v", exp, got)
+	}
+	if got = duplicate.Cluster(texts, 1.0); !reflect.DeepEqual(got, [][]id.Zid{{1, 2}}) {
+		t.Errorf("Expected only identical texts, but got %v", got)
+	}
+}
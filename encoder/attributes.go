@@ -0,0 +1,49 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package encoder provides a generic interface to encode the abstract syntax
+// tree into some text form.
+package encoder
+
+import (
+	"strings"
+
+	"zettelstore.de/z/config/startup"
+)
+
+// standardAttributes are the attributes that are always allowed.
+var standardAttributes = map[string]bool{
+	"class":    true,
+	"dir":      true,
+	"height":   true,
+	"hidden":   true,
+	"hreflang": true,
+	"id":       true,
+	"lang":     true,
+	"rel":      true,
+	"start":    true,
+	"target":   true,
+	"title":    true,
+	"width":    true,
+}
+
+// IsAttributeAllowed returns true, if an attribute with the given key may be
+// written to an encoded output that interprets attributes, e.g. HTML. This
+// prevents the injection of event handlers like "onclick". Allowed are some
+// standard attributes, all "data-*" and "aria-*" attributes, and all
+// attributes listed in the startup configuration key "allow-attributes".
+func IsAttributeAllowed(key string) bool {
+	key = strings.ToLower(key)
+	if standardAttributes[key] ||
+		strings.HasPrefix(key, "data-") || strings.HasPrefix(key, "aria-") {
+		return true
+	}
+	return startup.AllowAttribute(key)
+}
@@ -0,0 +1,40 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package encoder provides a generic interface to encode the abstract syntax
+// tree into some text form.
+package encoder
+
+import "testing"
+
+func TestIsAttributeAllowed(t *testing.T) {
+	testcases := []struct {
+		key string
+		exp bool
+	}{
+		{"class", true},
+		{"id", true},
+		{"lang", true},
+		{"data-x", true},
+		{"aria-label", true},
+		{"onclick", false},
+		{"ONCLICK", false},
+		{"onload", false},
+		{"href", false},
+		{"src", false},
+		{"formaction", false},
+		{"style", false},
+	}
+	for _, tc := range testcases {
+		if got := IsAttributeAllowed(tc.key); got != tc.exp {
+			t.Errorf("%q: expected %v, but got %v", tc.key, tc.exp, got)
+		}
+	}
+}
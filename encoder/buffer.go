@@ -15,8 +15,23 @@ package encoder
 import (
 	"encoding/base64"
 	"io"
+	"sync"
 )
 
+const (
+	bufSize        = 4096      // Initial size of a buffer
+	maxPoolBufSize = 64 * 1024 // Larger buffers are not put back into the pool
+)
+
+// bufPool contains buffers for BufWriter. It reduces allocations when many
+// small encodings are done, e.g. for the titles of a list page.
+var bufPool = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, 0, bufSize)
+		return &buf
+	},
+}
+
 // BufWriter is a specialized buffered writer for encoding zettel.
 type BufWriter struct {
 	w      io.Writer // The io.Writer to write to
@@ -25,9 +40,11 @@ type BufWriter struct {
 	buf    []byte    // Buffer to collect bytes
 }
 
-// NewBufWriter creates a new BufWriter
+// NewBufWriter creates a new BufWriter. Its buffer is taken from a pool and
+// given back on Flush.
 func NewBufWriter(w io.Writer) BufWriter {
-	return BufWriter{w: w, buf: make([]byte, 0, 4096)}
+	buf := bufPool.Get().(*[]byte)
+	return BufWriter{w: w, buf: (*buf)[:0]}
 }
 
 // Write writes the contents of p into the buffer.
@@ -87,11 +104,17 @@ func (w *BufWriter) WriteBase64(p []byte) {
 }
 
 // Flush writes any buffered data to the underlying io.Writer. It returns the
-// number of bytes written and an error if something went wrong.
+// number of bytes written and an error if something went wrong. The buffer is
+// given back to the pool, but the BufWriter may still be used.
 func (w *BufWriter) Flush() (int, error) {
 	if w.err == nil {
 		w.flush()
 	}
+	if w.buf != nil && cap(w.buf) <= maxPoolBufSize {
+		buf := w.buf[:0]
+		bufPool.Put(&buf)
+	}
+	w.buf = nil
 	return w.length, w.err
 }
 
@@ -23,6 +23,10 @@ import (
 )
 
 // Encoder is an interface that allows to encode different parts of a zettel.
+//
+// An encoder stores only its options. All data of one encoding, e.g. the
+// collected footnotes, belong to a value that is created by the Write method.
+// Therefore, an encoder may be used concurrently after all options are set.
 type Encoder interface {
 	SetOption(Option)
 
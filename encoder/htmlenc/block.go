@@ -88,6 +88,10 @@ func processSpanAttributes(attrs *ast.Attributes) *ast.Attributes {
 func (v *visitor) VisitRegion(rn *ast.RegionNode) {
 	var code string
 	attrs := rn.Attrs
+	if label, ok := v.num.float(rn); ok {
+		v.writeFloat(rn, label)
+		return
+	}
 	oldVerse := v.inVerse
 	switch rn.Code {
 	case ast.RegionSpan:
@@ -118,6 +122,20 @@ func (v *visitor) VisitRegion(rn *ast.RegionNode) {
 	v.inVerse = oldVerse
 }
 
+// writeFloat writes a numbered figure or table, together with its caption.
+func (v *visitor) writeFloat(rn *ast.RegionNode, label string) {
+	v.lang.push(rn.Attrs)
+	defer v.lang.pop()
+
+	v.b.WriteString("<figure")
+	v.visitAttributes(rn.Attrs)
+	v.b.WriteString(">\n")
+	v.acceptBlockSlice(rn.Blocks)
+	v.b.WriteStrings("<figcaption>", label, ": ")
+	v.acceptInlineSlice(rn.Inlines)
+	v.b.WriteString("</figcaption>\n</figure>\n")
+}
+
 // VisitHeading writes the HTML code for a heading.
 func (v *visitor) VisitHeading(hn *ast.HeadingNode) {
 	v.lang.push(hn.Attrs)
@@ -129,11 +147,24 @@ func (v *visitor) VisitHeading(hn *ast.HeadingNode) {
 	}
 	strLvl := strconv.Itoa(lvl)
 	v.b.WriteStrings("<h", strLvl)
-	v.visitAttributes(hn.Attrs)
-	if slug := hn.Slug; len(slug) > 0 {
-		v.b.WriteStrings(" id=\"", slug, "\"")
+	attrs := hn.Attrs
+	if len(hn.Slug) > 0 {
+		// The slug is a unique version of the id attribute, if there is one.
+		if _, ok := attrs.Get("id"); ok {
+			attrs = attrs.Clone()
+			attrs.Remove("id")
+		}
+	}
+	v.visitAttributes(attrs)
+	if len(hn.Slug) > 0 {
+		v.b.WriteString(" id=\"")
+		v.writeQuotedEscaped(hn.Slug)
+		v.b.WriteByte('"')
 	}
 	v.b.WriteByte('>')
+	if number, ok := v.num.heading(hn); ok {
+		v.b.WriteStrings("<span class=\"zs-number\">", number, "</span> ")
+	}
 	v.acceptInlineSlice(hn.Inlines)
 	v.b.WriteStrings("</h", strLvl, ">\n")
 }
@@ -174,14 +205,35 @@ func (v *visitor) VisitNestedList(ln *ast.NestedListNode) {
 	v.b.WriteStrings("<", code)
 	v.visitAttributes(ln.Attrs)
 	v.b.WriteString(">\n")
-	for _, item := range ln.Items {
+	for i, item := range ln.Items {
 		v.b.WriteString("<li>")
-		v.writeItemSliceOrPara(item, compact)
+		v.writeItemSliceOrPara(item, compact, ln.Task(i).State)
 		v.b.WriteString("</li>\n")
 	}
 	v.b.WriteStrings("</", code, ">\n")
 }
 
+// writeTask writes a disabled checkbox for a task item.
+func (v *visitor) writeTask(state ast.TaskState) {
+	switch state {
+	case ast.TaskOpen:
+		v.b.WriteString("<input type=\"checkbox\"")
+	case ast.TaskDone:
+		if v.xhtml {
+			v.b.WriteString("<input type=\"checkbox\" checked=\"checked\"")
+		} else {
+			v.b.WriteString("<input type=\"checkbox\" checked")
+		}
+	default:
+		return
+	}
+	if v.xhtml {
+		v.b.WriteString(" disabled=\"disabled\" /> ")
+	} else {
+		v.b.WriteString(" disabled> ")
+	}
+}
+
 func (v *visitor) writeQuotationList(ln *ast.NestedListNode) {
 	v.b.WriteString("<blockquote>\n")
 	inPara := false
@@ -244,14 +296,27 @@ func isCompactSlice(ins ast.ItemSlice) bool {
 
 // writeItemSliceOrPara emits the content of a paragraph if the paragraph is
 // the only element of the block slice and if compact mode is true. Otherwise,
-// the item slice is emitted normally.
-func (v *visitor) writeItemSliceOrPara(ins ast.ItemSlice, compact bool) {
+// the item slice is emitted normally. The checkbox of a task is placed inside
+// the first paragraph, if there is one.
+func (v *visitor) writeItemSliceOrPara(ins ast.ItemSlice, compact bool, task ast.TaskState) {
 	if compact && len(ins) == 1 {
 		if para, ok := ins[0].(*ast.ParaNode); ok {
+			v.writeTask(task)
+			v.acceptInlineSlice(para.Inlines)
+			return
+		}
+	}
+	if task != ast.TaskNone && len(ins) > 0 {
+		if para, ok := ins[0].(*ast.ParaNode); ok {
+			v.b.WriteString("<p>")
+			v.writeTask(task)
 			v.acceptInlineSlice(para.Inlines)
+			v.b.WriteString("</p>\n")
+			v.acceptItemSlice(ins[1:])
 			return
 		}
 	}
+	v.writeTask(task)
 	v.acceptItemSlice(ins)
 }
 
@@ -0,0 +1,63 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package htmlenc encodes the abstract syntax tree into HTML5.
+package htmlenc
+
+import (
+	"sort"
+	"strings"
+)
+
+// writeAbbr writes the first occurrence of an abbreviation as an <abbr>
+// element. A trailing punctuation character is not part of the abbreviation.
+// It returns false, if nothing was written.
+func (v *visitor) writeAbbr(text string) bool {
+	abbr, rest := text, ""
+	if l := len(text); l > 1 && strings.IndexByte(",;:!?.", text[l-1]) >= 0 {
+		abbr, rest = text[:l-1], text[l-1:]
+	}
+	expansion, ok := v.enc.glossary[abbr]
+	if !ok || v.abbrs[abbr] {
+		return false
+	}
+	if v.abbrs == nil {
+		v.abbrs = make(map[string]bool)
+	}
+	v.abbrs[abbr] = true
+	v.b.WriteString("<abbr title=\"")
+	v.writeQuotedEscaped(expansion)
+	v.b.WriteString("\">")
+	v.writeHTMLEscaped(abbr)
+	v.b.WriteString("</abbr>")
+	v.writeHTMLEscaped(rest)
+	return true
+}
+
+// writeGlossary writes a section with all abbreviations that were used.
+func (v *visitor) writeGlossary() {
+	if !v.enc.glossarySect || len(v.abbrs) == 0 {
+		return
+	}
+	abbrs := make([]string, 0, len(v.abbrs))
+	for abbr := range v.abbrs {
+		abbrs = append(abbrs, abbr)
+	}
+	sort.Strings(abbrs)
+	v.b.WriteString("<section class=\"zs-glossary\">\n<h2>Glossary</h2>\n<dl>\n") // l10n
+	for _, abbr := range abbrs {
+		v.b.WriteString("<dt>")
+		v.writeHTMLEscaped(abbr)
+		v.b.WriteString("</dt>\n<dd>")
+		v.writeHTMLEscaped(v.enc.glossary[abbr])
+		v.b.WriteString("</dd>\n")
+	}
+	v.b.WriteString("</dl>\n</section>\n")
+}
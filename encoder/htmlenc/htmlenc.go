@@ -32,11 +32,13 @@ type htmlEncoder struct {
 	xhtml          bool   // use XHTML syntax instead of HTML syntax
 	markerExternal string // Marker after link to (external) material.
 	newWindow      bool   // open link in new window
+	numbering      bool   // number headings, figures, and tables
 	adaptLink      func(*ast.LinkNode) ast.InlineNode
 	adaptImage     func(*ast.ImageNode) ast.InlineNode
 	adaptCite      func(*ast.CiteNode) ast.InlineNode
+	glossary       map[string]string // abbreviations and their expansions
+	glossarySect   bool              // append section with used abbreviations
 	ignoreMeta     map[string]bool
-	footnotes      []*ast.FootnoteNode
 }
 
 func (he *htmlEncoder) SetOption(option encoder.Option) {
@@ -54,6 +56,8 @@ func (he *htmlEncoder) SetOption(option encoder.Option) {
 			he.newWindow = opt.Value
 		case "xhtml":
 			he.xhtml = opt.Value
+		case meta.KeyNumbering:
+			he.numbering = opt.Value
 		}
 	case *encoder.StringsOption:
 		switch opt.Key {
@@ -69,6 +73,9 @@ func (he *htmlEncoder) SetOption(option encoder.Option) {
 		he.adaptImage = opt.Adapter
 	case *encoder.AdaptCiteOption:
 		he.adaptCite = opt.Adapter
+	case *encoder.GlossaryOption:
+		he.glossary = opt.Glossary
+		he.glossarySect = opt.Section
 	default:
 		var name string
 		if option != nil {
@@ -96,13 +103,23 @@ func (he *htmlEncoder) WriteZettel(
 		v.acceptMeta(zn.Zettel.Meta, false)
 	}
 	v.b.WriteString("\n</head>\n<body>\n")
+	if he.withNumbering(zn) {
+		v.num = newNumbering(zn.Ast)
+	}
 	v.acceptBlockSlice(zn.Ast)
 	v.writeEndnotes()
+	v.writeGlossary()
 	v.b.WriteString("</body>\n</html>")
 	length, err := v.b.Flush()
 	return length, err
 }
 
+// withNumbering returns true, if headings, figures, and tables of the zettel
+// should be numbered.
+func (he *htmlEncoder) withNumbering(zn *ast.ZettelNode) bool {
+	return he.numbering || (zn.InhMeta != nil && zn.InhMeta.GetBool(meta.KeyNumbering))
+}
+
 // WriteMeta encodes meta data as HTML5.
 func (he *htmlEncoder) WriteMeta(w io.Writer, m *meta.Meta) (int, error) {
 	v := newVisitor(he, w)
@@ -112,14 +129,22 @@ func (he *htmlEncoder) WriteMeta(w io.Writer, m *meta.Meta) (int, error) {
 }
 
 func (he *htmlEncoder) WriteContent(w io.Writer, zn *ast.ZettelNode) (int, error) {
-	return he.WriteBlocks(w, zn.Ast)
+	return he.writeBlocks(w, zn.Ast, he.withNumbering(zn))
 }
 
 // WriteBlocks encodes a block slice.
 func (he *htmlEncoder) WriteBlocks(w io.Writer, bs ast.BlockSlice) (int, error) {
+	return he.writeBlocks(w, bs, he.numbering)
+}
+
+func (he *htmlEncoder) writeBlocks(w io.Writer, bs ast.BlockSlice, numbering bool) (int, error) {
 	v := newVisitor(he, w)
+	if numbering {
+		v.num = newNumbering(bs)
+	}
 	v.acceptBlockSlice(bs)
 	v.writeEndnotes()
+	v.writeGlossary()
 	length, err := v.b.Flush()
 	return length, err
 }
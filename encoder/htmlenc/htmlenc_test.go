@@ -0,0 +1,113 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package htmlenc_test provides some tests for the HTML encoder.
+package htmlenc_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"zettelstore.de/z/ast"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/encoder"
+	"zettelstore.de/z/input"
+	"zettelstore.de/z/parser"
+
+	_ "zettelstore.de/z/encoder/htmlenc"
+	_ "zettelstore.de/z/parser/zettelmark"
+)
+
+func TestNumbering(t *testing.T) {
+	m := meta.New(id.Zid(1))
+	m.Set(meta.KeySyntax, meta.ValueSyntaxZmk)
+	m.Set(meta.KeyNumbering, meta.ValueTrue)
+	content := "=== Intro\n" +
+		"See [[#results]] and [[#arch]], [[#details]].\n" +
+		"==== Details\n" +
+		":::{id=arch}\n{{arch.png}}\n::: Architecture\n" +
+		":::{id=results}\n|a|b\n::: Results\n" +
+		"=== End\n"
+	zn := &ast.ZettelNode{
+		InhMeta: m,
+		Ast:     parser.ParseBlocks(input.NewInput(content), m, meta.ValueSyntaxZmk),
+	}
+
+	var sb strings.Builder
+	if _, err := encoder.Create("html").WriteContent(&sb, zn); err != nil {
+		t.Fatal(err)
+	}
+	got := sb.String()
+	exp := []string{
+		"<span class=\"zs-number\">1</span> Intro",
+		"<a href=\"#results\">Table 1</a>",
+		"<a href=\"#arch\">Figure 1</a>",
+		"<a href=\"#details\">Section 1.1</a>",
+		"<span class=\"zs-number\">1.1</span> Details",
+		"<figure id=\"arch\">",
+		"<figcaption>Figure 1: Architecture</figcaption>",
+		"<figcaption>Table 1: Results</figcaption>",
+		"<span class=\"zs-number\">2</span> End",
+	}
+	for _, e := range exp {
+		if !strings.Contains(got, e) {
+			t.Errorf("%q not found in:\n%s", e, got)
+		}
+	}
+
+	sb.Reset()
+	if _, err := encoder.Create("html").WriteBlocks(&sb, zn.Ast); err != nil {
+		t.Fatal(err)
+	}
+	if got = sb.String(); strings.Contains(got, "zs-number") || strings.Contains(got, "<figure") {
+		t.Errorf("Unexpected numbering:\n%s", got)
+	}
+}
+
+func TestFootnoteReuse(t *testing.T) {
+	bs := parser.ParseBlocks(input.NewInput("A[^x] B[^y]"), nil, meta.ValueSyntaxZmk)
+	enc := encoder.Create("html")
+	var first, second strings.Builder
+	if _, err := enc.WriteBlocks(&first, bs); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := enc.WriteBlocks(&second, bs); err != nil {
+		t.Fatal(err)
+	}
+	if first.String() != second.String() {
+		t.Errorf("Encoder reuse changes output:\n%s\n%s", first.String(), second.String())
+	}
+}
+
+func TestConcurrentUse(t *testing.T) {
+	bs := parser.ParseBlocks(input.NewInput("=== H\nA[^x] B[^y]"), nil, meta.ValueSyntaxZmk)
+	enc := encoder.Create("html", &encoder.BoolOption{Key: meta.KeyNumbering, Value: true})
+	var sb strings.Builder
+	if _, err := enc.WriteBlocks(&sb, bs); err != nil {
+		t.Fatal(err)
+	}
+	exp := sb.String()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var sb strings.Builder
+			enc.WriteBlocks(&sb, bs)
+			if got := sb.String(); got != exp {
+				t.Errorf("Expected:\n%s\nbut got:\n%s", exp, got)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -21,6 +21,9 @@ import (
 
 // VisitText writes text content.
 func (v *visitor) VisitText(tn *ast.TextNode) {
+	if v.enc.glossary != nil && !v.inAttr && v.writeAbbr(tn.Text) {
+		return
+	}
 	v.writeHTMLEscaped(tn.Text)
 }
 
@@ -64,6 +67,11 @@ func (v *visitor) VisitLink(ln *ast.LinkNode) {
 			return
 		}
 	}
+	if label, ok := v.num.label(ln); ok {
+		newLink := *ln
+		newLink.Inlines = ast.InlineSlice{&ast.TextNode{Text: label}}
+		ln = &newLink
+	}
 	v.lang.push(ln.Attrs)
 	defer v.lang.pop()
 
@@ -77,7 +85,7 @@ func (v *visitor) VisitLink(ln *ast.LinkNode) {
 		v.writeAHref(ln.Ref, attrs, ln.Inlines)
 	case ast.RefStateExternal:
 		attrs := ln.Attrs.Clone()
-		attrs = attrs.Set("class", "zs-external")
+		attrs = attrs.AddClass("zs-external")
 		if v.enc.newWindow {
 			attrs = attrs.Set("target", "_blank").Set("rel", "noopener noreferrer")
 		}
@@ -104,6 +112,10 @@ func (v *visitor) writeAHref(ref *ast.Reference, attrs *ast.Attributes, ins ast.
 	v.b.WriteString("</a>")
 }
 
+// dataURLEscaper escapes all characters of an SVG image that have a special
+// meaning in a data URL.
+var dataURLEscaper = strings.NewReplacer("%", "%25", "#", "%23")
+
 // VisitImage writes HTML code for images.
 func (v *visitor) VisitImage(in *ast.ImageNode) {
 	if adapt := v.enc.adaptImage; adapt != nil {
@@ -123,7 +135,7 @@ func (v *visitor) VisitImage(in *ast.ImageNode) {
 		switch in.Syntax {
 		case "svg":
 			v.b.WriteString("svg+xml;utf8,")
-			v.writeQuotedEscaped(string(in.Blob))
+			v.writeQuotedEscaped(dataURLEscaper.Replace(string(in.Blob)))
 		default:
 			v.b.WriteStrings(in.Syntax, ";base64,")
 			v.b.WriteBase64(in.Blob)
@@ -133,7 +145,9 @@ func (v *visitor) VisitImage(in *ast.ImageNode) {
 		v.writeReference(in.Ref)
 	}
 	v.b.WriteString("\" alt=\"")
+	v.inAttr = true
 	v.acceptInlineSlice(in.Inlines)
+	v.inAttr = false
 	v.b.WriteByte('"')
 	v.visitAttributes(in.Attrs)
 	if v.xhtml {
@@ -170,8 +184,8 @@ func (v *visitor) VisitFootnote(fn *ast.FootnoteNode) {
 	v.lang.push(fn.Attrs)
 	defer v.lang.pop()
 
-	v.enc.footnotes = append(v.enc.footnotes, fn)
-	n := strconv.Itoa(len(v.enc.footnotes))
+	n := v.fnPrefix + strconv.Itoa(len(v.footnotes)+1)
+	v.footnotes = append(v.footnotes, footnoteInfo{fn: fn, num: n})
 	v.b.WriteStrings("<sup id=\"fnref:", n, "\"><a href=\"#fn:", n, "\" class=\"zs-footnote-ref\" role=\"doc-noteref\">", n, "</a></sup>")
 	// TODO: what to do with Attrs?
 }
@@ -188,7 +202,7 @@ func (v *visitor) VisitFormat(fn *ast.FormatNode) {
 	v.lang.push(fn.Attrs)
 	defer v.lang.pop()
 
-	var code string
+	var code, style string
 	attrs := fn.Attrs
 	switch fn.Code {
 	case ast.FormatItalic:
@@ -220,7 +234,7 @@ func (v *visitor) VisitFormat(fn *ast.FormatNode) {
 		attrs = processSpanAttributes(attrs)
 	case ast.FormatMonospace:
 		code = "span"
-		attrs = attrs.Set("style", "font-family:monospace")
+		style = "font-family:monospace" // Not allowed as an attribute of the zettel
 	case ast.FormatQuote:
 		v.visitQuotes(fn)
 		return
@@ -229,6 +243,9 @@ func (v *visitor) VisitFormat(fn *ast.FormatNode) {
 	}
 	v.b.WriteStrings("<", code)
 	v.visitAttributes(attrs)
+	if style != "" {
+		v.b.WriteStrings(" style=\"", style, "\"")
+	}
 	v.b.WriteByte('>')
 	v.acceptInlineSlice(fn.Inlines)
 	v.b.WriteStrings("</", code, ">")
@@ -0,0 +1,173 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package htmlenc encodes the abstract syntax tree into HTML5.
+package htmlenc
+
+import (
+	"strconv"
+	"strings"
+
+	"zettelstore.de/z/ast"
+)
+
+// numbering stores the numbers of headings, figures, and tables of a block
+// slice. A figure or a table must be the only block of a span region that
+// has a caption, i.e. some text after the closing delimiter:
+//
+//	:::{id=results}
+//	|=Name|=Value
+//	|a|1
+//	::: Measured results
+//
+// A link without text to the identifier of a numbered element, e.g.
+// [[#results]], is rendered as "Table 1".
+type numbering struct {
+	headings map[*ast.HeadingNode]string
+	floats   map[*ast.RegionNode]string
+	labels   map[string]string // Maps identifiers to labels
+	minLevel int
+	levels   []int
+	figures  int
+	tables   int
+}
+
+func newNumbering(bs ast.BlockSlice) *numbering {
+	num := &numbering{
+		headings: make(map[*ast.HeadingNode]string),
+		floats:   make(map[*ast.RegionNode]string),
+		labels:   make(map[string]string),
+		minLevel: -1,
+	}
+	num.findMinLevel(bs)
+	num.visitBlocks(bs)
+	return num
+}
+
+func (num *numbering) findMinLevel(bs ast.BlockSlice) {
+	for _, bn := range bs {
+		switch n := bn.(type) {
+		case *ast.HeadingNode:
+			if num.minLevel < 0 || n.Level < num.minLevel {
+				num.minLevel = n.Level
+			}
+		case *ast.RegionNode:
+			num.findMinLevel(n.Blocks)
+		}
+	}
+}
+
+func (num *numbering) visitBlocks(bs ast.BlockSlice) {
+	for _, bn := range bs {
+		switch n := bn.(type) {
+		case *ast.HeadingNode:
+			num.addHeading(n)
+		case *ast.RegionNode:
+			if kind := floatKind(n); kind != "" {
+				num.addFloat(n, kind)
+			}
+			num.visitBlocks(n.Blocks)
+		}
+	}
+}
+
+func (num *numbering) addHeading(hn *ast.HeadingNode) {
+	pos := hn.Level - num.minLevel
+	for len(num.levels) <= pos {
+		num.levels = append(num.levels, 0)
+	}
+	num.levels[pos]++
+	num.levels = num.levels[:pos+1]
+	parts := make([]string, 0, len(num.levels))
+	for _, n := range num.levels {
+		parts = append(parts, strconv.Itoa(n))
+	}
+	number := strings.Join(parts, ".")
+	num.headings[hn] = number
+	id, ok := hn.Attrs.Get("id")
+	if !ok {
+		id = hn.Slug
+	}
+	if id != "" {
+		num.labels[id] = "Section " + number // l10n
+	}
+}
+
+func (num *numbering) addFloat(rn *ast.RegionNode, kind string) {
+	var label string
+	switch kind {
+	case "figure":
+		num.figures++
+		label = "Figure " + strconv.Itoa(num.figures) // l10n
+	case "table":
+		num.tables++
+		label = "Table " + strconv.Itoa(num.tables) // l10n
+	}
+	num.floats[rn] = label
+	if id, ok := rn.Attrs.Get("id"); ok && id != "" {
+		num.labels[id] = label
+	}
+}
+
+// floatKind returns "figure", if the region contains just one image, "table",
+// if it contains just one table, and the empty string otherwise. In addition,
+// the region must have a caption.
+func floatKind(rn *ast.RegionNode) string {
+	if rn.Code != ast.RegionSpan || len(rn.Inlines) == 0 || len(rn.Blocks) != 1 {
+		return ""
+	}
+	switch n := rn.Blocks[0].(type) {
+	case *ast.TableNode:
+		return "table"
+	case *ast.ParaNode:
+		images := 0
+		for _, in := range n.Inlines {
+			switch in.(type) {
+			case *ast.ImageNode:
+				images++
+			case *ast.SpaceNode, *ast.BreakNode:
+			default:
+				return ""
+			}
+		}
+		if images == 1 {
+			return "figure"
+		}
+	}
+	return ""
+}
+
+// heading returns the number of a heading.
+func (num *numbering) heading(hn *ast.HeadingNode) (string, bool) {
+	if num == nil {
+		return "", false
+	}
+	number, ok := num.headings[hn]
+	return number, ok
+}
+
+// float returns the label of a figure or a table.
+func (num *numbering) float(rn *ast.RegionNode) (string, bool) {
+	if num == nil {
+		return "", false
+	}
+	label, ok := num.floats[rn]
+	return label, ok
+}
+
+// label returns the label of a link to a numbered element, if the link has no
+// text of its own.
+func (num *numbering) label(ln *ast.LinkNode) (string, bool) {
+	if num == nil || !ln.OnlyRef || ln.Ref == nil || ln.Ref.State != ast.RefStateZettelSelf {
+		return "", false
+	}
+	label, ok := num.labels[ln.Ref.URL.Fragment]
+	return label, ok
+}
@@ -14,7 +14,6 @@ package htmlenc
 import (
 	"io"
 	"sort"
-	"strconv"
 	"strings"
 
 	"zettelstore.de/z/ast"
@@ -31,6 +30,17 @@ type visitor struct {
 	inVerse      bool // In verse block
 	xhtml        bool // copied from enc.xhtml
 	lang         langStack
+	num          *numbering      // Numbers of headings, figures, tables; may be nil
+	inAttr       bool            // Text is written as an attribute value
+	abbrs        map[string]bool // Abbreviations already written
+	footnotes    []footnoteInfo  // Footnotes of the current scope
+	fnPrefix     string          // Number prefix of footnotes in current scope
+}
+
+// footnoteInfo stores a footnote together with its number.
+type footnoteInfo struct {
+	fn  *ast.FootnoteNode
+	num string
 }
 
 func newVisitor(he *htmlEncoder, w io.Writer) *visitor {
@@ -98,26 +108,38 @@ func (v *visitor) acceptInlineSlice(ins ast.InlineSlice) {
 	}
 }
 
+// writeEndnotes writes all footnotes of the current scope. Footnotes that are
+// contained in a footnote get a hierarchical number, e.g. "2.1", and are
+// written as a nested list.
 func (v *visitor) writeEndnotes() {
-	if len(v.enc.footnotes) > 0 {
-		v.b.WriteString("<ol class=\"zs-endnotes\">\n")
-		for i := 0; i < len(v.enc.footnotes); i++ {
-			// Do not use a range loop above, because a footnote may contain
-			// a footnote. Therefore v.enc.footnote may grow during the loop.
-			fn := v.enc.footnotes[i]
-			n := strconv.Itoa(i + 1)
-			v.b.WriteStrings("<li id=\"fn:", n, "\" role=\"doc-endnote\">")
-			v.acceptInlineSlice(fn.Inlines)
-			v.b.WriteStrings(
-				" <a href=\"#fnref:",
-				n,
-				"\" class=\"zs-footnote-backref\" role=\"doc-backlink\">&#x21a9;&#xfe0e;</a></li>\n")
+	footnotes := v.footnotes
+	if len(footnotes) == 0 {
+		return
+	}
+	oldPrefix := v.fnPrefix
+	v.b.WriteString("<ol class=\"zs-endnotes\">\n")
+	for _, fni := range footnotes {
+		v.footnotes = nil
+		v.fnPrefix = fni.num + "."
+		v.b.WriteStrings("<li id=\"fn:", fni.num, "\" role=\"doc-endnote\">")
+		v.acceptInlineSlice(fni.fn.Inlines)
+		v.b.WriteStrings(
+			" <a href=\"#fnref:",
+			fni.num,
+			"\" class=\"zs-footnote-backref\" role=\"doc-backlink\">&#x21a9;&#xfe0e;</a>")
+		if len(v.footnotes) > 0 {
+			v.b.WriteByte('\n')
+			v.writeEndnotes()
 		}
-		v.b.WriteString("</ol>\n")
+		v.b.WriteString("</li>\n")
 	}
+	v.b.WriteString("</ol>\n")
+	v.footnotes = nil
+	v.fnPrefix = oldPrefix
 }
 
-// visitAttributes write HTML attributes
+// visitAttributes write HTML attributes. Attributes that are not allowed, e.g.
+// event handlers, are silently ignored.
 func (v *visitor) visitAttributes(a *ast.Attributes) {
 	if a == nil || len(a.Attrs) == 0 {
 		return
@@ -131,7 +153,7 @@ func (v *visitor) visitAttributes(a *ast.Attributes) {
 	sort.Strings(keys)
 
 	for _, k := range keys {
-		if k == "" || k == "-" {
+		if k == "" || k == "-" || !encoder.IsAttributeAllowed(k) {
 			continue
 		}
 		v.b.WriteStrings(" ", k)
@@ -204,7 +204,24 @@ func (v *detailVisitor) VisitNestedList(ln *ast.NestedListNode) {
 		}
 		v.acceptItemSlice(item)
 	}
-	v.b.WriteString("]}")
+	v.b.WriteByte(']')
+	if len(ln.Tasks) > 0 {
+		v.writeContentStart('k')
+		for i := range ln.Items {
+			if i > 0 {
+				v.b.WriteByte(',')
+			}
+			v.b.WriteStrings("\"", taskState[ln.Task(i).State], "\"")
+		}
+		v.b.WriteByte(']')
+	}
+	v.b.WriteByte('}')
+}
+
+var taskState = map[ast.TaskState]string{
+	ast.TaskNone: "",
+	ast.TaskOpen: "open",
+	ast.TaskDone: "done",
 }
 
 // VisitDescriptionList emits a JSON description list.
@@ -548,6 +565,7 @@ var contentCode = map[rune][]byte{
 	'g': []byte(",\"g\":["),  // General list
 	'i': []byte(",\"i\":"),   // List of inlines
 	'j': []byte(",\"j\":{"),  // Embedded JSON object
+	'k': []byte(",\"k\":["),  // List of task states
 	'l': []byte(",\"l\":["),  // List of lines
 	'n': []byte(",\"n\":"),   // Number
 	'o': []byte(",\"o\":\""), // Byte object
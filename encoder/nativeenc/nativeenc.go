@@ -237,6 +237,11 @@ var listCode = map[ast.NestedListCode][]byte{
 	ast.NestedListQuote:     []byte("[QuoteList"),
 }
 
+var taskCode = map[ast.TaskState]string{
+	ast.TaskOpen: "[Task Open]",
+	ast.TaskDone: "[Task Done]",
+}
+
 // VisitNestedList writes native code for lists and blockquotes.
 func (v *visitor) VisitNestedList(ln *ast.NestedListNode) {
 	v.b.Write(listCode[ln.Code])
@@ -248,6 +253,13 @@ func (v *visitor) VisitNestedList(ln *ast.NestedListNode) {
 		v.writeNewLine()
 		v.level++
 		v.b.WriteByte('[')
+		if task, ok := taskCode[ln.Task(i).State]; ok {
+			v.b.WriteString(task)
+			if len(item) > 0 {
+				v.b.WriteByte(',')
+				v.writeNewLine()
+			}
+		}
 		v.acceptItemSlice(item)
 		v.b.WriteByte(']')
 		v.level--
@@ -74,3 +74,12 @@ type AdaptCiteOption struct {
 
 // Name returns the visible name of this option.
 func (al *AdaptCiteOption) Name() string { return "AdaptCiteOption" }
+
+// GlossaryOption specifies abbreviations and their expansions.
+type GlossaryOption struct {
+	Glossary map[string]string // Maps abbreviations to expansions
+	Section  bool              // Append a section with all used abbreviations
+}
+
+// Name returns the visible name of this option.
+func (gl *GlossaryOption) Name() string { return "GlossaryOption" }
@@ -158,9 +158,15 @@ var listCode = map[ast.NestedListCode]byte{
 // VisitNestedList writes HTML code for lists and blockquotes.
 func (v *visitor) VisitNestedList(ln *ast.NestedListNode) {
 	v.prefix = append(v.prefix, listCode[ln.Code])
-	for _, item := range ln.Items {
+	for i, item := range ln.Items {
 		v.b.Write(v.prefix)
 		v.b.WriteByte(' ')
+		switch ln.Task(i).State {
+		case ast.TaskOpen:
+			v.b.WriteString("[ ] ")
+		case ast.TaskDone:
+			v.b.WriteString("[x] ")
+		}
 		for i, in := range item {
 			if i > 0 {
 				if _, ok := in.(*ast.ParaNode); ok {
@@ -315,6 +321,7 @@ func (v *visitor) VisitLink(ln *ast.LinkNode) {
 		v.b.WriteByte('|')
 	}
 	v.b.WriteStrings(ln.Ref.String(), "]]")
+	v.visitAttributes(ln.Attrs)
 }
 
 // VisitImage writes HTML code for images.
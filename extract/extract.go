@@ -0,0 +1,327 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package extract retrieves searchable text from binary zettel, e.g. by
+// optical character recognition of images.
+//
+// Text is extracted in the background by a function that is registered for
+// the syntax of a zettel, typically an external program. It is provided as
+// the "extracted-text" property of the meta data, so that the text is found
+// by a search. The text of a document may consist of pages, which are
+// separated by a form feed character. Extracted texts are cached in memory.
+// A cached text is removed when the zettel is changed.
+package extract
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/place"
+)
+
+// MaxLength is the maximum number of characters of an extracted text.
+const MaxLength = 64 << 10
+
+// ImageSyntaxes lists the syntax values of images that may contain text.
+var ImageSyntaxes = []string{"gif", "jpeg", "jpg", "png", "webp"}
+
+// Func extracts text from the content of a zettel.
+type Func func(ctx context.Context, content []byte) (string, error)
+
+// commandTimeout is the maximum time an external program may run.
+const commandTimeout = 2 * time.Minute
+
+// Command returns a function that runs an external program to extract text.
+// The command line consists of the program and its arguments, separated by
+// space characters. The content is written to the standard input of the
+// program, the text is read from its standard output, e.g. "tesseract stdin
+// stdout".
+func Command(cmdline string) Func {
+	args := strings.Fields(cmdline)
+	return func(ctx context.Context, content []byte) (string, error) {
+		if len(args) == 0 {
+			return "", fmt.Errorf("no command given")
+		}
+		ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+		defer cancel()
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Stdin = bytes.NewReader(content)
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		if err := cmd.Run(); err != nil {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return "", fmt.Errorf("%w: %v", err, msg)
+			}
+			return "", err
+		}
+		return stdout.String(), nil
+	}
+}
+
+// Port is the interface of the place whose zettel are processed.
+type Port interface {
+	// RegisterChangeObserver registers an observer that will be notified
+	// if a zettel was found to be changed.
+	RegisterChangeObserver(place.ObserverFunc)
+
+	// GetZettel retrieves a specific zettel.
+	GetZettel(ctx context.Context, zid id.Zid) (domain.Zettel, error)
+
+	// SelectMeta returns all zettel meta data that match the selection
+	// criteria. The result is ordered by descending zettel id.
+	SelectMeta(ctx context.Context, f *place.Filter, s *place.Sorter) ([]*meta.Meta, error)
+}
+
+// Extractor extracts text from zettel in the background.
+type Extractor struct {
+	funcs map[string]Func
+
+	mx      sync.Mutex          // protects all following fields
+	cache   map[id.Zid][]string // text of all pages
+	gen     uint64              // incremented on every change, to detect stale texts
+	pending map[id.Zid]bool
+	queue   []id.Zid
+	signal  chan struct{}
+}
+
+// New creates a new extractor without any extraction functions.
+func New() *Extractor {
+	return &Extractor{
+		funcs:   make(map[string]Func),
+		cache:   make(map[id.Zid][]string),
+		pending: make(map[id.Zid]bool),
+		signal:  make(chan struct{}, 1),
+	}
+}
+
+// Register sets the function that extracts text from zettel with the given
+// syntax. It must be called before the extractor is started.
+func (ex *Extractor) Register(syntax string, f Func) { ex.funcs[syntax] = f }
+
+// Handles returns true, if text can be extracted from the given zettel.
+func (ex *Extractor) Handles(m *meta.Meta) bool {
+	_, ok := ex.funcs[m.GetDefault(meta.KeySyntax, "")]
+	return ok
+}
+
+// Start extracts text from all zettel of the given place, whose syntax is
+// handled, until the context is done.
+func (ex *Extractor) Start(ctx context.Context, port Port) {
+	port.RegisterChangeObserver(ex.observe)
+	ex.enqueue(id.Invalid)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ex.signal:
+			}
+			for {
+				zid, ok := ex.next()
+				if !ok {
+					break
+				}
+				if zid == id.Invalid {
+					ex.enqueueAll(ctx, port)
+				} else {
+					ex.process(ctx, port, zid)
+				}
+			}
+		}
+	}()
+}
+
+func (ex *Extractor) observe(reason place.ChangeReason, zid id.Zid) {
+	ex.mx.Lock()
+	if reason == place.OnReload || !zid.IsValid() {
+		ex.cache = make(map[id.Zid][]string)
+		zid = id.Invalid // will enqueue all zettel
+	} else {
+		delete(ex.cache, zid)
+	}
+	ex.gen++
+	ex.mx.Unlock()
+	if reason != place.OnDelete {
+		ex.enqueue(zid)
+	}
+}
+
+// Text returns the extracted text of the given zettel. If the text is not
+// known yet, it will be extracted in the background.
+func (ex *Extractor) Text(zid id.Zid) (string, bool) {
+	pages, ok := ex.pages(zid)
+	return strings.Join(pages, " "), ok
+}
+
+// MatchedPages returns the numbers of all pages of the given zettel that
+// contain all the given terms, ignoring the case of letters. Page numbers
+// start with one. Nil is returned, if the text does not consist of pages.
+func (ex *Extractor) MatchedPages(zid id.Zid, terms []string) []int {
+	pages, _ := ex.pages(zid)
+	if len(pages) < 2 || len(terms) == 0 {
+		return nil
+	}
+	lowerTerms := make([]string, 0, len(terms))
+	for _, term := range terms {
+		lowerTerms = append(lowerTerms, strings.ToLower(term))
+	}
+	var result []int
+	for i, page := range pages {
+		if containsAll(strings.ToLower(page), lowerTerms) {
+			result = append(result, i+1)
+		}
+	}
+	return result
+}
+
+func containsAll(s string, terms []string) bool {
+	for _, term := range terms {
+		if !strings.Contains(s, term) {
+			return false
+		}
+	}
+	return true
+}
+
+func (ex *Extractor) pages(zid id.Zid) ([]string, bool) {
+	ex.mx.Lock()
+	pages, ok := ex.cache[zid]
+	ex.mx.Unlock()
+	if !ok {
+		ex.enqueue(zid)
+	}
+	return pages, ok
+}
+
+// SetText sets the extracted text as a property of the given meta data, if
+// it is known. Otherwise the text will be extracted in the background.
+func (ex *Extractor) SetText(m *meta.Meta) {
+	if !ex.Handles(m) {
+		return
+	}
+	if text, ok := ex.Text(m.Zid); ok && text != "" {
+		m.Set(meta.KeyExtractedText, text)
+	}
+}
+
+func (ex *Extractor) enqueue(zid id.Zid) {
+	ex.mx.Lock()
+	if !ex.pending[zid] {
+		ex.pending[zid] = true
+		ex.queue = append(ex.queue, zid)
+	}
+	ex.mx.Unlock()
+	select {
+	case ex.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (ex *Extractor) next() (id.Zid, bool) {
+	ex.mx.Lock()
+	defer ex.mx.Unlock()
+	if len(ex.queue) == 0 {
+		return id.Invalid, false
+	}
+	zid := ex.queue[0]
+	ex.queue = ex.queue[1:]
+	delete(ex.pending, zid)
+	return zid, true
+}
+
+func (ex *Extractor) enqueueAll(ctx context.Context, port Port) {
+	metaList, err := port.SelectMeta(ctx, nil, nil)
+	if err != nil {
+		log.Printf("Extract: %v", err)
+		return
+	}
+	for _, m := range metaList {
+		if ex.Handles(m) && !ex.known(m.Zid) {
+			ex.enqueue(m.Zid)
+		}
+	}
+}
+
+func (ex *Extractor) known(zid id.Zid) bool {
+	ex.mx.Lock()
+	_, ok := ex.cache[zid]
+	ex.mx.Unlock()
+	return ok
+}
+
+// process extracts the text of the zettel and stores it in the cache. If
+// some zettel was changed in the meantime, the zettel is processed again.
+func (ex *Extractor) process(ctx context.Context, port Port, zid id.Zid) {
+	if ex.known(zid) {
+		return
+	}
+	ex.mx.Lock()
+	gen := ex.gen
+	ex.mx.Unlock()
+	zettel, err := port.GetZettel(ctx, zid)
+	if err != nil {
+		return
+	}
+	f, ok := ex.funcs[zettel.Meta.GetDefault(meta.KeySyntax, "")]
+	if !ok {
+		return
+	}
+	text, err := f(ctx, zettel.Content.AsBytes())
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Extract %v: %v", zid, err)
+	}
+	pages := Normalize(text)
+	ex.mx.Lock()
+	current := ex.gen == gen
+	if current {
+		ex.cache[zid] = pages
+	}
+	ex.mx.Unlock()
+	if !current {
+		ex.enqueue(zid)
+	}
+}
+
+// Normalize splits the text into pages, so that they can be stored as a
+// meta value: all white space of a page is replaced by a single space
+// character. All pages together are cut to MaxLength characters. Empty pages
+// at the end are removed.
+func Normalize(text string) []string {
+	pages := strings.Split(text, PageSeparator)
+	remaining := MaxLength
+	for i, page := range pages {
+		page = strings.Join(strings.Fields(page), " ")
+		if n := utf8.RuneCountInString(page); n > remaining {
+			page = string([]rune(page)[:remaining])
+		}
+		remaining -= utf8.RuneCountInString(page)
+		pages[i] = page
+	}
+	for len(pages) > 0 && pages[len(pages)-1] == "" {
+		pages = pages[:len(pages)-1]
+	}
+	return pages
+}
@@ -0,0 +1,102 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package extract retrieves searchable text from binary zettel.
+package extract
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/place"
+)
+
+func TestNormalize(t *testing.T) {
+	if got := Normalize("  Hello,\n\n  world\t! \f\fEnd\f"); len(got) != 3 ||
+		got[0] != "Hello, world !" || got[1] != "" || got[2] != "End" {
+		t.Errorf("unexpected normalized text %q", got)
+	}
+	got := Normalize(strings.Repeat("ä", MaxLength-10) + "\f" + strings.Repeat("ö", 20))
+	if len(got) != 2 || len([]rune(got[1])) != 10 {
+		t.Errorf("text was not shortened: %q", got)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	text, err := Command("cat")(context.Background(), []byte("some text"))
+	if err != nil || text != "some text" {
+		t.Errorf("expected %q, but got %q (%v)", "some text", text, err)
+	}
+	if _, err = Command("false")(context.Background(), nil); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+type testPort struct {
+	zettel map[id.Zid]domain.Zettel
+}
+
+func (tp *testPort) RegisterChangeObserver(place.ObserverFunc) {}
+
+func (tp *testPort) GetZettel(_ context.Context, zid id.Zid) (domain.Zettel, error) {
+	if z, ok := tp.zettel[zid]; ok {
+		return z, nil
+	}
+	return domain.Zettel{}, place.ErrNotFound
+}
+
+func (tp *testPort) SelectMeta(context.Context, *place.Filter, *place.Sorter) ([]*meta.Meta, error) {
+	result := make([]*meta.Meta, 0, len(tp.zettel))
+	for _, z := range tp.zettel {
+		result = append(result, z.Meta)
+	}
+	return result, nil
+}
+
+func newZettel(zid id.Zid, syntax, content string) domain.Zettel {
+	m := meta.New(zid)
+	m.Set(meta.KeySyntax, syntax)
+	return domain.Zettel{Meta: m, Content: domain.NewContent(content)}
+}
+
+func TestExtractor(t *testing.T) {
+	port := &testPort{zettel: map[id.Zid]domain.Zettel{
+		1: newZettel(1, "png", "Whiteboard\nsketch"),
+		2: newZettel(2, "zmk", "Text"),
+	}}
+	ex := New()
+	ex.Register("png", func(_ context.Context, content []byte) (string, error) {
+		return string(content), nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ex.Start(ctx, port)
+
+	m := port.zettel[1].Meta.Clone()
+	for i := 0; i < 100; i++ {
+		if ex.SetText(m); m.GetDefault(meta.KeyExtractedText, "") != "" {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := m.GetDefault(meta.KeyExtractedText, ""); got != "Whiteboard sketch" {
+		t.Errorf("unexpected extracted text %q", got)
+	}
+	m = port.zettel[2].Meta.Clone()
+	ex.SetText(m)
+	if _, ok := m.Get(meta.KeyExtractedText); ok {
+		t.Error("text extracted from unhandled syntax")
+	}
+}
@@ -0,0 +1,527 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package extract retrieves searchable text from binary zettel.
+package extract
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// PageSeparator separates the text of two pages, like the output of the
+// programs pdftotext and tesseract.
+const PageSeparator = "\f"
+
+// maxStreamSize is the maximum size of a decoded stream of a PDF document.
+const maxStreamSize = 16 << 20
+
+// PDFText extracts the text of all pages of a PDF document. Only text that
+// is drawn by the content streams of the pages is found. Encrypted
+// documents are not supported.
+func PDFText(ctx context.Context, content []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(content, " \t\r\n"), []byte("%PDF-")) {
+		return "", errors.New("not a PDF document")
+	}
+	doc := newPDFDoc(content)
+	if doc.encrypted {
+		return "", errors.New("encrypted PDF documents are not supported")
+	}
+	pages := doc.pages()
+	texts := make([]string, 0, len(pages))
+	for _, page := range pages {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		texts = append(texts, doc.pageText(page))
+	}
+	return strings.Join(texts, PageSeparator), nil
+}
+
+// Values of a PDF document.
+type (
+	pdfName    string
+	pdfString  string
+	pdfKeyword string
+	pdfDict    map[string]interface{}
+	pdfRef     struct{ num, gen int }
+	pdfStream  struct {
+		dict pdfDict
+		data []byte
+	}
+)
+
+type pdfDoc struct {
+	objects   map[int]interface{}
+	fonts     map[int]*pdfFont // fonts that are referenced, by object number
+	encrypted bool
+}
+
+var pdfObjHeader = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+// newPDFDoc reads all objects of a document. The cross-reference table is
+// not used, because it is often damaged. Instead, all objects are searched.
+// A later object shadows an earlier object with the same number.
+func newPDFDoc(data []byte) *pdfDoc {
+	doc := &pdfDoc{objects: make(map[int]interface{}), fonts: make(map[int]*pdfFont)}
+	for pos := 0; pos < len(data); {
+		loc := pdfObjHeader.FindSubmatchIndex(data[pos:])
+		if loc == nil {
+			break
+		}
+		num, err := strconv.Atoi(string(data[pos+loc[2] : pos+loc[3]]))
+		lx := &pdfLexer{data: data, pos: pos + loc[1]}
+		obj := lx.parseValue(lx.next())
+		if err == nil {
+			doc.objects[num] = obj
+		}
+		save := lx.pos
+		if tok := lx.next(); tok.kind == tokKeyword && tok.text == "stream" {
+			dict, _ := obj.(pdfDict)
+			stream := &pdfStream{dict: dict}
+			stream.data, lx.pos = readStreamData(data, lx.pos, dict)
+			if err == nil {
+				doc.objects[num] = stream
+			}
+		} else {
+			lx.pos = save
+		}
+		if lx.pos <= pos {
+			lx.pos = pos + loc[1]
+		}
+		pos = lx.pos
+	}
+	doc.readObjectStreams()
+	doc.encrypted = bytes.Contains(data, []byte("/Encrypt"))
+	return doc
+}
+
+// readStreamData returns the data of a stream that starts after the keyword
+// "stream" at the given position, and the position after the keyword
+// "endstream".
+func readStreamData(data []byte, pos int, dict pdfDict) ([]byte, int) {
+	if pos < len(data) && data[pos] == '\r' {
+		pos++
+	}
+	if pos < len(data) && data[pos] == '\n' {
+		pos++
+	}
+	endstream := []byte("endstream")
+	if length, ok := dict["Length"].(float64); ok {
+		end := pos + int(length)
+		if length >= 0 && end <= len(data) {
+			rest := bytes.TrimLeft(data[end:], " \t\r\n")
+			if bytes.HasPrefix(rest, endstream) {
+				return data[pos:end], len(data) - len(rest) + len(endstream)
+			}
+		}
+	}
+	idx := bytes.Index(data[pos:], endstream)
+	if idx < 0 {
+		return data[pos:], len(data)
+	}
+	return bytes.TrimRight(data[pos:pos+idx], "\r\n"), pos + idx + len(endstream)
+}
+
+// readObjectStreams adds all objects that are stored in object streams.
+func (doc *pdfDoc) readObjectStreams() {
+	var streams []*pdfStream
+	for _, obj := range doc.objects {
+		if stream, ok := obj.(*pdfStream); ok && stream.dict["Type"] == pdfName("ObjStm") {
+			streams = append(streams, stream)
+		}
+	}
+	for _, stream := range streams {
+		data := doc.decode(stream)
+		n, _ := doc.resolve(stream.dict["N"]).(float64)
+		first, _ := doc.resolve(stream.dict["First"]).(float64)
+		if data == nil || n <= 0 || first <= 0 || int(first) > len(data) {
+			continue
+		}
+		header := &pdfLexer{data: data[:int(first)]}
+		for i := 0; i < int(n); i++ {
+			numTok, offTok := header.next(), header.next()
+			if numTok.kind != tokNumber || offTok.kind != tokNumber {
+				break
+			}
+			num, off := int(numTok.num), int(first)+int(offTok.num)
+			if _, ok := doc.objects[num]; ok || off >= len(data) {
+				continue
+			}
+			lx := &pdfLexer{data: data, pos: off}
+			doc.objects[num] = lx.parseValue(lx.next())
+		}
+	}
+}
+
+// resolve returns the object that is referenced by the given value.
+func (doc *pdfDoc) resolve(obj interface{}) interface{} {
+	for i := 0; i < 8; i++ {
+		ref, ok := obj.(pdfRef)
+		if !ok {
+			return obj
+		}
+		obj = doc.objects[ref.num]
+	}
+	return nil
+}
+
+func (doc *pdfDoc) resolveDict(obj interface{}) pdfDict {
+	switch v := doc.resolve(obj).(type) {
+	case pdfDict:
+		return v
+	case *pdfStream:
+		return v.dict
+	}
+	return nil
+}
+
+// decode returns the decoded data of a stream. Only the filter FlateDecode
+// is supported, nil is returned for other filters.
+func (doc *pdfDoc) decode(stream *pdfStream) []byte {
+	var filters []interface{}
+	switch f := doc.resolve(stream.dict["Filter"]).(type) {
+	case pdfName:
+		filters = []interface{}{f}
+	case []interface{}:
+		filters = f
+	}
+	data := stream.data
+	for _, f := range filters {
+		switch doc.resolve(f) {
+		case pdfName("FlateDecode"), pdfName("Fl"):
+			data = inflate(data)
+		default:
+			return nil
+		}
+	}
+	return data
+}
+
+// inflate decompresses data, even if it is damaged at the end.
+func inflate(data []byte) []byte {
+	var r io.Reader
+	if zr, err := zlib.NewReader(bytes.NewReader(data)); err == nil {
+		r = zr
+	} else {
+		r = flate.NewReader(bytes.NewReader(data))
+	}
+	result, _ := ioutil.ReadAll(io.LimitReader(r, maxStreamSize))
+	return result
+}
+
+type pdfPage struct {
+	dict      pdfDict
+	resources pdfDict
+}
+
+// maxPages is the maximum number of pages of a document.
+const maxPages = 10000
+
+// pages returns all pages in their order, found by traversing the page tree.
+func (doc *pdfDoc) pages() []pdfPage {
+	var catalog pdfDict
+	for _, obj := range doc.objects {
+		if dict := doc.resolveDict(obj); dict["Type"] == pdfName("Catalog") {
+			catalog = dict
+			break
+		}
+	}
+	if catalog == nil {
+		return nil
+	}
+	var result []pdfPage
+	visited := make(map[pdfRef]bool)
+	var walk func(node interface{}, resources pdfDict)
+	walk = func(node interface{}, resources pdfDict) {
+		if len(result) >= maxPages {
+			return
+		}
+		if ref, ok := node.(pdfRef); ok {
+			if visited[ref] {
+				return
+			}
+			visited[ref] = true
+		}
+		dict := doc.resolveDict(node)
+		if dict == nil {
+			return
+		}
+		if res := doc.resolveDict(dict["Resources"]); res != nil {
+			resources = res
+		}
+		if kids, ok := doc.resolve(dict["Kids"]).([]interface{}); ok {
+			for _, kid := range kids {
+				walk(kid, resources)
+			}
+			return
+		}
+		result = append(result, pdfPage{dict: dict, resources: resources})
+	}
+	walk(catalog["Pages"], nil)
+	return result
+}
+
+// pageText returns the text of the content streams of the given page.
+func (doc *pdfDoc) pageText(page pdfPage) string {
+	var streams []interface{}
+	switch c := doc.resolve(page.dict["Contents"]).(type) {
+	case *pdfStream:
+		streams = []interface{}{c}
+	case []interface{}:
+		streams = c
+	}
+	var content []byte
+	for _, s := range streams {
+		if stream, ok := doc.resolve(s).(*pdfStream); ok {
+			content = append(content, doc.decode(stream)...)
+			content = append(content, '\n')
+		}
+	}
+	fonts := make(map[string]*pdfFont)
+	for name, ref := range doc.resolveDict(page.resources["Font"]) {
+		fonts[name] = doc.font(ref)
+	}
+	return contentText(content, fonts)
+}
+
+// contentText interprets the text operators of a content stream.
+func contentText(content []byte, fonts map[string]*pdfFont) string {
+	var sb strings.Builder
+	var font *pdfFont
+	var operands []interface{}
+	lx := &pdfLexer{data: content}
+	for {
+		tok := lx.next()
+		switch tok.kind {
+		case tokEOF:
+			return sb.String()
+		case tokKeyword:
+		default:
+			operands = append(operands, lx.parseValue(tok))
+			continue
+		}
+		var last interface{}
+		if len(operands) > 0 {
+			last = operands[len(operands)-1]
+		}
+		switch tok.text {
+		case "true", "false", "null":
+			operands = append(operands, nil)
+			continue
+		case "Tf":
+			if len(operands) >= 2 {
+				if name, ok := operands[len(operands)-2].(pdfName); ok {
+					font = fonts[string(name)]
+				}
+			}
+		case "Tj":
+			font.write(&sb, last)
+		case "'", "\"":
+			sb.WriteByte('\n')
+			font.write(&sb, last)
+		case "TJ":
+			elems, _ := last.([]interface{})
+			for _, elem := range elems {
+				if n, ok := elem.(float64); ok {
+					if n < -200 {
+						sb.WriteByte(' ')
+					}
+				} else {
+					font.write(&sb, elem)
+				}
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				if ty, ok := operands[len(operands)-1].(float64); ok && ty != 0 {
+					sb.WriteByte('\n')
+					break
+				}
+			}
+			sb.WriteByte(' ')
+		case "T*":
+			sb.WriteByte('\n')
+		case "Tm", "ET":
+			sb.WriteByte(' ')
+		case "ID":
+			lx.skipInlineImage()
+		}
+		operands = operands[:0]
+	}
+}
+
+// pdfFont maps the codes of strings to text.
+type pdfFont struct {
+	codeLen int               // Number of bytes per code
+	toUni   map[uint32]string // Text of each code, may be nil
+}
+
+// font returns the font described by the given font dictionary.
+func (doc *pdfDoc) font(obj interface{}) *pdfFont {
+	ref, isRef := obj.(pdfRef)
+	if isRef {
+		if f, ok := doc.fonts[ref.num]; ok {
+			return f
+		}
+	}
+	f := &pdfFont{codeLen: 1}
+	dict := doc.resolveDict(obj)
+	if enc, ok := doc.resolve(dict["Encoding"]).(pdfName); ok && strings.HasPrefix(string(enc), "Identity-") {
+		f.codeLen = 2
+	}
+	if stream, ok := doc.resolve(dict["ToUnicode"]).(*pdfStream); ok {
+		var codeLen int
+		f.toUni, codeLen = parseCMap(doc.decode(stream))
+		if codeLen > 0 {
+			f.codeLen = codeLen
+		}
+	}
+	if isRef {
+		doc.fonts[ref.num] = f
+	}
+	return f
+}
+
+// write appends the text of a string value to the builder.
+func (f *pdfFont) write(sb *strings.Builder, obj interface{}) {
+	s, ok := obj.(pdfString)
+	if !ok {
+		return
+	}
+	if f != nil && f.toUni != nil {
+		n := f.codeLen
+		for i := 0; i+n <= len(s); i += n {
+			code := codeValue(string(s[i : i+n]))
+			if text, found := f.toUni[code]; found {
+				sb.WriteString(text)
+			} else if n == 1 {
+				writeLatin1(sb, s[i])
+			}
+		}
+		return
+	}
+	if f != nil && f.codeLen > 1 {
+		return // Codes cannot be mapped to text
+	}
+	if strings.HasPrefix(string(s), "\xfe\xff") {
+		sb.WriteString(decodeUTF16(string(s[2:])))
+		return
+	}
+	for i := 0; i < len(s); i++ {
+		writeLatin1(sb, s[i])
+	}
+}
+
+func writeLatin1(sb *strings.Builder, b byte) {
+	if b >= 0x20 && (b < 0x7f || b >= 0xa0) {
+		sb.WriteRune(rune(b))
+	} else if b == '\t' || b == '\n' || b == '\r' {
+		sb.WriteByte(' ')
+	}
+}
+
+func codeValue(s string) uint32 {
+	var code uint32
+	for i := 0; i < len(s); i++ {
+		code = code<<8 | uint32(s[i])
+	}
+	return code
+}
+
+func decodeUTF16(s string) string {
+	units := make([]uint16, 0, len(s)/2)
+	for i := 0; i+1 < len(s); i += 2 {
+		units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// maxRangeSize is the maximum number of codes of a range in a CMap.
+const maxRangeSize = 1 << 16
+
+// parseCMap parses a ToUnicode CMap. It returns the text of each code and
+// the number of bytes per code, or zero if this number is not specified.
+func parseCMap(data []byte) (map[uint32]string, int) {
+	result := make(map[uint32]string)
+	codeLen := 0
+	lx := &pdfLexer{data: data}
+	for {
+		tok := lx.next()
+		switch {
+		case tok.kind == tokEOF:
+			return result, codeLen
+		case tok.kind != tokKeyword:
+			continue
+		}
+		switch tok.text {
+		case "begincodespacerange":
+			if lo := lx.next(); lo.kind == tokString && codeLen == 0 {
+				codeLen = len(lo.text)
+			}
+		case "beginbfchar":
+			for {
+				src := lx.next()
+				if src.kind != tokString {
+					break
+				}
+				if dst := lx.next(); dst.kind == tokString {
+					result[codeValue(src.text)] = decodeUTF16(dst.text)
+				}
+			}
+		case "beginbfrange":
+			for {
+				lo := lx.next()
+				if lo.kind != tokString {
+					break
+				}
+				hi := lx.next()
+				dst := lx.parseValue(lx.next())
+				loCode, hiCode := codeValue(lo.text), codeValue(hi.text)
+				if hiCode < loCode || hiCode-loCode >= maxRangeSize {
+					continue
+				}
+				for code := loCode; code <= hiCode; code++ {
+					delta := code - loCode
+					switch d := dst.(type) {
+					case pdfString:
+						result[code] = incrementUTF16(string(d), delta)
+					case []interface{}:
+						if int(delta) < len(d) {
+							if s, ok := d[delta].(pdfString); ok {
+								result[code] = decodeUTF16(string(s))
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// incrementUTF16 adds delta to the last code unit of the UTF-16 string.
+func incrementUTF16(s string, delta uint32) string {
+	if len(s) < 2 {
+		return ""
+	}
+	b := []byte(s)
+	last := uint32(b[len(b)-2])<<8 | uint32(b[len(b)-1])
+	last += delta
+	b[len(b)-2], b[len(b)-1] = byte(last>>8), byte(last)
+	return decodeUTF16(string(b))
+}
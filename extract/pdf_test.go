@@ -0,0 +1,77 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package extract retrieves searchable text from binary zettel.
+package extract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func compressed(s string) string {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write([]byte(s))
+	zw.Close()
+	return buf.String()
+}
+
+func stream(dict, data string) string {
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+}
+
+func makePDF(objects ...string) []byte {
+	var sb strings.Builder
+	sb.WriteString("%PDF-1.4\n")
+	for i, obj := range objects {
+		fmt.Fprintf(&sb, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	sb.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return []byte(sb.String())
+}
+
+func TestPDFText(t *testing.T) {
+	cmap := "/CIDInit /ProcSet findresource begin begincmap\n" +
+		"1 begincodespacerange <0000> <FFFF> endcodespacerange\n" +
+		"1 beginbfchar <0001> <0048> endbfchar\n" +
+		"1 beginbfrange <0002> <0003> <0069> endbfrange\n" +
+		"endcmap"
+	content1 := "BT /F1 12 Tf 72 700 Td (Hello \\(World\\)) Tj 0 -14 Td [(Sec)-10(ond)-500(line)] TJ ET"
+	content2 := "BT /F2 12 Tf <000100020003> Tj ET BI /W 1 /H 1 ID \x00(x EI Q"
+	doc := makePDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /Resources << /Font << /F1 7 0 R >> >> >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 5 0 R >>",
+		"<< /Type /Page /Parent 2 0 R /Contents [6 0 R] /Resources << /Font << /F2 8 0 R >> >> >>",
+		stream("", content1),
+		stream("/Filter /FlateDecode", compressed(content2)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"<< /Type /Font /Subtype /Type0 /Encoding /Identity-H /ToUnicode 9 0 R >>",
+		stream("/Filter [/FlateDecode]", compressed(cmap)),
+	)
+	text, err := PDFText(context.Background(), doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pages := Normalize(text)
+	exp := []string{"Hello (World) Second line", "Hij"}
+	if len(pages) != len(exp) || pages[0] != exp[0] || pages[1] != exp[1] {
+		t.Errorf("expected %q, but got %q", exp, pages)
+	}
+
+	if _, err = PDFText(context.Background(), []byte("no pdf")); err == nil {
+		t.Error("expected an error for a document that is no PDF")
+	}
+}
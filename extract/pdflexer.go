@@ -0,0 +1,311 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package extract retrieves searchable text from binary zettel.
+package extract
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokNumber
+	tokName
+	tokString
+	tokKeyword
+	tokDictStart
+	tokDictEnd
+	tokArrayStart
+	tokArrayEnd
+)
+
+type pdfToken struct {
+	kind tokKind
+	num  float64
+	text string // name without "/", decoded string, or keyword
+}
+
+// pdfLexer splits PDF data into tokens.
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func isPDFSpace(ch byte) bool {
+	return ch == ' ' || ch == '\n' || ch == '\r' || ch == '\t' || ch == '\f' || ch == 0
+}
+
+func isPDFDelimiter(ch byte) bool {
+	return strings.IndexByte("()<>[]{}/%", ch) >= 0
+}
+
+// maxNesting is the maximum nesting depth of arrays and dictionaries.
+const maxNesting = 32
+
+func (lx *pdfLexer) next() pdfToken {
+	for lx.pos < len(lx.data) {
+		ch := lx.data[lx.pos]
+		switch {
+		case isPDFSpace(ch):
+			lx.pos++
+		case ch == '%':
+			for lx.pos < len(lx.data) && lx.data[lx.pos] != '\n' && lx.data[lx.pos] != '\r' {
+				lx.pos++
+			}
+		case ch == '(':
+			lx.pos++
+			return pdfToken{kind: tokString, text: lx.readLiteral()}
+		case ch == '<':
+			if lx.pos+1 < len(lx.data) && lx.data[lx.pos+1] == '<' {
+				lx.pos += 2
+				return pdfToken{kind: tokDictStart}
+			}
+			lx.pos++
+			return pdfToken{kind: tokString, text: lx.readHex()}
+		case ch == '>':
+			lx.pos++
+			if lx.pos < len(lx.data) && lx.data[lx.pos] == '>' {
+				lx.pos++
+				return pdfToken{kind: tokDictEnd}
+			}
+		case ch == '[':
+			lx.pos++
+			return pdfToken{kind: tokArrayStart}
+		case ch == ']':
+			lx.pos++
+			return pdfToken{kind: tokArrayEnd}
+		case ch == '/':
+			lx.pos++
+			return pdfToken{kind: tokName, text: lx.readName()}
+		case ch == '{' || ch == '}' || ch == ')':
+			lx.pos++
+		default:
+			start := lx.pos
+			for lx.pos < len(lx.data) && !isPDFSpace(lx.data[lx.pos]) && !isPDFDelimiter(lx.data[lx.pos]) {
+				lx.pos++
+			}
+			word := string(lx.data[start:lx.pos])
+			if num, err := strconv.ParseFloat(word, 64); err == nil && isPDFNumber(word) {
+				return pdfToken{kind: tokNumber, num: num}
+			}
+			return pdfToken{kind: tokKeyword, text: word}
+		}
+	}
+	return pdfToken{kind: tokEOF}
+}
+
+// isPDFNumber excludes values like "Inf" or "1e5" that are accepted by
+// strconv.ParseFloat, but are no numbers in PDF.
+func isPDFNumber(word string) bool {
+	for i := 0; i < len(word); i++ {
+		if ch := word[i]; !('0' <= ch && ch <= '9') && ch != '.' && ch != '-' && ch != '+' {
+			return false
+		}
+	}
+	return true
+}
+
+func (lx *pdfLexer) readLiteral() string {
+	var sb strings.Builder
+	depth := 1
+	for lx.pos < len(lx.data) {
+		ch := lx.data[lx.pos]
+		lx.pos++
+		switch ch {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return sb.String()
+			}
+		case '\\':
+			if lx.pos >= len(lx.data) {
+				return sb.String()
+			}
+			ch = lx.data[lx.pos]
+			lx.pos++
+			switch ch {
+			case 'n':
+				ch = '\n'
+			case 'r':
+				ch = '\r'
+			case 't':
+				ch = '\t'
+			case 'b':
+				ch = '\b'
+			case 'f':
+				ch = '\f'
+			case '\r':
+				if lx.pos < len(lx.data) && lx.data[lx.pos] == '\n' {
+					lx.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if '0' <= ch && ch <= '7' {
+					val := int(ch - '0')
+					for i := 0; i < 2 && lx.pos < len(lx.data); i++ {
+						d := lx.data[lx.pos]
+						if d < '0' || d > '7' {
+							break
+						}
+						val = val*8 + int(d-'0')
+						lx.pos++
+					}
+					ch = byte(val)
+				}
+			}
+		}
+		sb.WriteByte(ch)
+	}
+	return sb.String()
+}
+
+func (lx *pdfLexer) readHex() string {
+	var sb strings.Builder
+	var val byte
+	odd := false
+	for lx.pos < len(lx.data) {
+		ch := lx.data[lx.pos]
+		lx.pos++
+		var d byte
+		switch {
+		case ch == '>':
+			if odd {
+				sb.WriteByte(val << 4)
+			}
+			return sb.String()
+		case '0' <= ch && ch <= '9':
+			d = ch - '0'
+		case 'a' <= ch && ch <= 'f':
+			d = ch - 'a' + 10
+		case 'A' <= ch && ch <= 'F':
+			d = ch - 'A' + 10
+		default:
+			continue
+		}
+		if odd {
+			sb.WriteByte(val<<4 | d)
+		} else {
+			val = d
+		}
+		odd = !odd
+	}
+	return sb.String()
+}
+
+func (lx *pdfLexer) readName() string {
+	var sb strings.Builder
+	for lx.pos < len(lx.data) {
+		ch := lx.data[lx.pos]
+		if isPDFSpace(ch) || isPDFDelimiter(ch) {
+			break
+		}
+		lx.pos++
+		if ch == '#' && lx.pos+1 < len(lx.data) {
+			if val, err := strconv.ParseUint(string(lx.data[lx.pos:lx.pos+2]), 16, 8); err == nil {
+				ch = byte(val)
+				lx.pos += 2
+			}
+		}
+		sb.WriteByte(ch)
+	}
+	return sb.String()
+}
+
+// skipInlineImage skips the data of an inline image, which is terminated by
+// the keyword "EI".
+func (lx *pdfLexer) skipInlineImage() {
+	for {
+		idx := bytes.Index(lx.data[lx.pos:], []byte("EI"))
+		if idx < 0 {
+			lx.pos = len(lx.data)
+			return
+		}
+		end := lx.pos + idx + 2
+		if (idx == 0 || isPDFSpace(lx.data[lx.pos+idx-1])) &&
+			(end == len(lx.data) || isPDFSpace(lx.data[end])) {
+			lx.pos = end
+			return
+		}
+		lx.pos += idx + 2
+	}
+}
+
+// parseValue returns the value that starts with the given token. Numbers
+// followed by a generation number and the keyword "R" are references.
+func (lx *pdfLexer) parseValue(tok pdfToken) interface{} {
+	return lx.parseNested(tok, 0)
+}
+
+func (lx *pdfLexer) parseNested(tok pdfToken, depth int) interface{} {
+	switch tok.kind {
+	case tokNumber:
+		save := lx.pos
+		if gen := lx.next(); gen.kind == tokNumber && gen.num == float64(int(gen.num)) {
+			if r := lx.next(); r.kind == tokKeyword && r.text == "R" {
+				return pdfRef{num: int(tok.num), gen: int(gen.num)}
+			}
+		}
+		lx.pos = save
+		return tok.num
+	case tokName:
+		return pdfName(tok.text)
+	case tokString:
+		return pdfString(tok.text)
+	case tokKeyword:
+		switch tok.text {
+		case "true":
+			return true
+		case "false":
+			return false
+		case "null":
+			return nil
+		}
+		return pdfKeyword(tok.text)
+	case tokDictStart:
+		dict := pdfDict{}
+		for {
+			key := lx.next()
+			if key.kind == tokDictEnd || key.kind == tokEOF {
+				return dict
+			}
+			if key.kind != tokName {
+				continue
+			}
+			val := lx.next()
+			if val.kind == tokDictEnd {
+				return dict
+			}
+			if depth < maxNesting {
+				dict[key.text] = lx.parseNested(val, depth+1)
+			}
+		}
+	case tokArrayStart:
+		var arr []interface{}
+		for {
+			elem := lx.next()
+			if elem.kind == tokArrayEnd || elem.kind == tokEOF {
+				return arr
+			}
+			if depth < maxNesting {
+				arr = append(arr, lx.parseNested(elem, depth+1))
+			}
+		}
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ module zettelstore.de/z
 go 1.15
 
 require (
+	fyne.io/systray v1.11.0
 	github.com/fsnotify/fsnotify v1.4.9
 	github.com/pascaldekloe/jwt v1.10.0
 	github.com/yuin/goldmark v1.3.0
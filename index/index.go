@@ -0,0 +1,383 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package index maintains aggregated data about all zettel of a place, so
+// that it must not be computed on every request.
+package index
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"zettelstore.de/z/ast"
+	"zettelstore.de/z/collect"
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/parser"
+	"zettelstore.de/z/place"
+)
+
+// Port is the interface of the place that is indexed.
+type Port interface {
+	// RegisterChangeObserver registers an observer that will be notified
+	// if one or all zettel are found to be changed.
+	RegisterChangeObserver(place.ObserverFunc)
+
+	// GetZettel retrieves a specific zettel.
+	GetZettel(ctx context.Context, zid id.Zid) (domain.Zettel, error)
+
+	// GetMeta retrieves just the meta data of a specific zettel.
+	GetMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error)
+
+	// SelectMeta returns all zettel meta data that match the selection
+	// criteria. The result is ordered by descending zettel id.
+	SelectMeta(ctx context.Context, f *place.Filter, s *place.Sorter) ([]*meta.Meta, error)
+}
+
+// Indexer maintains the meta data of all zettel, together with the zettel
+// per tag, per role, and the zettel that link to a zettel.
+//
+// Change notifications are only recorded. The index is updated when it is
+// used the next time, because a place must not be called from within a
+// change observer.
+type Indexer struct {
+	port Port
+
+	mxState  sync.Mutex      // protects valid, pending, and the rebuild state
+	valid    bool            // all zettel were indexed
+	pending  map[id.Zid]bool // zettel that changed since the last update
+	progress Progress        // state of the last rebuild
+	changed  map[id.Zid]bool // zettel that changed while rebuilding
+	reloaded bool            // all zettel changed while rebuilding
+
+	mx        sync.Mutex // protects the index data
+	metas     map[id.Zid]*meta.Meta
+	tags      map[string]zidSet
+	roles     map[string]zidSet
+	links     map[id.Zid][]Link // zettel referenced by a zettel
+	backlinks map[id.Zid]zidSet // zettel that reference a zettel
+	sizes     map[id.Zid]int64  // size of the content of a zettel
+
+	// collectLinks returns all links of the given zettel to other zettel.
+	collectLinks func(domain.Zettel) []Link
+}
+
+type zidSet map[id.Zid]bool
+
+// Link is a reference from one zettel to another zettel. Rel is the type of
+// the relation, given by the attribute "rel" of the link. It is empty for
+// untyped links.
+type Link struct {
+	Zid id.Zid
+	Rel string
+}
+
+// New creates a new indexer for the given place.
+func New(port Port) *Indexer {
+	idx := &Indexer{port: port, collectLinks: collectLinks}
+	port.RegisterChangeObserver(idx.observe)
+	return idx
+}
+
+func (idx *Indexer) observe(reason place.ChangeReason, zid id.Zid) {
+	idx.mxState.Lock()
+	if idx.progress.Running {
+		if reason == place.OnReload || !zid.IsValid() {
+			idx.reloaded = true
+		} else {
+			idx.changed[zid] = true
+		}
+	}
+	if reason == place.OnReload || !zid.IsValid() {
+		idx.valid = false
+		idx.pending = nil
+	} else if idx.valid {
+		if idx.pending == nil {
+			idx.pending = make(map[id.Zid]bool)
+		}
+		idx.pending[zid] = true
+	}
+	idx.mxState.Unlock()
+}
+
+// update brings the index up to date. It must be called with a locked mx.
+func (idx *Indexer) update(ctx context.Context) error {
+	idx.mxState.Lock()
+	if idx.progress.Running && idx.metas != nil {
+		// Use the previous data until the rebuild is finished.
+		idx.mxState.Unlock()
+		return nil
+	}
+	valid, pending := idx.valid, idx.pending
+	idx.valid, idx.pending = true, nil
+	idx.mxState.Unlock()
+
+	if !valid {
+		metaList, err := idx.port.SelectMeta(ctx, nil, nil)
+		if err != nil {
+			idx.mxState.Lock()
+			idx.valid = false
+			idx.mxState.Unlock()
+			return err
+		}
+		idx.reset(len(metaList))
+		for _, m := range metaList {
+			idx.add(ctx, m)
+		}
+		return nil
+	}
+	for zid := range pending {
+		idx.remove(zid)
+		if m, err := idx.port.GetMeta(ctx, zid); err == nil {
+			idx.add(ctx, m)
+		}
+	}
+	return nil
+}
+
+// reset removes all index data.
+func (idx *Indexer) reset(size int) {
+	idx.metas = make(map[id.Zid]*meta.Meta, size)
+	idx.tags = make(map[string]zidSet)
+	idx.roles = make(map[string]zidSet)
+	idx.links = make(map[id.Zid][]Link)
+	idx.backlinks = make(map[id.Zid]zidSet)
+	idx.sizes = make(map[id.Zid]int64, size)
+}
+
+func (idx *Indexer) add(ctx context.Context, m *meta.Meta) {
+	idx.metas[m.Zid] = m
+	if zettel, err := idx.port.GetZettel(ctx, m.Zid); err == nil {
+		links := idx.collectLinks(zettel)
+		for _, link := range links {
+			sources, ok := idx.backlinks[link.Zid]
+			if !ok {
+				sources = make(zidSet)
+				idx.backlinks[link.Zid] = sources
+			}
+			sources[m.Zid] = true
+		}
+		idx.links[m.Zid] = links
+		idx.sizes[m.Zid] = int64(len(zettel.Content.AsString()))
+	}
+	if tags, ok := m.GetList(meta.KeyTags); ok {
+		for _, tag := range tags {
+			addToSet(idx.tags, tag, m.Zid)
+		}
+	}
+	if role, ok := m.Get(meta.KeyRole); ok && role != "" {
+		addToSet(idx.roles, role, m.Zid)
+	}
+}
+
+func (idx *Indexer) remove(zid id.Zid) {
+	m, ok := idx.metas[zid]
+	if !ok {
+		return
+	}
+	delete(idx.metas, zid)
+	if tags, ok := m.GetList(meta.KeyTags); ok {
+		for _, tag := range tags {
+			removeFromSet(idx.tags, tag, zid)
+		}
+	}
+	if role, ok := m.Get(meta.KeyRole); ok {
+		removeFromSet(idx.roles, role, zid)
+	}
+	for _, link := range idx.links[zid] {
+		if sources, ok := idx.backlinks[link.Zid]; ok {
+			delete(sources, zid)
+			if len(sources) == 0 {
+				delete(idx.backlinks, link.Zid)
+			}
+		}
+	}
+	delete(idx.links, zid)
+	delete(idx.sizes, zid)
+}
+
+// collectLinks returns all zettel that are referenced by a link or an image
+// of the given zettel, together with the relation type of the link. A
+// precursor is referenced with the relation type "precursor".
+func collectLinks(zettel domain.Zettel) []Link {
+	var result []Link
+	if precursors, ok := zettel.Meta.GetList(meta.KeyPrecursor); ok {
+		for _, val := range precursors {
+			if zid, err := id.Parse(val); err == nil && zid != zettel.Meta.Zid {
+				result = append(result, Link{Zid: zid, Rel: meta.KeyPrecursor})
+			}
+		}
+	}
+	if zettel.Content.IsBinary() {
+		return result
+	}
+	summary := collect.References(parser.ParseZettel(zettel, ""))
+	rels := make(map[*ast.Reference]string, len(summary.Relations))
+	for _, rel := range summary.Relations {
+		rels[rel.Ref] = rel.Rel
+	}
+	for _, refs := range [][]*ast.Reference{summary.Links, summary.Images} {
+		for _, ref := range refs {
+			if ref.State != ast.RefStateZettel {
+				continue
+			}
+			if zid, err := id.Parse(ref.URL.Path); err == nil && zid != zettel.Meta.Zid {
+				result = append(result, Link{Zid: zid, Rel: rels[ref]})
+			}
+		}
+	}
+	return result
+}
+
+func addToSet(sets map[string]zidSet, key string, zid id.Zid) {
+	set, ok := sets[key]
+	if !ok {
+		set = make(zidSet)
+		sets[key] = set
+	}
+	set[zid] = true
+}
+
+func removeFromSet(sets map[string]zidSet, key string, zid id.Zid) {
+	if set, ok := sets[key]; ok {
+		delete(set, zid)
+		if len(set) == 0 {
+			delete(sets, key)
+		}
+	}
+}
+
+// CreatorUsage returns the number and the total content size of all zettel
+// that were created by the given user, except the given zettel. All zettel
+// are counted, regardless of any policy.
+func (idx *Indexer) CreatorUsage(ctx context.Context, creator, except id.Zid) (int, int64, error) {
+	idx.mx.Lock()
+	defer idx.mx.Unlock()
+	if err := idx.update(ctx); err != nil {
+		return 0, 0, err
+	}
+	val := creator.String()
+	count, size := 0, int64(0)
+	for zid, m := range idx.metas {
+		if c, ok := m.Get(meta.KeyCreator); ok && c == val && zid != except {
+			count++
+			size += idx.sizes[zid]
+		}
+	}
+	return count, size, nil
+}
+
+// SelectFunc decides, whether the given meta data is visible within the
+// given context.
+type SelectFunc func(context.Context, *meta.Meta) bool
+
+// View is a view on the index that contains only selected zettel.
+type View struct {
+	idx *Indexer
+	sel SelectFunc
+}
+
+// NewView creates a new view, where only zettel are visible that are
+// selected by the given function.
+func (idx *Indexer) NewView(sel SelectFunc) *View {
+	return &View{idx: idx, sel: sel}
+}
+
+// SelectTags returns all tags, together with the meta data of all visible
+// zettel that use the tag. The meta data is ordered by descending zettel id.
+func (v *View) SelectTags(ctx context.Context) (map[string][]*meta.Meta, error) {
+	return v.selectSets(ctx, func(idx *Indexer) map[string]zidSet { return idx.tags })
+}
+
+// SelectRoles returns all roles, together with the meta data of all visible
+// zettel that have the role. The meta data is ordered by descending zettel id.
+func (v *View) SelectRoles(ctx context.Context) (map[string][]*meta.Meta, error) {
+	return v.selectSets(ctx, func(idx *Indexer) map[string]zidSet { return idx.roles })
+}
+
+func (v *View) selectSets(
+	ctx context.Context, getSets func(*Indexer) map[string]zidSet) (map[string][]*meta.Meta, error) {
+	idx := v.idx
+	idx.mx.Lock()
+	defer idx.mx.Unlock()
+	if err := idx.update(ctx); err != nil {
+		return nil, err
+	}
+	sets := getSets(idx)
+	result := make(map[string][]*meta.Meta, len(sets))
+	for key, set := range sets {
+		var metaList []*meta.Meta
+		for zid := range set {
+			if m := idx.metas[zid]; v.sel == nil || v.sel(ctx, m) {
+				metaList = append(metaList, m)
+			}
+		}
+		if len(metaList) == 0 {
+			continue
+		}
+		sort.Slice(metaList, func(i, j int) bool { return metaList[i].Zid > metaList[j].Zid })
+		result[key] = metaList
+	}
+	return result, nil
+}
+
+// SelectBacklinks returns the meta data of all visible zettel that link to
+// the zettel with the given identifier, ordered by descending zettel id.
+func (v *View) SelectBacklinks(ctx context.Context, zid id.Zid) ([]*meta.Meta, error) {
+	idx := v.idx
+	idx.mx.Lock()
+	defer idx.mx.Unlock()
+	if err := idx.update(ctx); err != nil {
+		return nil, err
+	}
+	var result []*meta.Meta
+	for source := range idx.backlinks[zid] {
+		if m := idx.metas[source]; v.sel == nil || v.sel(ctx, m) {
+			result = append(result, m)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Zid > result[j].Zid })
+	return result, nil
+}
+
+// SelectRelations returns the meta data of all visible zettel that link to
+// the zettel with the given identifier, grouped by the relation type of the
+// link. Untyped links are stored under the empty relation. A zettel may be
+// listed under more than one relation. The meta data is ordered by
+// descending zettel id.
+func (v *View) SelectRelations(ctx context.Context, zid id.Zid) (map[string][]*meta.Meta, error) {
+	idx := v.idx
+	idx.mx.Lock()
+	defer idx.mx.Unlock()
+	if err := idx.update(ctx); err != nil {
+		return nil, err
+	}
+	result := make(map[string][]*meta.Meta)
+	for source := range idx.backlinks[zid] {
+		m := idx.metas[source]
+		if v.sel != nil && !v.sel(ctx, m) {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, link := range idx.links[source] {
+			if link.Zid == zid && !seen[link.Rel] {
+				seen[link.Rel] = true
+				result[link.Rel] = append(result[link.Rel], m)
+			}
+		}
+	}
+	for _, metaList := range result {
+		ml := metaList
+		sort.Slice(ml, func(i, j int) bool { return ml[i].Zid > ml[j].Zid })
+	}
+	return result, nil
+}
@@ -0,0 +1,260 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package index maintains aggregated data about all zettel of a place, so
+// that it must not be computed on every request.
+package index
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/place"
+)
+
+type testPort struct {
+	metas    map[id.Zid]*meta.Meta
+	contents map[id.Zid]string
+	observer place.ObserverFunc
+	selects  int
+}
+
+func (tp *testPort) RegisterChangeObserver(f place.ObserverFunc) { tp.observer = f }
+
+func (tp *testPort) GetZettel(ctx context.Context, zid id.Zid) (domain.Zettel, error) {
+	if m, ok := tp.metas[zid]; ok {
+		return domain.Zettel{Meta: m, Content: domain.NewContent(tp.contents[zid])}, nil
+	}
+	return domain.Zettel{}, place.ErrNotFound
+}
+
+func (tp *testPort) GetMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error) {
+	if m, ok := tp.metas[zid]; ok {
+		return m, nil
+	}
+	return nil, place.ErrNotFound
+}
+
+func (tp *testPort) SelectMeta(
+	ctx context.Context, f *place.Filter, s *place.Sorter) ([]*meta.Meta, error) {
+	tp.selects++
+	result := make([]*meta.Meta, 0, len(tp.metas))
+	for _, m := range tp.metas {
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+func (tp *testPort) set(zid id.Zid, role, tags string) {
+	m := meta.New(zid)
+	m.Set(meta.KeyRole, role)
+	m.Set(meta.KeyTags, tags)
+	tp.metas[zid] = m
+}
+
+func countTags(t *testing.T, v *View) map[string]int {
+	t.Helper()
+	tags, err := v.SelectTags(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := make(map[string]int, len(tags))
+	for tag, ml := range tags {
+		result[tag] = len(ml)
+	}
+	return result
+}
+
+func checkCounts(t *testing.T, got, exp map[string]int) {
+	t.Helper()
+	if len(got) != len(exp) {
+		t.Errorf("expected %v, but got %v", exp, got)
+		return
+	}
+	for key, cnt := range exp {
+		if got[key] != cnt {
+			t.Errorf("expected %v, but got %v", exp, got)
+			return
+		}
+	}
+}
+
+func TestIndexer(t *testing.T) {
+	tp := &testPort{metas: make(map[id.Zid]*meta.Meta)}
+	tp.set(1, "zettel", "#a #b")
+	tp.set(2, "zettel", "#a")
+	tp.set(3, "note", "#c")
+	idx := New(tp)
+	idx.collectLinks = testLinks
+	v := idx.NewView(nil)
+
+	checkCounts(t, countTags(t, v), map[string]int{"#a": 2, "#b": 1, "#c": 1})
+	tp.set(2, "note", "#b")
+	tp.observer(place.OnUpdate, 2)
+	delete(tp.metas, 3)
+	tp.observer(place.OnDelete, 3)
+	checkCounts(t, countTags(t, v), map[string]int{"#a": 1, "#b": 2})
+	roles, err := v.SelectRoles(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roles) != 2 || len(roles["zettel"]) != 1 || len(roles["note"]) != 1 {
+		t.Errorf("unexpected roles: %v", roles)
+	}
+	if tp.selects != 1 {
+		t.Errorf("expected one full scan, but got %d", tp.selects)
+	}
+
+	tp.observer(place.OnReload, id.Invalid)
+	checkCounts(t, countTags(t, v), map[string]int{"#a": 1, "#b": 2})
+	if tp.selects != 2 {
+		t.Errorf("expected two full scans, but got %d", tp.selects)
+	}
+
+	v = v.idx.NewView(func(ctx context.Context, m *meta.Meta) bool { return m.Zid != 1 })
+	checkCounts(t, countTags(t, v), map[string]int{"#b": 1})
+}
+
+// testLinks interprets the meta value "links" as the zettel referenced by a
+// zettel. A relation type may follow the zettel identifier after a colon.
+func testLinks(zettel domain.Zettel) []Link {
+	var result []Link
+	if links, ok := zettel.Meta.GetList("links"); ok {
+		for _, link := range links {
+			var rel string
+			if pos := strings.IndexByte(link, ':'); pos >= 0 {
+				link, rel = link[:pos], link[pos+1:]
+			}
+			if zid, err := id.Parse(link); err == nil {
+				result = append(result, Link{Zid: zid, Rel: rel})
+			}
+		}
+	}
+	return result
+}
+
+func TestBacklinks(t *testing.T) {
+	tp := &testPort{metas: make(map[id.Zid]*meta.Meta)}
+	tp.set(1, "zettel", "")
+	tp.set(2, "zettel", "")
+	tp.metas[2].Set("links", "00000000000001")
+	tp.set(3, "zettel", "")
+	tp.metas[3].Set("links", "00000000000001:contradicts 00000000000002")
+	idx := New(tp)
+	idx.collectLinks = testLinks
+	v := idx.NewView(nil)
+
+	checkBacklinks := func(zid id.Zid, exp ...id.Zid) {
+		t.Helper()
+		ml, err := v.SelectBacklinks(context.Background(), zid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make([]id.Zid, 0, len(ml))
+		for _, m := range ml {
+			got = append(got, m.Zid)
+		}
+		if len(got) != len(exp) {
+			t.Errorf("backlinks of %v: expected %v, but got %v", zid, exp, got)
+			return
+		}
+		for i := range got {
+			if got[i] != exp[i] {
+				t.Errorf("backlinks of %v: expected %v, but got %v", zid, exp, got)
+				return
+			}
+		}
+	}
+	checkBacklinks(1, 3, 2)
+	checkBacklinks(2, 3)
+	checkBacklinks(3)
+	rels, err := v.SelectRelations(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rels) != 2 || len(rels[""]) != 1 || rels[""][0].Zid != 2 ||
+		len(rels["contradicts"]) != 1 || rels["contradicts"][0].Zid != 3 {
+		t.Errorf("unexpected relations of 1: %v", rels)
+	}
+
+	tp.metas[3].Delete("links")
+	tp.observer(place.OnUpdate, 3)
+	delete(tp.metas, 2)
+	tp.observer(place.OnDelete, 2)
+	checkBacklinks(1)
+	checkBacklinks(2)
+}
+
+func TestRebuild(t *testing.T) {
+	tp := &testPort{metas: make(map[id.Zid]*meta.Meta)}
+	tp.set(1, "zettel", "#a")
+	idx := New(tp)
+	idx.collectLinks = testLinks
+	v := idx.NewView(nil)
+	checkCounts(t, countTags(t, v), map[string]int{"#a": 1})
+
+	// Changed outside, without any notification.
+	tp.set(2, "zettel", "#b")
+	checkCounts(t, countTags(t, v), map[string]int{"#a": 1})
+
+	if !idx.Rebuild() {
+		t.Fatal("rebuild was not started")
+	}
+	for idx.Progress().Running {
+		time.Sleep(time.Millisecond)
+	}
+	p := idx.Progress()
+	if p.Err != nil || p.Indexed != 2 || p.Total != 2 || p.Finished.Before(p.Started) {
+		t.Errorf("unexpected progress: %+v", p)
+	}
+	checkCounts(t, countTags(t, v), map[string]int{"#a": 1, "#b": 1})
+	if tp.selects != 2 {
+		t.Errorf("expected two full scans, but got %d", tp.selects)
+	}
+}
+
+func TestCreatorUsage(t *testing.T) {
+	tp := &testPort{metas: make(map[id.Zid]*meta.Meta), contents: make(map[id.Zid]string)}
+	for zid, creator := range map[id.Zid]id.Zid{1: 7, 2: 7, 3: 8, 4: id.Invalid} {
+		tp.set(zid, "zettel", "")
+		if creator.IsValid() {
+			tp.metas[zid].Set(meta.KeyCreator, creator.String())
+		}
+		tp.contents[zid] = strings.Repeat("x", int(zid))
+	}
+	idx := New(tp)
+	idx.collectLinks = testLinks
+	ctx := context.Background()
+	if count, size, err := idx.CreatorUsage(ctx, 7, id.Invalid); err != nil || count != 2 || size != 3 {
+		t.Errorf("expected 2/3, but got %d/%d (%v)", count, size, err)
+	}
+	tp.contents[1] = "xxxxx"
+	tp.observer(place.OnUpdate, 1)
+	if count, size, err := idx.CreatorUsage(ctx, 7, 2); err != nil || count != 1 || size != 5 {
+		t.Errorf("expected 1/5, but got %d/%d (%v)", count, size, err)
+	}
+}
+
+func TestCollectPrecursors(t *testing.T) {
+	m := meta.New(3)
+	m.Set(meta.KeyPrecursor, "00000000000001 00000000000003 00000000000002")
+	// Binary content is not parsed.
+	links := collectLinks(domain.Zettel{Meta: m, Content: domain.NewContent("\x00")})
+	if len(links) != 2 ||
+		links[0] != (Link{Zid: 1, Rel: meta.KeyPrecursor}) ||
+		links[1] != (Link{Zid: 2, Rel: meta.KeyPrecursor}) {
+		t.Errorf("unexpected links: %v", links)
+	}
+}
@@ -0,0 +1,92 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package index maintains aggregated data about all zettel of a place, so
+// that it must not be computed on every request.
+package index
+
+import (
+	"context"
+	"time"
+
+	"zettelstore.de/z/domain/id"
+)
+
+// Progress describes the state of the last rebuild of the index.
+type Progress struct {
+	Running  bool      // the rebuild is not finished
+	Indexed  int       // number of zettel indexed so far
+	Total    int       // number of zettel to be indexed
+	Started  time.Time // start of the rebuild, zero if there was none
+	Finished time.Time // end of the rebuild, zero if it is running
+	Err      error     // error that stopped the rebuild
+}
+
+// Rebuild starts to build the index from scratch in the background. Until it
+// is finished, the previous index data is used. It returns false, if a rebuild
+// is already running.
+func (idx *Indexer) Rebuild() bool {
+	idx.mxState.Lock()
+	defer idx.mxState.Unlock()
+	if idx.progress.Running {
+		return false
+	}
+	idx.progress = Progress{Running: true, Started: time.Now()}
+	idx.changed = make(map[id.Zid]bool)
+	idx.reloaded = false
+	go idx.rebuild(context.Background())
+	return true
+}
+
+// Progress returns the state of the last rebuild.
+func (idx *Indexer) Progress() Progress {
+	idx.mxState.Lock()
+	defer idx.mxState.Unlock()
+	return idx.progress
+}
+
+func (idx *Indexer) rebuild(ctx context.Context) {
+	metaList, err := idx.port.SelectMeta(ctx, nil, nil)
+	if err != nil {
+		idx.finishRebuild(nil, err)
+		return
+	}
+	idx.mxState.Lock()
+	idx.progress.Total = len(metaList)
+	idx.mxState.Unlock()
+
+	tmp := &Indexer{port: idx.port, collectLinks: idx.collectLinks}
+	tmp.reset(len(metaList))
+	for i, m := range metaList {
+		tmp.add(ctx, m)
+		idx.mxState.Lock()
+		idx.progress.Indexed = i + 1
+		idx.mxState.Unlock()
+	}
+	idx.finishRebuild(tmp, nil)
+}
+
+// finishRebuild replaces the index data with the data of the given indexer.
+// Zettel that changed in the meantime are updated on next use.
+func (idx *Indexer) finishRebuild(tmp *Indexer, err error) {
+	idx.mx.Lock()
+	idx.mxState.Lock()
+	if tmp != nil {
+		idx.metas, idx.tags, idx.roles = tmp.metas, tmp.tags, tmp.roles
+		idx.links, idx.backlinks, idx.sizes = tmp.links, tmp.backlinks, tmp.sizes
+		idx.valid, idx.pending = !idx.reloaded, idx.changed
+	}
+	idx.progress.Running = false
+	idx.progress.Finished = time.Now()
+	idx.progress.Err = err
+	idx.changed = nil
+	idx.mxState.Unlock()
+	idx.mx.Unlock()
+}
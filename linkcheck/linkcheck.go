@@ -0,0 +1,467 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package linkcheck verifies the external links of all zettel.
+//
+// The check runs in the background, as a job of the scheduler. Requests to
+// the same host are delayed, and the robots.txt of every host is respected.
+// Failed requests are retried, before a link is considered to be dead.
+//
+// Links to loopback, private, and link-local addresses are never retrieved,
+// even after a redirect, because any writer could otherwise probe the
+// internal network of the server.
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"syscall"
+	"time"
+
+	"zettelstore.de/z/ast"
+	"zettelstore.de/z/collect"
+	"zettelstore.de/z/config/startup"
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/parser"
+	"zettelstore.de/z/place"
+)
+
+// Result is the outcome of checking an external link.
+type Result struct {
+	Checked time.Time // Time of the check
+	Status  int       // HTTP status code, zero if there was no response
+	Err     string    // Error message, if there was no response
+}
+
+// IsDead returns true, if the link does not lead to a resource.
+func (r Result) IsDead() bool {
+	switch r.Status {
+	case 0:
+		return r.Err != ""
+	case http.StatusNotFound, http.StatusGone:
+		return true
+	}
+	return false
+}
+
+const (
+	hostDelay     = 2 * time.Second    // minimum time between two requests to the same host
+	maxRetries    = 2                  // number of retries after a failed request
+	retryDelay    = 5 * time.Second    // delay before the first retry, doubled for each retry
+	recheckAlive  = 7 * 24 * time.Hour // time after which an alive link is checked again
+	recheckDead   = 24 * time.Hour     // time after which a dead link is checked again
+	maxRobotsSize = 64 << 10           // maximum size of a robots.txt file
+	maxResults    = 10000              // maximum number of remembered results
+)
+
+// Checker checks external links and remembers the results.
+type Checker struct {
+	agent   string
+	client  *http.Client
+	now     func() time.Time
+	wait    func(context.Context, time.Duration) error
+	allowIP func(net.IP) bool // decides, whether an address may be connected
+
+	mx       sync.RWMutex // protects all following fields
+	results  map[string]Result
+	robots   map[string]*robotRules // robots.txt per scheme and host
+	lastHost map[string]time.Time   // time of the last request per host
+	links    map[id.Zid][]string    // external links per zettel, found by CheckAll
+}
+
+var defaultChecker struct {
+	once    sync.Once
+	checker *Checker
+}
+
+// Default returns the checker that is used by the whole software.
+func Default() *Checker {
+	defaultChecker.once.Do(func() {
+		v := startup.GetVersion()
+		defaultChecker.checker = New(v.Prog + "/" + v.Build)
+	})
+	return defaultChecker.checker
+}
+
+// New creates a new checker that identifies itself with the given user
+// agent.
+func New(agent string) *Checker {
+	c := &Checker{
+		agent:    agent,
+		now:      time.Now,
+		wait:     waitContext,
+		allowIP:  isPublicIP,
+		results:  make(map[string]Result),
+		robots:   make(map[string]*robotRules),
+		lastHost: make(map[string]time.Time),
+		links:    make(map[id.Zid][]string),
+	}
+	// The address is checked when the connection is made, i.e. after the
+	// host name was resolved and for every redirect. No proxy is used,
+	// because the address of the target would not be checked otherwise.
+	dialer := &net.Dialer{Timeout: 30 * time.Second, Control: c.control}
+	c.client = &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+	return c
+}
+
+// errForbiddenAddress is returned if a link refers to an address that must
+// not be retrieved.
+var errForbiddenAddress = errors.New("address is not public")
+
+func (c *Checker) control(network, address string, conn syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !c.allowIP(ip) {
+		return errForbiddenAddress
+	}
+	return nil
+}
+
+// nonPublicNets are networks that are not reachable from the internet, and
+// are not covered by the methods of net.IP.
+var nonPublicNets = func() []*net.IPNet {
+	var result []*net.IPNet
+	for _, s := range []string{
+		"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "172.16.0.0/12",
+		"192.0.0.0/24", "192.168.0.0/16", "198.18.0.0/15", "fc00::/7",
+	} {
+		_, n, _ := net.ParseCIDR(s)
+		result = append(result, n)
+	}
+	return result
+}()
+
+// isPublicIP returns true, if the address is a public unicast address.
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() || ip.IsMulticast() {
+		return false
+	}
+	for _, n := range nonPublicNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func waitContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Result returns the result of the last check of the given link.
+func (c *Checker) Result(link string) (Result, bool) {
+	c.mx.RLock()
+	r, ok := c.results[link]
+	c.mx.RUnlock()
+	return r, ok
+}
+
+// IsDead returns true, if the last check found the link to be dead.
+func (c *Checker) IsDead(link string) bool {
+	r, ok := c.Result(link)
+	return ok && r.IsDead()
+}
+
+// DeadLinks returns the external links of the given zettel that were found
+// to be dead. Only links that were found by CheckAll are considered, so that
+// no zettel must be parsed.
+func (c *Checker) DeadLinks(zid id.Zid) []string {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+	var result []string
+	for _, link := range c.links[zid] {
+		if r, ok := c.results[link]; ok && r.IsDead() {
+			result = append(result, link)
+		}
+	}
+	return result
+}
+
+// forget removes the external links of the given zettel, e.g. because the
+// zettel was changed. They are found again by the next CheckAll.
+func (c *Checker) forget(zid id.Zid) {
+	c.mx.Lock()
+	delete(c.links, zid)
+	c.mx.Unlock()
+}
+
+// Port is the interface of the place whose zettel are checked.
+type Port interface {
+	// GetZettel retrieves a specific zettel.
+	GetZettel(ctx context.Context, zid id.Zid) (domain.Zettel, error)
+
+	// SelectMeta returns all zettel meta data that match the selection
+	// criteria. The result is ordered by descending zettel id.
+	SelectMeta(ctx context.Context, f *place.Filter, s *place.Sorter) ([]*meta.Meta, error)
+}
+
+// CheckAll checks the external links of all zettel, if they were not checked
+// recently. The links of each zettel are remembered, and results of links
+// that are no longer used are removed.
+func (c *Checker) CheckAll(ctx context.Context, port Port) error {
+	metaList, err := port.SelectMeta(ctx, nil, nil)
+	if err != nil {
+		return err
+	}
+	c.mx.Lock()
+	c.robots = make(map[string]*robotRules)
+	c.mx.Unlock()
+	zids := make(map[id.Zid]bool, len(metaList))
+	seen := make(map[string]bool)
+	for _, m := range metaList {
+		zettel, err1 := port.GetZettel(ctx, m.Zid)
+		if err1 != nil {
+			continue
+		}
+		links := ExternalLinks(zettel)
+		zids[m.Zid] = true
+		c.mx.Lock()
+		c.links[m.Zid] = links
+		c.mx.Unlock()
+		for _, link := range links {
+			if seen[link] {
+				continue
+			}
+			seen[link] = true
+			if r, ok := c.Result(link); ok && c.now().Sub(r.Checked) < recheckInterval(r) {
+				continue
+			}
+			if _, err1 = c.Check(ctx, link); err1 != nil {
+				return err1
+			}
+		}
+	}
+	c.prune(zids, seen)
+	return nil
+}
+
+// prune removes all data of zettel and links that were not found by the last
+// CheckAll.
+func (c *Checker) prune(zids map[id.Zid]bool, links map[string]bool) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	for zid := range c.links {
+		if !zids[zid] {
+			delete(c.links, zid)
+		}
+	}
+	for link := range c.results {
+		if !links[link] {
+			delete(c.results, link)
+		}
+	}
+	now := c.now()
+	for host, last := range c.lastHost {
+		if last.Before(now) {
+			delete(c.lastHost, host)
+		}
+	}
+}
+
+func recheckInterval(r Result) time.Duration {
+	if r.IsDead() {
+		return recheckDead
+	}
+	return recheckAlive
+}
+
+// ExternalLinks returns all external links and images of the given zettel.
+func ExternalLinks(zettel domain.Zettel) []string {
+	if zettel.Content.IsBinary() {
+		return nil
+	}
+	summary := collect.References(parser.ParseZettel(zettel, ""))
+	var result []string
+	for _, refs := range [][]*ast.Reference{summary.Links, summary.Images} {
+		_, _, extRefs := collect.DivideReferences(refs, false)
+		for _, ref := range extRefs {
+			if ref.URL != nil && (ref.URL.Scheme == "http" || ref.URL.Scheme == "https") {
+				result = append(result, ref.String())
+			}
+		}
+	}
+	return result
+}
+
+// Check retrieves the given link and records the result. A link that must
+// not be retrieved because of the robots.txt of its host is not recorded. An
+// error is only returned, if the context is done.
+func (c *Checker) Check(ctx context.Context, link string) (Result, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		r := Result{Checked: c.now(), Err: err.Error()}
+		c.record(link, r)
+		return r, nil
+	}
+	rules, err := c.robotRules(ctx, u)
+	if err != nil {
+		return Result{}, err
+	}
+	if !rules.allows(u.EscapedPath()) {
+		return Result{}, nil
+	}
+	var r Result
+	for i := 0; i <= maxRetries; i++ {
+		if i > 0 {
+			if err = c.wait(ctx, retryDelay<<(i-1)); err != nil {
+				return Result{}, err
+			}
+		}
+		if r, err = c.request(ctx, u, http.MethodHead); err != nil {
+			return Result{}, err
+		}
+		if r.Status == http.StatusMethodNotAllowed || r.Status == http.StatusNotImplemented {
+			if r, err = c.request(ctx, u, http.MethodGet); err != nil {
+				return Result{}, err
+			}
+		}
+		if r.Checked.IsZero() {
+			// Link must not be retrieved
+			return Result{}, nil
+		}
+		if !isTransient(r) {
+			break
+		}
+	}
+	c.record(link, r)
+	return r, nil
+}
+
+// isTransient returns true, if a request should be retried.
+func isTransient(r Result) bool {
+	return r.Status == 0 || r.Status == http.StatusTooManyRequests || r.Status >= 500
+}
+
+// record stores the result of a link. If too many results are stored, the
+// oldest one is removed.
+func (c *Checker) record(link string, r Result) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if _, ok := c.results[link]; !ok && len(c.results) >= maxResults {
+		var oldest string
+		var oldestTime time.Time
+		for l, res := range c.results {
+			if oldest == "" || res.Checked.Before(oldestTime) {
+				oldest, oldestTime = l, res.Checked
+			}
+		}
+		delete(c.results, oldest)
+	}
+	c.results[link] = r
+}
+
+// request sends one request to the host of the given URL, after the delay
+// for this host has elapsed. An error is only returned, if the context is
+// done. If the address of the host must not be retrieved, the zero result is
+// returned.
+func (c *Checker) request(ctx context.Context, u *url.URL, method string) (Result, error) {
+	resp, err := c.do(ctx, method, u.String())
+	if err != nil {
+		if ctx.Err() != nil {
+			return Result{}, ctx.Err()
+		}
+		if errors.Is(err, errForbiddenAddress) {
+			return Result{}, nil
+		}
+		return Result{Checked: c.now(), Err: err.Error()}, nil
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 4096))
+	return Result{Checked: c.now(), Status: resp.StatusCode}, nil
+}
+
+func (c *Checker) do(ctx context.Context, method, link string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, link, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.agent)
+	host := req.URL.Host
+	c.mx.Lock()
+	next := c.lastHost[host].Add(hostDelay)
+	now := c.now()
+	if next.Before(now) {
+		next = now
+	}
+	c.lastHost[host] = next
+	c.mx.Unlock()
+	if err = c.wait(ctx, next.Sub(now)); err != nil {
+		return nil, err
+	}
+	return c.client.Do(req)
+}
+
+// robotRules returns the rules of the robots.txt of the host of the given
+// URL. If there is no valid robots.txt, everything is allowed.
+func (c *Checker) robotRules(ctx context.Context, u *url.URL) (*robotRules, error) {
+	key := u.Scheme + "://" + u.Host
+	c.mx.RLock()
+	rules, ok := c.robots[key]
+	c.mx.RUnlock()
+	if ok {
+		return rules, nil
+	}
+	rules = &robotRules{}
+	resp, err := c.do(ctx, http.MethodGet, key+"/robots.txt")
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	} else {
+		if resp.StatusCode == http.StatusOK {
+			data, err1 := ioutil.ReadAll(io.LimitReader(resp.Body, maxRobotsSize))
+			if err1 == nil {
+				rules = parseRobots(string(data), c.agent)
+			}
+		}
+		resp.Body.Close()
+	}
+	c.mx.Lock()
+	c.robots[key] = rules
+	c.mx.Unlock()
+	return rules, nil
+}
+
+// String returns a short description of the result.
+func (r Result) String() string {
+	if r.Status == 0 {
+		return r.Err
+	}
+	return fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status))
+}
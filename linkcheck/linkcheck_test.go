@@ -0,0 +1,137 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package linkcheck verifies the external links of all zettel.
+package linkcheck
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseRobots(t *testing.T) {
+	content := "User-agent: *\nDisallow: /private\n\n" +
+		"User-agent: Zettelstore\nDisallow: /\nAllow: /public # comment\n"
+	rules := parseRobots(content, "Zettelstore/1.0")
+	testcases := []struct {
+		path string
+		exp  bool
+	}{
+		{"/", false},
+		{"/private", false},
+		{"/public", true},
+		{"/public/x", true},
+		{"/other", false},
+	}
+	for _, tc := range testcases {
+		if got := rules.allows(tc.path); got != tc.exp {
+			t.Errorf("allows(%q) = %v, but expected %v", tc.path, got, tc.exp)
+		}
+	}
+	rules = parseRobots(content, "other")
+	if rules.allows("/private/x") || !rules.allows("/other") {
+		t.Errorf("unexpected rules for general agent: %v", rules)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	var mx sync.Mutex
+	calls := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mx.Lock()
+		calls[r.URL.Path]++
+		n := calls[r.URL.Path]
+		mx.Unlock()
+		switch r.URL.Path {
+		case "/robots.txt":
+			w.Write([]byte("User-agent: *\nDisallow: /secret\n"))
+		case "/missing":
+			http.NotFound(w, r)
+		case "/flaky":
+			if n < 2 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		case "/nohead":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		}
+	}))
+	defer srv.Close()
+
+	c := New("Zettelstore/test")
+	c.wait = func(context.Context, time.Duration) error { return nil }
+	c.allowIP = func(net.IP) bool { return true }
+	testcases := []struct {
+		path   string
+		status int
+		dead   bool
+	}{
+		{"/ok", http.StatusOK, false},
+		{"/missing", http.StatusNotFound, true},
+		{"/flaky", http.StatusOK, false},
+		{"/nohead", http.StatusOK, false},
+		{"/secret", 0, false},
+	}
+	for _, tc := range testcases {
+		link := srv.URL + tc.path
+		r, err := c.Check(context.Background(), link)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tc.path, err)
+			continue
+		}
+		if r.Status != tc.status || c.IsDead(link) != tc.dead {
+			t.Errorf("%s: got %v (dead=%v), but expected status %d (dead=%v)",
+				tc.path, r, c.IsDead(link), tc.status, tc.dead)
+		}
+	}
+	if calls["/secret"] != 0 {
+		t.Error("disallowed path was retrieved")
+	}
+	if calls["/robots.txt"] != 1 {
+		t.Errorf("robots.txt retrieved %d times", calls["/robots.txt"])
+	}
+	if _, ok := c.Result(srv.URL + "/secret"); ok {
+		t.Error("disallowed path has a result")
+	}
+}
+
+func TestNonPublicAddress(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer srv.Close()
+
+	c := New("Zettelstore/test")
+	c.wait = func(context.Context, time.Duration) error { return nil }
+	link := srv.URL + "/ok"
+	if r, err := c.Check(context.Background(), link); err != nil || r.Status != 0 || r.Err != "" {
+		t.Errorf("unexpected result %v (err=%v)", r, err)
+	}
+	if _, ok := c.Result(link); ok || calls != 0 {
+		t.Errorf("loopback address was retrieved %d times", calls)
+	}
+
+	for addr, exp := range map[string]bool{
+		"127.0.0.1": false, "10.1.2.3": false, "172.20.0.1": false, "192.168.1.1": false,
+		"169.254.169.254": false, "::1": false, "fe80::1": false, "fd00::1": false,
+		"0.0.0.0": false, "::ffff:127.0.0.1": false, "93.184.216.34": true, "2606:2800::1": true,
+	} {
+		if got := isPublicIP(net.ParseIP(addr)); got != exp {
+			t.Errorf("isPublicIP(%v) = %v, but expected %v", addr, got, exp)
+		}
+	}
+}
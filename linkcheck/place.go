@@ -0,0 +1,85 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+package linkcheck
+
+import (
+	"context"
+	"strings"
+
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/place"
+)
+
+// lcPlace adds the dead external links to all meta data returned by another
+// place. The links of a zettel are only known after they were checked, so
+// that no zettel must be parsed here.
+type lcPlace struct {
+	place.Place
+	checker *Checker
+}
+
+// NewPlace wraps the given place, so that it returns meta data together with
+// the external links of the zettel that were found to be dead.
+func NewPlace(p place.Place, checker *Checker) place.Place {
+	lp := &lcPlace{Place: p, checker: checker}
+	p.RegisterChangeObserver(lp.observe)
+	return lp
+}
+
+// observe forgets the links of a changed zettel, because they may have been
+// removed. They are checked again by the next check of all links.
+func (lp *lcPlace) observe(reason place.ChangeReason, zid id.Zid) {
+	if reason != place.OnReload && zid.IsValid() {
+		lp.checker.forget(zid)
+	}
+}
+
+// GetZettel retrieves a specific zettel.
+func (lp *lcPlace) GetZettel(ctx context.Context, zid id.Zid) (domain.Zettel, error) {
+	zettel, err := lp.Place.GetZettel(ctx, zid)
+	if err != nil {
+		return zettel, err
+	}
+	lp.setDeadLinks(zettel.Meta)
+	return zettel, nil
+}
+
+// GetMeta retrieves just the meta data of a specific zettel.
+func (lp *lcPlace) GetMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error) {
+	m, err := lp.Place.GetMeta(ctx, zid)
+	if err != nil {
+		return m, err
+	}
+	lp.setDeadLinks(m)
+	return m, nil
+}
+
+// SelectMeta returns all zettel meta data that match the selection
+// criteria. The result is ordered by descending zettel id.
+func (lp *lcPlace) SelectMeta(
+	ctx context.Context, f *place.Filter, s *place.Sorter) ([]*meta.Meta, error) {
+	metaList, err := lp.Place.SelectMeta(ctx, f, s)
+	if err != nil {
+		return metaList, err
+	}
+	for _, m := range metaList {
+		lp.setDeadLinks(m)
+	}
+	return metaList, nil
+}
+
+func (lp *lcPlace) setDeadLinks(m *meta.Meta) {
+	if dead := lp.checker.DeadLinks(m.Zid); len(dead) > 0 {
+		m.Set(meta.KeyDeadLinks, strings.Join(dead, " "))
+	}
+}
@@ -0,0 +1,104 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package linkcheck verifies the external links of all zettel.
+package linkcheck
+
+import (
+	"strings"
+)
+
+// robotRules are the rules of a robots.txt that apply to the checker.
+type robotRules struct {
+	allow    []string
+	disallow []string
+}
+
+// parseRobots extracts the rules for the given user agent from the content
+// of a robots.txt. If there is no group for the user agent, the group for
+// all user agents ("*") applies.
+func parseRobots(content, agent string) *robotRules {
+	agent = strings.ToLower(agent)
+	if pos := strings.IndexByte(agent, '/'); pos >= 0 {
+		agent = agent[:pos]
+	}
+	var specific, general *robotRules
+	var current []*robotRules // groups that the current rules belong to
+	inRules := false
+	for _, line := range strings.Split(content, "\n") {
+		if pos := strings.IndexByte(line, '#'); pos >= 0 {
+			line = line[:pos]
+		}
+		pos := strings.IndexByte(line, ':')
+		if pos < 0 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:pos]))
+		value := strings.TrimSpace(line[pos+1:])
+		switch key {
+		case "user-agent":
+			if inRules {
+				current, inRules = nil, false
+			}
+			name := strings.ToLower(value)
+			if name == "*" {
+				if general == nil {
+					general = &robotRules{}
+				}
+				current = append(current, general)
+			} else if name != "" && strings.HasPrefix(agent, name) {
+				if specific == nil {
+					specific = &robotRules{}
+				}
+				current = append(current, specific)
+			}
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				continue
+			}
+			for _, rules := range current {
+				if key == "allow" {
+					rules.allow = append(rules.allow, value)
+				} else {
+					rules.disallow = append(rules.disallow, value)
+				}
+			}
+		}
+	}
+	if specific != nil {
+		return specific
+	}
+	if general != nil {
+		return general
+	}
+	return &robotRules{}
+}
+
+// allows returns true, if the given path may be retrieved. The longest
+// matching rule decides. If an allow and a disallow rule have the same
+// length, the allow rule wins.
+func (rr *robotRules) allows(path string) bool {
+	if path == "" {
+		path = "/"
+	}
+	allow, disallow := longestPrefix(rr.allow, path), longestPrefix(rr.disallow, path)
+	return allow >= disallow
+}
+
+func longestPrefix(prefixes []string, path string) int {
+	result := -1
+	for _, prefix := range prefixes {
+		if len(prefix) > result && strings.HasPrefix(path, prefix) {
+			result = len(prefix)
+		}
+	}
+	return result
+}
@@ -0,0 +1,99 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package lock manages advisory locks of zettel.
+//
+// A lock does not prevent any change of a zettel. It just tells other users
+// that someone is working on it.
+package lock
+
+import (
+	"sync"
+	"time"
+
+	"zettelstore.de/z/domain/id"
+)
+
+// Lock is the advisory lock of a zettel, held by a user.
+type Lock struct {
+	Zid     id.Zid
+	User    string // User identification of the lock holder
+	Expires time.Time
+}
+
+// Registry stores all locks in memory. A lock expires automatically after
+// the duration given when the registry was created.
+type Registry struct {
+	duration time.Duration
+	now      func() time.Time
+
+	mx    sync.Mutex // protects locks
+	locks map[id.Zid]Lock
+}
+
+// New creates a new registry.
+func New(duration time.Duration) *Registry {
+	return &Registry{
+		duration: duration,
+		now:      time.Now,
+		locks:    make(map[id.Zid]Lock),
+	}
+}
+
+// Get returns the lock of the given zettel, if there is one that has not
+// expired.
+func (r *Registry) Get(zid id.Zid) (Lock, bool) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	return r.get(zid)
+}
+
+// get returns the current lock. It must be called with a locked mx.
+func (r *Registry) get(zid id.Zid) (Lock, bool) {
+	l, ok := r.locks[zid]
+	if !ok {
+		return Lock{}, false
+	}
+	if !r.now().Before(l.Expires) {
+		delete(r.locks, zid)
+		return Lock{}, false
+	}
+	return l, true
+}
+
+// Acquire locks the given zettel for the given user. If the user already
+// holds the lock, it is extended. If another user holds the lock, this lock
+// is returned, together with false.
+func (r *Registry) Acquire(zid id.Zid, user string) (Lock, bool) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	if l, ok := r.get(zid); ok && l.User != user {
+		return l, false
+	}
+	l := Lock{Zid: zid, User: user, Expires: r.now().Add(r.duration)}
+	r.locks[zid] = l
+	return l, true
+}
+
+// Release removes the lock of the given zettel, if the given user holds it.
+// If another user holds the lock, this lock is returned, together with false.
+func (r *Registry) Release(zid id.Zid, user string) (Lock, bool) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	l, ok := r.get(zid)
+	if !ok {
+		return Lock{}, true
+	}
+	if l.User != user {
+		return l, false
+	}
+	delete(r.locks, zid)
+	return Lock{}, true
+}
@@ -0,0 +1,51 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package lock manages advisory locks of zettel.
+package lock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := New(10 * time.Minute)
+	r.now = func() time.Time { return now }
+
+	if _, ok := r.Acquire(1, "alice"); !ok {
+		t.Fatal("alice could not acquire free lock")
+	}
+	if l, ok := r.Acquire(1, "bob"); ok || l.User != "alice" {
+		t.Errorf("bob acquired lock of alice: %v", l)
+	}
+	if _, ok := r.Release(1, "bob"); ok {
+		t.Error("bob released lock of alice")
+	}
+
+	now = now.Add(9 * time.Minute)
+	if l, ok := r.Acquire(1, "alice"); !ok || !l.Expires.Equal(now.Add(10*time.Minute)) {
+		t.Errorf("alice could not extend lock: %v", l)
+	}
+	now = now.Add(10 * time.Minute)
+	if l, ok := r.Get(1); ok {
+		t.Errorf("lock did not expire: %v", l)
+	}
+	if _, ok := r.Acquire(1, "bob"); !ok {
+		t.Error("bob could not acquire expired lock")
+	}
+	if _, ok := r.Release(1, "bob"); !ok {
+		t.Error("bob could not release the lock")
+	}
+	if _, ok := r.Get(1); ok {
+		t.Error("released lock still exists")
+	}
+}
@@ -0,0 +1,122 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package mimetype maps zettel syntax values to MIME types and back.
+package mimetype
+
+import "strings"
+
+const plainText = "text/plain; charset=utf-8"
+
+// entry describes one syntax value, its MIME type, and file extensions that
+// are mapped to the syntax value, besides the syntax value itself.
+type entry struct {
+	syntax   string
+	mimeType string
+	exts     []string
+}
+
+// entries lists all known syntax values. If more than one syntax value is
+// mapped to the same MIME type, the first one is the preferred one.
+var entries = []entry{
+	{"abc", "text/vnd.abc; charset=utf-8", nil},
+	{"css", "text/css; charset=utf-8", nil},
+	{"drawing", "application/vnd.excalidraw+json", []string{"excalidraw"}},
+	{"gif", "image/gif", nil},
+	{"html", "text/html; charset=utf-8", []string{"htm"}},
+	{"jpeg", "image/jpeg", nil},
+	{"jpg", "image/jpeg", nil},
+	{"js", "text/javascript; charset=utf-8", nil},
+	{"pdf", "application/pdf", nil},
+	{"png", "image/png", nil},
+	{"svg", "image/svg+xml", nil},
+	{"webp", "image/webp", nil},
+	{"mp3", "audio/mpeg", nil},
+	{"ogg", "audio/ogg", nil},
+	{"wav", "audio/wav", nil},
+	{"mp4", "video/mp4", nil},
+	{"webm", "video/webm", nil},
+	{"xml", "text/xml; charset=utf-8", nil},
+	{"zmk", "text/x-zmk; charset=utf-8", nil},
+	{"plain", plainText, nil},
+	{"text", plainText, nil},
+	{"markdown", "text/markdown; charset=utf-8", nil},
+	{"md", "text/markdown; charset=utf-8", nil},
+	{"mustache", plainText, nil},
+}
+
+var (
+	syntax2type = make(map[string]string, len(entries))
+	type2syntax = make(map[string]string, len(entries))
+	ext2syntax  = make(map[string]string)
+)
+
+func init() {
+	for _, e := range entries {
+		syntax2type[e.syntax] = e.mimeType
+		if t := baseType(e.mimeType); type2syntax[t] == "" {
+			type2syntax[t] = e.syntax
+		}
+		for _, ext := range e.exts {
+			ext2syntax[ext] = e.syntax
+		}
+	}
+}
+
+// ContentType returns the MIME type of the given syntax value. Values in
+// configured, where each value has the form "syntax:mime/type", take
+// precedence over the built-in mapping.
+func ContentType(syntax string, configured []string) (string, bool) {
+	for _, value := range configured {
+		if s, t, ok := splitValue(value); ok && s == syntax {
+			return t, true
+		}
+	}
+	t, ok := syntax2type[syntax]
+	return t, ok
+}
+
+// Syntax returns the syntax value for the given MIME type, e.g. for the
+// content type of uploaded data. Parameters of the MIME type are ignored.
+func Syntax(contentType string, configured []string) (string, bool) {
+	ct := baseType(contentType)
+	for _, value := range configured {
+		if s, t, ok := splitValue(value); ok && baseType(t) == ct {
+			return s, true
+		}
+	}
+	s, ok := type2syntax[ct]
+	return s, ok
+}
+
+// ExtSyntax returns the syntax value for a file extension. Most syntax
+// values are the same as the file extension.
+func ExtSyntax(ext string) string {
+	ext = strings.ToLower(ext)
+	if syntax, ok := ext2syntax[ext]; ok {
+		return syntax
+	}
+	return ext
+}
+
+func splitValue(value string) (string, string, bool) {
+	pos := strings.IndexByte(value, ':')
+	if pos <= 0 || pos == len(value)-1 {
+		return "", "", false
+	}
+	return strings.ToLower(value[:pos]), value[pos+1:], true
+}
+
+func baseType(contentType string) string {
+	if pos := strings.IndexByte(contentType, ';'); pos >= 0 {
+		contentType = contentType[:pos]
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
@@ -0,0 +1,65 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package mimetype_test provides some tests for mapping MIME types.
+package mimetype_test
+
+import (
+	"testing"
+
+	"zettelstore.de/z/mimetype"
+)
+
+func TestRoundTrip(t *testing.T) {
+	configured := []string{"abc:application/x-abc", "webp:image/x-webp", "invalid", ":x/y"}
+	testcases := []struct {
+		syntax string
+		ct     string
+		back   string
+	}{
+		{"png", "image/png", "png"},
+		{"jpg", "image/jpeg", "jpeg"},
+		{"webp", "image/x-webp", "webp"},
+		{"mp3", "audio/mpeg", "mp3"},
+		{"abc", "application/x-abc", "abc"},
+		{"zmk", "text/x-zmk; charset=utf-8", "zmk"},
+		{"unknown", "", ""},
+	}
+	for _, tc := range testcases {
+		ct, ok := mimetype.ContentType(tc.syntax, configured)
+		if ct != tc.ct || ok != (tc.ct != "") {
+			t.Errorf("ContentType(%q): exp %q, got %q/%v", tc.syntax, tc.ct, ct, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if syntax, _ := mimetype.Syntax(ct, configured); syntax != tc.back {
+			t.Errorf("Syntax(%q): exp %q, got %q", ct, tc.back, syntax)
+		}
+	}
+}
+
+func TestExtSyntax(t *testing.T) {
+	testcases := []struct {
+		ext    string
+		syntax string
+	}{
+		{"htm", "html"},
+		{"HTML", "html"},
+		{"webp", "webp"},
+		{"zettel", "zettel"},
+	}
+	for _, tc := range testcases {
+		if got := mimetype.ExtSyntax(tc.ext); got != tc.syntax {
+			t.Errorf("ExtSyntax(%q): exp %q, got %q", tc.ext, tc.syntax, got)
+		}
+	}
+}
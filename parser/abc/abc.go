@@ -0,0 +1,518 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package abc provides a parser for music in ABC notation, which is rendered
+// as a score.
+//
+// Only a subset of ABC is supported: header fields, notes with accidentals,
+// octaves and lengths, chords, rests, bar lines, broken rhythm, triplets, and
+// chord symbols. Other elements, like decorations, slurs, and grace notes,
+// are ignored.
+package abc
+
+import (
+	"strconv"
+	"strings"
+
+	"zettelstore.de/z/ast"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/input"
+	"zettelstore.de/z/parser"
+)
+
+func init() {
+	parser.Register(&parser.Info{
+		Name:         "abc",
+		ParseBlocks:  parseBlocks,
+		ParseInlines: parseInlines,
+	})
+}
+
+// Tune is a single tune of ABC source, which may contain a collection of
+// tunes.
+type Tune struct {
+	Title    string
+	Composer string
+	Tempo    string
+	Lines    []Line
+}
+
+// Line is a line of music, which is rendered as one staff.
+type Line struct {
+	Key     int    // Number of sharps (positive) or flats (negative)
+	Meter   string // Meter to be shown at the start of the line, if any
+	Symbols []Symbol
+}
+
+// Kind states the kind of a symbol.
+type Kind int
+
+// Constants for Kind
+const (
+	KindNote Kind = iota
+	KindRest
+	KindBar
+)
+
+// Symbol is a note, a chord, a rest, or a bar line.
+type Symbol struct {
+	Kind   Kind
+	Notes  []Note  // More than one note for a chord
+	Length float64 // Length as a fraction of a whole note
+	Bar    string  // Bar line, e.g. "|", "||", ":|"
+	Text   string  // Chord symbol or annotation above the staff
+}
+
+// Note is a single note, relative to the middle C.
+type Note struct {
+	Step       int    // Diatonic step: C=0, D=1, ..., c=7
+	Accidental string // "^", "^^", "_", "__", "=", or ""
+}
+
+func parseBlocks(inp *input.Input, m *meta.Meta, syntax string) ast.BlockSlice {
+	ins := parseInlines(inp, syntax)
+	if len(ins) == 0 {
+		// Show the source, if it contains no music.
+		return ast.BlockSlice{
+			&ast.VerbatimNode{
+				Code:  ast.VerbatimProg,
+				Attrs: &ast.Attributes{Attrs: map[string]string{"": syntax}},
+				Lines: strings.Split(strings.TrimRight(inp.Src, "\n"), "\n"),
+			},
+		}
+	}
+	bs := make(ast.BlockSlice, 0, len(ins))
+	for _, in := range ins {
+		bs = append(bs, &ast.ParaNode{Inlines: ast.InlineSlice{in}})
+	}
+	return bs
+}
+
+func parseInlines(inp *input.Input, syntax string) ast.InlineSlice {
+	var ins ast.InlineSlice
+	for _, tune := range Parse(inp.Src) {
+		if len(tune.Lines) == 0 {
+			continue
+		}
+		var alt ast.InlineSlice
+		if tune.Title != "" {
+			alt = ast.InlineSlice{&ast.TextNode{Text: tune.Title}}
+		}
+		ins = append(ins, &ast.ImageNode{
+			Blob:    []byte(tune.SVG()),
+			Syntax:  "svg",
+			Inlines: alt,
+		})
+	}
+	return ins
+}
+
+// tuneParser contains the state while parsing ABC source.
+type tuneParser struct {
+	tunes   []*Tune
+	tune    *Tune
+	unit    float64 // Default note length
+	unitSet bool
+	key     int
+	meter   string // Meter to be shown at the next line
+}
+
+// Parse reads all tunes of the given ABC source. A tune starts with a "X:"
+// field or an empty line.
+func Parse(src string) []*Tune {
+	tp := tuneParser{}
+	for _, line := range strings.Split(src, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		switch {
+		case line == "":
+			tp.tune = nil
+		case strings.HasPrefix(line, "%"):
+		case isField(line):
+			tp.field(line[0], strings.TrimSpace(line[2:]))
+		default:
+			tp.music(line)
+		}
+	}
+	return tp.tunes
+}
+
+func isField(line string) bool {
+	return len(line) >= 2 && line[1] == ':' &&
+		('A' <= line[0] && line[0] <= 'Z' || 'a' <= line[0] && line[0] <= 'z')
+}
+
+func (tp *tuneParser) ensureTune() {
+	if tp.tune == nil {
+		tp.tune = &Tune{}
+		tp.tunes = append(tp.tunes, tp.tune)
+		tp.unit, tp.unitSet, tp.key, tp.meter = 1.0/8, false, 0, ""
+	}
+}
+
+func (tp *tuneParser) field(name byte, value string) {
+	if name == 'X' {
+		tp.tune = nil
+	}
+	tp.ensureTune()
+	switch name {
+	case 'T':
+		if tp.tune.Title == "" {
+			tp.tune.Title = value
+		}
+	case 'C':
+		tp.tune.Composer = value
+	case 'Q':
+		tp.tune.Tempo = value
+	case 'M':
+		tp.meter = value
+		if !tp.unitSet && meterValue(value) < 0.75 {
+			tp.unit = 1.0 / 16
+		}
+	case 'L':
+		if num, den, ok := parseFraction(value); ok {
+			tp.unit, tp.unitSet = float64(num)/float64(den), true
+		}
+	case 'K':
+		tp.key = parseKey(value)
+	}
+}
+
+// meterValue returns the value of a meter, e.g. 0.75 for "3/4".
+func meterValue(meter string) float64 {
+	switch meter {
+	case "C":
+		return 1
+	case "C|":
+		return 1
+	}
+	if num, den, ok := parseFraction(meter); ok {
+		return float64(num) / float64(den)
+	}
+	return 1
+}
+
+func parseFraction(s string) (int, int, bool) {
+	pos := strings.IndexByte(s, '/')
+	if pos < 0 {
+		return 0, 0, false
+	}
+	num, err1 := strconv.Atoi(strings.TrimSpace(s[:pos]))
+	den, err2 := strconv.Atoi(strings.TrimSpace(s[pos+1:]))
+	if err1 != nil || err2 != nil || num <= 0 || den <= 0 {
+		return 0, 0, false
+	}
+	return num, den, true
+}
+
+// keySharps contains the number of sharps of the major key of each tonic.
+var keySharps = map[byte]int{'C': 0, 'D': 2, 'E': 4, 'F': -1, 'G': 1, 'A': 3, 'B': 5}
+
+// modeShift contains the difference of the number of sharps of a mode,
+// compared to the major key.
+var modeShift = map[string]int{
+	"":    0,
+	"maj": 0,
+	"ion": 0,
+	"lyd": 1,
+	"mix": -1,
+	"dor": -2,
+	"m":   -3,
+	"min": -3,
+	"aeo": -3,
+	"phr": -4,
+	"loc": -5,
+}
+
+// parseKey returns the number of sharps (positive) or flats (negative) of a
+// key, e.g. 1 for "G" or "Em", and -2 for "Bb".
+func parseKey(value string) int {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return 0
+	}
+	key := fields[0]
+	sharps, ok := keySharps[strings.ToUpper(key[:1])[0]]
+	if !ok {
+		return 0
+	}
+	key = key[1:]
+	if strings.HasPrefix(key, "#") {
+		sharps += 7
+		key = key[1:]
+	} else if strings.HasPrefix(key, "b") {
+		sharps -= 7
+		key = key[1:]
+	}
+	if key == "" && len(fields) > 1 {
+		key = fields[1]
+	}
+	mode := strings.ToLower(key)
+	if len(mode) > 3 {
+		mode = mode[:3]
+	}
+	sharps += modeShift[mode]
+	if sharps > 7 {
+		sharps = 7
+	} else if sharps < -7 {
+		sharps = -7
+	}
+	return sharps
+}
+
+// musicParser contains the state while parsing a line of music.
+type musicParser struct {
+	src     []rune
+	pos     int
+	unit    float64
+	symbols []Symbol
+	text    string  // Chord symbol for the next note
+	broken  float64 // Factor of the next note, because of broken rhythm
+	tuplet  float64 // Factor of the next notes, because of a tuplet
+	inTuple int     // Number of notes remaining in the tuplet
+}
+
+func (tp *tuneParser) music(line string) {
+	tp.ensureTune()
+	mp := musicParser{src: []rune(line), unit: tp.unit}
+	mp.parse()
+	if len(mp.symbols) == 0 {
+		return
+	}
+	tp.tune.Lines = append(tp.tune.Lines, Line{Key: tp.key, Meter: tp.meter, Symbols: mp.symbols})
+	tp.meter = ""
+}
+
+func (mp *musicParser) peek(offset int) rune {
+	if p := mp.pos + offset; p < len(mp.src) {
+		return mp.src[p]
+	}
+	return 0
+}
+
+// skipTo skips all characters until the given one, inclusively, and
+// returns the skipped text.
+func (mp *musicParser) skipTo(ch rune) string {
+	start := mp.pos
+	for mp.pos < len(mp.src) && mp.src[mp.pos] != ch {
+		mp.pos++
+	}
+	text := string(mp.src[start:mp.pos])
+	mp.pos++
+	return text
+}
+
+func (mp *musicParser) parse() {
+	for mp.pos < len(mp.src) {
+		ch := mp.src[mp.pos]
+		switch {
+		case ch == '%':
+			return
+		case ch == '"':
+			mp.pos++
+			mp.text = strings.TrimLeft(mp.skipTo('"'), "^_<>@")
+		case ch == '!' || ch == '+':
+			mp.pos++
+			mp.skipTo(ch)
+		case ch == '{':
+			mp.skipTo('}')
+		case ch == '[' && isLetter(mp.peek(1)) && mp.peek(2) == ':':
+			// Inline fields are ignored
+			mp.skipTo(']')
+		case ch == '[' && mp.peek(1) == '|':
+			mp.parseBar()
+		case ch == '[' && isDigit(mp.peek(1)):
+			// Variant endings are ignored
+			mp.pos++
+		case ch == '[':
+			mp.pos++
+			mp.parseChord()
+		case ch == '|' || ch == ':':
+			mp.parseBar()
+		case ch == '(' && isDigit(mp.peek(1)):
+			n := int(mp.peek(1) - '0')
+			mp.pos += 2
+			if n > 1 {
+				mp.inTuple = n
+				mp.tuplet = tupletFactor(n)
+			}
+		case ch == '>' || ch == '<':
+			mp.pos++
+			mp.applyBroken(ch == '>')
+		case ch == 'z' || ch == 'x':
+			mp.pos++
+			length := mp.parseLength()
+			if ch == 'z' {
+				mp.add(Symbol{Kind: KindRest, Length: length})
+			}
+		case ch == 'Z':
+			mp.pos++
+			mp.parseLength()
+			mp.add(Symbol{Kind: KindRest, Length: 1})
+		case isNoteStart(ch):
+			note, ok := mp.parseNote()
+			if ok {
+				mp.add(Symbol{Kind: KindNote, Notes: []Note{note}, Length: mp.parseLength()})
+			}
+		default:
+			mp.pos++
+		}
+	}
+}
+
+func isLetter(ch rune) bool { return 'A' <= ch && ch <= 'Z' || 'a' <= ch && ch <= 'z' }
+func isDigit(ch rune) bool  { return '0' <= ch && ch <= '9' }
+func isNoteStart(ch rune) bool {
+	return strings.ContainsRune("^_=ABCDEFGabcdefg", ch)
+}
+
+// tupletFactor returns the factor of the note length within a tuplet, e.g.
+// three notes in the time of two.
+func tupletFactor(n int) float64 {
+	switch n {
+	case 2:
+		return 3.0 / 2
+	case 3, 6:
+		return 2.0 / 3
+	case 4:
+		return 3.0 / 4
+	}
+	return 2.0 / float64(n)
+}
+
+// add appends a symbol, after applying broken rhythm, tuplets, and chord
+// symbols.
+func (mp *musicParser) add(sym Symbol) {
+	if sym.Kind != KindBar {
+		if mp.broken != 0 {
+			sym.Length *= mp.broken
+			mp.broken = 0
+		}
+		if mp.inTuple > 0 {
+			sym.Length *= mp.tuplet
+			mp.inTuple--
+		}
+		sym.Text, mp.text = mp.text, ""
+	}
+	mp.symbols = append(mp.symbols, sym)
+}
+
+// applyBroken changes the length of the previous note and of the next note.
+// A ">" lengthens the previous note by half, and shortens the next one.
+func (mp *musicParser) applyBroken(longFirst bool) {
+	n := len(mp.symbols)
+	if n == 0 || mp.symbols[n-1].Kind == KindBar {
+		return
+	}
+	if longFirst {
+		mp.symbols[n-1].Length *= 1.5
+		mp.broken = 0.5
+	} else {
+		mp.symbols[n-1].Length *= 0.5
+		mp.broken = 1.5
+	}
+}
+
+func (mp *musicParser) parseBar() {
+	start := mp.pos
+	for mp.pos < len(mp.src) && strings.ContainsRune("|:[]", mp.src[mp.pos]) {
+		if mp.src[mp.pos] == '[' && mp.peek(1) != '|' {
+			break
+		}
+		mp.pos++
+	}
+	bar := string(mp.src[start:mp.pos])
+	// Numbers of variant endings are ignored
+	for isDigit(mp.peek(0)) {
+		mp.pos++
+	}
+	if bar == ":" {
+		return
+	}
+	mp.add(Symbol{Kind: KindBar, Bar: bar})
+}
+
+func (mp *musicParser) parseNote() (Note, bool) {
+	var note Note
+	start := mp.pos
+	for strings.ContainsRune("^_=", mp.peek(0)) {
+		mp.pos++
+	}
+	note.Accidental = string(mp.src[start:mp.pos])
+	ch := mp.peek(0)
+	idx := strings.IndexRune("CDEFGABcdefgab", ch)
+	if idx < 0 {
+		return note, false
+	}
+	mp.pos++
+	note.Step = idx
+	for {
+		switch mp.peek(0) {
+		case ',':
+			note.Step -= 7
+		case '\'':
+			note.Step += 7
+		default:
+			return note, true
+		}
+		mp.pos++
+	}
+}
+
+func (mp *musicParser) parseChord() {
+	var notes []Note
+	for mp.pos < len(mp.src) && mp.src[mp.pos] != ']' {
+		if isNoteStart(mp.src[mp.pos]) {
+			if note, ok := mp.parseNote(); ok {
+				notes = append(notes, note)
+				mp.parseLength() // Length of chord notes are ignored
+				continue
+			}
+		}
+		mp.pos++
+	}
+	mp.pos++
+	length := mp.parseLength()
+	if len(notes) > 0 {
+		mp.add(Symbol{Kind: KindNote, Notes: notes, Length: length})
+	}
+}
+
+// parseLength parses the length of a note, e.g. "2", "/", "3/2", and
+// returns it as a fraction of a whole note.
+func (mp *musicParser) parseLength() float64 {
+	num, den := mp.parseNumber(1), 1
+	for mp.peek(0) == '/' {
+		mp.pos++
+		if isDigit(mp.peek(0)) {
+			den *= mp.parseNumber(1)
+		} else {
+			den *= 2
+		}
+	}
+	if den == 0 {
+		den = 1
+	}
+	return mp.unit * float64(num) / float64(den)
+}
+
+func (mp *musicParser) parseNumber(def int) int {
+	start := mp.pos
+	for isDigit(mp.peek(0)) {
+		mp.pos++
+	}
+	if start == mp.pos {
+		return def
+	}
+	num, err := strconv.Atoi(string(mp.src[start:mp.pos]))
+	if err != nil {
+		return def
+	}
+	return num
+}
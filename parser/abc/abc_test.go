@@ -0,0 +1,110 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package abc provides a parser for music in ABC notation, which is rendered
+// as a score.
+package abc
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseKey(t *testing.T) {
+	var testcases = []struct {
+		key string
+		exp int
+	}{
+		{"", 0},
+		{"C", 0},
+		{"G", 1},
+		{"Em", 1},
+		{"Bb", -2},
+		{"F#", 6},
+		{"D dor", 0},
+		{"Amix", 2},
+		{"none", 0},
+	}
+	for _, tc := range testcases {
+		if got := parseKey(tc.key); got != tc.exp {
+			t.Errorf("Key %q: expected %d, but got %d", tc.key, tc.exp, got)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	src := "X:1\nT:Example\n% a comment\nM:6/8\nL:1/8\nK:G\n" +
+		`"G"B2A G>A B | [DGB]3 z3 |]` + "\n" +
+		"(3cde ^f,/ _B' =c4 ||\n\nX:2\nT:Second\n"
+	tunes := Parse(src)
+	if len(tunes) != 2 {
+		t.Fatalf("Expected 2 tunes, but got %d", len(tunes))
+	}
+	tune := tunes[0]
+	if tune.Title != "Example" || len(tune.Lines) != 2 {
+		t.Fatalf("Unexpected tune %q with %d lines", tune.Title, len(tune.Lines))
+	}
+	line := tune.Lines[0]
+	if line.Key != 1 || line.Meter != "6/8" || tune.Lines[1].Meter != "" {
+		t.Errorf("Unexpected key %d and meter %q", line.Key, line.Meter)
+	}
+	var got []string
+	for _, l := range tune.Lines {
+		for _, sym := range l.Symbols {
+			got = append(got, symbolString(sym))
+		}
+	}
+	exp := "G:B/0.25 A/0.125 G/0.1875 A/0.0625 B/0.125 | DGB/0.375 z/0.375 |] " +
+		"c/0.0833 d/0.0833 e/0.0833 ^F/0.0625 _b/0.125 =c/0.5 ||"
+	if s := strings.Join(got, " "); s != exp {
+		t.Errorf("\nexp=%q\ngot=%q", exp, s)
+	}
+	if svg := tune.SVG(); !strings.HasPrefix(svg, "<svg ") || !strings.Contains(svg, ">Example</text>") {
+		t.Errorf("Unexpected SVG: %q", svg)
+	}
+}
+
+func symbolString(sym Symbol) string {
+	var sb strings.Builder
+	if sym.Text != "" {
+		sb.WriteString(sym.Text)
+		sb.WriteByte(':')
+	}
+	switch sym.Kind {
+	case KindBar:
+		return sym.Bar
+	case KindRest:
+		sb.WriteByte('z')
+	case KindNote:
+		for _, n := range sym.Notes {
+			sb.WriteString(n.Accidental)
+			step := n.Step
+			octave := 0
+			for step < 0 {
+				step += 7
+				octave--
+			}
+			for step >= 14 {
+				step -= 7
+				octave++
+			}
+			sb.WriteByte("CDEFGABcdefgab"[step])
+			if octave < 0 {
+				sb.WriteString(strings.Repeat(",", -octave))
+			} else {
+				sb.WriteString(strings.Repeat("'", octave))
+			}
+		}
+	}
+	sb.WriteByte('/')
+	sb.WriteString(strings.TrimRight(strconv.FormatFloat(sym.Length, 'f', 4, 64), "0"))
+	return sb.String()
+}
@@ -0,0 +1,420 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package abc provides a parser for music in ABC notation, which is rendered
+// as a score.
+package abc
+
+import (
+	"html"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Dimensions of the score. All vertical positions are computed from the
+// distance of two staff lines.
+const (
+	lineGap      = 8.0              // Distance of two staff lines
+	stepHeight   = lineGap / 2      // Distance of two diagonal steps
+	staffHeight  = 4 * lineGap      // Distance of top and bottom line
+	rowHeight    = staffHeight + 68 // Height of one line of music
+	staffOffset  = 36.0             // Space above the staff of a row
+	headerHeight = 44.0             // Height of title and composer
+	stemLength   = 3.5 * lineGap    // Length of a stem
+	margin       = 10.0             // Left and right margin
+	clefWidth    = 28.0             // Space for the clef
+	minWidth     = 200.0            // Minimum width of the score
+	topStep      = 10               // Step of the top staff line (F5)
+	middleStep   = 6                // Step of the middle staff line (B4)
+	bottomStep   = 2                // Step of the bottom staff line (E4)
+	fontFamily   = "serif"          // Font of all text
+	strokeStyle  = `stroke="black"` // Style of all lines
+	fillStyle    = `fill="black"`   // Style of all filled shapes
+)
+
+// Steps of the accidentals of a key signature, in the order they are added.
+var (
+	sharpSteps = []int{10, 7, 11, 8, 5, 9, 6}
+	flatSteps  = []int{6, 9, 5, 8, 4, 7, 3}
+)
+
+// score contains the state while writing a SVG.
+type score struct {
+	sb       strings.Builder
+	staffTop float64
+}
+
+// SVG returns the tune as a SVG image.
+func (t *Tune) SVG() string {
+	width := minWidth
+	for i := range t.Lines {
+		width = math.Max(width, t.Lines[i].width())
+	}
+	top := 0.0
+	if t.Title != "" || t.Composer != "" || t.Tempo != "" {
+		top = headerHeight
+	}
+	height := top + float64(len(t.Lines))*rowHeight
+
+	var s score
+	s.sb.WriteString(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 `)
+	s.numbers(" ", width, height)
+	s.sb.WriteString(`" width="`)
+	s.numbers("", width)
+	s.sb.WriteString(`" height="`)
+	s.numbers("", height)
+	s.sb.WriteString(`" font-family="` + fontFamily + `">`)
+	s.header(t, width)
+	for i := range t.Lines {
+		s.staffTop = top + float64(i)*rowHeight + staffOffset
+		s.line(&t.Lines[i], width)
+	}
+	s.sb.WriteString(`</svg>`)
+	return s.sb.String()
+}
+
+// width returns the width of the line, when written as a staff.
+func (l *Line) width() float64 {
+	x := l.prefixWidth()
+	for i := range l.Symbols {
+		x += l.Symbols[i].width()
+	}
+	return x + margin
+}
+
+// prefixWidth returns the width of the clef, key, and meter.
+func (l *Line) prefixWidth() float64 {
+	x := margin + clefWidth + 7*math.Abs(float64(l.Key)) + 4
+	if l.Meter != "" {
+		x += 20
+	}
+	return x
+}
+
+// width returns the horizontal space of a symbol.
+func (sym *Symbol) width() float64 {
+	if sym.Kind == KindBar {
+		return 8 + 4*float64(len(sym.Bar))
+	}
+	w := math.Min(14+48*sym.Length, 48)
+	for _, n := range sym.Notes {
+		if n.Accidental != "" {
+			w += 10
+			break
+		}
+	}
+	if _, dotted := noteValue(sym.Length); dotted {
+		w += 4
+	}
+	return w
+}
+
+// noteValue returns the written note value of a length, e.g. 1/4 for a
+// quarter note, and whether the note is dotted.
+func noteValue(length float64) (float64, bool) {
+	value := 1.0
+	for value > 1.0/64 && value > length+1e-9 {
+		value /= 2
+	}
+	if value < length && math.Abs(length-1.5*value) < 1e-9 {
+		return value, true
+	}
+	return value, false
+}
+
+// stepY returns the vertical position of a diatonic step.
+func (s *score) stepY(step int) float64 {
+	return s.staffTop + float64(topStep-step)*stepHeight
+}
+
+func (s *score) header(t *Tune, width float64) {
+	if t.Title != "" {
+		s.text(width/2, 20, "middle", 16, t.Title)
+	}
+	if t.Composer != "" {
+		s.text(width-margin, 36, "end", 11, t.Composer)
+	}
+	if t.Tempo != "" {
+		s.text(margin, 36, "start", 11, t.Tempo)
+	}
+}
+
+func (s *score) line(l *Line, width float64) {
+	for step := bottomStep; step <= topStep; step += 2 {
+		y := s.stepY(step)
+		s.hline(margin, width-margin, y)
+	}
+	s.text(margin, s.stepY(bottomStep)+lineGap, "start", 4.8*lineGap, "\U0001D11E")
+	x := margin + clefWidth
+	steps, glyph := sharpSteps, "♯"
+	if l.Key < 0 {
+		steps, glyph = flatSteps, "♭"
+	}
+	for i := 0; i < int(math.Abs(float64(l.Key))); i++ {
+		s.text(x+3, s.stepY(steps[i])+stepHeight, "middle", 2*lineGap, glyph)
+		x += 7
+	}
+	x += 4
+	if l.Meter != "" {
+		s.meter(x+8, l.Meter)
+		x += 20
+	}
+	for i := range l.Symbols {
+		sym := &l.Symbols[i]
+		w := sym.width()
+		switch sym.Kind {
+		case KindBar:
+			s.bar(x+w/2, sym.Bar)
+		case KindRest:
+			s.rest(x+w/2, sym.Length)
+		case KindNote:
+			s.chord(x+w-math.Min(14+48*sym.Length, 48)/2, sym)
+		}
+		if sym.Text != "" {
+			s.text(x+2, s.staffTop-2*lineGap, "start", 11, sym.Text)
+		}
+		x += w
+	}
+}
+
+func (s *score) meter(x float64, meter string) {
+	if meter == "C" || meter == "C|" {
+		s.text(x, s.stepY(middleStep)+lineGap, "middle", 3*lineGap, meter[:1])
+		if meter == "C|" {
+			s.vline(x, s.staffTop-stepHeight, s.staffTop+staffHeight+stepHeight, 1)
+		}
+		return
+	}
+	if num, den, ok := parseFraction(meter); ok {
+		s.text(x, s.staffTop+2*lineGap-1, "middle", 2.5*lineGap, strconv.Itoa(num))
+		s.text(x, s.staffTop+staffHeight-1, "middle", 2.5*lineGap, strconv.Itoa(den))
+	}
+}
+
+func (s *score) bar(x float64, bar string) {
+	top, bottom := s.staffTop, s.staffTop+staffHeight
+	w := 4 * float64(len(bar))
+	pos := x - w/2
+	for i, ch := range bar {
+		px := pos + 4*float64(i) + 2
+		switch ch {
+		case '|':
+			s.vline(px, top, bottom, 1)
+		case ']', '[':
+			s.vline(px, top, bottom, 3)
+		case ':':
+			s.circle(px, s.stepY(middleStep-1), 1.5)
+			s.circle(px, s.stepY(middleStep+1), 1.5)
+		}
+	}
+}
+
+func (s *score) rest(x, length float64) {
+	value, dotted := noteValue(length)
+	switch {
+	case value >= 1:
+		s.rect(x-5, s.stepY(middleStep+2), 10, stepHeight)
+	case value >= 0.5:
+		s.rect(x-5, s.stepY(middleStep)-stepHeight, 10, stepHeight)
+	case value >= 0.25:
+		y := s.stepY(middleStep + 3)
+		s.sb.WriteString(`<path fill="none" ` + strokeStyle + ` stroke-width="2" d="M`)
+		s.numbers(",", x-2, y)
+		s.sb.WriteString(`l5,6l-5,6l5,6l-3,-1l-2,3"/>`)
+	default:
+		flags := flagCount(value)
+		y := s.stepY(middleStep + 2)
+		for i := 0; i < flags; i++ {
+			s.circle(x-2-float64(i), y+float64(i)*lineGap+2, 2)
+		}
+		s.sb.WriteString(`<line ` + strokeStyle + ` stroke-width="1.2" x1="`)
+		s.numbers(`" y1="`, x+3, y+1)
+		s.sb.WriteString(`" x2="`)
+		s.numbers(`" y2="`, x-1-float64(flags), y+float64(flags)*lineGap+4)
+		s.sb.WriteString(`"/>`)
+	}
+	if dotted {
+		s.circle(x+8, s.stepY(middleStep+1), 1.5)
+	}
+}
+
+// flagCount returns the number of flags of a note value.
+func flagCount(value float64) int {
+	flags := 0
+	for v := value; v < 0.25-1e-9; v *= 2 {
+		flags++
+	}
+	return flags
+}
+
+func (s *score) chord(x float64, sym *Symbol) {
+	value, dotted := noteValue(sym.Length)
+	low, high, sum := sym.Notes[0].Step, sym.Notes[0].Step, 0
+	for _, n := range sym.Notes {
+		low, high = minInt(low, n.Step), maxInt(high, n.Step)
+		sum += n.Step
+	}
+	for _, n := range sym.Notes {
+		y := s.stepY(n.Step)
+		s.ledgers(x, n.Step)
+		s.head(x, y, value >= 0.5)
+		if glyph := accidentalGlyph(n.Accidental); glyph != "" {
+			s.text(x-10, y+stepHeight, "middle", 2*lineGap, glyph)
+		}
+		if dotted {
+			dy := 0.0
+			if n.Step%2 == 0 {
+				dy = -stepHeight // Dots are written in a space, not on a line
+			}
+			s.circle(x+8, y+dy, 1.5)
+		}
+	}
+	if value >= 1 {
+		return
+	}
+	flags := flagCount(value)
+	if sum < middleStep*len(sym.Notes) {
+		// Stem up, on the right side of the note head
+		sx, y1, y2 := x+4, s.stepY(low), s.stepY(high)-stemLength
+		s.vline(sx, y2, y1, 1)
+		for i := 0; i < flags; i++ {
+			s.flag(sx, y2+float64(i)*stepHeight*1.5, 1)
+		}
+		return
+	}
+	// Stem down, on the left side of the note head
+	sx, y1, y2 := x-4, s.stepY(high), s.stepY(low)+stemLength
+	s.vline(sx, y1, y2, 1)
+	for i := 0; i < flags; i++ {
+		s.flag(sx, y2-float64(i)*stepHeight*1.5, -1)
+	}
+}
+
+// ledgers writes the ledger lines of a note outside of the staff.
+func (s *score) ledgers(x float64, step int) {
+	for l := bottomStep - 2; l >= step; l -= 2 {
+		s.hline(x-7, x+7, s.stepY(l))
+	}
+	for l := topStep + 2; l <= step; l += 2 {
+		s.hline(x-7, x+7, s.stepY(l))
+	}
+}
+
+func (s *score) head(x, y float64, hollow bool) {
+	s.sb.WriteString(`<ellipse cx="`)
+	s.numbers(`" cy="`, x, y)
+	s.sb.WriteString(`" rx="4.5" ry="3.3" transform="rotate(-20 `)
+	s.numbers(" ", x, y)
+	s.sb.WriteString(`)`)
+	if hollow {
+		s.sb.WriteString(`" fill="none" ` + strokeStyle + ` stroke-width="1.5"/>`)
+	} else {
+		s.sb.WriteString(`" ` + fillStyle + `/>`)
+	}
+}
+
+// flag writes a flag at the end of a stem. The direction is 1 for an upward
+// stem, and -1 for a downward stem.
+func (s *score) flag(x, y, dir float64) {
+	s.sb.WriteString(`<path fill="none" ` + strokeStyle + ` stroke-width="1.5" d="M`)
+	s.numbers(",", x, y)
+	s.sb.WriteString(`q`)
+	s.numbers(",", 2, 5*dir, 6, 8*dir)
+	s.sb.WriteString(`"/>`)
+}
+
+func accidentalGlyph(acc string) string {
+	switch acc {
+	case "^":
+		return "♯"
+	case "^^":
+		return "\U0001D12A"
+	case "_":
+		return "♭"
+	case "__":
+		return "\U0001D12B"
+	case "=":
+		return "♮"
+	}
+	return ""
+}
+
+func (s *score) hline(x1, x2, y float64) {
+	s.sb.WriteString(`<line ` + strokeStyle + ` stroke-width="0.8" x1="`)
+	s.numbers(`" y1="`, x1, y)
+	s.sb.WriteString(`" x2="`)
+	s.numbers(`" y2="`, x2, y)
+	s.sb.WriteString(`"/>`)
+}
+
+func (s *score) vline(x, y1, y2, width float64) {
+	s.sb.WriteString(`<line ` + strokeStyle + ` stroke-width="`)
+	s.numbers("", width)
+	s.sb.WriteString(`" x1="`)
+	s.numbers(`" y1="`, x, y1)
+	s.sb.WriteString(`" x2="`)
+	s.numbers(`" y2="`, x, y2)
+	s.sb.WriteString(`"/>`)
+}
+
+func (s *score) rect(x, y, w, h float64) {
+	s.sb.WriteString(`<rect x="`)
+	s.numbers(`" y="`, x, y)
+	s.sb.WriteString(`" width="`)
+	s.numbers(`" height="`, w, h)
+	s.sb.WriteString(`" ` + fillStyle + `/>`)
+}
+
+func (s *score) circle(x, y, r float64) {
+	s.sb.WriteString(`<circle cx="`)
+	s.numbers(`" cy="`, x, y)
+	s.sb.WriteString(`" r="`)
+	s.numbers("", r)
+	s.sb.WriteString(`" ` + fillStyle + `/>`)
+}
+
+func (s *score) text(x, y float64, anchor string, size float64, text string) {
+	s.sb.WriteString(`<text x="`)
+	s.numbers(`" y="`, x, y)
+	s.sb.WriteString(`" text-anchor="` + anchor + `" font-size="`)
+	s.numbers("", size)
+	s.sb.WriteString(`" ` + fillStyle + `>`)
+	s.sb.WriteString(html.EscapeString(text))
+	s.sb.WriteString(`</text>`)
+}
+
+func (s *score) numbers(sep string, nums ...float64) {
+	for i, n := range nums {
+		if i > 0 {
+			s.sb.WriteString(sep)
+		}
+		s.sb.WriteString(num(n))
+	}
+}
+
+// num formats a number with at most one decimal place.
+func num(f float64) string {
+	return strconv.FormatFloat(math.Round(f*10)/10, 'f', -1, 64)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
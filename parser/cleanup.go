@@ -30,6 +30,12 @@ func cleanupBlockSlice(bs ast.BlockSlice) {
 	}
 	t := ast.NewTopDownTraverser(cv)
 	t.VisitBlockSlice(bs)
+
+	// Slugs are calculated after the traversal, so that they never use an
+	// identifier that is given explicitly by a later heading.
+	for _, hn := range cv.headings {
+		cv.setSlug(hn)
+	}
 	if cv.hasMark {
 		cv.doMark = true
 		t.VisitBlockSlice(bs)
@@ -37,10 +43,11 @@ func cleanupBlockSlice(bs ast.BlockSlice) {
 }
 
 type cleanupVisitor struct {
-	textEnc encoder.Encoder
-	ids     map[string]ast.Node
-	hasMark bool
-	doMark  bool
+	textEnc  encoder.Encoder
+	ids      map[string]ast.Node
+	headings []*ast.HeadingNode // headings without an explicit identifier
+	hasMark  bool
+	doMark   bool
 }
 
 // VisitVerbatim does nothing.
@@ -49,9 +56,23 @@ func (cv *cleanupVisitor) VisitVerbatim(vn *ast.VerbatimNode) {}
 // VisitRegion does nothing.
 func (cv *cleanupVisitor) VisitRegion(rn *ast.RegionNode) {}
 
-// VisitHeading calculates the heading slug.
+// VisitHeading reserves an identifier given as an attribute as the heading
+// slug. It is changed only if another heading has the same identifier. All
+// other slugs are calculated later.
 func (cv *cleanupVisitor) VisitHeading(hn *ast.HeadingNode) {
-	if cv.doMark || hn == nil || hn.Inlines == nil {
+	if cv.doMark || hn == nil {
+		return
+	}
+	if id, ok := hn.Attrs.Get("id"); ok && id != "" {
+		hn.Slug = cv.addIdentifier(id, hn)
+		return
+	}
+	cv.headings = append(cv.headings, hn)
+}
+
+// setSlug calculates the slug of a heading from its text.
+func (cv *cleanupVisitor) setSlug(hn *ast.HeadingNode) {
+	if hn.Inlines == nil {
 		return
 	}
 	var sb strings.Builder
@@ -0,0 +1,108 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package drawing provides a parser for drawings, which are stored as JSON.
+package drawing
+
+import (
+	"encoding/json"
+	"strings"
+
+	"zettelstore.de/z/ast"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/input"
+	"zettelstore.de/z/parser"
+)
+
+func init() {
+	parser.Register(&parser.Info{
+		Name:         "drawing",
+		AltNames:     []string{"excalidraw"},
+		ParseBlocks:  parseBlocks,
+		ParseInlines: parseInlines,
+	})
+}
+
+// Drawing is the content of a drawing zettel. Its format is a subset of the
+// format used by Excalidraw, so that drawings can be exchanged.
+type Drawing struct {
+	Type     string    `json:"type"`
+	Version  int       `json:"version"`
+	Elements []Element `json:"elements"`
+	AppState struct {
+		ViewBackgroundColor string `json:"viewBackgroundColor,omitempty"`
+	} `json:"appState"`
+}
+
+// Element is a single shape of a drawing. Coordinates of points are relative
+// to X and Y.
+type Element struct {
+	Type            string       `json:"type"`
+	X               float64      `json:"x"`
+	Y               float64      `json:"y"`
+	Width           float64      `json:"width"`
+	Height          float64      `json:"height"`
+	Angle           float64      `json:"angle,omitempty"`
+	StrokeColor     string       `json:"strokeColor,omitempty"`
+	BackgroundColor string       `json:"backgroundColor,omitempty"`
+	StrokeWidth     float64      `json:"strokeWidth,omitempty"`
+	Points          [][2]float64 `json:"points,omitempty"`
+	Text            string       `json:"text,omitempty"`
+	FontSize        float64      `json:"fontSize,omitempty"`
+	IsDeleted       bool         `json:"isDeleted,omitempty"`
+}
+
+// Parse reads a drawing from its JSON source.
+func Parse(src string) (*Drawing, error) {
+	var d Drawing
+	if err := json.Unmarshal([]byte(src), &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func parseBlocks(inp *input.Input, m *meta.Meta, syntax string) ast.BlockSlice {
+	if ins := parseDrawing(inp, m.GetDefault(meta.KeyTitle, "")); ins != nil {
+		return ast.BlockSlice{&ast.ParaNode{Inlines: ins}}
+	}
+	// Show the source, if it is not a valid drawing.
+	return ast.BlockSlice{
+		&ast.VerbatimNode{
+			Code:  ast.VerbatimProg,
+			Attrs: &ast.Attributes{Attrs: map[string]string{"": "json"}},
+			Lines: strings.Split(strings.TrimRight(inp.Src, "\n"), "\n"),
+		},
+	}
+}
+
+func parseInlines(inp *input.Input, syntax string) ast.InlineSlice {
+	if ins := parseDrawing(inp, ""); ins != nil {
+		return ins
+	}
+	return ast.InlineSlice{}
+}
+
+func parseDrawing(inp *input.Input, title string) ast.InlineSlice {
+	d, err := Parse(inp.Src)
+	if err != nil {
+		return nil
+	}
+	var alt ast.InlineSlice
+	if title != "" {
+		alt = ast.InlineSlice{&ast.TextNode{Text: title}}
+	}
+	return ast.InlineSlice{
+		&ast.ImageNode{
+			Blob:    []byte(d.SVG()),
+			Syntax:  "svg",
+			Inlines: alt,
+		},
+	}
+}
@@ -0,0 +1,49 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package drawing provides a parser for drawings, which are stored as JSON.
+package drawing
+
+import "testing"
+
+func TestSVG(t *testing.T) {
+	var testcases = []struct {
+		src string
+		exp string
+	}{
+		{`{"elements":[]}`,
+			`<svg xmlns="http://www.w3.org/2000/svg" viewBox="-10 -10 120 120" width="120" height="120"></svg>`},
+		{`{"elements":[{"type":"rectangle","x":0,"y":0,"width":10,"height":20}]}`,
+			`<svg xmlns="http://www.w3.org/2000/svg" viewBox="-10 -10 30 40" width="30" height="40">` +
+				`<g stroke="black" stroke-width="1" stroke-linecap="round" stroke-linejoin="round" fill="none">` +
+				`<rect x="0" y="0" width="10" height="20"/></g></svg>`},
+		{`{"elements":[{"type":"ellipse","x":10,"y":10,"width":-10,"height":-10,"isDeleted":true}]}`,
+			`<svg xmlns="http://www.w3.org/2000/svg" viewBox="-10 -10 120 120" width="120" height="120"></svg>`},
+		{`{"elements":[{"type":"line","x":5,"y":5,"points":[[0,0],[10,0]],"strokeColor":"red\" onload=\"x"}]}`,
+			`<svg xmlns="http://www.w3.org/2000/svg" viewBox="-5 -5 30 20" width="30" height="20">` +
+				`<g stroke="black" stroke-width="1" stroke-linecap="round" stroke-linejoin="round" fill="none">` +
+				`<polyline fill="none" points="5,5 15,5"/></g></svg>`},
+		{`{"elements":[{"type":"text","x":0,"y":0,"text":"a<b","fontSize":10,"strokeColor":"#00f"}]}`,
+			`<svg xmlns="http://www.w3.org/2000/svg" viewBox="-10 -10 38 32.5" width="38" height="32.5">` +
+				`<g stroke="rgb(0,0,255)" stroke-width="1" stroke-linecap="round" stroke-linejoin="round" fill="none">` +
+				`<text stroke="none" fill="rgb(0,0,255)" font-family="sans-serif" font-size="10">` +
+				`<tspan x="0" y="10">a&lt;b</tspan></text></g></svg>`},
+	}
+	for i, tc := range testcases {
+		d, err := Parse(tc.src)
+		if err != nil {
+			t.Errorf("TC=%d: unexpected error %v", i, err)
+			continue
+		}
+		if got := d.SVG(); got != tc.exp {
+			t.Errorf("TC=%d:\nexp=%q\ngot=%q", i, tc.exp, got)
+		}
+	}
+}
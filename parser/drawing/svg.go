@@ -0,0 +1,298 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package drawing provides a parser for drawings, which are stored as JSON.
+package drawing
+
+import (
+	"html"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Default values of an element, if not specified otherwise.
+const (
+	defaultStroke   = "black"
+	defaultFontSize = 20
+	lineHeight      = 1.25
+	padding         = 10
+	arrowHeadLength = 12
+)
+
+// box is a bounding box.
+type box struct {
+	minX, minY, maxX, maxY float64
+	valid                  bool
+}
+
+func (b *box) add(x, y float64) {
+	if !b.valid {
+		b.minX, b.minY, b.maxX, b.maxY, b.valid = x, y, x, y, true
+		return
+	}
+	b.minX, b.maxX = math.Min(b.minX, x), math.Max(b.maxX, x)
+	b.minY, b.maxY = math.Min(b.minY, y), math.Max(b.maxY, y)
+}
+
+// SVG returns the drawing as a SVG image.
+func (d *Drawing) SVG() string {
+	var bounds box
+	elems := make([]Element, 0, len(d.Elements))
+	for _, e := range d.Elements {
+		if e.IsDeleted {
+			continue
+		}
+		e.normalize()
+		elems = append(elems, e)
+		e.addBounds(&bounds)
+	}
+	if !bounds.valid {
+		bounds = box{0, 0, 100, 100, true}
+	}
+	x, y := bounds.minX-padding, bounds.minY-padding
+	w, h := bounds.maxX-bounds.minX+2*padding, bounds.maxY-bounds.minY+2*padding
+
+	var sb strings.Builder
+	sb.WriteString(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="`)
+	writeNumbers(&sb, " ", x, y, w, h)
+	sb.WriteString(`" width="`)
+	writeNumbers(&sb, "", w)
+	sb.WriteString(`" height="`)
+	writeNumbers(&sb, "", h)
+	sb.WriteString(`">`)
+	if bg := color(d.AppState.ViewBackgroundColor, ""); bg != "" && bg != "none" {
+		sb.WriteString(`<rect x="`)
+		writeNumbers(&sb, "", x)
+		sb.WriteString(`" y="`)
+		writeNumbers(&sb, "", y)
+		sb.WriteString(`" width="100%" height="100%" fill="`)
+		sb.WriteString(bg)
+		sb.WriteString(`"/>`)
+	}
+	for _, e := range elems {
+		e.writeSVG(&sb)
+	}
+	sb.WriteString("</svg>")
+	return sb.String()
+}
+
+// normalize ensures that width and height are not negative, and that text
+// has a size.
+func (e *Element) normalize() {
+	if e.Width < 0 {
+		e.X, e.Width = e.X+e.Width, -e.Width
+	}
+	if e.Height < 0 {
+		e.Y, e.Height = e.Y+e.Height, -e.Height
+	}
+	if e.Type != "text" {
+		return
+	}
+	if e.FontSize <= 0 {
+		e.FontSize = defaultFontSize
+	}
+	lines := strings.Split(e.Text, "\n")
+	if e.Height == 0 {
+		e.Height = float64(len(lines)) * e.FontSize * lineHeight
+	}
+	if e.Width == 0 {
+		maxLen := 0
+		for _, line := range lines {
+			if l := len([]rune(line)); l > maxLen {
+				maxLen = l
+			}
+		}
+		e.Width = float64(maxLen) * e.FontSize * 0.6
+	}
+}
+
+func (e *Element) addBounds(b *box) {
+	switch e.Type {
+	case "line", "arrow", "freedraw":
+		for _, p := range e.Points {
+			b.add(e.X+p[0], e.Y+p[1])
+		}
+	default:
+		b.add(e.X, e.Y)
+		b.add(e.X+e.Width, e.Y+e.Height)
+	}
+}
+
+func (e *Element) writeSVG(sb *strings.Builder) {
+	stroke := color(e.StrokeColor, defaultStroke)
+	fill := color(e.BackgroundColor, "none")
+	strokeWidth := e.StrokeWidth
+	if strokeWidth <= 0 {
+		strokeWidth = 1
+	}
+	sb.WriteString("<g")
+	if e.Angle != 0 {
+		sb.WriteString(` transform="rotate(`)
+		writeNumbers(sb, " ", e.Angle*180/math.Pi, e.X+e.Width/2, e.Y+e.Height/2)
+		sb.WriteString(`)"`)
+	}
+	sb.WriteString(` stroke="`)
+	sb.WriteString(stroke)
+	sb.WriteString(`" stroke-width="`)
+	writeNumbers(sb, "", strokeWidth)
+	sb.WriteString(`" stroke-linecap="round" stroke-linejoin="round" fill="`)
+	sb.WriteString(fill)
+	sb.WriteString(`">`)
+	switch e.Type {
+	case "rectangle":
+		sb.WriteString(`<rect x="`)
+		writeNumbers(sb, "", e.X)
+		sb.WriteString(`" y="`)
+		writeNumbers(sb, "", e.Y)
+		sb.WriteString(`" width="`)
+		writeNumbers(sb, "", e.Width)
+		sb.WriteString(`" height="`)
+		writeNumbers(sb, "", e.Height)
+		sb.WriteString(`"/>`)
+	case "ellipse":
+		sb.WriteString(`<ellipse cx="`)
+		writeNumbers(sb, "", e.X+e.Width/2)
+		sb.WriteString(`" cy="`)
+		writeNumbers(sb, "", e.Y+e.Height/2)
+		sb.WriteString(`" rx="`)
+		writeNumbers(sb, "", e.Width/2)
+		sb.WriteString(`" ry="`)
+		writeNumbers(sb, "", e.Height/2)
+		sb.WriteString(`"/>`)
+	case "diamond":
+		sb.WriteString(`<polygon points="`)
+		writeNumbers(sb, " ",
+			e.X+e.Width/2, e.Y, e.X+e.Width, e.Y+e.Height/2,
+			e.X+e.Width/2, e.Y+e.Height, e.X, e.Y+e.Height/2)
+		sb.WriteString(`"/>`)
+	case "line", "freedraw":
+		e.writePolyline(sb, e.Points)
+	case "arrow":
+		e.writePolyline(sb, e.Points)
+		e.writeArrowHead(sb)
+	case "text":
+		e.writeText(sb, stroke)
+	}
+	sb.WriteString("</g>")
+}
+
+func (e *Element) writePolyline(sb *strings.Builder, points [][2]float64) {
+	if len(points) == 0 {
+		return
+	}
+	sb.WriteString(`<polyline fill="none" points="`)
+	for i, p := range points {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		writeNumbers(sb, ",", e.X+p[0], e.Y+p[1])
+	}
+	sb.WriteString(`"/>`)
+}
+
+func (e *Element) writeArrowHead(sb *strings.Builder) {
+	n := len(e.Points)
+	if n < 2 {
+		return
+	}
+	from, to := e.Points[n-2], e.Points[n-1]
+	angle := math.Atan2(to[1]-from[1], to[0]-from[0])
+	head := make([][2]float64, 0, 3)
+	for _, delta := range []float64{math.Pi / 6, 0, -math.Pi / 6} {
+		if delta == 0 {
+			head = append(head, to)
+			continue
+		}
+		head = append(head, [2]float64{
+			to[0] - arrowHeadLength*math.Cos(angle+delta),
+			to[1] - arrowHeadLength*math.Sin(angle+delta),
+		})
+	}
+	e.writePolyline(sb, head)
+}
+
+func (e *Element) writeText(sb *strings.Builder, stroke string) {
+	sb.WriteString(`<text stroke="none" fill="`)
+	sb.WriteString(stroke)
+	sb.WriteString(`" font-family="sans-serif" font-size="`)
+	writeNumbers(sb, "", e.FontSize)
+	sb.WriteString(`">`)
+	for i, line := range strings.Split(e.Text, "\n") {
+		sb.WriteString(`<tspan x="`)
+		writeNumbers(sb, "", e.X)
+		sb.WriteString(`" y="`)
+		writeNumbers(sb, "", e.Y+(float64(i)+1)*e.FontSize*lineHeight-e.FontSize*(lineHeight-1))
+		sb.WriteString(`">`)
+		sb.WriteString(html.EscapeString(line))
+		sb.WriteString("</tspan>")
+	}
+	sb.WriteString("</text>")
+}
+
+// reColor matches all color values that are safe to be used in a SVG
+// attribute.
+var reColor = regexp.MustCompile(`^(#[0-9a-fA-F]{3,8}|[a-zA-Z]+|rgba?\([0-9., ]+\))$`)
+
+// color returns a safe color value, or the default value. Hexadecimal
+// values are translated into the rgb() notation, because the SVG image is
+// embedded into a data URL, where the character "#" starts a fragment.
+func color(value, def string) string {
+	if value == "transparent" {
+		return "none"
+	}
+	if !reColor.MatchString(value) {
+		return def
+	}
+	if value[0] != '#' {
+		return value
+	}
+	hex := value[1:]
+	if len(hex) == 3 || len(hex) == 4 {
+		hex = expandHex(hex)
+	}
+	if len(hex) != 6 && len(hex) != 8 {
+		return def
+	}
+	var comps []string
+	for i := 0; i < len(hex); i += 2 {
+		c, err := strconv.ParseUint(hex[i:i+2], 16, 8)
+		if err != nil {
+			return def
+		}
+		comps = append(comps, strconv.FormatUint(c, 10))
+	}
+	if len(comps) == 4 {
+		alpha, _ := strconv.Atoi(comps[3])
+		comps[3] = strconv.FormatFloat(math.Round(float64(alpha)/255*100)/100, 'f', -1, 64)
+		return "rgba(" + strings.Join(comps, ",") + ")"
+	}
+	return "rgb(" + strings.Join(comps, ",") + ")"
+}
+
+// expandHex doubles each digit of a short hexadecimal color value.
+func expandHex(hex string) string {
+	var sb strings.Builder
+	for _, ch := range hex {
+		sb.WriteRune(ch)
+		sb.WriteRune(ch)
+	}
+	return sb.String()
+}
+
+func writeNumbers(sb *strings.Builder, sep string, numbers ...float64) {
+	for i, f := range numbers {
+		if i > 0 {
+			sb.WriteString(sep)
+		}
+		sb.WriteString(strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64))
+	}
+}
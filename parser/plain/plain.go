@@ -33,6 +33,11 @@ func init() {
 		ParseBlocks:  parseBlocks,
 		ParseInlines: parseInlines,
 	})
+	parser.Register(&parser.Info{
+		Name:         "js",
+		ParseBlocks:  parseBlocks,
+		ParseInlines: parseInlines,
+	})
 	parser.Register(&parser.Info{
 		Name:         "svg",
 		ParseBlocks:  parseSVGBlocks,
@@ -122,9 +122,13 @@ func (cp *zmkP) parseColon() (ast.BlockNode, bool) {
 	return cp.parseDefDescr()
 }
 
+// initialInlines is the initial capacity of the inline slice of a paragraph.
+// It avoids repeated slice growth for typical paragraphs.
+const initialInlines = 16
+
 // parsePara parses paragraphed inline material.
 func (cp *zmkP) parsePara() *ast.ParaNode {
-	pn := &ast.ParaNode{}
+	pn := &ast.ParaNode{Inlines: make(ast.InlineSlice, 0, initialInlines)}
 	for {
 		in := cp.parseInline()
 		if in == nil {
@@ -282,6 +286,9 @@ func (cp *zmkP) parseHeading() (hn *ast.HeadingNode, success bool) {
 			return hn, true
 		}
 		if inp.Ch == '{' {
+			if _, ok := in.(*ast.SpaceNode); !ok {
+				hn.Inlines = append(hn.Inlines, in)
+			}
 			attrs := cp.parseAttributes(true)
 			hn.Attrs = attrs
 			inp.SkipToEOL()
@@ -359,6 +366,11 @@ loopInit:
 			cp.lists = append(cp.lists, ln)
 		}
 	}
+	if code := codes[len(codes)-1]; code != ast.NestedListQuote {
+		if task, ok := cp.parseTask(); ok {
+			ln.SetTask(len(ln.Items), task)
+		}
+	}
 	ln.Items = append(ln.Items, ast.ItemSlice{cp.parseLinePara()})
 	listDepth := len(cp.lists)
 	for i := 0; i < newLnCount; i++ {
@@ -379,6 +391,36 @@ loopInit:
 	return nil, true
 }
 
+// parseTask parses the marker of a task item: "[ ]", "[x]", or "[X]".
+func (cp *zmkP) parseTask() (ast.Task, bool) {
+	inp := cp.inp
+	if inp.Ch != '[' || inp.PeekN(1) != ']' {
+		return ast.Task{}, false
+	}
+	var state ast.TaskState
+	switch inp.Peek() {
+	case ' ':
+		state = ast.TaskOpen
+	case 'x', 'X':
+		state = ast.TaskDone
+	default:
+		return ast.Task{}, false
+	}
+	switch inp.PeekN(2) {
+	case ' ', input.EOS, '\n', '\r':
+	default:
+		return ast.Task{}, false
+	}
+	task := ast.Task{State: state, Pos: inp.Pos}
+	inp.Next()
+	inp.Next()
+	inp.Next()
+	for inp.Ch == ' ' {
+		inp.Next()
+	}
+	return task, true
+}
+
 // parseDefTerm parses a term of a definition list.
 func (cp *zmkP) parseDefTerm() (res ast.BlockNode, success bool) {
 	inp := cp.inp
@@ -514,11 +556,11 @@ func (cp *zmkP) parseIndent() (res ast.BlockNode, success bool) {
 
 // parseLinePara parses one line of inline material.
 func (cp *zmkP) parseLinePara() *ast.ParaNode {
-	pn := &ast.ParaNode{}
+	pn := &ast.ParaNode{Inlines: make(ast.InlineSlice, 0, initialInlines)}
 	for {
 		in := cp.parseInline()
 		if in == nil {
-			if pn.Inlines == nil {
+			if len(pn.Inlines) == 0 {
 				return nil
 			}
 			return pn
@@ -468,6 +468,10 @@ func (cp *zmkP) parseNdash() (res *ast.TextNode, success bool) {
 	}
 	inp.Next()
 	inp.Next()
+	if inp.Ch == '-' {
+		inp.Next()
+		return &ast.TextNode{Text: "\u2014"}, true
+	}
 	return &ast.TextNode{Text: "\u2013"}, true
 }
 
@@ -15,23 +15,35 @@ import (
 	"strings"
 
 	"zettelstore.de/z/ast"
+	"zettelstore.de/z/config/runtime"
 )
 
 // postProcessBlocks is the entry point for post-processing a list of block nodes.
 func postProcessBlocks(bs ast.BlockSlice) ast.BlockSlice {
-	pp := postProcessor{}
+	pp := postProcessor{repl: getReplacements()}
 	return pp.processBlockSlice(bs)
 }
 
 // postProcessInlines is the entry point for post-processing a list of inline nodes.
 func postProcessInlines(is ast.InlineSlice) ast.InlineSlice {
-	pp := postProcessor{}
+	pp := postProcessor{repl: getReplacements()}
 	return pp.processInlineSlice(is)
 }
 
+// defaultReplacements are used if there is no runtime configuration.
+var defaultReplacements = map[string]string{"...": "\u2026"}
+
+func getReplacements() map[string]string {
+	if repl := runtime.GetReplacements(); repl != nil {
+		return repl
+	}
+	return defaultReplacements
+}
+
 // postProcessor is a visitor that cleans the abstract syntax tree.
 type postProcessor struct {
 	inVerse bool
+	repl    map[string]string // Replacements of text
 }
 
 // VisitPara post-processes a paragraph.
@@ -439,14 +451,19 @@ func (pp *postProcessor) processInlineSliceTail(ins ast.InlineSlice, toPos int)
 	return toPos
 }
 
+// processInlineSliceInplace replaces text, e.g. "..." with an ellipsis. Only
+// whole words are replaced, optionally followed by a punctuation character.
+// Literal and verbatim nodes are not changed.
 func (pp *postProcessor) processInlineSliceInplace(ins ast.InlineSlice) {
 	for _, in := range ins {
 		switch n := in.(type) {
 		case *ast.TextNode:
-			if n.Text == "..." {
-				n.Text = "\u2026"
-			} else if len(n.Text) == 4 && strings.IndexByte(",;:!?", n.Text[3]) >= 0 && n.Text[:3] == "..." {
-				n.Text = "\u2026" + n.Text[3:]
+			if repl, ok := pp.repl[n.Text]; ok {
+				n.Text = repl
+			} else if l := len(n.Text); l > 1 && strings.IndexByte(",;:!?", n.Text[l-1]) >= 0 {
+				if repl, ok = pp.repl[n.Text[:l-1]]; ok {
+					n.Text = repl + n.Text[l-1:]
+				}
 			}
 		}
 	}
@@ -331,6 +331,8 @@ func TestNDash(t *testing.T) {
 	checkTcs(t, TestCases{
 		{"--", "(PARA \u2013)"},
 		{"a--b", "(PARA a\u2013b)"},
+		{"a---b", "(PARA a\u2014b)"},
+		{"a --- b", "(PARA a SP \u2014 SP b)"},
 	})
 }
 
@@ -422,6 +424,8 @@ func TestHeading(t *testing.T) {
 		{" =", "(PARA =)"},
 		{"=== h\na", "(H2 h)(PARA a)"},
 		{"=== h i {-}", "(H2 h SP i)[ATTR -]"},
+		{"=== h{-}", "(H2 h)[ATTR -]"},
+		{"=== h i{id=x}", "(H2 h SP i)[ATTR id=x]"},
 	})
 }
 
@@ -485,6 +489,19 @@ func TestList(t *testing.T) {
 	})
 }
 
+func TestTaskList(t *testing.T) {
+	checkTcs(t, TestCases{
+		{"* [ ] abc", "(UL {[ ]@2(PARA abc)})"},
+		{"* [x] abc", "(UL {[x]@2(PARA abc)})"},
+		{"# [X]  abc", "(OL {[x]@2(PARA abc)})"},
+		{"* [ ]", "(UL {[ ]@2})"},
+		{"* abc\n* [ ] def\n** [x] ghi", "(UL {(PARA abc)} {[ ]@8(PARA def)(UL {[x]@19(PARA ghi)})})"},
+		{"* [ ]abc", "(UL {(PARA [ SP ]abc)})"},
+		{"* [y] abc", "(UL {(PARA [y] SP abc)})"},
+		{"> [ ] abc", "(QL {(PARA [ SP ] SP abc)})"},
+	})
+}
+
 func TestEnumAfterPara(t *testing.T) {
 	checkTcs(t, TestCases{
 		{"abc\n* def", "(PARA abc)(UL {(PARA def)})"},
@@ -675,8 +692,14 @@ var mapNestedListCode = map[ast.NestedListCode]string{
 
 func (tv *TestVisitor) VisitNestedList(ln *ast.NestedListNode) {
 	tv.b.WriteString(mapNestedListCode[ln.Code])
-	for _, item := range ln.Items {
+	for i, item := range ln.Items {
 		tv.b.WriteString(" {")
+		switch task := ln.Task(i); task.State {
+		case ast.TaskOpen:
+			fmt.Fprintf(&tv.b, "[ ]@%d", task.Pos)
+		case ast.TaskDone:
+			fmt.Fprintf(&tv.b, "[x]@%d", task.Pos)
+		}
 		tv.visitItemSlice(item)
 		tv.b.WriteByte('}')
 	}
@@ -49,11 +49,21 @@ var constZettelMap = map[id.Zid]constZettel{
 <meta name="generator" content="Zettelstore">
 {{{MetaHeader}}}
 <link rel="stylesheet" href="{{{StylesheetURL}}}">
+<link rel="manifest" href="{{{ManifestURL}}}">
+<link rel="icon" href="{{{IconURL}}}" type="image/svg+xml">
+<meta name="theme-color" content="#ffffff">
+<script defer src="{{{AppScriptURL}}}" data-sw="{{{ServiceWorkerURL}}}" data-scope="{{{HomeURL}}}"></script>
+{{#ZettelAssets}}
+{{{ZettelAssets}}}
+{{/ZettelAssets}}
 {{{Header}}}
 <title>{{Title}}</title>
 </head>
 <body>
+<div id="zs-offline" class="zs-offline" hidden>You are offline. Only zettel visited before are available.</div>
 <nav class="zs-menu">
+<input type="checkbox" id="zs-menu-toggle" class="zs-menu-toggle">
+<label for="zs-menu-toggle" class="zs-menu-button" title="Menu">&#9776;</label>
 <a href="{{{HomeURL}}}">Home</a>
 <div class="zs-dropdown">
 <button>Lists</button>
@@ -61,7 +71,16 @@ var constZettelMap = map[id.Zid]constZettel{
 <a href="{{{ListZettelURL}}}">List Zettel</a>
 <a href="{{{ListRolesURL}}}">List Roles</a>
 <a href="{{{ListTagsURL}}}">List Tags</a>
-</nav>
+<a href="{{{ListTasksURL}}}">List Tasks</a>
+<a href="{{{ReviewURL}}}">Review</a>
+<a href="{{{StudyURL}}}">Study Cards</a>
+<a href="{{{ListHierarchyURL}}}">List Hierarchy</a>
+<a href="{{{ModifiedURL}}}">Recently Modified</a>
+{{#StatusLinks}}<a href="{{{URL}}}">{{Text}}</a>
+{{/StatusLinks}}<a href="{{{DuplicatesURL}}}">Duplicate Zettel</a>
+<a href="{{{CitationsURL}}}">Literature</a>
+{{#ShowRoutes}}<a href="{{{RoutesURL}}}">Routes</a>
+{{/ShowRoutes}}</nav>
 </div>
 {{#CanCreate}}
 <div class="zs-dropdown">
@@ -73,12 +92,25 @@ var constZettelMap = map[id.Zid]constZettel{
 </nav>
 </div>
 {{/CanCreate}}
+{{#HasPinned}}
+<div class="zs-dropdown">
+<button>Pinned</button>
+<nav class="zs-dropdown-content">
+{{#PinnedLinks}}
+<a href="{{{URL}}}">{{{Text}}}</a>
+{{/PinnedLinks}}
+</nav>
+</div>
+{{/HasPinned}}
 {{#WithAuth}}
 <div class="zs-dropdown">
 <button>User</button>
 <nav class="zs-dropdown-content">
 {{#UserIsValid}}
 <a href="{{{UserZettelURL}}}">{{UserIdent}}</a>
+<a href="{{{FavoritesURL}}}">My favorites</a>
+<a href="{{{ReadLaterURL}}}">Read later</a>
+<a href="{{{VisitedURL}}}">Recently visited</a>
 <a href="{{{UserLogoutURL}}}">Logout</a>
 {{/UserIsValid}}
 {{^UserIsValid}}
@@ -87,6 +119,9 @@ var constZettelMap = map[id.Zid]constZettel{
 {{#CanReload}}
 <a href="{{{ReloadURL}}}">Reload</a>
 {{/CanReload}}
+{{#CanCustomize}}
+<a href="{{{CustomizeURL}}}">Customized zettel</a>
+{{/CanCustomize}}
 </nav>
 </div>
 {{/WithAuth}}
@@ -96,6 +131,16 @@ var constZettelMap = map[id.Zid]constZettel{
 </form>
 </nav>
 <main class="content">
+{{#HasTemplateErrors}}
+<input type="checkbox" id="zs-banner-close" class="zs-banner-close" hidden>
+<div class="zs-indication zs-error zs-banner">
+<label for="zs-banner-close" class="zs-banner-button" title="Dismiss">&times;</label>
+{{#TemplateErrors}}<p>{{.}}</p>{{/TemplateErrors}}
+</div>
+{{/HasTemplateErrors}}
+{{#Degraded}}
+<div class="zs-indication zs-warning">Some places are currently not available. Zettel and lists may be incomplete.</div>
+{{/Degraded}}
 {{{Content}}}
 </main>
 {{#FooterHTML}}
@@ -145,10 +190,37 @@ var constZettelMap = map[id.Zid]constZettel{
 			meta.KeySyntax:     syntaxTemplate,
 		},
 		domain.NewContent(
-			`<h1>{{Title}}</h1>
+			`{{#HasFacets}}{{#Sidebar}}
+<aside class="zs-facets">
+<p>{{Count}} zettel</p>
+{{#Facets}}<h2>{{Name}}</h2>
 <ul>
-{{#Metas}}<li><a href="{{{URL}}}">{{{Title}}}</a></li>
+{{#Values}}<li><a href="{{{URL}}}">{{Text}}</a> ({{Count}})</li>
+{{/Values}}</ul>
+{{/Facets}}</aside>
+{{/Sidebar}}{{/HasFacets}}
+<h1>{{Title}}</h1>
+{{#HasTagDescr}}{{#TagDescription}}
+<div class="zs-tag-description">
+<p>{{Text}}</p>
+<p><a href="{{{URL}}}">{{{Title}}}</a></p>
+</div>
+{{/TagDescription}}{{/HasTagDescr}}
+{{#HasColumns}}
+<table class="zs-list">
+<thead>
+<tr>{{#TitleColumn}}<th><a href="{{{SortURL}}}">{{Name}}</a>{{#Sorted}}{{#Descending}} &#9660;{{/Descending}}{{^Descending}} &#9650;{{/Descending}}{{/Sorted}}</th>{{/TitleColumn}}{{#Columns}}<th><a href="{{{SortURL}}}">{{Name}}</a>{{#Sorted}}{{#Descending}} &#9660;{{/Descending}}{{^Descending}} &#9650;{{/Descending}}{{/Sorted}}</th>{{/Columns}}</tr>
+</thead>
+<tbody>
+{{#Metas}}<tr><td><a href="{{{URL}}}">{{{Title}}}</a>{{#HasPages}} <span class="zs-pages">p.{{#Pages}} <a href="{{{URL}}}">{{Number}}</a>{{/Pages}}</span>{{/HasPages}}{{#HasStatus}}{{#Status}} <form class="zs-status" method="POST" action="{{{URL}}}">{{#States}}<button type="submit" name="status" value="{{Name}}"{{#IsCurrent}} disabled{{/IsCurrent}}>{{Name}}</button>{{/States}}</form>{{/Status}}{{/HasStatus}}</td>{{#Cells}}<td>{{#Values}}{{#HasURL}}<a href="{{{URL}}}">{{Text}}</a>{{/HasURL}}{{^HasURL}}{{Text}}{{/HasURL}} {{/Values}}</td>{{/Cells}}</tr>
+{{/Metas}}</tbody>
+</table>
+{{/HasColumns}}
+{{^HasColumns}}
+<ul>
+{{#Metas}}<li><a href="{{{URL}}}">{{{Title}}}</a>{{#HasPages}} <span class="zs-pages">p.{{#Pages}} <a href="{{{URL}}}">{{Number}}</a>{{/Pages}}</span>{{/HasPages}}{{#HasStatus}}{{#Status}} <form class="zs-status" method="POST" action="{{{URL}}}">{{#States}}<button type="submit" name="status" value="{{Name}}"{{#IsCurrent}} disabled{{/IsCurrent}}>{{Name}}</button>{{/States}}</form>{{/Status}}{{/HasStatus}}</li>
 {{/Metas}}</ul>
+{{/HasColumns}}
 {{#HasPrevNext}}
 <p>
 {{#HasPrev}}
@@ -159,7 +231,25 @@ var constZettelMap = map[id.Zid]constZettel{
 <a href="{{{NextURL}}}" rel="next">Next</a>
 {{/HasNext}}
 </p>
-{{/HasPrevNext}}`)},
+{{/HasPrevNext}}
+{{#HasCSV}}<p class="zs-meta"><a href="{{{CSVURL}}}">CSV</a></p>{{/HasCSV}}
+{{#CanBulkEdit}}
+<details class="zs-bulk">
+<summary>Change meta data of listed zettel</summary>
+<form method="POST" action="{{{BulkURL}}}">
+{{#Metas}}<input type="hidden" name="zid" value="{{Zid}}">
+{{/Metas}}<div>
+<label for="zs-bulk-key">Key</label>
+<input class="zs-input" type="text" id="zs-bulk-key" name="key" placeholder="tags" required>
+</div>
+<div>
+<label for="zs-bulk-value">Value</label>
+<input class="zs-input" type="text" id="zs-bulk-value" name="value" placeholder="Empty to remove the key">
+</div>
+<input class="zs-button" type="submit" value="Change">
+</form>
+</details>
+{{/CanBulkEdit}}`)},
 
 	id.DetailTemplateZid: constZettel{
 		constHeader{
@@ -171,9 +261,11 @@ var constZettelMap = map[id.Zid]constZettel{
 		domain.NewContent(
 			`<article>
 <header>
+{{#HasBreadcrumbs}}<nav class="zs-breadcrumbs">{{#Breadcrumbs}}<a href="{{{URL}}}">{{{Title}}}</a> &#8250; {{/Breadcrumbs}}</nav>{{/HasBreadcrumbs}}
 <h1>{{{HTMLTitle}}}</h1>
 <div class="zs-meta">
 {{#CanWrite}}<a href="{{{EditURL}}}">Edit</a> &#183;{{/CanWrite}}
+{{#HasLock}}{{#Lock}}{{#CanLock}}<form class="zs-lock" method="POST" action="{{{URL}}}"><button type="submit" name="action" value="lock">{{#CanUnlock}}Extend lock{{/CanUnlock}}{{^CanUnlock}}Lock{{/CanUnlock}}</button>{{#CanUnlock}} <button type="submit" name="action" value="unlock">Unlock</button>{{/CanUnlock}}</form> &#183;{{/CanLock}}{{/Lock}}{{/HasLock}}
 {{Zid}} &#183;
 <a href="{{{InfoURL}}}">Info</a> &#183;
 (<a href="{{{RoleURL}}}">{{RoleText}}</a>)
@@ -181,10 +273,55 @@ var constZettelMap = map[id.Zid]constZettel{
 {{#CanCopy}}&#183; <a href="{{{CopyURL}}}">Copy</a>{{/CanCopy}}
 {{#CanFolge}}&#183; <a href="{{{FolgeURL}}}">Folge</a>{{/CanFolge}}
 {{#CanNew}}&#183; <a href="{{{NewURL}}}">New</a>{{/CanNew}}
+{{#CanSuggest}}&#183; <a href="{{{SuggestURL}}}">Suggest a change</a>{{/CanSuggest}}
+{{#HasFlags}}{{#Flags}}&#183; <form class="zs-lock" method="POST" action="{{{URL}}}"><input type="hidden" name="flag" value="favorite"><button type="submit" name="action" value="{{#IsFavorite}}unset{{/IsFavorite}}{{^IsFavorite}}set{{/IsFavorite}}">{{#IsFavorite}}&#9733; Unfavorite{{/IsFavorite}}{{^IsFavorite}}&#9734; Favorite{{/IsFavorite}}</button></form>
+<form class="zs-lock" method="POST" action="{{{URL}}}"><input type="hidden" name="flag" value="read-later"><button type="submit" name="action" value="{{#IsReadLater}}unset{{/IsReadLater}}{{^IsReadLater}}set{{/IsReadLater}}">{{#IsReadLater}}Done reading{{/IsReadLater}}{{^IsReadLater}}Read later{{/IsReadLater}}</button></form>{{/Flags}}{{/HasFlags}}
+{{#HasStatus}}{{#Status}}<br>Status: <form class="zs-status" method="POST" action="{{{URL}}}">{{#States}}<button type="submit" name="status" value="{{Name}}"{{#IsCurrent}} disabled{{/IsCurrent}}>{{Name}}</button>{{/States}}{{#HasCurrent}}<button type="submit" name="status" value="">clear</button>{{/HasCurrent}}</form>{{/Status}}{{/HasStatus}}
 {{#HasExtURL}}<br>URL: <a href="{{{ExtURL}}}"{{{ExtNewWindow}}}>{{ExtURL}}</a>{{/HasExtURL}}
+{{#HasLock}}{{#Lock}}{{#IsLocked}}<br>Locked by {{User}} until {{Expires}}{{/IsLocked}}{{/Lock}}{{/HasLock}}
+{{#HasQuota}}{{#Quota}}<br>Created: {{Zettel}} zettel{{#MaxZettel}} of at most {{MaxZettel}}{{/MaxZettel}}, {{Size}}{{#MaxSize}} of at most {{MaxSize}}{{/MaxSize}}{{/Quota}}{{/HasQuota}}
 </div>
 </header>
 {{{Content}}}
+{{#HasAttribution}}
+<footer class="zs-attribution">
+{{#Copyright}}<p>{{Copyright}}</p>{{/Copyright}}
+{{#License}}<p>License: {{License}}</p>{{/License}}
+</footer>
+{{/HasAttribution}}
+{{#HasRecent}}{{#Recent}}
+<section class="zs-recent">
+{{#HasVisited}}<div>
+<h2>Recently visited</h2>
+<ul>
+{{#Visited}}<li><a href="{{{URL}}}">{{{Title}}}</a></li>
+{{/Visited}}</ul>
+<p class="zs-meta"><a href="{{{VisitedURL}}}">More</a></p>
+</div>{{/HasVisited}}
+{{#HasModified}}<div>
+<h2>Recently modified</h2>
+<ul>
+{{#Modified}}<li><a href="{{{URL}}}">{{{Title}}}</a></li>
+{{/Modified}}</ul>
+<p class="zs-meta"><a href="{{{ModifiedURL}}}">More</a></p>
+</div>{{/HasModified}}
+</section>
+{{/Recent}}{{/HasRecent}}
+{{#HasComments}}
+<section>
+<h2>Comments</h2>
+{{{Comments}}}
+</section>
+{{/HasComments}}
+{{#CanComment}}
+<form method="POST" action="{{{CommentURL}}}">
+<div>
+<label for="comment">Your comment</label>
+<textarea class="zs-input" id="comment" name="content" rows="4"></textarea>
+</div>
+<input class="zs-button" type="submit" value="Comment">
+</form>
+{{/CanComment}}
 </article>`)},
 
 	id.InfoTemplateZid: constZettel{
@@ -205,9 +342,36 @@ var constZettelMap = map[id.Zid]constZettel{
 {{#CanNew}} &#183; <a href="{{{NewURL}}}">New</a>{{/CanNew}}
 {{#CanRename}}&#183; <a href="{{{RenameURL}}}">Rename</a>{{/CanRename}}
 {{#CanDelete}}&#183; <a href="{{{DeleteURL}}}">Delete</a>{{/CanDelete}}
+{{#CanUndo}}&#183; <a href="{{{UndoURL}}}">Undo</a>{{/CanUndo}}
+{{#CanReload}}&#183; <a href="{{{ReloadURL}}}">Reload</a>{{/CanReload}}
+{{#CanDiff}}&#183; <a href="{{{DiffURL}}}">Diff</a>{{/CanDiff}}
+{{#CanExplain}}&#183; <a href="{{{PolicyURL}}}">Policy</a>{{/CanExplain}}
 </header>
 <h2>Interpreted Meta Data</h2>
 <table>{{#MetaData}}<tr><td>{{Key}}</td><td>{{{Value}}}</td></tr>{{/MetaData}}</table>
+{{#HasPlaces}}
+<h2>Places</h2>
+{{#IsBuiltin}}<p>This is a built-in zettel of Zettelstore.</p>{{/IsBuiltin}}
+{{#IsComputed}}<p>This zettel is computed by Zettelstore.</p>{{/IsComputed}}
+{{#Shadows}}
+<p>This zettel shadows other zettel with the same identifier:</p>
+<ul>
+{{#Shadowed}}<li>{{.}}</li>
+{{/Shadowed}}</ul>
+{{/Shadows}}
+{{#CanCustomize}}
+<form method="POST" action="{{{ShadowURL}}}">
+<input type="hidden" name="action" value="customize">
+<input class="zs-button" type="submit" value="Customize">
+</form>
+{{/CanCustomize}}
+{{#CanRevert}}
+<form method="POST" action="{{{ShadowURL}}}">
+<input type="hidden" name="action" value="revert">
+<input class="zs-button" type="submit" value="Revert to shadowed zettel">
+</form>
+{{/CanRevert}}
+{{/HasPlaces}}
 {{#HasLinks}}
 <h2>References</h2>
 {{#HasZetLinks}}
@@ -235,6 +399,28 @@ var constZettelMap = map[id.Zid]constZettel{
 </ul>
 {{/HasExtLinks}}
 {{/HasLinks}}
+{{#HasRelations}}
+<h2>Relations</h2>
+{{#Relations}}
+<h3>{{Rel}}</h3>
+{{#HasOutgoing}}
+<p>Links from this zettel:</p>
+<ul>
+{{#Outgoing}}
+<li>{{#HasURL}}<a href="{{{URL}}}">{{Title}}</a>{{/HasURL}}{{^HasURL}}{{Zid}}{{/HasURL}}</li>
+{{/Outgoing}}
+</ul>
+{{/HasOutgoing}}
+{{#HasIncoming}}
+<p>Links to this zettel:</p>
+<ul>
+{{#Incoming}}
+<li><a href="{{{URL}}}">{{Title}}</a></li>
+{{/Incoming}}
+</ul>
+{{/HasIncoming}}
+{{/Relations}}
+{{/HasRelations}}
 <h2>Parts and format</h3>
 <table>
 {{#Matrix}}
@@ -258,7 +444,8 @@ var constZettelMap = map[id.Zid]constZettel{
 <header>
 <h1>{{Heading}}</h1>
 </header>
-<form method="POST">
+{{#IsLocked}}{{#Lock}}<div class="zs-indication zs-warning">This zettel is locked by {{User}} until {{Expires}}.</div>{{/Lock}}{{/IsLocked}}
+<form method="POST" enctype="multipart/form-data">
 <div>
 <label for="title">Title</label>
 <input class="zs-input" type="text" id="title" name="title" placeholder="Title.." value="{{MetaTitle}}" autofocus>
@@ -270,6 +457,12 @@ var constZettelMap = map[id.Zid]constZettel{
 </div>
 <label for="tags">Tags</label>
 <input class="zs-input" type="text" id="tags" name="tags" placeholder="#tag" value="{{MetaTags}}">
+{{#HasTagSuggestions}}
+<div class="zs-tag-suggestions" data-target="tags">Suggested tags:
+{{#TagSuggestions}}<button class="zs-tag" type="button" value="{{.}}">{{.}}</button>
+{{/TagSuggestions}}
+</div>
+{{/HasTagSuggestions}}
 </div>
 <div>
 <label for="meta">Metadata</label>
@@ -286,12 +479,22 @@ var constZettelMap = map[id.Zid]constZettel{
 <div>
 {{#IsTextContent}}
 <label for="content">Content</label>
-<textarea class="zs-input zs-content" id="meta" name="content" rows="20" placeholder="Your content..">
+<div class="zs-picker" data-url="{{{PickerURL}}}" data-target="content">
+<input class="zs-input" type="search" placeholder="Link to zettel: title words, #tag, role:name.." aria-label="Link to zettel">
+<ul></ul>
+</div>
+<textarea class="zs-input zs-content" id="content" name="content" rows="20" placeholder="Your content..">
 {{Content}}
 </textarea>
 {{/IsTextContent}}
 </div>
+<div>
+<label for="content-file">{{#IsTextContent}}Or upload content{{/IsTextContent}}{{^IsTextContent}}Replace content{{/IsTextContent}}</label>
+<input class="zs-input" type="file" id="content-file" name="content-file">
+</div>
+<div class="zs-form-actions">
 <input class="zs-button" type="submit" value="Submit">
+</div>
 </form>
 </article>`,
 	},
@@ -342,12 +545,140 @@ var constZettelMap = map[id.Zid]constZettel{
 {{/MetaPairs}}
 </dl>
 <form method="POST">
+{{#HasReferrers}}
+<p>The following zettel link to this zettel:</p>
+<ul>
+{{#Referrers}}<li><a href="{{{URL}}}">{{{Title}}}</a></li>
+{{/Referrers}}</ul>
+<fieldset>
+<legend>Links to this zettel</legend>
+<div><input type="radio" id="links-keep" name="links" value="keep" checked>
+<label for="links-keep">Leave broken links</label></div>
+<div><input type="radio" id="links-remove" name="links" value="remove">
+<label for="links-remove">Remove links, keep their text</label></div>
+<div><input type="radio" id="links-redirect" name="links" value="redirect">
+<label for="links-redirect">Redirect links to zettel</label>
+<input class="zs-input" type="text" id="replacement" name="replacement" placeholder="ZID.." aria-label="Replacement zettel"></div>
+</fieldset>
+{{/HasReferrers}}
 <input class="zs-button" type="submit" value="Delete">
 </form>
 </article>
 {{end}}`,
 	},
 
+	id.DiffTemplateZid: constZettel{
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Diff HTML Template",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeyVisibility: meta.ValueVisibilityExpert,
+			meta.KeySyntax:     syntaxTemplate,
+		},
+		`<article>
+<header>
+<h1>Differences of Zettel {{Zid}}</h1>
+<div class="zs-meta"><a href="{{{InfoURL}}}">Info</a></div>
+</header>
+{{#HasDiff}}
+<pre class="zs-diff">{{#Lines}}<span class="{{Class}}">{{{HTML}}}</span>
+{{/Lines}}</pre>
+{{/HasDiff}}
+<form method="POST">
+<div>
+<label for="content">Draft to compare with the stored content</label>
+<textarea class="zs-input zs-content" id="content" name="content" rows="20">{{Draft}}</textarea>
+</div>
+<input class="zs-button" type="submit" value="Compare">
+</form>
+</article>`,
+	},
+
+	id.UndoTemplateZid: constZettel{
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Undo HTML Template",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeyVisibility: meta.ValueVisibilityExpert,
+			meta.KeySyntax:     syntaxTemplate,
+		},
+		`<article>
+<header>
+<h1>Undo Last Change of Zettel {{Zid}}</h1>
+</header>
+<p>Do you really want to restore the previous version of zettel &#8220;{{Title}}&#8221;?</p>
+<p>The current version will become the previous one, so that you can revert this undo.</p>
+<form method="POST">
+<input class="zs-button" type="submit" value="Undo">
+</form>
+</article>`,
+	},
+
+	id.PolicyTemplateZid: constZettel{
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Policy HTML Template",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeyVisibility: meta.ValueVisibilityExpert,
+			meta.KeySyntax:     syntaxTemplate,
+		},
+		`<article>
+<header>
+<h1>Policy Decisions for Zettel {{Zid}}</h1>
+<div class="zs-meta"><a href="{{{InfoURL}}}">Info</a></div>
+</header>
+<form>
+<div>
+<label for="user">User zettel identifier (empty for an anonymous user)</label>
+<input class="zs-input" type="text" id="user" name="user" value="{{UserZid}}">
+</div>
+<input class="zs-button" type="submit" value="Explain">
+</form>
+<h2>Decisions for {{UserName}}</h2>
+<table>
+<tr><th>Operation</th><th>Decision</th><th>Applied rules</th></tr>
+{{#Explanations}}
+<tr><td>{{Operation}}</td><td>{{Decision}}</td><td><ol>{{#Steps}}<li>{{Rule}}: {{Decision}}, {{Reason}}</li>{{/Steps}}</ol></td></tr>
+{{/Explanations}}
+</table>
+</article>`,
+	},
+
+	id.SuggestTemplateZid: constZettel{
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Suggest HTML Template",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeyVisibility: meta.ValueVisibilityExpert,
+			meta.KeySyntax:     syntaxTemplate,
+		},
+		`<article>
+<header>
+<h1>Suggest a Change of Zettel &#8220;{{Title}}&#8221;</h1>
+<div class="zs-meta"><a href="{{{WebURL}}}">Back to zettel</a></div>
+</header>
+{{#HasSent}}
+<p>Thank you! Your suggestion was sent to the owner of this Zettelstore.</p>
+{{/HasSent}}
+{{^HasSent}}
+<p>Your suggestion will not change the zettel. It will be reviewed by the owner.</p>
+{{#HasError}}<div class="zs-indication zs-error">{{Error}}</div>{{/HasError}}
+<form method="POST">
+<div>
+<label for="content">Suggested content</label>
+<textarea class="zs-input zs-content" id="content" name="content" rows="20">{{Content}}</textarea>
+</div>
+<div>
+<label for="note">Note for the owner (optional)</label>
+<input class="zs-input" type="text" id="note" name="note" value="{{Note}}">
+</div>
+<div>
+<label for="answer">{{Question}}</label>
+<input class="zs-input" type="text" id="answer" name="answer" autocomplete="off">
+<input type="hidden" name="token" value="{{Token}}">
+</div>
+<input class="zs-button" type="submit" value="Suggest">
+</form>
+{{/HasSent}}
+</article>`,
+	},
+
 	id.RolesTemplateZid: constZettel{
 		constHeader{
 			meta.KeyTitle:      "Zettelstore List Roles HTML Template",
@@ -373,9 +704,727 @@ var constZettelMap = map[id.Zid]constZettel{
 <a href="{{{#ListTagsURL}}}">All</a>{{#MinCounts}}, <a href="{{{URL}}}">{{Count}}</a>{{/MinCounts}}
 </div>
 {{#Tags}} <a href="{{{URL}}}" style="font-size:{{Size}}%">{{Name}}</a><sup>{{Count}}</sup>
-{{/Tags}}`,
+{{/Tags}}
+{{#HasDescriptions}}
+<dl class="zs-tag-descriptions">
+{{#Tags}}{{#HasDescr}}{{#Descr}}<dt><a href="{{{URL}}}">{{{Title}}}</a></dt>
+<dd>{{Text}}</dd>
+{{/Descr}}{{/HasDescr}}{{/Tags}}</dl>
+{{/HasDescriptions}}`,
 	},
 
+	id.TasksTemplateZid: constZettel{
+		constHeader{
+			meta.KeyTitle:      "Zettelstore List Tasks HTML Template",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeyVisibility: meta.ValueVisibilityExpert,
+			meta.KeySyntax:     syntaxTemplate,
+		},
+		`<h1>Open tasks</h1>
+{{#Zettel}}<h2><a href="{{{URL}}}">{{{Title}}}</a></h2>
+<ul class="zs-tasks">
+{{#Tasks}}<li><input type="checkbox" disabled> {{Text}} <span class="zs-meta">(line {{Line}})</span></li>
+{{/Tasks}}</ul>
+{{/Zettel}}{{^Zettel}}<p>There are no open tasks.</p>
+{{/Zettel}}`,
+	},
+
+	id.HierarchyTemplateZid: constZettel{
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Hierarchy HTML Template",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeyVisibility: meta.ValueVisibilityExpert,
+			meta.KeySyntax:     syntaxTemplate,
+		},
+		`<h1>Hierarchy</h1>
+{{#HasTree}}{{{Tree}}}{{/HasTree}}{{^HasTree}}<p>There are no zettel with a parent.</p>
+{{/HasTree}}`,
+	},
+
+	id.CustomizeTemplateZid: constZettel{
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Customized Zettel HTML Template",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeyVisibility: meta.ValueVisibilityExpert,
+			meta.KeySyntax:     syntaxTemplate,
+		},
+		`<h1>Customized Zettel</h1>
+{{#HasCustomized}}
+{{#Customized}}
+<section class="zs-customized">
+<h2><a href="{{{URL}}}">{{Title}}</a> <small>{{Zid}}</small></h2>
+<details>
+<summary>Differences to the built-in version</summary>
+<pre class="zs-diff">{{#Lines}}<span class="{{Class}}">{{{HTML}}}</span>
+{{/Lines}}</pre>
+</details>
+{{#CanReset}}
+<form method="POST" action="{{{ResetURL}}}">
+<input type="hidden" name="zid" value="{{Zid}}">
+<input class="zs-button" type="submit" value="Reset to built-in version">
+</form>
+{{/CanReset}}
+</section>
+{{/Customized}}
+{{/HasCustomized}}
+{{^HasCustomized}}
+<p>All configuration zettel are the same as their built-in versions.</p>
+{{/HasCustomized}}
+{{#HasUnchanged}}
+<h2>Unchanged</h2>
+<ul>
+{{#Unchanged}}<li><a href="{{{URL}}}">{{Title}}</a> <small>{{Zid}}</small></li>
+{{/Unchanged}}</ul>
+{{/HasUnchanged}}`,
+	},
+
+	id.DuplicatesTemplateZid: constZettel{
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Duplicate Zettel HTML Template",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeyVisibility: meta.ValueVisibilityExpert,
+			meta.KeySyntax:     syntaxTemplate,
+		},
+		`<h1>Duplicate Zettel</h1>
+{{#HasGroups}}
+<p>The zettel of each group have nearly the same content.</p>
+{{#Groups}}
+<section class="zs-duplicates">
+<ul>
+{{#Zettel}}<li><a href="{{{URL}}}">{{Title}}</a> <small>{{Zid}}</small>
+(<a href="{{{InfoURL}}}">Info</a>{{#CanDelete}}, <a href="{{{DeleteURL}}}">Delete</a>{{/CanDelete}})</li>
+{{/Zettel}}</ul>
+</section>
+{{/Groups}}
+{{/HasGroups}}
+{{^HasGroups}}
+<p>No duplicate zettel found.</p>
+{{/HasGroups}}`,
+	},
+
+	id.CitationsTemplateZid: constZettel{
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Literature HTML Template",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeyVisibility: meta.ValueVisibilityExpert,
+			meta.KeySyntax:     syntaxTemplate,
+		},
+		`<h1>Literature</h1>
+{{#HasLiterature}}
+{{#HasCited}}
+<h2>Most Cited</h2>
+<table class="zs-citations">
+<tr><th>Literature</th><th>Citations</th><th>Cited by</th><th>Cites</th></tr>
+{{#Cited}}<tr><td><a href="{{{URL}}}">{{Title}}</a> <small>{{Zid}}</small></td><td class="zs-count">{{Count}}</td>
+<td>{{#CitedBy}}<a href="{{{URL}}}">{{Title}}</a><br>{{/CitedBy}}</td>
+<td>{{#Cites}}<a href="{{{URL}}}">{{Title}}</a><br>{{/Cites}}</td></tr>
+{{/Cited}}</table>
+{{/HasCited}}
+{{#HasUnread}}
+<h2>To Read</h2>
+<p>Literature you want to read later.</p>
+<ul>
+{{#Unread}}<li><a href="{{{URL}}}">{{Title}}</a> <small>{{Zid}}</small> ({{Count}} citations)</li>
+{{/Unread}}</ul>
+{{/HasUnread}}
+{{#HasUncited}}
+<h2>Uncited</h2>
+<p>Literature that no zettel cites or links to.</p>
+<ul>
+{{#Uncited}}<li><a href="{{{URL}}}">{{Title}}</a> <small>{{Zid}}</small>{{#HasCites}}, cites {{#Cites}}<a href="{{{URL}}}">{{Title}}</a> {{/Cites}}{{/HasCites}}</li>
+{{/Uncited}}</ul>
+{{/HasUncited}}
+{{/HasLiterature}}
+{{^HasLiterature}}
+<p>There is no zettel with role "literature".</p>
+{{/HasLiterature}}`,
+	},
+
+	id.RoutesTemplateZid: constZettel{
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Routes HTML Template",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeyVisibility: meta.ValueVisibilityExpert,
+			meta.KeySyntax:     syntaxTemplate,
+		},
+		`<h1>Routes</h1>
+<p>All URLs that are currently served, with their requirements.
+The placeholder <code>{zid}</code> denotes a zettel identifier.</p>
+<table class="zs-routes">
+<tr><th>Method</th><th>URL</th><th>Requirements</th><th>Description</th></tr>
+{{#Routes}}<tr><td>{{Method}}</td><td><code>{{URL}}</code></td><td>{{Requirements}}</td><td>{{Description}}</td></tr>
+{{/Routes}}</table>`,
+	},
+
+	id.ReviewTemplateZid: constZettel{
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Review HTML Template",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeyVisibility: meta.ValueVisibilityExpert,
+			meta.KeySyntax:     syntaxTemplate,
+		},
+		`<h1>Review</h1>
+{{#Daily}}<h2>Zettel of the day</h2>
+<p><a href="{{{URL}}}">{{Title}}</a> <span class="zs-meta">({{Interval}}, due: {{Due}})</span></p>
+{{/Daily}}{{^Daily}}<p>There are no zettel with a <code>review-interval</code>.</p>
+{{/Daily}}<h2>Due for review</h2>
+{{#HasDue}}<ul class="zs-review">
+{{#Due}}<li><a href="{{{URL}}}">{{Title}}</a> <span class="zs-meta">({{Interval}}, due: {{Due}})</span>
+{{#CanReview}}<form class="zs-lock" method="POST" action="{{{ReviewURL}}}"><button type="submit">Reviewed</button></form>{{/CanReview}}</li>
+{{/Due}}</ul>
+{{/HasDue}}{{^HasDue}}<p>No zettel is due for review.</p>
+{{/HasDue}}`,
+	},
+
+	id.StudyTemplateZid: constZettel{
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Study HTML Template",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeyVisibility: meta.ValueVisibilityExpert,
+			meta.KeySyntax:     syntaxTemplate,
+		},
+		`<h1>Study</h1>
+{{#HasCard}}<p class="zs-meta">Cards due: {{Remaining}}</p>
+<section class="zs-card">
+<h2>{{{Question}}} <small><a href="{{{URL}}}">&#8599;</a></small></h2>
+<details>
+<summary>Show answer</summary>
+{{{Answer}}}
+{{#CanGrade}}<form method="POST" action="{{{GradeURL}}}">
+<button type="submit" name="grade" value="again">Again</button>
+<button type="submit" name="grade" value="hard">Hard</button>
+<button type="submit" name="grade" value="good">Good</button>
+<button type="submit" name="grade" value="easy">Easy</button>
+</form>{{/CanGrade}}
+</details>
+</section>
+{{/HasCard}}{{^HasCard}}<p>No card is due. Cards are zettel with the role <code>card</code>.</p>
+{{/HasCard}}`,
+	},
+
+	id.ErrorTemplateZid: constZettel{
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Error HTML Template",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeyVisibility: meta.ValueVisibilityExpert,
+			meta.KeySyntax:     syntaxTemplate,
+		},
+		`<h1>{{Title}}</h1>
+<p>{{Message}}</p>
+{{#HasZid}}<p>Zettel: {{Zid}}</p>{{/HasZid}}
+{{#HasField}}<p>Field: <code>{{Field}}</code></p>{{/HasField}}
+<p><small>Error code: {{Code}}</small></p>`,
+	},
+
+	id.ForbiddenTemplateZid: constZettel{
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Forbidden HTML Template",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeyVisibility: meta.ValueVisibilityExpert,
+			meta.KeySyntax:     syntaxTemplate,
+		},
+		`<h1>{{Title}}</h1>
+<p>{{Message}}</p>
+{{#HasZid}}<p>Zettel: {{Zid}}</p>{{/HasZid}}
+{{#ShowLogin}}<p>You may <a href="{{{LoginURL}}}">login</a> to get access.</p>{{/ShowLogin}}`,
+	},
+
+	id.NotFoundTemplateZid: constZettel{
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Not Found HTML Template",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeyVisibility: meta.ValueVisibilityExpert,
+			meta.KeySyntax:     syntaxTemplate,
+		},
+		`<h1>{{Title}}</h1>
+{{#HasZid}}<p>There is no zettel <code>{{Zid}}</code>.</p>{{/HasZid}}
+{{^HasZid}}<p>{{Message}}</p>{{/HasZid}}
+<p>You may search for it:</p>
+<form action="{{{SearchURL}}}">
+<input type="text" placeholder="Search.." name="s" value="{{Zid}}">
+<input class="zs-button" type="submit" value="Search">
+</form>`,
+	},
+
+	id.InternalTemplateZid: constZettel{
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Internal Error HTML Template",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeyVisibility: meta.ValueVisibilityExpert,
+			meta.KeySyntax:     syntaxTemplate,
+		},
+		`<h1>{{Title}}</h1>
+<p>{{Message}}</p>
+<p>The request could not be completed. The problem was logged, please try again later.</p>
+<p><small>Error code: {{Code}}</small></p>`,
+	},
+
+	id.ManifestZid: constZettel{
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Web App Manifest",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeyVisibility: meta.ValueVisibilityExpert,
+			meta.KeySyntax:     syntaxTemplate,
+		},
+		domain.NewContent(
+			`{
+  "name": {{{Name}}},
+  "short_name": {{{Name}}},
+  "start_url": "{{{StartURL}}}",
+  "scope": "{{{StartURL}}}",
+  "display": "standalone",
+  "background_color": "#ffffff",
+  "theme_color": "#ffffff",
+  "icons": [
+    {"src": "{{{IconURL}}}", "sizes": "any", "type": "image/svg+xml"}
+  ]
+}`)},
+
+	id.ServiceWorkerZid: constZettel{
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Service Worker",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeyVisibility: meta.ValueVisibilityExpert,
+			meta.KeySyntax:     syntaxTemplate,
+		},
+		domain.NewContent(
+			`"use strict";
+// Caches visited zettel, so that they can be read while offline.
+const cacheName = "zettelstore-{{{Version}}}";
+const prefix = "{{{Prefix}}}";
+const shell = ["{{{StylesheetURL}}}", "{{{AppScriptURL}}}", "{{{IconURL}}}"];
+const maxEntries = {{MaxEntries}};
+
+self.addEventListener("install", (event) => {
+  event.waitUntil(caches.open(cacheName).then((cache) => cache.addAll(shell)));
+  self.skipWaiting();
+});
+
+self.addEventListener("activate", (event) => {
+  event.waitUntil(caches.keys().then((keys) => Promise.all(
+    keys.filter((key) => key !== cacheName).map((key) => caches.delete(key))
+  )).then(() => self.clients.claim()));
+});
+
+function isCacheable(url) {
+  return url.pathname === prefix || url.pathname.startsWith(prefix + "h/") ||
+    shell.includes(url.pathname + url.search);
+}
+
+async function trim(cache) {
+  const keys = await cache.keys();
+  for (let i = 0; i < keys.length - maxEntries; i++) {
+    await cache.delete(keys[i]);
+  }
+}
+
+const offlinePage = "<!DOCTYPE html><title>Offline</title>" +
+  "<p>You are offline. This page was not visited before.</p>";
+
+self.addEventListener("fetch", (event) => {
+  const request = event.request;
+  const url = new URL(request.url);
+  if (request.method !== "GET" || url.origin !== self.location.origin) {
+    return;
+  }
+  if (url.pathname.startsWith(prefix + "a/")) {
+    // The user logs out: forget all pages seen by this user.
+    event.waitUntil(caches.delete(cacheName));
+    return;
+  }
+  if (!isCacheable(url)) {
+    return;
+  }
+  event.respondWith(fetch(request).then((response) => {
+    if (response.ok) {
+      const copy = response.clone();
+      event.waitUntil(caches.open(cacheName).then(
+        (cache) => cache.put(request, copy).then(() => trim(cache))));
+    }
+    return response;
+  }).catch(() => caches.match(request).then((cached) => cached || new Response(
+    offlinePage, {status: 503, headers: {"Content-Type": "text/html; charset=utf-8"}}))));
+});
+`)},
+
+	id.AppScriptZid: constZettel{
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Web App Script",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeyVisibility: meta.ValueVisibilityPublic,
+			meta.KeySyntax:     "js",
+		},
+		domain.NewContent(
+			`"use strict";
+// Registers the service worker, shows whether the browser is offline, opens
+// drop-down menus on touch devices, and previews linked zettel.
+(function () {
+  const script = document.currentScript;
+  if ("serviceWorker" in navigator && script && script.dataset.sw) {
+    navigator.serviceWorker.register(
+      script.dataset.sw, {scope: script.dataset.scope}).catch(() => {});
+  }
+  function update() {
+    const indicator = document.getElementById("zs-offline");
+    if (indicator) {
+      indicator.hidden = navigator.onLine;
+    }
+  }
+  window.addEventListener("online", update);
+  window.addEventListener("offline", update);
+  update();
+
+  document.addEventListener("click", (event) => {
+    const button = event.target.closest(".zs-dropdown > button");
+    const current = button ? button.parentElement : null;
+    document.querySelectorAll(".zs-dropdown.zs-open").forEach((dropdown) => {
+      if (dropdown !== current) {
+        dropdown.classList.remove("zs-open");
+      }
+    });
+    if (current) {
+      current.classList.toggle("zs-open");
+    }
+  });
+
+  // A picker searches zettel by title and inserts a link to the selected
+  // zettel into its target text area.
+  document.querySelectorAll(".zs-picker").forEach((picker) => {
+    const input = picker.querySelector("input");
+    const list = picker.querySelector("ul");
+    const target = document.getElementById(picker.dataset.target);
+    let timer = null;
+    function clear() {
+      list.replaceChildren();
+    }
+    function search() {
+      const params = new URLSearchParams();
+      const words = [];
+      input.value.split(/\s+/).forEach((word) => {
+        if (word.startsWith("#") && word.length > 1) {
+          params.set("tag", word);
+        } else if (word.startsWith("role:") && word.length > 5) {
+          params.set("role", word.substring(5));
+        } else if (word) {
+          words.push(word);
+        }
+      });
+      if (words.length === 0 && !params.has("tag") && !params.has("role")) {
+        clear();
+        return;
+      }
+      params.set("q", words.join(" "));
+      fetch(picker.dataset.url + "?" + params.toString(), {credentials: "same-origin"})
+        .then((response) => response.ok ? response.json() : {list: []})
+        .then((data) => {
+          clear();
+          data.list.forEach((info) => {
+            const button = document.createElement("button");
+            button.type = "button";
+            button.textContent = info.title;
+            button.addEventListener("click", () => {
+              target.setRangeText(info.link, target.selectionStart, target.selectionEnd, "end");
+              target.focus();
+              input.value = "";
+              clear();
+            });
+            const item = document.createElement("li");
+            item.appendChild(button);
+            list.appendChild(item);
+          });
+        })
+        .catch(clear);
+    }
+    input.addEventListener("input", () => {
+      clearTimeout(timer);
+      timer = setTimeout(search, 200);
+    });
+    input.addEventListener("keydown", (event) => {
+      if (event.key === "Enter") {
+        // Do not submit the form
+        event.preventDefault();
+        const first = list.querySelector("button");
+        if (first) {
+          first.click();
+        }
+      } else if (event.key === "Escape") {
+        clear();
+      }
+    });
+  });
+
+  // A suggested tag is appended to the tags field, when it is clicked.
+  document.querySelectorAll(".zs-tag-suggestions").forEach((suggestions) => {
+    const target = document.getElementById(suggestions.dataset.target);
+    suggestions.querySelectorAll("button").forEach((button) => {
+      button.addEventListener("click", () => {
+        target.value = (target.value.trim() + " " + button.value).trim();
+        button.remove();
+      });
+    });
+  });
+
+  // A drawing editor allows to sketch on a canvas, if the syntax of the
+  // edited zettel is "drawing". The drawing is stored as JSON in the content
+  // text area, which may still be edited directly.
+  function drawElement(ctx, e) {
+    const points = e.points || [];
+    ctx.save();
+    ctx.strokeStyle = e.strokeColor || "#000000";
+    ctx.fillStyle = ctx.strokeStyle;
+    ctx.lineWidth = e.strokeWidth || 1;
+    ctx.lineCap = "round";
+    ctx.lineJoin = "round";
+    ctx.beginPath();
+    switch (e.type) {
+    case "rectangle":
+      ctx.strokeRect(e.x, e.y, e.width, e.height);
+      break;
+    case "ellipse":
+      ctx.ellipse(e.x + e.width / 2, e.y + e.height / 2,
+        Math.abs(e.width / 2), Math.abs(e.height / 2), 0, 0, 2 * Math.PI);
+      ctx.stroke();
+      break;
+    case "diamond":
+      ctx.moveTo(e.x + e.width / 2, e.y);
+      ctx.lineTo(e.x + e.width, e.y + e.height / 2);
+      ctx.lineTo(e.x + e.width / 2, e.y + e.height);
+      ctx.lineTo(e.x, e.y + e.height / 2);
+      ctx.closePath();
+      ctx.stroke();
+      break;
+    case "text":
+      ctx.font = (e.fontSize || 20) + "px sans-serif";
+      ctx.textBaseline = "top";
+      (e.text || "").split("\n").forEach((line, i) => {
+        ctx.fillText(line, e.x, e.y + i * (e.fontSize || 20) * 1.25);
+      });
+      break;
+    default:
+      points.forEach(([px, py], i) => {
+        if (i === 0) {
+          ctx.moveTo(e.x + px, e.y + py);
+        } else {
+          ctx.lineTo(e.x + px, e.y + py);
+        }
+      });
+      if (e.type === "arrow" && points.length > 1) {
+        const [fx, fy] = points[points.length - 2];
+        const [tx, ty] = points[points.length - 1];
+        const angle = Math.atan2(ty - fy, tx - fx);
+        [Math.PI / 6, -Math.PI / 6].forEach((delta) => {
+          ctx.moveTo(e.x + tx, e.y + ty);
+          ctx.lineTo(e.x + tx - 12 * Math.cos(angle + delta), e.y + ty - 12 * Math.sin(angle + delta));
+        });
+      }
+      ctx.stroke();
+    }
+    ctx.restore();
+  }
+  function createDrawingEditor(target) {
+    const editor = document.createElement("div");
+    editor.className = "zs-drawing-editor";
+    const toolbar = document.createElement("div");
+    const colorInput = document.createElement("input");
+    colorInput.type = "color";
+    colorInput.title = "Color";
+    const canvas = document.createElement("canvas");
+    canvas.width = 800;
+    canvas.height = 500;
+    let drawing = {type: "excalidraw", version: 2, elements: [], appState: {}};
+    let tool = "freedraw";
+    let current = null;
+    function load() {
+      try {
+        const parsed = JSON.parse(target.value);
+        if (parsed && Array.isArray(parsed.elements)) {
+          drawing = parsed;
+        }
+      } catch (e) {
+        // Keep the current drawing, while the JSON is edited.
+      }
+    }
+    function save() {
+      target.value = JSON.stringify(drawing, null, 2);
+    }
+    function render() {
+      const ctx = canvas.getContext("2d");
+      ctx.clearRect(0, 0, canvas.width, canvas.height);
+      drawing.elements.forEach((e) => {
+        if (!e.isDeleted) {
+          drawElement(ctx, e);
+        }
+      });
+    }
+    function position(event) {
+      const rect = canvas.getBoundingClientRect();
+      return [
+        Math.round((event.clientX - rect.left) * canvas.width / rect.width),
+        Math.round((event.clientY - rect.top) * canvas.height / rect.height)];
+    }
+    [["freedraw", "Pen"], ["line", "Line"], ["arrow", "Arrow"], ["rectangle", "Rectangle"],
+      ["ellipse", "Ellipse"], ["diamond", "Diamond"], ["text", "Text"]].forEach(([name, label]) => {
+      const button = document.createElement("button");
+      button.type = "button";
+      button.value = name;
+      button.textContent = label;
+      button.classList.toggle("zs-active", name === tool);
+      button.addEventListener("click", () => {
+        tool = name;
+        toolbar.querySelectorAll("button[value]").forEach((b) => {
+          b.classList.toggle("zs-active", b.value === tool);
+        });
+      });
+      toolbar.appendChild(button);
+    });
+    toolbar.appendChild(colorInput);
+    const undo = document.createElement("button");
+    undo.type = "button";
+    undo.textContent = "Undo";
+    undo.addEventListener("click", () => {
+      drawing.elements.pop();
+      save();
+      render();
+    });
+    toolbar.appendChild(undo);
+    canvas.addEventListener("pointerdown", (event) => {
+      const [x, y] = position(event);
+      if (tool === "text") {
+        const text = window.prompt("Text");
+        if (text) {
+          drawing.elements.push({type: "text", x, y, text, fontSize: 20, strokeColor: colorInput.value});
+          save();
+          render();
+        }
+        return;
+      }
+      current = {
+        type: tool, x, y, width: 0, height: 0,
+        strokeColor: colorInput.value, backgroundColor: "transparent", strokeWidth: 2};
+      if (tool === "freedraw" || tool === "line" || tool === "arrow") {
+        current.points = [[0, 0]];
+      }
+      drawing.elements.push(current);
+      canvas.setPointerCapture(event.pointerId);
+    });
+    canvas.addEventListener("pointermove", (event) => {
+      if (!current) {
+        return;
+      }
+      const [x, y] = position(event);
+      const dx = x - current.x;
+      const dy = y - current.y;
+      if (current.type === "freedraw") {
+        current.points.push([dx, dy]);
+      } else if (current.points) {
+        current.points = [[0, 0], [dx, dy]];
+      }
+      current.width = dx;
+      current.height = dy;
+      render();
+    });
+    canvas.addEventListener("pointerup", () => {
+      if (current) {
+        current = null;
+        save();
+      }
+    });
+    target.addEventListener("input", () => {
+      load();
+      render();
+    });
+    editor.appendChild(toolbar);
+    editor.appendChild(canvas);
+    target.parentElement.insertBefore(editor, target);
+    load();
+    render();
+    return editor;
+  }
+  const syntaxInput = document.getElementById("syntax");
+  const contentArea = document.getElementById("content");
+  if (syntaxInput && contentArea) {
+    let drawingEditor = null;
+    function toggleDrawingEditor() {
+      const isDrawing = ["drawing", "excalidraw"].includes(syntaxInput.value.trim());
+      if (isDrawing && !drawingEditor) {
+        drawingEditor = createDrawingEditor(contentArea);
+      }
+      if (drawingEditor) {
+        drawingEditor.hidden = !isDrawing;
+      }
+    }
+    syntaxInput.addEventListener("change", toggleDrawingEditor);
+    toggleDrawingEditor();
+  }
+
+  // Hovering over a link to another zettel shows its title and abstract.
+  const previews = new Map();
+  let preview = null;
+  let previewTimer = null;
+  function hidePreview() {
+    clearTimeout(previewTimer);
+    if (preview) {
+      preview.remove();
+      preview = null;
+    }
+  }
+  function showPreview(link, html) {
+    hidePreview();
+    if (!html) {
+      return;
+    }
+    preview = document.createElement("div");
+    preview.className = "zs-preview";
+    preview.innerHTML = html;
+    document.body.appendChild(preview);
+    const rect = link.getBoundingClientRect();
+    preview.style.left = (window.scrollX + rect.left) + "px";
+    preview.style.top = (window.scrollY + rect.bottom + 4) + "px";
+  }
+  document.addEventListener("mouseover", (event) => {
+    const link = event.target.closest("a[data-preview]");
+    if (!link) {
+      return;
+    }
+    const url = link.dataset.preview;
+    clearTimeout(previewTimer);
+    previewTimer = setTimeout(() => {
+      if (previews.has(url)) {
+        showPreview(link, previews.get(url));
+        return;
+      }
+      fetch(url, {credentials: "same-origin"})
+        .then((response) => response.ok ? response.text() : "")
+        .then((html) => {
+          previews.set(url, html);
+          if (link.matches(":hover")) {
+            showPreview(link, html);
+          }
+        })
+        .catch(() => {});
+    }, 300);
+  });
+  document.addEventListener("mouseout", (event) => {
+    if (event.target.closest("a[data-preview]")) {
+      hidePreview();
+    }
+  });
+})();
+`)},
+
+	id.AppIconZid: constZettel{
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Web App Icon",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeyVisibility: meta.ValueVisibilityPublic,
+			meta.KeySyntax:     "svg",
+		},
+		domain.NewContent(
+			`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 64 64">
+<rect width="64" height="64" rx="8" fill="#f3f3f3"/>
+<path d="M16 16h32v6L24 42h24v6H16v-6l24-20H16z" fill="#333"/>
+</svg>`)},
+
 	id.BaseCSSZid: constZettel{
 		constHeader{
 			meta.KeyTitle:      "Zettelstore Base CSS",
@@ -417,9 +1466,12 @@ nav.zs-menu > a {
   text-decoration: none;
   color:black;
 }
-nav.zs-menu > a:hover, .zs-dropdown:hover button {
+nav.zs-menu > a:hover, .zs-dropdown:hover button, .zs-dropdown.zs-open button {
   background-color: hsl(210, 28%, 80%);
 }
+.zs-menu-toggle, .zs-menu-button {
+  display: none;
+}
 nav.zs-menu form {
   float: right;
 }
@@ -462,7 +1514,12 @@ nav.zs-menu form input[type=text] {
 .zs-dropdown-content > a:hover {
   background-color: hsl(210, 28%, 75%);
 }
-.zs-dropdown:hover > .zs-dropdown-content {
+@media (hover: hover) {
+  .zs-dropdown:hover > .zs-dropdown-content {
+    display: block;
+  }
+}
+.zs-dropdown.zs-open > .zs-dropdown-content {
   display: block;
 }
 main {
@@ -515,6 +1572,13 @@ blockquote p {
 blockquote cite {
   font-style: normal;
 }
+figure {
+  margin: .5rem 0;
+}
+figcaption {
+  font-size: .9rem;
+  font-style: italic;
+}
 table {
   border-collapse: collapse;
   border-spacing: 0;
@@ -570,6 +1634,9 @@ a:not([class]) {
 .zs-broken {
   text-decoration: line-through;
 }
+.zs-dead {
+  text-decoration: line-through wavy;
+}
 img {
   max-width: 100%;
 }
@@ -622,6 +1689,27 @@ span.zs-indication {
   border-style: none !important;
   font-weight: bold;
 }
+.zs-diff > span {
+  display: block;
+}
+.zs-diff-delete {
+  background-color: #fdd;
+}
+.zs-diff-insert {
+  background-color: #dfd;
+}
+.zs-diff del {
+  background-color: #f99;
+  text-decoration: none;
+}
+.zs-diff ins {
+  background-color: #9e9;
+  text-decoration: none;
+}
+.zs-warning {
+  background-color: lightyellow;
+  border-style: none !important;
+}
 kbd {
   background: hsl(210, 5%, 100%);
   border: 1px solid hsl(210, 5%, 70%);
@@ -634,6 +1722,158 @@ kbd {
   color:#888;
   margin-bottom:1rem;
 }
+ul.zs-tasks {
+  list-style:none;
+  padding-left:0;
+}
+table.zs-citations td { vertical-align: top; }
+table.zs-citations td.zs-count { text-align: right; }
+table.zs-routes td { vertical-align: top; }
+div.zs-drawing-editor canvas {
+  display:block;
+  max-width:100%;
+  border:1px solid #ccc;
+  background-color:#fff;
+  touch-action:none;
+}
+div.zs-drawing-editor button.zs-active { font-weight:bold; }
+figure.zs-diagram svg { max-width:100%; height:auto; }
+span.zs-pages { font-size:smaller; }
+div.zs-preview {
+  position:absolute;
+  z-index:10;
+  max-width:25rem;
+  padding:.25rem .5rem;
+  background:#fff;
+  border:1px solid #ccc;
+  border-radius:.25rem;
+  box-shadow:0 2px 4px rgba(0,0,0,.2);
+  font-size:smaller;
+}
+div.zs-preview p { margin:.25rem 0 0 0; }
+.zs-preview-title { font-weight:bold; }
+ul.zs-comments {
+  list-style:none;
+  padding-left:0;
+}
+ul.zs-comments ul.zs-comments {
+  padding-left:1.5rem;
+  border-left:2px solid #ddd;
+}
+ul.zs-comments .zs-meta {
+  margin-bottom:.25rem;
+}
+details.zs-bulk {
+  margin: .5rem 0;
+}
+details.zs-bulk form > div {
+  margin: .25rem 0;
+}
+div.zs-tag-suggestions {
+  margin: .25rem 0;
+  font-size: smaller;
+}
+div.zs-tag-suggestions button.zs-tag {
+  border: 1px solid #ccc;
+  border-radius: .25rem;
+  background-color: inherit;
+  margin-left: .25rem;
+  cursor: pointer;
+}
+div.zs-picker {
+  position: relative;
+}
+div.zs-picker ul {
+  position: absolute;
+  z-index: 1;
+  list-style: none;
+  margin: 0;
+  padding: 0;
+  background-color: #f9f9f9;
+  box-shadow: 0px 8px 16px 0px rgba(0,0,0,0.2);
+}
+div.zs-picker ul:empty {
+  display: none;
+}
+div.zs-picker button {
+  display: block;
+  width: 100%;
+  border: none;
+  background-color: inherit;
+  text-align: left;
+  padding: .25rem .5rem;
+}
+div.zs-picker button:hover, div.zs-picker button:focus {
+  background-color: hsl(210, 28%, 75%);
+}
+div.zs-banner > label.zs-banner-button {
+  float:right;
+  cursor:pointer;
+  font-size:1.5rem;
+  line-height:1;
+}
+input.zs-banner-close:checked + div.zs-banner {
+  display:none;
+}
+div.zs-offline {
+  background-color:#fec;
+  padding:.25rem .5rem;
+  text-align:center;
+}
+section.zs-recent {
+  display:flex;
+  flex-wrap:wrap;
+}
+section.zs-recent > div {
+  flex:1;
+  min-width:15rem;
+}
+aside.zs-facets {
+  float:right;
+  width:12rem;
+  margin-left:1rem;
+  font-size:.85rem;
+}
+aside.zs-facets h2 {
+  font-size:1rem;
+  margin:.5rem 0 .25rem 0;
+}
+aside.zs-facets ul {
+  list-style:none;
+  padding-left:0;
+  margin:0;
+}
+table.zs-list th a {
+  text-decoration:none;
+}
+form.zs-lock {
+  display:inline;
+}
+form.zs-status {
+  display:inline;
+  font-size:80%;
+}
+div.zs-tag-description {
+  border-left:3px solid #ccc;
+  padding-left:.5rem;
+  margin-bottom:1rem;
+}
+dl.zs-tag-descriptions {
+  margin-top:2rem;
+}
+.zs-breadcrumbs {
+  font-size:.75rem;
+  color:#888;
+}
+.zs-breadcrumbs a {
+  color:#888;
+}
+.zs-attribution {
+  font-size:.75rem;
+  color:#888;
+  border-top:1px solid #ccc;
+  margin-top:1rem;
+}
 .zs-meta a {
   color:#888;
 }
@@ -643,6 +1883,75 @@ h1+.zs-meta {
 footer {
   padding: 0 1rem;
 }
+@media (max-width: 40rem) {
+  nav.zs-menu {
+    overflow: visible;
+    white-space: normal;
+    padding-left: 0;
+  }
+  nav.zs-menu::after {
+    content: "";
+    display: block;
+    clear: both;
+  }
+  .zs-menu-button {
+    display: block;
+    float: right;
+    padding: .41rem .75rem;
+    cursor: pointer;
+  }
+  nav.zs-menu > a:not(:first-of-type), nav.zs-menu > .zs-dropdown, nav.zs-menu > form {
+    display: none;
+  }
+  .zs-menu-toggle:checked ~ a, .zs-menu-toggle:checked ~ .zs-dropdown,
+  .zs-menu-toggle:checked ~ form {
+    display: block;
+    float: none;
+    clear: both;
+    text-align: left;
+  }
+  .zs-dropdown > button {
+    width: 100%;
+    text-align: left;
+  }
+  .zs-dropdown-content {
+    position: static;
+    box-shadow: none;
+    padding-left: 1rem;
+  }
+  nav.zs-menu form input[type=text] {
+    width: calc(100% - 1rem);
+    margin: .25rem .5rem;
+  }
+  main {
+    padding: 0 .5rem;
+  }
+  main form {
+    padding: 0;
+  }
+  textarea, .zs-input {
+    font-size: 16px;
+  }
+  .zs-form-actions {
+    position: sticky;
+    bottom: 0;
+    background-color: #f8f8f8;
+    border-top: 1px solid #ccc;
+  }
+  .zs-form-actions .zs-button {
+    width: 100%;
+    float: none;
+    margin: .5rem 0;
+  }
+  aside.zs-facets {
+    float: none;
+    width: auto;
+    margin-left: 0;
+  }
+  table.zs-list th:not(:first-child), table.zs-list td:not(:first-child) {
+    display: none;
+  }
+}
 @media (prefers-reduced-motion: reduce) {
   * {
     animation-duration: 0.01ms !important;
@@ -654,12 +1963,107 @@ footer {
 `,
 	},
 
+	id.ReplacementsZid: constZettel{
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Text Replacements",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeyVisibility: meta.ValueVisibilityExpert,
+			meta.KeySyntax:     meta.ValueSyntaxNone,
+		},
+		`% Each line contains a word and its replacement, separated by space.
+% Words are only replaced outside of literal text and verbatim blocks.
+... …
+-> →
+<- ←
+<-> ↔
+=> ⇒
+<= ⇐
+<=> ⇔
+(c) ©
+(r) ®
+(tm) ™
+:smile: 😄
+:wink: 😉
+:laughing: 😆
+:cry: 😢
+:thinking: 🤔
+:heart: ❤️
+:+1: 👍
+:-1: 👎
+:star: ⭐
+:bulb: 💡
+:warning: ⚠️
+:check: ✔️
+:x: ❌
+:rocket: 🚀
+:tada: 🎉`,
+	},
+
+	id.MenuZid: constZettel{
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Menu",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeyVisibility: meta.ValueVisibilityExpert,
+			meta.KeySyntax:     meta.ValueSyntaxZmk,
+		},
+		`%% Each item of an unordered list is an additional entry of the menu.
+%% An item with a link is shown as a link. An item with some text and a
+%% nested list of links is shown as a drop-down menu. Example:
+%%
+%% * [[Manual|https://zettelstore.de/manual/]]
+%% * Projects
+%% ** [[Project A|20210101000000]]
+%% ** [[Project B|20210102000000]]`,
+	},
+
+	id.PolicyRulesZid: constZettel{
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Policy Rules",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeyVisibility: meta.ValueVisibilityOwner,
+			meta.KeySyntax:     meta.ValueSyntaxNone,
+		},
+		`% Each line contains a rule that extends the built-in policy:
+%
+%   <user-role> may|must-not <operation>[,<operation>...] [role <role>] [tag <tag>]
+%
+% Operations are create, read, write, rename, and delete. A rule without a
+% role or tag applies to all zettel. Rules that deny an operation have
+% precedence. Rules never allow to change user zettel, and they never
+% override the read-only mode. Examples:
+%
+% writer may delete role scratch
+% reader must-not read tag #private`,
+	},
+
+	id.JobsZid: constZettel{
+		constHeader{
+			meta.KeyTitle:      "Zettelstore Jobs",
+			meta.KeyRole:       meta.ValueRoleConfiguration,
+			meta.KeyVisibility: meta.ValueVisibilityOwner,
+			meta.KeySyntax:     meta.ValueSyntaxNone,
+		},
+		`% Each line schedules a maintenance job:
+%
+%   <job> <interval>
+%
+% The interval is given in minutes (30m), hours (12h), or days (7d). It must
+% be at least one minute. Jobs without an interval never run. The status of
+% all jobs is shown in zettel 00000000000028. Available jobs:
+%
+% reindex: rebuild the index
+% linkcheck: check external links, respecting robots.txt; dead links are
+%   listed in the property "dead-links" and marked when rendered
+%
+% Example:
+%
+% reindex 24h`,
+	},
+
 	id.TemplateNewZettelZid: constZettel{
 		constHeader{
-			meta.KeyTitle:   "New Zettel",
-			meta.KeyRole:    meta.ValueRoleNewTemplate,
-			meta.KeyNewRole: meta.ValueRoleZettel,
-			meta.KeySyntax:  meta.ValueSyntaxZmk,
+			meta.KeyTitle: "New Zettel",
+			meta.KeyRole:  meta.ValueRoleNewTemplate,
 		},
 		"",
 	},
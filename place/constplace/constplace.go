@@ -13,7 +13,11 @@ package constplace
 
 import (
 	"context"
+	"io"
+	"io/ioutil"
 	"net/url"
+	"sort"
+	"strings"
 
 	"zettelstore.de/z/domain"
 	"zettelstore.de/z/domain/id"
@@ -45,6 +49,27 @@ type constZettel struct {
 	content domain.Content
 }
 
+// BuiltinContent returns the content of a zettel that is placed inside the
+// executable, even if it is overwritten by another place.
+func BuiltinContent(zid id.Zid) (domain.Content, bool) {
+	z, ok := constZettelMap[zid]
+	return z.content, ok
+}
+
+// CustomizableZettel returns all built-in configuration zettel that users may
+// customize, ordered by their identifier. The runtime configuration is not
+// included, because it is meant to be changed.
+func CustomizableZettel() []domain.Zettel {
+	result := make([]domain.Zettel, 0, len(constZettelMap))
+	for zid, z := range constZettelMap {
+		if zid != id.ConfigurationZid && z.header[meta.KeyRole] == meta.ValueRoleConfiguration {
+			result = append(result, domain.Zettel{Meta: makeMeta(zid, z.header), Content: z.content})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Meta.Zid < result[j].Meta.Zid })
+	return result
+}
+
 type constPlace struct {
 	zettel map[id.Zid]constZettel
 	filter manager.MetaFilter
@@ -74,6 +99,11 @@ func (cp *constPlace) CreateZettel(
 	return id.Invalid, place.ErrReadOnly
 }
 
+func (cp *constPlace) CreateZettelFrom(
+	ctx context.Context, m *meta.Meta, r io.Reader) (id.Zid, error) {
+	return id.Invalid, place.ErrReadOnly
+}
+
 // GetZettel retrieves a specific zettel.
 func (cp *constPlace) GetZettel(
 	ctx context.Context, zid id.Zid) (domain.Zettel, error) {
@@ -91,6 +121,14 @@ func (cp *constPlace) GetMeta(ctx context.Context, zid id.Zid) (*meta.Meta, erro
 	return nil, place.ErrNotFound
 }
 
+// OpenContent returns a reader for the content of a specific zettel.
+func (cp *constPlace) OpenContent(ctx context.Context, zid id.Zid) (io.ReadCloser, error) {
+	if z, ok := cp.zettel[zid]; ok {
+		return ioutil.NopCloser(strings.NewReader(z.content.AsString())), nil
+	}
+	return nil, place.ErrNotFound
+}
+
 // SelectMeta returns all zettel meta data that match the selection
 // criteria. The result is ordered by descending zettel id.
 func (cp *constPlace) SelectMeta(
@@ -114,6 +152,13 @@ func (cp *constPlace) UpdateZettel(ctx context.Context, zettel domain.Zettel) er
 	return place.ErrReadOnly
 }
 
+func (cp *constPlace) UpdateMeta(ctx context.Context, m *meta.Meta) error {
+	if _, ok := cp.zettel[m.Zid]; ok {
+		return place.ErrReadOnly
+	}
+	return place.ErrNotFound
+}
+
 func (cp *constPlace) AllowRenameZettel(ctx context.Context, zid id.Zid) bool {
 	_, ok := cp.zettel[zid]
 	return !ok
@@ -136,9 +181,32 @@ func (cp *constPlace) DeleteZettel(ctx context.Context, zid id.Zid) error {
 	return place.ErrNotFound
 }
 
+func (cp *constPlace) CanUndoZettel(ctx context.Context, zid id.Zid) bool { return false }
+
+func (cp *constPlace) GetUndoMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error) {
+	return nil, place.ErrNotFound
+}
+
+func (cp *constPlace) UndoZettel(ctx context.Context, zid id.Zid) error {
+	if _, ok := cp.zettel[zid]; ok {
+		return place.ErrReadOnly
+	}
+	return place.ErrNotFound
+}
+
 func (cp *constPlace) Reload(ctx context.Context) error { return nil }
 
-func (cp *constPlace) ReadStats(st *place.Stats) {
-	st.ReadOnly = true
-	st.Zettel = len(cp.zettel)
+func (cp *constPlace) ReloadZettel(ctx context.Context, zid id.Zid) error {
+	if _, ok := cp.zettel[zid]; ok {
+		return nil
+	}
+	return place.ErrNotFound
+}
+
+func (cp *constPlace) Stats(ctx context.Context) place.Stats {
+	return place.Stats{
+		ReadOnly: true,
+		Zettel:   len(cp.zettel),
+		Location: cp.Location(),
+	}
 }
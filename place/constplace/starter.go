@@ -0,0 +1,137 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package constplace places zettel inside the executable.
+package constplace
+
+import (
+	"sort"
+
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+)
+
+// StarterZettel returns the tutorial zettel that are stored in an empty
+// place on first start. In contrast to the other constant zettel, they are
+// not part of the constant place, so that the user can change them.
+func StarterZettel() []domain.Zettel {
+	result := make([]domain.Zettel, 0, len(starterZettelMap))
+	for zid, z := range starterZettelMap {
+		result = append(result, domain.Zettel{Meta: makeMeta(zid, z.header), Content: z.content})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Meta.Zid < result[j].Meta.Zid })
+	return result
+}
+
+const tagTutorial = "#tutorial"
+
+var starterZettelMap = map[id.Zid]constZettel{
+	19700101000100: {
+		constHeader{
+			meta.KeyTitle:  "Getting Started",
+			meta.KeyRole:   meta.ValueRoleZettel,
+			meta.KeySyntax: meta.ValueSyntaxZmk,
+			meta.KeyTags:   tagTutorial,
+		},
+		`Welcome to your new Zettelstore!
+A zettel is a small note about one topic.
+Every zettel has a unique identifier, some metadata like a title or some tags, and its content.
+
+These zettel will help you to start:
+* [[How to link zettel|19700101000101]]
+* [[How to tag zettel|19700101000102]]
+* [[How to search zettel|19700101000103]]
+* [[How to use templates|19700101000104]]
+
+All tutorial zettel are tagged with ''#tutorial''.
+They were created because your Zettelstore was empty.
+Feel free to change them or to delete them when you do not need them any more.`,
+	},
+	19700101000101: {
+		constHeader{
+			meta.KeyTitle:  "How to Link Zettel",
+			meta.KeyRole:   meta.ValueRoleZettel,
+			meta.KeySyntax: meta.ValueSyntaxZmk,
+			meta.KeyTags:   tagTutorial,
+		},
+		`Zettel become valuable when they are connected.
+A link to another zettel is written with two square brackets around its identifier: ''[[19700101000100]]'' becomes [[19700101000100]].
+
+You can give a link its own text by writing it before a vertical bar: ''[[start here|19700101000100]]'' becomes [[start here|19700101000100]].
+External links work the same way: ''[[Zettelstore|https://zettelstore.de]]''.
+
+The info page of a zettel lists all zettel that it links to.
+Continue with [[How to tag zettel|19700101000102]].`,
+	},
+	19700101000102: {
+		constHeader{
+			meta.KeyTitle:  "How to Tag Zettel",
+			meta.KeyRole:   meta.ValueRoleZettel,
+			meta.KeySyntax: meta.ValueSyntaxZmk,
+			meta.KeyTags:   tagTutorial,
+		},
+		`Tags group zettel that belong to the same topic.
+They are stored in the metadata of a zettel, below the key ''tags''.
+Every tag starts with the character ''#'', e.g. ''tags: #tutorial #idea''.
+
+Click on a tag to list all zettel with that tag.
+The menu entry ""List Tags"" shows all tags that are in use.
+Continue with [[How to search zettel|19700101000103]].`,
+	},
+	19700101000103: {
+		constHeader{
+			meta.KeyTitle:  "How to Search Zettel",
+			meta.KeyRole:   meta.ValueRoleZettel,
+			meta.KeySyntax: meta.ValueSyntaxZmk,
+			meta.KeyTags:   tagTutorial,
+		},
+		`Enter some words into the search field of the menu to find all zettel containing them.
+
+To narrow a list of zettel, use the menu entry ""List Zettel"".
+Its side bar shows the roles and tags of all listed zettel.
+Click on one of them to list only the zettel with that role or tag.
+
+Continue with [[How to use templates|19700101000104]].`,
+	},
+	19700101000104: {
+		constHeader{
+			meta.KeyTitle:  "How to Use Templates",
+			meta.KeyRole:   meta.ValueRoleZettel,
+			meta.KeySyntax: meta.ValueSyntaxZmk,
+			meta.KeyTags:   tagTutorial,
+		},
+		`New zettel are created from templates.
+Every zettel with role ''new-template'' is a template and is shown in the ""New"" menu.
+
+The [[Meeting Note|19700101000105]] template is an example.
+Its metadata key ''new-role'' determines the role of every zettel created from it.
+Its content is copied into the new zettel.
+Change the template to fit your needs, or copy it to create another one.`,
+	},
+	19700101000105: {
+		constHeader{
+			meta.KeyTitle:   "Meeting Note",
+			meta.KeyRole:    meta.ValueRoleNewTemplate,
+			meta.KeyNewRole: meta.ValueRoleZettel,
+			meta.KeySyntax:  meta.ValueSyntaxZmk,
+			meta.KeyTags:    "#meeting",
+		},
+		`=== Participants
+* Name
+
+=== Topics
+# Topic
+
+=== Decisions
+* Decision
+`,
+	},
+}
@@ -0,0 +1,218 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package dirplace provides a directory-based zettel place.
+package dirplace
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/place/dirplace/directory"
+)
+
+// blobDirName is the name of the sub-directory that stores deduplicated content.
+// Blobs are never removed automatically, even if no zettel refers to them.
+const blobDirName = ".blobs"
+
+func (dp *dirPlace) blobDir() string {
+	return filepath.Join(dp.dir, blobDirName)
+}
+
+// setContentHash stores the hash value of a separately stored content as a
+// computed property, if deduplication is enabled. The hash value is cached in
+// the directory entry, as long as the content file is not changed.
+func (dp *dirPlace) setContentHash(m *meta.Meta, entry *directory.Entry) {
+	if !dp.isDeduplicated(entry) {
+		return
+	}
+	hash, current := entry.ContentHash()
+	if !current {
+		newHash, err := hashFile(entry.ContentPath)
+		if err != nil {
+			return
+		}
+		dp.updateContentHash(entry, hash, newHash)
+		hash = newHash
+	}
+	m.Set(meta.KeyContentHash, hash)
+}
+
+// verifyContentHash compares the cached hash value with the hash value of the
+// content that was just read. The content file might have been changed in
+// place, before the directory service noticed it.
+func (dp *dirPlace) verifyContentHash(entry *directory.Entry, content string) {
+	if !dp.isDeduplicated(entry) {
+		return
+	}
+	hash, current := entry.ContentHash()
+	if newHash := hashContent(content); !current || newHash != hash {
+		dp.updateContentHash(entry, hash, newHash)
+	}
+}
+
+func (dp *dirPlace) isDeduplicated(entry *directory.Entry) bool {
+	if !dp.dedup {
+		return false
+	}
+	switch entry.MetaSpec {
+	case directory.MetaSpecFile, directory.MetaSpecNone:
+		return true
+	}
+	return false
+}
+
+// updateContentHash caches the new hash value of the content file. If the
+// content file is still a link to the blob of the old hash value, the blob was
+// changed in place, e.g. by an external editor. All zettel that share the blob
+// are changed too, which cannot be undone. The blob is removed, so that it is
+// never used for new content.
+func (dp *dirPlace) updateContentHash(entry *directory.Entry, oldHash, newHash string) {
+	if oldHash != "" && oldHash != newHash {
+		blobPath := filepath.Join(dp.blobDir(), oldHash)
+		if isSameFile(blobPath, entry.ContentPath) {
+			log.Printf("DIRPLACE: blob %v was changed in place, all zettel sharing it with %v changed",
+				oldHash, entry.Zid)
+			dp.countError(os.Remove(blobPath))
+		}
+	}
+	entry.SetContentHash(newHash)
+	dp.dirSrv.SetContentHash(entry)
+}
+
+func isSameFile(path1, path2 string) bool {
+	fi1, err := os.Stat(path1)
+	if err != nil {
+		return false
+	}
+	fi2, err := os.Stat(path2)
+	return err == nil && os.SameFile(fi1, fi2)
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// writeContent writes the content into a file. If blobDir is not empty, the
+// content is stored only once in the blob directory and the file is a hard
+// link to it. If hard links are not supported, the file is written normally.
+func writeContent(blobDir, path, content string) error {
+	if blobDir == "" {
+		return writeFileContent(path, content)
+	}
+	blobPath := filepath.Join(blobDir, hashContent(content))
+	if _, err := os.Stat(blobPath); os.IsNotExist(err) {
+		if err = writeFileContent(blobPath, content); err != nil {
+			return err
+		}
+	}
+
+	if err := breakLink(path); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.Link(blobPath, path); err != nil {
+		return writeFileContent(path, content)
+	}
+	return nil
+}
+
+// writeContentFrom writes the content read from r into a file. If blobDir is
+// not empty, the content is deduplicated like in writeContent. Because the hash
+// value is known only after reading all content, the content is first written
+// into a temporary file of the blob directory. The hash value is returned.
+func writeContentFrom(blobDir, path string, r io.Reader) (string, error) {
+	if blobDir == "" {
+		return "", writeFileFrom(path, r)
+	}
+	if err := os.MkdirAll(blobDir, 0755); err != nil {
+		return "", err
+	}
+	f, err := ioutil.TempFile(blobDir, "tmp-")
+	if err != nil {
+		return "", err
+	}
+	tmpPath := f.Name()
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, h), r)
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, 0644)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+
+	hash := hex.EncodeToString(h.Sum(nil))
+	blobPath := filepath.Join(blobDir, hash)
+	if _, err = os.Stat(blobPath); err == nil {
+		err = os.Remove(tmpPath)
+	} else {
+		err = os.Rename(tmpPath, blobPath)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err = breakLink(path); err != nil {
+		return "", err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	if err = os.Link(blobPath, path); err != nil {
+		err = copyFile(blobPath, path)
+	}
+	return hash, err
+}
+
+func copyFile(srcPath, dstPath string) error {
+	f, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return writeFileFrom(dstPath, f)
+}
+
+// breakLink removes the file, so that a following write creates a new file
+// instead of changing a blob that is shared with other zettel. If
+// deduplication is enabled, an existing content file must never be written.
+func breakLink(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
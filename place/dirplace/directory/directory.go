@@ -12,50 +12,108 @@
 package directory
 
 import (
+	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"zettelstore.de/z/domain/id"
 	"zettelstore.de/z/place"
 )
 
+// Sharding specifies how zettel files are distributed over sub-directories.
+type Sharding int
+
+// Constants for Sharding
+const (
+	ShardNone  Sharding = iota // All files are stored in one directory
+	ShardYear                  // Sub-directory "YYYY" of the zettel id
+	ShardMonth                 // Sub-directories "YYYY/MM" of the zettel id
+)
+
 // Service specifies a directory scan service.
 type Service struct {
 	dirPath     string
 	rescanTime  time.Duration
+	workers     int
+	sharding    Sharding
+	scanning    int32  // 1, if initial scan is not completed
+	lastScan    int64  // time of last completed scan in nanoseconds
+	numErrors   uint64 // number of scan errors
 	done        chan struct{}
 	cmds        chan dirCmd
 	changeFuncs []place.ObserverFunc
 	mxFuncs     sync.RWMutex
+	zids        *id.Allocator
 }
 
-// NewService creates a new directory service.
-func NewService(directoryPath string, rescanTime time.Duration) *Service {
+// NewService creates a new directory service. The given number of workers
+// is used to scan the directory in parallel.
+func NewService(
+	directoryPath string, rescanTime time.Duration, workers int, sharding Sharding) *Service {
 	srv := &Service{
 		dirPath:    directoryPath,
 		rescanTime: rescanTime,
+		workers:    workers,
+		sharding:   sharding,
 		cmds:       make(chan dirCmd),
+		zids:       id.NewAllocator(),
 	}
 	return srv
 }
 
+// ZettelDir returns the directory, where the files of the given zettel id
+// should be stored.
+func (srv *Service) ZettelDir(zid id.Zid) string {
+	s := zid.String()
+	switch srv.sharding {
+	case ShardYear:
+		return filepath.Join(srv.dirPath, s[:4])
+	case ShardMonth:
+		return filepath.Join(srv.dirPath, s[:4], s[4:6])
+	}
+	return srv.dirPath
+}
+
 // Start makes the directory service operational.
+//
+// It does not wait for the initial directory scan to complete. While scanning,
+// entries are retrieved from the already scanned files. All operations that
+// need to know all entries are delayed until the initial scan is completed.
 func (srv *Service) Start() {
 	tick := make(chan struct{})
 	rawEvents := make(chan *fileEvent)
 	events := make(chan *fileEvent)
 
-	ready := make(chan int)
-	go srv.directoryService(events, ready)
+	atomic.StoreInt32(&srv.scanning, 1)
+	go srv.directoryService(events)
 	go collectEvents(events, rawEvents)
-	go watchDirectory(srv.dirPath, rawEvents, tick)
+	go watchDirectory(srv.dirPath, srv.sharding != ShardNone, srv.workers, rawEvents, tick)
 
 	if srv.done != nil {
 		panic("src.done already set")
 	}
 	srv.done = make(chan struct{})
 	go ping(tick, srv.rescanTime, srv.done)
-	<-ready
+}
+
+// IsScanning returns true, if the initial directory scan is not completed.
+func (srv *Service) IsScanning() bool {
+	return atomic.LoadInt32(&srv.scanning) != 0
+}
+
+// LastScan returns the time, when the last directory scan was completed. If
+// no scan was completed, the zero time is returned.
+func (srv *Service) LastScan() time.Time {
+	if ns := atomic.LoadInt64(&srv.lastScan); ns != 0 {
+		return time.Unix(0, ns)
+	}
+	return time.Time{}
+}
+
+// NumErrors returns the number of errors that occurred while scanning.
+func (srv *Service) NumErrors() int {
+	return int(atomic.LoadUint64(&srv.numErrors))
 }
 
 // Stop stops the directory service.
@@ -82,7 +140,8 @@ func (srv *Service) notifyChange(reason place.ChangeReason, zid id.Zid) {
 	}
 }
 
-// NumEntries returns the number of managed zettel.
+// NumEntries returns the number of managed zettel. While the initial scan is
+// running, only the already scanned zettel are counted.
 func (srv *Service) NumEntries() int {
 	resChan := make(chan resNumEntries)
 	srv.cmds <- &cmdNumEntries{resChan}
@@ -104,10 +163,11 @@ func (srv *Service) GetEntry(zid id.Zid) Entry {
 	return <-resChan
 }
 
-// GetNew returns an entry with a new zettel id.
+// GetNew returns an entry with a new zettel id. Concurrent calls never
+// return the same zettel id.
 func (srv *Service) GetNew() Entry {
 	resChan := make(chan resNewEntry)
-	srv.cmds <- &cmdNewEntry{resChan}
+	srv.cmds <- &cmdNewEntry{srv.zids, resChan}
 	return <-resChan
 }
 
@@ -118,6 +178,14 @@ func (srv *Service) UpdateEntry(entry *Entry) {
 	<-resChan
 }
 
+// SetContentHash caches the hash value of the content file of the given entry.
+// It is ignored, if the content file was changed in the meantime.
+func (srv *Service) SetContentHash(entry *Entry) {
+	resChan := make(chan struct{})
+	srv.cmds <- &cmdSetContentHash{entry, resChan}
+	<-resChan
+}
+
 // RenameEntry notifies the directory of an renamed entry.
 func (srv *Service) RenameEntry(curEntry, newEntry *Entry) error {
 	resChan := make(chan resRenameEntry)
@@ -131,3 +199,27 @@ func (srv *Service) DeleteEntry(zid id.Zid) {
 	srv.cmds <- &cmdDeleteEntry{zid, resChan}
 	<-resChan
 }
+
+// ReloadEntry rescans the files of the given zettel id and updates its entry.
+// It returns false, if no file belongs to the zettel id any more.
+func (srv *Service) ReloadEntry(zid id.Zid) (bool, error) {
+	dirs := []string{srv.dirPath}
+	if zettelDir := srv.ZettelDir(zid); zettelDir != srv.dirPath {
+		dirs = append(dirs, zettelDir)
+	}
+	entry, err := scanEntry(zid, dirs...)
+	if err != nil {
+		return false, err
+	}
+	resChan := make(chan resReloadEntry)
+	srv.cmds <- &cmdReloadEntry{zid, entry, resChan}
+	known := <-resChan
+	if entry == nil {
+		if known {
+			srv.notifyChange(place.OnDelete, zid)
+		}
+		return false, nil
+	}
+	srv.notifyChange(place.OnUpdate, zid)
+	return true, nil
+}
@@ -12,10 +12,11 @@
 package directory
 
 import (
-	"strings"
+	"time"
 
 	"zettelstore.de/z/domain/id"
 	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/mimetype"
 )
 
 // MetaSpec defines all possibilities where meta data can be stored.
@@ -37,29 +38,59 @@ type Entry struct {
 	ContentPath string   // file path of zettel content
 	ContentExt  string   // (normalized) file extension of zettel content
 	Duplicates  bool     // multiple content files
+
+	metaStamp    fileStamp
+	contentStamp fileStamp
+	contentHash  string    // hash value of the content file, if calculated
+	hashStamp    fileStamp // state of the content file, when hashed
 }
 
-// IsValid checks whether the entry is valid.
-func (e *Entry) IsValid() bool {
-	return e.Zid.IsValid()
+// fileStamp records the state of a file, to detect changes without reading it.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
 }
 
-var alternativeSyntax = map[string]string{
-	"htm": "html",
+// sameFiles returns true, if both entries refer to the same, unchanged files.
+func (e *Entry) sameFiles(other *Entry) bool {
+	return e.MetaSpec == other.MetaSpec &&
+		e.MetaPath == other.MetaPath &&
+		e.ContentPath == other.ContentPath &&
+		e.ContentExt == other.ContentExt &&
+		e.Duplicates == other.Duplicates &&
+		e.metaStamp == other.metaStamp &&
+		e.contentStamp == other.contentStamp
 }
 
-func (e *Entry) calculateSyntax() string {
-	ext := strings.ToLower(e.ContentExt)
-	if syntax, ok := alternativeSyntax[ext]; ok {
-		return syntax
+// ContentHash returns the cached hash value of the content file. The second
+// result is false, if the content file was changed since the hash value was
+// calculated. An outdated hash value is still returned, if known.
+func (e *Entry) ContentHash() (string, bool) {
+	return e.contentHash, e.contentHash != "" && e.hashStamp == e.contentStamp
+}
+
+// SetContentHash caches the hash value of the current content file.
+func (e *Entry) SetContentHash(hash string) {
+	e.contentHash, e.hashStamp = hash, e.contentStamp
+}
+
+// keepContentHash copies the cached hash value of the other entry, if both
+// refer to the same content file.
+func (e *Entry) keepContentHash(other *Entry) {
+	if e.ContentPath == other.ContentPath {
+		e.contentHash, e.hashStamp = other.contentHash, other.hashStamp
 	}
-	return ext
+}
+
+// IsValid checks whether the entry is valid.
+func (e *Entry) IsValid() bool {
+	return e.Zid.IsValid()
 }
 
 // CalcDefaultMeta returns metadata with default values for the given entry.
 func (e *Entry) CalcDefaultMeta() *meta.Meta {
 	m := meta.New(e.Zid)
 	m.Set(meta.KeyTitle, e.Zid.String())
-	m.Set(meta.KeySyntax, e.calculateSyntax())
+	m.Set(meta.KeySyntax, mimetype.ExtSyntax(e.ContentExt))
 	return m
 }
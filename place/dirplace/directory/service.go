@@ -13,6 +13,7 @@ package directory
 
 import (
 	"log"
+	"sync/atomic"
 	"time"
 
 	"zettelstore.de/z/domain/id"
@@ -50,6 +51,7 @@ func updateEntry(de *Entry, ev *fileEvent) {
 	if ev.ext == "meta" {
 		de.MetaSpec = MetaSpecFile
 		de.MetaPath = ev.path
+		de.metaStamp = ev.stamp
 		return
 	}
 	if len(de.ContentExt) != 0 && de.ContentExt != ev.ext {
@@ -65,6 +67,7 @@ func updateEntry(de *Entry, ev *fileEvent) {
 	}
 	de.ContentPath = ev.path
 	de.ContentExt = ev.ext
+	de.contentStamp = ev.stamp
 }
 
 type dirMap map[id.Zid]*Entry
@@ -79,21 +82,74 @@ func dirMapUpdate(dm dirMap, ev *fileEvent) {
 }
 
 func deleteFromMap(dm dirMap, ev *fileEvent) {
+	entry, ok := dm[ev.zid]
+	if !ok {
+		return
+	}
 	if ev.ext == "meta" {
-		if entry, ok := dm[ev.zid]; ok {
-			if entry.MetaSpec == MetaSpecFile {
+		if entry.MetaSpec == MetaSpecFile {
+			if entry.MetaPath == ev.path {
 				entry.MetaSpec = MetaSpecNone
-				return
 			}
+			return
 		}
 	}
-	delete(dm, ev.zid)
+	// Files of the zettel with an outdated name may be removed, e.g. after
+	// the title has changed.
+	if entry.ContentPath == "" || entry.ContentPath == ev.path {
+		delete(dm, ev.zid)
+	}
+}
+
+type dirChange struct {
+	reason place.ChangeReason
+	zid    id.Zid
+}
+
+// diffMaps compares the entries of the current map with the entries of the
+// newly scanned map and returns all detected changes.
+func diffMaps(curMap, newMap dirMap) []dirChange {
+	var result []dirChange
+	for zid, ne := range newMap {
+		if ce, ok := curMap[zid]; !ok {
+			result = append(result, dirChange{place.OnCreate, zid})
+		} else if !ce.sameFiles(ne) {
+			result = append(result, dirChange{place.OnUpdate, zid})
+		}
+	}
+	for zid := range curMap {
+		if _, ok := newMap[zid]; !ok {
+			result = append(result, dirChange{place.OnDelete, zid})
+		}
+	}
+	return result
+}
+
+// keepContentHashes copies the cached hash values of the current entries into
+// the newly scanned entries.
+func keepContentHashes(curMap, newMap dirMap) {
+	for zid, ne := range newMap {
+		if ce, ok := curMap[zid]; ok {
+			ne.keepContentHash(ce)
+		}
+	}
+}
+
+// needsAllEntries returns true, if the command must wait for the initial scan.
+func needsAllEntries(cmd dirCmd) bool {
+	switch cmd.(type) {
+	case *cmdGetEntries, *cmdNewEntry:
+		return true
+	}
+	return false
 }
 
 // directoryService is the main service.
-func (srv *Service) directoryService(events <-chan *fileEvent, ready chan<- int) {
+func (srv *Service) directoryService(events <-chan *fileEvent) {
 	curMap := make(dirMap)
 	var newMap dirMap
+	scanning := true
+	var delayed []dirCmd
 	for {
 		select {
 		case ev, ok := <-events:
@@ -102,17 +158,34 @@ func (srv *Service) directoryService(events <-chan *fileEvent, ready chan<- int)
 			}
 			switch ev.status {
 			case fileStatusReloadStart:
-				newMap = make(dirMap)
+				if scanning {
+					// Fill the current map, so that it can be used while scanning.
+					newMap = curMap
+				} else {
+					newMap = make(dirMap)
+				}
 			case fileStatusReloadEnd:
+				atomic.StoreInt64(&srv.lastScan, time.Now().UnixNano())
+				if scanning {
+					newMap = nil
+					scanning = false
+					atomic.StoreInt32(&srv.scanning, 0)
+					for _, cmd := range delayed {
+						cmd.run(curMap)
+					}
+					delayed = nil
+					srv.notifyChange(place.OnReload, id.Invalid)
+					continue
+				}
+				changes := diffMaps(curMap, newMap)
+				keepContentHashes(curMap, newMap)
 				curMap = newMap
 				newMap = nil
-				if ready != nil {
-					ready <- len(curMap)
-					close(ready)
-					ready = nil
+				for _, c := range changes {
+					srv.notifyChange(c.reason, c.zid)
 				}
-				srv.notifyChange(place.OnReload, id.Invalid)
 			case fileStatusError:
+				atomic.AddUint64(&srv.numErrors, 1)
 				log.Println("DIRPLACE", "ERROR", ev.err)
 			case fileStatusUpdate:
 				if newMap != nil {
@@ -131,7 +204,11 @@ func (srv *Service) directoryService(events <-chan *fileEvent, ready chan<- int)
 			}
 		case cmd, ok := <-srv.cmds:
 			if ok {
-				cmd.run(curMap)
+				if scanning && needsAllEntries(cmd) {
+					delayed = append(delayed, cmd)
+				} else {
+					cmd.run(curMap)
+				}
 			}
 		}
 	}
@@ -179,29 +256,19 @@ func (cmd *cmdGetEntry) run(m dirMap) {
 }
 
 type cmdNewEntry struct {
+	zids   *id.Allocator
 	result chan<- resNewEntry
 }
 type resNewEntry = Entry
 
 func (cmd *cmdNewEntry) run(m dirMap) {
-	zid := id.New(false)
-	if _, ok := m[zid]; !ok {
-		entry := &Entry{Zid: zid, MetaSpec: MetaSpecUnknown}
-		m[zid] = entry
-		cmd.result <- *entry
-		return
-	}
-	for {
-		zid = id.New(true)
-		if _, ok := m[zid]; !ok {
-			entry := &Entry{Zid: zid, MetaSpec: MetaSpecUnknown}
-			m[zid] = entry
-			cmd.result <- *entry
-			return
-		}
-		// TODO: do not wait here, but in a non-blocking goroutine.
-		time.Sleep(100 * time.Millisecond)
-	}
+	zid := cmd.zids.New(false, func(zid id.Zid) bool {
+		_, ok := m[zid]
+		return ok
+	})
+	entry := &Entry{Zid: zid, MetaSpec: MetaSpecUnknown}
+	m[zid] = entry
+	cmd.result <- *entry
 }
 
 type cmdUpdateEntry struct {
@@ -215,6 +282,19 @@ func (cmd *cmdUpdateEntry) run(m dirMap) {
 	cmd.result <- struct{}{}
 }
 
+type cmdSetContentHash struct {
+	entry  *Entry
+	result chan<- struct{}
+}
+
+func (cmd *cmdSetContentHash) run(m dirMap) {
+	if entry := m[cmd.entry.Zid]; entry != nil && entry.ContentPath == cmd.entry.ContentPath &&
+		entry.contentStamp == cmd.entry.hashStamp {
+		entry.contentHash, entry.hashStamp = cmd.entry.contentHash, cmd.entry.hashStamp
+	}
+	cmd.result <- struct{}{}
+}
+
 type cmdRenameEntry struct {
 	curEntry *Entry
 	newEntry *Entry
@@ -235,6 +315,26 @@ func (cmd *cmdRenameEntry) run(m dirMap) {
 	cmd.result <- nil
 }
 
+type cmdReloadEntry struct {
+	zid    id.Zid
+	entry  *Entry
+	result chan<- resReloadEntry
+}
+type resReloadEntry = bool
+
+func (cmd *cmdReloadEntry) run(m dirMap) {
+	ce, known := m[cmd.zid]
+	if cmd.entry == nil {
+		delete(m, cmd.zid)
+	} else {
+		if known {
+			cmd.entry.keepContentHash(ce)
+		}
+		m[cmd.zid] = cmd.entry
+	}
+	cmd.result <- known
+}
+
 type cmdDeleteEntry struct {
 	zid    id.Zid
 	result chan<- struct{}
@@ -0,0 +1,106 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package directory manages the directory part of a directory place.
+package directory
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/place"
+)
+
+func TestDiffMaps(t *testing.T) {
+	now := time.Now()
+	mkEntry := func(zid id.Zid, size int64) *Entry {
+		return &Entry{
+			Zid:          zid,
+			MetaSpec:     MetaSpecHeader,
+			ContentPath:  zid.String() + ".zettel",
+			ContentExt:   "zettel",
+			contentStamp: fileStamp{now, size},
+		}
+	}
+	curMap := dirMap{1: mkEntry(1, 10), 2: mkEntry(2, 20), 3: mkEntry(3, 30)}
+	newMap := dirMap{1: mkEntry(1, 10), 2: mkEntry(2, 21), 4: mkEntry(4, 40)}
+
+	exp := map[id.Zid]place.ChangeReason{2: place.OnUpdate, 3: place.OnDelete, 4: place.OnCreate}
+	got := diffMaps(curMap, newMap)
+	if len(got) != len(exp) {
+		t.Fatalf("exp %d changes, got %v", len(exp), got)
+	}
+	for _, c := range got {
+		if reason, ok := exp[c.zid]; !ok || reason != c.reason {
+			t.Errorf("unexpected change %v for zid %v", c.reason, c.zid)
+		}
+	}
+}
+
+func TestContentHash(t *testing.T) {
+	now := time.Now()
+	e := &Entry{Zid: 1, ContentPath: "1.txt", contentStamp: fileStamp{now, 10}}
+	if _, current := e.ContentHash(); current {
+		t.Error("hash must not be current initially")
+	}
+	e.SetContentHash("abc")
+	if hash, current := e.ContentHash(); hash != "abc" || !current {
+		t.Errorf("expected current hash abc, but got %q/%v", hash, current)
+	}
+	updateEntry(e, &fileEvent{zid: 1, path: "1.txt", ext: "txt", stamp: fileStamp{now, 11}})
+	if hash, current := e.ContentHash(); hash != "abc" || current {
+		t.Errorf("expected outdated hash abc, but got %q/%v", hash, current)
+	}
+
+	e.SetContentHash("def")
+	ne := &Entry{Zid: 1, ContentPath: "1.txt", contentStamp: fileStamp{now, 11}}
+	keepContentHashes(dirMap{1: e}, dirMap{1: ne})
+	if hash, current := ne.ContentHash(); hash != "def" || !current {
+		t.Errorf("expected kept hash def, but got %q/%v", hash, current)
+	}
+}
+
+func TestGetNewConcurrent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zs-directory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	srv := NewService(dir, time.Hour, 1, ShardNone)
+	srv.Start()
+	defer srv.Stop()
+
+	const n = 20
+	zids := make(chan id.Zid, n)
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			zids <- srv.GetNew().Zid
+		}()
+	}
+	wg.Wait()
+	close(zids)
+	seen := make(map[id.Zid]bool, n)
+	for zid := range zids {
+		if seen[zid] {
+			t.Errorf("zid %v allocated twice", zid)
+		}
+		seen[zid] = true
+	}
+	if got := srv.NumEntries(); got != n {
+		t.Errorf("expected %d entries, but got %d", n, got)
+	}
+}
@@ -12,10 +12,13 @@
 package directory
 
 import (
-	"io/ioutil"
+	"log"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
@@ -45,7 +48,8 @@ type fileEvent struct {
 	path   string // Full file path
 	zid    id.Zid
 	ext    string // File extension
-	err    error  // Error if Status == fileStatusError
+	stamp  fileStamp
+	err    error // Error if Status == fileStatusError
 }
 
 type sendResult int
@@ -56,7 +60,17 @@ const (
 	sendExit
 )
 
-func watchDirectory(directory string, events chan<- *fileEvent, tick <-chan struct{}) {
+// scanChunkSize is the number of files that are inspected in parallel, before
+// their events are sent.
+const scanChunkSize = 1024
+
+func watchDirectory(
+	directory string,
+	recursive bool,
+	workers int,
+	events chan<- *fileEvent,
+	tick <-chan struct{},
+) {
 	defer close(events)
 
 	var watcher *fsnotify.Watcher
@@ -82,7 +96,7 @@ func watchDirectory(directory string, events chan<- *fileEvent, tick <-chan stru
 		return sendEvent(&fileEvent{status: fileStatusError, err: err})
 	}
 
-	sendFileEvent := func(status fileStatus, path string, match []string) sendResult {
+	sendFileEvent := func(status fileStatus, path string, match []string, fi os.FileInfo) sendResult {
 		zid, err := id.Parse(match[1])
 		if err != nil {
 			return sendDone
@@ -93,13 +107,17 @@ func watchDirectory(directory string, events chan<- *fileEvent, tick <-chan stru
 			zid:    zid,
 			ext:    match[3],
 		}
+		if fi != nil {
+			event.stamp = fileStamp{fi.ModTime(), fi.Size()}
+		}
 		return sendEvent(event)
 	}
 
 	reloadStartEvent := &fileEvent{status: fileStatusReloadStart}
 	reloadEndEvent := &fileEvent{status: fileStatusReloadEnd}
+	firstScan := true
 	reloadFiles := func() bool {
-		files, err := ioutil.ReadDir(directory)
+		names, dirs, err := readFileNames(directory, recursive)
 		if err != nil {
 			if res := sendError(err); res != sendDone {
 				return res == sendReload
@@ -121,25 +139,35 @@ func watchDirectory(directory string, events chan<- *fileEvent, tick <-chan stru
 			}
 		}
 
-		for _, file := range files {
-			if !file.Mode().IsRegular() {
-				continue
+		progress := newScanProgress(directory, len(names), firstScan)
+		firstScan = false
+		for start := 0; start < len(names); start += scanChunkSize {
+			end := start + scanChunkSize
+			if end > len(names) {
+				end = len(names)
 			}
-			name := file.Name()
-			match := matchValidFileName(name)
-			if len(match) > 0 {
+			chunk := names[start:end]
+			for i, fi := range statFiles(directory, chunk, workers) {
+				if fi == nil || !fi.Mode().IsRegular() {
+					continue
+				}
+				name := chunk[i]
 				path := filepath.Join(directory, name)
-				if res := sendFileEvent(fileStatusUpdate, path, match); res != sendDone {
+				if res := sendFileEvent(
+					fileStatusUpdate, path, matchValidFileName(filepath.Base(name)), fi); res != sendDone {
 					return res == sendReload
 				}
 			}
+			progress.update(end)
 		}
+		progress.done()
 
 		if watcher != nil {
-			err = watcher.Add(directory)
-			if err != nil {
-				if res := sendError(err); res != sendDone {
-					return res == sendReload
+			for _, dir := range append([]string{directory}, dirs...) {
+				if err = watcher.Add(dir); err != nil {
+					if res := sendError(err); res != sendDone {
+						return res == sendReload
+					}
 				}
 			}
 		}
@@ -160,22 +188,33 @@ func watchDirectory(directory string, events chan<- *fileEvent, tick <-chan stru
 					return false
 				}
 				path := filepath.Clean(wevent.Name)
+				if recursive && wevent.Op&fsnotify.Create != 0 && isShardName(filepath.Base(path)) {
+					if fi, err := os.Lstat(path); err == nil && fi.IsDir() {
+						if err = watcher.Add(path); err != nil {
+							if res := sendError(err); res != sendDone {
+								return res == sendReload
+							}
+						}
+						continue
+					}
+				}
 				match := matchValidFileName(filepath.Base(path))
 				if len(match) == 0 {
 					continue
 				}
 				if wevent.Op&createOps != 0 {
-					if fi, err := os.Lstat(path); err != nil || !fi.Mode().IsRegular() {
+					fi, err := os.Lstat(path)
+					if err != nil || !fi.Mode().IsRegular() {
 						continue
 					}
 					if res := sendFileEvent(
-						fileStatusUpdate, path, match); res != sendDone {
+						fileStatusUpdate, path, match, fi); res != sendDone {
 						return res == sendReload
 					}
 				}
 				if wevent.Op&deleteOps != 0 {
 					if res := sendFileEvent(
-						fileStatusDelete, path, match); res != sendDone {
+						fileStatusDelete, path, match, nil); res != sendDone {
 						return res == sendReload
 					}
 				}
@@ -217,6 +256,188 @@ func watchDirectory(directory string, events chan<- *fileEvent, tick <-chan stru
 	}
 }
 
+// readFileNames returns the sorted names of all files of the directory that
+// could possibly store a zettel. If recursive is true, shard sub-directories
+// are read too. In this case, the names are relative to the directory, and
+// all read shard sub-directories are returned.
+func readFileNames(directory string, recursive bool) (names, dirs []string, err error) {
+	if err = collectFileNames(directory, "", recursive, &names, &dirs); err != nil {
+		return nil, nil, err
+	}
+	sort.Strings(names)
+	return names, dirs, nil
+}
+
+func collectFileNames(directory, rel string, recursive bool, names, dirs *[]string) error {
+	f, err := os.Open(filepath.Join(directory, rel))
+	if err != nil {
+		return err
+	}
+	if !recursive {
+		dirNames, err := f.Readdirnames(-1)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		for _, name := range dirNames {
+			if len(matchValidFileName(name)) > 0 {
+				*names = append(*names, filepath.Join(rel, name))
+			}
+		}
+		return nil
+	}
+
+	fis, err := f.Readdir(-1)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	for _, fi := range fis {
+		name := fi.Name()
+		if fi.IsDir() {
+			if isShardName(name) {
+				subRel := filepath.Join(rel, name)
+				*dirs = append(*dirs, filepath.Join(directory, subRel))
+				if err = collectFileNames(directory, subRel, recursive, names, dirs); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if len(matchValidFileName(name)) > 0 {
+			*names = append(*names, filepath.Join(rel, name))
+		}
+	}
+	return nil
+}
+
+// isShardName returns true, if the given name could be the name of a shard
+// sub-directory.
+func isShardName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, ch := range name {
+		if ch < '0' || '9' < ch {
+			return false
+		}
+	}
+	return true
+}
+
+// statFiles retrieves the file information of the given file names, using
+// the given number of workers in parallel. If a file could not be inspected,
+// its file information is nil.
+func statFiles(directory string, names []string, workers int) []os.FileInfo {
+	if workers < 1 {
+		workers = 1
+	}
+	fis := make([]os.FileInfo, len(names))
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < len(names); i += workers {
+				if fi, err := os.Lstat(filepath.Join(directory, names[i])); err == nil {
+					fis[i] = fi
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	return fis
+}
+
+// scanProgress logs the progress of a long running initial directory scan.
+type scanProgress struct {
+	directory string
+	total     int
+	enabled   bool
+	logged    bool
+	start     time.Time
+	lastLog   time.Time
+}
+
+// scanLogInterval specifies how often the progress of a scan is logged.
+const scanLogInterval = 5 * time.Second
+
+func newScanProgress(directory string, total int, enabled bool) *scanProgress {
+	now := time.Now()
+	return &scanProgress{
+		directory: directory,
+		total:     total,
+		enabled:   enabled,
+		start:     now,
+		lastLog:   now,
+	}
+}
+
+func (sp *scanProgress) update(scanned int) {
+	if !sp.enabled {
+		return
+	}
+	if now := time.Now(); now.Sub(sp.lastLog) >= scanLogInterval {
+		log.Printf("DIRPLACE SCAN %v: %d of %d files", sp.directory, scanned, sp.total)
+		sp.lastLog = now
+		sp.logged = true
+	}
+}
+
+func (sp *scanProgress) done() {
+	if sp.enabled && sp.logged {
+		log.Printf("DIRPLACE SCAN %v: %d files in %v", sp.directory, sp.total, time.Since(sp.start))
+	}
+}
+
+// scanEntry reads the given directories to build the entry of the given
+// zettel id. It returns nil, if no file belongs to the zettel id.
+func scanEntry(zid id.Zid, directories ...string) (*Entry, error) {
+	var paths []string
+	for _, directory := range directories {
+		names, _, err := readFileNames(directory, false)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, name := range names {
+			paths = append(paths, filepath.Join(directory, name))
+		}
+	}
+
+	sZid := zid.String()
+	var de *Entry
+	for _, path := range paths {
+		name := filepath.Base(path)
+		if !strings.HasPrefix(name, sZid) {
+			continue
+		}
+		match := matchValidFileName(name)
+		if len(match) == 0 || match[1] != sZid {
+			continue
+		}
+		fi, err := os.Lstat(path)
+		if err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		ev := &fileEvent{
+			status: fileStatusUpdate,
+			path:   path,
+			zid:    zid,
+			ext:    match[3],
+			stamp:  fileStamp{fi.ModTime(), fi.Size()},
+		}
+		if de == nil {
+			de = newEntry(ev)
+		} else {
+			updateEntry(de, ev)
+		}
+	}
+	return de, nil
+}
+
 func sendCollectedEvents(out chan<- *fileEvent, events []*fileEvent) {
 	for _, ev := range events {
 		if ev.status != fileStatusNone {
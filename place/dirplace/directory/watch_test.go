@@ -12,7 +12,12 @@
 package directory
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+
+	"zettelstore.de/z/domain/id"
 )
 
 func sameStringSlices(sl1, sl2 []string) bool {
@@ -55,3 +60,75 @@ func TestMatchValidFileName(t *testing.T) {
 		}
 	}
 }
+
+func TestScanEntry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zs-directory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"12345678901234.zettel", "12345678901235.meta", "12345678901235.png", "123456789012345.txt"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testcases := []struct {
+		zid      id.Zid
+		metaSpec MetaSpec
+		ext      string
+	}{
+		{12345678901234, MetaSpecHeader, "zettel"},
+		{12345678901235, MetaSpecFile, "png"},
+		{12345678901236, MetaSpecUnknown, ""},
+	}
+	for i, tc := range testcases {
+		de, err := scanEntry(tc.zid, dir)
+		if err != nil {
+			t.Errorf("TC=%d: unexpected error %v", i, err)
+			continue
+		}
+		if de == nil {
+			if tc.metaSpec != MetaSpecUnknown {
+				t.Errorf("TC=%d: no entry found", i)
+			}
+			continue
+		}
+		if de.MetaSpec != tc.metaSpec || de.ContentExt != tc.ext {
+			t.Errorf("TC=%d: exp=(%v,%q), got=(%v,%q)", i, tc.metaSpec, tc.ext, de.MetaSpec, de.ContentExt)
+		}
+	}
+}
+
+func TestReadFileNamesRecursive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zs-directory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, sub := range []string{"2021/01", ".blobs"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"20200101000000.zettel", "2021/01/20210101000000.zettel", ".blobs/12345678901234.png"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names, dirs, err := readFileNames(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expNames := []string{"20200101000000.zettel", filepath.Join("2021", "01", "20210101000000.zettel")}
+	if !sameStringSlices(names, expNames) {
+		t.Errorf("exp=%v, got=%v", expNames, names)
+	}
+	if len(dirs) != 2 {
+		t.Errorf("exp two shard directories, got=%v", dirs)
+	}
+	if names, _, err = readFileNames(dir, false); err != nil || len(names) != 1 {
+		t.Errorf("non-recursive: got=%v, err=%v", names, err)
+	}
+}
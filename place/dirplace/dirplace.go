@@ -13,13 +13,18 @@ package dirplace
 
 import (
 	"context"
+	"io"
+	"io/ioutil"
+	"log"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"zettelstore.de/z/config/runtime"
 	"zettelstore.de/z/domain"
@@ -28,6 +33,7 @@ import (
 	"zettelstore.de/z/place"
 	"zettelstore.de/z/place/dirplace/directory"
 	"zettelstore.de/z/place/manager"
+	"zettelstore.de/z/strfun"
 )
 
 func init() {
@@ -39,6 +45,9 @@ func init() {
 		dp := dirPlace{
 			u:        u,
 			readonly: getQueryBool(u, "readonly"),
+			dedup:    getQueryBool(u, "dedup"),
+			naming:   getQueryNaming(u),
+			sharding: getQuerySharding(u),
 			dir:      path,
 			dirRescan: time.Duration(
 				getQueryInt(u, "rescan", 60, 600, 30*24*60*60)) * time.Second,
@@ -61,6 +70,32 @@ func getQueryBool(u *url.URL, key string) bool {
 	return ok
 }
 
+// fileNaming specifies how the files of a zettel are named.
+type fileNaming int
+
+// Constants for fileNaming
+const (
+	namingZid  fileNaming = iota // "<zid>.<ext>"
+	namingSlug                   // "<zid> <title-slug>.<ext>"
+)
+
+func getQueryNaming(u *url.URL) fileNaming {
+	if u.Query().Get("naming") == "slug" {
+		return namingSlug
+	}
+	return namingZid
+}
+
+func getQuerySharding(u *url.URL) directory.Sharding {
+	switch u.Query().Get("shard") {
+	case "year":
+		return directory.ShardYear
+	case "month":
+		return directory.ShardMonth
+	}
+	return directory.ShardNone
+}
+
 func getQueryInt(u *url.URL, key string, min, def, max int) int {
 	sVal := u.Query().Get(key)
 	if sVal == "" {
@@ -83,6 +118,9 @@ func getQueryInt(u *url.URL, key string, min, def, max int) int {
 type dirPlace struct {
 	u          *url.URL
 	readonly   bool
+	dedup      bool
+	naming     fileNaming
+	sharding   directory.Sharding
 	observers  []place.ObserverFunc
 	mxObserver sync.RWMutex
 	dir        string
@@ -92,6 +130,7 @@ type dirPlace struct {
 	fCmds      []chan fileCmd
 	mxCmds     sync.RWMutex
 	filter     manager.MetaFilter
+	numErrors  uint64 // number of file access errors, accessed atomically
 }
 
 func (dp *dirPlace) Location() string {
@@ -99,6 +138,11 @@ func (dp *dirPlace) Location() string {
 }
 
 func (dp *dirPlace) Start(ctx context.Context) error {
+	if dp.dedup && !dp.readonly {
+		if err := os.MkdirAll(dp.blobDir(), 0755); err != nil {
+			return err
+		}
+	}
 	dp.mxCmds.Lock()
 	dp.fCmds = make([]chan fileCmd, 0, dp.fSrvs)
 	for i := uint32(0); i < dp.fSrvs; i++ {
@@ -106,7 +150,7 @@ func (dp *dirPlace) Start(ctx context.Context) error {
 		go fileService(i, cc)
 		dp.fCmds = append(dp.fCmds, cc)
 	}
-	dp.dirSrv = directory.NewService(dp.dir, dp.dirRescan)
+	dp.dirSrv = directory.NewService(dp.dir, dp.dirRescan, int(dp.fSrvs), dp.sharding)
 	dp.mxCmds.Unlock()
 	dp.dirSrv.Subscribe(dp.notifyChanged)
 	dp.dirSrv.Start()
@@ -177,6 +221,37 @@ func (dp *dirPlace) CreateZettel(
 }
 
 // GetZettel reads the zettel from a file.
+// CreateZettelFrom writes the new zettel without the file services, because
+// the zettel is not known to other requests before it is created.
+func (dp *dirPlace) CreateZettelFrom(
+	ctx context.Context, m *meta.Meta, r io.Reader) (id.Zid, error) {
+	if dp.readonly {
+		return id.Invalid, place.ErrReadOnly
+	}
+
+	entry := dp.dirSrv.GetNew()
+	m.Zid = entry.Zid
+	dp.updateEntryFromMeta(&entry, m)
+
+	var blobDir string
+	if dp.dedup {
+		blobDir = dp.blobDir()
+	}
+	hash, err := writeZettelFrom(&entry, m, r, blobDir)
+	if err != nil {
+		removeEntryFiles(&entry)
+		dp.dirSrv.DeleteEntry(entry.Zid)
+		return id.Invalid, dp.countError(err)
+	}
+	if hash != "" && dp.isDeduplicated(&entry) {
+		entry.SetContentHash(hash)
+		dp.dirSrv.SetContentHash(&entry)
+	}
+	dp.dirSrv.UpdateEntry(&entry)
+	dp.notifyChanged(place.OnCreate, m.Zid)
+	return m.Zid, nil
+}
+
 func (dp *dirPlace) GetZettel(ctx context.Context, zid id.Zid) (domain.Zettel, error) {
 	entry := dp.dirSrv.GetEntry(zid)
 	if !entry.IsValid() {
@@ -184,9 +259,11 @@ func (dp *dirPlace) GetZettel(ctx context.Context, zid id.Zid) (domain.Zettel, e
 	}
 	m, c, err := getMetaContent(dp, &entry, zid)
 	if err != nil {
-		return domain.Zettel{}, err
+		return domain.Zettel{}, dp.checkAvailable(err)
 	}
 	dp.cleanupMeta(ctx, m)
+	dp.verifyContentHash(&entry, c)
+	dp.setContentHash(m, &entry)
 	zettel := domain.Zettel{Meta: m, Content: domain.NewContent(c)}
 	return zettel, nil
 }
@@ -199,27 +276,58 @@ func (dp *dirPlace) GetMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error)
 	}
 	m, err := getMeta(dp, &entry, zid)
 	if err != nil {
-		return nil, err
+		return nil, dp.checkAvailable(err)
 	}
 	dp.cleanupMeta(ctx, m)
+	dp.setContentHash(m, &entry)
 	return m, nil
 }
 
+// OpenContent returns a reader for the content of a specific zettel. If the
+// content is stored in its own file, the file is not read into memory.
+func (dp *dirPlace) OpenContent(ctx context.Context, zid id.Zid) (io.ReadCloser, error) {
+	entry := dp.dirSrv.GetEntry(zid)
+	if !entry.IsValid() {
+		return nil, place.ErrNotFound
+	}
+	switch entry.MetaSpec {
+	case directory.MetaSpecFile, directory.MetaSpecNone:
+		f, err := os.Open(entry.ContentPath)
+		if err != nil {
+			return nil, dp.checkAvailable(err)
+		}
+		return f, nil
+	}
+	_, c, err := getMetaContent(dp, &entry, zid)
+	if err != nil {
+		return nil, dp.checkAvailable(err)
+	}
+	return ioutil.NopCloser(strings.NewReader(c)), nil
+}
+
 // SelectMeta returns all zettel meta data that match the selection
 // criteria. The result is ordered by descending zettel id.
 func (dp *dirPlace) SelectMeta(
 	ctx context.Context, f *place.Filter, s *place.Sorter) (res []*meta.Meta, err error) {
 
+	if err = dp.checkDirectory(); err != nil {
+		return nil, err
+	}
 	hasMatch := place.CreateFilterFunc(f)
+	hasZidMatch := place.CreateZidFilterFunc(f)
 	entries := dp.dirSrv.GetEntries()
 	res = make([]*meta.Meta, 0, len(entries))
 	for _, entry := range entries {
+		if hasZidMatch != nil && !hasZidMatch(entry.Zid) {
+			continue
+		}
 		// TODO: execute requests in parallel
 		m, err := getMeta(dp, &entry, entry.Zid)
 		if err != nil {
 			continue
 		}
 		dp.cleanupMeta(ctx, m)
+		dp.setContentHash(m, &entry)
 		dp.filter.UpdateProperties(m)
 
 		if hasMatch(m) {
@@ -246,6 +354,7 @@ func (dp *dirPlace) UpdateZettel(ctx context.Context, zettel domain.Zettel) erro
 		return &place.ErrInvalidID{Zid: meta.Zid}
 	}
 	entry := dp.dirSrv.GetEntry(meta.Zid)
+	oldEntry := entry
 	if !entry.IsValid() {
 		// Existing zettel, but new in this place.
 		entry.Zid = meta.Zid
@@ -255,19 +364,113 @@ func (dp *dirPlace) UpdateZettel(ctx context.Context, zettel domain.Zettel) erro
 			dp.updateEntryFromMeta(&entry, meta)
 			dp.dirSrv.UpdateEntry(&entry)
 		}
+	} else if dp.naming != namingZid {
+		dp.updateEntryPaths(&entry, meta)
+		if entry.ContentPath != oldEntry.ContentPath || entry.MetaPath != oldEntry.MetaPath {
+			dp.dirSrv.UpdateEntry(&entry)
+		}
+	}
+	if oldEntry.IsValid() {
+		if err := dp.saveUndo(&oldEntry); err != nil {
+			log.Println("DIRPLACE", "UNDO", dp.countError(err))
+		}
 	}
 	dp.notifyChanged(place.OnUpdate, meta.Zid)
-	return setZettel(dp, &entry, zettel)
+	err := setZettel(dp, &entry, zettel)
+	if err == nil {
+		if oldEntry.IsValid() {
+			removeStaleFiles(&oldEntry, &entry)
+		} else {
+			// Do not wait for the directory scan to detect the new files.
+			dp.dirSrv.UpdateEntry(&entry)
+		}
+	}
+	return err
+}
+
+// UpdateMeta writes just the meta file of a zettel, if the meta data is
+// stored in its own file and the file names do not change. Otherwise the
+// whole zettel is written.
+func (dp *dirPlace) UpdateMeta(ctx context.Context, m *meta.Meta) error {
+	if dp.readonly {
+		return place.ErrReadOnly
+	}
+	entry := dp.dirSrv.GetEntry(m.Zid)
+	if !entry.IsValid() {
+		return place.ErrNotFound
+	}
+	if entry.MetaSpec == directory.MetaSpecFile {
+		newEntry := entry
+		if dp.naming != namingZid {
+			dp.updateEntryPaths(&newEntry, m)
+		}
+		spec, ext := calcSpecExt(m)
+		if spec == entry.MetaSpec && ext == entry.ContentExt &&
+			newEntry.MetaPath == entry.MetaPath && newEntry.ContentPath == entry.ContentPath {
+			if err := dp.saveUndo(&entry); err != nil {
+				log.Println("DIRPLACE", "UNDO", dp.countError(err))
+			}
+			dp.notifyChanged(place.OnUpdate, m.Zid)
+			return setMeta(dp, &entry, m)
+		}
+	}
+	_, content, err := getMetaContent(dp, &entry, m.Zid)
+	if err != nil {
+		return err
+	}
+	return dp.UpdateZettel(ctx, domain.Zettel{Meta: m, Content: domain.NewContent(content)})
 }
 
 func (dp *dirPlace) updateEntryFromMeta(entry *directory.Entry, meta *meta.Meta) {
 	entry.MetaSpec, entry.ContentExt = calcSpecExt(meta)
-	basePath := filepath.Join(dp.dir, entry.Zid.String())
+	dp.updateEntryPaths(entry, meta)
+	entry.Duplicates = false
+}
+
+// updateEntryPaths calculates the file paths of the entry, according to the
+// file naming scheme.
+func (dp *dirPlace) updateEntryPaths(entry *directory.Entry, m *meta.Meta) {
+	name := entry.Zid.String()
+	if dp.naming == namingSlug {
+		if slug := titleSlug(m); slug != "" {
+			name += " " + slug
+		}
+	}
+	basePath := filepath.Join(dp.dirSrv.ZettelDir(entry.Zid), name)
 	if entry.MetaSpec == directory.MetaSpecFile {
 		entry.MetaPath = basePath + ".meta"
 	}
 	entry.ContentPath = basePath + "." + entry.ContentExt
-	entry.Duplicates = false
+}
+
+// maxSlugLength is the maximum number of bytes of a title slug in a file name.
+const maxSlugLength = 64
+
+func titleSlug(m *meta.Meta) string {
+	title, ok := m.Get(meta.KeyTitle)
+	if !ok {
+		return ""
+	}
+	slug := strfun.Slugify(title)
+	if len(slug) > maxSlugLength {
+		slug = slug[:maxSlugLength]
+		for !utf8.ValidString(slug) {
+			slug = slug[:len(slug)-1]
+		}
+		slug = strings.TrimRight(slug, "-")
+	}
+	return slug
+}
+
+// removeStaleFiles removes the files of the old entry that are not used by
+// the new entry any more, e.g. because the file naming has changed.
+func removeStaleFiles(oldEntry, newEntry *directory.Entry) {
+	if oldEntry.ContentPath != "" && oldEntry.ContentPath != newEntry.ContentPath {
+		os.Remove(oldEntry.ContentPath)
+	}
+	if oldEntry.MetaSpec == directory.MetaSpecFile && oldEntry.MetaPath != newEntry.MetaPath {
+		os.Remove(oldEntry.MetaPath)
+	}
 }
 
 func calcSpecExt(m *meta.Meta) (directory.MetaSpec, string) {
@@ -314,11 +517,12 @@ func (dp *dirPlace) RenameZettel(ctx context.Context, curZid, newZid id.Zid) err
 		return err
 	}
 
+	newDir := dp.dirSrv.ZettelDir(newZid)
 	newEntry := directory.Entry{
 		Zid:         newZid,
 		MetaSpec:    curEntry.MetaSpec,
-		MetaPath:    renamePath(curEntry.MetaPath, curZid, newZid),
-		ContentPath: renamePath(curEntry.ContentPath, curZid, newZid),
+		MetaPath:    renamePath(curEntry.MetaPath, curZid, newZid, newDir),
+		ContentPath: renamePath(curEntry.ContentPath, curZid, newZid, newDir),
 		ContentExt:  curEntry.ContentExt,
 	}
 
@@ -335,6 +539,7 @@ func (dp *dirPlace) RenameZettel(ctx context.Context, curZid, newZid id.Zid) err
 	if err := deleteZettel(dp, &curEntry, curZid); err != nil {
 		return err
 	}
+	dp.removeUndo(curZid)
 	dp.notifyChanged(place.OnDelete, curZid)
 	dp.notifyChanged(place.OnCreate, newZid)
 	return nil
@@ -360,6 +565,7 @@ func (dp *dirPlace) DeleteZettel(ctx context.Context, zid id.Zid) error {
 		return nil
 	}
 	dp.dirSrv.DeleteEntry(zid)
+	dp.removeUndo(zid)
 	err := deleteZettel(dp, &entry, zid)
 	dp.notifyChanged(place.OnDelete, zid)
 	return err
@@ -375,9 +581,56 @@ func (dp *dirPlace) Reload(ctx context.Context) error {
 	return err
 }
 
-func (dp *dirPlace) ReadStats(st *place.Stats) {
-	st.ReadOnly = dp.readonly
-	st.Zettel = dp.dirSrv.NumEntries()
+func (dp *dirPlace) ReloadZettel(ctx context.Context, zid id.Zid) error {
+	found, err := dp.dirSrv.ReloadEntry(zid)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return place.ErrNotFound
+	}
+	return nil
+}
+
+func (dp *dirPlace) Stats(ctx context.Context) place.Stats {
+	return place.Stats{
+		ReadOnly: dp.readonly,
+		Zettel:   dp.dirSrv.NumEntries(),
+		Scanning: dp.dirSrv.IsScanning(),
+		LastScan: dp.dirSrv.LastScan(),
+		Errors:   dp.dirSrv.NumErrors() + int(atomic.LoadUint64(&dp.numErrors)),
+		Location: dp.Location(),
+	}
+}
+
+// countError records an error that occurred while accessing a file.
+func (dp *dirPlace) countError(err error) error {
+	if err != nil {
+		atomic.AddUint64(&dp.numErrors, 1)
+	}
+	return err
+}
+
+// checkDirectory returns an error, if the directory of the place cannot be
+// accessed.
+func (dp *dirPlace) checkDirectory() error {
+	if _, err := os.Stat(dp.dir); err != nil {
+		return &place.ErrUnavailable{Err: err}
+	}
+	return nil
+}
+
+// checkAvailable returns an error that signals an unavailable place, if the
+// given error was caused by an inaccessible directory. Otherwise the error
+// concerns only a single zettel and is returned unchanged.
+func (dp *dirPlace) checkAvailable(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errDir := dp.checkDirectory(); errDir != nil {
+		return errDir
+	}
+	return err
 }
 
 func (dp *dirPlace) cleanupMeta(ctx context.Context, m *meta.Meta) {
@@ -389,11 +642,13 @@ func (dp *dirPlace) cleanupMeta(ctx context.Context, m *meta.Meta) {
 	}
 }
 
-func renamePath(path string, curID, newID id.Zid) string {
-	dir, file := filepath.Split(path)
+// renamePath calculates the path of a file of a renamed zettel. The file will
+// be placed into the given directory.
+func renamePath(path string, curID, newID id.Zid, newDir string) string {
+	_, file := filepath.Split(path)
 	if cur := curID.String(); strings.HasPrefix(file, cur) {
 		file = newID.String() + file[len(cur):]
-		return filepath.Join(dir, file)
+		return filepath.Join(newDir, file)
 	}
 	return path
 }
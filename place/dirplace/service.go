@@ -12,8 +12,11 @@
 package dirplace
 
 import (
+	"errors"
+	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 
 	"zettelstore.de/z/domain"
 	"zettelstore.de/z/domain/id"
@@ -41,7 +44,7 @@ func getMeta(dp *dirPlace, entry *directory.Entry, zid id.Zid) (*meta.Meta, erro
 	dp.getFileChan(zid) <- &fileGetMetaContent{entry, rc}
 	res := <-rc
 	close(rc)
-	return res.meta, res.err
+	return res.meta, dp.countError(res.err)
 }
 
 type fileGetMeta struct {
@@ -79,7 +82,7 @@ func getMetaContent(dp *dirPlace, entry *directory.Entry, zid id.Zid) (*meta.Met
 	dp.getFileChan(zid) <- &fileGetMetaContent{entry, rc}
 	res := <-rc
 	close(rc)
-	return res.meta, res.content, res.err
+	return res.meta, res.content, dp.countError(res.err)
 }
 
 type fileGetMetaContent struct {
@@ -119,16 +122,25 @@ func (cmd *fileGetMetaContent) run() {
 
 func setZettel(dp *dirPlace, entry *directory.Entry, zettel domain.Zettel) error {
 	rc := make(chan resSetZettel)
-	dp.getFileChan(zettel.Meta.Zid) <- &fileSetZettel{entry, zettel, rc}
+	var blobDir string
+	if dp.dedup {
+		blobDir = dp.blobDir()
+	}
+	dp.getFileChan(zettel.Meta.Zid) <- &fileSetZettel{entry, zettel, blobDir, rc}
 	err := <-rc
 	close(rc)
-	return err
+	if err == nil && dp.isDeduplicated(entry) {
+		entry.SetContentHash(hashContent(zettel.Content.AsString()))
+		dp.dirSrv.SetContentHash(entry)
+	}
+	return dp.countError(err)
 }
 
 type fileSetZettel struct {
-	entry  *directory.Entry
-	zettel domain.Zettel
-	rc     chan<- resSetZettel
+	entry   *directory.Entry
+	zettel  domain.Zettel
+	blobDir string // if not empty: store content deduplicated
+	rc      chan<- resSetZettel
 }
 type resSetZettel = error
 
@@ -148,13 +160,20 @@ func (cmd *fileSetZettel) run() {
 				}
 
 				if err == nil {
-					err = writeFileContent(cmd.entry.ContentPath, cmd.zettel.Content.AsString())
+					err = writeContent(cmd.blobDir, cmd.entry.ContentPath, cmd.zettel.Content.AsString())
 				}
 			}
 		}
 
 	case directory.MetaSpecHeader:
-		f, err = openFileWrite(cmd.entry.ContentPath)
+		if cmd.blobDir != "" {
+			// The content file might be a link to a blob, if the meta data was
+			// stored in a separate file before.
+			err = breakLink(cmd.entry.ContentPath)
+		}
+		if err == nil {
+			f, err = openFileWrite(cmd.entry.ContentPath)
+		}
 		if err == nil {
 			err = writeFileZid(f, cmd.zettel.Meta.Zid)
 			if err == nil {
@@ -172,7 +191,7 @@ func (cmd *fileSetZettel) run() {
 		// TODO: if meta has some additional infos: write meta to new .meta;
 		// update entry in dir
 
-		err = writeFileContent(cmd.entry.ContentPath, cmd.zettel.Content.AsString())
+		err = writeContent(cmd.blobDir, cmd.entry.ContentPath, cmd.zettel.Content.AsString())
 
 	case directory.MetaSpecUnknown:
 		panic("TODO: ???")
@@ -180,6 +199,99 @@ func (cmd *fileSetZettel) run() {
 	cmd.rc <- err
 }
 
+// writeZettelFrom writes a new zettel, whose content is read from the given
+// reader. It returns the hash value of the content, if it was deduplicated.
+func writeZettelFrom(entry *directory.Entry, m *meta.Meta, r io.Reader, blobDir string) (string, error) {
+	switch entry.MetaSpec {
+	case directory.MetaSpecFile:
+		f, err := openFileWrite(entry.MetaPath)
+		if err != nil {
+			return "", err
+		}
+		err = writeFileZid(f, m.Zid)
+		if err == nil {
+			_, err = m.Write(f, true)
+		}
+		if err1 := f.Close(); err == nil {
+			err = err1
+		}
+		if err != nil {
+			return "", err
+		}
+		return writeContentFrom(blobDir, entry.ContentPath, r)
+
+	case directory.MetaSpecHeader:
+		f, err := openFileWrite(entry.ContentPath)
+		if err != nil {
+			return "", err
+		}
+		err = writeFileZid(f, m.Zid)
+		if err == nil {
+			_, err = m.WriteAsHeader(f, true)
+			if err == nil {
+				_, err = io.Copy(f, r)
+			}
+		}
+		if err1 := f.Close(); err == nil {
+			err = err1
+		}
+		return "", err
+
+	case directory.MetaSpecNone:
+		return writeContentFrom(blobDir, entry.ContentPath, r)
+	}
+	return "", errUnknownMetaSpec
+}
+
+// errUnknownMetaSpec is returned, if it is not known how the meta data of a
+// zettel must be written.
+var errUnknownMetaSpec = errors.New("unknown specification of meta data")
+
+// removeEntryFiles removes the files of a zettel that could not be written
+// completely.
+func removeEntryFiles(entry *directory.Entry) {
+	if entry.MetaPath != "" {
+		os.Remove(entry.MetaPath)
+	}
+	if entry.ContentPath != "" {
+		os.Remove(entry.ContentPath)
+	}
+}
+
+// COMMAND: setMeta ----------------------------------------
+//
+// Writes just the meta data of an existing zettel into its meta file. The
+// content file is not touched.
+
+func setMeta(dp *dirPlace, entry *directory.Entry, m *meta.Meta) error {
+	rc := make(chan resSetMeta)
+	dp.getFileChan(m.Zid) <- &fileSetMeta{entry, m, rc}
+	err := <-rc
+	close(rc)
+	return dp.countError(err)
+}
+
+type fileSetMeta struct {
+	entry *directory.Entry
+	meta  *meta.Meta
+	rc    chan<- resSetMeta
+}
+type resSetMeta = error
+
+func (cmd *fileSetMeta) run() {
+	f, err := openFileWrite(cmd.entry.MetaPath)
+	if err == nil {
+		err = writeFileZid(f, cmd.meta.Zid)
+		if err == nil {
+			_, err = cmd.meta.Write(f, true)
+		}
+		if err1 := f.Close(); err == nil {
+			err = err1
+		}
+	}
+	cmd.rc <- err
+}
+
 // COMMAND: deleteZettel ----------------------------------------
 //
 // Deletes an existing zettel.
@@ -189,7 +301,7 @@ func deleteZettel(dp *dirPlace, entry *directory.Entry, zid id.Zid) error {
 	dp.getFileChan(zid) <- &fileDeleteZettel{entry, rc}
 	err := <-rc
 	close(rc)
-	return err
+	return dp.countError(err)
 }
 
 type fileDeleteZettel struct {
@@ -270,6 +382,10 @@ func cleanupMeta(m *meta.Meta, entry *directory.Entry) {
 }
 
 func openFileWrite(path string) (*os.File, error) {
+	// The directory might be a shard sub-directory, which is not created yet.
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
 	return os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
 }
 
@@ -284,6 +400,17 @@ func writeFileZid(f *os.File, zid id.Zid) error {
 	return err
 }
 
+func writeFileFrom(path string, r io.Reader) error {
+	f, err := openFileWrite(path)
+	if err == nil {
+		_, err = io.Copy(f, r)
+		if err1 := f.Close(); err == nil {
+			err = err1
+		}
+	}
+	return err
+}
+
 func writeFileContent(path string, content string) error {
 	f, err := openFileWrite(path)
 	if err == nil {
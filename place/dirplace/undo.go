@@ -0,0 +1,125 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package dirplace provides a directory-based zettel place.
+package dirplace
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/place"
+	"zettelstore.de/z/place/dirplace/directory"
+)
+
+// undoDirName is the name of the sub-directory that stores the previous
+// version of each updated zettel. There is only one previous version per
+// zettel, it is overwritten by the next update.
+const undoDirName = ".undo"
+
+func (dp *dirPlace) undoPaths(zid id.Zid) (metaPath, contentPath string) {
+	base := filepath.Join(dp.dir, undoDirName, zid.String())
+	return base + ".meta", base + ".content"
+}
+
+// saveUndo stores the current version of a zettel as its previous version.
+func (dp *dirPlace) saveUndo(entry *directory.Entry) error {
+	m, content, err := getMetaContent(dp, entry, entry.Zid)
+	if err != nil {
+		return err
+	}
+	metaPath, contentPath := dp.undoPaths(entry.Zid)
+	f, err := openFileWrite(metaPath)
+	if err != nil {
+		return err
+	}
+	err = writeFileZid(f, entry.Zid)
+	if err == nil {
+		_, err = m.Write(f, true)
+	}
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err == nil {
+		err = writeFileContent(contentPath, content)
+	}
+	return err
+}
+
+// loadUndoMeta reads the metadata of the previous version of a zettel.
+func (dp *dirPlace) loadUndoMeta(zid id.Zid) (*meta.Meta, error) {
+	metaPath, _ := dp.undoPaths(zid)
+	m, err := parseMetaFile(zid, metaPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, place.ErrNotFound
+		}
+		return nil, err
+	}
+	return m, nil
+}
+
+// loadUndo reads the previous version of a zettel.
+func (dp *dirPlace) loadUndo(zid id.Zid) (domain.Zettel, error) {
+	m, err := dp.loadUndoMeta(zid)
+	if err != nil {
+		return domain.Zettel{}, err
+	}
+	_, contentPath := dp.undoPaths(zid)
+	content, err := readFileContent(contentPath)
+	if err != nil {
+		return domain.Zettel{}, err
+	}
+	return domain.Zettel{Meta: m, Content: domain.NewContent(content)}, nil
+}
+
+// removeUndo removes the previous version of a zettel.
+func (dp *dirPlace) removeUndo(zid id.Zid) {
+	metaPath, contentPath := dp.undoPaths(zid)
+	os.Remove(metaPath)
+	os.Remove(contentPath)
+}
+
+func (dp *dirPlace) CanUndoZettel(ctx context.Context, zid id.Zid) bool {
+	if dp.readonly {
+		return false
+	}
+	if entry := dp.dirSrv.GetEntry(zid); !entry.IsValid() {
+		return false
+	}
+	metaPath, _ := dp.undoPaths(zid)
+	_, err := os.Stat(metaPath)
+	return err == nil
+}
+
+func (dp *dirPlace) GetUndoMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error) {
+	if entry := dp.dirSrv.GetEntry(zid); !entry.IsValid() {
+		return nil, place.ErrNotFound
+	}
+	return dp.loadUndoMeta(zid)
+}
+
+func (dp *dirPlace) UndoZettel(ctx context.Context, zid id.Zid) error {
+	if dp.readonly {
+		return place.ErrReadOnly
+	}
+	if entry := dp.dirSrv.GetEntry(zid); !entry.IsValid() {
+		return place.ErrNotFound
+	}
+	zettel, err := dp.loadUndo(zid)
+	if err != nil {
+		return err
+	}
+	return dp.UpdateZettel(ctx, zettel)
+}
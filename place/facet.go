@@ -0,0 +1,62 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package place provides a generic interface to zettel places.
+package place
+
+import (
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+)
+
+// FacetYear is a pseudo metadata key that aggregates zettel by the year of
+// their creation, as given by their identifier.
+const FacetYear = "_year"
+
+// Facets maps a metadata key to the number of zettel per value.
+type Facets map[string]map[string]int
+
+// CountFacets aggregates the given list of meta data by the values of the
+// given keys. Set-valued keys count each of their values.
+func CountFacets(metaList []*meta.Meta, keys []string) Facets {
+	result := make(Facets, len(keys))
+	for _, key := range keys {
+		counts := make(map[string]int)
+		for _, m := range metaList {
+			for _, val := range facetValues(m, key) {
+				counts[val]++
+			}
+		}
+		result[key] = counts
+	}
+	return result
+}
+
+// minYearZid is the smallest identifier that encodes a year. Smaller
+// identifiers are used for predefined zettel.
+const minYearZid = id.Zid(10000101000000)
+
+func facetValues(m *meta.Meta, key string) []string {
+	if key == FacetYear {
+		if m.Zid < minYearZid {
+			return nil
+		}
+		return []string{m.Zid.String()[:4]}
+	}
+	value, ok := m.Get(key)
+	if !ok {
+		return nil
+	}
+	switch meta.KeyType(key) {
+	case meta.TypeTagSet, meta.TypeWordSet, meta.TypeIDSet:
+		return meta.ListFromValue(value)
+	}
+	return []string{value}
+}
@@ -14,6 +14,7 @@ package place
 import (
 	"strings"
 
+	"zettelstore.de/z/domain/id"
 	"zettelstore.de/z/domain/meta"
 )
 
@@ -37,8 +38,9 @@ func matchAlways(value string) bool { return true }
 func matchNever(value string) bool  { return false }
 
 type matchSpec struct {
-	key   string
-	match matchFunc
+	key          string
+	match        matchFunc
+	matchMissing bool // result if the meta data does not contain the key
 }
 
 // CreateFilterFunc calculates a filter func based on the given filter.
@@ -56,10 +58,8 @@ func CreateFilterFunc(filter *Filter) FilterFunc {
 			continue
 		}
 		if meta.KeyIsValid(key) {
-			match := createMatchFunc(key, values)
-			if match != nil {
-				specs = append(specs, matchSpec{key, match})
-			}
+			match, matchMissing := createKeyMatchFunc(key, values)
+			specs = append(specs, matchSpec{key, match, matchMissing})
 		}
 	}
 	if len(specs) == 0 {
@@ -74,8 +74,11 @@ func CreateFilterFunc(filter *Filter) FilterFunc {
 	negate := filter.Negate
 	searchMeta := func(m *meta.Meta) bool {
 		for _, s := range specs {
-			value, ok := m.Get(s.key)
-			if !ok || !s.match(value) {
+			if value, ok := m.Get(s.key); ok {
+				if !s.match(value) {
+					return negate
+				}
+			} else if !s.matchMissing {
 				return negate
 			}
 		}
@@ -101,6 +104,97 @@ func addSelectFunc(filter *Filter, f FilterFunc) FilterFunc {
 	return f
 }
 
+// Syntax of filter values for a specific key.
+const (
+	filterAltSep = '|'  // separates alternative terms of a value
+	filterNot    = '!'  // prefix of a term that must not match
+	filterEscape = '\\' // the next character is taken literally
+)
+
+// createKeyMatchFunc creates a matchFunc for a specific key. All values must
+// match. A value may consist of alternative terms, separated by "|", where at
+// least one term must match. A term with a leading "!" matches if the term
+// without the "!" does not match. A "\" escapes the following character, so
+// that "\|", "\!", and "\\" stand for themselves. The second result states
+// whether meta data without the key is selected: this is true if every value
+// contains a negated term.
+func createKeyMatchFunc(key string, values []string) (matchFunc, bool) {
+	conj := make([][]matchFunc, 0, len(values))
+	matchMissing := true
+	for _, val := range values {
+		terms := splitFilterValue(val)
+		disj := make([]matchFunc, 0, len(terms))
+		hasNegated := false
+		for _, term := range terms {
+			match := createMatchFunc(key, []string{term.text})
+			if term.negated {
+				hasNegated = true
+				match = negateMatchFunc(match)
+			}
+			disj = append(disj, match)
+		}
+		conj = append(conj, disj)
+		matchMissing = matchMissing && hasNegated
+	}
+	return func(value string) bool {
+		for _, disj := range conj {
+			if !matchAny(disj, value) {
+				return false
+			}
+		}
+		return true
+	}, matchMissing
+}
+
+type filterTerm struct {
+	text    string
+	negated bool
+}
+
+// splitFilterValue splits a value into its alternative terms and removes the
+// escape characters. A trailing escape character is taken literally.
+func splitFilterValue(val string) []filterTerm {
+	var result []filterTerm
+	var sb strings.Builder
+	term := filterTerm{}
+	atStart := true
+	for i := 0; i < len(val); i++ {
+		ch := val[i]
+		switch {
+		case ch == filterEscape && i+1 < len(val):
+			i++
+			sb.WriteByte(val[i])
+		case ch == filterAltSep:
+			term.text = sb.String()
+			result = append(result, term)
+			sb.Reset()
+			term = filterTerm{}
+			atStart = true
+			continue
+		case ch == filterNot && atStart:
+			term.negated = true
+		default:
+			sb.WriteByte(ch)
+		}
+		atStart = false
+	}
+	term.text = sb.String()
+	return append(result, term)
+}
+
+func negateMatchFunc(match matchFunc) matchFunc {
+	return func(value string) bool { return !match(value) }
+}
+
+func matchAny(matches []matchFunc, value string) bool {
+	for _, match := range matches {
+		if match(value) {
+			return true
+		}
+	}
+	return false
+}
+
 func createMatchFunc(key string, values []string) matchFunc {
 	switch meta.KeyType(key) {
 	case meta.TypeBool:
@@ -122,7 +216,11 @@ func createMatchFunc(key string, values []string) matchFunc {
 	case meta.TypeID, meta.TypeTimestamp: // ID and timestamp use the same layout
 		return func(value string) bool {
 			for _, v := range values {
-				if !strings.HasPrefix(value, v) {
+				if op, operand, ok := parseCompareValue(v); ok {
+					if !compareValue(value, op, operand) {
+						return false
+					}
+				} else if !strings.HasPrefix(value, v) {
 					return false
 				}
 			}
@@ -179,6 +277,69 @@ func createMatchFunc(key string, values []string) matchFunc {
 	}
 }
 
+// Comparison operators for identifier and timestamp values.
+var compareOps = []string{">=", "<=", ">", "<"}
+
+// compareDigits is the number of digits of an identifier and a timestamp.
+const compareDigits = 14
+
+// parseCompareValue splits a value like ">=2020-06" into the operator and a
+// prefix of the identifier / timestamp layout, e.g. "202006".
+func parseCompareValue(v string) (string, string, bool) {
+	for _, op := range compareOps {
+		if strings.HasPrefix(v, op) {
+			operand := make([]byte, 0, compareDigits)
+			for i := len(op); i < len(v) && len(operand) < compareDigits; i++ {
+				if ch := v[i]; '0' <= ch && ch <= '9' {
+					operand = append(operand, ch)
+				}
+			}
+			return op, string(operand), len(operand) > 0
+		}
+	}
+	return "", "", false
+}
+
+// compareValue compares the prefix of the given value with the operand. The
+// prefix has the same length as the operand, so that e.g. "<2020" selects all
+// values before the year 2020.
+func compareValue(value, op, operand string) bool {
+	if len(value) > len(operand) {
+		value = value[:len(operand)]
+	}
+	switch op {
+	case ">=":
+		return value >= operand
+	case "<=":
+		return value <= operand
+	case ">":
+		return value > operand
+	case "<":
+		return value < operand
+	}
+	return false
+}
+
+// CreateZidFilterFunc returns a predicate that checks only the zettel
+// identifier against the filter. If the predicate returns false, the zettel
+// will not be selected by the filter, so that its meta data need not be
+// read. If the filter cannot be checked by the identifier only, nil is
+// returned.
+func CreateZidFilterFunc(filter *Filter) func(id.Zid) bool {
+	if filter == nil || filter.Negate {
+		return nil
+	}
+	if _, ok := filter.Expr[""]; ok {
+		return nil
+	}
+	values, ok := filter.Expr[meta.KeyID]
+	if !ok {
+		return nil
+	}
+	match, _ := createKeyMatchFunc(meta.KeyID, values)
+	return func(zid id.Zid) bool { return match(zid.String()) }
+}
+
 func createSearchAllFunc(values []string, negate bool) FilterFunc {
 	matchFuncs := map[*meta.DescriptionType]matchFunc{}
 	return func(m *meta.Meta) bool {
@@ -0,0 +1,112 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package place provides a generic interface to zettel places.
+package place
+
+import (
+	"testing"
+
+	"zettelstore.de/z/domain/meta"
+)
+
+func newTestMeta(tags, title string) *meta.Meta {
+	m := meta.New(20210101000001)
+	if tags != "" {
+		m.Set(meta.KeyTags, tags)
+	}
+	if title != "" {
+		m.Set(meta.KeyTitle, title)
+	}
+	return m
+}
+
+func TestKeyFilter(t *testing.T) {
+	testcases := []struct {
+		key    string
+		values []string
+		tags   string
+		title  string
+		exp    bool
+	}{
+		// Repeated values of a key must all match.
+		{meta.KeyTags, []string{"#a", "#b"}, "#a #b", "", true},
+		{meta.KeyTags, []string{"#a", "#b"}, "#a", "", false},
+		{meta.KeyTags, []string{"#a", "#b"}, "#b #c", "", false},
+
+		// One of the alternatives must match.
+		{meta.KeyTags, []string{"#a|#b"}, "#b", "", true},
+		{meta.KeyTags, []string{"#a|#b"}, "#c", "", false},
+		{meta.KeyTags, []string{"#a|#b", "#c"}, "#b #c", "", true},
+		{meta.KeyTags, []string{"#a|#b", "#c"}, "#b", "", false},
+
+		// A leading "!" negates an alternative.
+		{meta.KeyTags, []string{"!#draft"}, "#a", "", true},
+		{meta.KeyTags, []string{"!#draft"}, "#a #draft", "", false},
+		{meta.KeyTags, []string{"#a|!#draft"}, "#a #draft", "", true},
+		{meta.KeyTags, []string{"#a", "!#draft"}, "#a #draft", "", false},
+
+		// Escaped characters are taken literally.
+		{meta.KeyTitle, []string{`a\|b`}, "", "x a|b y", true},
+		{meta.KeyTitle, []string{`a\|b`}, "", "a", false},
+		{meta.KeyTitle, []string{`\!a`}, "", "say !a", true},
+		{meta.KeyTitle, []string{`\!a`}, "", "a", false},
+		{meta.KeyTitle, []string{`a\\|b`}, "", `a\`, true},
+		{meta.KeyTitle, []string{`a\\|b`}, "", "b", true},
+		{meta.KeyTitle, []string{`a\\|b`}, "", "a", false},
+		{meta.KeyTitle, []string{`a!`}, "", "a!", true},
+		{meta.KeyTitle, []string{`a\`}, "", `a\`, true},
+
+		// Without the key, only values that contain a negated term match.
+		{meta.KeyTags, []string{"#a"}, "", "x", false},
+		{meta.KeyTags, []string{"!#a"}, "", "x", true},
+		{meta.KeyTags, []string{"#b|!#a"}, "", "x", true},
+		{meta.KeyTags, []string{"!#a", "#b"}, "", "x", false},
+		{meta.KeyTags, []string{"!#a", "!#b"}, "", "x", true},
+		{meta.KeyTags, []string{`\!#a`}, "", "x", false},
+	}
+	for i, tc := range testcases {
+		filter := &Filter{Expr: FilterExpr{tc.key: tc.values}}
+		got := CreateFilterFunc(filter)(newTestMeta(tc.tags, tc.title))
+		if got != tc.exp {
+			t.Errorf("%d: %v=%q on tags=%q title=%q: expected %v, but got %v",
+				i, tc.key, tc.values, tc.tags, tc.title, tc.exp, got)
+		}
+	}
+}
+
+func TestSplitFilterValue(t *testing.T) {
+	testcases := []struct {
+		val string
+		exp []filterTerm
+	}{
+		{"", []filterTerm{{"", false}}},
+		{"a|!b", []filterTerm{{"a", false}, {"b", true}}},
+		{"!a!", []filterTerm{{"a!", true}}},
+		{`a\|b`, []filterTerm{{"a|b", false}}},
+		{`\!a|\\`, []filterTerm{{"!a", false}, {`\`, false}}},
+		{`!\!a`, []filterTerm{{"!a", true}}},
+		{`a\`, []filterTerm{{`a\`, false}}},
+		{"|", []filterTerm{{"", false}, {"", false}}},
+	}
+	for i, tc := range testcases {
+		got := splitFilterValue(tc.val)
+		if len(got) != len(tc.exp) {
+			t.Errorf("%d: %q: expected %v, but got %v", i, tc.val, tc.exp, got)
+			continue
+		}
+		for j, term := range got {
+			if term != tc.exp[j] {
+				t.Errorf("%d: %q: expected %v, but got %v", i, tc.val, tc.exp, got)
+				break
+			}
+		}
+	}
+}
@@ -11,7 +11,11 @@
 // Package manager coordinates the various places of a Zettelstore.
 package manager
 
-import "zettelstore.de/z/domain/meta"
+import (
+	"sync"
+
+	"zettelstore.de/z/domain/meta"
+)
 
 // MetaFilter is used by places to filter and set computed metadata value.
 type MetaFilter interface {
@@ -26,9 +30,12 @@ type MetaFilter interface {
 
 type metaFilter struct {
 	properties map[string]bool // Set of property key names
+
+	mx    sync.RWMutex // protects funcs
+	funcs []func(*meta.Meta)
 }
 
-func newFilter() MetaFilter {
+func newFilter() *metaFilter {
 	properties := make(map[string]bool)
 	for _, kd := range meta.GetSortedKeyDescriptions() {
 		if kd.IsProperty() {
@@ -42,6 +49,18 @@ func newFilter() MetaFilter {
 
 func (mf *metaFilter) UpdateProperties(m *meta.Meta) {
 	computePublished(m)
+	mf.mx.RLock()
+	funcs := mf.funcs
+	mf.mx.RUnlock()
+	for _, f := range funcs {
+		f(m)
+	}
+}
+
+func (mf *metaFilter) addProperty(f func(*meta.Meta)) {
+	mf.mx.Lock()
+	mf.funcs = append(mf.funcs, f)
+	mf.mx.Unlock()
 }
 
 func computePublished(m *meta.Meta) {
@@ -0,0 +1,83 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+package manager
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"zettelstore.de/z/place"
+)
+
+// retryTime is the time between two attempts to use a degraded place again.
+const retryTime = 30 * time.Second
+
+// isFailure returns true, if the error signals that the storage of a place
+// does not work as a whole. Errors that concern a single zettel, or that are
+// caused by the request, e.g. a canceled context, do not count as failures.
+func isFailure(err error) bool {
+	_, ok := err.(*place.ErrUnavailable)
+	return ok
+}
+
+// isDegraded returns true, if the sub-place with the given index is
+// currently skipped for read operations.
+func (mgr *Manager) isDegraded(i int) bool {
+	return atomic.LoadInt32(&mgr.degraded[i]) != 0
+}
+
+// IsDegraded returns true, if at least one sub-place is currently skipped for
+// read operations.
+func (mgr *Manager) IsDegraded() bool {
+	for i := range mgr.subplaces {
+		if mgr.isDegraded(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFailure marks the sub-place with the given index as degraded, if err
+// signals a failure. It returns true in this case.
+func (mgr *Manager) checkFailure(i int, err error) bool {
+	if !isFailure(err) {
+		return false
+	}
+	if atomic.CompareAndSwapInt32(&mgr.degraded[i], 0, 1) {
+		log.Println("PLACE", "DEGRADED", mgr.subplaces[i].Location(), err)
+	}
+	return true
+}
+
+// retryDegraded periodically checks all degraded places, whether they work
+// again.
+func (mgr *Manager) retryDegraded(done <-chan struct{}) {
+	ticker := time.NewTicker(retryTime)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			for i, p := range mgr.subplaces {
+				if !mgr.isDegraded(i) {
+					continue
+				}
+				if _, err := p.SelectMeta(context.Background(), nil, nil); !isFailure(err) {
+					atomic.StoreInt32(&mgr.degraded[i], 0)
+					log.Println("PLACE", "RECOVERED", p.Location())
+				}
+			}
+		}
+	}
+}
@@ -13,6 +13,7 @@ package manager
 
 import (
 	"context"
+	"io"
 	"log"
 	"net/url"
 	"sort"
@@ -84,19 +85,29 @@ type Manager struct {
 	started   bool
 	placeURIs []url.URL
 	subplaces []place.Place
-	filter    MetaFilter
+	routes    [][]routeRule
+	degraded  []int32 // 1, if sub-place is degraded; accessed atomically
+	done      chan struct{}
+	filter    *metaFilter
+	zids      *id.Allocator
 }
 
 // New creates a new managing place.
 func New(placeURIs []string, readonlyMode bool) (*Manager, error) {
 	filter := newFilter()
 	subplaces := make([]place.Place, 0, len(placeURIs)+2)
+	routes := make([][]routeRule, 0, len(placeURIs))
 	for _, uri := range placeURIs {
 		p, err := Connect(uri, readonlyMode, filter)
 		if err != nil {
 			return nil, err
 		}
+		rules, err := getRouteRules(uri)
+		if err != nil {
+			return nil, err
+		}
 		subplaces = append(subplaces, p)
+		routes = append(routes, rules)
 	}
 	constplace, err := registry[" const"](nil, filter)
 	if err != nil {
@@ -109,7 +120,10 @@ func New(placeURIs []string, readonlyMode bool) (*Manager, error) {
 	subplaces = append(subplaces, constplace, progplace)
 	result := &Manager{
 		subplaces: subplaces,
+		routes:    routes,
+		degraded:  make([]int32, len(subplaces)),
 		filter:    filter,
+		zids:      id.NewAllocator(),
 	}
 	return result, nil
 }
@@ -143,6 +157,8 @@ func (mgr *Manager) Start(ctx context.Context) error {
 			return err
 		}
 	}
+	mgr.done = make(chan struct{})
+	go mgr.retryDegraded(mgr.done)
 	mgr.started = true
 	return nil
 }
@@ -152,6 +168,8 @@ func (mgr *Manager) Stop(ctx context.Context) error {
 	if !mgr.started {
 		return place.ErrStopped
 	}
+	close(mgr.done)
+	mgr.done = nil
 	var err error
 	for _, p := range mgr.subplaces {
 		if err1 := p.Stop(ctx); err1 != nil && err == nil {
@@ -172,15 +190,69 @@ func (mgr *Manager) RegisterChangeObserver(f place.ObserverFunc) {
 
 // CanCreateZettel returns true, if place could possibly create a new zettel.
 func (mgr *Manager) CanCreateZettel(ctx context.Context) bool {
-	return mgr.started && mgr.subplaces[0].CanCreateZettel(ctx)
+	if !mgr.started {
+		return false
+	}
+	defPlace := mgr.routePlace(nil)
+	for i, p := range mgr.subplaces[:len(mgr.routes)] {
+		if (i == defPlace || len(mgr.routes[i]) > 0) && p.CanCreateZettel(ctx) {
+			return true
+		}
+	}
+	return false
 }
 
-// CreateZettel creates a new zettel.
+// CreateZettel creates a new zettel in the place selected by the routing rules.
 func (mgr *Manager) CreateZettel(ctx context.Context, zettel domain.Zettel) (id.Zid, error) {
 	if !mgr.started {
 		return id.Invalid, place.ErrStopped
 	}
-	return mgr.subplaces[0].CreateZettel(ctx, zettel)
+	pos := mgr.routePlace(zettel.Meta)
+	zid, err := mgr.subplaces[pos].CreateZettel(ctx, zettel)
+	return mgr.uniqueZid(ctx, pos, zid, err)
+}
+
+// CreateZettelFrom creates a new zettel in the place selected by the routing
+// rules, whose content is read from the given reader.
+func (mgr *Manager) CreateZettelFrom(ctx context.Context, m *meta.Meta, r io.Reader) (id.Zid, error) {
+	if !mgr.started {
+		return id.Invalid, place.ErrStopped
+	}
+	pos := mgr.routePlace(m)
+	zid, err := mgr.subplaces[pos].CreateZettelFrom(ctx, m, r)
+	return mgr.uniqueZid(ctx, pos, zid, err)
+}
+
+// uniqueZid renames a newly created zettel of the place with the given index,
+// if its identifier is already used by another place of the chain. Each place
+// generates its own identifier.
+func (mgr *Manager) uniqueZid(ctx context.Context, pos int, zid id.Zid, err error) (id.Zid, error) {
+	if err != nil || !mgr.zidUsedElsewhere(ctx, zid, pos) {
+		return zid, err
+	}
+	p := mgr.subplaces[pos]
+	newZid := mgr.zids.New(true, func(zid id.Zid) bool {
+		_, err := p.GetMeta(ctx, zid)
+		return err == nil || mgr.zidUsedElsewhere(ctx, zid, pos)
+	})
+	if err := p.RenameZettel(ctx, zid, newZid); err != nil {
+		return zid, err
+	}
+	return newZid, nil
+}
+
+// zidUsedElsewhere returns true, if a place other than the one with the given
+// index stores a zettel with the given identifier. A negative index checks
+// all places.
+func (mgr *Manager) zidUsedElsewhere(ctx context.Context, zid id.Zid, pos int) bool {
+	for i, p := range mgr.subplaces {
+		if i != pos {
+			if _, err := p.GetMeta(ctx, zid); err == nil {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // GetZettel retrieves a specific zettel.
@@ -188,9 +260,14 @@ func (mgr *Manager) GetZettel(ctx context.Context, zid id.Zid) (domain.Zettel, e
 	if !mgr.started {
 		return domain.Zettel{}, place.ErrStopped
 	}
-	for _, p := range mgr.subplaces {
-		if z, err := p.GetZettel(ctx, zid); err != place.ErrNotFound {
-			mgr.filter.UpdateProperties(z.Meta)
+	for i, p := range mgr.subplaces {
+		if mgr.isDegraded(i) {
+			continue
+		}
+		if z, err := p.GetZettel(ctx, zid); err != place.ErrNotFound && !mgr.checkFailure(i, err) {
+			if err == nil {
+				mgr.filter.UpdateProperties(z.Meta)
+			}
 			return z, err
 		}
 	}
@@ -202,15 +279,36 @@ func (mgr *Manager) GetMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error)
 	if !mgr.started {
 		return nil, place.ErrStopped
 	}
-	for _, p := range mgr.subplaces {
-		if m, err := p.GetMeta(ctx, zid); err != place.ErrNotFound {
-			mgr.filter.UpdateProperties(m)
+	for i, p := range mgr.subplaces {
+		if mgr.isDegraded(i) {
+			continue
+		}
+		if m, err := p.GetMeta(ctx, zid); err != place.ErrNotFound && !mgr.checkFailure(i, err) {
+			if err == nil {
+				mgr.filter.UpdateProperties(m)
+			}
 			return m, err
 		}
 	}
 	return nil, place.ErrNotFound
 }
 
+// OpenContent returns a reader for the content of a specific zettel.
+func (mgr *Manager) OpenContent(ctx context.Context, zid id.Zid) (io.ReadCloser, error) {
+	if !mgr.started {
+		return nil, place.ErrStopped
+	}
+	for i, p := range mgr.subplaces {
+		if mgr.isDegraded(i) {
+			continue
+		}
+		if rc, err := p.OpenContent(ctx, zid); err != place.ErrNotFound && !mgr.checkFailure(i, err) {
+			return rc, err
+		}
+	}
+	return nil, place.ErrNotFound
+}
+
 // SelectMeta returns all zettel meta data that match the selection
 // criteria. The result is ordered by descending zettel id.
 func (mgr *Manager) SelectMeta(ctx context.Context, f *place.Filter, s *place.Sorter) ([]*meta.Meta, error) {
@@ -218,8 +316,14 @@ func (mgr *Manager) SelectMeta(ctx context.Context, f *place.Filter, s *place.So
 		return nil, place.ErrStopped
 	}
 	var result []*meta.Meta
-	for _, p := range mgr.subplaces {
+	for i, p := range mgr.subplaces {
+		if mgr.isDegraded(i) {
+			continue
+		}
 		selected, err := p.SelectMeta(ctx, f, nil)
+		if mgr.checkFailure(i, err) {
+			continue
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -237,7 +341,7 @@ func (mgr *Manager) SelectMeta(ctx context.Context, f *place.Filter, s *place.So
 
 // CanUpdateZettel returns true, if place could possibly update the given zettel.
 func (mgr *Manager) CanUpdateZettel(ctx context.Context, zettel domain.Zettel) bool {
-	return mgr.started && mgr.subplaces[0].CanUpdateZettel(ctx, zettel)
+	return mgr.started && mgr.subplaces[mgr.updatePlace(ctx, zettel)].CanUpdateZettel(ctx, zettel)
 }
 
 // UpdateZettel updates an existing zettel.
@@ -247,7 +351,43 @@ func (mgr *Manager) UpdateZettel(ctx context.Context, zettel domain.Zettel) erro
 	}
 	zettel.Meta = zettel.Meta.Clone()
 	mgr.filter.RemoveProperties(zettel.Meta)
-	return mgr.subplaces[0].UpdateZettel(ctx, zettel)
+	return mgr.subplaces[mgr.updatePlace(ctx, zettel)].UpdateZettel(ctx, zettel)
+}
+
+// UpdateMeta updates just the meta data of an existing zettel. If the zettel
+// is stored in a place that cannot be changed, e.g. if it is a built-in
+// zettel, the whole zettel is stored in the place selected by the routing
+// rules.
+func (mgr *Manager) UpdateMeta(ctx context.Context, m *meta.Meta) error {
+	if !mgr.started {
+		return place.ErrStopped
+	}
+	m = m.Clone()
+	mgr.filter.RemoveProperties(m)
+	p := mgr.subplaces[mgr.updatePlace(ctx, domain.Zettel{Meta: m})]
+	if err := p.UpdateMeta(ctx, m); err != place.ErrNotFound {
+		return err
+	}
+	zettel, err := mgr.GetZettel(ctx, m.Zid)
+	if err != nil {
+		return err
+	}
+	return p.UpdateZettel(ctx, domain.Zettel{Meta: m, Content: zettel.Content})
+}
+
+// updatePlace returns the index of the place that should store an updated
+// zettel. A zettel already stored in one of the configured places stays
+// there, all other zettel are written according to the routing rules.
+func (mgr *Manager) updatePlace(ctx context.Context, zettel domain.Zettel) int {
+	for i, p := range mgr.subplaces[:len(mgr.routes)] {
+		if _, err := p.GetMeta(ctx, zettel.Meta.Zid); err == nil {
+			if p.CanUpdateZettel(ctx, zettel) {
+				return i
+			}
+			break
+		}
+	}
+	return mgr.routePlace(zettel.Meta)
 }
 
 // AllowRenameZettel returns true, if place will not disallow renaming the zettel.
@@ -264,13 +404,35 @@ func (mgr *Manager) AllowRenameZettel(ctx context.Context, zid id.Zid) bool {
 }
 
 // RenameZettel changes the current zid to a new zid.
+//
+// Every place of the chain that stores a zettel with the current zid renames
+// it, so that a zettel shadowed by another one stays shadowed. If one of them
+// refuses, e.g. because it is read-only, all previous renames are reverted.
+// The new zid must not be used by any place, including the constant and the
+// computed zettel. Otherwise the renamed zettel would shadow another zettel,
+// or it would be shadowed itself.
 func (mgr *Manager) RenameZettel(ctx context.Context, curZid, newZid id.Zid) error {
 	if !mgr.started {
 		return place.ErrStopped
 	}
+	var storing []int
 	for i, p := range mgr.subplaces {
-		if err := p.RenameZettel(ctx, curZid, newZid); err != nil && err != place.ErrNotFound {
-			for j := 0; j < i; j++ {
+		if _, err := p.GetMeta(ctx, curZid); err == nil {
+			storing = append(storing, i)
+		}
+	}
+	if len(storing) == 0 {
+		return place.ErrNotFound
+	}
+	if curZid == newZid {
+		return nil
+	}
+	if !newZid.IsValid() || mgr.zidUsedElsewhere(ctx, newZid, -1) {
+		return &place.ErrInvalidID{Zid: newZid}
+	}
+	for n, i := range storing {
+		if err := mgr.subplaces[i].RenameZettel(ctx, curZid, newZid); err != nil {
+			for _, j := range storing[:n] {
 				mgr.subplaces[j].RenameZettel(ctx, newZid, curZid)
 			}
 			return err
@@ -305,6 +467,54 @@ func (mgr *Manager) DeleteZettel(ctx context.Context, zid id.Zid) error {
 	return place.ErrNotFound
 }
 
+// CanUndoZettel returns true, if the place storing the zettel could restore
+// its previous version.
+func (mgr *Manager) CanUndoZettel(ctx context.Context, zid id.Zid) bool {
+	if !mgr.started {
+		return false
+	}
+	if p := mgr.storingPlace(ctx, zid); p != nil {
+		return p.CanUndoZettel(ctx, zid)
+	}
+	return false
+}
+
+// GetUndoMeta returns the metadata of the previous version of the given
+// zettel.
+func (mgr *Manager) GetUndoMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error) {
+	if !mgr.started {
+		return nil, place.ErrStopped
+	}
+	if p := mgr.storingPlace(ctx, zid); p != nil {
+		return p.GetUndoMeta(ctx, zid)
+	}
+	return nil, place.ErrNotFound
+}
+
+// UndoZettel restores the previous version of the given zettel.
+func (mgr *Manager) UndoZettel(ctx context.Context, zid id.Zid) error {
+	if !mgr.started {
+		return place.ErrStopped
+	}
+	if p := mgr.storingPlace(ctx, zid); p != nil {
+		return p.UndoZettel(ctx, zid)
+	}
+	return place.ErrNotFound
+}
+
+// storingPlace returns the first sub-place that stores the given zettel.
+func (mgr *Manager) storingPlace(ctx context.Context, zid id.Zid) place.Place {
+	for i, p := range mgr.subplaces {
+		if mgr.isDegraded(i) {
+			continue
+		}
+		if _, err := p.GetMeta(ctx, zid); err == nil {
+			return p
+		}
+	}
+	return nil
+}
+
 // Reload clears all caches, reloads all internal data to reflect changes
 // that were possibly undetected.
 func (mgr *Manager) Reload(ctx context.Context) error {
@@ -317,23 +527,79 @@ func (mgr *Manager) Reload(ctx context.Context) error {
 	return err
 }
 
-// ReadStats populates st with place statistics
-func (mgr *Manager) ReadStats(st *place.Stats) {
+// ReloadZettel clears all caches of the given zettel and reloads its
+// internal data to reflect changes that were possibly undetected.
+func (mgr *Manager) ReloadZettel(ctx context.Context, zid id.Zid) error {
+	if !mgr.started {
+		return place.ErrStopped
+	}
+	found := false
+	for _, p := range mgr.subplaces {
+		err := p.ReloadZettel(ctx, zid)
+		if err == nil {
+			found = true
+		} else if err != place.ErrNotFound {
+			return err
+		}
+	}
+	if !found {
+		return place.ErrNotFound
+	}
+	return nil
+}
+
+// Stats returns the statistics of all sub-places, aggregated along the chain.
+func (mgr *Manager) Stats(ctx context.Context) place.Stats {
 	subStats := make([]place.Stats, len(mgr.subplaces))
 	for i, p := range mgr.subplaces {
-		p.ReadStats(&subStats[i])
+		subStats[i] = p.Stats(ctx)
+		subStats[i].Degraded = mgr.isDegraded(i)
 	}
 
-	st.ReadOnly = true
-	sumZettel := 0
+	st := place.Stats{
+		ReadOnly: true,
+		Location: mgr.Location(),
+		Places:   subStats,
+	}
 	for _, sst := range subStats {
 		if !sst.ReadOnly {
 			st.ReadOnly = false
 		}
-		sumZettel += sst.Zettel
+		if sst.Scanning {
+			st.Scanning = true
+		}
+		if sst.Degraded {
+			st.Degraded = true
+		}
+		if sst.LastScan.After(st.LastScan) {
+			st.LastScan = sst.LastScan
+		}
+		st.Zettel += sst.Zettel
+		st.Errors += sst.Errors
 	}
-	st.Zettel = sumZettel
+	return st
 }
 
+// AddProperty registers a function that computes an additional property
+// of meta data, whenever meta data is read.
+func (mgr *Manager) AddProperty(f func(m *meta.Meta)) { mgr.filter.addProperty(f) }
+
 // NumPlaces returns the number of managed places.
 func (mgr *Manager) NumPlaces() int { return len(mgr.subplaces) }
+
+// Placements returns all places that store a zettel with the given
+// identifier, in the order of the chain. The constant and the computed zettel
+// are stored in the last two places.
+func (mgr *Manager) Placements(ctx context.Context, zid id.Zid) []place.Placement {
+	var result []place.Placement
+	for i, p := range mgr.subplaces {
+		if _, err := p.GetMeta(ctx, zid); err == nil {
+			result = append(result, place.Placement{
+				Place:    i + 1,
+				Builtin:  i == len(mgr.subplaces)-2,
+				Computed: i == len(mgr.subplaces)-1,
+			})
+		}
+	}
+	return result
+}
@@ -0,0 +1,236 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package manager coordinates the various places of a Zettelstore.
+package manager
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/place"
+)
+
+// testPlace stores the meta data of some zettel. It is read-only, if it
+// simulates the place of constant zettel.
+type testPlace struct {
+	zettel   map[id.Zid]bool
+	readonly bool
+	err      error // returned when reading a stored zettel, if not nil
+}
+
+func newTestPlace(readonly bool, zids ...id.Zid) *testPlace {
+	tp := &testPlace{zettel: make(map[id.Zid]bool, len(zids)), readonly: readonly}
+	for _, zid := range zids {
+		tp.zettel[zid] = true
+	}
+	return tp
+}
+
+func (tp *testPlace) Location() string                          { return "test:" }
+func (tp *testPlace) Start(ctx context.Context) error           { return nil }
+func (tp *testPlace) Stop(ctx context.Context) error            { return nil }
+func (tp *testPlace) RegisterChangeObserver(place.ObserverFunc) {}
+func (tp *testPlace) CanCreateZettel(ctx context.Context) bool  { return false }
+func (tp *testPlace) CreateZettel(ctx context.Context, zettel domain.Zettel) (id.Zid, error) {
+	return id.Invalid, place.ErrReadOnly
+}
+func (tp *testPlace) CreateZettelFrom(ctx context.Context, m *meta.Meta, r io.Reader) (id.Zid, error) {
+	return id.Invalid, place.ErrReadOnly
+}
+func (tp *testPlace) GetZettel(ctx context.Context, zid id.Zid) (domain.Zettel, error) {
+	m, err := tp.GetMeta(ctx, zid)
+	return domain.Zettel{Meta: m}, err
+}
+func (tp *testPlace) GetMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error) {
+	if tp.zettel[zid] {
+		if tp.err != nil {
+			return nil, tp.err
+		}
+		return meta.New(zid), nil
+	}
+	return nil, place.ErrNotFound
+}
+func (tp *testPlace) OpenContent(ctx context.Context, zid id.Zid) (io.ReadCloser, error) {
+	return nil, place.ErrNotFound
+}
+func (tp *testPlace) SelectMeta(ctx context.Context, f *place.Filter, s *place.Sorter) ([]*meta.Meta, error) {
+	return nil, nil
+}
+func (tp *testPlace) CanUpdateZettel(ctx context.Context, zettel domain.Zettel) bool { return false }
+func (tp *testPlace) UpdateZettel(ctx context.Context, zettel domain.Zettel) error {
+	return place.ErrReadOnly
+}
+func (tp *testPlace) UpdateMeta(ctx context.Context, m *meta.Meta) error {
+	return place.ErrReadOnly
+}
+func (tp *testPlace) AllowRenameZettel(ctx context.Context, zid id.Zid) bool {
+	return !tp.readonly || !tp.zettel[zid]
+}
+func (tp *testPlace) RenameZettel(ctx context.Context, curZid, newZid id.Zid) error {
+	if !tp.zettel[curZid] {
+		return place.ErrNotFound
+	}
+	if tp.readonly {
+		return place.ErrReadOnly
+	}
+	if tp.zettel[newZid] {
+		return &place.ErrInvalidID{Zid: newZid}
+	}
+	delete(tp.zettel, curZid)
+	tp.zettel[newZid] = true
+	return nil
+}
+func (tp *testPlace) CanDeleteZettel(ctx context.Context, zid id.Zid) bool { return false }
+func (tp *testPlace) DeleteZettel(ctx context.Context, zid id.Zid) error {
+	return place.ErrReadOnly
+}
+func (tp *testPlace) CanUndoZettel(ctx context.Context, zid id.Zid) bool { return false }
+func (tp *testPlace) GetUndoMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error) {
+	return nil, place.ErrNotFound
+}
+func (tp *testPlace) UndoZettel(ctx context.Context, zid id.Zid) error   { return place.ErrReadOnly }
+func (tp *testPlace) Reload(ctx context.Context) error                   { return nil }
+func (tp *testPlace) ReloadZettel(ctx context.Context, zid id.Zid) error { return nil }
+func (tp *testPlace) Stats(ctx context.Context) place.Stats              { return place.Stats{} }
+
+func TestRenameZettel(t *testing.T) {
+	const (
+		zidBoth   = id.Zid(20210301120000) // stored in first place, shadows second
+		zidSecond = id.Zid(20210301120100) // stored in second place only
+		zidConst  = id.Zid(10100)          // constant zettel
+		zidShadow = id.Zid(20210301120200) // stored in first place, shadows constant
+		zidNew    = id.Zid(20210301120300)
+	)
+	ctx := context.Background()
+	first := newTestPlace(false, zidBoth, zidShadow)
+	second := newTestPlace(false, zidBoth, zidSecond)
+	consts := newTestPlace(true, zidConst, zidShadow)
+	mgr := &Manager{
+		started:   true,
+		subplaces: []place.Place{first, second, consts},
+		degraded:  make([]int32, 3),
+	}
+
+	testcases := []struct {
+		curZid, newZid id.Zid
+		err            error
+		exp            []*testPlace // places that store newZid afterwards
+	}{
+		{zidNew, zidNew + 1, place.ErrNotFound, nil},
+		{zidBoth, zidBoth, nil, []*testPlace{first, second}},
+		{zidBoth, id.Invalid, &place.ErrInvalidID{Zid: id.Invalid}, nil},
+		{zidBoth, zidSecond, &place.ErrInvalidID{Zid: zidSecond}, []*testPlace{second}},
+		{zidBoth, zidConst, &place.ErrInvalidID{Zid: zidConst}, []*testPlace{consts}},
+		{zidSecond, zidConst, &place.ErrInvalidID{Zid: zidConst}, []*testPlace{consts}},
+		{zidShadow, zidNew, place.ErrReadOnly, nil},
+		{zidBoth, zidNew, nil, []*testPlace{first, second}},
+	}
+	for i, tc := range testcases {
+		err := mgr.RenameZettel(ctx, tc.curZid, tc.newZid)
+		if !sameError(err, tc.err) {
+			t.Errorf("%d: expected error %v, but got %v", i, tc.err, err)
+		}
+		for j, tp := range []*testPlace{first, second, consts} {
+			exp := false
+			for _, e := range tc.exp {
+				exp = exp || e == tp
+			}
+			if got := tp.zettel[tc.newZid]; got != exp {
+				t.Errorf("%d: place %d stores %v: expected %v, but got %v", i, j, tc.newZid, exp, got)
+			}
+		}
+	}
+	if !first.zettel[zidShadow] || !consts.zettel[zidShadow] {
+		t.Error("failed rename was not reverted")
+	}
+	if first.zettel[zidBoth] || second.zettel[zidBoth] {
+		t.Error("old zid still stored")
+	}
+}
+
+func sameError(err1, err2 error) bool {
+	if err1 == nil || err2 == nil {
+		return err1 == err2
+	}
+	return err1.Error() == err2.Error()
+}
+
+func TestDegraded(t *testing.T) {
+	const zid = id.Zid(20210301120000)
+	ctx := context.Background()
+	first := newTestPlace(false, zid)
+	second := newTestPlace(false, zid)
+	mgr := &Manager{
+		started:   true,
+		subplaces: []place.Place{first, second},
+		degraded:  make([]int32, 2),
+		filter:    newFilter(),
+	}
+	for _, err := range []error{context.Canceled, context.DeadlineExceeded, io.ErrUnexpectedEOF} {
+		first.err = err
+		if _, got := mgr.GetMeta(ctx, zid); got != err {
+			t.Errorf("expected error %v, but got %v", err, got)
+		}
+		if mgr.IsDegraded() {
+			t.Errorf("error %v degraded the place", err)
+		}
+	}
+	first.err = &place.ErrUnavailable{Err: io.ErrClosedPipe}
+	if m, err := mgr.GetMeta(ctx, zid); err != nil || m == nil {
+		t.Errorf("zettel of second place not found: %v", err)
+	}
+	if !mgr.isDegraded(0) || mgr.isDegraded(1) {
+		t.Errorf("expected only first place to be degraded, but got %v", mgr.degraded)
+	}
+}
+
+func TestPlacements(t *testing.T) {
+	const (
+		zidUser     = id.Zid(20210301120000)
+		zidConst    = id.Zid(10100)
+		zidShadow   = id.Zid(100)
+		zidComputed = id.Zid(1)
+	)
+	mgr := &Manager{
+		started: true,
+		subplaces: []place.Place{
+			newTestPlace(false, zidUser, zidShadow),
+			newTestPlace(true, zidConst, zidShadow),
+			newTestPlace(true, zidComputed),
+		},
+	}
+	testcases := []struct {
+		zid id.Zid
+		exp []place.Placement
+	}{
+		{zidUser, []place.Placement{{Place: 1}}},
+		{zidConst, []place.Placement{{Place: 2, Builtin: true}}},
+		{zidShadow, []place.Placement{{Place: 1}, {Place: 2, Builtin: true}}},
+		{zidComputed, []place.Placement{{Place: 3, Computed: true}}},
+		{id.Zid(2), nil},
+	}
+	for _, tc := range testcases {
+		got := mgr.Placements(context.Background(), tc.zid)
+		if len(got) != len(tc.exp) {
+			t.Errorf("%v: expected %v, but got %v", tc.zid, tc.exp, got)
+			continue
+		}
+		for i, p := range got {
+			if p != tc.exp[i] {
+				t.Errorf("%v: expected %v, but got %v", tc.zid, tc.exp, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+package manager
+
+import (
+	"net/url"
+	"strings"
+
+	"zettelstore.de/z/domain/meta"
+)
+
+// routeRule decides, whether a zettel should be stored in a specific place.
+type routeRule struct {
+	key   string
+	value string
+}
+
+// getRouteRules extracts all routing rules of a place URI. A rule is given
+// as the query value "route=KEY:VALUE", where KEY is one of "role", "tag",
+// or "visibility".
+func getRouteRules(rawURL string) ([]routeRule, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	values := u.Query()["route"]
+	if len(values) == 0 {
+		return nil, nil
+	}
+	result := make([]routeRule, 0, len(values))
+	for _, val := range values {
+		pos := strings.IndexByte(val, ':')
+		if pos <= 0 || pos == len(val)-1 {
+			return nil, &ErrInvalidRoute{val}
+		}
+		key, value := val[:pos], val[pos+1:]
+		switch key {
+		case "role", "visibility":
+		case "tag":
+			if value[0] != '#' {
+				value = "#" + value
+			}
+		default:
+			return nil, &ErrInvalidRoute{val}
+		}
+		result = append(result, routeRule{key, value})
+	}
+	return result, nil
+}
+
+// ErrInvalidRoute is returned if a routing rule of a place URI is malformed.
+type ErrInvalidRoute struct{ Route string }
+
+func (err *ErrInvalidRoute) Error() string { return "Invalid route: " + err.Route }
+
+// matches returns true, if the metadata satisfies the rule.
+func (rr routeRule) matches(m *meta.Meta) bool {
+	switch rr.key {
+	case "role":
+		return m.GetDefault(meta.KeyRole, "") == rr.value
+	case "visibility":
+		return m.GetDefault(meta.KeyVisibility, "") == rr.value
+	case "tag":
+		for _, tag := range m.GetListOrNil(meta.KeyTags) {
+			if tag == rr.value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// routePlace returns the index of the place that should store a zettel with
+// the given metadata. Places with routing rules take precedence, if one of
+// their rules matches. Otherwise the first place without rules is used.
+func (mgr *Manager) routePlace(m *meta.Meta) int {
+	if m != nil {
+		for i, rules := range mgr.routes {
+			for _, rr := range rules {
+				if rr.matches(m) {
+					return i
+				}
+			}
+		}
+	}
+	for i, rules := range mgr.routes {
+		if len(rules) == 0 {
+			return i
+		}
+	}
+	return 0
+}
@@ -13,9 +13,11 @@ package memplace
 
 import (
 	"context"
+	"io"
+	"io/ioutil"
 	"net/url"
+	"strings"
 	"sync"
-	"time"
 
 	"zettelstore.de/z/domain"
 	"zettelstore.de/z/domain/id"
@@ -35,9 +37,11 @@ func init() {
 type memPlace struct {
 	u         *url.URL
 	zettel    map[id.Zid]domain.Zettel
+	previous  map[id.Zid]domain.Zettel // previous version of updated zettel
 	mx        sync.RWMutex
 	observers []place.ObserverFunc
 	filter    manager.MetaFilter
+	zids      *id.Allocator
 }
 
 func (mp *memPlace) notifyChanged(reason place.ChangeReason, zid id.Zid) {
@@ -54,6 +58,10 @@ func (mp *memPlace) Start(ctx context.Context) error {
 	mp.mx.Lock()
 	defer mp.mx.Unlock()
 	mp.zettel = make(map[id.Zid]domain.Zettel)
+	mp.previous = make(map[id.Zid]domain.Zettel)
+	if mp.zids == nil {
+		mp.zids = id.NewAllocator()
+	}
 	return nil
 }
 
@@ -61,6 +69,7 @@ func (mp *memPlace) Stop(ctx context.Context) error {
 	mp.mx.Lock()
 	defer mp.mx.Unlock()
 	mp.zettel = nil
+	mp.previous = nil
 	return nil
 }
 
@@ -84,18 +93,21 @@ func (mp *memPlace) CreateZettel(ctx context.Context, zettel domain.Zettel) (id.
 	return meta.Zid, nil
 }
 
-func (mp *memPlace) calcNewZid() id.Zid {
-	zid := id.New(false)
-	if _, ok := mp.zettel[zid]; !ok {
-		return zid
-	}
-	for {
-		zid = id.New(true)
-		if _, ok := mp.zettel[zid]; !ok {
-			return zid
-		}
-		time.Sleep(100 * time.Millisecond)
+// CreateZettelFrom reads the whole content into memory, because there is no
+// other place to store it.
+func (mp *memPlace) CreateZettelFrom(ctx context.Context, m *meta.Meta, r io.Reader) (id.Zid, error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return id.Invalid, err
 	}
+	return mp.CreateZettel(ctx, domain.Zettel{Meta: m, Content: domain.NewContent(string(content))})
+}
+
+func (mp *memPlace) calcNewZid() id.Zid {
+	return mp.zids.New(false, func(zid id.Zid) bool {
+		_, ok := mp.zettel[zid]
+		return ok
+	})
 }
 
 func (mp *memPlace) GetZettel(ctx context.Context, zid id.Zid) (domain.Zettel, error) {
@@ -119,10 +131,20 @@ func (mp *memPlace) GetMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error)
 	return zettel.Meta.Clone(), nil
 }
 
+func (mp *memPlace) OpenContent(ctx context.Context, zid id.Zid) (io.ReadCloser, error) {
+	mp.mx.RLock()
+	zettel, ok := mp.zettel[zid]
+	mp.mx.RUnlock()
+	if !ok {
+		return nil, place.ErrNotFound
+	}
+	return ioutil.NopCloser(strings.NewReader(zettel.Content.AsString())), nil
+}
+
 func (mp *memPlace) SelectMeta(ctx context.Context, f *place.Filter, s *place.Sorter) ([]*meta.Meta, error) {
 	filterFunc := place.CreateFilterFunc(f)
-	result := make([]*meta.Meta, 0, len(mp.zettel))
 	mp.mx.RLock()
+	result := make([]*meta.Meta, 0, len(mp.zettel))
 	for _, zettel := range mp.zettel {
 		m := zettel.Meta.Clone()
 		mp.filter.UpdateProperties(m)
@@ -147,11 +169,28 @@ func (mp *memPlace) UpdateZettel(ctx context.Context, zettel domain.Zettel) erro
 		return &place.ErrInvalidID{Zid: meta.Zid}
 	}
 	zettel.Meta = meta
+	if prev, ok := mp.zettel[meta.Zid]; ok {
+		mp.previous[meta.Zid] = prev
+	}
 	mp.zettel[meta.Zid] = zettel
 	mp.notifyChanged(place.OnUpdate, meta.Zid)
 	return nil
 }
 
+func (mp *memPlace) UpdateMeta(ctx context.Context, m *meta.Meta) error {
+	mp.mx.Lock()
+	defer mp.mx.Unlock()
+
+	prev, ok := mp.zettel[m.Zid]
+	if !ok {
+		return place.ErrNotFound
+	}
+	mp.previous[m.Zid] = prev
+	mp.zettel[m.Zid] = domain.Zettel{Meta: m.Clone(), Content: prev.Content}
+	mp.notifyChanged(place.OnUpdate, m.Zid)
+	return nil
+}
+
 func (mp *memPlace) AllowRenameZettel(ctx context.Context, zid id.Zid) bool { return true }
 
 func (mp *memPlace) RenameZettel(ctx context.Context, curZid, newZid id.Zid) error {
@@ -173,6 +212,7 @@ func (mp *memPlace) RenameZettel(ctx context.Context, curZid, newZid id.Zid) err
 	zettel.Meta = meta
 	mp.zettel[newZid] = zettel
 	delete(mp.zettel, curZid)
+	delete(mp.previous, curZid)
 	mp.notifyChanged(place.OnDelete, curZid)
 	mp.notifyChanged(place.OnCreate, newZid)
 	return nil
@@ -181,7 +221,7 @@ func (mp *memPlace) RenameZettel(ctx context.Context, curZid, newZid id.Zid) err
 func (mp *memPlace) CanDeleteZettel(ctx context.Context, zid id.Zid) bool {
 	mp.mx.RLock()
 	_, ok := mp.zettel[zid]
-	mp.mx.Unlock()
+	mp.mx.RUnlock()
 	return ok
 }
 
@@ -193,15 +233,61 @@ func (mp *memPlace) DeleteZettel(ctx context.Context, zid id.Zid) error {
 		return place.ErrNotFound
 	}
 	delete(mp.zettel, zid)
+	delete(mp.previous, zid)
 	mp.notifyChanged(place.OnDelete, zid)
 	return nil
 }
 
+func (mp *memPlace) CanUndoZettel(ctx context.Context, zid id.Zid) bool {
+	mp.mx.RLock()
+	_, ok := mp.previous[zid]
+	mp.mx.RUnlock()
+	return ok
+}
+
+func (mp *memPlace) GetUndoMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error) {
+	mp.mx.RLock()
+	prev, ok := mp.previous[zid]
+	mp.mx.RUnlock()
+	if !ok {
+		return nil, place.ErrNotFound
+	}
+	return prev.Meta.Clone(), nil
+}
+
+func (mp *memPlace) UndoZettel(ctx context.Context, zid id.Zid) error {
+	mp.mx.Lock()
+	defer mp.mx.Unlock()
+
+	prev, ok := mp.previous[zid]
+	if !ok {
+		return place.ErrNotFound
+	}
+	mp.previous[zid] = mp.zettel[zid]
+	mp.zettel[zid] = prev
+	mp.notifyChanged(place.OnUpdate, zid)
+	return nil
+}
+
 func (mp *memPlace) Reload(ctx context.Context) error { return nil }
 
-func (mp *memPlace) ReadStats(st *place.Stats) {
-	st.ReadOnly = false
+func (mp *memPlace) ReloadZettel(ctx context.Context, zid id.Zid) error {
 	mp.mx.RLock()
-	st.Zettel = len(mp.zettel)
+	_, ok := mp.zettel[zid]
 	mp.mx.RUnlock()
+	if !ok {
+		return place.ErrNotFound
+	}
+	return nil
+}
+
+func (mp *memPlace) Stats(ctx context.Context) place.Stats {
+	mp.mx.RLock()
+	numZettel := len(mp.zettel)
+	mp.mx.RUnlock()
+	return place.Stats{
+		ReadOnly: false,
+		Zettel:   numZettel,
+		Location: mp.Location(),
+	}
 }
@@ -0,0 +1,178 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package memplace stores zettel volatile in main memory.
+package memplace
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/place"
+)
+
+type testFilter struct{}
+
+func (testFilter) UpdateProperties(m *meta.Meta) { m.Set("computed", "true") }
+func (testFilter) RemoveProperties(m *meta.Meta) { m.Delete("computed") }
+
+// TestConcurrentAccess should be run with the race detector. Callers may
+// change the returned meta data without affecting the stored zettel.
+func TestConcurrentAccess(t *testing.T) {
+	ctx := context.Background()
+	mp := &memPlace{u: &url.URL{Scheme: "mem"}, filter: testFilter{}}
+	if err := mp.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	m := meta.New(0)
+	m.Set(meta.KeyTitle, "0")
+	zid, err := mp.CreateZettel(ctx, domain.Zettel{Meta: m, Content: domain.NewContent("c")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Set(meta.KeyTitle, "changed by caller")
+
+	const rounds = 100
+	var wg sync.WaitGroup
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= rounds; i++ {
+			um := meta.New(zid)
+			um.Set(meta.KeyTitle, strconv.Itoa(i))
+			if err := mp.UpdateZettel(ctx, domain.Zettel{Meta: um}); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < rounds; i++ {
+			m, err := mp.GetMeta(ctx, zid)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+			m.Set(meta.KeyTitle, "changed by reader")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < rounds; i++ {
+			ml, err := mp.SelectMeta(ctx, nil, nil)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+			for _, m := range ml {
+				m.Set(meta.KeyTitle, "changed by selector")
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < rounds; i++ {
+			if !mp.CanDeleteZettel(ctx, zid) {
+				t.Error("zettel cannot be deleted")
+			}
+			z, err := mp.GetZettel(ctx, zid)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+			z.Meta.Set(meta.KeyTitle, "changed by getter")
+		}
+	}()
+	wg.Wait()
+
+	got, err := mp.GetMeta(ctx, zid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title := got.GetDefault(meta.KeyTitle, ""); title != strconv.Itoa(rounds) {
+		t.Errorf("expected title %v, but got %q", rounds, title)
+	}
+}
+
+func TestConcurrentCreate(t *testing.T) {
+	ctx := context.Background()
+	mp := &memPlace{u: &url.URL{Scheme: "mem"}, filter: testFilter{}}
+	if err := mp.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	const n = 20
+	zids := make(chan id.Zid, n)
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			zid, err := mp.CreateZettel(ctx, domain.Zettel{Meta: meta.New(0)})
+			if err != nil {
+				t.Error(err)
+			}
+			zids <- zid
+		}()
+	}
+	wg.Wait()
+	close(zids)
+	seen := make(map[id.Zid]bool, n)
+	for zid := range zids {
+		if seen[zid] {
+			t.Errorf("zid %v created twice", zid)
+		}
+		seen[zid] = true
+	}
+	if got := mp.Stats(ctx).Zettel; got != n {
+		t.Errorf("expected %d zettel, but got %d", n, got)
+	}
+}
+
+func TestUpdateMeta(t *testing.T) {
+	ctx := context.Background()
+	mp := &memPlace{u: &url.URL{Scheme: "mem"}, filter: testFilter{}}
+	if err := mp.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	m := meta.New(0)
+	m.Set(meta.KeyTitle, "Old")
+	zid, err := mp.CreateZettel(ctx, domain.Zettel{Meta: m, Content: domain.NewContent("c")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m = meta.New(zid)
+	m.Set(meta.KeyTitle, "New")
+	if err = mp.UpdateMeta(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	z, err := mp.GetZettel(ctx, zid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title := z.Meta.GetDefault(meta.KeyTitle, ""); title != "New" {
+		t.Errorf("expected title %q, but got %q", "New", title)
+	}
+	if content := z.Content.AsString(); content != "c" {
+		t.Errorf("content changed to %q", content)
+	}
+	if !mp.CanUndoZettel(ctx, zid) {
+		t.Error("previous version not stored")
+	}
+	if err = mp.UpdateMeta(ctx, meta.New(zid+1)); err != place.ErrNotFound {
+		t.Errorf("expected error %v, but got %v", place.ErrNotFound, err)
+	}
+}
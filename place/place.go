@@ -15,6 +15,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"time"
 
 	"zettelstore.de/z/domain"
 	"zettelstore.de/z/domain/id"
@@ -63,12 +65,23 @@ type Place interface {
 	// Returns the new zettel id (and an error indication).
 	CreateZettel(ctx context.Context, zettel domain.Zettel) (id.Zid, error)
 
+	// CreateZettelFrom creates a new zettel, whose content is read from the
+	// given reader, without reading the whole content into memory, if
+	// possible. If reading fails, no zettel is created.
+	// Returns the new zettel id (and an error indication).
+	CreateZettelFrom(ctx context.Context, m *meta.Meta, r io.Reader) (id.Zid, error)
+
 	// GetZettel retrieves a specific zettel.
 	GetZettel(ctx context.Context, zid id.Zid) (domain.Zettel, error)
 
 	// GetMeta retrieves just the meta data of a specific zettel.
 	GetMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error)
 
+	// OpenContent returns a reader for the content of a specific zettel,
+	// without reading the whole content into memory, if possible.
+	// The caller must close the reader.
+	OpenContent(ctx context.Context, zid id.Zid) (io.ReadCloser, error)
+
 	// SelectMeta returns all zettel meta data that match the selection criteria.
 	// TODO: more docs
 	SelectMeta(ctx context.Context, f *Filter, s *Sorter) ([]*meta.Meta, error)
@@ -79,6 +92,10 @@ type Place interface {
 	// UpdateZettel updates an existing zettel.
 	UpdateZettel(ctx context.Context, zettel domain.Zettel) error
 
+	// UpdateMeta updates just the meta data of an existing zettel. If
+	// possible, the content is not written again.
+	UpdateMeta(ctx context.Context, m *meta.Meta) error
+
 	// AllowRenameZettel returns true, if place will not disallow renaming the zettel.
 	AllowRenameZettel(ctx context.Context, zid id.Zid) bool
 
@@ -91,12 +108,29 @@ type Place interface {
 	// DeleteZettel removes the zettel from the place.
 	DeleteZettel(ctx context.Context, zid id.Zid) error
 
+	// CanUndoZettel returns true, if place stores a previous version of the
+	// given zettel that could be restored.
+	CanUndoZettel(ctx context.Context, zid id.Zid) bool
+
+	// GetUndoMeta returns the metadata of the previous version of the given
+	// zettel. If there is no previous version, ErrNotFound is returned.
+	GetUndoMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error)
+
+	// UndoZettel restores the previous version of the given zettel. The
+	// current version becomes the previous one, so that an undo can be
+	// reverted by another undo.
+	UndoZettel(ctx context.Context, zid id.Zid) error
+
 	// Reload clears all caches, reloads all internal data to reflect changes
 	// that were possibly undetected.
 	Reload(ctx context.Context) error
 
-	// ReadStats populates st with place statistics
-	ReadStats(st *Stats)
+	// ReloadZettel clears all caches of the given zettel and reloads its
+	// internal data to reflect changes that were possibly undetected.
+	ReloadZettel(ctx context.Context, zid id.Zid) error
+
+	// Stats returns statistics and health data of the place.
+	Stats(ctx context.Context) Stats
 }
 
 // Manager is a place-managing place.
@@ -105,6 +139,32 @@ type Manager interface {
 
 	// NumPlaces returns the number of managed places.
 	NumPlaces() int
+
+	// IsDegraded returns true, if at least one managed place is currently
+	// skipped for reading. In contrast to Stats, it is cheap to call.
+	IsDegraded() bool
+
+	// Placements returns all places that store a zettel with the given
+	// identifier, in the order of the chain. Only the zettel of the first
+	// place is visible, it shadows all other zettel.
+	Placements(ctx context.Context, zid id.Zid) []Placement
+
+	// AddProperty registers a function that computes an additional property
+	// of meta data, whenever meta data is read. The property can be used
+	// to select zettel.
+	AddProperty(f func(m *meta.Meta))
+}
+
+// Placement describes a place of a manager that stores a specific zettel.
+type Placement struct {
+	// Place is the number of the place within the chain, starting with 1.
+	Place int
+
+	// Builtin is true, if the place stores the constant zettel of the software.
+	Builtin bool
+
+	// Computed is true, if the place stores zettel computed at runtime.
+	Computed bool
 }
 
 // Stats records statistics about the place.
@@ -114,6 +174,27 @@ type Stats struct {
 
 	// Zettel is the number of zettel managed by the place.
 	Zettel int
+
+	// Scanning indicates that the place has not completed its initial scan.
+	Scanning bool
+
+	// LastScan is the time of the last completed scan, if the place scans
+	// an external storage.
+	LastScan time.Time
+
+	// Degraded indicates that the place is currently skipped for reading,
+	// because it failed recently.
+	Degraded bool
+
+	// Errors is the number of errors that occurred while accessing the
+	// storage of the place.
+	Errors int
+
+	// Location is the location of the place, as returned by Location().
+	Location string
+
+	// Places contains the statistics of all sub-places, if any.
+	Places []Stats
 }
 
 // ErrNotAllowed is returned if the caller is not allowed to perform the operation.
@@ -180,6 +261,16 @@ type ErrInvalidID struct{ Zid id.Zid }
 
 func (err *ErrInvalidID) Error() string { return "Invalid Zettel id: " + err.Zid.String() }
 
+// ErrUnavailable is returned if the storage of a place cannot be accessed as
+// a whole, e.g. because its directory was removed or a network is down. Errors
+// that concern a single zettel are not of this type.
+type ErrUnavailable struct{ Err error }
+
+func (err *ErrUnavailable) Error() string { return "Place not available: " + err.Err.Error() }
+
+// Unwrap returns the error that caused the place to be unavailable.
+func (err *ErrUnavailable) Unwrap() error { return err.Err }
+
 // Filter specifies a mechanism for selecting zettel.
 type Filter struct {
 	Expr   FilterExpr
@@ -188,12 +279,13 @@ type Filter struct {
 }
 
 // FilterExpr is the encoding of a search filter.
-type FilterExpr map[string][]string // map of keys to or-ed values
+type FilterExpr map[string][]string // map of keys to and-ed values
 
 // Sorter specifies ordering and limiting a sequnce of meta data.
 type Sorter struct {
 	Order      string // Name of meta key. None given: use "id"
 	Descending bool   // Sort by order, but descending
+	After      id.Zid // Valid: start after the zettel with this identifier
 	Offset     int    // <= 0: no offset
 	Limit      int    // <= 0: no limit
 }
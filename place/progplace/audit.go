@@ -0,0 +1,69 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package progplace provides zettel that inform the user about the internal Zettelstore state.
+package progplace
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"zettelstore.de/z/audit"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+)
+
+func genAuditM(zid id.Zid) *meta.Meta {
+	m := meta.New(zid)
+	m.Set(meta.KeyTitle, "Zettelstore Audit Trail")
+	m.Set(meta.KeyVisibility, meta.ValueVisibilityOwner)
+	return m
+}
+
+func genAuditC(*meta.Meta) string {
+	entries := audit.Entries()
+	if len(entries) == 0 {
+		return "No events recorded."
+	}
+	var sb strings.Builder
+	sb.WriteString("|=Time|=Event|=User|=Zettel|=Detail\n")
+	for _, e := range entries {
+		zid := ""
+		if e.Zid.IsValid() {
+			zid = e.Zid.String()
+		}
+		fmt.Fprintf(&sb, "|%v|%v|%v|%v|%v\n",
+			e.Time.Format("2006-01-02 15:04:05"), e.Event, escapeCell(e.User), zid, escapeCell(e.Detail))
+	}
+	return sb.String()
+}
+
+// escapeCell escapes a table cell, so that its text is never interpreted as
+// markup. User identification and details may contain arbitrary text, e.g.
+// from a failed login. All ASCII punctuation characters are escaped by a
+// backslash, all control characters, including line endings, are replaced by
+// a space.
+func escapeCell(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch {
+		case unicode.IsControl(r):
+			sb.WriteByte(' ')
+		case r < utf8.RuneSelf && (unicode.IsPunct(r) || unicode.IsSymbol(r)):
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
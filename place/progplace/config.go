@@ -72,9 +72,21 @@ func genConfigC(m *meta.Meta) string {
 	fmt.Fprintf(&sb, "|Simple|%v\n", startup.IsSimple())
 	fmt.Fprintf(&sb, "|Verbose|%v\n", startup.IsVerbose())
 	fmt.Fprintf(&sb, "|Read-only|%v\n", startup.IsReadOnlyMode())
+	fmt.Fprintf(&sb, "|Starter pack|%v\n", startup.StarterPack())
 	fmt.Fprintf(&sb, "|URL prefix|%v\n", startup.URLPrefix())
+	fmt.Fprintf(&sb, "|Trust proxy|%v\n", startup.TrustProxy())
+	fmt.Fprintf(&sb, "|Update check|%v\n", startup.UpdateChecker() != nil)
+	fmt.Fprintf(&sb, "|OCR command|%v\n", startup.OCRCommand())
+	fmt.Fprintf(&sb, "|Archive URL|%v\n", startup.ArchiveURL())
+	fmt.Fprintf(&sb, "|Request timeout|%v\n", startup.RequestTimeout())
+	fmt.Fprintf(&sb, "|Max request body|%v\n", startup.MaxRequestBody())
+	fmt.Fprintf(&sb, "|Max zettel size|%v\n", startup.MaxZettelSize())
+	fmt.Fprintf(&sb, "|Quota zettel|%v\n", startup.QuotaZettel())
+	fmt.Fprintf(&sb, "|Quota size|%v\n", startup.QuotaSize())
 	// There must be a space before the next "%v". Listen address may start with a ":"
 	fmt.Fprintf(&sb, "|Listen address| %v\n", startup.ListenAddress())
+	fmt.Fprintf(&sb, "|Public listen address| %v\n", startup.PublicListenAddress())
+	fmt.Fprintf(&sb, "|Allowed attributes|%v\n", strings.Join(startup.AllowAttributes(), " "))
 	fmt.Fprintf(&sb, "|Authentication enabled|%v\n", startup.WithAuth())
 	fmt.Fprintf(&sb, "|Secure cookie|%v\n", startup.SecureCookie())
 	fmt.Fprintf(&sb, "|Persistent Cookie|%v\n", startup.PersistentCookie())
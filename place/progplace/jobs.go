@@ -0,0 +1,58 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package progplace provides zettel that inform the user about the internal Zettelstore state.
+package progplace
+
+import (
+	"fmt"
+	"strings"
+
+	"zettelstore.de/z/config/startup"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+)
+
+func genJobsM(zid id.Zid) *meta.Meta {
+	m := meta.New(zid)
+	m.Set(meta.KeyTitle, "Zettelstore Jobs Status")
+	m.Set(meta.KeyVisibility, meta.ValueVisibilityOwner)
+	return m
+}
+
+func genJobsC(*meta.Meta) string {
+	scheduler := startup.Scheduler()
+	if scheduler == nil {
+		return "No scheduler available."
+	}
+	jobs := scheduler.Status()
+	if len(jobs) == 0 {
+		return "No jobs registered."
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Jobs are scheduled in zettel [[%v]].\n\n", id.JobsZid)
+	sb.WriteString("|=Job|=Interval|=Last run|=Duration|=Result\n")
+	for _, job := range jobs {
+		interval, lastRun, duration, result := "never", "", "", ""
+		if job.Interval > 0 {
+			interval = job.Interval.String()
+		}
+		if !job.LastRun.IsZero() {
+			lastRun = job.LastRun.Local().Format("2006-01-02 15:04:05")
+			duration = job.Duration.String()
+			result = "ok"
+			if job.Err != nil {
+				result = escapeCell(job.Err.Error())
+			}
+		}
+		fmt.Fprintf(&sb, "|%v|%v|%v|%v|%v\n", job.Name, interval, lastRun, duration, result)
+	}
+	return sb.String()
+}
@@ -13,12 +13,13 @@
 package progplace
 
 import (
+	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"zettelstore.de/z/domain/id"
 	"zettelstore.de/z/domain/meta"
-	"zettelstore.de/z/place"
 )
 
 func genManagerM(zid id.Zid) *meta.Meta {
@@ -33,13 +34,30 @@ func genManagerM(zid id.Zid) *meta.Meta {
 func genManagerC(*meta.Meta) string {
 	mgr := myPlace.manager
 
-	var stats place.Stats
-	mgr.ReadStats(&stats)
+	stats := mgr.Stats(context.Background())
 
 	var sb strings.Builder
 	sb.WriteString("|=Name|=Value>\n")
 	fmt.Fprintf(&sb, "|Read-only| %v\n", stats.ReadOnly)
 	fmt.Fprintf(&sb, "|Zettel| %v\n", stats.Zettel)
+	fmt.Fprintf(&sb, "|Scanning| %v\n", stats.Scanning)
+	fmt.Fprintf(&sb, "|Last scan| %v\n", formatScanTime(stats.LastScan))
+	fmt.Fprintf(&sb, "|Degraded| %v\n", stats.Degraded)
+	fmt.Fprintf(&sb, "|Errors| %v\n", stats.Errors)
 	fmt.Fprintf(&sb, "|Sub-places| %v\n", mgr.NumPlaces())
+
+	sb.WriteString("\n|=Place|=Zettel>|=Read-only|=Scanning|=Degraded|=Last scan|=Errors>\n")
+	for _, sst := range stats.Places {
+		fmt.Fprintf(&sb, "|``%v``|%v|%v|%v|%v|%v|%v\n",
+			sst.Location, sst.Zettel, sst.ReadOnly, sst.Scanning, sst.Degraded,
+			formatScanTime(sst.LastScan), sst.Errors)
+	}
 	return sb.String()
 }
+
+func formatScanTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
@@ -14,7 +14,10 @@ package progplace
 
 import (
 	"context"
+	"io"
+	"io/ioutil"
 	"net/url"
+	"strings"
 
 	"zettelstore.de/z/domain"
 	"zettelstore.de/z/domain/id"
@@ -55,9 +58,12 @@ func getPlace(mf manager.MetaFilter) place.Place {
 				id.Zid(1):  {genVersionBuildM, genVersionBuildC},
 				id.Zid(2):  {genVersionHostM, genVersionHostC},
 				id.Zid(3):  {genVersionOSM, genVersionOSC},
+				id.Zid(4):  {genVersionUpdateM, genVersionUpdateC},
 				id.Zid(6):  {genEnvironmentM, genEnvironmentC},
 				id.Zid(8):  {genRuntimeM, genRuntimeC},
 				id.Zid(20): {genManagerM, genManagerC},
+				id.Zid(24): {genAuditM, genAuditC},
+				id.Zid(28): {genJobsM, genJobsC},
 				id.Zid(90): {genKeysM, genKeysC},
 				id.Zid(96): {genConfigZettelM, genConfigZettelC},
 				id.Zid(98): {genConfigM, genConfigC},
@@ -81,7 +87,7 @@ func Setup(startConfig *meta.Meta, manager place.Manager) {
 }
 
 // Location returns some information where the place is located.
-func (pp *progPlace) Location() string { return "" }
+func (pp *progPlace) Location() string { return "prog:" }
 
 // Start the place. Now all other functions of the place are allowed.
 // Starting an already started place is not allowed.
@@ -101,6 +107,11 @@ func (pp *progPlace) CreateZettel(
 	return id.Invalid, place.ErrReadOnly
 }
 
+func (pp *progPlace) CreateZettelFrom(
+	ctx context.Context, m *meta.Meta, r io.Reader) (id.Zid, error) {
+	return id.Invalid, place.ErrReadOnly
+}
+
 // GetZettel retrieves a specific zettel.
 func (pp *progPlace) GetZettel(
 	ctx context.Context, zid id.Zid) (domain.Zettel, error) {
@@ -132,6 +143,15 @@ func (pp *progPlace) GetMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error
 	return nil, place.ErrNotFound
 }
 
+// OpenContent returns a reader for the content of a specific zettel.
+func (pp *progPlace) OpenContent(ctx context.Context, zid id.Zid) (io.ReadCloser, error) {
+	zettel, err := pp.GetZettel(ctx, zid)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(strings.NewReader(zettel.Content.AsString())), nil
+}
+
 // SelectMeta returns all zettel meta data that match the selection
 // criteria. The result is ordered by descending zettel id.
 func (pp *progPlace) SelectMeta(
@@ -159,6 +179,13 @@ func (pp *progPlace) UpdateZettel(ctx context.Context, zettel domain.Zettel) err
 	return place.ErrReadOnly
 }
 
+func (pp *progPlace) UpdateMeta(ctx context.Context, m *meta.Meta) error {
+	if _, ok := pp.zettel[m.Zid]; ok {
+		return place.ErrReadOnly
+	}
+	return place.ErrNotFound
+}
+
 func (pp *progPlace) AllowRenameZettel(ctx context.Context, zid id.Zid) bool {
 	_, ok := pp.zettel[zid]
 	return !ok
@@ -182,11 +209,34 @@ func (pp *progPlace) DeleteZettel(ctx context.Context, zid id.Zid) error {
 	return place.ErrNotFound
 }
 
+func (pp *progPlace) CanUndoZettel(ctx context.Context, zid id.Zid) bool { return false }
+
+func (pp *progPlace) GetUndoMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error) {
+	return nil, place.ErrNotFound
+}
+
+func (pp *progPlace) UndoZettel(ctx context.Context, zid id.Zid) error {
+	if _, ok := pp.zettel[zid]; ok {
+		return place.ErrReadOnly
+	}
+	return place.ErrNotFound
+}
+
 func (pp *progPlace) Reload(ctx context.Context) error { return nil }
 
-func (pp *progPlace) ReadStats(st *place.Stats) {
-	st.ReadOnly = true
-	st.Zettel = len(pp.zettel)
+func (pp *progPlace) ReloadZettel(ctx context.Context, zid id.Zid) error {
+	if _, ok := pp.zettel[zid]; ok {
+		return nil
+	}
+	return place.ErrNotFound
+}
+
+func (pp *progPlace) Stats(ctx context.Context) place.Stats {
+	return place.Stats{
+		ReadOnly: true,
+		Zettel:   len(pp.zettel),
+		Location: pp.Location(),
+	}
 }
 
 func updateMeta(m *meta.Meta) {
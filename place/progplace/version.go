@@ -13,6 +13,7 @@ package progplace
 
 import (
 	"fmt"
+	"strings"
 
 	"zettelstore.de/z/config/startup"
 	"zettelstore.de/z/domain/id"
@@ -45,3 +46,33 @@ func genVersionOSC(*meta.Meta) string {
 	v := startup.GetVersion()
 	return fmt.Sprintf("%v/%v", v.Os, v.Arch)
 }
+
+func genVersionUpdateM(zid id.Zid) *meta.Meta {
+	return getVersionMeta(zid, "Zettelstore Build and Update Status")
+}
+func genVersionUpdateC(*meta.Meta) string {
+	v := startup.GetVersion()
+	var sb strings.Builder
+	sb.WriteString("|=Name|=Value>\n")
+	fmt.Fprintf(&sb, "|Version|%v\n", v.Build)
+	fmt.Fprintf(&sb, "|Commit|%v\n", v.Commit)
+	fmt.Fprintf(&sb, "|Build date|%v\n", v.Date)
+	checker := startup.UpdateChecker()
+	if checker == nil {
+		sb.WriteString("|Update check|disabled\n")
+		return sb.String()
+	}
+	status := checker.Status()
+	if status.Checked.IsZero() {
+		sb.WriteString("|Update check|pending\n")
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, "|Last check|%v\n", status.Checked.Local().Format("2006-01-02 15:04:05"))
+	if status.Err != nil {
+		fmt.Fprintf(&sb, "|Check error|%v\n", status.Err)
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, "|Latest release|%v\n", status.Latest)
+	fmt.Fprintf(&sb, "|Newer release available|%v\n", status.Newer)
+	return sb.String()
+}
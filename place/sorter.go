@@ -16,6 +16,7 @@ import (
 	"sort"
 	"strconv"
 
+	"zettelstore.de/z/domain/id"
 	"zettelstore.de/z/domain/meta"
 )
 
@@ -30,7 +31,9 @@ func EnsureSorter(sorter *Sorter) *Sorter {
 	return sorter
 }
 
-// ApplySorter applies the given sorter to the slide of meta data.
+// ApplySorter applies the given sorter to the slide of meta data. Zettel with
+// equal sort values are ordered by descending identifier, so that the order is
+// stable and the list can be continued after a given zettel.
 func ApplySorter(metaList []*meta.Meta, s *Sorter) []*meta.Meta {
 	if len(metaList) == 0 {
 		return metaList
@@ -45,14 +48,21 @@ func ApplySorter(metaList []*meta.Meta, s *Sorter) []*meta.Meta {
 		return metaList
 	}
 
-	if s.Order == "" {
-		sort.Slice(metaList, getSortFunc(meta.KeyID, true, metaList))
+	if s.Order == "" || s.Order == meta.KeyID {
+		descending := s.Order == "" || s.Descending
+		if s.After.IsValid() {
+			metaList = filterAfter(metaList, s.After, descending)
+		}
+		sort.Slice(metaList, getSortFunc(meta.KeyID, descending, metaList))
 	} else if s.Order == RandomOrder {
 		rand.Shuffle(len(metaList), func(i, j int) {
 			metaList[i], metaList[j] = metaList[j], metaList[i]
 		})
 	} else {
 		sort.Slice(metaList, getSortFunc(s.Order, s.Descending, metaList))
+		if s.After.IsValid() {
+			metaList = sliceAfter(metaList, s.After)
+		}
 	}
 
 	if s.Offset > 0 {
@@ -67,6 +77,30 @@ func ApplySorter(metaList []*meta.Meta, s *Sorter) []*meta.Meta {
 	return metaList
 }
 
+// filterAfter removes all meta data that are not after the given zettel
+// identifier. It does not need the zettel to be in the list.
+func filterAfter(metaList []*meta.Meta, after id.Zid, descending bool) []*meta.Meta {
+	result := metaList[:0]
+	for _, m := range metaList {
+		if (descending && m.Zid < after) || (!descending && m.Zid > after) {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// sliceAfter returns the part of the sorted list after the zettel with the
+// given identifier. If this zettel is not in the list, e.g. because it was
+// deleted in the meantime, an empty list is returned.
+func sliceAfter(metaList []*meta.Meta, after id.Zid) []*meta.Meta {
+	for i, m := range metaList {
+		if m.Zid == after {
+			return metaList[i+1:]
+		}
+	}
+	return nil
+}
+
 type sortFunc func(i, j int) bool
 
 func getSortFunc(key string, descending bool, ml []*meta.Meta) sortFunc {
@@ -81,7 +115,7 @@ func getSortFunc(key string, descending bool, ml []*meta.Meta) sortFunc {
 			return func(i, j int) bool {
 				left := ml[i].GetBool(key)
 				if left == ml[j].GetBool(key) {
-					return i > j
+					return ml[i].Zid > ml[j].Zid
 				}
 				return left
 			}
@@ -89,36 +123,34 @@ func getSortFunc(key string, descending bool, ml []*meta.Meta) sortFunc {
 		return func(i, j int) bool {
 			right := ml[j].GetBool(key)
 			if ml[i].GetBool(key) == right {
-				return i < j
+				return ml[i].Zid > ml[j].Zid
 			}
 			return right
 		}
 	} else if keyType == meta.TypeNumber {
-		if descending {
-			return func(i, j int) bool {
-				iVal, iOk := getNum(ml[i], key)
-				jVal, jOk := getNum(ml[j], key)
-				return (iOk && (!jOk || iVal > jVal)) || !jOk
-			}
-		}
 		return func(i, j int) bool {
 			iVal, iOk := getNum(ml[i], key)
 			jVal, jOk := getNum(ml[j], key)
-			return (iOk && (!jOk || iVal < jVal)) || !jOk
+			if iOk != jOk {
+				return iOk
+			}
+			if iVal != jVal {
+				return (iVal < jVal) != descending
+			}
+			return ml[i].Zid > ml[j].Zid
 		}
 	}
 
-	if descending {
-		return func(i, j int) bool {
-			iVal, iOk := ml[i].Get(key)
-			jVal, jOk := ml[j].Get(key)
-			return (iOk && (!jOk || iVal > jVal)) || !jOk
-		}
-	}
 	return func(i, j int) bool {
 		iVal, iOk := ml[i].Get(key)
 		jVal, jOk := ml[j].Get(key)
-		return (iOk && (!jOk || iVal < jVal)) || !jOk
+		if iOk != jOk {
+			return iOk
+		}
+		if iVal != jVal {
+			return (iVal < jVal) != descending
+		}
+		return ml[i].Zid > ml[j].Zid
 	}
 }
 
@@ -101,17 +101,26 @@ func (s *defaultStock) Subscribe(zid id.Zid) error {
 	return nil
 }
 
-// GetZettel returns the zettel with the given zid, if in stock, else an empty zettel
+// GetZettel returns the zettel with the given zid, if in stock, else an empty
+// zettel. The meta data of the zettel is a copy, so the caller may change it.
 func (s *defaultStock) GetZettel(zid id.Zid) domain.Zettel {
 	s.mxSubs.RLock()
-	defer s.mxSubs.RUnlock()
-	return s.subs[zid]
+	zettel := s.subs[zid]
+	s.mxSubs.RUnlock()
+	if zettel.Meta != nil {
+		zettel.Meta = zettel.Meta.Clone()
+	}
+	return zettel
 }
 
-// GetZettel returns the zettel Meta with the given zid, if in stock, else nil.
+// GetMeta returns a copy of the zettel Meta with the given zid, if in stock,
+// else nil.
 func (s *defaultStock) GetMeta(zid id.Zid) *meta.Meta {
 	s.mxSubs.RLock()
 	zettel := s.subs[zid]
 	s.mxSubs.RUnlock()
-	return zettel.Meta
+	if zettel.Meta == nil {
+		return nil
+	}
+	return zettel.Meta.Clone()
 }
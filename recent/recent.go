@@ -0,0 +1,102 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package recent tracks recently visited and recently modified zettel.
+//
+// All data is stored in memory only. It is lost when the software is
+// restarted.
+package recent
+
+import (
+	"sync"
+
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/place"
+)
+
+// Tracker stores the most recently visited zettel of every user and the
+// most recently modified zettel.
+type Tracker struct {
+	size int
+
+	mx       sync.Mutex // protects visited and modified
+	visited  map[id.Zid][]id.Zid
+	modified []id.Zid
+}
+
+// New creates a new tracker that remembers at most size zettel per list.
+func New(size int) *Tracker {
+	return &Tracker{
+		size:    size,
+		visited: make(map[id.Zid][]id.Zid),
+	}
+}
+
+// Visit records that the given user has visited the given zettel.
+func (t *Tracker) Visit(user, zid id.Zid) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	t.visited[user] = t.push(t.visited[user], zid)
+}
+
+// Visited returns the zettel recently visited by the given user, the most
+// recent first.
+func (t *Tracker) Visited(user id.Zid) []id.Zid {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	return copyList(t.visited[user])
+}
+
+// Modified returns the recently modified zettel, the most recent first.
+func (t *Tracker) Modified() []id.Zid {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	return copyList(t.modified)
+}
+
+// Observe tracks all changes a place signals. It is a place.ObserverFunc.
+func (t *Tracker) Observe(reason place.ChangeReason, zid id.Zid) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	switch reason {
+	case place.OnCreate, place.OnUpdate:
+		t.modified = t.push(t.modified, zid)
+	case place.OnDelete:
+		t.modified = remove(t.modified, zid)
+		for user, list := range t.visited {
+			t.visited[user] = remove(list, zid)
+		}
+	}
+}
+
+// push places the zettel identifier at the front of the list. It must be
+// called with a locked mx.
+func (t *Tracker) push(list []id.Zid, zid id.Zid) []id.Zid {
+	list = remove(list, zid)
+	if len(list) >= t.size {
+		list = list[:t.size-1]
+	}
+	return append([]id.Zid{zid}, list...)
+}
+
+func remove(list []id.Zid, zid id.Zid) []id.Zid {
+	for i, lzid := range list {
+		if lzid == zid {
+			return append(list[:i:i], list[i+1:]...)
+		}
+	}
+	return list
+}
+
+func copyList(list []id.Zid) []id.Zid {
+	result := make([]id.Zid, len(list))
+	copy(result, list)
+	return result
+}
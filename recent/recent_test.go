@@ -0,0 +1,60 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package recent tracks recently visited and recently modified zettel.
+package recent
+
+import (
+	"testing"
+
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/place"
+)
+
+func equalList(got []id.Zid, exp ...id.Zid) bool {
+	if len(got) != len(exp) {
+		return false
+	}
+	for i, zid := range got {
+		if zid != exp[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestTracker(t *testing.T) {
+	tr := New(3)
+	for _, zid := range []id.Zid{1, 2, 3, 2, 4} {
+		tr.Visit(100, zid)
+	}
+	if got := tr.Visited(100); !equalList(got, 4, 2, 3) {
+		t.Errorf("visited: expected [4 2 3], but got %v", got)
+	}
+	if got := tr.Visited(200); len(got) != 0 {
+		t.Errorf("other user: expected nothing, but got %v", got)
+	}
+
+	tr.Observe(place.OnCreate, 5)
+	tr.Observe(place.OnUpdate, 2)
+	tr.Observe(place.OnUpdate, 5)
+	tr.Observe(place.OnReload, id.Invalid)
+	if got := tr.Modified(); !equalList(got, 5, 2) {
+		t.Errorf("modified: expected [5 2], but got %v", got)
+	}
+
+	tr.Observe(place.OnDelete, 2)
+	if got := tr.Modified(); !equalList(got, 5) {
+		t.Errorf("modified after delete: expected [5], but got %v", got)
+	}
+	if got := tr.Visited(100); !equalList(got, 4, 3) {
+		t.Errorf("visited after delete: expected [4 3], but got %v", got)
+	}
+}
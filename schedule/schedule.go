@@ -0,0 +1,197 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package schedule runs maintenance jobs periodically.
+//
+// Jobs are registered by name. The interval of each job is configured in the
+// jobs zettel. Each line of the zettel has the form "<job> <interval>", e.g.
+// "reindex 24h". A job without a configured interval never runs.
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Func is the function that performs a job.
+type Func func(ctx context.Context) error
+
+// Status describes a registered job and the result of its last run.
+type Status struct {
+	Name     string
+	Interval time.Duration // Configured interval, zero if not scheduled
+	LastRun  time.Time     // Start of the last run, zero if never run
+	Duration time.Duration // Duration of the last run
+	Err      error         // Error of the last run
+}
+
+type job struct {
+	run    Func
+	status Status
+}
+
+// Scheduler runs all registered jobs, when their interval has elapsed.
+type Scheduler struct {
+	now func() time.Time
+
+	mx        sync.Mutex // protects all following fields
+	jobs      map[string]*job
+	getConfig func() string
+	started   time.Time
+	content   string
+	intervals map[string]time.Duration
+}
+
+// New creates a new scheduler without any jobs.
+func New() *Scheduler {
+	return &Scheduler{now: time.Now, jobs: make(map[string]*job)}
+}
+
+// Register adds a job with the given name.
+func (s *Scheduler) Register(name string, run Func) {
+	s.mx.Lock()
+	s.jobs[name] = &job{run: run, status: Status{Name: name}}
+	s.mx.Unlock()
+}
+
+// Start checks every tick whether a job must run, until the context is done.
+// The function getConfig returns the content of the jobs zettel.
+func (s *Scheduler) Start(ctx context.Context, getConfig func() string, tick time.Duration) {
+	s.mx.Lock()
+	s.getConfig = getConfig
+	s.started = s.now()
+	s.mx.Unlock()
+	go func() {
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.runDue(ctx)
+			}
+		}
+	}()
+}
+
+// runDue runs all jobs whose interval has elapsed since their last run, or
+// since the start of the scheduler. Jobs run one after another.
+func (s *Scheduler) runDue(ctx context.Context) {
+	s.mx.Lock()
+	s.updateIntervals()
+	now := s.now()
+	var due []*job
+	for name, j := range s.jobs {
+		interval := s.intervals[name]
+		if interval <= 0 {
+			continue
+		}
+		last := j.status.LastRun
+		if last.IsZero() {
+			last = s.started
+		}
+		if now.Sub(last) >= interval {
+			due = append(due, j)
+		}
+	}
+	s.mx.Unlock()
+	sort.Slice(due, func(i, k int) bool { return due[i].status.Name < due[k].status.Name })
+	for _, j := range due {
+		start := s.now()
+		err := j.run(ctx)
+		if err != nil {
+			log.Printf("Job %v: %v", j.status.Name, err)
+		}
+		s.mx.Lock()
+		j.status.LastRun, j.status.Duration, j.status.Err = start, s.now().Sub(start), err
+		s.mx.Unlock()
+	}
+}
+
+// updateIntervals parses the jobs zettel, if it has changed. It must be
+// called with a locked mx.
+func (s *Scheduler) updateIntervals() {
+	if s.getConfig == nil {
+		return
+	}
+	content := s.getConfig()
+	if s.intervals != nil && content == s.content {
+		return
+	}
+	intervals, errs := ParseConfig(content)
+	for _, err := range errs {
+		log.Printf("Jobs: %v", err)
+	}
+	s.content, s.intervals = content, intervals
+}
+
+// Status returns the status of all registered jobs, ordered by name.
+func (s *Scheduler) Status() []Status {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.updateIntervals()
+	result := make([]Status, 0, len(s.jobs))
+	for name, j := range s.jobs {
+		status := j.status
+		status.Interval = s.intervals[name]
+		result = append(result, status)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// ParseConfig parses the content of a jobs zettel. Each line contains a job
+// name and an interval. The interval is a Go duration like "90m" or "12h",
+// or a number of days like "7d". Empty lines and lines starting with "%" are
+// ignored. Lines that cannot be parsed are returned as errors.
+func ParseConfig(content string) (map[string]time.Duration, []error) {
+	result := make(map[string]time.Duration)
+	var errs []error
+	for i, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "%") {
+			continue
+		}
+		if len(fields) != 2 {
+			errs = append(errs, fmt.Errorf("line %d: expected job and interval", i+1))
+			continue
+		}
+		interval, err := parseInterval(fields[1])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %v", i+1, err))
+			continue
+		}
+		result[fields[0]] = interval
+	}
+	return result, errs
+}
+
+func parseInterval(s string) (time.Duration, error) {
+	var d time.Duration
+	var err error
+	if days := strings.TrimSuffix(s, "d"); days != s {
+		var n int
+		n, err = strconv.Atoi(days)
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err = time.ParseDuration(s)
+	}
+	if err != nil || d < time.Minute {
+		return 0, fmt.Errorf("invalid interval %q", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,62 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package schedule runs maintenance jobs periodically.
+package schedule
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseConfig(t *testing.T) {
+	intervals, errs := ParseConfig("% comment\n\nreindex 12h\npurge 7d\nbad\nshort 1s\n")
+	if len(intervals) != 2 || intervals["reindex"] != 12*time.Hour ||
+		intervals["purge"] != 7*24*time.Hour {
+		t.Errorf("unexpected intervals %v", intervals)
+	}
+	if len(errs) != 2 {
+		t.Errorf("expected two errors, but got %v", errs)
+	}
+}
+
+func TestRunDue(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := New()
+	s.now = func() time.Time { return now }
+	var runs int
+	s.Register("count", func(context.Context) error { runs++; return nil })
+	s.Register("fail", func(context.Context) error { return errors.New("failed") })
+	s.getConfig = func() string { return "count 1h\nfail 2h" }
+	s.started = now
+
+	s.runDue(context.Background())
+	if runs != 0 {
+		t.Errorf("job ran before its interval: %d", runs)
+	}
+	now = now.Add(time.Hour)
+	s.runDue(context.Background())
+	s.runDue(context.Background())
+	if runs != 1 {
+		t.Errorf("expected one run, but got %d", runs)
+	}
+	now = now.Add(time.Hour)
+	s.runDue(context.Background())
+	if runs != 2 {
+		t.Errorf("expected two runs, but got %d", runs)
+	}
+	status := s.Status()
+	if len(status) != 2 || status[0].Name != "count" || status[0].Interval != time.Hour ||
+		!status[0].LastRun.Equal(now) || status[1].Err == nil {
+		t.Errorf("unexpected status %v", status)
+	}
+}
@@ -0,0 +1,122 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+package template
+
+import (
+	"reflect"
+	"strings"
+)
+
+// MissingNames returns the names of all tags that cannot be resolved when
+// the template is rendered with data of the given type. The type is checked
+// in the same way Render looks up values. Names that are resolved through an
+// interface or a map cannot be checked statically, they are assumed to exist.
+func (tmpl *Template) MissingNames(typ reflect.Type) []string {
+	var missing []string
+	checkNodes(tmpl.nodes, []reflect.Type{typ}, &missing)
+	return missing
+}
+
+func checkNodes(nodes []node, stack []reflect.Type, missing *[]string) {
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case *varNode:
+			if _, ok := lookupType(stack, n.name); !ok {
+				addMissing(missing, n.name)
+			}
+		case *sectionNode:
+			typ, ok := lookupType(stack, n.name)
+			if !ok {
+				addMissing(missing, n.name)
+				continue
+			}
+			inner := stack
+			if !n.inverted {
+				if typ == nil {
+					inner = append(stack[:len(stack):len(stack)], nil)
+				} else {
+					switch it := indirectType(typ); it.Kind() {
+					case reflect.Interface:
+						inner = append(stack[:len(stack):len(stack)], nil)
+					case reflect.Slice, reflect.Array:
+						inner = append(stack[:len(stack):len(stack)], it.Elem())
+					case reflect.Map, reflect.Struct:
+						inner = append(stack[:len(stack):len(stack)], typ)
+					}
+				}
+			}
+			checkNodes(n.nodes, inner, missing)
+		}
+	}
+}
+
+func addMissing(missing *[]string, name string) {
+	for _, s := range *missing {
+		if s == name {
+			return
+		}
+	}
+	*missing = append(*missing, name)
+}
+
+// lookupType resolves the name like lookup, but only with the help of types.
+// A nil type denotes a value whose type is only known at run time.
+func lookupType(stack []reflect.Type, name string) (reflect.Type, bool) {
+	if pos := strings.IndexByte(name, '.'); pos > 0 && pos < len(name)-1 {
+		typ, ok := lookupType(stack, name[:pos])
+		if !ok {
+			return nil, false
+		}
+		return lookupType([]reflect.Type{typ}, name[pos+1:])
+	}
+
+Outer:
+	for i := len(stack) - 1; i >= 0; i-- {
+		typ := stack[i]
+		for {
+			if typ == nil {
+				return nil, true
+			}
+			for j, n := 0, typ.NumMethod(); j < n; j++ {
+				m := typ.Method(j)
+				if m.Name == name && m.Type.NumIn() == 1 {
+					return m.Type.Out(0), true
+				}
+			}
+			if name == "." {
+				return typ, true
+			}
+			switch typ.Kind() {
+			case reflect.Ptr:
+				typ = typ.Elem()
+			case reflect.Interface:
+				return nil, true
+			case reflect.Struct:
+				if f, ok := typ.FieldByName(name); ok {
+					return f.Type, true
+				}
+				continue Outer
+			case reflect.Map:
+				return typ.Elem(), true
+			default:
+				continue Outer
+			}
+		}
+	}
+	return nil, false
+}
+
+func indirectType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ
+}
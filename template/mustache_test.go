@@ -23,6 +23,7 @@ package template_test
 import (
 	"bytes"
 	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
 	"testing"
@@ -427,6 +428,41 @@ var tagTests = []tagsTest{
 	},
 }
 
+func TestMissingNames(t *testing.T) {
+	type listData struct {
+		Title string
+		Users []User
+		Extra interface{}
+		Attrs map[string]string
+	}
+	typ := reflect.TypeOf(listData{})
+	testCases := []struct {
+		tmpl    string
+		missing string
+	}{
+		{"{{Title}}", ""},
+		{"{{Titel}}", "Titel"},
+		{"{{#Users}}{{Name}} {{Func1}}{{/Users}}", ""},
+		{"{{#Users}}{{Func2}} {{Title}}{{/Users}}", "Func2"},
+		{"{{#Users}}{{Nmae}}{{/Users}}{{^Users}}{{Nmae}}{{/Users}}", "Nmae"},
+		{"{{#Extra}}{{Whatever}}{{/Extra}}{{Extra.Any}}", ""},
+		{"{{Attrs.key}} {{#Attrs}}{{x}}{{/Attrs}}", ""},
+		{"{{#Usres}}{{Name}}{{/Usres}}", "Usres"},
+		{"{{Title.Len}}", "Title.Len"},
+	}
+	for _, tc := range testCases {
+		tmpl, err := parseString(tc.tmpl)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		got := strings.Join(tmpl.MissingNames(typ), " ")
+		if got != tc.missing {
+			t.Errorf("%q: expected missing %q, but got %q", tc.tmpl, tc.missing, got)
+		}
+	}
+}
+
 func TestTags(t *testing.T) {
 	for _, test := range tagTests {
 		testTags(t, &test)
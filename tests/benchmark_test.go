@@ -0,0 +1,85 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package tests provides some higher-level tests.
+package tests
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"zettelstore.de/z/ast"
+	"zettelstore.de/z/encoder"
+	"zettelstore.de/z/input"
+	"zettelstore.de/z/parser"
+)
+
+// benchContent returns representative zettel contents of about the given size.
+func benchContent() map[string]string {
+	var list, nested, table, prose strings.Builder
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&list, "* Item %d with **bold** text and a [[link|%014d]]\n", i, i)
+	}
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&nested, "%s Level %d with //emphasis//\n", strings.Repeat("*", i%7+1), i)
+		fmt.Fprintf(&nested, "%s Quote %d\n", strings.Repeat(">", i%5+1), i)
+	}
+	table.WriteString("|=Name|=Value|=Description\n")
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&table, "|Name %d|%d|Some ''text'' in cell %d\n", i, i*i, i)
+	}
+	for i := 0; i < 500; i++ {
+		fmt.Fprintf(&prose, "=== Heading %d\n", i)
+		prose.WriteString("This is a paragraph with some text, a footnote[^Note] and an ")
+		prose.WriteString("entity &amp; --- and a literal ``x := y``...\n")
+		prose.WriteString("It continues on the next line with ##marked## text.\n\n")
+	}
+	return map[string]string{
+		"list":   list.String(),
+		"nested": nested.String(),
+		"table":  table.String(),
+		"prose":  prose.String(),
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	for name, content := range benchContent() {
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(content)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				parser.ParseBlocks(input.NewInput(content), nil, "zmk")
+			}
+		})
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	for name, content := range benchContent() {
+		bs := parser.ParseBlocks(input.NewInput(content), nil, "zmk")
+		for _, format := range formats {
+			b.Run(name+"/"+format, func(b *testing.B) {
+				benchmarkEncode(b, bs, format)
+			})
+		}
+	}
+}
+
+func benchmarkEncode(b *testing.B, bs ast.BlockSlice, format string) {
+	enc := encoder.Create(format)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := enc.WriteBlocks(ioutil.Discard, bs); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
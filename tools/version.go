@@ -40,6 +40,14 @@ func readFossilVersion() (string, error) {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "-commit" {
+		fossil, err := readFossilVersion()
+		if err != nil {
+			os.Exit(1)
+		}
+		fmt.Print(fossil)
+		return
+	}
 	base, err := readVersionFile()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "No VERSION found: %v\n", err)
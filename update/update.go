@@ -0,0 +1,162 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package update checks whether a newer version of the software was released.
+//
+// The release feed is a plain text document, whose first non-empty line
+// contains the version of the latest release, like the file VERSION of the
+// source code.
+package update
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Status is the result of the last check.
+type Status struct {
+	Checked time.Time // Time of the last check, zero if not checked
+	Latest  string    // Version of the latest release
+	Newer   bool      // Latest release is newer than the current version
+	Err     error     // Error of the last check
+}
+
+// Checker retrieves the release feed periodically.
+type Checker struct {
+	url     string
+	current string
+	client  *http.Client
+
+	mx     sync.RWMutex // protects status
+	status Status
+}
+
+// New creates a new checker for the given release feed. The current version
+// is compared with the latest released version.
+func New(url, current string) *Checker {
+	return &Checker{
+		url:     url,
+		current: current,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Start checks the release feed now and after every interval, until the
+// context is done.
+func (c *Checker) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			c.Check(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Check retrieves the release feed and returns the new status.
+func (c *Checker) Check(ctx context.Context) Status {
+	latest, err := c.fetch(ctx)
+	status := Status{Checked: time.Now(), Latest: latest, Err: err}
+	if err == nil {
+		status.Newer = Compare(latest, c.current) > 0
+	}
+	c.mx.Lock()
+	c.status = status
+	c.mx.Unlock()
+	return status
+}
+
+func (c *Checker) fetch(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("release feed returned %v", resp.Status)
+	}
+	scanner := bufio.NewScanner(io.LimitReader(resp.Body, 4096))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			return line, nil
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.New("release feed contains no version")
+}
+
+// Status returns the result of the last check.
+func (c *Checker) Status() Status {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+	return c.status
+}
+
+// Compare returns a negative number, if version a is older than version b,
+// a positive number, if it is newer, and zero otherwise. A version consists
+// of numbers, separated by a dot, optionally followed by a dash and some
+// pre-release or build information. A version with such information is
+// older than the same version without it.
+func Compare(a, b string) int {
+	numsA, extA := splitVersion(a)
+	numsB, extB := splitVersion(b)
+	for i := 0; i < len(numsA) || i < len(numsB); i++ {
+		var na, nb int
+		if i < len(numsA) {
+			na = numsA[i]
+		}
+		if i < len(numsB) {
+			nb = numsB[i]
+		}
+		if na != nb {
+			return na - nb
+		}
+	}
+	switch {
+	case !extA && extB:
+		return 1
+	case extA && !extB:
+		return -1
+	}
+	return 0
+}
+
+func splitVersion(v string) (nums []int, hasExt bool) {
+	if pos := strings.IndexByte(v, '-'); pos >= 0 {
+		v, hasExt = v[:pos], true
+	}
+	for _, s := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(strings.TrimPrefix(s, "v"))
+		if err != nil {
+			break
+		}
+		nums = append(nums, n)
+	}
+	return nums, hasExt
+}
@@ -0,0 +1,76 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package update checks whether a newer version of the software was released.
+package update
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	testCases := []struct {
+		a, b string
+		exp  int
+	}{
+		{"0.0.9", "0.0.9", 0},
+		{"0.0.10", "0.0.9", 1},
+		{"0.0.9", "0.0.10", -1},
+		{"0.1", "0.0.10", 1},
+		{"0.0.9", "0.0.9-dev", 1},
+		{"0.0.9-dev-abc", "0.0.9", -1},
+		{"0.0.9-dev", "0.0.9-dev-abc", 0},
+		{"v1.0", "1.0.0", 0},
+	}
+	for _, tc := range testCases {
+		got := Compare(tc.a, tc.b)
+		if got > 0 {
+			got = 1
+		} else if got < 0 {
+			got = -1
+		}
+		if got != tc.exp {
+			t.Errorf("Compare(%q, %q) should be %v, but got %v", tc.a, tc.b, tc.exp, got)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	latest := "0.0.10"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if latest == "" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, "\n%v\nignored\n", latest)
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, "0.0.9-dev-abc")
+	if st := c.Status(); !st.Checked.IsZero() {
+		t.Errorf("checker without check has status %v", st)
+	}
+	st := c.Check(context.Background())
+	if st.Err != nil || st.Latest != latest || !st.Newer || st.Checked.IsZero() {
+		t.Errorf("unexpected status %v", st)
+	}
+	latest = ""
+	st = c.Check(context.Background())
+	if st.Err == nil || st.Newer {
+		t.Errorf("error expected, but got status %v", st)
+	}
+	if c.Status() != st {
+		t.Errorf("last status not stored: %v", c.Status())
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"math/rand"
 	"time"
 
+	"zettelstore.de/z/audit"
 	"zettelstore.de/z/auth/cred"
 	"zettelstore.de/z/auth/token"
 	"zettelstore.de/z/domain/id"
@@ -43,13 +44,22 @@ func NewAuthenticate(port AuthenticatePort) Authenticate {
 	}
 }
 
-// Run executes the use case.
-func (uc Authenticate) Run(ctx context.Context, ident string, credential string, d time.Duration, k token.Kind) ([]byte, error) {
+// Run executes the use case. The token grants access only within the given
+// scope.
+func (uc Authenticate) Run(
+	ctx context.Context,
+	ident string,
+	credential string,
+	d time.Duration,
+	k token.Kind,
+	scope token.Scope,
+) ([]byte, error) {
 	identMeta, err := uc.ucGetUser.Run(ctx, ident)
 	defer addDelay(time.Now(), 500*time.Millisecond, 100*time.Millisecond)
 
 	if identMeta == nil || err != nil {
 		compensateCompare()
+		audit.RecordIdent(audit.EventLoginFailed, ident, "unknown user")
 		return nil, err
 	}
 
@@ -59,15 +69,18 @@ func (uc Authenticate) Run(ctx context.Context, ident string, credential string,
 			return nil, err
 		}
 		if ok {
-			token, err := token.GetToken(identMeta, d, k)
+			token, err := token.GetScopedToken(identMeta, d, k, scope)
 			if err != nil {
 				return nil, err
 			}
+			audit.Record(audit.EventLogin, identMeta, identMeta.Zid, "")
 			return token, nil
 		}
+		audit.Record(audit.EventLoginFailed, identMeta, identMeta.Zid, "wrong credential")
 		return nil, nil
 	}
 	compensateCompare()
+	audit.Record(audit.EventLoginFailed, identMeta, identMeta.Zid, "no credential")
 	return nil, nil
 }
 
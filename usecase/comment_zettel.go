@@ -0,0 +1,77 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package usecase provides (business) use cases for the zettelstore.
+package usecase
+
+import (
+	"context"
+	"strings"
+
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/place"
+)
+
+// CommentZettelPort is the interface used by this use case.
+type CommentZettelPort interface {
+	// GetMeta retrieves just the meta data of a specific zettel.
+	GetMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error)
+}
+
+// CommentZettel is the data for this use case.
+type CommentZettel struct {
+	port   CommentZettelPort
+	create CreateZettel
+}
+
+// NewCommentZettel creates a new use case. Comments are created like other
+// zettel, so that the size and the quota of the user are checked.
+func NewCommentZettel(port CommentZettelPort, create CreateZettel) CommentZettel {
+	return CommentZettel{port: port, create: create}
+}
+
+// NewCommentMeta returns the meta data of a new comment on the given zettel,
+// written by the given user. The comment has the same visibility as the
+// zettel.
+func NewCommentMeta(target, user *meta.Meta) *meta.Meta {
+	m := meta.New(id.Invalid)
+	m.Set(meta.KeyTitle, "Comment on "+target.GetDefault(meta.KeyTitle, target.Zid.String()))
+	m.Set(meta.KeyRole, meta.ValueRoleComment)
+	m.Set(meta.KeySyntax, meta.ValueSyntaxZmk)
+	m.Set(meta.KeyPrecursor, target.Zid.String())
+	if user != nil {
+		m.Set(meta.KeyAuthor, user.GetDefault(meta.KeyUserID, user.Zid.String()))
+	}
+	if vis, ok := target.Get(meta.KeyVisibility); ok {
+		m.Set(meta.KeyVisibility, vis)
+	}
+	return m
+}
+
+// Run executes the use case. Only authenticated users may comment. The
+// policy decides, whether the user is allowed to create the comment zettel.
+func (uc CommentZettel) Run(
+	ctx context.Context, user *meta.Meta, zid id.Zid, content string) (id.Zid, error) {
+	if user == nil {
+		return id.Invalid, place.NewErrNotAllowed("Comment", user, zid)
+	}
+	target, err := uc.port.GetMeta(ctx, zid)
+	if err != nil {
+		return id.Invalid, err
+	}
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return id.Invalid, nil
+	}
+	m := NewCommentMeta(target, user)
+	return uc.create.Run(ctx, user, domain.Zettel{Meta: m, Content: domain.NewContent(content)})
+}
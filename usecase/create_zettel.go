@@ -13,8 +13,11 @@ package usecase
 
 import (
 	"context"
+	"io"
+	"io/ioutil"
 
 	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/config/startup"
 	"zettelstore.de/z/domain"
 	"zettelstore.de/z/domain/id"
 	"zettelstore.de/z/domain/meta"
@@ -24,24 +27,87 @@ import (
 type CreateZettelPort interface {
 	// CreateZettel creates a new zettel.
 	CreateZettel(ctx context.Context, zettel domain.Zettel) (id.Zid, error)
+
+	// CreateZettelFrom creates a new zettel, whose content is read from the
+	// given reader.
+	CreateZettelFrom(ctx context.Context, m *meta.Meta, r io.Reader) (id.Zid, error)
 }
 
 // CreateZettel is the data for this use case.
 type CreateZettel struct {
-	port CreateZettelPort
+	port   CreateZettelPort
+	linter TemplateLinter
+	quota  Quota
 }
 
 // NewCreateZettel creates a new use case.
-func NewCreateZettel(port CreateZettelPort) CreateZettel {
-	return CreateZettel{port: port}
+func NewCreateZettel(port CreateZettelPort, linter TemplateLinter, quota Quota) CreateZettel {
+	return CreateZettel{port: port, linter: linter, quota: quota}
 }
 
-// Run executes the use case.
-func (uc CreateZettel) Run(ctx context.Context, zettel domain.Zettel) (id.Zid, error) {
+// Run executes the use case. The given user is stored as the creator of the
+// zettel, whose quota must not be exceeded.
+func (uc CreateZettel) Run(
+	ctx context.Context, user *meta.Meta, zettel domain.Zettel) (id.Zid, error) {
 	m := zettel.Meta
 	if m.Zid.IsValid() {
 		return m.Zid, nil // TODO: new error: already exists
 	}
+	setNewMeta(m, user)
+	if err := checkZettelSize(zettel); err != nil {
+		return id.Invalid, err
+	}
+	if err := lintTemplate(uc.linter, zettel); err != nil {
+		return id.Invalid, err
+	}
+	if err := uc.quota.check(ctx, zettel, true); err != nil {
+		return id.Invalid, err
+	}
+
+	return uc.port.CreateZettel(ctx, zettel)
+}
+
+// RunFrom executes the use case, but the content of the zettel is read from
+// the given reader. Size and quota are checked while reading, so that a large
+// content is never stored in memory. Only templates are read fully, because
+// they must be linted before they are stored.
+func (uc CreateZettel) RunFrom(
+	ctx context.Context, user *meta.Meta, m *meta.Meta, r io.Reader) (id.Zid, error) {
+	if m.Zid.IsValid() {
+		return m.Zid, nil // TODO: new error: already exists
+	}
+	setNewMeta(m, user)
+	creator, usage, err := uc.quota.creatorUsage(ctx, m, true)
+	if err != nil {
+		return id.Invalid, err
+	}
+	usage.Zettel++
+	if creator.IsValid() && usage.exceeded() {
+		return id.Invalid, &ErrQuotaExceeded{User: creator, Usage: usage}
+	}
+	sr := &sizeReader{r: r, max: startup.MaxZettelSize(), creator: creator, usage: usage}
+
+	if syntax, _ := m.Get(meta.KeySyntax); syntax == meta.ValueSyntaxMustache {
+		content, err := ioutil.ReadAll(sr)
+		if err != nil {
+			return id.Invalid, err
+		}
+		zettel := domain.Zettel{Meta: m, Content: domain.NewContent(string(content))}
+		if err = lintTemplate(uc.linter, zettel); err != nil {
+			return id.Invalid, err
+		}
+		return uc.port.CreateZettel(ctx, zettel)
+	}
+	return uc.port.CreateZettelFrom(ctx, m, sr)
+}
+
+// setNewMeta sets the creator and the default values of a new zettel.
+func setNewMeta(m *meta.Meta, user *meta.Meta) {
+	if user != nil {
+		m.Set(meta.KeyCreator, user.Zid.String())
+	} else {
+		m.Delete(meta.KeyCreator)
+	}
 
 	if title, ok := m.Get(meta.KeyTitle); !ok || title == "" {
 		m.Set(meta.KeyTitle, runtime.GetDefaultTitle())
@@ -53,6 +119,4 @@ func (uc CreateZettel) Run(ctx context.Context, zettel domain.Zettel) (id.Zid, e
 		m.Set(meta.KeySyntax, runtime.GetDefaultSyntax())
 	}
 	m.YamlSep = runtime.GetYAMLHeader()
-
-	return uc.port.CreateZettel(ctx, zettel)
 }
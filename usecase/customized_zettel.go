@@ -0,0 +1,106 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package usecase provides (business) use cases for the zettelstore.
+package usecase
+
+import (
+	"context"
+
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/place"
+)
+
+// CustomizedZettel describes a built-in zettel together with its current
+// version, which may be customized by the user.
+type CustomizedZettel struct {
+	Meta       *meta.Meta
+	Builtin    string
+	Content    string
+	Customized bool
+}
+
+// ListCustomizedPort is the interface used by this use case.
+type ListCustomizedPort interface {
+	// GetZettel retrieves a specific zettel.
+	GetZettel(ctx context.Context, zid id.Zid) (domain.Zettel, error)
+}
+
+// ListCustomized is the data for this use case.
+type ListCustomized struct {
+	port    ListCustomizedPort
+	builtin []domain.Zettel
+}
+
+// NewListCustomized creates a new use case. The builtin zettel are the ones
+// that may be customized.
+func NewListCustomized(port ListCustomizedPort, builtin []domain.Zettel) ListCustomized {
+	return ListCustomized{port: port, builtin: builtin}
+}
+
+// Run executes the use case. Zettel the user is not allowed to read are
+// ignored.
+func (uc ListCustomized) Run(ctx context.Context) ([]CustomizedZettel, error) {
+	result := make([]CustomizedZettel, 0, len(uc.builtin))
+	for _, bz := range uc.builtin {
+		zettel, err := uc.port.GetZettel(ctx, bz.Meta.Zid)
+		if err != nil {
+			if _, ok := err.(*place.ErrNotAllowed); ok || err == place.ErrNotFound {
+				continue
+			}
+			return nil, err
+		}
+		builtin, content := bz.Content.AsString(), zettel.Content.AsString()
+		result = append(result, CustomizedZettel{
+			Meta:       zettel.Meta,
+			Builtin:    builtin,
+			Content:    content,
+			Customized: builtin != content,
+		})
+	}
+	return result, nil
+}
+
+// ResetZettelPort is the interface used by this use case.
+type ResetZettelPort interface {
+	// DeleteZettel removes the zettel from the place.
+	DeleteZettel(ctx context.Context, zid id.Zid) error
+}
+
+// ResetZettel is the data for this use case.
+type ResetZettel struct {
+	port    ResetZettelPort
+	builtin []domain.Zettel
+}
+
+// NewResetZettel creates a new use case. Only the builtin zettel can be
+// reset.
+func NewResetZettel(port ResetZettelPort, builtin []domain.Zettel) ResetZettel {
+	return ResetZettel{port: port, builtin: builtin}
+}
+
+// Run executes the use case. It removes the customized version of a built-in
+// zettel, so that the built-in version is used again. If there is no such
+// built-in zettel, place.ErrNotFound is returned.
+func (uc ResetZettel) Run(ctx context.Context, zid id.Zid) error {
+	for _, bz := range uc.builtin {
+		if bz.Meta.Zid == zid {
+			err := uc.port.DeleteZettel(ctx, zid)
+			if err == place.ErrNotFound {
+				// There was no customized version.
+				return nil
+			}
+			return err
+		}
+	}
+	return place.ErrNotFound
+}
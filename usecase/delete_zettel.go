@@ -13,8 +13,14 @@ package usecase
 
 import (
 	"context"
+	"regexp"
+	"strings"
 
+	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/domain"
 	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/place"
 )
 
 // DeleteZettelPort is the interface used by this use case.
@@ -37,3 +43,175 @@ func NewDeleteZettel(port DeleteZettelPort) DeleteZettel {
 func (uc DeleteZettel) Run(ctx context.Context, zid id.Zid) error {
 	return uc.port.DeleteZettel(ctx, zid)
 }
+
+// ListBacklinksPort is the interface used by this use case.
+type ListBacklinksPort interface {
+	// SelectBacklinks returns the meta data of all zettel that link to the
+	// zettel with the given identifier.
+	SelectBacklinks(ctx context.Context, zid id.Zid) ([]*meta.Meta, error)
+}
+
+// ListBacklinks is the data for this use case.
+type ListBacklinks struct {
+	port ListBacklinksPort
+}
+
+// NewListBacklinks creates a new use case.
+func NewListBacklinks(port ListBacklinksPort) ListBacklinks {
+	return ListBacklinks{port: port}
+}
+
+// Run executes the use case.
+func (uc ListBacklinks) Run(ctx context.Context, zid id.Zid) ([]*meta.Meta, error) {
+	return uc.port.SelectBacklinks(ctx, zid)
+}
+
+// LinkAction specifies what happens to the links that refer to a deleted
+// zettel.
+type LinkAction int
+
+// Values for LinkAction
+const (
+	LinksKeep     LinkAction = iota // Links are left broken
+	LinksRemove                     // Links are replaced by their text
+	LinksRedirect                   // Links refer to a replacement zettel
+)
+
+// DeleteWithLinksPort is the interface used by this use case.
+type DeleteWithLinksPort interface {
+	// GetZettel retrieves a specific zettel.
+	GetZettel(ctx context.Context, zid id.Zid) (domain.Zettel, error)
+
+	// GetMeta retrieves just the meta data of a specific zettel.
+	GetMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error)
+
+	// UpdateZettel updates an existing zettel.
+	UpdateZettel(ctx context.Context, zettel domain.Zettel) error
+
+	// DeleteZettel removes the zettel from the place.
+	DeleteZettel(ctx context.Context, zid id.Zid) error
+}
+
+// DeleteWithLinks is the data for this use case.
+type DeleteWithLinks struct {
+	port      DeleteWithLinksPort
+	backlinks ListBacklinks
+}
+
+// NewDeleteWithLinks creates a new use case.
+func NewDeleteWithLinks(port DeleteWithLinksPort, backlinks ListBacklinks) DeleteWithLinks {
+	return DeleteWithLinks{port: port, backlinks: backlinks}
+}
+
+// Run executes the use case. It deletes a zettel and changes the links of all
+// zettel that refer to it, according to the given action. Either all
+// zettel are changed and the zettel is deleted, or nothing is changed.
+func (uc DeleteWithLinks) Run(
+	ctx context.Context, zid id.Zid, action LinkAction, replacement id.Zid) error {
+	if action == LinksRedirect {
+		if replacement == zid {
+			return &place.ErrInvalidID{Zid: replacement}
+		}
+		if _, err := uc.port.GetMeta(ctx, replacement); err != nil {
+			if err == place.ErrNotFound {
+				return &place.ErrInvalidID{Zid: replacement}
+			}
+			return err
+		}
+	} else {
+		replacement = id.Invalid
+	}
+
+	var originals []domain.Zettel
+	if action != LinksKeep {
+		backlinks, err := uc.backlinks.Run(ctx, zid)
+		if err != nil {
+			return err
+		}
+		for _, m := range backlinks {
+			zettel, err := uc.port.GetZettel(ctx, m.Zid)
+			if err != nil {
+				uc.rollback(ctx, originals)
+				return err
+			}
+			content := zettel.Content.AsString()
+			newContent := rewriteLinks(content, runtime.GetSyntax(zettel.Meta), zid, replacement)
+			if newContent == content {
+				continue
+			}
+			nm := zettel.Meta.Clone()
+			nm.SetNow(meta.KeyModified)
+			if err = uc.port.UpdateZettel(
+				ctx, domain.Zettel{Meta: nm, Content: domain.NewContent(newContent)}); err != nil {
+				uc.rollback(ctx, originals)
+				return err
+			}
+			originals = append(originals, zettel)
+		}
+	}
+	if err := uc.port.DeleteZettel(ctx, zid); err != nil {
+		uc.rollback(ctx, originals)
+		return err
+	}
+	return nil
+}
+
+// rollback restores all changed zettel.
+func (uc DeleteWithLinks) rollback(ctx context.Context, originals []domain.Zettel) {
+	for i := len(originals) - 1; i >= 0; i-- {
+		uc.port.UpdateZettel(ctx, originals[i])
+	}
+}
+
+// linkPattern describes how links to a zettel look like in some syntax.
+type linkPattern struct {
+	re      *regexp.Regexp
+	textPos int // submatch of the link text, or -1 if there is no text
+	zidPos  int // submatch of the zettel identifier
+}
+
+// rewriteLinks changes all links and images of the content that refer to
+// the zettel zid. If replacement is valid, they refer to replacement
+// afterwards. Otherwise links are replaced by their text and images are
+// removed. Only Zettelmarkup and Markdown are supported.
+func rewriteLinks(content, syntax string, zid, replacement id.Zid) string {
+	s := regexp.QuoteMeta(zid.String())
+	var patterns []linkPattern
+	switch syntax {
+	case meta.ValueSyntaxZmk:
+		patterns = []linkPattern{
+			{re: regexp.MustCompile(`\[\[(?:([^\]|]*)\|)?(` + s + `)(?:#[^\]]*)?\]\]`), textPos: 1, zidPos: 2},
+			{re: regexp.MustCompile(`\{\{(?:[^}|]*\|)?(` + s + `)\}\}`), textPos: -1, zidPos: 1},
+		}
+	case "markdown", "md":
+		patterns = []linkPattern{
+			{re: regexp.MustCompile(`!\[[^\]]*\]\((` + s + `)(?:#[^)\s]*)?(?:\s+"[^"]*")?\)`), textPos: -1, zidPos: 1},
+			{re: regexp.MustCompile(`\[([^\]]*)\]\((` + s + `)(?:#[^)\s]*)?(?:\s+"[^"]*")?\)`), textPos: 1, zidPos: 2},
+		}
+	default:
+		return content
+	}
+	for _, p := range patterns {
+		content = p.rewrite(content, replacement)
+	}
+	return content
+}
+
+func (p linkPattern) rewrite(content string, replacement id.Zid) string {
+	var sb strings.Builder
+	last := 0
+	for _, loc := range p.re.FindAllStringSubmatchIndex(content, -1) {
+		sb.WriteString(content[last:loc[0]])
+		switch {
+		case replacement.IsValid():
+			sb.WriteString(content[loc[0]:loc[2*p.zidPos]])
+			sb.WriteString(replacement.String())
+			sb.WriteString(content[loc[2*p.zidPos+1]:loc[1]])
+		case p.textPos >= 0 && loc[2*p.textPos] >= 0:
+			sb.WriteString(content[loc[2*p.textPos]:loc[2*p.textPos+1]])
+		}
+		last = loc[1]
+	}
+	sb.WriteString(content[last:])
+	return sb.String()
+}
@@ -0,0 +1,131 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package usecase provides (business) use cases for the zettelstore.
+package usecase
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/place"
+)
+
+// Flags that a user may set on a zettel.
+const (
+	FlagFavorite  = "favorite"
+	FlagReadLater = "read-later"
+)
+
+// flagKeys maps a flag to the key of the user zettel that stores the
+// identifiers of all flagged zettel.
+var flagKeys = map[string]string{
+	FlagFavorite:  meta.KeyFavorites,
+	FlagReadLater: meta.KeyReadLater,
+}
+
+// GetFlagged returns the identifiers of all zettel that the given user has
+// flagged with the given flag.
+func GetFlagged(user *meta.Meta, flag string) []id.Zid {
+	key, ok := flagKeys[flag]
+	if user == nil || !ok {
+		return nil
+	}
+	vals, _ := user.GetList(key)
+	result := make([]id.Zid, 0, len(vals))
+	for _, val := range vals {
+		if zid, err := id.Parse(val); err == nil {
+			result = append(result, zid)
+		}
+	}
+	return result
+}
+
+// IsFlagged returns true, if the given user has flagged the given zettel.
+func IsFlagged(user *meta.Meta, flag string, zid id.Zid) bool {
+	for _, fzid := range GetFlagged(user, flag) {
+		if fzid == zid {
+			return true
+		}
+	}
+	return false
+}
+
+// FlagZettelPort is the interface used by this use case.
+type FlagZettelPort interface {
+	// GetZettel retrieves a specific zettel.
+	GetZettel(ctx context.Context, zid id.Zid) (domain.Zettel, error)
+
+	// UpdateZettel updates an existing zettel.
+	UpdateZettel(ctx context.Context, zettel domain.Zettel) error
+}
+
+// FlagZettel is the data for this use case.
+type FlagZettel struct {
+	port    FlagZettelPort
+	getMeta GetMeta
+	mx      *sync.Mutex // serializes the changes of user zettel
+}
+
+// NewFlagZettel creates a new use case. The flags are stored in the user
+// zettel, which is retrieved and updated by the port without checking any
+// policy. This allows to flag read-only zettel. Therefore, the caller must
+// ensure that the user is allowed to change zettel at all, e.g. that the user
+// was not authenticated by a read-only token.
+func NewFlagZettel(port FlagZettelPort, getMeta GetMeta) FlagZettel {
+	return FlagZettel{port: port, getMeta: getMeta, mx: new(sync.Mutex)}
+}
+
+// Run executes the use case. It sets or removes the flag of a zettel that
+// the user is allowed to read. The flagged zettel is never changed.
+func (uc FlagZettel) Run(
+	ctx context.Context, user *meta.Meta, zid id.Zid, flag string, set bool) error {
+	key, ok := flagKeys[flag]
+	if user == nil || !ok {
+		return place.NewErrNotAllowed("Flag", user, zid)
+	}
+	if _, err := uc.getMeta.Run(ctx, zid); err != nil {
+		return err
+	}
+
+	// Concurrent requests must not lose the flags set by each other.
+	uc.mx.Lock()
+	defer uc.mx.Unlock()
+	userZettel, err := uc.port.GetZettel(ctx, user.Zid)
+	if err != nil {
+		return err
+	}
+	m := userZettel.Meta.Clone()
+	vals := make([]string, 0, 8)
+	found := false
+	for _, fzid := range GetFlagged(m, flag) {
+		if fzid == zid {
+			found = true
+			continue
+		}
+		vals = append(vals, fzid.String())
+	}
+	if found == set {
+		return nil
+	}
+	if set {
+		vals = append(vals, zid.String())
+	}
+	if len(vals) == 0 {
+		m.Delete(key)
+	} else {
+		m.Set(key, strings.Join(vals, " "))
+	}
+	return uc.port.UpdateZettel(ctx, domain.Zettel{Meta: m, Content: userZettel.Content})
+}
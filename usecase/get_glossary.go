@@ -0,0 +1,104 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package usecase provides (business) use cases for the zettelstore.
+package usecase
+
+import (
+	"context"
+	"strings"
+
+	"zettelstore.de/z/ast"
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/encoder"
+	"zettelstore.de/z/parser"
+	"zettelstore.de/z/place"
+)
+
+// GetGlossaryPort is the interface used by this use case.
+type GetGlossaryPort interface {
+	// SelectMeta returns all zettel meta data that match the selection
+	// criteria.
+	SelectMeta(ctx context.Context, f *place.Filter, s *place.Sorter) ([]*meta.Meta, error)
+
+	// GetZettel retrieves a specific zettel.
+	GetZettel(ctx context.Context, zid id.Zid) (domain.Zettel, error)
+}
+
+// GetGlossary is the data for this use case.
+type GetGlossary struct {
+	port GetGlossaryPort
+}
+
+// NewGetGlossary creates a new use case.
+func NewGetGlossary(port GetGlossaryPort) GetGlossary {
+	return GetGlossary{port: port}
+}
+
+// Run executes the use case. It returns a map of abbreviations to their
+// expansions. They are defined by the description lists of all zettel with
+// the role "glossary": each term is an abbreviation, the first paragraph of
+// its description is the expansion.
+func (uc GetGlossary) Run(ctx context.Context) (map[string]string, error) {
+	f := &place.Filter{
+		Expr: place.FilterExpr{meta.KeyRole: []string{meta.ValueRoleGlossary}},
+	}
+	metaList, err := uc.port.SelectMeta(ctx, f, nil)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]string)
+	for _, m := range metaList {
+		if role, ok := m.Get(meta.KeyRole); !ok || role != meta.ValueRoleGlossary {
+			continue
+		}
+		zettel, err := uc.port.GetZettel(ctx, m.Zid)
+		if err != nil {
+			continue
+		}
+		for _, bn := range parser.ParseZettel(zettel, "").Ast {
+			if dn, ok := bn.(*ast.DescriptionListNode); ok {
+				addGlossaryEntries(result, dn)
+			}
+		}
+	}
+	return result, nil
+}
+
+func addGlossaryEntries(glossary map[string]string, dn *ast.DescriptionListNode) {
+	for _, descr := range dn.Descriptions {
+		abbr := inlineText(descr.Term)
+		if abbr == "" || len(descr.Descriptions) == 0 {
+			continue
+		}
+		for _, dn := range descr.Descriptions[0] {
+			if pn, ok := dn.(*ast.ParaNode); ok {
+				if expansion := inlineText(pn.Inlines); expansion != "" {
+					glossary[abbr] = expansion
+				}
+				break
+			}
+		}
+	}
+}
+
+func inlineText(ins ast.InlineSlice) string {
+	enc := encoder.Create("text")
+	if enc == nil {
+		return ""
+	}
+	var sb strings.Builder
+	if _, err := enc.WriteInlines(&sb, ins); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(sb.String())
+}
@@ -0,0 +1,38 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package usecase provides (business) use cases for the zettelstore.
+package usecase
+
+import (
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/lock"
+)
+
+// GetLockPort is the interface used by this use case.
+type GetLockPort interface {
+	// Get returns the lock of the given zettel, if there is one.
+	Get(zid id.Zid) (lock.Lock, bool)
+}
+
+// GetLock is the data for this use case.
+type GetLock struct {
+	port GetLockPort
+}
+
+// NewGetLock creates a new use case.
+func NewGetLock(port GetLockPort) GetLock {
+	return GetLock{port: port}
+}
+
+// Run executes the use case.
+func (uc GetLock) Run(zid id.Zid) (lock.Lock, bool) {
+	return uc.port.Get(zid)
+}
@@ -0,0 +1,55 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package usecase provides (business) use cases for the zettelstore.
+package usecase
+
+import (
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+)
+
+// TemplateLinter checks the content of a template zettel before it is stored.
+type TemplateLinter interface {
+	// LintTemplate returns an error, if the given content cannot be used as
+	// the template with the given identifier.
+	LintTemplate(zid id.Zid, content string) error
+}
+
+// ErrInvalidTemplate is returned if the content of a template zettel is not
+// usable.
+type ErrInvalidTemplate struct {
+	Zid id.Zid
+	Err error
+}
+
+func (err *ErrInvalidTemplate) Error() string {
+	if err.Zid.IsValid() {
+		return "Template " + err.Zid.String() + " is invalid: " + err.Err.Error()
+	}
+	return "Template is invalid: " + err.Err.Error()
+}
+
+// lintTemplate checks the zettel, if it is a template zettel. The check is
+// skipped, if no linter was given.
+func lintTemplate(linter TemplateLinter, zettel domain.Zettel) error {
+	if linter == nil {
+		return nil
+	}
+	m := zettel.Meta
+	if syntax, ok := m.Get(meta.KeySyntax); !ok || syntax != meta.ValueSyntaxMustache {
+		return nil
+	}
+	if err := linter.LintTemplate(m.Zid, zettel.Content.AsString()); err != nil {
+		return &ErrInvalidTemplate{Zid: m.Zid, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package usecase provides (business) use cases for the zettelstore.
+package usecase
+
+import (
+	"context"
+	"sort"
+
+	"zettelstore.de/z/ast"
+	"zettelstore.de/z/collect"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/place"
+)
+
+// Citation describes a literature zettel within the citation graph.
+type Citation struct {
+	Meta    *meta.Meta   // the literature zettel
+	CitedBy []*meta.Meta // all zettel that cite the literature
+	Cites   []*meta.Meta // all literature zettel cited by the literature
+}
+
+// ListCitationsPort is the interface used by this use case.
+type ListCitationsPort interface {
+	// SelectMeta returns all zettel meta data that match the selection
+	// criteria. The result is ordered by descending zettel id.
+	SelectMeta(ctx context.Context, f *place.Filter, s *place.Sorter) ([]*meta.Meta, error)
+}
+
+// ListCitations is the data for this use case.
+type ListCitations struct {
+	port        ListCitationsPort
+	parseZettel ParseZettel
+}
+
+// NewListCitations creates a new use case.
+func NewListCitations(port ListCitationsPort, parseZettel ParseZettel) ListCitations {
+	return ListCitations{port: port, parseZettel: parseZettel}
+}
+
+// Run executes the use case. It returns all zettel with role "literature",
+// together with the zettel that cite them. A zettel cites a literature
+// zettel, if it contains a citation with the value of the key "cite-key" or
+// the identifier of the literature zettel, or if it links to the literature
+// zettel. The most cited literature comes first.
+func (uc ListCitations) Run(ctx context.Context) ([]Citation, error) {
+	metaList, err := uc.port.SelectMeta(ctx, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	literature := make(map[id.Zid]*Citation)
+	keys := make(map[string]id.Zid)
+	for _, m := range metaList {
+		if m.GetDefault(meta.KeyRole, "") != meta.ValueRoleLiterature {
+			continue
+		}
+		literature[m.Zid] = &Citation{Meta: m}
+		keys[m.Zid.String()] = m.Zid
+		if key, ok := m.Get(meta.KeyCiteKey); ok && key != "" {
+			keys[key] = m.Zid
+		}
+	}
+	if len(literature) == 0 {
+		return nil, nil
+	}
+	for _, m := range metaList {
+		if m.GetDefault(meta.KeySyntax, "") == meta.ValueSyntaxNone {
+			continue
+		}
+		zn, err := uc.parseZettel.Run(ctx, m.Zid, "")
+		if err != nil || zn.Zettel.Content.IsBinary() {
+			continue
+		}
+		for zid := range citedLiterature(m.Zid, collect.References(zn), keys, literature) {
+			cit := literature[zid]
+			cit.CitedBy = append(cit.CitedBy, m)
+			if source, ok := literature[m.Zid]; ok {
+				source.Cites = append(source.Cites, cit.Meta)
+			}
+		}
+	}
+	result := make([]Citation, 0, len(literature))
+	for _, cit := range literature {
+		result = append(result, *cit)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if ci, cj := len(result[i].CitedBy), len(result[j].CitedBy); ci != cj {
+			return ci > cj
+		}
+		return result[i].Meta.Zid > result[j].Meta.Zid
+	})
+	return result, nil
+}
+
+// citedLiterature returns the literature zettel that are cited by the zettel
+// with the given summary. A zettel does not cite itself.
+func citedLiterature(
+	source id.Zid, summary collect.Summary,
+	keys map[string]id.Zid, literature map[id.Zid]*Citation) map[id.Zid]bool {
+	result := make(map[id.Zid]bool)
+	for _, cn := range summary.Cites {
+		if zid, ok := keys[cn.Key]; ok && zid != source {
+			result[zid] = true
+		}
+	}
+	for _, ref := range summary.Links {
+		if ref.State != ast.RefStateZettel {
+			continue
+		}
+		if zid, err := id.Parse(ref.URL.Path); err == nil && zid != source {
+			if _, ok := literature[zid]; ok {
+				result[zid] = true
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,82 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package usecase provides (business) use cases for the zettelstore.
+package usecase
+
+import (
+	"context"
+
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+)
+
+// Comment is a comment zettel, together with all replies to it.
+type Comment struct {
+	Meta    *meta.Meta
+	Replies []*Comment
+}
+
+// ListComments is the data for this use case.
+type ListComments struct {
+	port ListBacklinksPort
+}
+
+// NewListComments creates a new use case. The comments are retrieved from
+// the backlinks of an index, which references the precursors of a zettel.
+func NewListComments(port ListBacklinksPort) ListComments {
+	return ListComments{port: port}
+}
+
+// Run executes the use case. It returns all readable comments on the given
+// zettel. A comment on a comment is a reply. Comments and replies are
+// ordered by time, oldest first.
+func (uc ListComments) Run(ctx context.Context, zid id.Zid) ([]*Comment, error) {
+	return uc.buildComments(ctx, zid, make(map[id.Zid]bool))
+}
+
+// buildComments returns the comments on the given zettel. Visited zettel
+// are ignored, so that cyclic precursors do not lead to an endless loop.
+func (uc ListComments) buildComments(
+	ctx context.Context, zid id.Zid, visited map[id.Zid]bool) ([]*Comment, error) {
+	visited[zid] = true
+	backlinks, err := uc.port.SelectBacklinks(ctx, zid)
+	if err != nil {
+		return nil, err
+	}
+	var result []*Comment
+	for i := len(backlinks) - 1; i >= 0; i-- {
+		m := backlinks[i]
+		if visited[m.Zid] || !isCommentOn(m, zid) {
+			continue
+		}
+		replies, err := uc.buildComments(ctx, m.Zid, visited)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, &Comment{Meta: m, Replies: replies})
+	}
+	return result, nil
+}
+
+// isCommentOn returns true, if the meta data describes a comment on the given
+// zettel. Other backlinks only link to the zettel.
+func isCommentOn(m *meta.Meta, zid id.Zid) bool {
+	if m.GetDefault(meta.KeyRole, "") != meta.ValueRoleComment {
+		return false
+	}
+	precursors, _ := m.GetList(meta.KeyPrecursor)
+	for _, val := range precursors {
+		if pzid, err := id.Parse(val); err == nil && pzid == zid {
+			return true
+		}
+	}
+	return false
+}
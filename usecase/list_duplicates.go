@@ -0,0 +1,75 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package usecase provides (business) use cases for the zettelstore.
+package usecase
+
+import (
+	"context"
+
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/duplicate"
+	"zettelstore.de/z/place"
+)
+
+// duplicateThreshold is the minimum similarity of the content of two zettel,
+// so that they are reported as duplicates.
+const duplicateThreshold = 0.8
+
+// ListDuplicatesPort is the interface used by this use case.
+type ListDuplicatesPort interface {
+	// GetZettel retrieves a specific zettel.
+	GetZettel(ctx context.Context, zid id.Zid) (domain.Zettel, error)
+
+	// SelectMeta returns all zettel meta data that match the selection
+	// criteria. The result is ordered by descending zettel id.
+	SelectMeta(ctx context.Context, f *place.Filter, s *place.Sorter) ([]*meta.Meta, error)
+}
+
+// ListDuplicates is the data for this use case.
+type ListDuplicates struct {
+	port ListDuplicatesPort
+}
+
+// NewListDuplicates creates a new use case.
+func NewListDuplicates(port ListDuplicatesPort) ListDuplicates {
+	return ListDuplicates{port: port}
+}
+
+// Run executes the use case. It returns groups of zettel with nearly the
+// same textual content. Within a group, the oldest zettel comes first.
+func (uc ListDuplicates) Run(ctx context.Context) ([][]*meta.Meta, error) {
+	metaList, err := uc.port.SelectMeta(ctx, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	metas := make(map[id.Zid]*meta.Meta, len(metaList))
+	texts := make(map[id.Zid]string, len(metaList))
+	for _, m := range metaList {
+		zettel, err := uc.port.GetZettel(ctx, m.Zid)
+		if err != nil || zettel.Content.IsBinary() {
+			continue
+		}
+		metas[m.Zid] = m
+		texts[m.Zid] = zettel.Content.AsString()
+	}
+	clusters := duplicate.Cluster(texts, duplicateThreshold)
+	result := make([][]*meta.Meta, 0, len(clusters))
+	for _, zids := range clusters {
+		group := make([]*meta.Meta, 0, len(zids))
+		for _, zid := range zids {
+			group = append(group, metas[zid])
+		}
+		result = append(result, group)
+	}
+	return result, nil
+}
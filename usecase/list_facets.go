@@ -0,0 +1,47 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package usecase provides (business) use cases for the zettelstore.
+package usecase
+
+import (
+	"context"
+
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/place"
+)
+
+// ListFacetsPort is the interface used by this use case.
+type ListFacetsPort interface {
+	// SelectMeta returns all zettel meta data that match the selection
+	// criteria. The result is ordered by descending zettel id.
+	SelectMeta(ctx context.Context, f *place.Filter, s *place.Sorter) ([]*meta.Meta, error)
+}
+
+// ListFacets is the data for this use case.
+type ListFacets struct {
+	port ListFacetsPort
+}
+
+// NewListFacets creates a new use case.
+func NewListFacets(port ListFacetsPort) ListFacets {
+	return ListFacets{port: port}
+}
+
+// Run executes the use case. It returns the number of zettel that match the
+// filter, and the facets of these zettel for the given keys.
+func (uc ListFacets) Run(
+	ctx context.Context, f *place.Filter, keys []string) (int, place.Facets, error) {
+	metas, err := uc.port.SelectMeta(ctx, f, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	return len(metas), place.CountFacets(metas, keys), nil
+}
@@ -0,0 +1,85 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package usecase provides (business) use cases for the zettelstore.
+package usecase
+
+import (
+	"context"
+
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+)
+
+// RecentPort is the interface used by the use cases about recent zettel.
+type RecentPort interface {
+	// Visit records that the given user has visited the given zettel.
+	Visit(user, zid id.Zid)
+
+	// Visited returns the zettel recently visited by the given user.
+	Visited(user id.Zid) []id.Zid
+
+	// Modified returns the recently modified zettel.
+	Modified() []id.Zid
+}
+
+// TrackVisit is the data for this use case.
+type TrackVisit struct {
+	port RecentPort
+}
+
+// NewTrackVisit creates a new use case.
+func NewTrackVisit(port RecentPort) TrackVisit {
+	return TrackVisit{port: port}
+}
+
+// Run executes the use case. Visits of anonymous users are not tracked.
+func (uc TrackVisit) Run(user *meta.Meta, zid id.Zid) {
+	if user != nil {
+		uc.port.Visit(user.Zid, zid)
+	}
+}
+
+// ListRecent is the data for this use case.
+type ListRecent struct {
+	port    RecentPort
+	getMeta GetMeta
+}
+
+// NewListRecent creates a new use case.
+func NewListRecent(port RecentPort, getMeta GetMeta) ListRecent {
+	return ListRecent{port: port, getMeta: getMeta}
+}
+
+// RunVisited returns the metadata of all zettel recently visited by the
+// given user, the most recent first.
+func (uc ListRecent) RunVisited(ctx context.Context, user *meta.Meta) []*meta.Meta {
+	if user == nil {
+		return nil
+	}
+	return uc.collect(ctx, uc.port.Visited(user.Zid))
+}
+
+// RunModified returns the metadata of all recently modified zettel, the most
+// recent first.
+func (uc ListRecent) RunModified(ctx context.Context) []*meta.Meta {
+	return uc.collect(ctx, uc.port.Modified())
+}
+
+// collect returns the metadata of all given zettel that can be read.
+func (uc ListRecent) collect(ctx context.Context, zids []id.Zid) []*meta.Meta {
+	result := make([]*meta.Meta, 0, len(zids))
+	for _, zid := range zids {
+		if m, err := uc.getMeta.Run(ctx, zid); err == nil {
+			result = append(result, m)
+		}
+	}
+	return result
+}
@@ -0,0 +1,42 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package usecase provides (business) use cases for the zettelstore.
+package usecase
+
+import (
+	"context"
+
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+)
+
+// ListRelationsPort is the interface used by this use case.
+type ListRelationsPort interface {
+	// SelectRelations returns the meta data of all zettel that link to the
+	// zettel with the given identifier, grouped by the relation type of the
+	// link. Untyped links are stored under the empty relation.
+	SelectRelations(ctx context.Context, zid id.Zid) (map[string][]*meta.Meta, error)
+}
+
+// ListRelations is the data for this use case.
+type ListRelations struct {
+	port ListRelationsPort
+}
+
+// NewListRelations creates a new use case.
+func NewListRelations(port ListRelationsPort) ListRelations {
+	return ListRelations{port: port}
+}
+
+// Run executes the use case.
+func (uc ListRelations) Run(ctx context.Context, zid id.Zid) (map[string][]*meta.Meta, error) {
+	return uc.port.SelectRelations(ctx, zid)
+}
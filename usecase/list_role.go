@@ -16,14 +16,13 @@ import (
 	"sort"
 
 	"zettelstore.de/z/domain/meta"
-	"zettelstore.de/z/place"
 )
 
 // ListRolePort is the interface used by this use case.
 type ListRolePort interface {
-	// SelectMeta returns all zettel meta data that match the selection
-	// criteria. The result is ordered by descending zettel id.
-	SelectMeta(ctx context.Context, f *place.Filter, s *place.Sorter) ([]*meta.Meta, error)
+	// SelectRoles returns all roles, together with the meta data of all
+	// zettel that have the role.
+	SelectRoles(ctx context.Context) (map[string][]*meta.Meta, error)
 }
 
 // ListRole is the data for this use case.
@@ -38,16 +37,10 @@ func NewListRole(port ListRolePort) ListRole {
 
 // Run executes the use case.
 func (uc ListRole) Run(ctx context.Context) ([]string, error) {
-	metas, err := uc.port.SelectMeta(ctx, nil, nil)
+	roles, err := uc.port.SelectRoles(ctx)
 	if err != nil {
 		return nil, err
 	}
-	roles := make(map[string]bool, 8)
-	for _, m := range metas {
-		if role, ok := m.Get(meta.KeyRole); ok && role != "" {
-			roles[role] = true
-		}
-	}
 	result := make([]string, 0, len(roles))
 	for role := range roles {
 		result = append(result, role)
@@ -15,14 +15,13 @@ import (
 	"context"
 
 	"zettelstore.de/z/domain/meta"
-	"zettelstore.de/z/place"
 )
 
 // ListTagsPort is the interface used by this use case.
 type ListTagsPort interface {
-	// SelectMeta returns all zettel meta data that match the selection
-	// criteria. The result is ordered by descending zettel id.
-	SelectMeta(ctx context.Context, f *place.Filter, s *place.Sorter) ([]*meta.Meta, error)
+	// SelectTags returns all tags, together with the meta data of all zettel
+	// that use the tag.
+	SelectTags(ctx context.Context) (map[string][]*meta.Meta, error)
 }
 
 // ListTags is the data for this use case.
@@ -40,18 +39,11 @@ type TagData map[string][]*meta.Meta
 
 // Run executes the use case.
 func (uc ListTags) Run(ctx context.Context, minCount int) (TagData, error) {
-	metas, err := uc.port.SelectMeta(ctx, nil, nil)
+	tags, err := uc.port.SelectTags(ctx)
 	if err != nil {
 		return nil, err
 	}
-	result := make(TagData)
-	for _, m := range metas {
-		if tl, ok := m.GetList(meta.KeyTags); ok && len(tl) > 0 {
-			for _, t := range tl {
-				result[t] = append(result[t], m)
-			}
-		}
-	}
+	result := TagData(tags)
 	if minCount > 1 {
 		for t, ms := range result {
 			if len(ms) < minCount {
@@ -0,0 +1,89 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package usecase provides (business) use cases for the zettelstore.
+package usecase
+
+import (
+	"context"
+	"strings"
+
+	"zettelstore.de/z/ast"
+	"zettelstore.de/z/collect"
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/parser"
+	"zettelstore.de/z/place"
+)
+
+// ListTasksPort is the interface used by this use case.
+type ListTasksPort interface {
+	// SelectMeta returns all zettel meta data that match the selection
+	// criteria.
+	SelectMeta(ctx context.Context, f *place.Filter, s *place.Sorter) ([]*meta.Meta, error)
+
+	// GetZettel retrieves a specific zettel.
+	GetZettel(ctx context.Context, zid id.Zid) (domain.Zettel, error)
+}
+
+// ListTasks is the data for this use case.
+type ListTasks struct {
+	port ListTasksPort
+}
+
+// NewListTasks creates a new use case.
+func NewListTasks(port ListTasksPort) ListTasks {
+	return ListTasks{port: port}
+}
+
+// TaskInfo describes an open task of a zettel.
+type TaskInfo struct {
+	Meta *meta.Meta    // Meta data of the zettel that contains the task
+	Num  int           // Number of the task within the zettel, starting with 0
+	Line int           // Line of the task within the zettel content, starting with 1
+	Item ast.ItemSlice // Content of the task
+}
+
+// Run executes the use case. It returns all open tasks of the selected
+// zettel. The tasks of one zettel are in the order of their appearance.
+func (uc ListTasks) Run(
+	ctx context.Context, f *place.Filter, s *place.Sorter) ([]TaskInfo, error) {
+	metaList, err := uc.port.SelectMeta(ctx, f, s)
+	if err != nil {
+		return nil, err
+	}
+	var result []TaskInfo
+	for _, m := range metaList {
+		if syntax, ok := m.Get(meta.KeySyntax); ok && syntax != meta.ValueSyntaxZmk {
+			continue
+		}
+		zettel, err := uc.port.GetZettel(ctx, m.Zid)
+		if err != nil {
+			continue
+		}
+		content := zettel.Content.AsString()
+		if !strings.Contains(content, "[ ]") {
+			continue
+		}
+		for num, task := range collect.Tasks(parser.ParseZettel(zettel, "")) {
+			if task.State != ast.TaskOpen {
+				continue
+			}
+			result = append(result, TaskInfo{
+				Meta: m,
+				Num:  num,
+				Line: strings.Count(content[:task.Pos], "\n") + 1,
+				Item: task.Item,
+			})
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,72 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package usecase provides (business) use cases for the zettelstore.
+package usecase
+
+import (
+	"context"
+
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/lock"
+	"zettelstore.de/z/place"
+)
+
+// LockZettelPort is the interface used by this use case.
+type LockZettelPort interface {
+	// Acquire locks the given zettel for the given user.
+	Acquire(zid id.Zid, user string) (lock.Lock, bool)
+
+	// Release removes the lock of the given zettel, if the user holds it.
+	Release(zid id.Zid, user string) (lock.Lock, bool)
+}
+
+// LockZettel is the data for this use case.
+type LockZettel struct {
+	port    LockZettelPort
+	getMeta GetMeta
+}
+
+// ErrZettelLocked is returned if the zettel is locked by another user.
+type ErrZettelLocked struct{ Lock lock.Lock }
+
+func (err *ErrZettelLocked) Error() string {
+	return "Zettel " + err.Lock.Zid.String() + " is locked by " + err.Lock.User
+}
+
+// NewLockZettel creates a new use case.
+func NewLockZettel(port LockZettelPort, getMeta GetMeta) LockZettel {
+	return LockZettel{port: port, getMeta: getMeta}
+}
+
+// Run executes the use case. It acquires the lock of the zettel for the
+// given user, or it releases it.
+func (uc LockZettel) Run(
+	ctx context.Context, user *meta.Meta, zid id.Zid, acquire bool) (lock.Lock, error) {
+	if user == nil {
+		return lock.Lock{}, place.NewErrNotAllowed("Lock", user, zid)
+	}
+	if _, err := uc.getMeta.Run(ctx, zid); err != nil {
+		return lock.Lock{}, err
+	}
+	userID := user.GetDefault(meta.KeyUserID, user.Zid.String())
+	var l lock.Lock
+	var ok bool
+	if acquire {
+		l, ok = uc.port.Acquire(zid, userID)
+	} else {
+		l, ok = uc.port.Release(zid, userID)
+	}
+	if !ok {
+		return l, &ErrZettelLocked{Lock: l}
+	}
+	return l, nil
+}
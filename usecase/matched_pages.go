@@ -0,0 +1,39 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package usecase provides (business) use cases for the zettelstore.
+package usecase
+
+import (
+	"zettelstore.de/z/domain/id"
+)
+
+// MatchedPagesPort is the interface used by this use case.
+type MatchedPagesPort interface {
+	// MatchedPages returns the numbers of all pages of the given zettel that
+	// contain all the given terms.
+	MatchedPages(zid id.Zid, terms []string) []int
+}
+
+// MatchedPages is the data for this use case.
+type MatchedPages struct {
+	port MatchedPagesPort
+}
+
+// NewMatchedPages creates a new use case.
+func NewMatchedPages(port MatchedPagesPort) MatchedPages {
+	return MatchedPages{port: port}
+}
+
+// Run executes the use case. It returns the numbers of the pages of a
+// document, where the search terms were found.
+func (uc MatchedPages) Run(zid id.Zid, terms []string) []int {
+	return uc.port.MatchedPages(zid, terms)
+}
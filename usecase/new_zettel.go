@@ -24,10 +24,22 @@ func NewNewZettel() NewZettel {
 	return NewZettel{}
 }
 
-// Run executes the use case.
-func (uc NewZettel) Run(origZettel domain.Zettel) domain.Zettel {
+// userDefaults maps the keys of a user zettel that store the defaults of the
+// user to the keys of a new zettel.
+var userDefaults = map[string]string{
+	meta.KeyDefaultRole:       meta.KeyRole,
+	meta.KeyDefaultSyntax:     meta.KeySyntax,
+	meta.KeyDefaultVisibility: meta.KeyVisibility,
+}
+
+// Run executes the use case. Values for role, syntax, and visibility that are
+// not set by the original zettel are taken from the defaults of the given
+// user, if any.
+func (uc NewZettel) Run(user *meta.Meta, origZettel domain.Zettel) domain.Zettel {
 	m := origZettel.Meta.Clone()
 	if role, ok := m.Get(meta.KeyRole); ok && role == meta.ValueRoleNewTemplate {
+		// The new zettel is not a template, unless "new-role" says so.
+		m.Delete(meta.KeyRole)
 		const prefix = "new-"
 		for _, pair := range m.PairsRest(false) {
 			if key := pair.Key; len(key) > len(prefix) && key[0:len(prefix)] == prefix {
@@ -36,5 +48,15 @@ func (uc NewZettel) Run(origZettel domain.Zettel) domain.Zettel {
 			}
 		}
 	}
+	if user != nil {
+		for userKey, key := range userDefaults {
+			if _, ok := m.Get(key); ok {
+				continue
+			}
+			if val, ok := user.Get(userKey); ok && val != "" {
+				m.Set(key, val)
+			}
+		}
+	}
 	return domain.Zettel{Meta: m, Content: origZettel.Content}
 }
@@ -0,0 +1,40 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package usecase provides (business) use cases for the zettelstore.
+package usecase
+
+import (
+	"context"
+	"io"
+
+	"zettelstore.de/z/domain/id"
+)
+
+// OpenContentPort is the interface used by this use case.
+type OpenContentPort interface {
+	// OpenContent returns a reader for the content of a specific zettel.
+	OpenContent(ctx context.Context, zid id.Zid) (io.ReadCloser, error)
+}
+
+// OpenContent is the data for this use case.
+type OpenContent struct {
+	port OpenContentPort
+}
+
+// NewOpenContent creates a new use case.
+func NewOpenContent(port OpenContentPort) OpenContent {
+	return OpenContent{port: port}
+}
+
+// Run executes the use case. The caller must close the returned reader.
+func (uc OpenContent) Run(ctx context.Context, zid id.Zid) (io.ReadCloser, error) {
+	return uc.port.OpenContent(ctx, zid)
+}
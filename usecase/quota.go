@@ -0,0 +1,167 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package usecase provides (business) use cases for the zettelstore.
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/config/startup"
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+)
+
+// QuotaPort is the interface used by this use case. It must not check any
+// policy, because a quota counts all zettel of a user.
+type QuotaPort interface {
+	// GetMeta retrieves just the meta data of a specific zettel.
+	GetMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error)
+}
+
+// QuotaIndex is the index used by this use case.
+type QuotaIndex interface {
+	// CreatorUsage returns the number and the total content size of all
+	// zettel that were created by the given user, except the given zettel.
+	CreatorUsage(ctx context.Context, creator, except id.Zid) (int, int64, error)
+}
+
+// Quota is the data for this use case. Its zero value does not check any
+// quota.
+type Quota struct {
+	port  QuotaPort
+	index QuotaIndex
+}
+
+// NewQuota creates a new use case. The sizes of the zettel are retrieved
+// from the index, so that they are not read on every check.
+func NewQuota(port QuotaPort, index QuotaIndex) Quota {
+	return Quota{port: port, index: index}
+}
+
+// QuotaUsage describes the zettel that a user has created, and how many of
+// them are allowed. A maximum of zero means no limit.
+type QuotaUsage struct {
+	Zettel    int
+	MaxZettel int
+	Size      int64
+	MaxSize   int64
+}
+
+// ErrQuotaExceeded is returned if a zettel cannot be stored, because its
+// creator would exceed the quota.
+type ErrQuotaExceeded struct {
+	User  id.Zid
+	Usage QuotaUsage
+}
+
+func (err *ErrQuotaExceeded) Error() string {
+	u := err.Usage
+	if u.MaxZettel > 0 && u.Zettel > u.MaxZettel {
+		return fmt.Sprintf(
+			"Quota exceeded: at most %v zettel are allowed", u.MaxZettel)
+	}
+	return fmt.Sprintf(
+		"Quota exceeded: %v would be used, but at most %v are allowed",
+		FormatSize(u.Size), FormatSize(u.MaxSize))
+}
+
+// quotaLimits returns the maximum number of zettel and the maximum total
+// size that the given user may create. The owner has no limits.
+func quotaLimits(user *meta.Meta) (int, int64) {
+	if user == nil || startup.IsOwner(user.Zid) ||
+		runtime.GetUserRole(user) == meta.UserRoleOwner {
+		return 0, 0
+	}
+	maxZettel := startup.QuotaZettel()
+	if val, ok := user.Get(meta.KeyQuotaZettel); ok {
+		if n, err := strconv.Atoi(val); err == nil && n >= 0 {
+			maxZettel = n
+		}
+	}
+	maxSize := startup.QuotaSize()
+	if val, ok := user.Get(meta.KeyQuotaSize); ok {
+		if n, ok := startup.ParseSize(val); ok {
+			maxSize = n
+		}
+	}
+	return maxZettel, maxSize
+}
+
+// Run returns the usage of the given user.
+func (uc Quota) Run(ctx context.Context, user *meta.Meta) (QuotaUsage, error) {
+	var usage QuotaUsage
+	if user == nil {
+		return usage, nil
+	}
+	usage.MaxZettel, usage.MaxSize = quotaLimits(user)
+	if uc.index == nil {
+		return usage, nil
+	}
+	var err error
+	usage.Zettel, usage.Size, err = uc.index.CreatorUsage(ctx, user.Zid, id.Invalid)
+	return usage, err
+}
+
+// check returns an error, if the creator of the zettel would exceed the
+// quota, when the zettel is stored. The number of zettel is only checked for
+// new zettel.
+func (uc Quota) check(ctx context.Context, zettel domain.Zettel, isNew bool) error {
+	creator, usage, err := uc.creatorUsage(ctx, zettel.Meta, isNew)
+	if err != nil || !creator.IsValid() {
+		return err
+	}
+	usage.Zettel++
+	usage.Size += int64(len(zettel.Content.AsString()))
+	if usage.exceeded() {
+		return &ErrQuotaExceeded{User: creator, Usage: usage}
+	}
+	return nil
+}
+
+// creatorUsage returns the creator of the zettel and its usage, without the
+// zettel itself. The creator is invalid, if no quota must be checked.
+func (uc Quota) creatorUsage(
+	ctx context.Context, m *meta.Meta, isNew bool) (id.Zid, QuotaUsage, error) {
+	var usage QuotaUsage
+	val, ok := m.Get(meta.KeyCreator)
+	if !ok || uc.port == nil || uc.index == nil {
+		return id.Invalid, usage, nil
+	}
+	creator, err := id.Parse(val)
+	if err != nil {
+		return id.Invalid, usage, nil
+	}
+	user, err := uc.port.GetMeta(ctx, creator)
+	if err != nil {
+		return id.Invalid, usage, nil
+	}
+	usage.MaxZettel, usage.MaxSize = quotaLimits(user)
+	if !isNew {
+		usage.MaxZettel = 0
+	}
+	if usage.MaxZettel <= 0 && usage.MaxSize <= 0 {
+		return id.Invalid, usage, nil
+	}
+	usage.Zettel, usage.Size, err = uc.index.CreatorUsage(ctx, creator, m.Zid)
+	if err != nil {
+		return id.Invalid, usage, err
+	}
+	return creator, usage, nil
+}
+
+func (u *QuotaUsage) exceeded() bool {
+	return (u.MaxZettel > 0 && u.Zettel > u.MaxZettel) ||
+		(u.MaxSize > 0 && u.Size > u.MaxSize)
+}
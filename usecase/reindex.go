@@ -0,0 +1,77 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package usecase provides (business) use cases for the zettelstore.
+package usecase
+
+import (
+	"context"
+
+	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/config/startup"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/index"
+	"zettelstore.de/z/place"
+)
+
+// ReindexPort is the interface used by this use case.
+type ReindexPort interface {
+	// Rebuild starts to build the index from scratch in the background.
+	Rebuild() bool
+
+	// Progress returns the state of the last rebuild.
+	Progress() index.Progress
+}
+
+// Reindex is the data for this use case.
+type Reindex struct {
+	port   ReindexPort
+	reload ReloadPort
+}
+
+// NewReindex creates a new use case. Before the index is rebuilt, the given
+// place is reloaded to detect all changes that were made outside.
+func NewReindex(port ReindexPort, reload ReloadPort) Reindex {
+	return Reindex{port: port, reload: reload}
+}
+
+// Run reloads the place and starts to rebuild the index, if no rebuild is
+// running. It returns the current progress of the rebuild. Only the owner is
+// allowed to rebuild the index.
+func (uc Reindex) Run(ctx context.Context, user *meta.Meta) (index.Progress, error) {
+	if !canReindex(user) {
+		return index.Progress{}, place.NewErrNotAllowed("Reindex", user, id.Invalid)
+	}
+	if !uc.port.Progress().Running {
+		if err := uc.reload.Reload(ctx); err != nil {
+			return index.Progress{}, err
+		}
+		uc.port.Rebuild()
+	}
+	return uc.port.Progress(), nil
+}
+
+// Progress returns the state of the last rebuild. Only the owner is allowed
+// to retrieve it.
+func (uc Reindex) Progress(user *meta.Meta) (index.Progress, error) {
+	if !canReindex(user) {
+		return index.Progress{}, place.NewErrNotAllowed("Reindex", user, id.Invalid)
+	}
+	return uc.port.Progress(), nil
+}
+
+func canReindex(user *meta.Meta) bool {
+	if !startup.WithAuth() {
+		return true
+	}
+	return user != nil &&
+		(startup.IsOwner(user.Zid) || runtime.GetUserRole(user) == meta.UserRoleOwner)
+}
@@ -0,0 +1,40 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package usecase provides (business) use cases for the zettelstore.
+package usecase
+
+import (
+	"context"
+
+	"zettelstore.de/z/domain/id"
+)
+
+// ReloadZettelPort is the interface used by this use case.
+type ReloadZettelPort interface {
+	// ReloadZettel clears all caches of the given zettel and reloads its
+	// internal data to reflect changes that were possibly undetected.
+	ReloadZettel(ctx context.Context, zid id.Zid) error
+}
+
+// ReloadZettel is the data for this use case.
+type ReloadZettel struct {
+	port ReloadZettelPort
+}
+
+// NewReloadZettel creates a new use case.
+func NewReloadZettel(port ReloadZettelPort) ReloadZettel {
+	return ReloadZettel{port: port}
+}
+
+// Run executes the use case.
+func (uc ReloadZettel) Run(ctx context.Context, zid id.Zid) error {
+	return uc.port.ReloadZettel(ctx, zid)
+}
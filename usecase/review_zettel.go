@@ -0,0 +1,210 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package usecase provides (business) use cases for the zettelstore.
+package usecase
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/place"
+)
+
+// ErrNoReview is returned, if a zettel is marked as reviewed, but has no
+// valid review interval.
+type ErrNoReview struct{ Zid id.Zid }
+
+func (err *ErrNoReview) Error() string {
+	return "Zettel " + err.Zid.String() + " has no valid review interval"
+}
+
+// ReviewInterval returns the number of days between two reviews of the
+// zettel. It returns 0, if the zettel is not to be reviewed. Cards are always
+// reviewed, starting with an interval of one day.
+func ReviewInterval(m *meta.Meta) int {
+	if value, ok := m.Get(meta.KeyReviewInterval); ok {
+		if days, err := strconv.Atoi(value); err == nil && days > 0 {
+			return days
+		}
+	}
+	if isCard(m) {
+		return 1
+	}
+	return 0
+}
+
+func isCard(m *meta.Meta) bool {
+	return m.GetDefault(meta.KeyRole, "") == meta.ValueRoleCard
+}
+
+// Grade states how well the content of a zettel was remembered at a review.
+type Grade int
+
+// Constants for Grade
+const (
+	GradeAgain Grade = iota // Not remembered, start again with one day
+	GradeHard               // Remembered with difficulties, halve the interval
+	GradeGood               // Remembered, keep the interval
+	GradeEasy               // Remembered easily, double the interval
+)
+
+var gradeNames = map[string]Grade{
+	"again": GradeAgain,
+	"hard":  GradeHard,
+	"good":  GradeGood,
+	"easy":  GradeEasy,
+}
+
+// ParseGrade returns the grade with the given name.
+func ParseGrade(name string) (Grade, bool) {
+	grade, ok := gradeNames[name]
+	return grade, ok
+}
+
+// nextInterval returns the review interval that follows the given interval,
+// depending on the grade.
+func nextInterval(days int, grade Grade) int {
+	switch grade {
+	case GradeAgain:
+		return 1
+	case GradeHard:
+		if days > 1 {
+			return days / 2
+		}
+		return 1
+	case GradeEasy:
+		return 2 * days
+	}
+	return days
+}
+
+// timestampLayout is the layout of all timestamp values.
+const timestampLayout = "20060102150405"
+
+// ListReviewPort is the interface used by this use case.
+type ListReviewPort interface {
+	// SelectMeta returns all zettel meta data that match the selection
+	// criteria.
+	SelectMeta(ctx context.Context, f *place.Filter, s *place.Sorter) ([]*meta.Meta, error)
+}
+
+// ListReview is the data for this use case.
+type ListReview struct {
+	port ListReviewPort
+}
+
+// NewListReview creates a new use case.
+func NewListReview(port ListReviewPort) ListReview {
+	return ListReview{port: port}
+}
+
+// ReviewList contains all zettel that are due for a review, together with
+// the zettel of the day.
+type ReviewList struct {
+	Due   []*meta.Meta // Zettel due for a review, most overdue first
+	Daily *meta.Meta   // Zettel of the day, or nil if no zettel is to be reviewed
+}
+
+// Run executes the use case. A zettel is due, if it has a review interval
+// and its due date is not after the given time. A zettel without a due date
+// has never been reviewed and is therefore due. The zettel of the day is
+// chosen from all zettel with a review interval, so that it does not change
+// during the day. Cards are studied separately and are not part of the
+// result.
+func (uc ListReview) Run(ctx context.Context, now time.Time) (ReviewList, error) {
+	metaList, err := uc.selectReview(ctx, func(m *meta.Meta) bool {
+		return !isCard(m) && ReviewInterval(m) > 0
+	})
+	if err != nil || len(metaList) == 0 {
+		return ReviewList{}, err
+	}
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400
+	return ReviewList{
+		Due:   dueReview(metaList, now),
+		Daily: metaList[int(day%int64(len(metaList)))],
+	}, nil
+}
+
+// RunCards executes the use case for cards. It returns all cards that are
+// due at the given time, most overdue first.
+func (uc ListReview) RunCards(ctx context.Context, now time.Time) ([]*meta.Meta, error) {
+	metaList, err := uc.selectReview(ctx, isCard)
+	if err != nil {
+		return nil, err
+	}
+	return dueReview(metaList, now), nil
+}
+
+func (uc ListReview) selectReview(
+	ctx context.Context, sel func(*meta.Meta) bool) ([]*meta.Meta, error) {
+	return uc.port.SelectMeta(ctx, &place.Filter{Select: sel}, &place.Sorter{Order: meta.KeyID})
+}
+
+func dueReview(metaList []*meta.Meta, now time.Time) []*meta.Meta {
+	// Timestamps of the same layout are ordered like strings.
+	today := now.Format(timestampLayout)
+	var due []*meta.Meta
+	for _, m := range metaList {
+		if m.GetDefault(meta.KeyReviewDue, "") <= today {
+			due = append(due, m)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].GetDefault(meta.KeyReviewDue, "") < due[j].GetDefault(meta.KeyReviewDue, "")
+	})
+	return due
+}
+
+// ReviewZettelPort is the interface used by this use case.
+type ReviewZettelPort interface {
+	// GetZettel retrieves a specific zettel.
+	GetZettel(ctx context.Context, zid id.Zid) (domain.Zettel, error)
+
+	// UpdateZettel updates an existing zettel.
+	UpdateZettel(ctx context.Context, zettel domain.Zettel) error
+}
+
+// ReviewZettel is the data for this use case.
+type ReviewZettel struct {
+	port ReviewZettelPort
+}
+
+// NewReviewZettel creates a new use case.
+func NewReviewZettel(port ReviewZettelPort) ReviewZettel {
+	return ReviewZettel{port: port}
+}
+
+// Run executes the use case. It marks the zettel as reviewed at the given
+// time with the given grade. The grade determines the new review interval,
+// after which the next review is due.
+func (uc ReviewZettel) Run(ctx context.Context, zid id.Zid, grade Grade, now time.Time) error {
+	zettel, err := uc.port.GetZettel(ctx, zid)
+	if err != nil {
+		return err
+	}
+	days := ReviewInterval(zettel.Meta)
+	if days == 0 {
+		return &ErrNoReview{Zid: zid}
+	}
+	m := zettel.Meta.Clone()
+	if next := nextInterval(days, grade); next != days || isCard(m) {
+		days = next
+		m.Set(meta.KeyReviewInterval, strconv.Itoa(days))
+	}
+	m.Set(meta.KeyReviewDue, now.AddDate(0, 0, days).Format(timestampLayout))
+	m.SetNow(meta.KeyModified)
+	return uc.port.UpdateZettel(ctx, domain.Zettel{Meta: m, Content: zettel.Content})
+}
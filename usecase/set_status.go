@@ -0,0 +1,83 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package usecase provides (business) use cases for the zettelstore.
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+)
+
+// ErrInvalidStatus is returned, if a status is not one of the configured
+// workflow states.
+type ErrInvalidStatus struct{ Status string }
+
+func (err *ErrInvalidStatus) Error() string {
+	return fmt.Sprintf("Status %q is not a configured workflow state", err.Status)
+}
+
+// IsValidStatus returns true, if the given status is one of the configured
+// workflow states.
+func IsValidStatus(status string) bool {
+	for _, state := range runtime.GetStatusStates() {
+		if state == status {
+			return true
+		}
+	}
+	return false
+}
+
+// SetStatusPort is the interface used by this use case.
+type SetStatusPort interface {
+	// GetZettel retrieves a specific zettel.
+	GetZettel(ctx context.Context, zid id.Zid) (domain.Zettel, error)
+
+	// UpdateZettel updates an existing zettel.
+	UpdateZettel(ctx context.Context, zettel domain.Zettel) error
+}
+
+// SetStatus is the data for this use case.
+type SetStatus struct {
+	port SetStatusPort
+}
+
+// NewSetStatus creates a new use case.
+func NewSetStatus(port SetStatusPort) SetStatus {
+	return SetStatus{port: port}
+}
+
+// Run executes the use case. It sets the workflow state of a zettel. An
+// empty status removes the zettel from the workflow.
+func (uc SetStatus) Run(ctx context.Context, zid id.Zid, status string) error {
+	if status != "" && !IsValidStatus(status) {
+		return &ErrInvalidStatus{Status: status}
+	}
+	zettel, err := uc.port.GetZettel(ctx, zid)
+	if err != nil {
+		return err
+	}
+	if zettel.Meta.GetDefault(meta.KeyStatus, "") == status {
+		return nil
+	}
+	m := zettel.Meta.Clone()
+	if status == "" {
+		m.Delete(meta.KeyStatus)
+	} else {
+		m.Set(meta.KeyStatus, status)
+	}
+	m.SetNow(meta.KeyModified)
+	return uc.port.UpdateZettel(ctx, domain.Zettel{Meta: m, Content: zettel.Content})
+}
@@ -0,0 +1,161 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package usecase provides (business) use cases for the zettelstore.
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/place"
+)
+
+// Shadowing describes all places that store a zettel with the same
+// identifier. Only the zettel of the first place is visible.
+type Shadowing struct {
+	Placements []place.Placement
+}
+
+// Shadows returns true, if the visible zettel shadows other zettel.
+func (s Shadowing) Shadows() bool { return len(s.Placements) > 1 }
+
+// IsBuiltin returns true, if the visible zettel is a constant zettel of the
+// software.
+func (s Shadowing) IsBuiltin() bool { return len(s.Placements) > 0 && s.Placements[0].Builtin }
+
+// IsComputed returns true, if the visible zettel is computed at runtime.
+func (s Shadowing) IsComputed() bool { return len(s.Placements) > 0 && s.Placements[0].Computed }
+
+// CanCustomize returns true, if the visible zettel can be copied into a
+// changeable place.
+func (s Shadowing) CanCustomize() bool { return s.IsBuiltin() }
+
+// CanRevert returns true, if the visible zettel can be removed, so that the
+// shadowed zettel becomes visible.
+func (s Shadowing) CanRevert() bool {
+	return s.Shadows() && !s.Placements[0].Builtin && !s.Placements[0].Computed
+}
+
+// ErrNotShadowing is returned, if a zettel cannot be customized or reverted,
+// because of the places that store it.
+type ErrNotShadowing struct {
+	Op  string
+	Zid id.Zid
+}
+
+func (err *ErrNotShadowing) Error() string {
+	if err.Op == "Customize" {
+		return fmt.Sprintf("Zettel %v is not a built-in zettel", err.Zid)
+	}
+	return fmt.Sprintf("Zettel %v does not shadow another zettel", err.Zid)
+}
+
+// PlacementPort is the interface to retrieve the places of a zettel.
+type PlacementPort interface {
+	// Placements returns all places that store a zettel with the given
+	// identifier, in the order of the chain.
+	Placements(ctx context.Context, zid id.Zid) []place.Placement
+}
+
+// GetShadowingPort is the interface used by this use case.
+type GetShadowingPort interface {
+	// GetMeta retrieves just the meta data of a specific zettel.
+	GetMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error)
+}
+
+// GetShadowing is the data for this use case.
+type GetShadowing struct {
+	port   GetShadowingPort
+	placer PlacementPort
+}
+
+// NewGetShadowing creates a new use case. The port checks, whether the zettel
+// may be read, the placer must not check any policy.
+func NewGetShadowing(port GetShadowingPort, placer PlacementPort) GetShadowing {
+	return GetShadowing{port: port, placer: placer}
+}
+
+// Run executes the use case.
+func (uc GetShadowing) Run(ctx context.Context, zid id.Zid) (Shadowing, error) {
+	if _, err := uc.port.GetMeta(ctx, zid); err != nil {
+		return Shadowing{}, err
+	}
+	return Shadowing{Placements: uc.placer.Placements(ctx, zid)}, nil
+}
+
+// CustomizeZettelPort is the interface used by this use case.
+type CustomizeZettelPort interface {
+	// GetZettel retrieves a specific zettel.
+	GetZettel(ctx context.Context, zid id.Zid) (domain.Zettel, error)
+
+	// UpdateZettel updates an existing zettel.
+	UpdateZettel(ctx context.Context, zettel domain.Zettel) error
+}
+
+// CustomizeZettel is the data for this use case.
+type CustomizeZettel struct {
+	port   CustomizeZettelPort
+	placer PlacementPort
+}
+
+// NewCustomizeZettel creates a new use case.
+func NewCustomizeZettel(port CustomizeZettelPort, placer PlacementPort) CustomizeZettel {
+	return CustomizeZettel{port: port, placer: placer}
+}
+
+// Run executes the use case. It stores a copy of a built-in zettel with the
+// same identifier in a changeable place. The copy shadows the built-in zettel
+// from now on.
+func (uc CustomizeZettel) Run(ctx context.Context, zid id.Zid) error {
+	zettel, err := uc.port.GetZettel(ctx, zid)
+	if err != nil {
+		return err
+	}
+	if !(Shadowing{Placements: uc.placer.Placements(ctx, zid)}).CanCustomize() {
+		return &ErrNotShadowing{Op: "Customize", Zid: zid}
+	}
+	return uc.port.UpdateZettel(ctx, zettel)
+}
+
+// RevertZettelPort is the interface used by this use case.
+type RevertZettelPort interface {
+	// GetMeta retrieves just the meta data of a specific zettel.
+	GetMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error)
+
+	// DeleteZettel removes the zettel from the place.
+	DeleteZettel(ctx context.Context, zid id.Zid) error
+}
+
+// RevertZettel is the data for this use case.
+type RevertZettel struct {
+	port   RevertZettelPort
+	placer PlacementPort
+}
+
+// NewRevertZettel creates a new use case.
+func NewRevertZettel(port RevertZettelPort, placer PlacementPort) RevertZettel {
+	return RevertZettel{port: port, placer: placer}
+}
+
+// Run executes the use case. It removes the visible zettel, but only if it
+// shadows another zettel, which becomes visible again.
+func (uc RevertZettel) Run(ctx context.Context, zid id.Zid) error {
+	if _, err := uc.port.GetMeta(ctx, zid); err != nil {
+		return err
+	}
+	if !(Shadowing{Placements: uc.placer.Placements(ctx, zid)}).CanRevert() {
+		return &ErrNotShadowing{Op: "Revert", Zid: zid}
+	}
+	return uc.port.DeleteZettel(ctx, zid)
+}
@@ -0,0 +1,179 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package usecase provides (business) use cases for the zettelstore.
+package usecase
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"zettelstore.de/z/ast"
+	"zettelstore.de/z/collect"
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/encoder"
+	"zettelstore.de/z/parser"
+	"zettelstore.de/z/place"
+)
+
+// Some constants to tune the tag suggestions.
+const (
+	maxTagSuggestions = 8   // maximum number of suggested tags
+	minTermLength     = 4   // shorter words are never suggested
+	linkedTagWeight   = 0.5 // score of a tag for every linked zettel that uses it
+)
+
+// SuggestTagsPort is the interface used by this use case.
+type SuggestTagsPort interface {
+	// GetMeta retrieves just the meta data of a specific zettel.
+	GetMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error)
+
+	// SelectMeta returns all zettel meta data that match the selection
+	// criteria. The result is ordered by descending zettel id.
+	SelectMeta(ctx context.Context, f *place.Filter, s *place.Sorter) ([]*meta.Meta, error)
+}
+
+// SuggestTags is the data for this use case.
+type SuggestTags struct {
+	port SuggestTagsPort
+}
+
+// NewSuggestTags creates a new use case.
+func NewSuggestTags(port SuggestTagsPort) SuggestTags {
+	return SuggestTags{port: port}
+}
+
+// Run executes the use case. It returns tags that might describe the given
+// zettel, the most relevant first. Words of the zettel that are frequent in
+// the zettel, but rare in the title and abstract of all other zettel, are
+// good candidates, especially if they are already used as a tag. Tags of
+// linked zettel are candidates too. Tags of the zettel itself are never
+// suggested.
+func (uc SuggestTags) Run(ctx context.Context, zettel domain.Zettel) ([]string, error) {
+	metaList, err := uc.port.SelectMeta(ctx, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	docFreq := make(map[string]int)
+	usedTags := make(map[string]bool)
+	for _, m := range metaList {
+		if m.Zid == zettel.Meta.Zid {
+			continue
+		}
+		terms := countTerms(m.GetDefault(meta.KeyTitle, "") + " " + m.GetDefault(meta.KeyAbstract, ""))
+		for term := range terms {
+			docFreq[term]++
+		}
+		if tags, ok := m.GetList(meta.KeyTags); ok {
+			for _, tag := range tags {
+				usedTags[strings.ToLower(tag)] = true
+			}
+		}
+	}
+
+	zn := parser.ParseZettel(zettel, "")
+	scores := scoreTerms(zn, docFreq, len(metaList), usedTags)
+	for _, ref := range collect.References(zn).Links {
+		if !ref.IsZettel() {
+			continue
+		}
+		zid, err := id.Parse(ref.URL.Path)
+		if err != nil {
+			continue
+		}
+		if m, err := uc.port.GetMeta(ctx, zid); err == nil {
+			if tags, ok := m.GetList(meta.KeyTags); ok {
+				for _, tag := range tags {
+					scores[strings.ToLower(tag)] += linkedTagWeight
+				}
+			}
+		}
+	}
+	if tags, ok := zettel.Meta.GetList(meta.KeyTags); ok {
+		for _, tag := range tags {
+			delete(scores, strings.ToLower(tag))
+		}
+	}
+	return bestTags(scores), nil
+}
+
+// scoreTerms returns a score for every term of the zettel, based on its
+// frequency within the zettel and within all n zettel. The best term gets a
+// score of one, if it is already used as a tag.
+func scoreTerms(
+	zn *ast.ZettelNode, docFreq map[string]int, n int, usedTags map[string]bool) map[string]float64 {
+	var sb strings.Builder
+	sb.WriteString(zn.InhMeta.GetDefault(meta.KeyTitle, ""))
+	sb.WriteByte(' ')
+	if enc := encoder.Create("text"); enc != nil {
+		enc.WriteBlocks(&sb, zn.Ast)
+	}
+	scores := make(map[string]float64)
+	maxScore := 0.0
+	for term, count := range countTerms(sb.String()) {
+		tag := "#" + term
+		if count < 2 && !usedTags[tag] {
+			// A new tag must be used more than once
+			continue
+		}
+		score := float64(count) * math.Log(float64(n+1)/float64(docFreq[term]+1))
+		if score <= 0 {
+			continue
+		}
+		if !usedTags[tag] {
+			score /= 2
+		}
+		scores[tag] = score
+		if score > maxScore {
+			maxScore = score
+		}
+	}
+	for tag := range scores {
+		scores[tag] /= maxScore
+	}
+	return scores
+}
+
+// countTerms returns the lower case words of the text, together with the
+// number of their occurrences.
+func countTerms(text string) map[string]int {
+	result := make(map[string]int)
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-'
+	}) {
+		word = strings.ToLower(strings.Trim(word, "-"))
+		if len([]rune(word)) >= minTermLength && strings.IndexFunc(word, unicode.IsLetter) >= 0 {
+			result[word]++
+		}
+	}
+	return result
+}
+
+func bestTags(scores map[string]float64) []string {
+	result := make([]string, 0, len(scores))
+	for tag := range scores {
+		result = append(result, tag)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if scores[result[i]] != scores[result[j]] {
+			return scores[result[i]] > scores[result[j]]
+		}
+		return result[i] < result[j]
+	})
+	if len(result) > maxTagSuggestions {
+		result = result[:maxTagSuggestions]
+	}
+	return result
+}
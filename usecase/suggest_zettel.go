@@ -0,0 +1,145 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package usecase provides (business) use cases for the zettelstore.
+package usecase
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/place"
+)
+
+// SuggestZettelPort is the interface used by this use case.
+type SuggestZettelPort interface {
+	// GetMeta retrieves just the meta data of a specific zettel.
+	GetMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error)
+}
+
+// Limits of suggestions, because they can be made anonymously.
+const (
+	MaxSuggestionSize    = 64 << 10  // Maximum size of content and note
+	suggestionWindow     = time.Hour // Time span of the following limits
+	maxClientSuggestions = 5         // Maximum number per client
+	maxSuggestions       = 50        // Maximum number of all clients
+)
+
+// ErrTooManySuggestions is returned if a client, or all clients together,
+// made too many suggestions recently.
+type ErrTooManySuggestions struct {
+	Zid id.Zid
+}
+
+func (err *ErrTooManySuggestions) Error() string {
+	return "Too many suggestions were made recently, please try again later"
+}
+
+// SuggestZettel is the data for this use case.
+type SuggestZettel struct {
+	port    SuggestZettelPort
+	create  CreateZettel
+	limiter *suggestLimiter
+}
+
+// NewSuggestZettel creates a new use case. The port of the use case that
+// creates the suggestion must not check any policy, because anonymous
+// visitors are not allowed to create zettel. All copies of the use case
+// share the same limits.
+func NewSuggestZettel(port SuggestZettelPort, create CreateZettel) SuggestZettel {
+	return SuggestZettel{
+		port:    port,
+		create:  create,
+		limiter: &suggestLimiter{now: time.Now, clients: make(map[string][]time.Time)},
+	}
+}
+
+// CanSuggest returns true, if changes of the given zettel may be suggested.
+func CanSuggest(m *meta.Meta) bool {
+	return runtime.GetSuggestions() && runtime.GetVisibility(m) == meta.VisibilityPublic
+}
+
+// Run executes the use case. It stores the suggested content as a new
+// zettel with role "suggestion" that is only visible for the owner. The
+// original zettel is never changed. The client identifies the sender, e.g.
+// by its address, to limit the number of suggestions.
+func (uc SuggestZettel) Run(
+	ctx context.Context, client string, zid id.Zid, content, note string) (id.Zid, error) {
+	m, err := uc.port.GetMeta(ctx, zid)
+	if err != nil {
+		return id.Invalid, err
+	}
+	if !CanSuggest(m) {
+		return id.Invalid, place.NewErrNotAllowed("Suggest", nil, zid)
+	}
+	if size := int64(len(content) + len(note)); size > MaxSuggestionSize {
+		return id.Invalid, &ErrZettelTooLarge{Zid: zid, Size: size, Max: MaxSuggestionSize}
+	}
+	if !uc.limiter.allow(client) {
+		return id.Invalid, &ErrTooManySuggestions{Zid: zid}
+	}
+	sm := meta.New(id.Invalid)
+	sm.Set(meta.KeyTitle, "Suggestion for "+m.GetDefault(meta.KeyTitle, zid.String()))
+	sm.Set(meta.KeyRole, meta.ValueRoleSuggestion)
+	sm.Set(meta.KeySyntax, m.GetDefault(meta.KeySyntax, runtime.GetDefaultSyntax()))
+	sm.Set(meta.KeyVisibility, meta.ValueVisibilityOwner)
+	sm.Set(meta.KeySuggestionFor, zid.String())
+	if note = strings.Join(strings.Fields(note), " "); note != "" {
+		sm.Set(meta.KeySuggestionNote, note)
+	}
+	return uc.create.Run(ctx, nil, domain.Zettel{Meta: sm, Content: domain.NewContent(content)})
+}
+
+// suggestLimiter counts the recent suggestions, per client and in total.
+type suggestLimiter struct {
+	now func() time.Time
+
+	mx      sync.Mutex // protects all following fields
+	clients map[string][]time.Time
+	all     []time.Time
+}
+
+// allow returns true, if the client may make another suggestion, which is
+// then counted.
+func (sl *suggestLimiter) allow(client string) bool {
+	now := sl.now()
+	since := now.Add(-suggestionWindow)
+	sl.mx.Lock()
+	defer sl.mx.Unlock()
+	sl.all = recentTimes(sl.all, since)
+	for c, times := range sl.clients {
+		if times = recentTimes(times, since); len(times) > 0 {
+			sl.clients[c] = times
+		} else {
+			delete(sl.clients, c)
+		}
+	}
+	if len(sl.all) >= maxSuggestions || len(sl.clients[client]) >= maxClientSuggestions {
+		return false
+	}
+	sl.all = append(sl.all, now)
+	sl.clients[client] = append(sl.clients[client], now)
+	return true
+}
+
+// recentTimes removes all times before the given one. The times are sorted.
+func recentTimes(times []time.Time, since time.Time) []time.Time {
+	i := 0
+	for i < len(times) && times[i].Before(since) {
+		i++
+	}
+	return times[i:]
+}
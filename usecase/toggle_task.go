@@ -0,0 +1,79 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package usecase provides (business) use cases for the zettelstore.
+package usecase
+
+import (
+	"context"
+	"strconv"
+
+	"zettelstore.de/z/ast"
+	"zettelstore.de/z/collect"
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/parser"
+)
+
+// ToggleTaskPort is the interface used by this use case.
+type ToggleTaskPort interface {
+	// GetZettel retrieves a specific zettel.
+	GetZettel(ctx context.Context, zid id.Zid) (domain.Zettel, error)
+
+	// UpdateZettel updates an existing zettel.
+	UpdateZettel(ctx context.Context, zettel domain.Zettel) error
+}
+
+// ToggleTask is the data for this use case.
+type ToggleTask struct {
+	port   ToggleTaskPort
+	update UpdateZettel
+}
+
+// ErrNoSuchTask is returned if a zettel does not contain the given task.
+type ErrNoSuchTask struct {
+	Zid id.Zid
+	Num int
+}
+
+func (err *ErrNoSuchTask) Error() string {
+	return "Zettel " + err.Zid.String() + " has no task " + strconv.Itoa(err.Num)
+}
+
+// NewToggleTask creates a new use case.
+func NewToggleTask(port ToggleTaskPort, quota Quota) ToggleTask {
+	return ToggleTask{port: port, update: NewUpdateZettel(port, nil, quota)}
+}
+
+// Run executes the use case. Tasks are numbered from zero, in the order of
+// their appearance. It returns true, if the task is done afterwards.
+func (uc ToggleTask) Run(ctx context.Context, zid id.Zid, num int) (bool, error) {
+	zettel, err := uc.port.GetZettel(ctx, zid)
+	if err != nil {
+		return false, err
+	}
+	tasks := collect.Tasks(parser.ParseZettel(zettel, ""))
+	if num < 0 || num >= len(tasks) {
+		return false, &ErrNoSuchTask{Zid: zid, Num: num}
+	}
+	task := tasks[num]
+
+	// The task marker is "[ ]" or "[x]", starting at task.Pos.
+	content := []byte(zettel.Content.AsString())
+	done := task.State != ast.TaskDone
+	if done {
+		content[task.Pos+1] = 'x'
+	} else {
+		content[task.Pos+1] = ' '
+	}
+	zettel.Meta = zettel.Meta.Clone()
+	zettel.Content = domain.NewContent(string(content))
+	return done, uc.update.Run(ctx, zettel, true)
+}
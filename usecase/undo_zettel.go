@@ -0,0 +1,39 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package usecase provides (business) use cases for the zettelstore.
+package usecase
+
+import (
+	"context"
+
+	"zettelstore.de/z/domain/id"
+)
+
+// UndoZettelPort is the interface used by this use case.
+type UndoZettelPort interface {
+	// UndoZettel restores the previous version of the given zettel.
+	UndoZettel(ctx context.Context, zid id.Zid) error
+}
+
+// UndoZettel is the data for this use case.
+type UndoZettel struct {
+	port UndoZettelPort
+}
+
+// NewUndoZettel creates a new use case.
+func NewUndoZettel(port UndoZettelPort) UndoZettel {
+	return UndoZettel{port: port}
+}
+
+// Run executes the use case.
+func (uc UndoZettel) Run(ctx context.Context, zid id.Zid) error {
+	return uc.port.UndoZettel(ctx, zid)
+}
@@ -0,0 +1,85 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package usecase provides (business) use cases for the zettelstore.
+package usecase
+
+import (
+	"context"
+	"strings"
+
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+)
+
+// UpdateMetaPort is the interface used by this use case.
+type UpdateMetaPort interface {
+	// GetMeta retrieves just the meta data of a specific zettel.
+	GetMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error)
+
+	// UpdateMeta updates just the meta data of an existing zettel.
+	UpdateMeta(ctx context.Context, m *meta.Meta) error
+}
+
+// UpdateMeta is the data for this use case.
+type UpdateMeta struct {
+	port UpdateMetaPort
+}
+
+// NewUpdateMeta creates a new use case.
+func NewUpdateMeta(port UpdateMetaPort) UpdateMeta {
+	return UpdateMeta{port: port}
+}
+
+// ErrInvalidMetaKey is returned if a meta key cannot be changed.
+type ErrInvalidMetaKey struct{ Key string }
+
+func (err *ErrInvalidMetaKey) Error() string {
+	return "Meta key " + err.Key + " cannot be changed"
+}
+
+// Run executes the use case. Only the keys of the patch are changed: a key
+// with an empty value is removed, all other keys are set to the given value.
+// The content of the zettel is not sent to the place again.
+func (uc UpdateMeta) Run(ctx context.Context, zid id.Zid, patch map[string]string) error {
+	for key := range patch {
+		if key == meta.KeyID || key == meta.KeyCreator || !meta.KeyIsValid(key) {
+			return &ErrInvalidMetaKey{Key: key}
+		}
+	}
+	m, err := uc.port.GetMeta(ctx, zid)
+	if err != nil {
+		return err
+	}
+	m = m.Clone()
+	changed := false
+	for key, value := range patch {
+		oldValue, ok := m.Get(key)
+		if strings.TrimSpace(value) == "" {
+			if ok {
+				m.Delete(key)
+				changed = true
+			}
+			continue
+		}
+		m.Set(key, value)
+		if newValue, _ := m.Get(key); !ok || newValue != oldValue {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	m.SetNow(meta.KeyModified)
+	if zid == id.ConfigurationZid {
+		m.Set(meta.KeySyntax, meta.ValueSyntaxNone)
+	}
+	return uc.port.UpdateMeta(ctx, m)
+}
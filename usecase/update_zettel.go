@@ -30,12 +30,14 @@ type UpdateZettelPort interface {
 
 // UpdateZettel is the data for this use case.
 type UpdateZettel struct {
-	port UpdateZettelPort
+	port   UpdateZettelPort
+	linter TemplateLinter
+	quota  Quota
 }
 
 // NewUpdateZettel creates a new use case.
-func NewUpdateZettel(port UpdateZettelPort) UpdateZettel {
-	return UpdateZettel{port: port}
+func NewUpdateZettel(port UpdateZettelPort, linter TemplateLinter, quota Quota) UpdateZettel {
+	return UpdateZettel{port: port, linter: linter, quota: quota}
 }
 
 // Run executes the use case.
@@ -50,11 +52,26 @@ func (uc UpdateZettel) Run(ctx context.Context, zettel domain.Zettel, hasContent
 	}
 	m.SetNow(meta.KeyModified)
 	m.YamlSep = oldZettel.Meta.YamlSep
+	if creator, ok := oldZettel.Meta.Get(meta.KeyCreator); ok {
+		m.Set(meta.KeyCreator, creator)
+	} else {
+		m.Delete(meta.KeyCreator)
+	}
 	if m.Zid == id.ConfigurationZid {
 		m.Set(meta.KeySyntax, meta.ValueSyntaxNone)
 	}
 	if !hasContent {
 		zettel.Content = oldZettel.Content
+	} else if err = checkZettelSize(zettel); err != nil {
+		return err
+	} else if len(zettel.Content.AsBytes()) > len(oldZettel.Content.AsBytes()) {
+		// Shrinking a zettel is always allowed, even if the quota is exceeded.
+		if err = uc.quota.check(ctx, zettel, false); err != nil {
+			return err
+		}
+	}
+	if err = lintTemplate(uc.linter, zettel); err != nil {
+		return err
 	}
 	return uc.port.UpdateZettel(ctx, zettel)
 }
@@ -0,0 +1,86 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package usecase provides (business) use cases for the zettelstore.
+package usecase
+
+import (
+	"fmt"
+	"io"
+
+	"zettelstore.de/z/config/startup"
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+)
+
+// ErrZettelTooLarge is returned if the content of a zettel exceeds the
+// configured maximum size.
+type ErrZettelTooLarge struct {
+	Zid  id.Zid
+	Size int64
+	Max  int64
+}
+
+func (err *ErrZettelTooLarge) Error() string {
+	return fmt.Sprintf(
+		"Zettel content is too large: it has %v, but at most %v are allowed",
+		FormatSize(err.Size), FormatSize(err.Max))
+}
+
+// FormatSize returns a human readable representation of a number of bytes.
+func FormatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%v bytes", n)
+}
+
+// checkZettelSize returns an error, if the content of the zettel is larger
+// than allowed by the startup configuration.
+func checkZettelSize(zettel domain.Zettel) error {
+	max := startup.MaxZettelSize()
+	if max <= 0 {
+		return nil
+	}
+	if size := int64(len(zettel.Content.AsString())); size > max {
+		return &ErrZettelTooLarge{Zid: zettel.Meta.Zid, Size: size, Max: max}
+	}
+	return nil
+}
+
+// sizeReader reads the content of a new zettel. It returns an error as soon as
+// the content is larger than allowed by the startup configuration or by the
+// quota of its creator.
+type sizeReader struct {
+	r       io.Reader
+	size    int64
+	max     int64
+	creator id.Zid // quota is only checked, if valid
+	usage   QuotaUsage
+}
+
+func (sr *sizeReader) Read(p []byte) (int, error) {
+	n, err := sr.r.Read(p)
+	sr.size += int64(n)
+	if sr.max > 0 && sr.size > sr.max {
+		return n, &ErrZettelTooLarge{Size: sr.size, Max: sr.max}
+	}
+	if sr.creator.IsValid() {
+		usage := sr.usage
+		usage.Size += sr.size
+		if usage.exceeded() {
+			return n, &ErrQuotaExceeded{User: sr.creator, Usage: usage}
+		}
+	}
+	return n, err
+}
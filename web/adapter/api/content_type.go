@@ -11,9 +11,15 @@
 // Package api provides api handlers for web requests.
 package api
 
+import (
+	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/mimetype"
+)
+
 const plainText = "text/plain; charset=utf-8"
 
 var mapFormat2CT = map[string]string{
+	"csv":    "text/csv; charset=utf-8",
 	"html":   "text/html; charset=utf-8",
 	"native": plainText,
 	"json":   "application/json",
@@ -31,27 +37,6 @@ func format2ContentType(format string) string {
 	return ct
 }
 
-var mapSyntax2CT = map[string]string{
-	"css":      "text/css; charset=utf-8",
-	"gif":      "image/gif",
-	"html":     "text/html; charset=utf-8",
-	"jpeg":     "image/jpeg",
-	"jpg":      "image/jpeg",
-	"js":       "text/javascript; charset=utf-8",
-	"pdf":      "application/pdf",
-	"png":      "image/png",
-	"svg":      "image/svg+xml",
-	"xml":      "text/xml; charset=utf-8",
-	"zmk":      "text/x-zmk; charset=utf-8",
-	"plain":    plainText,
-	"text":     plainText,
-	"markdown": "text/markdown; charset=utf-8",
-	"md":       "text/markdown; charset=utf-8",
-	"mustache": plainText,
-	//"graphviz":      "text/vnd.graphviz; charset=utf-8",
-}
-
 func syntax2contentType(syntax string) (string, bool) {
-	contentType, ok := mapSyntax2CT[syntax]
-	return contentType, ok
+	return mimetype.ContentType(syntax, runtime.GetMIMETypes())
 }
@@ -0,0 +1,57 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package api provides api handlers for web requests.
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"zettelstore.de/z/encoder"
+	"zettelstore.de/z/web/adapter"
+)
+
+type jsonEndpoint struct {
+	URL        string               `json:"url"`
+	Method     string               `json:"method"`
+	Formats    []string             `json:"formats"`
+	Parts      []string             `json:"parts"`
+	Parameters []adapter.QueryParam `json:"parameters"`
+}
+
+// MakeDescribeListHandler creates a new HTTP handler that describes the
+// query parameters of the zettel list endpoint in a machine-readable way.
+func MakeDescribeListHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format := adapter.GetFormat(r, r.URL.Query(), encoder.GetDefaultFormat())
+		if format != "json" {
+			adapter.BadRequest(w, r, fmt.Sprintf("Endpoint description not available in format %q", format))
+			return
+		}
+		params := append([]adapter.QueryParam{
+			{Name: "_format", Value: "FORMAT", Description: "Format of the returned list."},
+			{Name: "_part", Value: "PART", Description: "Part of each zettel to be returned."},
+			{Name: "_columns", Value: "KEYS",
+				Description: "Meta keys returned in format csv, in addition to id and title."},
+		}, adapter.FilterSorterParams(false)...)
+		w.Header().Set("Content-Type", format2ContentType(format))
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.Encode(jsonEndpoint{
+			URL:        adapter.NewURLBuilder(r.Context(), 'z').String(),
+			Method:     http.MethodGet,
+			Formats:    []string{"json", "djson", "html", "csv"},
+			Parts:      []string{"zettel", "meta", "content", "id"},
+			Parameters: params,
+		})
+	}
+}
@@ -12,13 +12,16 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"sort"
 	"strconv"
 
 	"zettelstore.de/z/ast"
 	"zettelstore.de/z/collect"
 	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
 	"zettelstore.de/z/usecase"
 	"zettelstore.de/z/web/adapter"
 )
@@ -27,10 +30,10 @@ type jsonGetLinks struct {
 	ID    string `json:"id"`
 	URL   string `json:"url"`
 	Links struct {
-		Incoming []jsonIDURL `json:"incoming"`
-		Outgoing []jsonIDURL `json:"outgoing"`
-		Local    []string    `json:"local"`
-		External []string    `json:"external"`
+		Incoming []jsonRelLink `json:"incoming"`
+		Outgoing []jsonRelLink `json:"outgoing"`
+		Local    []string      `json:"local"`
+		External []string      `json:"external"`
 	} `json:"links"`
 	Images struct {
 		Outgoing []jsonIDURL `json:"outgoing"`
@@ -40,19 +43,29 @@ type jsonGetLinks struct {
 	Cites []string `json:"cites"`
 }
 
-// MakeGetLinksHandler creates a new API handler to return links to other material.
-func MakeGetLinksHandler(parseZettel usecase.ParseZettel) http.HandlerFunc {
+// jsonRelLink is a link to another zettel, together with its relation type.
+type jsonRelLink struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+	Rel string `json:"rel,omitempty"`
+}
+
+// MakeGetLinksHandler creates a new API handler to return links to other
+// material. The query parameter "rel" restricts incoming and outgoing zettel
+// links to the given relation type.
+func MakeGetLinksHandler(
+	parseZettel usecase.ParseZettel, listRelations usecase.ListRelations) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		zid, err := id.Parse(r.URL.Path[1:])
 		if err != nil {
-			http.NotFound(w, r)
+			adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
 			return
 		}
 		ctx := r.Context()
 		q := r.URL.Query()
 		zn, err := parseZettel.Run(ctx, zid, q.Get("syntax"))
 		if err != nil {
-			adapter.ReportUsecaseError(w, err)
+			adapter.ReportUsecaseError(w, r, err)
 			return
 		}
 		summary := collect.References(zn)
@@ -60,23 +73,30 @@ func MakeGetLinksHandler(parseZettel usecase.ParseZettel) http.HandlerFunc {
 		kind := getKindFromValue(q.Get("kind"))
 		matter := getMatterFromValue(q.Get("matter"))
 		if !validKindMatter(kind, matter) {
-			adapter.BadRequest(w, "Invalid kind/matter")
+			adapter.BadRequest(w, r, "Invalid kind/matter")
 			return
 		}
 
 		outData := jsonGetLinks{
 			ID:  zid.String(),
-			URL: adapter.NewURLBuilder('z').SetZid(zid).String(),
+			URL: adapter.NewURLBuilder(ctx, 'z').SetZid(zid).String(),
 		}
 		if kind&kindLink != 0 {
+			rel := q.Get("rel")
+			_, hasRel := q["rel"]
 			if matter&matterIncoming != 0 {
-				// Backlinks not yet implemented
-				outData.Links.Incoming = []jsonIDURL{}
+				relations, err1 := listRelations.Run(ctx, zid)
+				if err1 != nil {
+					adapter.ReportUsecaseError(w, r, err1)
+					return
+				}
+				outData.Links.Incoming = incomingRelLinks(ctx, relations, rel, hasRel)
 			}
-			zetRefs, locRefs, extRefs := collect.DivideReferences(summary.Links, false)
+			zetRefs, _, _ := collect.DivideReferences(summary.Links, true)
 			if matter&matterOutgoing != 0 {
-				outData.Links.Outgoing = idURLRefs(zetRefs)
+				outData.Links.Outgoing = outgoingRelLinks(ctx, zetRefs, summary.Relations, rel, hasRel)
 			}
+			_, locRefs, extRefs := collect.DivideReferences(summary.Links, false)
 			if matter&matterLocal != 0 {
 				outData.Links.Local = stringRefs(locRefs)
 			}
@@ -87,7 +107,7 @@ func MakeGetLinksHandler(parseZettel usecase.ParseZettel) http.HandlerFunc {
 		if kind&kindImage != 0 {
 			zetRefs, locRefs, extRefs := collect.DivideReferences(summary.Images, false)
 			if matter&matterOutgoing != 0 {
-				outData.Images.Outgoing = idURLRefs(zetRefs)
+				outData.Images.Outgoing = idURLRefs(ctx, zetRefs)
 			}
 			if matter&matterLocal != 0 {
 				outData.Images.Local = stringRefs(locRefs)
@@ -107,11 +127,11 @@ func MakeGetLinksHandler(parseZettel usecase.ParseZettel) http.HandlerFunc {
 	}
 }
 
-func idURLRefs(refs []*ast.Reference) []jsonIDURL {
+func idURLRefs(ctx context.Context, refs []*ast.Reference) []jsonIDURL {
 	result := make([]jsonIDURL, 0, len(refs))
 	for _, ref := range refs {
 		path := ref.URL.Path
-		ub := adapter.NewURLBuilder('z').AppendPath(path)
+		ub := adapter.NewURLBuilder(ctx, 'z').AppendPath(path)
 		if fragment := ref.URL.Fragment; len(fragment) > 0 {
 			ub.SetFragment(fragment)
 		}
@@ -120,6 +140,49 @@ func idURLRefs(refs []*ast.Reference) []jsonIDURL {
 	return result
 }
 
+func incomingRelLinks(
+	ctx context.Context, relations map[string][]*meta.Meta, rel string, hasRel bool) []jsonRelLink {
+	rels := make([]string, 0, len(relations))
+	for r := range relations {
+		if !hasRel || r == rel {
+			rels = append(rels, r)
+		}
+	}
+	sort.Strings(rels)
+	result := []jsonRelLink{}
+	for _, r := range rels {
+		for _, m := range relations[r] {
+			result = append(result, jsonRelLink{
+				ID:  m.Zid.String(),
+				URL: adapter.NewURLBuilder(ctx, 'z').SetZid(m.Zid).String(),
+				Rel: r,
+			})
+		}
+	}
+	return result
+}
+
+// outgoingRelLinks returns the given zettel references with their relation
+// type. A reference is listed once per relation type.
+func outgoingRelLinks(
+	ctx context.Context, refs []*ast.Reference, relations []collect.Relation,
+	rel string, hasRel bool) []jsonRelLink {
+	rels := make(map[*ast.Reference]string, len(relations))
+	for _, r := range relations {
+		rels[r.Ref] = r.Rel
+	}
+	seen := make(map[jsonRelLink]bool, len(refs))
+	result := make([]jsonRelLink, 0, len(refs))
+	for i, idURL := range idURLRefs(ctx, refs) {
+		link := jsonRelLink{ID: idURL.ID, URL: idURL.URL, Rel: rels[refs[i]]}
+		if (!hasRel || link.Rel == rel) && !seen[link] {
+			seen[link] = true
+			result = append(result, link)
+		}
+	}
+	return result
+}
+
 func stringRefs(refs []*ast.Reference) []string {
 	result := make([]string, 0, len(refs))
 	for _, ref := range refs {
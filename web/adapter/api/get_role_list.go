@@ -27,7 +27,7 @@ func MakeListRoleHandler(listRole usecase.ListRole) http.HandlerFunc {
 		ctx := r.Context()
 		roleList, err := listRole.Run(ctx)
 		if err != nil {
-			adapter.ReportUsecaseError(w, err)
+			adapter.ReportUsecaseError(w, r, err)
 			return
 		}
 
@@ -37,7 +37,7 @@ func MakeListRoleHandler(listRole usecase.ListRole) http.HandlerFunc {
 			w.Header().Set("Content-Type", format2ContentType(format))
 			renderListRoleJSON(w, roleList)
 		default:
-			adapter.BadRequest(w, fmt.Sprintf("Role list not available in format %q", format))
+			adapter.BadRequest(w, r, fmt.Sprintf("Role list not available in format %q", format))
 		}
 
 	}
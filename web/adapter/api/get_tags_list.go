@@ -30,7 +30,7 @@ func MakeListTagsHandler(listTags usecase.ListTags) http.HandlerFunc {
 		iMinCount, _ := strconv.Atoi(r.URL.Query().Get("min"))
 		tagData, err := listTags.Run(ctx, iMinCount)
 		if err != nil {
-			adapter.ReportUsecaseError(w, err)
+			adapter.ReportUsecaseError(w, r, err)
 			return
 		}
 
@@ -40,7 +40,7 @@ func MakeListTagsHandler(listTags usecase.ListTags) http.HandlerFunc {
 			w.Header().Set("Content-Type", format2ContentType(format))
 			renderListTagsJSON(w, tagData)
 		default:
-			adapter.BadRequest(w, fmt.Sprintf("Tags list not available in format %q", format))
+			adapter.BadRequest(w, r, fmt.Sprintf("Tags list not available in format %q", format))
 		}
 	}
 }
@@ -12,10 +12,16 @@
 package api
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
 
+	"zettelstore.de/z/ast"
+	"zettelstore.de/z/collect"
 	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/domain"
 	"zettelstore.de/z/domain/id"
 	"zettelstore.de/z/domain/meta"
 	"zettelstore.de/z/encoder"
@@ -25,40 +31,61 @@ import (
 
 // MakeGetZettelHandler creates a new HTTP handler to return a rendered zettel.
 func MakeGetZettelHandler(
-	parseZettel usecase.ParseZettel, getMeta usecase.GetMeta) http.HandlerFunc {
+	parseZettel usecase.ParseZettel,
+	getMeta usecase.GetMeta,
+	openContent usecase.OpenContent,
+) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		zid, err := id.Parse(r.URL.Path[1:])
 		if err != nil {
-			http.NotFound(w, r)
+			adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
 			return
 		}
 
 		ctx := r.Context()
 		q := r.URL.Query()
+		format := adapter.GetFormat(r, q, encoder.GetDefaultFormat())
+		part := getPart(q, "zettel")
+		if part == "content" && format == "raw" {
+			writeRawContent(w, r, zid, getMeta, openContent)
+			return
+		}
+
 		zn, err := parseZettel.Run(ctx, zid, q.Get("syntax"))
 		if err != nil {
-			adapter.ReportUsecaseError(w, err)
+			adapter.ReportUsecaseError(w, r, err)
 			return
 		}
+		if part == "section" {
+			mark := q.Get("_mark")
+			if mark == "" {
+				adapter.BadRequest(w, r, "Missing _mark parameter for _part=section")
+				return
+			}
+			section, ok := sectionZettel(zn, mark)
+			if !ok {
+				adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
+				return
+			}
+			zn, part = section, "content"
+		}
 
-		format := adapter.GetFormat(r, q, encoder.GetDefaultFormat())
-		part := getPart(q, "zettel")
 		switch format {
 		case "json", "djson":
 			switch part {
 			case "zettel", "meta", "content", "id":
 			default:
-				adapter.BadRequest(w, fmt.Sprintf("Unknown _part=%v parameter", part))
+				adapter.BadRequest(w, r, fmt.Sprintf("Unknown _part=%v parameter", part))
 				return
 			}
 			w.Header().Set("Content-Type", format2ContentType(format))
 			if format != "djson" {
-				err = writeJSONZettel(w, zn, part)
+				err = writeJSONZettel(ctx, w, zn, part)
 			} else {
 				err = writeDJSONZettel(ctx, w, zn, part, getMeta)
 			}
 			if err != nil {
-				adapter.InternalServerError(w, "Write D/JSON", err)
+				adapter.InternalServerError(w, r, "Write D/JSON", err)
 			}
 			return
 		}
@@ -67,7 +94,7 @@ func MakeGetZettelHandler(
 		linkAdapter := encoder.AdaptLinkOption{
 			Adapter: adapter.MakeLinkAdapter(ctx, 'z', getMeta, part, format),
 		}
-		imageAdapter := encoder.AdaptImageOption{Adapter: adapter.MakeImageAdapter()}
+		imageAdapter := encoder.AdaptImageOption{Adapter: adapter.MakeImageAdapter(ctx)}
 
 		switch part {
 		case "zettel":
@@ -100,13 +127,7 @@ func MakeGetZettelHandler(
 				err = writeMeta(w, zn.InhMeta, format)
 			}
 		case "content":
-			if format == "raw" {
-				if ct, ok := syntax2contentType(runtime.GetSyntax(zn.Zettel.Meta)); ok {
-					w.Header().Add("Content-Type", ct)
-				}
-			} else {
-				w.Header().Set("Content-Type", format2ContentType(format))
-			}
+			w.Header().Set("Content-Type", format2ContentType(format))
 			err = writeContent(w, zn, format,
 				&langOption,
 				&encoder.StringOption{
@@ -116,15 +137,66 @@ func MakeGetZettelHandler(
 				&imageAdapter,
 			)
 		default:
-			adapter.BadRequest(w, fmt.Sprintf("Unknown _part=%v parameter", part))
+			adapter.BadRequest(w, r, fmt.Sprintf("Unknown _part=%v parameter", part))
 			return
 		}
 		if err != nil {
 			if err == adapter.ErrNoSuchFormat {
-				adapter.BadRequest(w, fmt.Sprintf("Zettel %q not available in format %q", zid.String(), format))
+				adapter.BadRequest(w, r, fmt.Sprintf("Zettel %q not available in format %q", zid.String(), format))
 				return
 			}
-			adapter.InternalServerError(w, "Get zettel", err)
+			adapter.InternalServerError(w, r, "Get zettel", err)
 		}
 	}
 }
+
+// sectionZettel returns a copy of the zettel node that contains just the
+// section starting with the heading of the given mark. The content of the copy
+// is the section, encoded as Zettelmarkup.
+func sectionZettel(zn *ast.ZettelNode, mark string) (*ast.ZettelNode, bool) {
+	bs, ok := collect.Section(zn, mark)
+	if !ok {
+		return nil, false
+	}
+	var buf bytes.Buffer
+	encoder.Create("zmk").WriteBlocks(&buf, bs)
+	section := *zn
+	section.Zettel.Content = domain.NewContent(buf.String())
+	section.Ast = bs
+	return &section, true
+}
+
+// writeRawContent streams the uninterpreted content of a zettel, without
+// reading it completely into memory. If the content is stored in its own
+// file, range requests are supported, so that clients can seek within media.
+func writeRawContent(
+	w http.ResponseWriter,
+	r *http.Request,
+	zid id.Zid,
+	getMeta usecase.GetMeta,
+	openContent usecase.OpenContent,
+) {
+	ctx := r.Context()
+	m, err := getMeta.Run(ctx, zid)
+	if err != nil {
+		adapter.ReportUsecaseError(w, r, err)
+		return
+	}
+	rc, err := openContent.Run(ctx, zid)
+	if err != nil {
+		adapter.ReportUsecaseError(w, r, err)
+		return
+	}
+	defer rc.Close()
+	if ct, ok := syntax2contentType(runtime.GetSyntax(m)); ok {
+		w.Header().Add("Content-Type", ct)
+	}
+	if rs, ok := rc.(io.ReadSeeker); ok {
+		modified, _ := m.GetTime(meta.KeyPublished)
+		http.ServeContent(w, r, "", modified, rs)
+		return
+	}
+	if _, err = io.Copy(w, rc); err != nil {
+		log.Printf("Unable to write content of zettel %v: %v", zid, err)
+	}
+}
@@ -12,6 +12,7 @@
 package api
 
 import (
+	"encoding/csv"
 	"fmt"
 	"net/http"
 
@@ -34,7 +35,7 @@ func MakeListMetaHandler(
 		filter, sorter := adapter.GetFilterSorter(q, false)
 		metaList, err := listMeta.Run(r.Context(), filter, sorter)
 		if err != nil {
-			adapter.ReportUsecaseError(w, err)
+			adapter.ReportUsecaseError(w, r, err)
 			return
 		}
 
@@ -43,18 +44,21 @@ func MakeListMetaHandler(
 		w.Header().Set("Content-Type", format2ContentType(format))
 		switch format {
 		case "html":
-			renderListMetaHTML(w, metaList)
+			renderListMetaHTML(w, r, metaList)
 		case "json", "djson":
-			renderListMetaXJSON(r.Context(), w, metaList, format, part, getMeta, parseZettel)
+			renderListMetaXJSON(w, r, metaList, format, part, getMeta, parseZettel)
+		case "csv":
+			renderListMetaCSV(w, metaList, adapter.GetListColumns(q, "_columns"))
 		case "native", "raw", "text", "zmk":
-			adapter.NotImplemented(w, fmt.Sprintf("Zettel list in format %q not yet implemented", format))
+			adapter.NotImplemented(w, r, fmt.Sprintf("Zettel list in format %q not yet implemented", format))
 		default:
-			adapter.BadRequest(w, fmt.Sprintf("Zettel list not available in format %q", format))
+			adapter.BadRequest(w, r, fmt.Sprintf("Zettel list not available in format %q", format))
 		}
 	}
 }
 
-func renderListMetaHTML(w http.ResponseWriter, metaList []*meta.Meta) {
+func renderListMetaHTML(w http.ResponseWriter, r *http.Request, metaList []*meta.Meta) {
+	ctx := r.Context()
 	buf := encoder.NewBufWriter(w)
 
 	buf.WriteStrings("<html lang=\"", runtime.GetDefaultLang(), "\">\n<body>\n<ul>\n")
@@ -62,12 +66,12 @@ func renderListMetaHTML(w http.ResponseWriter, metaList []*meta.Meta) {
 		title := m.GetDefault(meta.KeyTitle, "")
 		htmlTitle, err := adapter.FormatInlines(parser.ParseTitle(title), "html")
 		if err != nil {
-			adapter.InternalServerError(w, "Format HTML inlines", err)
+			adapter.InternalServerError(w, r, "Format HTML inlines", err)
 			return
 		}
 		buf.WriteStrings(
 			"<li><a href=\"",
-			adapter.NewURLBuilder('z').SetZid(m.Zid).AppendQuery("format", "html").String(),
+			adapter.NewURLBuilder(ctx, 'z').SetZid(m.Zid).AppendQuery("format", "html").String(),
 			"\">",
 			htmlTitle,
 			"</a></li>\n")
@@ -75,3 +79,32 @@ func renderListMetaHTML(w http.ResponseWriter, metaList []*meta.Meta) {
 	buf.WriteString("</ul>\n</body>\n</html>")
 	buf.Flush()
 }
+
+// renderListMetaCSV writes the identifier, the title, and the values of the
+// given meta keys of all zettel as comma separated values.
+func renderListMetaCSV(w http.ResponseWriter, metaList []*meta.Meta, columns []string) {
+	keys := make([]string, 0, len(columns)+2)
+	keys = append(keys, meta.KeyID, meta.KeyTitle)
+	for _, key := range columns {
+		if key != meta.KeyID && key != meta.KeyTitle {
+			keys = append(keys, key)
+		}
+	}
+	cw := csv.NewWriter(w)
+	cw.Write(keys)
+	record := make([]string, len(keys))
+	for _, m := range metaList {
+		for i, key := range keys {
+			switch {
+			case key == meta.KeyID:
+				record[i] = m.Zid.String()
+			case m.Type(key) == meta.TypeCredential:
+				record[i] = ""
+			default:
+				record[i] = m.GetDefault(key, "")
+			}
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+}
@@ -0,0 +1,120 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package api provides api handlers for web requests.
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/input"
+	"zettelstore.de/z/mimetype"
+	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/adapter"
+	"zettelstore.de/z/web/session"
+)
+
+// maxImportHeader is the maximum size of the meta data of an imported zettel.
+const maxImportHeader = 64 << 10
+
+var errImportHeader = errors.New("meta data too large")
+
+type jsonImport struct {
+	Created []jsonIDURL `json:"created"`
+}
+
+// MakePostImportHandler creates a new HTTP handler to create zettel from a
+// multipart request. Every part is a zettel. A part without a file name or
+// with the extension ".zettel" is formatted like a zettel file: some lines of
+// meta data, an empty line, and the content. Other parts are just content,
+// whose syntax is derived from the file extension. The content is passed to
+// the place while it is read, so that large imports do not fill the memory.
+// Zettel that were created before an error occurred are kept.
+func MakePostImportHandler(createZettel usecase.CreateZettel) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		mr, err := r.MultipartReader()
+		if err != nil {
+			adapter.BadRequest(w, r, "Multipart request expected")
+			return
+		}
+		ctx := r.Context()
+		user := session.GetUser(ctx)
+		result := jsonImport{Created: []jsonIDURL{}}
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				adapter.BadRequest(w, r, "Unable to read multipart request")
+				return
+			}
+			var content io.Reader = part
+			m := meta.New(id.Invalid)
+			fileName := part.FileName()
+			if ext := filepath.Ext(fileName); fileName == "" || ext == ".zettel" {
+				br := bufio.NewReaderSize(part, maxImportHeader)
+				if m, err = readImportMeta(br); err != nil {
+					part.Close()
+					adapter.BadRequest(w, r, "Unable to read meta data of part "+part.FormName())
+					return
+				}
+				content = br
+			} else if len(ext) > 1 {
+				m.Set(meta.KeySyntax, mimetype.ExtSyntax(ext[1:]))
+			}
+			zid, err := createZettel.RunFrom(ctx, user, m, content)
+			part.Close()
+			if err != nil {
+				adapter.ReportUsecaseError(w, r, err)
+				return
+			}
+			result.Created = append(result.Created, jsonIDURL{
+				ID:  zid.String(),
+				URL: adapter.NewURLBuilder(ctx, 'z').SetZid(zid).String(),
+			})
+		}
+		w.Header().Set("Content-Type", format2ContentType("json"))
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+// readImportMeta reads the meta data of an imported zettel. The rest of the
+// reader is the content of the zettel.
+func readImportMeta(br *bufio.Reader) (*meta.Meta, error) {
+	var header []byte
+	for {
+		line, err := br.ReadSlice('\n')
+		header = append(header, line...)
+		if err == bufio.ErrBufferFull || len(header) > maxImportHeader {
+			return nil, errImportHeader
+		}
+		trimmed := bytes.TrimSpace(line)
+		if len(trimmed) == 0 || err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(header) > len(line) && string(trimmed) == "---" {
+			break
+		}
+	}
+	return meta.NewFromInput(id.Invalid, input.NewInput(string(header))), nil
+}
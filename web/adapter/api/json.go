@@ -52,11 +52,11 @@ type jsonContent struct {
 	Content  interface{} `json:"content"`
 }
 
-func writeJSONZettel(w http.ResponseWriter, z *ast.ZettelNode, part string) error {
+func writeJSONZettel(ctx context.Context, w http.ResponseWriter, z *ast.ZettelNode, part string) error {
 	var outData interface{}
 	idData := jsonIDURL{
 		ID:  z.Zid.String(),
-		URL: adapter.NewURLBuilder('z').SetZid(z.Zid).String(),
+		URL: adapter.NewURLBuilder(ctx, 'z').SetZid(z.Zid).String(),
 	}
 
 	switch part {
@@ -109,7 +109,7 @@ func writeDJSONZettel(
 ) (err error) {
 	switch part {
 	case "zettel":
-		err = writeDJSONHeader(w, z.Zid)
+		err = writeDJSONHeader(ctx, w, z.Zid)
 		if err == nil {
 			err = writeDJSONMeta(w, z)
 		}
@@ -117,17 +117,17 @@ func writeDJSONZettel(
 			err = writeDJSONContent(ctx, w, z, part, getMeta)
 		}
 	case "meta":
-		err = writeDJSONHeader(w, z.Zid)
+		err = writeDJSONHeader(ctx, w, z.Zid)
 		if err == nil {
 			err = writeDJSONMeta(w, z)
 		}
 	case "content":
-		err = writeDJSONHeader(w, z.Zid)
+		err = writeDJSONHeader(ctx, w, z.Zid)
 		if err == nil {
 			err = writeDJSONContent(ctx, w, z, part, getMeta)
 		}
 	case "id":
-		writeDJSONHeader(w, z.Zid)
+		writeDJSONHeader(ctx, w, z.Zid)
 	default:
 		panic(part)
 	}
@@ -147,7 +147,7 @@ var (
 	djsonFooter        = []byte("}")
 )
 
-func writeDJSONHeader(w http.ResponseWriter, zid id.Zid) error {
+func writeDJSONHeader(ctx context.Context, w http.ResponseWriter, zid id.Zid) error {
 	_, err := w.Write(djsonHeader1)
 	if err == nil {
 		_, err = w.Write(zid.Bytes())
@@ -156,7 +156,7 @@ func writeDJSONHeader(w http.ResponseWriter, zid id.Zid) error {
 		_, err = w.Write(djsonHeader2)
 	}
 	if err == nil {
-		_, err = io.WriteString(w, adapter.NewURLBuilder('z').SetZid(zid).String())
+		_, err = io.WriteString(w, adapter.NewURLBuilder(ctx, 'z').SetZid(zid).String())
 	}
 	if err == nil {
 		_, err = w.Write(djsonHeader3)
@@ -191,7 +191,7 @@ func writeDJSONContent(
 			&encoder.AdaptLinkOption{
 				Adapter: adapter.MakeLinkAdapter(ctx, 'z', getMeta, part, "djson"),
 			},
-			&encoder.AdaptImageOption{Adapter: adapter.MakeImageAdapter()},
+			&encoder.AdaptImageOption{Adapter: adapter.MakeImageAdapter(ctx)},
 		)
 	}
 	return err
@@ -206,13 +206,14 @@ var (
 var setJSON = map[string]bool{"json": true}
 
 func renderListMetaXJSON(
-	ctx context.Context,
 	w http.ResponseWriter,
+	r *http.Request,
 	metaList []*meta.Meta,
 	format string, part string,
 	getMeta usecase.GetMeta,
 	parseZettel usecase.ParseZettel,
 ) {
+	ctx := r.Context()
 	var readZettel bool
 	switch part {
 	case "zettel", "content":
@@ -220,7 +221,7 @@ func renderListMetaXJSON(
 	case "meta", "id":
 		readZettel = false
 	default:
-		adapter.BadRequest(w, fmt.Sprintf("Unknown _part=%v parameter", part))
+		adapter.BadRequest(w, r, fmt.Sprintf("Unknown _part=%v parameter", part))
 		return
 	}
 	isJSON := setJSON[format]
@@ -254,7 +255,7 @@ func renderListMetaXJSON(
 			}
 		}
 		if isJSON {
-			err = writeJSONZettel(w, zn, part)
+			err = writeJSONZettel(ctx, w, zn, part)
 		} else {
 			err = writeDJSONZettel(ctx, w, zn, part, getMeta)
 		}
@@ -263,7 +264,7 @@ func renderListMetaXJSON(
 		_, err = w.Write(jsonListFooter)
 	}
 	if err != nil {
-		adapter.InternalServerError(w, "Get list", err)
+		adapter.InternalServerError(w, r, "Get list", err)
 	}
 }
 
@@ -0,0 +1,128 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package api provides api handlers for web requests.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/parser"
+	"zettelstore.de/z/place"
+	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/adapter"
+)
+
+// jsonLinkInfo contains all data needed to link to a zettel, e.g. by a
+// browser extension.
+type jsonLinkInfo struct {
+	ID    string `json:"id"`
+	URL   string `json:"url"`   // Canonical URL of the zettel in the web user interface
+	Title string `json:"title"` // Title as plain text
+	Link  string `json:"link"`  // Link in Zettelmarkup
+}
+
+type jsonLinkInfoList struct {
+	List []jsonLinkInfo `json:"list"`
+}
+
+// MakeGetLinkInfoHandler creates a new API handler to return the data needed
+// to link to a zettel.
+func MakeGetLinkInfoHandler(getMeta usecase.GetMeta) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		zid, err := id.Parse(r.URL.Path[1:])
+		if err != nil {
+			adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
+			return
+		}
+		ctx := r.Context()
+		m, err := getMeta.Run(ctx, zid)
+		if err != nil {
+			adapter.ReportUsecaseError(w, r, err)
+			return
+		}
+		writeLinkInfo(w, buildLinkInfo(ctx, m))
+	}
+}
+
+// MakeLookupURLHandler creates a new API handler to return the data needed to
+// link to all zettel that store the given URL in their metadata. It allows a
+// browser extension to check whether a web page was already captured.
+func MakeLookupURLHandler(listMeta usecase.ListMeta) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u := normalizeURL(r.URL.Query().Get("url"))
+		if u == "" {
+			adapter.BadRequest(w, r, "Missing or invalid URL")
+			return
+		}
+		filter := &place.Filter{
+			Select: func(m *meta.Meta) bool {
+				val, ok := m.Get(meta.KeyURL)
+				return ok && normalizeURL(val) == u
+			},
+		}
+		ctx := r.Context()
+		metaList, err := listMeta.Run(ctx, filter, nil)
+		if err != nil {
+			adapter.ReportUsecaseError(w, r, err)
+			return
+		}
+		result := jsonLinkInfoList{List: make([]jsonLinkInfo, 0, len(metaList))}
+		for _, m := range metaList {
+			result.List = append(result.List, buildLinkInfo(ctx, m))
+		}
+		writeLinkInfo(w, result)
+	}
+}
+
+func buildLinkInfo(ctx context.Context, m *meta.Meta) jsonLinkInfo {
+	title, err := adapter.FormatInlines(
+		parser.ParseTitle(m.GetDefault(meta.KeyTitle, "")), "text")
+	if err != nil || title == "" {
+		title = m.Zid.String()
+	}
+	return jsonLinkInfo{
+		ID:    m.Zid.String(),
+		URL:   adapter.NewCanonicalURLBuilder('h').SetZid(m.Zid).String(),
+		Title: title,
+		Link:  "[[" + linkTextEscaper.Replace(title) + "|" + m.Zid.String() + "]]",
+	}
+}
+
+// linkTextEscaper escapes all characters that would end the text of a link.
+var linkTextEscaper = strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`, `|`, `\|`)
+
+// normalizeURL returns the given URL without fragment and trailing slash, so
+// that slightly different URLs of the same web page are found. An empty
+// string is returned, if the URL is not absolute.
+func normalizeURL(s string) string {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		return ""
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	return u.String()
+}
+
+func writeLinkInfo(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", format2ContentType("json"))
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.Encode(data)
+}
@@ -13,7 +13,9 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"zettelstore.de/z/auth/token"
@@ -42,14 +44,19 @@ func authenticateViaJSON(
 	r *http.Request,
 	authDuration time.Duration,
 ) {
-	token, err := authenticateForJSON(auth, w, r, authDuration)
+	scope, err := getScope(r)
 	if err != nil {
-		adapter.ReportUsecaseError(w, err)
+		adapter.BadRequest(w, r, err.Error())
+		return
+	}
+	token, err := authenticateForJSON(auth, w, r, authDuration, scope)
+	if err != nil {
+		adapter.ReportUsecaseError(w, r, err)
 		return
 	}
 	if token == nil {
 		w.Header().Set("WWW-Authenticate", `Bearer realm="Default"`)
-		http.Error(w, "Authentication failed", http.StatusUnauthorized)
+		adapter.ReportError(w, r, adapter.NewError(http.StatusUnauthorized, "Authentication failed"))
 		return
 	}
 
@@ -62,6 +69,7 @@ func authenticateForJSON(
 	w http.ResponseWriter,
 	r *http.Request,
 	authDuration time.Duration,
+	scope token.Scope,
 ) ([]byte, error) {
 	ident, cred, ok := adapter.GetCredentialsViaForm(r)
 	if !ok {
@@ -69,10 +77,36 @@ func authenticateForJSON(
 			return nil, nil
 		}
 	}
-	token, err := auth.Run(r.Context(), ident, cred, authDuration, token.KindJSON)
+	token, err := auth.Run(r.Context(), ident, cred, authDuration, token.KindJSON, scope)
 	return token, err
 }
 
+// getScope returns the scope of the requested token. It is given by the form
+// values "scope", with one of "readonly", "role:ROLE", and "tag:TAG". The
+// leading "#" of TAG is optional.
+func getScope(r *http.Request) (token.Scope, error) {
+	var scope token.Scope
+	if err := r.ParseForm(); err != nil {
+		return scope, err
+	}
+	for _, val := range r.Form["scope"] {
+		switch {
+		case val == "readonly":
+			scope.ReadOnly = true
+		case strings.HasPrefix(val, "role:") && len(val) > len("role:"):
+			scope.Role = val[len("role:"):]
+		case strings.HasPrefix(val, "tag:") && len(val) > len("tag:"):
+			scope.Tag = val[len("tag:"):]
+			if scope.Tag[0] != '#' {
+				scope.Tag = "#" + scope.Tag
+			}
+		default:
+			return token.Scope{}, fmt.Errorf("Unknown scope %q", val)
+		}
+	}
+	return scope, nil
+}
+
 func writeJSONToken(w http.ResponseWriter, token string, lifetime time.Duration) {
 	je := json.NewEncoder(w)
 	je.Encode(struct {
@@ -92,7 +126,7 @@ func MakeRenewAuthHandler() http.HandlerFunc {
 		ctx := r.Context()
 		auth := session.GetAuthData(ctx)
 		if auth == nil || auth.Token == nil || auth.User == nil {
-			adapter.BadRequest(w, "Not authenticated")
+			adapter.BadRequest(w, r, "Not authenticated")
 			return
 		}
 		totalLifetime := auth.Expires.Sub(auth.Issued)
@@ -106,9 +140,9 @@ func MakeRenewAuthHandler() http.HandlerFunc {
 
 		// Toke is a little bit aged. Create a new one
 		_, apiDur := startup.TokenLifetime()
-		token, err := token.GetToken(auth.User, apiDur, token.KindJSON)
+		token, err := token.GetScopedToken(auth.User, apiDur, token.KindJSON, auth.Scope)
 		if err != nil {
-			adapter.ReportUsecaseError(w, err)
+			adapter.ReportUsecaseError(w, r, err)
 			return
 		}
 		w.Header().Set("Content-Type", format2ContentType("json"))
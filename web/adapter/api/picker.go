@@ -0,0 +1,91 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package api provides api handlers for web requests.
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/place"
+	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/adapter"
+)
+
+// Number of zettel returned by the picker, if not specified otherwise.
+const (
+	pickerDefaultLimit = 10
+	pickerMaxLimit     = 50
+)
+
+// MakeZettelPickerHandler creates a new API handler to search zettel by
+// their title, while the user is typing. All words of query parameter "q"
+// must be contained in the title. The result may be restricted to a role
+// (parameter "role") and to a tag (parameter "tag").
+func MakeZettelPickerHandler(listMeta usecase.ListMeta) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		words := strings.Fields(strings.ToLower(q.Get("q")))
+		role := q.Get("role")
+		tag := q.Get("tag")
+		if tag != "" && tag[0] != '#' {
+			tag = "#" + tag
+		}
+		limit, err := strconv.Atoi(q.Get("limit"))
+		if err != nil || limit <= 0 {
+			limit = pickerDefaultLimit
+		} else if limit > pickerMaxLimit {
+			limit = pickerMaxLimit
+		}
+
+		filter := &place.Filter{
+			Select: func(m *meta.Meta) bool {
+				if role != "" && m.GetDefault(meta.KeyRole, "") != role {
+					return false
+				}
+				if tag != "" && !hasTag(m, tag) {
+					return false
+				}
+				title := strings.ToLower(m.GetDefault(meta.KeyTitle, ""))
+				for _, word := range words {
+					if !strings.Contains(title, word) {
+						return false
+					}
+				}
+				return true
+			},
+		}
+		sorter := &place.Sorter{Order: meta.KeyTitle, Limit: limit}
+		ctx := r.Context()
+		metaList, err := listMeta.Run(ctx, filter, sorter)
+		if err != nil {
+			adapter.ReportUsecaseError(w, r, err)
+			return
+		}
+		result := jsonLinkInfoList{List: make([]jsonLinkInfo, 0, len(metaList))}
+		for _, m := range metaList {
+			result.List = append(result.List, buildLinkInfo(ctx, m))
+		}
+		writeLinkInfo(w, result)
+	}
+}
+
+func hasTag(m *meta.Meta, tag string) bool {
+	tags, _ := m.GetList(meta.KeyTags)
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
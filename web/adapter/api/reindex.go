@@ -0,0 +1,79 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package api provides api handlers for web requests.
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"zettelstore.de/z/index"
+	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/adapter"
+	"zettelstore.de/z/web/session"
+)
+
+type jsonReindex struct {
+	Running  bool   `json:"running"`
+	Indexed  int    `json:"indexed"`
+	Total    int    `json:"total"`
+	Started  string `json:"started,omitempty"`
+	Finished string `json:"finished,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// MakePostReindexHandler creates a new HTTP handler that starts to rebuild the
+// index. It returns the progress of the rebuild.
+func MakePostReindexHandler(reindex usecase.Reindex) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		progress, err := reindex.Run(ctx, session.GetUser(ctx))
+		if err != nil {
+			adapter.ReportUsecaseError(w, r, err)
+			return
+		}
+		writeReindexProgress(w, progress, http.StatusAccepted)
+	}
+}
+
+// MakeGetReindexHandler creates a new HTTP handler that returns the progress
+// of the last rebuild of the index.
+func MakeGetReindexHandler(reindex usecase.Reindex) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		progress, err := reindex.Progress(session.GetUser(r.Context()))
+		if err != nil {
+			adapter.ReportUsecaseError(w, r, err)
+			return
+		}
+		writeReindexProgress(w, progress, http.StatusOK)
+	}
+}
+
+func writeReindexProgress(w http.ResponseWriter, progress index.Progress, code int) {
+	result := jsonReindex{
+		Running: progress.Running,
+		Indexed: progress.Indexed,
+		Total:   progress.Total,
+	}
+	if !progress.Started.IsZero() {
+		result.Started = progress.Started.UTC().Format(time.RFC3339)
+	}
+	if !progress.Finished.IsZero() {
+		result.Finished = progress.Finished.UTC().Format(time.RFC3339)
+	}
+	if progress.Err != nil {
+		result.Error = progress.Err.Error()
+	}
+	w.Header().Set("Content-Type", format2ContentType("json"))
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(result)
+}
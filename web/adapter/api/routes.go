@@ -0,0 +1,67 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package api provides api handlers for web requests.
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/web/adapter"
+	"zettelstore.de/z/web/router"
+)
+
+type jsonRoute struct {
+	URL         string `json:"url"`
+	Key         string `json:"key,omitempty"`
+	Method      string `json:"method,omitempty"`
+	Auth        bool   `json:"auth"`
+	Write       bool   `json:"write"`
+	API         bool   `json:"api"`
+	Admin       bool   `json:"admin"`
+	Description string `json:"description,omitempty"`
+}
+
+// MakeListRoutesHandler creates a new HTTP handler that lists all routes
+// that are currently available. It is only available in expert mode.
+func MakeListRoutesHandler(routes func() []router.RouteInfo) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !runtime.GetExpertMode() {
+			adapter.Forbidden(w, r, "The list of routes is only available in expert mode")
+			return
+		}
+		prefix := adapter.NewURLBuilder(r.Context(), '/').String()
+		infos := routes()
+		result := make([]jsonRoute, 0, len(infos))
+		for _, ri := range infos {
+			jr := jsonRoute{
+				URL:         prefix + ri.Path[1:],
+				Method:      ri.Method,
+				Auth:        ri.Auth == router.AuthUser,
+				Write:       ri.Write,
+				API:         ri.API,
+				Admin:       ri.Admin,
+				Description: ri.Description,
+			}
+			if ri.Key != 0 {
+				jr.Key = string(ri.Key)
+			}
+			result = append(result, jr)
+		}
+		w.Header().Set("Content-Type", format2ContentType("json"))
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.Encode(struct {
+			Routes []jsonRoute `json:"routes"`
+		}{result})
+	}
+}
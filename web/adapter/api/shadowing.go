@@ -0,0 +1,68 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package api provides api handlers for web requests.
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/adapter"
+)
+
+type jsonPlacement struct {
+	Place    int  `json:"place"`
+	Builtin  bool `json:"builtin"`
+	Computed bool `json:"computed"`
+}
+
+type jsonShadowing struct {
+	ID           string          `json:"id"`
+	URL          string          `json:"url"`
+	Shadows      bool            `json:"shadows"`
+	CanCustomize bool            `json:"can-customize"`
+	CanRevert    bool            `json:"can-revert"`
+	Places       []jsonPlacement `json:"places"`
+}
+
+// MakeGetShadowingHandler creates a new HTTP handler that returns all places
+// storing a zettel with the given identifier. The first place stores the
+// visible zettel, which shadows all others.
+func MakeGetShadowingHandler(getShadowing usecase.GetShadowing) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		zid, err := id.Parse(r.URL.Path[1:])
+		if err != nil {
+			adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
+			return
+		}
+		ctx := r.Context()
+		sh, err := getShadowing.Run(ctx, zid)
+		if err != nil {
+			adapter.ReportUsecaseError(w, r, err)
+			return
+		}
+		places := make([]jsonPlacement, 0, len(sh.Placements))
+		for _, p := range sh.Placements {
+			places = append(places, jsonPlacement{p.Place, p.Builtin, p.Computed})
+		}
+		w.Header().Set("Content-Type", format2ContentType("json"))
+		json.NewEncoder(w).Encode(jsonShadowing{
+			ID:           zid.String(),
+			URL:          adapter.NewURLBuilder(ctx, 'z').SetZid(zid).String(),
+			Shadows:      sh.Shadows(),
+			CanCustomize: sh.CanCustomize(),
+			CanRevert:    sh.CanRevert(),
+			Places:       places,
+		})
+	}
+}
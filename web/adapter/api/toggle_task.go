@@ -0,0 +1,61 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package api provides api handlers for web requests.
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/adapter"
+)
+
+type jsonToggleTask struct {
+	ID   string `json:"id"`
+	URL  string `json:"url"`
+	Task int    `json:"task"`
+	Done bool   `json:"done"`
+}
+
+// MakeToggleTaskHandler creates a new HTTP handler to toggle the state of
+// a task of a zettel. The task is given by its number, starting with zero.
+func MakeToggleTaskHandler(toggleTask usecase.ToggleTask) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		zid, err := id.Parse(r.URL.Path[1:])
+		if err != nil {
+			adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
+			return
+		}
+		num, err := strconv.Atoi(r.URL.Query().Get("_task"))
+		if err != nil {
+			adapter.BadRequest(w, r, "Missing or invalid _task parameter")
+			return
+		}
+		ctx := r.Context()
+		done, err := toggleTask.Run(ctx, zid, num)
+		if err != nil {
+			adapter.ReportUsecaseError(w, r, err)
+			return
+		}
+		w.Header().Set("Content-Type", format2ContentType("json"))
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.Encode(jsonToggleTask{
+			ID:   zid.String(),
+			URL:  adapter.NewURLBuilder(ctx, 'z').SetZid(zid).String(),
+			Task: num,
+			Done: done,
+		})
+	}
+}
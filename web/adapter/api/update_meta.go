@@ -0,0 +1,45 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package api provides api handlers for web requests.
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/adapter"
+)
+
+// MakeUpdateMetaHandler creates a new HTTP handler to change just the meta
+// data of a zettel. The request body is a JSON object that maps meta keys to
+// their new values. An empty value removes the key, all keys not mentioned
+// stay as they are.
+func MakeUpdateMetaHandler(updateMeta usecase.UpdateMeta) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		zid, err := id.Parse(r.URL.Path[1:])
+		if err != nil {
+			adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
+			return
+		}
+		var patch map[string]string
+		if err = json.NewDecoder(r.Body).Decode(&patch); err != nil {
+			adapter.BadRequest(w, r, "Unable to read meta data patch")
+			return
+		}
+		if err = updateMeta.Run(r.Context(), zid, patch); err != nil {
+			adapter.ReportUsecaseError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,73 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package api provides api handlers for web requests.
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"zettelstore.de/z/config/startup"
+)
+
+// VersionPath is the path of the build information, relative to the URL
+// prefix.
+const VersionPath = "version"
+
+type jsonVersion struct {
+	Program   string      `json:"program"`
+	Version   string      `json:"version"`
+	Commit    string      `json:"commit"`
+	Date      string      `json:"date"`
+	GoVersion string      `json:"go-version"`
+	OS        string      `json:"os"`
+	Arch      string      `json:"arch"`
+	Update    *jsonUpdate `json:"update,omitempty"`
+}
+
+type jsonUpdate struct {
+	Checked string `json:"checked,omitempty"`
+	Latest  string `json:"latest,omitempty"`
+	Newer   bool   `json:"newer"`
+	Error   string `json:"error,omitempty"`
+}
+
+// MakeGetVersionHandler creates a new HTTP handler to retrieve the build
+// information of the software, e.g. for monitoring many installations. If
+// a release feed was configured, the result of the last update check is
+// included.
+func MakeGetVersionHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		v := startup.GetVersion()
+		result := jsonVersion{
+			Program:   v.Prog,
+			Version:   v.Build,
+			Commit:    v.Commit,
+			Date:      v.Date,
+			GoVersion: v.GoVersion,
+			OS:        v.Os,
+			Arch:      v.Arch,
+		}
+		if checker := startup.UpdateChecker(); checker != nil {
+			status := checker.Status()
+			result.Update = &jsonUpdate{Latest: status.Latest, Newer: status.Newer}
+			if !status.Checked.IsZero() {
+				result.Update.Checked = status.Checked.UTC().Format(time.RFC3339)
+			}
+			if status.Err != nil {
+				result.Update.Error = status.Err.Error()
+			}
+		}
+		w.Header().Set("Content-Type", format2ContentType("json"))
+		json.NewEncoder(w).Encode(result)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"zettelstore.de/z/ast"
 	"zettelstore.de/z/domain/id"
 	"zettelstore.de/z/encoder"
+	"zettelstore.de/z/linkcheck"
 	"zettelstore.de/z/place"
 	"zettelstore.de/z/usecase"
 )
@@ -51,6 +52,9 @@ func MakeLinkAdapter(
 ) func(*ast.LinkNode) ast.InlineNode {
 	return func(origLink *ast.LinkNode) ast.InlineNode {
 		origRef := origLink.Ref
+		if origRef != nil && origRef.State == ast.RefStateExternal {
+			return markDeadLink(origLink)
+		}
 		if origRef == nil || origRef.State != ast.RefStateZettel {
 			return origLink
 		}
@@ -61,7 +65,7 @@ func MakeLinkAdapter(
 		_, err = getMeta.Run(ctx, zid)
 		newLink := *origLink
 		if err == nil {
-			u := NewURLBuilder(key).SetZid(zid)
+			u := NewURLBuilder(ctx, key).SetZid(zid)
 			if part != "" {
 				u.AppendQuery("_part", part)
 			}
@@ -74,6 +78,11 @@ func MakeLinkAdapter(
 			newRef := ast.ParseReference(u.String())
 			newRef.State = ast.RefStateZettelFound
 			newLink.Ref = newRef
+			if key == 'h' {
+				// The web user interface shows a preview when hovering over the link.
+				newLink.Attrs = origLink.Attrs.Clone().Set(
+					"data-preview", NewURLBuilder(ctx, 'w').SetZid(zid).String())
+			}
 			return &newLink
 		}
 		if place.IsErrNotAllowed(err) {
@@ -90,8 +99,19 @@ func MakeLinkAdapter(
 	}
 }
 
+// markDeadLink marks an external link, if the link checker found it to be
+// dead.
+func markDeadLink(origLink *ast.LinkNode) ast.InlineNode {
+	if !linkcheck.Default().IsDead(origLink.Ref.String()) {
+		return origLink
+	}
+	newLink := *origLink
+	newLink.Attrs = origLink.Attrs.Clone().AddClass("zs-dead").Set("title", "Link seems to be dead") // l10n
+	return &newLink
+}
+
 // MakeImageAdapter creates an adapter to change an image node during encoding.
-func MakeImageAdapter() func(*ast.ImageNode) ast.InlineNode {
+func MakeImageAdapter(ctx context.Context) func(*ast.ImageNode) ast.InlineNode {
 	return func(origImage *ast.ImageNode) ast.InlineNode {
 		if origImage.Ref == nil || origImage.Ref.State != ast.RefStateZettel {
 			return origImage
@@ -102,7 +122,7 @@ func MakeImageAdapter() func(*ast.ImageNode) ast.InlineNode {
 			panic(err)
 		}
 		newImage.Ref = ast.ParseReference(
-			NewURLBuilder('z').SetZid(zid).AppendQuery("_part", "content").AppendQuery(
+			NewURLBuilder(ctx, 'z').SetZid(zid).AppendQuery("_part", "content").AppendQuery(
 				"_format", "raw").String())
 		newImage.Ref.State = ast.RefStateZettelFound
 		return &newImage
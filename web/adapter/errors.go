@@ -12,28 +12,164 @@
 package adapter
 
 import (
+	"context"
+	"encoding/json"
 	"log"
 	"net/http"
+
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/web/router"
+)
+
+// Error describes an error that is reported to the client. API clients
+// receive it as a JSON object, the web user interface shows it as a page.
+type Error struct {
+	Status  int    // HTTP status code
+	Code    string // Short, stable identification of the kind of error
+	Message string // Text for humans
+	Zid     id.Zid // Zettel that caused the error, if valid
+	Field   string // Name of the form field or meta key that caused the error
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Codes of errors that are not caused by a use case.
+const (
+	CodeBadRequest       = "bad-request"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not-found"
+	CodeMethodNotAllowed = "method-not-allowed"
+	CodeConflict         = "conflict"
+	CodeTooLarge         = "too-large"
+	CodeInternal         = "internal"
+	CodeNotImplemented   = "not-implemented"
 )
 
+var statusCodes = map[int]string{
+	http.StatusBadRequest:            CodeBadRequest,
+	http.StatusUnauthorized:          CodeUnauthorized,
+	http.StatusForbidden:             CodeForbidden,
+	http.StatusNotFound:              CodeNotFound,
+	http.StatusMethodNotAllowed:      CodeMethodNotAllowed,
+	http.StatusConflict:              CodeConflict,
+	http.StatusRequestEntityTooLarge: CodeTooLarge,
+	http.StatusInternalServerError:   CodeInternal,
+	http.StatusNotImplemented:        CodeNotImplemented,
+}
+
+// NewError creates a new error with the given HTTP status code and message.
+func NewError(status int, text string) *Error {
+	code, ok := statusCodes[status]
+	if !ok {
+		code = CodeBadRequest
+		if status >= 500 {
+			code = CodeInternal
+		}
+	}
+	return &Error{Status: status, Code: code, Message: text}
+}
+
+// ErrorReporter writes an error to the client.
+type ErrorReporter func(w http.ResponseWriter, r *http.Request, e *Error)
+
+type ctxKeyErrorReporter struct{}
+
+// ErrorMiddleware returns a middleware that lets the given reporter write all
+// errors of requests that are not made by API clients.
+func ErrorMiddleware(rep ErrorReporter) router.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), ctxKeyErrorReporter{}, rep)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ReportError writes the given error to the client: as JSON, if the request
+// was made by an API client, and by the reporter of the web user interface
+// otherwise.
+func ReportError(w http.ResponseWriter, r *http.Request, e *Error) {
+	if isAPIRequest(r) {
+		writeJSONError(w, e)
+		return
+	}
+	if rep, ok := r.Context().Value(ctxKeyErrorReporter{}).(ErrorReporter); ok && rep != nil {
+		rep(w, r, e)
+		return
+	}
+	http.Error(w, e.Message, e.Status)
+}
+
+// ReportStatus writes an error with the given HTTP status code. It is used
+// for errors of the router.
+func ReportStatus(w http.ResponseWriter, r *http.Request, code int, text string) {
+	ReportError(w, r, NewError(code, text))
+}
+
+// isAPIRequest returns true, if the request is handled by an API route, or if
+// it asks for an encoding that is not shown to humans directly.
+func isAPIRequest(r *http.Request) bool {
+	if router.IsAPIRequest(r) {
+		return true
+	}
+	switch r.URL.Query().Get("_format") {
+	case "", "html", "raw":
+		return false
+	}
+	return true
+}
+
+type jsonError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Zid     string `json:"zid,omitempty"`
+	Field   string `json:"field,omitempty"`
+}
+
+func writeJSONError(w http.ResponseWriter, e *Error) {
+	je := jsonError{Code: e.Code, Message: e.Message, Field: e.Field}
+	if e.Zid.IsValid() {
+		je.Zid = e.Zid.String()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(e.Status)
+	json.NewEncoder(w).Encode(je)
+}
+
 // BadRequest signals HTTP status code 400.
-func BadRequest(w http.ResponseWriter, text string) {
-	http.Error(w, text, http.StatusBadRequest)
+func BadRequest(w http.ResponseWriter, r *http.Request, text string) {
+	ReportError(w, r, NewError(http.StatusBadRequest, text))
 }
 
 // Forbidden signals HTTP status code 403.
-func Forbidden(w http.ResponseWriter, text string) {
-	http.Error(w, text, http.StatusForbidden)
+func Forbidden(w http.ResponseWriter, r *http.Request, text string) {
+	ReportError(w, r, NewError(http.StatusForbidden, text))
 }
 
 // NotFound signals HTTP status code 404.
-func NotFound(w http.ResponseWriter, text string) {
-	http.Error(w, text, http.StatusNotFound)
+func NotFound(w http.ResponseWriter, r *http.Request, text string) {
+	ReportError(w, r, NewError(http.StatusNotFound, text))
+}
+
+// Conflict signals HTTP status code 409.
+func Conflict(w http.ResponseWriter, r *http.Request, text string) {
+	ReportError(w, r, NewError(http.StatusConflict, text))
+}
+
+// RequestEntityTooLarge signals HTTP status code 413.
+func RequestEntityTooLarge(w http.ResponseWriter, r *http.Request, text string) {
+	ReportError(w, r, NewError(http.StatusRequestEntityTooLarge, text))
 }
 
 // InternalServerError signals HTTP status code 500.
-func InternalServerError(w http.ResponseWriter, text string, err error) {
-	http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+func InternalServerError(w http.ResponseWriter, r *http.Request, text string, err error) {
+	ReportError(w, r, NewError(http.StatusInternalServerError, "Internal Server Error"))
+	logInternalError(text, err)
+}
+
+func logInternalError(text string, err error) {
 	if text == "" {
 		log.Println(err)
 	} else {
@@ -42,7 +178,7 @@ func InternalServerError(w http.ResponseWriter, text string, err error) {
 }
 
 // NotImplemented signals HTTP status code 501
-func NotImplemented(w http.ResponseWriter, text string) {
-	http.Error(w, text, http.StatusNotImplemented)
+func NotImplemented(w http.ResponseWriter, r *http.Request, text string) {
+	ReportError(w, r, NewError(http.StatusNotImplemented, text))
 	log.Println(text)
 }
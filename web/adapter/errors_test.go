@@ -0,0 +1,49 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package adapter provides handlers for web requests.
+package adapter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"zettelstore.de/z/usecase"
+)
+
+func TestReportError(t *testing.T) {
+	var reported *Error
+	handler := ErrorMiddleware(func(w http.ResponseWriter, r *http.Request, e *Error) {
+		reported = e
+		w.WriteHeader(e.Status)
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ReportUsecaseError(w, r, &usecase.ErrInvalidMetaKey{Key: "creator"})
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/h", nil))
+	if w.Code != http.StatusBadRequest || reported == nil ||
+		reported.Code != CodeInvalidMetaKey || reported.Field != "creator" {
+		t.Errorf("unexpected HTML error: %v %+v", w.Code, reported)
+	}
+
+	reported = nil
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/h?_format=json", nil))
+	exp := `{"code":"invalid-meta-key","message":"Meta key creator cannot be changed","field":"creator"}`
+	if got := strings.TrimSpace(w.Body.String()); w.Code != http.StatusBadRequest || got != exp {
+		t.Errorf("expected %v %q, but got %v %q", http.StatusBadRequest, exp, w.Code, got)
+	}
+	if reported != nil {
+		t.Errorf("JSON error was reported as HTML: %+v", reported)
+	}
+}
@@ -0,0 +1,33 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package adapter provides handlers for web requests.
+package adapter
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// MakeGetInfoHandler creates a new HTTP handler that returns information
+// about a zettel. The HTML page is the default, format "json" is handled by
+// the API handler.
+func MakeGetInfoHandler(apiHandler, htmlHandler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch format := GetFormat(r, r.URL.Query(), "html"); format {
+		case "json":
+			apiHandler(w, r)
+		case "html":
+			htmlHandler(w, r)
+		default:
+			BadRequest(w, r, fmt.Sprintf("Zettel info not available in format %q", format))
+		}
+	}
+}
@@ -14,6 +14,7 @@ package adapter
 import (
 	"net/http"
 
+	"zettelstore.de/z/domain/id"
 	"zettelstore.de/z/encoder"
 	"zettelstore.de/z/usecase"
 )
@@ -26,7 +27,7 @@ func MakeReloadHandler(
 	return func(w http.ResponseWriter, r *http.Request) {
 		err := reload.Run(r.Context())
 		if err != nil {
-			ReportUsecaseError(w, err)
+			ReportUsecaseError(w, r, err)
 			return
 		}
 
@@ -36,3 +37,27 @@ func MakeReloadHandler(
 		htmlHandler(w, r)
 	}
 }
+
+// MakeReloadZettelHandler creates a new HTTP handler for the use case "reload zettel".
+func MakeReloadZettelHandler(
+	reloadZettel usecase.ReloadZettel,
+	apiHandler func(http.ResponseWriter, *http.Request, string),
+	htmlHandler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		zid, err := id.Parse(r.URL.Path[1:])
+		if err != nil {
+			NotFound(w, r, http.StatusText(http.StatusNotFound))
+			return
+		}
+		if err = reloadZettel.Run(r.Context(), zid); err != nil {
+			ReportUsecaseError(w, r, err)
+			return
+		}
+
+		if format := GetFormat(r, r.URL.Query(), encoder.GetDefaultFormat()); format != "html" {
+			apiHandler(w, r, format)
+			return
+		}
+		htmlHandler(w, r)
+	}
+}
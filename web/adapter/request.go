@@ -14,53 +14,158 @@ package adapter
 import (
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 
+	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/domain/id"
 	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/encoder"
 	"zettelstore.de/z/place"
 )
 
-// GetFormat returns the data format selected by the caller.
+// GetFormat returns the data format selected by the caller. The query
+// parameter "_format" overrides the "Accept" header. If neither specifies an
+// available format, the "Content-Type" header is used.
 func GetFormat(r *http.Request, q url.Values, defFormat string) string {
 	format := q.Get("_format")
 	if len(format) > 0 {
 		return format
 	}
-	if format, ok := getOneFormat(r, "Accept"); ok {
-		return format
-	}
-	if format, ok := getOneFormat(r, "Content-Type"); ok {
-		return format
+	if values, ok := r.Header["Accept"]; ok {
+		if format, ok := negotiateFormat(values, defFormat); ok {
+			return format
+		}
 	}
-	return defFormat
-}
-
-func getOneFormat(r *http.Request, key string) (string, bool) {
-	if values, ok := r.Header[key]; ok {
+	if values, ok := r.Header["Content-Type"]; ok {
 		for _, value := range values {
 			if format, ok := contentType2format(value); ok {
-				return format, true
+				return format
 			}
 		}
 	}
-	return "", false
+	return defFormat
 }
 
 var mapCT2format = map[string]string{
-	"application/json": "json",
-	"text/html":        "html",
+	"application/json":      "json",
+	"application/xhtml+xml": "html",
+	"text/html":             "html",
+	"text/plain":            "text",
+	"text/x-zmk":            "zmk",
+}
+
+// preferredMediaTypes lists the media types of mapCT2format in the order of
+// preference, if a media range like "text/*" matches more than one of them.
+var preferredMediaTypes = []string{
+	"text/html", "application/json", "text/plain", "text/x-zmk", "application/xhtml+xml",
 }
 
+// contentType2format returns the format for a media type, if an encoder for
+// this format is available.
 func contentType2format(contentType string) (string, bool) {
-	// TODO: only check before first ';'
-	format, ok := mapCT2format[contentType]
-	return format, ok
+	if pos := strings.IndexByte(contentType, ';'); pos >= 0 {
+		contentType = contentType[:pos]
+	}
+	format, ok := mapCT2format[strings.ToLower(strings.TrimSpace(contentType))]
+	if !ok || encoder.Create(format) == nil {
+		return "", false
+	}
+	return format, true
+}
+
+type mediaRange struct {
+	mediaType string
+	quality   float64
+	order     int
+}
+
+// specificity returns 2 for a complete media type, 1 for "type/*", and 0
+// for "*/*".
+func (mr *mediaRange) specificity() int {
+	if mr.mediaType == "*/*" {
+		return 0
+	}
+	if strings.HasSuffix(mr.mediaType, "/*") {
+		return 1
+	}
+	return 2
+}
+
+// negotiateFormat selects the best available format according to the given
+// values of an "Accept" header.
+func negotiateFormat(values []string, defFormat string) (string, bool) {
+	ranges := parseAccept(values)
+	for _, mr := range ranges {
+		switch mr.specificity() {
+		case 0:
+			return defFormat, true
+		case 1:
+			prefix := mr.mediaType[:len(mr.mediaType)-1]
+			for _, ct := range preferredMediaTypes {
+				if mapCT2format[ct] == defFormat && strings.HasPrefix(ct, prefix) {
+					return defFormat, true
+				}
+			}
+			for _, ct := range preferredMediaTypes {
+				if strings.HasPrefix(ct, prefix) {
+					if format, ok := contentType2format(ct); ok {
+						return format, true
+					}
+				}
+			}
+		default:
+			if format, ok := contentType2format(mr.mediaType); ok {
+				return format, true
+			}
+		}
+	}
+	return "", false
+}
+
+// parseAccept parses the values of an "Accept" header. The result is ordered
+// by descending quality. Media ranges with the same quality are ordered by
+// descending specificity, then by their position. Media ranges with quality
+// zero are removed.
+func parseAccept(values []string) []mediaRange {
+	var result []mediaRange
+	for _, value := range values {
+		for _, elem := range strings.Split(value, ",") {
+			params := strings.Split(elem, ";")
+			mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+			if mediaType == "" {
+				continue
+			}
+			quality := 1.0
+			for _, param := range params[1:] {
+				param = strings.TrimSpace(param)
+				if strings.HasPrefix(param, "q=") {
+					if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+						quality = q
+					}
+				}
+			}
+			if quality > 0 {
+				result = append(result, mediaRange{mediaType, quality, len(result)})
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].quality != result[j].quality {
+			return result[i].quality > result[j].quality
+		}
+		if si, sj := result[i].specificity(), result[j].specificity(); si != sj {
+			return si > sj
+		}
+		return result[i].order < result[j].order
+	})
+	return result
 }
 
 // GetFilterSorter retrieves the specified filter and sorting options from a query.
 func GetFilterSorter(q url.Values, forSearch bool) (filter *place.Filter, sorter *place.Sorter) {
-	sortQKey, orderQKey, offsetQKey, limitQKey, negateQKey, sQKey := getQueryKeys(forSearch)
+	sortQKey, orderQKey, afterQKey, offsetQKey, limitQKey, negateQKey, sQKey := getQueryKeys(forSearch)
 	for key, values := range q {
 		switch key {
 		case sortQKey, orderQKey:
@@ -77,6 +182,13 @@ func GetFilterSorter(q url.Values, forSearch bool) (filter *place.Filter, sorter
 					sorter.Descending = descending
 				}
 			}
+		case afterQKey:
+			if len(values) > 0 {
+				if zid, err := id.Parse(values[0]); err == nil {
+					sorter = place.EnsureSorter(sorter)
+					sorter.After = zid
+				}
+			}
 		case offsetQKey:
 			if len(values) > 0 {
 				if offset, err := strconv.Atoi(values[0]); err == nil {
@@ -106,18 +218,115 @@ func GetFilterSorter(q url.Values, forSearch bool) (filter *place.Filter, sorter
 				filter.Expr[""] = cleanedValues
 			}
 		default:
-			if !forSearch && meta.KeyIsValid(key) {
+			if forSearch {
+				continue
+			}
+			if cKey, cValues, ok := getCompareFilter(key, values); ok {
+				filter = place.EnsureFilter(filter)
+				filter.Expr[cKey] = append(filter.Expr[cKey], cValues...)
+			} else if meta.KeyIsValid(key) {
 				filter = place.EnsureFilter(filter)
-				filter.Expr[key] = values
+				filter.Expr[key] = append(filter.Expr[key], values...)
 			}
 		}
 	}
 	return filter, sorter
 }
 
-func getQueryKeys(forSearch bool) (string, string, string, string, string, string) {
+// filterKeyCreated selects zettel by their creation time, i.e. by their
+// identifier.
+const filterKeyCreated = "created"
+
+// getCompareFilter retrieves a filter with a comparison, like
+// "created>=2020-06". Since the query was split at the first "=", the
+// operator is part of the key.
+func getCompareFilter(key string, values []string) (string, []string, bool) {
+	pos := strings.IndexAny(key, "<>")
+	if pos <= 0 {
+		return "", nil, false
+	}
+	name, op := key[:pos], key[pos:]
+	if name == filterKeyCreated {
+		name = meta.KeyID
+	}
+	if !meta.KeyIsValid(name) {
+		return "", nil, false
+	}
+	result := make([]string, 0, len(values))
+	for _, val := range values {
+		if val == "" {
+			result = append(result, op)
+		} else {
+			result = append(result, op+"="+val)
+		}
+	}
+	return name, result, true
+}
+
+// GetListColumns returns the meta keys that should be shown as columns of a
+// zettel list. They are given by the query parameter with the given name,
+// separated by comma or space. If there is no such parameter, the runtime
+// configuration applies.
+func GetListColumns(q url.Values, columnsKey string) []string {
+	values, ok := q[columnsKey]
+	if !ok {
+		return runtime.GetListColumns()
+	}
+	var result []string
+	for _, val := range values {
+		for _, key := range strings.FieldsFunc(val, isColumnSeparator) {
+			if meta.KeyIsValid(key) && key != meta.KeyTitle {
+				result = append(result, key)
+			}
+		}
+	}
+	return result
+}
+
+func isColumnSeparator(r rune) bool { return r == ',' || r == ' ' }
+
+// QueryParam describes a query parameter that is interpreted by GetFilterSorter.
+type QueryParam struct {
+	Name        string `json:"name"`
+	Value       string `json:"value"`
+	Description string `json:"description"`
+}
+
+// FilterSorterParams returns the description of all query parameters that
+// are interpreted by GetFilterSorter.
+func FilterSorterParams(forSearch bool) []QueryParam {
+	sortQKey, orderQKey, afterQKey, offsetQKey, limitQKey, negateQKey, sQKey := getQueryKeys(forSearch)
+	result := []QueryParam{
+		{sortQKey, "KEY",
+			"Sort by the value of metadata key KEY. A leading '-' sorts in descending order, " +
+				"KEY '" + place.RandomOrder + "' sorts randomly."},
+		{orderQKey, "KEY", "Same as " + sortQKey + "."},
+		{afterQKey, "ZID", "Continue the sorted list after the zettel with identifier ZID."},
+		{offsetQKey, "NUMBER", "Skip the first NUMBER zettel of the sorted list."},
+		{limitQKey, "NUMBER", "Return at most NUMBER zettel."},
+		{negateQKey, "", "Select all zettel that do not match the other selection criteria."},
+		{sQKey, "TEXT", "Select zettel with TEXT in any metadata value. May be given multiple times."},
+	}
+	if !forSearch {
+		result = append(result,
+			QueryParam{"KEY", "VALUE",
+				"Select zettel whose value of metadata key KEY matches VALUE, e.g. role=zettel " +
+					"or tags=#example. May be given multiple times, all values must match. " +
+					"VALUE may contain alternatives, separated by '|', where one must match, " +
+					"e.g. tags=#a|#b. A leading '!' negates an alternative, e.g. tags=!#draft. " +
+					"A '\\' escapes the next character, e.g. title=a\\|b."},
+			QueryParam{"KEY>=DATE", "",
+				"Select zettel whose identifier or timestamp KEY is within a date range, " +
+					"e.g. created>=2020-06 or modified<2020-01-01. The operators <, <=, >, " +
+					"and >= compare with a prefix of the date. KEY '" + filterKeyCreated +
+					"' refers to the identifier."})
+	}
+	return result
+}
+
+func getQueryKeys(forSearch bool) (string, string, string, string, string, string, string) {
 	if forSearch {
-		return "sort", "order", "offset", "limit", "negate", "s"
+		return "sort", "order", "after", "offset", "limit", "negate", "s"
 	}
-	return "_sort", "_order", "_offset", "_limit", "_negate", "_s"
+	return "_sort", "_order", "_after", "_offset", "_limit", "_negate", "_s"
 }
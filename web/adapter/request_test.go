@@ -0,0 +1,78 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package adapter provides handlers for web requests.
+package adapter
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	_ "zettelstore.de/z/encoder/htmlenc"
+	_ "zettelstore.de/z/encoder/jsonenc"
+	_ "zettelstore.de/z/encoder/textenc"
+	_ "zettelstore.de/z/encoder/zmkenc"
+)
+
+func TestGetFormat(t *testing.T) {
+	testcases := []struct {
+		query  string
+		accept string
+		exp    string
+	}{
+		{"", "", "def"},
+		{"_format=zmk", "application/json", "zmk"},
+		{"", "application/json", "json"},
+		{"", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "html"},
+		{"", "*/*", "def"},
+		{"", "text/plain;q=0.5, application/json", "json"},
+		{"", "text/plain;q=0.5, application/json;q=0", "text"},
+		{"", "image/png", "def"},
+		{"", "text/*", "html"},
+		{"", "application/*;q=0.9, text/x-zmk", "zmk"},
+		{"", "TEXT/PLAIN; charset=utf-8", "text"},
+	}
+	for i, tc := range testcases {
+		q, _ := url.ParseQuery(tc.query)
+		r := &http.Request{Header: http.Header{}}
+		if tc.accept != "" {
+			r.Header.Set("Accept", tc.accept)
+		}
+		if got := GetFormat(r, q, "def"); got != tc.exp {
+			t.Errorf("%d: %q/%q: exp=%q, got=%q", i, tc.query, tc.accept, tc.exp, got)
+		}
+	}
+}
+
+func TestGetFilterSorterCompare(t *testing.T) {
+	testcases := []struct {
+		query string
+		key   string
+		exp   string
+	}{
+		{"created>=2020-06", "id", ">=2020-06"},
+		{"created>2020", "id", ">2020"},
+		{"modified<2020-01-01", "modified", "<2020-01-01"},
+		{"modified<=2020", "modified", "<=2020"},
+		{"id=2020", "id", "2020"},
+	}
+	for i, tc := range testcases {
+		q, _ := url.ParseQuery(tc.query)
+		filter, _ := GetFilterSorter(q, false)
+		if filter == nil {
+			t.Errorf("%d: %q: no filter", i, tc.query)
+			continue
+		}
+		if got := filter.Expr[tc.key]; len(got) != 1 || got[0] != tc.exp {
+			t.Errorf("%d: %q: exp=%q, got=%q", i, tc.query, tc.exp, got)
+		}
+	}
+}
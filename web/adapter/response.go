@@ -19,27 +19,80 @@ import (
 	"zettelstore.de/z/usecase"
 )
 
-// ReportUsecaseError returns an appropriate HTTP status code for errors in use cases.
-func ReportUsecaseError(w http.ResponseWriter, err error) {
-	if err == place.ErrNotFound {
-		NotFound(w, http.StatusText(404))
-		return
-	}
-	if err, ok := err.(*place.ErrNotAllowed); ok {
-		Forbidden(w, err.Error())
-		return
+// Codes of errors that are caused by a use case.
+const (
+	CodeNotAllowed      = "not-allowed"
+	CodeInvalidZid      = "invalid-zid"
+	CodeZidInUse        = "zid-in-use"
+	CodeZettelLocked    = "zettel-locked"
+	CodeZettelTooLarge  = "zettel-too-large"
+	CodeQuotaExceeded   = "quota-exceeded"
+	CodeInvalidTemplate = "invalid-template"
+	CodeInvalidMetaKey  = "invalid-meta-key"
+	CodeInvalidStatus   = "invalid-status"
+	CodeNotShadowing    = "not-shadowing"
+	CodeNoSuchTask      = "no-such-task"
+	CodeNoReview        = "no-review"
+	CodeTooMany         = "too-many-requests"
+	CodeNotOperational  = "not-operational"
+)
+
+// MakeError maps an error of a use case to the error that is reported to
+// the client. Errors that are not known result in an internal server error.
+func MakeError(err error) *Error {
+	if e, ok := err.(*Error); ok {
+		return e
 	}
-	if err, ok := err.(*place.ErrInvalidID); ok {
-		BadRequest(w, fmt.Sprintf("Zettel-ID %q not appropriate in this context.", err.Zid.String()))
-		return
+	if err == place.ErrNotFound {
+		return NewError(http.StatusNotFound, http.StatusText(http.StatusNotFound))
 	}
-	if err, ok := err.(*usecase.ErrZidInUse); ok {
-		BadRequest(w, fmt.Sprintf("Zettel-ID %q already in use.", err.Zid.String()))
-		return
+	switch err := err.(type) {
+	case *place.ErrNotAllowed:
+		return &Error{http.StatusForbidden, CodeNotAllowed, err.Error(), err.Zid, ""}
+	case *place.ErrUnavailable:
+		return &Error{http.StatusServiceUnavailable, CodeNotOperational,
+			"Zettel place not available.", 0, ""}
+	case *place.ErrInvalidID:
+		return &Error{http.StatusBadRequest, CodeInvalidZid,
+			fmt.Sprintf("Zettel-ID %q not appropriate in this context.", err.Zid.String()),
+			err.Zid, ""}
+	case *usecase.ErrZidInUse:
+		return &Error{http.StatusBadRequest, CodeZidInUse,
+			fmt.Sprintf("Zettel-ID %q already in use.", err.Zid.String()), err.Zid, ""}
+	case *usecase.ErrZettelLocked:
+		return &Error{http.StatusConflict, CodeZettelLocked, err.Error(), err.Lock.Zid, ""}
+	case *usecase.ErrZettelTooLarge:
+		return &Error{
+			http.StatusRequestEntityTooLarge, CodeZettelTooLarge, err.Error(), err.Zid, "content"}
+	case *usecase.ErrQuotaExceeded:
+		return &Error{http.StatusForbidden, CodeQuotaExceeded, err.Error(), err.User, ""}
+	case *usecase.ErrInvalidTemplate:
+		return &Error{http.StatusBadRequest, CodeInvalidTemplate, err.Error(), err.Zid, ""}
+	case *usecase.ErrInvalidMetaKey:
+		return &Error{http.StatusBadRequest, CodeInvalidMetaKey, err.Error(), 0, err.Key}
+	case *usecase.ErrInvalidStatus:
+		return &Error{http.StatusBadRequest, CodeInvalidStatus, err.Error(), 0, "status"}
+	case *usecase.ErrNotShadowing:
+		return &Error{http.StatusConflict, CodeNotShadowing, err.Error(), err.Zid, ""}
+	case *usecase.ErrNoSuchTask:
+		return &Error{http.StatusNotFound, CodeNoSuchTask, err.Error(), err.Zid, ""}
+	case *usecase.ErrNoReview:
+		return &Error{http.StatusConflict, CodeNoReview, err.Error(), err.Zid, "review-interval"}
+	case *usecase.ErrTooManySuggestions:
+		return &Error{http.StatusTooManyRequests, CodeTooMany, err.Error(), err.Zid, ""}
 	}
 	if err == place.ErrStopped {
-		InternalServerError(w, "Zettelstore not operational.", err)
-		return
+		return &Error{http.StatusInternalServerError, CodeNotOperational,
+			"Zettelstore not operational.", 0, ""}
+	}
+	return NewError(http.StatusInternalServerError, "Internal Server Error")
+}
+
+// ReportUsecaseError returns an appropriate HTTP status code for errors in use cases.
+func ReportUsecaseError(w http.ResponseWriter, r *http.Request, err error) {
+	e := MakeError(err)
+	if e.Status == http.StatusInternalServerError {
+		logInternalError(e.Message, err)
 	}
-	InternalServerError(w, "", err)
+	ReportError(w, r, e)
 }
@@ -12,31 +12,50 @@
 package adapter
 
 import (
+	"context"
 	"net/url"
 	"strings"
 
 	"zettelstore.de/z/config/startup"
 	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/web/router"
 )
 
 type urlQuery struct{ key, val string }
 
 // URLBuilder should be used to create zettelstore URLs.
 type URLBuilder struct {
+	prefix   string
 	key      byte
 	path     []string
 	query    []urlQuery
 	fragment string
 }
 
-// NewURLBuilder creates a new URLBuilder.
-func NewURLBuilder(key byte) *URLBuilder {
-	return &URLBuilder{key: key}
+// NewURLBuilder creates a new URLBuilder. The URL prefix is taken from the
+// current request, because it may be changed by a reverse proxy.
+func NewURLBuilder(ctx context.Context, key byte) *URLBuilder {
+	return &URLBuilder{prefix: URLPrefix(ctx), key: key}
+}
+
+// NewCanonicalURLBuilder creates a new URLBuilder for absolute URLs. They are
+// based on the configured base URL of the service, not on the current request.
+func NewCanonicalURLBuilder(key byte) *URLBuilder {
+	return &URLBuilder{prefix: startup.BaseURL(), key: key}
+}
+
+// URLPrefix returns the URL prefix of the service for the current request.
+func URLPrefix(ctx context.Context) string {
+	if fwd := router.GetForwarded(ctx); fwd != nil {
+		return fwd.Prefix
+	}
+	return startup.URLPrefix()
 }
 
 // Clone an URLBuilder
 func (ub *URLBuilder) Clone() *URLBuilder {
 	copy := new(URLBuilder)
+	copy.prefix = ub.prefix
 	copy.key = ub.key
 	if len(ub.path) > 0 {
 		copy.path = make([]string, 0, len(ub.path))
@@ -92,7 +111,7 @@ func (ub *URLBuilder) SetFragment(s string) *URLBuilder {
 func (ub *URLBuilder) String() string {
 	var sb strings.Builder
 
-	sb.WriteString(startup.URLPrefix())
+	sb.WriteString(ub.prefix)
 	if ub.key != '/' {
 		sb.WriteByte(ub.key)
 	}
@@ -100,10 +119,6 @@ func (ub *URLBuilder) String() string {
 		sb.WriteByte('/')
 		sb.WriteString(url.PathEscape(p))
 	}
-	if len(ub.fragment) > 0 {
-		sb.WriteByte('#')
-		sb.WriteString(ub.fragment)
-	}
 	for i, q := range ub.query {
 		if i == 0 {
 			sb.WriteByte('?')
@@ -114,5 +129,9 @@ func (ub *URLBuilder) String() string {
 		sb.WriteByte('=')
 		sb.WriteString(url.QueryEscape(q.val))
 	}
+	if len(ub.fragment) > 0 {
+		sb.WriteByte('#')
+		sb.WriteString(ub.fragment)
+	}
 	return sb.String()
 }
@@ -0,0 +1,97 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/config/startup"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/web/adapter"
+)
+
+// Paths of the files needed to install the web user interface as an app.
+// They must be placed directly below the URL prefix, so that the service
+// worker is allowed to control all pages.
+const (
+	ManifestPath      = "manifest.webmanifest"
+	ServiceWorkerPath = "sw.js"
+)
+
+// offlineCacheSize is the maximum number of pages cached for offline reading.
+const offlineCacheSize = 100
+
+func rawContentURL(ctx context.Context, zid id.Zid) string {
+	return adapter.NewURLBuilder(ctx, 'z').SetZid(zid).AppendQuery(
+		"_format", "raw").AppendQuery("_part", "content").String()
+}
+
+// MakeGetManifestHandler creates a new HTTP handler to retrieve the web app
+// manifest.
+func MakeGetManifestHandler(te *TemplateEngine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		name, err := json.Marshal(runtime.GetSiteName())
+		if err != nil {
+			adapter.InternalServerError(w, r, "Encode site name", err)
+			return
+		}
+		te.renderAppFile(w, r, id.ManifestZid, "application/manifest+json", struct {
+			Name     string
+			StartURL string
+			IconURL  string
+		}{
+			Name:     string(name),
+			StartURL: adapter.NewURLBuilder(ctx, '/').String(),
+			IconURL:  rawContentURL(ctx, id.AppIconZid),
+		})
+	}
+}
+
+// MakeGetServiceWorkerHandler creates a new HTTP handler to retrieve the
+// service worker that caches zettel for offline reading.
+func MakeGetServiceWorkerHandler(te *TemplateEngine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		w.Header().Set("Cache-Control", "no-cache")
+		te.renderAppFile(w, r, id.ServiceWorkerZid, "text/javascript; charset=utf-8", struct {
+			Version       string
+			Prefix        string
+			StylesheetURL string
+			AppScriptURL  string
+			IconURL       string
+			MaxEntries    int
+		}{
+			Version:       startup.GetVersion().Build,
+			Prefix:        adapter.NewURLBuilder(ctx, '/').String(),
+			StylesheetURL: rawContentURL(ctx, id.BaseCSSZid),
+			AppScriptURL:  rawContentURL(ctx, id.AppScriptZid),
+			IconURL:       rawContentURL(ctx, id.AppIconZid),
+			MaxEntries:    offlineCacheSize,
+		})
+	}
+}
+
+func (te *TemplateEngine) renderAppFile(
+	w http.ResponseWriter, r *http.Request, zid id.Zid, contentType string,
+	data interface{}) {
+	content, err := te.executeTemplate(r.Context(), zid, data, nil)
+	if err != nil {
+		adapter.InternalServerError(w, r, "Unable to render template", err)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(content)
+}
@@ -0,0 +1,75 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"context"
+	"strings"
+
+	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/strfun"
+	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/adapter"
+)
+
+// zettelAssets returns the HTML elements to include the style and
+// script zettel of a zettel. They are only included in expert mode, and only
+// if the referenced zettel is readable and has the appropriate syntax. The
+// policy allows only the owner to create or change zettel with such a syntax.
+func zettelAssets(ctx context.Context, m *meta.Meta, getMeta usecase.GetMeta) string {
+	if !runtime.GetExpertMode() {
+		return ""
+	}
+	var sb strings.Builder
+	if u, ok := assetURL(ctx, m, meta.KeyCSSZettel, "css", getMeta); ok {
+		sb.WriteString("<link rel=\"stylesheet\" href=\"")
+		strfun.HTMLAttrEscape(&sb, u)
+		sb.WriteString("\">")
+	}
+	if u, ok := assetURL(ctx, m, meta.KeyJSZettel, "js", getMeta); ok {
+		if sb.Len() > 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString("<script defer src=\"")
+		strfun.HTMLAttrEscape(&sb, u)
+		sb.WriteString("\"></script>")
+	}
+	return sb.String()
+}
+
+func assetURL(
+	ctx context.Context,
+	m *meta.Meta,
+	key, syntax string,
+	getMeta usecase.GetMeta,
+) (string, bool) {
+	val, ok := m.Get(key)
+	if !ok {
+		return "", false
+	}
+	zid, err := id.Parse(val)
+	if err != nil {
+		return "", false
+	}
+	am, err := getMeta.Run(ctx, zid)
+	if err != nil || runtime.GetSyntax(am) != syntax {
+		return "", false
+	}
+	if am.GetDefault(meta.KeyRole, "") == meta.ValueRoleSuggestion {
+		// Content of a suggestion was written by an anonymous visitor.
+		return "", false
+	}
+	return adapter.NewURLBuilder(ctx, 'z').SetZid(zid).AppendQuery(
+		"_format", "raw").AppendQuery("_part", "content").String(), true
+}
@@ -0,0 +1,67 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/adapter"
+)
+
+// newBulkEditURL returns the URL to change the meta data of all listed
+// zettel. After the change, the same list is shown again.
+func newBulkEditURL(ctx context.Context, query url.Values) string {
+	urlBuilder := adapter.NewURLBuilder(ctx, 'h')
+	for key, values := range query {
+		for _, val := range values {
+			urlBuilder.AppendQuery(key, val)
+		}
+	}
+	return urlBuilder.String()
+}
+
+// MakePostBulkEditHandler creates a new HTTP handler to change one meta key
+// of many zettel at once. The zettel are given by the form field "zid", the
+// form fields "key" and "value" specify the change. An empty value removes
+// the key. The content of the zettel is not written again.
+func MakePostBulkEditHandler(updateMeta usecase.UpdateMeta) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			adapter.BadRequest(w, r, "Unable to read bulk edit form")
+			return
+		}
+		key := strings.TrimSpace(r.PostFormValue("key"))
+		if key == "" {
+			adapter.BadRequest(w, r, "Missing meta key")
+			return
+		}
+		patch := map[string]string{key: r.PostFormValue("value")}
+		ctx := r.Context()
+		for _, val := range r.PostForm["zid"] {
+			zid, err := id.Parse(val)
+			if err != nil {
+				adapter.BadRequest(w, r, "Invalid zettel identifier "+val)
+				return
+			}
+			if err = updateMeta.Run(ctx, zid, patch); err != nil {
+				adapter.ReportUsecaseError(w, r, err)
+				return
+			}
+		}
+		http.Redirect(w, r, newBulkEditURL(ctx, r.URL.Query()), http.StatusFound)
+	}
+}
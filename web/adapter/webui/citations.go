@@ -0,0 +1,106 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"context"
+	"net/http"
+
+	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/adapter"
+	"zettelstore.de/z/web/session"
+)
+
+// Identifier of the citation report of all literature zettel, below the 'k'
+// route.
+const citationsZid = id.Zid(12)
+
+type citationLink struct {
+	Title string
+	URL   string
+}
+
+type citationInfo struct {
+	Zid      string
+	Title    string
+	URL      string
+	Count    int
+	CitedBy  []citationLink
+	HasCites bool
+	Cites    []citationLink
+}
+
+func renderWebUICitationsList(
+	w http.ResponseWriter, r *http.Request, te *TemplateEngine,
+	listCitations usecase.ListCitations) {
+	ctx := r.Context()
+	citations, err := listCitations.Run(ctx)
+	if err != nil {
+		adapter.ReportUsecaseError(w, r, err)
+		return
+	}
+	user := session.GetUser(ctx)
+	var cited, uncited, unread []citationInfo
+	for _, cit := range citations {
+		info := citationInfo{
+			Zid:      cit.Meta.Zid.String(),
+			Title:    cit.Meta.GetDefault(meta.KeyTitle, ""),
+			URL:      adapter.NewURLBuilder(ctx, 'h').SetZid(cit.Meta.Zid).String(),
+			Count:    len(cit.CitedBy),
+			CitedBy:  citationLinks(ctx, cit.CitedBy),
+			HasCites: len(cit.Cites) > 0,
+			Cites:    citationLinks(ctx, cit.Cites),
+		}
+		if info.Count > 0 {
+			cited = append(cited, info)
+		} else {
+			uncited = append(uncited, info)
+		}
+		if usecase.IsFlagged(user, usecase.FlagReadLater, cit.Meta.Zid) {
+			unread = append(unread, info)
+		}
+	}
+
+	var base baseData
+	te.makeBaseData(ctx, runtime.GetDefaultLang(), "Literature", user, &base)
+	te.renderTemplate(ctx, w, id.CitationsTemplateZid, &base, struct {
+		HasLiterature bool
+		HasCited      bool
+		Cited         []citationInfo
+		HasUnread     bool
+		Unread        []citationInfo
+		HasUncited    bool
+		Uncited       []citationInfo
+	}{
+		HasLiterature: len(citations) > 0,
+		HasCited:      len(cited) > 0,
+		Cited:         cited,
+		HasUnread:     len(unread) > 0,
+		Unread:        unread,
+		HasUncited:    len(uncited) > 0,
+		Uncited:       uncited,
+	})
+}
+
+func citationLinks(ctx context.Context, metaList []*meta.Meta) []citationLink {
+	result := make([]citationLink, 0, len(metaList))
+	for _, m := range metaList {
+		result = append(result, citationLink{
+			Title: m.GetDefault(meta.KeyTitle, m.Zid.String()),
+			URL:   adapter.NewURLBuilder(ctx, 'h').SetZid(m.Zid).String(),
+		})
+	}
+	return result
+}
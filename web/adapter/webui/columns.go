@@ -0,0 +1,152 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/place"
+	"zettelstore.de/z/web/adapter"
+)
+
+// columnInfo describes a column of a zettel list.
+type columnInfo struct {
+	Name       string
+	SortURL    string
+	Sorted     bool
+	Descending bool
+}
+
+// cellInfo contains all values of a zettel for one column.
+type cellInfo struct {
+	Values []cellValue
+}
+
+// cellValue is one value of a cell. It is linked, if the type of the meta
+// key allows it.
+type cellValue struct {
+	Text   string
+	HasURL bool
+	URL    string
+}
+
+// buildListColumns returns the column headers. Each header links to the list
+// sorted by its key; if the list is already sorted by that key, the order is
+// reversed.
+func buildListColumns(
+	columns []string, sorter *place.Sorter, sortURL func(string) string) (columnInfo, []columnInfo) {
+	newColumn := func(key string) columnInfo {
+		sorted := sorter != nil && sorter.Order == key
+		descending := sorted && sorter.Descending
+		order := key
+		if sorted && !descending {
+			order = "-" + key
+		}
+		return columnInfo{
+			Name:       key,
+			SortURL:    sortURL(order),
+			Sorted:     sorted,
+			Descending: descending,
+		}
+	}
+	result := make([]columnInfo, 0, len(columns))
+	for _, key := range columns {
+		result = append(result, newColumn(key))
+	}
+	return newColumn(meta.KeyTitle), result
+}
+
+// buildListCells returns the typed values of the given meta data for all
+// columns.
+func buildListCells(ctx context.Context, m *meta.Meta, columns []string) []cellInfo {
+	result := make([]cellInfo, 0, len(columns))
+	for _, key := range columns {
+		value, ok := m.Get(key)
+		if !ok {
+			result = append(result, cellInfo{})
+			continue
+		}
+		var values []cellValue
+		switch m.Type(key) {
+		case meta.TypeID:
+			values = []cellValue{zidCellValue(ctx, value)}
+		case meta.TypeIDSet:
+			for _, val := range meta.ListFromValue(value) {
+				values = append(values, zidCellValue(ctx, val))
+			}
+		case meta.TypeWord:
+			values = []cellValue{filterCellValue(ctx, key, value)}
+		case meta.TypeTagSet, meta.TypeWordSet:
+			for _, val := range meta.ListFromValue(value) {
+				values = append(values, filterCellValue(ctx, key, val))
+			}
+		case meta.TypeURL:
+			hasURL := strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://")
+			values = []cellValue{{Text: value, HasURL: hasURL, URL: value}}
+		case meta.TypeTimestamp:
+			if t, ok := meta.TimeValue(value); ok {
+				value = t.Format("2006-01-02 15:04:05")
+			}
+			values = []cellValue{{Text: value}}
+		case meta.TypeCredential:
+			values = []cellValue{{Text: "***"}}
+		default:
+			values = []cellValue{{Text: value}}
+		}
+		result = append(result, cellInfo{Values: values})
+	}
+	return result
+}
+
+func zidCellValue(ctx context.Context, value string) cellValue {
+	zid, err := id.Parse(value)
+	if err != nil {
+		return cellValue{Text: value}
+	}
+	return cellValue{
+		Text:   value,
+		HasURL: true,
+		URL:    adapter.NewURLBuilder(ctx, 'h').SetZid(zid).String(),
+	}
+}
+
+func filterCellValue(ctx context.Context, key, value string) cellValue {
+	return cellValue{
+		Text:   value,
+		HasURL: true,
+		URL:    adapter.NewURLBuilder(ctx, 'h').AppendQuery(key, value).String(),
+	}
+}
+
+// newSortURL returns the URL of the given list, sorted by the given order.
+// The list starts at its first element.
+func newSortURL(
+	ctx context.Context,
+	key byte,
+	query url.Values,
+	order, sortKey, orderKey, afterKey, offsetKey string,
+) string {
+	urlBuilder := adapter.NewURLBuilder(ctx, key)
+	for key, values := range query {
+		if key != sortKey && key != orderKey && key != afterKey && key != offsetKey {
+			for _, val := range values {
+				urlBuilder.AppendQuery(key, val)
+			}
+		}
+	}
+	urlBuilder.AppendQuery(sortKey, order)
+	return urlBuilder.String()
+}
@@ -0,0 +1,121 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/encoder"
+	"zettelstore.de/z/strfun"
+	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/adapter"
+	"zettelstore.de/z/web/session"
+)
+
+// buildCommentsHTML returns the HTML of the given comments. Replies are
+// nested below the comment they refer to.
+func buildCommentsHTML(
+	ctx context.Context,
+	comments []*usecase.Comment,
+	parseZettel usecase.ParseZettel,
+	getMeta usecase.GetMeta,
+) (string, error) {
+	if len(comments) == 0 {
+		return "", nil
+	}
+	var sb strings.Builder
+	if err := writeComments(ctx, &sb, comments, parseZettel, getMeta); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+func writeComments(
+	ctx context.Context,
+	sb *strings.Builder,
+	comments []*usecase.Comment,
+	parseZettel usecase.ParseZettel,
+	getMeta usecase.GetMeta,
+) error {
+	sb.WriteString("<ul class=\"zs-comments\">\n")
+	for _, c := range comments {
+		zn, err := parseZettel.Run(ctx, c.Meta.Zid, "")
+		if err != nil {
+			return err
+		}
+		content, err := formatBlocks(
+			zn.Ast,
+			"html",
+			&encoder.AdaptLinkOption{
+				Adapter: adapter.MakeLinkAdapter(ctx, 'h', getMeta, "", ""),
+			},
+			&encoder.AdaptImageOption{Adapter: adapter.MakeImageAdapter(ctx)},
+		)
+		if err != nil {
+			return err
+		}
+		sb.WriteString("<li>\n<div class=\"zs-meta\"><a href=\"")
+		strfun.HTMLAttrEscape(sb, adapter.NewURLBuilder(ctx, 'h').SetZid(c.Meta.Zid).String())
+		sb.WriteString("\">")
+		strfun.HTMLEscape(sb, c.Meta.GetDefault(meta.KeyAuthor, "?"), false)
+		sb.WriteString(", ")
+		strfun.HTMLEscape(sb, commentTime(c.Meta.Zid), false)
+		sb.WriteString("</a></div>\n")
+		sb.WriteString(content)
+		if len(c.Replies) > 0 {
+			if err = writeComments(ctx, sb, c.Replies, parseZettel, getMeta); err != nil {
+				return err
+			}
+		}
+		sb.WriteString("</li>\n")
+	}
+	sb.WriteString("</ul>\n")
+	return nil
+}
+
+// commentTime returns the creation time of a comment, as encoded in its
+// zettel identifier.
+func commentTime(zid id.Zid) string {
+	s := zid.String()
+	return s[0:4] + "-" + s[4:6] + "-" + s[6:8] + " " + s[8:10] + ":" + s[10:12]
+}
+
+// MakePostCommentZettelHandler creates a new HTTP handler to store a comment
+// on a zettel.
+func MakePostCommentZettelHandler(commentZettel usecase.CommentZettel) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		zid, err := id.Parse(r.URL.Path[1:])
+		if err != nil {
+			adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
+			return
+		}
+		if err = r.ParseForm(); err != nil {
+			adapter.BadRequest(w, r, "Unable to read comment form")
+			return
+		}
+		content := r.PostFormValue("content")
+		if strings.TrimSpace(content) == "" {
+			adapter.BadRequest(w, r, "Empty comment")
+			return
+		}
+		ctx := r.Context()
+		if _, err = commentZettel.Run(ctx, session.GetUser(ctx), zid, content); err != nil {
+			adapter.ReportUsecaseError(w, r, err)
+			return
+		}
+		http.Redirect(w, r, adapter.NewURLBuilder(ctx, 'h').SetZid(zid).String(), http.StatusFound)
+	}
+}
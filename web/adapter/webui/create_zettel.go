@@ -79,16 +79,16 @@ func MakeGetNewZettelHandler(
 			langOption := encoder.StringOption{Key: "lang", Value: runtime.GetLang(m)}
 			textTitle, err := adapter.FormatInlines(title, "text", &langOption)
 			if err != nil {
-				adapter.InternalServerError(w, "Format Text inlines for WebUI", err)
+				adapter.InternalServerError(w, r, "Format Text inlines for WebUI", err)
 				return
 			}
 			htmlTitle, err := adapter.FormatInlines(title, "html", &langOption)
 			if err != nil {
-				adapter.InternalServerError(w, "Format HTML inlines for WebUI", err)
+				adapter.InternalServerError(w, r, "Format HTML inlines for WebUI", err)
 				return
 			}
 			renderZettelForm(
-				w, r, te, newZettel.Run(origZettel), textTitle, htmlTitle)
+				w, r, te, newZettel.Run(session.GetUser(r.Context()), origZettel), textTitle, htmlTitle)
 		}
 	}
 }
@@ -100,17 +100,17 @@ func getOrigZettel(
 	op string,
 ) (domain.Zettel, bool) {
 	if format := adapter.GetFormat(r, r.URL.Query(), "html"); format != "html" {
-		adapter.BadRequest(w, fmt.Sprintf("%v zettel not possible in format %q", op, format))
+		adapter.BadRequest(w, r, fmt.Sprintf("%v zettel not possible in format %q", op, format))
 		return domain.Zettel{}, false
 	}
 	zid, err := id.Parse(r.URL.Path[1:])
 	if err != nil {
-		http.NotFound(w, r)
+		adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
 		return domain.Zettel{}, false
 	}
 	origZettel, err := getZettel.Run(r.Context(), zid)
 	if err != nil {
-		http.NotFound(w, r)
+		adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
 		return domain.Zettel{}, false
 	}
 	return origZettel, true
@@ -138,6 +138,7 @@ func renderZettelForm(
 		MetaPairsRest: m.PairsRest(false),
 		IsTextContent: !zettel.Content.IsBinary(),
 		Content:       zettel.Content.AsString(),
+		PickerURL:     adapter.NewURLBuilder(ctx, 'w').String(),
 	})
 }
 
@@ -145,21 +146,34 @@ func renderZettelForm(
 // an existing zettel.
 func MakePostCreateZettelHandler(createZettel usecase.CreateZettel) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		zettel, hasContent, err := parseZettelForm(r, id.Invalid)
+		m, err := parseZettelMeta(r, id.Invalid)
 		if err != nil {
-			adapter.BadRequest(w, "Unable to read form data")
+			reportFormError(w, r, err, "Unable to read form data")
 			return
 		}
-		if !hasContent {
-			adapter.BadRequest(w, "Content is missing")
+		f, _, err := openContentFile(r, id.Invalid)
+		if err != nil {
+			reportFormError(w, r, err, "Unable to read form data")
 			return
 		}
-
-		if newZid, err := createZettel.Run(r.Context(), zettel); err != nil {
-			adapter.ReportUsecaseError(w, err)
+		var newZid id.Zid
+		ctx := r.Context()
+		if f != nil {
+			// An uploaded file is passed to the place without reading it into memory.
+			newZid, err = createZettel.RunFrom(ctx, session.GetUser(ctx), m, f)
+			f.Close()
+		} else if content, ok := formContent(r); ok {
+			newZid, err = createZettel.Run(
+				ctx, session.GetUser(ctx), domain.Zettel{Meta: m, Content: content})
+		} else {
+			adapter.BadRequest(w, r, "Content is missing")
+			return
+		}
+		if err != nil {
+			adapter.ReportUsecaseError(w, r, err)
 		} else {
 			http.Redirect(
-				w, r, adapter.NewURLBuilder('h').SetZid(newZid).String(), http.StatusFound)
+				w, r, adapter.NewURLBuilder(r.Context(), 'h').SetZid(newZid).String(), http.StatusFound)
 		}
 	}
 }
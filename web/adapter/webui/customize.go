@@ -0,0 +1,108 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"net/http"
+
+	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/adapter"
+	"zettelstore.de/z/web/session"
+)
+
+// Identifier of the list of customized zettel, below the 'k' route.
+const customizedZid = id.Zid(10)
+
+type customizedInfo struct {
+	Zid      string
+	Title    string
+	URL      string
+	CanReset bool
+	Lines    []diffLine
+}
+
+func renderWebUICustomizedList(
+	w http.ResponseWriter, r *http.Request, te *TemplateEngine,
+	listCustomized usecase.ListCustomized) {
+	if !runtime.GetExpertMode() {
+		adapter.Forbidden(w, r, "Customized zettel are only shown in expert mode")
+		return
+	}
+	ctx := r.Context()
+	zettel, err := listCustomized.Run(ctx)
+	if err != nil {
+		adapter.ReportUsecaseError(w, r, err)
+		return
+	}
+	user := session.GetUser(ctx)
+	var customized, unchanged []customizedInfo
+	for _, cz := range zettel {
+		info := customizedInfo{
+			Zid:   cz.Meta.Zid.String(),
+			Title: cz.Meta.GetDefault(meta.KeyTitle, ""),
+			URL:   adapter.NewURLBuilder(ctx, 'h').SetZid(cz.Meta.Zid).String(),
+		}
+		if !cz.Customized {
+			unchanged = append(unchanged, info)
+			continue
+		}
+		info.CanReset = te.canDelete(ctx, user, cz.Meta)
+		info.Lines = diffLines(cz.Builtin, cz.Content)
+		customized = append(customized, info)
+	}
+
+	var base baseData
+	te.makeBaseData(ctx, runtime.GetDefaultLang(), "Customized Zettel", user, &base)
+	te.renderTemplate(ctx, w, id.CustomizeTemplateZid, &base, struct {
+		ResetURL      string
+		HasCustomized bool
+		Customized    []customizedInfo
+		HasUnchanged  bool
+		Unchanged     []customizedInfo
+	}{
+		ResetURL:      adapter.NewURLBuilder(ctx, 'k').SetZid(customizedZid).String(),
+		HasCustomized: len(customized) > 0,
+		Customized:    customized,
+		HasUnchanged:  len(unchanged) > 0,
+		Unchanged:     unchanged,
+	})
+}
+
+// MakePostResetZettelHandler creates a new HTTP handler to reset a
+// customized zettel to its built-in version.
+func MakePostResetZettelHandler(resetZettel usecase.ResetZettel) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if listZid, err := id.Parse(r.URL.Path[1:]); err != nil || listZid != customizedZid {
+			adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			adapter.BadRequest(w, r, "Unable to read reset form")
+			return
+		}
+		zid, err := id.Parse(r.PostFormValue("zid"))
+		if err != nil {
+			adapter.BadRequest(w, r, "Missing zettel identifier")
+			return
+		}
+		ctx := r.Context()
+		if err = resetZettel.Run(ctx, zid); err != nil {
+			adapter.ReportUsecaseError(w, r, err)
+			return
+		}
+		http.Redirect(
+			w, r, adapter.NewURLBuilder(ctx, 'k').SetZid(customizedZid).String(), http.StatusFound)
+	}
+}
@@ -28,23 +28,35 @@ import (
 func MakeGetDeleteZettelHandler(
 	te *TemplateEngine,
 	getZettel usecase.GetZettel,
+	listBacklinks usecase.ListBacklinks,
 ) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if format := adapter.GetFormat(r, r.URL.Query(), "html"); format != "html" {
-			adapter.BadRequest(w, fmt.Sprintf("Delete zettel not possible in format %q", format))
+			adapter.BadRequest(w, r, fmt.Sprintf("Delete zettel not possible in format %q", format))
 			return
 		}
 
 		zid, err := id.Parse(r.URL.Path[1:])
 		if err != nil {
-			http.NotFound(w, r)
+			adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
 			return
 		}
 
 		ctx := r.Context()
 		zettel, err := getZettel.Run(ctx, zid)
 		if err != nil {
-			adapter.ReportUsecaseError(w, err)
+			adapter.ReportUsecaseError(w, r, err)
+			return
+		}
+
+		backlinks, err := listBacklinks.Run(ctx, zid)
+		if err != nil {
+			adapter.ReportUsecaseError(w, r, err)
+			return
+		}
+		referrers, err := buildHTMLMetaList(ctx, backlinks)
+		if err != nil {
+			adapter.InternalServerError(w, r, "Build referrer list", err)
 			return
 		}
 
@@ -53,28 +65,53 @@ func MakeGetDeleteZettelHandler(
 		var base baseData
 		te.makeBaseData(ctx, runtime.GetLang(m), "Delete Zettel "+m.Zid.String(), user, &base)
 		te.renderTemplate(ctx, w, id.DeleteTemplateZid, &base, struct {
-			Zid       string
-			MetaPairs []meta.Pair
+			Zid          string
+			MetaPairs    []meta.Pair
+			HasReferrers bool
+			Referrers    []metaInfo
 		}{
-			Zid:       zid.String(),
-			MetaPairs: m.Pairs(true),
+			Zid:          zid.String(),
+			MetaPairs:    m.Pairs(true),
+			HasReferrers: len(referrers) > 0,
+			Referrers:    referrers,
 		})
 	}
 }
 
 // MakePostDeleteZettelHandler creates a new HTTP handler to delete a zettel.
-func MakePostDeleteZettelHandler(deleteZettel usecase.DeleteZettel) http.HandlerFunc {
+// Links to the deleted zettel are kept, removed, or redirected to a
+// replacement zettel.
+func MakePostDeleteZettelHandler(deleteWithLinks usecase.DeleteWithLinks) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		zid, err := id.Parse(r.URL.Path[1:])
 		if err != nil {
-			http.NotFound(w, r)
+			adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
+			return
+		}
+		if err = r.ParseForm(); err != nil {
+			adapter.BadRequest(w, r, "Unable to read delete form")
+			return
+		}
+		action, replacement := usecase.LinksKeep, id.Invalid
+		switch r.PostFormValue("links") {
+		case "", "keep":
+		case "remove":
+			action = usecase.LinksRemove
+		case "redirect":
+			action = usecase.LinksRedirect
+			if replacement, err = id.Parse(r.PostFormValue("replacement")); err != nil {
+				adapter.BadRequest(w, r, "Missing or invalid replacement zettel identifier")
+				return
+			}
+		default:
+			adapter.BadRequest(w, r, fmt.Sprintf("Unknown link action %q", r.PostFormValue("links")))
 			return
 		}
 
-		if err := deleteZettel.Run(r.Context(), zid); err != nil {
-			adapter.ReportUsecaseError(w, err)
+		if err = deleteWithLinks.Run(r.Context(), zid, action, replacement); err != nil {
+			adapter.ReportUsecaseError(w, r, err)
 			return
 		}
-		http.Redirect(w, r, adapter.NewURLBuilder('/').String(), http.StatusFound)
+		http.Redirect(w, r, adapter.NewURLBuilder(r.Context(), '/').String(), http.StatusFound)
 	}
 }
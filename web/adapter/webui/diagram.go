@@ -0,0 +1,82 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"zettelstore.de/z/ast"
+	"zettelstore.de/z/config/startup"
+	"zettelstore.de/z/diagram"
+)
+
+// renderDiagrams replaces all verbatim blocks, whose language is a diagram
+// language, by the rendered diagram. Example:
+//
+//	```dot
+//	digraph { a -> b }
+//	```
+//
+// If the diagram cannot be rendered, the verbatim block is kept.
+func renderDiagrams(ctx context.Context, bs ast.BlockSlice) {
+	for i, bn := range bs {
+		switch n := bn.(type) {
+		case *ast.VerbatimNode:
+			if dn := renderDiagram(ctx, n); dn != nil {
+				bs[i] = dn
+			}
+		case *ast.RegionNode:
+			renderDiagrams(ctx, n.Blocks)
+		case *ast.NestedListNode:
+			for _, item := range n.Items {
+				for j, in := range item {
+					if vn, ok := in.(*ast.VerbatimNode); ok {
+						if dn := renderDiagram(ctx, vn); dn != nil {
+							item[j] = dn
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// renderDiagram returns a node with the rendered diagram, or nil if the
+// verbatim node is not a diagram or cannot be rendered.
+func renderDiagram(ctx context.Context, vn *ast.VerbatimNode) *ast.VerbatimNode {
+	if vn.Code != ast.VerbatimProg {
+		return nil
+	}
+	code, ok := vn.Attrs.Get("")
+	if !ok {
+		return nil
+	}
+	lang, ok := diagram.Language(code)
+	if !ok {
+		return nil
+	}
+	r := startup.Diagrams()
+	if r == nil || !r.Handles(lang) {
+		return nil
+	}
+	svg, err := r.SVG(ctx, lang, strings.Join(vn.Lines, "\n")+"\n")
+	if err != nil {
+		log.Printf("Unable to render %v diagram: %v", lang, err)
+		return nil
+	}
+	return &ast.VerbatimNode{
+		Code:  ast.VerbatimHTML,
+		Lines: []string{`<figure class="zs-diagram">`, svg, "</figure>"},
+	}
+}
@@ -0,0 +1,184 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/config/startup"
+	"zettelstore.de/z/diff"
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/strfun"
+	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/adapter"
+	"zettelstore.de/z/web/session"
+)
+
+// MakeGetDiffZettelHandler creates a new HTTP handler to display a form for
+// comparing the content of a zettel with a draft.
+func MakeGetDiffZettelHandler(te *TemplateEngine, getZettel usecase.GetZettel) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		zettel, ok := getDiffZettel(w, r, getZettel)
+		if ok {
+			renderDiffZettel(w, r, te, zettel, zettel.Content.AsString(), nil)
+		}
+	}
+}
+
+// MakePostDiffZettelHandler creates a new HTTP handler to display the
+// differences between the content of a zettel and a draft.
+func MakePostDiffZettelHandler(te *TemplateEngine, getZettel usecase.GetZettel) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		zettel, ok := getDiffZettel(w, r, getZettel)
+		if !ok {
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			adapter.BadRequest(w, r, "Unable to read diff zettel form")
+			return
+		}
+		draft := r.PostFormValue("content")
+		if max := startup.MaxZettelSize(); max > 0 && int64(len(draft)) > max {
+			adapter.BadRequest(w, r, fmt.Sprintf("Draft is larger than %v bytes", max))
+			return
+		}
+		renderDiffZettel(w, r, te, zettel, draft, diffLines(zettel.Content.AsString(), draft))
+	}
+}
+
+func getDiffZettel(
+	w http.ResponseWriter, r *http.Request, getZettel usecase.GetZettel) (domain.Zettel, bool) {
+	if format := adapter.GetFormat(r, r.URL.Query(), "html"); format != "html" {
+		adapter.BadRequest(w, r, fmt.Sprintf("Diff zettel not possible in format %q", format))
+		return domain.Zettel{}, false
+	}
+	zid, err := id.Parse(r.URL.Path[1:])
+	if err != nil {
+		adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
+		return domain.Zettel{}, false
+	}
+	zettel, err := getZettel.Run(r.Context(), zid)
+	if err != nil {
+		adapter.ReportUsecaseError(w, r, err)
+		return domain.Zettel{}, false
+	}
+	if zettel.Content.IsBinary() {
+		adapter.BadRequest(w, r, "Diff not possible for binary content")
+		return domain.Zettel{}, false
+	}
+	return zettel, true
+}
+
+func renderDiffZettel(
+	w http.ResponseWriter, r *http.Request, te *TemplateEngine,
+	zettel domain.Zettel, draft string, lines []diffLine) {
+	ctx := r.Context()
+	m := zettel.Meta
+	var base baseData
+	te.makeBaseData(ctx, runtime.GetLang(m), "Diff Zettel "+m.Zid.String(), session.GetUser(ctx), &base)
+	te.renderTemplate(ctx, w, id.DiffTemplateZid, &base, struct {
+		Zid     string
+		InfoURL string
+		HasDiff bool
+		Lines   []diffLine
+		Draft   string
+	}{
+		Zid:     m.Zid.String(),
+		InfoURL: adapter.NewURLBuilder(ctx, 'i').SetZid(m.Zid).String(),
+		HasDiff: lines != nil,
+		Lines:   lines,
+		Draft:   draft,
+	})
+}
+
+type diffLine struct {
+	Class string
+	HTML  string
+}
+
+// diffLines calculates the line differences of both texts. Lines that were
+// changed are additionally compared word by word.
+func diffLines(a, b string) []diffLine {
+	edits := diff.Lines(a, b)
+	result := make([]diffLine, 0, len(edits))
+	for i := 0; i < len(edits); {
+		if edits[i].Op == diff.Equal {
+			result = append(result, diffLine{"zs-diff-equal", "  " + escapeHTML(edits[i].Text)})
+			i++
+			continue
+		}
+		var dels, inss []string
+		for ; i < len(edits) && edits[i].Op == diff.Delete; i++ {
+			dels = append(dels, edits[i].Text)
+		}
+		for ; i < len(edits) && edits[i].Op == diff.Insert; i++ {
+			inss = append(inss, edits[i].Text)
+		}
+		result = appendChangedLines(result, dels, inss)
+	}
+	return result
+}
+
+func appendChangedLines(result []diffLine, dels, inss []string) []diffLine {
+	n := len(dels)
+	if len(inss) < n {
+		n = len(inss)
+	}
+	delHTML := make([]string, len(dels))
+	insHTML := make([]string, len(inss))
+	for i := 0; i < n; i++ {
+		delHTML[i], insHTML[i] = diffWords(dels[i], inss[i])
+	}
+	for i := n; i < len(dels); i++ {
+		delHTML[i] = escapeHTML(dels[i])
+	}
+	for i := n; i < len(inss); i++ {
+		insHTML[i] = escapeHTML(inss[i])
+	}
+	for _, s := range delHTML {
+		result = append(result, diffLine{"zs-diff-delete", "- " + s})
+	}
+	for _, s := range insHTML {
+		result = append(result, diffLine{"zs-diff-insert", "+ " + s})
+	}
+	return result
+}
+
+func diffWords(a, b string) (string, string) {
+	var sbDel, sbIns strings.Builder
+	for _, e := range diff.Words(a, b) {
+		switch e.Op {
+		case diff.Equal:
+			strfun.HTMLEscape(&sbDel, e.Text, false)
+			strfun.HTMLEscape(&sbIns, e.Text, false)
+		case diff.Delete:
+			sbDel.WriteString("<del>")
+			strfun.HTMLEscape(&sbDel, e.Text, false)
+			sbDel.WriteString("</del>")
+		case diff.Insert:
+			sbIns.WriteString("<ins>")
+			strfun.HTMLEscape(&sbIns, e.Text, false)
+			sbIns.WriteString("</ins>")
+		}
+	}
+	return sbDel.String(), sbIns.String()
+}
+
+func escapeHTML(s string) string {
+	var sb strings.Builder
+	strfun.HTMLEscape(&sb, s, false)
+	return sb.String()
+}
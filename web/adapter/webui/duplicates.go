@@ -0,0 +1,76 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"net/http"
+
+	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/adapter"
+	"zettelstore.de/z/web/session"
+)
+
+// Identifier of the list of duplicate zettel, below the 'k' route.
+const duplicatesZid = id.Zid(11)
+
+type duplicateInfo struct {
+	Zid       string
+	Title     string
+	URL       string
+	InfoURL   string
+	CanDelete bool
+	DeleteURL string
+}
+
+type duplicateGroup struct {
+	Zettel []duplicateInfo
+}
+
+func renderWebUIDuplicatesList(
+	w http.ResponseWriter, r *http.Request, te *TemplateEngine,
+	listDuplicates usecase.ListDuplicates) {
+	ctx := r.Context()
+	clusters, err := listDuplicates.Run(ctx)
+	if err != nil {
+		adapter.ReportUsecaseError(w, r, err)
+		return
+	}
+	user := session.GetUser(ctx)
+	groups := make([]duplicateGroup, 0, len(clusters))
+	for _, metas := range clusters {
+		infos := make([]duplicateInfo, 0, len(metas))
+		for _, m := range metas {
+			infos = append(infos, duplicateInfo{
+				Zid:       m.Zid.String(),
+				Title:     m.GetDefault(meta.KeyTitle, ""),
+				URL:       adapter.NewURLBuilder(ctx, 'h').SetZid(m.Zid).String(),
+				InfoURL:   adapter.NewURLBuilder(ctx, 'i').SetZid(m.Zid).String(),
+				CanDelete: te.canDelete(ctx, user, m),
+				DeleteURL: adapter.NewURLBuilder(ctx, 'd').SetZid(m.Zid).String(),
+			})
+		}
+		groups = append(groups, duplicateGroup{Zettel: infos})
+	}
+
+	var base baseData
+	te.makeBaseData(ctx, runtime.GetDefaultLang(), "Duplicate Zettel", user, &base)
+	te.renderTemplate(ctx, w, id.DuplicatesTemplateZid, &base, struct {
+		HasGroups bool
+		Groups    []duplicateGroup
+	}{
+		HasGroups: len(groups) > 0,
+		Groups:    groups,
+	})
+}
@@ -26,23 +26,33 @@ import (
 // MakeEditGetZettelHandler creates a new HTTP handler to display the
 // HTML edit view of a zettel.
 func MakeEditGetZettelHandler(
-	te *TemplateEngine, getZettel usecase.GetZettel) http.HandlerFunc {
+	te *TemplateEngine,
+	getZettel usecase.GetZettel,
+	getLock usecase.GetLock,
+	suggestTags usecase.SuggestTags,
+) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		zid, err := id.Parse(r.URL.Path[1:])
 		if err != nil {
-			http.NotFound(w, r)
+			adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
 			return
 		}
 
 		ctx := r.Context()
 		zettel, err := getZettel.Run(ctx, zid)
 		if err != nil {
-			adapter.ReportUsecaseError(w, err)
+			adapter.ReportUsecaseError(w, r, err)
 			return
 		}
 
 		if format := adapter.GetFormat(r, r.URL.Query(), "html"); format != "html" {
-			adapter.BadRequest(w, fmt.Sprintf("Edit zettel %q not possible in format %q", zid.String(), format))
+			adapter.BadRequest(w, r, fmt.Sprintf("Edit zettel %q not possible in format %q", zid.String(), format))
+			return
+		}
+
+		tags, err := suggestTags.Run(ctx, zettel)
+		if err != nil {
+			adapter.ReportUsecaseError(w, r, err)
 			return
 		}
 
@@ -50,15 +60,21 @@ func MakeEditGetZettelHandler(
 		m := zettel.Meta
 		var base baseData
 		te.makeBaseData(ctx, runtime.GetLang(m), "Edit Zettel", user, &base)
+		lockInfo := buildLockData(ctx, user, zid, getLock)
 		te.renderTemplate(ctx, w, id.FormTemplateZid, &base, formZettelData{
-			Heading:       base.Title,
-			MetaTitle:     m.GetDefault(meta.KeyTitle, ""),
-			MetaRole:      m.GetDefault(meta.KeyRole, ""),
-			MetaTags:      m.GetDefault(meta.KeyTags, ""),
-			MetaSyntax:    m.GetDefault(meta.KeySyntax, ""),
-			MetaPairsRest: m.PairsRest(false),
-			IsTextContent: !zettel.Content.IsBinary(),
-			Content:       zettel.Content.AsString(),
+			Heading:           base.Title,
+			IsLocked:          lockInfo.isForeignLock(),
+			Lock:              lockInfo,
+			MetaTitle:         m.GetDefault(meta.KeyTitle, ""),
+			MetaRole:          m.GetDefault(meta.KeyRole, ""),
+			MetaTags:          m.GetDefault(meta.KeyTags, ""),
+			HasTagSuggestions: len(tags) > 0,
+			TagSuggestions:    tags,
+			MetaSyntax:        m.GetDefault(meta.KeySyntax, ""),
+			MetaPairsRest:     m.PairsRest(false),
+			IsTextContent:     !zettel.Content.IsBinary(),
+			Content:           zettel.Content.AsString(),
+			PickerURL:         adapter.NewURLBuilder(ctx, 'w').String(),
 		})
 	}
 }
@@ -69,20 +85,20 @@ func MakeEditSetZettelHandler(updateZettel usecase.UpdateZettel) http.HandlerFun
 	return func(w http.ResponseWriter, r *http.Request) {
 		zid, err := id.Parse(r.URL.Path[1:])
 		if err != nil {
-			http.NotFound(w, r)
+			adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
 			return
 		}
 		zettel, hasContent, err := parseZettelForm(r, zid)
 		if err != nil {
-			adapter.BadRequest(w, "Unable to read zettel form")
+			reportFormError(w, r, err, "Unable to read zettel form")
 			return
 		}
 
 		if err := updateZettel.Run(r.Context(), zettel, hasContent); err != nil {
-			adapter.ReportUsecaseError(w, err)
+			adapter.ReportUsecaseError(w, r, err)
 			return
 		}
 		http.Redirect(
-			w, r, adapter.NewURLBuilder('h').SetZid(zid).String(), http.StatusFound)
+			w, r, adapter.NewURLBuilder(r.Context(), 'h').SetZid(zid).String(), http.StatusFound)
 	}
 }
@@ -0,0 +1,88 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"net/http"
+	"strings"
+
+	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/web/adapter"
+	"zettelstore.de/z/web/session"
+)
+
+// errorTemplates maps a HTTP status code to the template of its error page.
+// All other errors use the template id.ErrorTemplateZid.
+var errorTemplates = map[int]id.Zid{
+	http.StatusForbidden:           id.ForbiddenTemplateZid,
+	http.StatusNotFound:            id.NotFoundTemplateZid,
+	http.StatusInternalServerError: id.InternalTemplateZid,
+}
+
+type errorData struct {
+	Title     string
+	Code      string
+	Message   string
+	HasZid    bool
+	Zid       string
+	HasField  bool
+	Field     string
+	ShowLogin bool
+	LoginURL  string
+	SearchURL string
+}
+
+// ReportError shows the given error as a page of the web user interface.
+func (te *TemplateEngine) ReportError(w http.ResponseWriter, r *http.Request, e *adapter.Error) {
+	ctx := r.Context()
+	user := session.GetUser(ctx)
+	var base baseData
+	title := http.StatusText(e.Status)
+	te.makeBaseData(ctx, runtime.GetDefaultLang(), title, user, &base)
+	var zid string
+	if e.Zid.IsValid() {
+		zid = e.Zid.String()
+	} else if e.Status == http.StatusNotFound {
+		zid = attemptedZid(r)
+	}
+	templateID, ok := errorTemplates[e.Status]
+	if !ok {
+		templateID = id.ErrorTemplateZid
+	}
+	te.renderTemplateStatus(ctx, w, e.Status, templateID, &base, errorData{
+		Title:     title,
+		Code:      e.Code,
+		Message:   e.Message,
+		HasZid:    zid != "",
+		Zid:       zid,
+		HasField:  e.Field != "",
+		Field:     e.Field,
+		ShowLogin: te.withAuth && user == nil,
+		LoginURL:  base.LoginURL,
+		SearchURL: base.SearchURL,
+	})
+}
+
+// attemptedZid returns the zettel identifier that was given in the URL of the
+// request, even if it is not valid. If there is none, the empty string is
+// returned.
+func attemptedZid(r *http.Request) string {
+	path := r.URL.Path
+	if pos := strings.LastIndexByte(path, '/'); pos >= 0 {
+		path = path[pos+1:]
+	}
+	if path == "" || strings.TrimLeft(path, "0123456789") != "" {
+		return ""
+	}
+	return path
+}
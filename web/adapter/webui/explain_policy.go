@@ -0,0 +1,153 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"fmt"
+	"net/http"
+
+	"zettelstore.de/z/auth/policy"
+	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/adapter"
+	"zettelstore.de/z/web/session"
+)
+
+type policyStep struct {
+	Rule     string
+	Decision string
+	Reason   string
+}
+
+type policyExplanation struct {
+	Operation string
+	Decision  string
+	Steps     []policyStep
+}
+
+// canExplainPolicy returns true, if the given user is allowed to see how
+// the policy decides for other users. This is only the owner in expert mode.
+func canExplainPolicy(user *meta.Meta) bool {
+	return runtime.GetExpertMode() && runtime.GetUserRole(user) == meta.UserRoleOwner
+}
+
+// MakeGetPolicyHandler creates a new HTTP handler that explains, which
+// policy rules allow or deny the operations of a user on a zettel.
+func MakeGetPolicyHandler(te *TemplateEngine, getMeta usecase.GetMeta) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if format := adapter.GetFormat(r, q, "html"); format != "html" {
+			adapter.BadRequest(w, r, fmt.Sprintf("Policy decisions not available in format %q", format))
+			return
+		}
+		zid, err := id.Parse(r.URL.Path[1:])
+		if err != nil {
+			adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
+			return
+		}
+		ctx := r.Context()
+		user := session.GetUser(ctx)
+		if !canExplainPolicy(user) {
+			adapter.Forbidden(w, r, "Policy decisions are only shown to the owner in expert mode")
+			return
+		}
+		m, err := getMeta.Run(ctx, zid)
+		if err != nil {
+			adapter.ReportUsecaseError(w, r, err)
+			return
+		}
+		userZid, other, ok := getPolicyUser(w, r, q.Get("user"), getMeta)
+		if !ok {
+			return
+		}
+		explanations := policy.Explain(te.policy, other, m)
+		if explanations == nil {
+			adapter.NotImplemented(w, r, "Policy is not able to explain its decisions")
+			return
+		}
+
+		userName := "an anonymous user"
+		if other != nil {
+			userName = other.GetDefault(meta.KeyUserID, userZid)
+		}
+		var base baseData
+		te.makeBaseData(
+			ctx, runtime.GetLang(m), "Policy Decisions for Zettel "+zid.String(), user, &base)
+		te.renderTemplate(ctx, w, id.PolicyTemplateZid, &base, struct {
+			Zid          string
+			InfoURL      string
+			UserZid      string
+			UserName     string
+			Explanations []policyExplanation
+		}{
+			Zid:          zid.String(),
+			InfoURL:      adapter.NewURLBuilder(ctx, 'i').SetZid(zid).String(),
+			UserZid:      userZid,
+			UserName:     userName,
+			Explanations: buildPolicyExplanations(explanations),
+		})
+	}
+}
+
+// getPolicyUser returns the user zettel, for which the policy decisions
+// should be explained. An empty zettel identifier denotes an anonymous user.
+func getPolicyUser(
+	w http.ResponseWriter, r *http.Request, val string, getMeta usecase.GetMeta,
+) (string, *meta.Meta, bool) {
+	if val == "" {
+		return "", nil, true
+	}
+	zid, err := id.Parse(val)
+	if err != nil {
+		adapter.BadRequest(w, r, fmt.Sprintf("Invalid user zettel identifier %q", val))
+		return "", nil, false
+	}
+	m, err := getMeta.Run(r.Context(), zid)
+	if err != nil {
+		adapter.ReportUsecaseError(w, r, err)
+		return "", nil, false
+	}
+	if m.GetDefault(meta.KeyRole, "") != meta.ValueRoleUser {
+		adapter.BadRequest(w, r, fmt.Sprintf("Zettel %v is not a user zettel", zid))
+		return "", nil, false
+	}
+	return zid.String(), m, true
+}
+
+func buildPolicyExplanations(explanations []policy.Explanation) []policyExplanation {
+	result := make([]policyExplanation, 0, len(explanations))
+	for _, expl := range explanations {
+		steps := make([]policyStep, 0, len(expl.Steps))
+		for _, step := range expl.Steps {
+			steps = append(steps, policyStep{
+				Rule:     step.Rule,
+				Decision: policyDecision(step.Allowed),
+				Reason:   step.Reason,
+			})
+		}
+		result = append(result, policyExplanation{
+			Operation: expl.Operation,
+			Decision:  policyDecision(expl.Allowed),
+			Steps:     steps,
+		})
+	}
+	return result
+}
+
+func policyDecision(allowed bool) string {
+	if allowed {
+		return "allowed"
+	}
+	return "denied"
+}
@@ -0,0 +1,128 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/place"
+	"zettelstore.de/z/web/adapter"
+)
+
+// maxFacetValues is the maximum number of values shown for a facet.
+const maxFacetValues = 20
+
+// facetKeys lists the facets of a zettel list, together with their names.
+var facetKeys = []struct {
+	key  string
+	name string
+}{
+	{meta.KeyRole, "Roles"},
+	{meta.KeyTags, "Tags"},
+	{place.FacetYear, "Years"},
+}
+
+// facetData contains all data of the facet sidebar.
+type facetData struct {
+	Count  string
+	Facets []facetInfo
+}
+
+type facetInfo struct {
+	Name   string
+	Values []facetValue
+}
+
+type facetValue struct {
+	Text  string
+	Count string
+	URL   string
+}
+
+// facetQueryKey returns the query key to select zettel by the facet key.
+func facetQueryKey(key string) string {
+	if key == place.FacetYear {
+		return meta.KeyID
+	}
+	return key
+}
+
+func getFacetKeys() []string {
+	result := make([]string, 0, len(facetKeys))
+	for _, fk := range facetKeys {
+		result = append(result, fk.key)
+	}
+	return result
+}
+
+// buildFacetData returns the data of the facet sidebar. Each facet value
+// links to the current list, restricted to that value. Values that are
+// already selected are omitted.
+func buildFacetData(
+	ctx context.Context, query url.Values, count int, facets place.Facets) *facetData {
+	result := &facetData{Count: strconv.Itoa(count)}
+	for _, fk := range facetKeys {
+		qKey := facetQueryKey(fk.key)
+		selected := make(map[string]bool, len(query[qKey]))
+		for _, val := range query[qKey] {
+			selected[val] = true
+		}
+		counts := facets[fk.key]
+		values := make([]string, 0, len(counts))
+		for val := range counts {
+			if !selected[val] {
+				values = append(values, val)
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+		sort.Slice(values, func(i, j int) bool {
+			if ci, cj := counts[values[i]], counts[values[j]]; ci != cj {
+				return ci > cj
+			}
+			return values[i] < values[j]
+		})
+		if len(values) > maxFacetValues {
+			values = values[:maxFacetValues]
+		}
+		fi := facetInfo{Name: fk.name, Values: make([]facetValue, 0, len(values))}
+		for _, val := range values {
+			fi.Values = append(fi.Values, facetValue{
+				Text:  val,
+				Count: strconv.Itoa(counts[val]),
+				URL:   newFacetURL(ctx, query, qKey, val),
+			})
+		}
+		result.Facets = append(result.Facets, fi)
+	}
+	return result
+}
+
+// newFacetURL returns the URL of the current list, restricted to zettel
+// where the given key has the given value. The list starts at its beginning.
+func newFacetURL(ctx context.Context, query url.Values, key, value string) string {
+	urlBuilder := adapter.NewURLBuilder(ctx, 'h')
+	for qKey, values := range query {
+		if qKey != "_offset" && qKey != "_after" {
+			for _, val := range values {
+				urlBuilder.AppendQuery(qKey, val)
+			}
+		}
+	}
+	urlBuilder.AppendQuery(key, value)
+	return urlBuilder.String()
+}
@@ -0,0 +1,148 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/place"
+	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/adapter"
+	"zettelstore.de/z/web/session"
+)
+
+// flagData contains the data about the flags a user has set on a zettel.
+type flagData struct {
+	URL         string
+	IsFavorite  bool
+	IsReadLater bool
+}
+
+// buildFlagData returns the flags of the given zettel, as set by the given
+// user. It returns nil, if flags cannot be set.
+func (te *TemplateEngine) buildFlagData(
+	ctx context.Context, user *meta.Meta, zid id.Zid) *flagData {
+	if user == nil || !te.withAuth || session.GetScope(ctx).ReadOnly {
+		return nil
+	}
+	return &flagData{
+		URL:         adapter.NewURLBuilder(ctx, 'j').SetZid(zid).String(),
+		IsFavorite:  usecase.IsFlagged(user, usecase.FlagFavorite, zid),
+		IsReadLater: usecase.IsFlagged(user, usecase.FlagReadLater, zid),
+	}
+}
+
+// MakePostFlagZettelHandler creates a new HTTP handler to set or to remove a
+// flag of the current user on a zettel.
+func MakePostFlagZettelHandler(flagZettel usecase.FlagZettel) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		zid, err := id.Parse(r.URL.Path[1:])
+		if err != nil {
+			adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
+			return
+		}
+		if err = r.ParseForm(); err != nil {
+			adapter.BadRequest(w, r, "Unable to read flag form")
+			return
+		}
+		ctx := r.Context()
+		user := session.GetUser(ctx)
+		if session.GetScope(ctx).ReadOnly {
+			// The use case changes the user zettel without checking the scope.
+			adapter.ReportUsecaseError(w, r, place.NewErrNotAllowed("Flag", user, zid))
+			return
+		}
+		flag, set := r.PostFormValue("flag"), r.PostFormValue("action") != "unset"
+		if err = flagZettel.Run(ctx, user, zid, flag, set); err != nil {
+			adapter.ReportUsecaseError(w, r, err)
+			return
+		}
+		http.Redirect(w, r, adapter.NewURLBuilder(ctx, 'h').SetZid(zid).String(), http.StatusFound)
+	}
+}
+
+// renderWebUIFlaggedList renders all zettel that the current user has
+// flagged with the given flag. The list is reachable below the given
+// identifier.
+func renderWebUIFlaggedList(
+	w http.ResponseWriter,
+	r *http.Request,
+	te *TemplateEngine,
+	listMeta usecase.ListMeta,
+	listZid id.Zid,
+	flag string,
+) {
+	ctx := r.Context()
+	user := session.GetUser(ctx)
+	if user == nil {
+		adapter.Forbidden(w, r, "Flagged zettel are only available for authenticated users")
+		return
+	}
+	flagged := make(map[id.Zid]bool)
+	for _, zid := range usecase.GetFlagged(user, flag) {
+		flagged[zid] = true
+	}
+	query := r.URL.Query()
+	filter, sorter := adapter.GetFilterSorter(query, false)
+	filter = place.EnsureFilter(filter)
+	filter.Select = func(m *meta.Meta) bool { return flagged[m.Zid] }
+	renderWebUIMetaList(
+		w, r, te, sorter, adapter.GetListColumns(query, "_columns"), nil, nil, "", "", nil,
+		func(sorter *place.Sorter) ([]*meta.Meta, error) {
+			if len(flagged) == 0 {
+				return nil, nil
+			}
+			return listMeta.Run(ctx, filter, sorter)
+		},
+		func(offset int) string {
+			return newFlaggedListURL(
+				ctx, listZid, query, "_offset", strconv.Itoa(offset), "_after", "_limit")
+		},
+		func(after id.Zid) string {
+			return newFlaggedListURL(ctx, listZid, query, "_after", after.String(), "_offset")
+		},
+		func(order string) string {
+			return newFlaggedListURL(
+				ctx, listZid, query, "_sort", order, "_order", "_after", "_offset")
+		})
+}
+
+// newFlaggedListURL returns the URL of a list of flagged zettel, where the
+// value of the given query key is replaced and some other keys are removed.
+func newFlaggedListURL(
+	ctx context.Context, listZid id.Zid, query url.Values, key, value string, drop ...string) string {
+	urlBuilder := adapter.NewURLBuilder(ctx, 'k').SetZid(listZid)
+	for k, values := range query {
+		if k == key || containsString(drop, k) {
+			continue
+		}
+		for _, val := range values {
+			urlBuilder.AppendQuery(k, val)
+		}
+	}
+	urlBuilder.AppendQuery(key, value)
+	return urlBuilder.String()
+}
+
+func containsString(list []string, s string) bool {
+	for _, elem := range list {
+		if elem == s {
+			return true
+		}
+	}
+	return false
+}
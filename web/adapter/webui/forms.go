@@ -12,31 +12,67 @@
 package webui
 
 import (
+	"bytes"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"strings"
 
+	"zettelstore.de/z/config/startup"
 	"zettelstore.de/z/domain"
 	"zettelstore.de/z/domain/id"
 	"zettelstore.de/z/domain/meta"
 	"zettelstore.de/z/input"
+	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/adapter"
 )
 
 type formZettelData struct {
-	Heading       string
-	MetaTitle     string
-	MetaRole      string
-	MetaTags      string
-	MetaSyntax    string
-	MetaPairsRest []meta.Pair
-	IsTextContent bool
-	Content       string
+	Heading           string
+	IsLocked          bool
+	Lock              *lockData
+	MetaTitle         string
+	MetaRole          string
+	MetaTags          string
+	HasTagSuggestions bool
+	TagSuggestions    []string
+	MetaSyntax        string
+	MetaPairsRest     []meta.Pair
+	IsTextContent     bool
+	Content           string
+	PickerURL         string
+}
+
+// formMemory is the maximum number of bytes of a multipart form that are
+// kept in memory. Larger parts, like uploaded content, are stored in
+// temporary files, which are removed after the request was handled.
+const formMemory = 1 << 20
+
+// parseForm parses an URL encoded or a multipart form.
+func parseForm(r *http.Request) error {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		return r.ParseMultipartForm(formMemory)
+	}
+	return r.ParseForm()
 }
 
 func parseZettelForm(r *http.Request, zid id.Zid) (domain.Zettel, bool, error) {
-	err := r.ParseForm()
+	m, err := parseZettelMeta(r, zid)
 	if err != nil {
 		return domain.Zettel{}, false, err
 	}
+	if content, ok, err := readContentFile(r, zid); err != nil || ok {
+		return domain.Zettel{Meta: m, Content: content}, ok, err
+	}
+	content, ok := formContent(r)
+	return domain.Zettel{Meta: m, Content: content}, ok, nil
+}
+
+// parseZettelMeta parses the form and returns the meta data of the zettel.
+func parseZettelMeta(r *http.Request, zid id.Zid) (*meta.Meta, error) {
+	if err := parseForm(r); err != nil {
+		return nil, err
+	}
 
 	var m *meta.Meta
 	if postMeta, ok := trimmedFormValue(r, "meta"); ok {
@@ -58,17 +94,62 @@ func parseZettelForm(r *http.Request, zid id.Zid) (domain.Zettel, bool, error) {
 	if postSyntax, ok := trimmedFormValue(r, "syntax"); ok {
 		m.Set(meta.KeySyntax, postSyntax)
 	}
+	return m, nil
+}
+
+// formContent returns the content of the text area.
+func formContent(r *http.Request) (domain.Content, bool) {
 	if values, ok := r.PostForm["content"]; ok && len(values) > 0 {
-		return domain.Zettel{
-			Meta: m,
-			Content: domain.NewContent(
-				strings.ReplaceAll(strings.TrimSpace(values[0]), "\r\n", "\n")),
-		}, true, nil
-	}
-	return domain.Zettel{
-		Meta:    m,
-		Content: domain.NewContent(""),
-	}, false, nil
+		return domain.NewContent(
+			strings.ReplaceAll(strings.TrimSpace(values[0]), "\r\n", "\n")), true
+	}
+	return domain.NewContent(""), false
+}
+
+// openContentFile opens an uploaded file, if there is one. Its size is checked
+// before the file is opened. A nil file means that no file was uploaded.
+func openContentFile(r *http.Request, zid id.Zid) (multipart.File, int64, error) {
+	if r.MultipartForm == nil {
+		return nil, 0, nil
+	}
+	files := r.MultipartForm.File["content-file"]
+	if len(files) == 0 || files[0].Size == 0 {
+		return nil, 0, nil
+	}
+	fh := files[0]
+	if max := startup.MaxZettelSize(); max > 0 && fh.Size > max {
+		return nil, 0, &usecase.ErrZettelTooLarge{Zid: zid, Size: fh.Size, Max: max}
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return nil, 0, err
+	}
+	return f, fh.Size, nil
+}
+
+// readContentFile returns the content of an uploaded file, if there is one.
+func readContentFile(r *http.Request, zid id.Zid) (domain.Content, bool, error) {
+	f, size, err := openContentFile(r, zid)
+	if err != nil || f == nil {
+		return "", false, err
+	}
+	defer f.Close()
+	var buf bytes.Buffer
+	buf.Grow(int(size))
+	if _, err = io.Copy(&buf, io.LimitReader(f, size)); err != nil {
+		return "", false, err
+	}
+	return domain.NewContent(buf.String()), true, nil
+}
+
+// reportFormError writes an error response for a zettel form that could not
+// be read.
+func reportFormError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	if _, ok := err.(*usecase.ErrZettelTooLarge); ok {
+		adapter.ReportUsecaseError(w, r, err)
+		return
+	}
+	adapter.BadRequest(w, r, msg)
 }
 
 func trimmedFormValue(r *http.Request, key string) (string, bool) {
@@ -12,8 +12,10 @@
 package webui
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 
 	"zettelstore.de/z/ast"
@@ -41,6 +43,16 @@ type zettelReference struct {
 	URL    string
 }
 
+// relationGroup contains all zettel that are linked with the same relation
+// type, either from the zettel or to the zettel.
+type relationGroup struct {
+	Rel         string
+	HasOutgoing bool
+	Outgoing    []zettelReference
+	HasIncoming bool
+	Incoming    []zettelReference
+}
+
 type matrixElement struct {
 	Text   string
 	HasURL bool
@@ -55,24 +67,26 @@ func MakeGetInfoHandler(
 	te *TemplateEngine,
 	parseZettel usecase.ParseZettel,
 	getMeta usecase.GetMeta,
+	getShadowing usecase.GetShadowing,
+	listRelations usecase.ListRelations,
 ) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		q := r.URL.Query()
 		if format := adapter.GetFormat(r, q, "html"); format != "html" {
-			adapter.BadRequest(w, fmt.Sprintf("Zettel info not available in format %q", format))
+			adapter.BadRequest(w, r, fmt.Sprintf("Zettel info not available in format %q", format))
 			return
 		}
 
 		zid, err := id.Parse(r.URL.Path[1:])
 		if err != nil {
-			http.NotFound(w, r)
+			adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
 			return
 		}
 
 		ctx := r.Context()
 		zn, err := parseZettel.Run(ctx, zid, q.Get("syntax"))
 		if err != nil {
-			adapter.ReportUsecaseError(w, err)
+			adapter.ReportUsecaseError(w, r, err)
 			return
 		}
 
@@ -96,11 +110,18 @@ func MakeGetInfoHandler(
 		}
 		summary := collect.References(zn)
 		zetLinks, locLinks, extLinks := splitIntExtLinks(
-			getTitle, append(summary.Links, summary.Images...))
+			ctx, getTitle, append(summary.Links, summary.Images...))
+
+		incoming, err := listRelations.Run(ctx, zid)
+		if err != nil {
+			adapter.ReportUsecaseError(w, r, err)
+			return
+		}
+		relations := groupRelations(ctx, getTitle, summary.Relations, incoming)
 
 		textTitle, err := adapter.FormatInlines(zn.Title, "text", nil, langOption)
 		if err != nil {
-			adapter.InternalServerError(w, "Format Text inlines for info", err)
+			adapter.InternalServerError(w, r, "Format Text inlines for info", err)
 			return
 		}
 
@@ -109,14 +130,14 @@ func MakeGetInfoHandler(
 		metaData := make([]metaDataInfo, 0, len(pairs))
 		for _, p := range pairs {
 			var html strings.Builder
-			writeHTMLMetaValue(&html, zn.Zettel.Meta, p.Key, getTitle, langOption)
+			writeHTMLMetaValue(ctx, &html, zn.Zettel.Meta, p.Key, getTitle, langOption)
 			metaData = append(metaData, metaDataInfo{p.Key, html.String()})
 		}
 		formats := encoder.GetFormats()
 		defFormat := encoder.GetDefaultFormat()
 		parts := []string{"zettel", "meta", "content"}
 		matrix := make([]matrixLine, 0, len(parts))
-		u := adapter.NewURLBuilder('z').SetZid(zid)
+		u := adapter.NewURLBuilder(ctx, 'z').SetZid(zid)
 		for _, part := range parts {
 			row := make([]matrixElement, 0, len(formats)+1)
 			row = append(row, matrixElement{part, false, ""})
@@ -130,9 +151,16 @@ func MakeGetInfoHandler(
 			}
 			matrix = append(matrix, matrixLine{row})
 		}
+		sh, err := getShadowing.Run(ctx, zid)
+		if err != nil {
+			adapter.ReportUsecaseError(w, r, err)
+			return
+		}
 		var base baseData
 		te.makeBaseData(ctx, langOption.Value, textTitle, user, &base)
 		canCopy := base.CanCreate && !zn.Zettel.Content.IsBinary()
+		canWrite := te.canWrite(ctx, user, zn.Zettel)
+		canDelete := te.canDelete(ctx, user, zn.Zettel.Meta)
 		te.renderTemplate(ctx, w, id.InfoTemplateZid, &base, struct {
 			Zid          string
 			WebURL       string
@@ -148,7 +176,23 @@ func MakeGetInfoHandler(
 			RenameURL    string
 			CanDelete    bool
 			DeleteURL    string
+			CanUndo      bool
+			UndoURL      string
+			CanReload    bool
+			ReloadURL    string
+			CanDiff      bool
+			DiffURL      string
+			CanExplain   bool
+			PolicyURL    string
 			MetaData     []metaDataInfo
+			HasPlaces    bool
+			IsBuiltin    bool
+			IsComputed   bool
+			Shadows      bool
+			Shadowed     []string
+			CanCustomize bool
+			CanRevert    bool
+			ShadowURL    string
 			HasLinks     bool
 			HasZetLinks  bool
 			ZetLinks     []zettelReference
@@ -157,24 +201,42 @@ func MakeGetInfoHandler(
 			HasExtLinks  bool
 			ExtLinks     []string
 			ExtNewWindow string
+			HasRelations bool
+			Relations    []relationGroup
 			Matrix       []matrixLine
 		}{
 			Zid:      zid.String(),
-			WebURL:   adapter.NewURLBuilder('h').SetZid(zid).String(),
-			CanWrite: te.canWrite(ctx, user, zn.Zettel),
-			EditURL:  adapter.NewURLBuilder('e').SetZid(zid).String(),
+			WebURL:   adapter.NewURLBuilder(ctx, 'h').SetZid(zid).String(),
+			CanWrite: canWrite,
+			EditURL:  adapter.NewURLBuilder(ctx, 'e').SetZid(zid).String(),
 			CanFolge: base.CanCreate && !zn.Zettel.Content.IsBinary(),
-			FolgeURL: adapter.NewURLBuilder('f').SetZid(zid).String(),
+			FolgeURL: adapter.NewURLBuilder(ctx, 'f').SetZid(zid).String(),
 			CanCopy:  canCopy,
-			CopyURL:  adapter.NewURLBuilder('c').SetZid(zid).String(),
+			CopyURL:  adapter.NewURLBuilder(ctx, 'c').SetZid(zid).String(),
 			CanNew: canCopy && zn.Zettel.Meta.GetDefault(meta.KeyRole, "") ==
 				meta.ValueRoleNewTemplate,
-			NewURL:       adapter.NewURLBuilder('n').SetZid(zid).String(),
+			NewURL:       adapter.NewURLBuilder(ctx, 'n').SetZid(zid).String(),
 			CanRename:    te.canRename(ctx, user, zn.Zettel.Meta),
-			RenameURL:    adapter.NewURLBuilder('r').SetZid(zid).String(),
-			CanDelete:    te.canDelete(ctx, user, zn.Zettel.Meta),
-			DeleteURL:    adapter.NewURLBuilder('d').SetZid(zid).String(),
+			RenameURL:    adapter.NewURLBuilder(ctx, 'r').SetZid(zid).String(),
+			CanDelete:    canDelete,
+			DeleteURL:    adapter.NewURLBuilder(ctx, 'd').SetZid(zid).String(),
+			CanUndo:      te.canUndo(ctx, user, zn.Zettel.Meta),
+			UndoURL:      adapter.NewURLBuilder(ctx, 'o').SetZid(zid).String(),
+			CanReload:    base.CanReload,
+			ReloadURL:    adapter.NewURLBuilder(ctx, 'u').SetZid(zid).AppendQuery("_format", "html").String(),
+			CanDiff:      !zn.Zettel.Content.IsBinary(),
+			DiffURL:      adapter.NewURLBuilder(ctx, 'v').SetZid(zid).String(),
+			CanExplain:   canExplainPolicy(user),
+			PolicyURL:    adapter.NewURLBuilder(ctx, 'p').SetZid(zid).String(),
 			MetaData:     metaData,
+			HasPlaces:    sh.IsBuiltin() || sh.IsComputed() || sh.Shadows(),
+			IsBuiltin:    sh.IsBuiltin(),
+			IsComputed:   sh.IsComputed(),
+			Shadows:      sh.Shadows(),
+			Shadowed:     shadowedPlaces(sh),
+			CanCustomize: canWrite && sh.CanCustomize(),
+			CanRevert:    canDelete && sh.CanRevert(),
+			ShadowURL:    adapter.NewURLBuilder(ctx, 'i').SetZid(zid).String(),
 			HasLinks:     len(zetLinks)+len(extLinks)+len(locLinks) > 0,
 			HasZetLinks:  len(zetLinks) > 0,
 			ZetLinks:     zetLinks,
@@ -183,12 +245,84 @@ func MakeGetInfoHandler(
 			HasExtLinks:  len(extLinks) > 0,
 			ExtLinks:     extLinks,
 			ExtNewWindow: htmlAttrNewWindow(len(extLinks) > 0),
+			HasRelations: len(relations) > 0,
+			Relations:    relations,
 			Matrix:       matrix,
 		})
 	}
 }
 
+// shadowedPlaces describes the places of all zettel that are shadowed by the
+// visible zettel.
+func shadowedPlaces(sh usecase.Shadowing) []string {
+	if !sh.Shadows() {
+		return nil
+	}
+	result := make([]string, 0, len(sh.Placements)-1)
+	for _, p := range sh.Placements[1:] {
+		switch {
+		case p.Builtin:
+			result = append(result, "Built-in zettel")
+		case p.Computed:
+			result = append(result, "Computed zettel")
+		default:
+			result = append(result, fmt.Sprintf("Zettel in place %d", p.Place))
+		}
+	}
+	return result
+}
+
+// groupRelations groups the typed links of a zettel and the typed links to
+// the zettel by their relation type. Untyped links are ignored.
+func groupRelations(
+	ctx context.Context,
+	getTitle func(id.Zid, string) (string, int),
+	outgoing []collect.Relation,
+	incoming map[string][]*meta.Meta,
+) []relationGroup {
+	refs := make(map[string][]*ast.Reference, len(outgoing))
+	for _, rel := range outgoing {
+		refs[rel.Rel] = append(refs[rel.Rel], rel.Ref)
+	}
+	rels := make([]string, 0, len(refs)+len(incoming))
+	for rel := range refs {
+		rels = append(rels, rel)
+	}
+	for rel := range incoming {
+		if _, ok := refs[rel]; !ok && rel != "" {
+			rels = append(rels, rel)
+		}
+	}
+	sort.Strings(rels)
+	result := make([]relationGroup, 0, len(rels))
+	for _, rel := range rels {
+		zetRefs, _, _ := collect.DivideReferences(refs[rel], false)
+		zetLinks, _, _ := splitIntExtLinks(ctx, getTitle, zetRefs)
+		var inLinks []zettelReference
+		for _, m := range incoming[rel] {
+			title, _ := getTitle(m.Zid, "html")
+			if len(title) == 0 {
+				title = m.Zid.String()
+			}
+			u := adapter.NewURLBuilder(ctx, 'h').SetZid(m.Zid).String()
+			inLinks = append(inLinks, zettelReference{m.Zid, title, true, u})
+		}
+		if len(zetLinks)+len(inLinks) == 0 {
+			continue
+		}
+		result = append(result, relationGroup{
+			Rel:         rel,
+			HasOutgoing: len(zetLinks) > 0,
+			Outgoing:    zetLinks,
+			HasIncoming: len(inLinks) > 0,
+			Incoming:    inLinks,
+		})
+	}
+	return result
+}
+
 func splitIntExtLinks(
+	ctx context.Context,
 	getTitle func(id.Zid, string) (string, int),
 	links []*ast.Reference,
 ) (zetLinks []zettelReference, locLinks []string, extLinks []string) {
@@ -211,7 +345,7 @@ func splitIntExtLinks(
 				}
 				var u string
 				if found == 1 {
-					ub := adapter.NewURLBuilder('h').SetZid(zid)
+					ub := adapter.NewURLBuilder(ctx, 'h').SetZid(zid)
 					if fragment := ref.URL.EscapedFragment(); len(fragment) > 0 {
 						ub.SetFragment(fragment)
 					}
@@ -12,6 +12,7 @@
 package webui
 
 import (
+	"context"
 	"net/http"
 	"strings"
 
@@ -29,11 +30,18 @@ import (
 func MakeGetHTMLZettelHandler(
 	te *TemplateEngine,
 	parseZettel usecase.ParseZettel,
-	getMeta usecase.GetMeta) http.HandlerFunc {
+	getMeta usecase.GetMeta,
+	listMeta usecase.ListMeta,
+	getGlossary usecase.GetGlossary,
+	getLock usecase.GetLock,
+	listComments usecase.ListComments,
+	trackVisit usecase.TrackVisit,
+	listRecent usecase.ListRecent,
+	quota usecase.Quota) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		zid, err := id.Parse(r.URL.Path[1:])
 		if err != nil {
-			http.NotFound(w, r)
+			adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
 			return
 		}
 
@@ -41,7 +49,7 @@ func MakeGetHTMLZettelHandler(
 		syntax := r.URL.Query().Get("syntax")
 		zn, err := parseZettel.Run(ctx, zid, syntax)
 		if err != nil {
-			adapter.ReportUsecaseError(w, err)
+			adapter.ReportUsecaseError(w, r, err)
 			return
 		}
 
@@ -50,94 +58,214 @@ func MakeGetHTMLZettelHandler(
 			"html",
 			&encoder.StringsOption{
 				Key:   "no-meta",
-				Value: []string{meta.KeyTitle, meta.KeyLang},
+				Value: []string{meta.KeyTitle, meta.KeyLang, meta.KeyExtractedText},
 			},
 		)
 		if err != nil {
-			adapter.InternalServerError(w, "Format meta", err)
+			adapter.InternalServerError(w, r, "Format meta", err)
 			return
 		}
 		langOption := encoder.StringOption{Key: "lang", Value: runtime.GetLang(zn.InhMeta)}
 		htmlTitle, err := adapter.FormatInlines(zn.Title, "html", &langOption)
 		if err != nil {
-			adapter.InternalServerError(w, "Format HTML inlines", err)
+			adapter.InternalServerError(w, r, "Format HTML inlines", err)
 			return
 		}
 		textTitle, err := adapter.FormatInlines(zn.Title, "text", &langOption)
 		if err != nil {
-			adapter.InternalServerError(w, "Format text inlines", err)
+			adapter.InternalServerError(w, r, "Format text inlines", err)
+			return
+		}
+		te.expandQueries(ctx, zn.Ast, listMeta)
+		renderDiagrams(ctx, zn.Ast)
+		glossaryOption, err := getGlossaryOption(ctx, zn.InhMeta, getGlossary)
+		if err != nil {
+			adapter.InternalServerError(w, r, "Get glossary", err)
 			return
 		}
 		newWindow := true
 		htmlContent, err := formatBlocks(
 			zn.Ast,
 			"html",
+			glossaryOption,
 			&langOption,
 			&encoder.StringOption{
 				Key:   meta.KeyMarkerExternal,
 				Value: runtime.GetMarkerExternal()},
 			&encoder.BoolOption{Key: "newwindow", Value: newWindow},
+			&encoder.BoolOption{
+				Key:   meta.KeyNumbering,
+				Value: zn.InhMeta.GetBool(meta.KeyNumbering)},
 			&encoder.AdaptLinkOption{
 				Adapter: adapter.MakeLinkAdapter(ctx, 'h', getMeta, "", ""),
 			},
-			&encoder.AdaptImageOption{Adapter: adapter.MakeImageAdapter()},
+			&encoder.AdaptImageOption{Adapter: adapter.MakeImageAdapter(ctx)},
 		)
 		if err != nil {
-			adapter.InternalServerError(w, "Format blocks", err)
+			adapter.InternalServerError(w, r, "Format blocks", err)
 			return
 		}
 		user := session.GetUser(ctx)
 		roleText := zn.Zettel.Meta.GetDefault(meta.KeyRole, "*")
-		tags := buildTagInfos(zn.Zettel.Meta)
+		tags := buildTagInfos(ctx, zn.Zettel.Meta)
 		extURL, hasExtURL := zn.Zettel.Meta.Get(meta.KeyURL)
+		copyright := zn.InhMeta.GetDefault(meta.KeyCopyright, "")
+		license := zn.InhMeta.GetDefault(meta.KeyLicense, "")
 		var base baseData
+		breadcrumbs, err := buildBreadcrumbs(ctx, zn.Zettel.Meta, getMeta)
+		if err != nil {
+			adapter.InternalServerError(w, r, "Build breadcrumbs", err)
+			return
+		}
 		te.makeBaseData(ctx, langOption.Value, textTitle, user, &base)
-		base.MetaHeader = metaHeader
+		base.MetaHeader = metaHeader + openGraphHeader(ctx, zn, textTitle, getMeta)
+		base.ZettelAssets = zettelAssets(ctx, zn.Zettel.Meta, getMeta)
 		canCopy := base.CanCreate && !zn.Zettel.Content.IsBinary()
+		canWrite := te.canWrite(ctx, user, zn.Zettel)
+		comments, err := listComments.Run(ctx, zid)
+		if err != nil {
+			adapter.InternalServerError(w, r, "List comments", err)
+			return
+		}
+		commentsHTML, err := buildCommentsHTML(ctx, comments, parseZettel, getMeta)
+		if err != nil {
+			adapter.InternalServerError(w, r, "Format comments", err)
+			return
+		}
+		canSuggest := !canWrite && !zn.Zettel.Content.IsBinary() &&
+			usecase.CanSuggest(zn.Zettel.Meta)
+		flags := te.buildFlagData(ctx, user, zid)
+		status := te.buildStatusData(ctx, user, zn.Zettel)
+		var recent *recentData
+		if zid == runtime.GetStart() {
+			if recent, err = buildRecentData(ctx, user, listRecent); err != nil {
+				adapter.InternalServerError(w, r, "Build recent zettel", err)
+				return
+			}
+		}
+		quotaInfo, err := buildQuotaData(ctx, user, zid, quota)
+		if err != nil {
+			adapter.InternalServerError(w, r, "Build quota", err)
+			return
+		}
+		trackVisit.Run(user, zid)
+		var lockInfo *lockData
+		if canWrite {
+			lockInfo = buildLockData(ctx, user, zid, getLock)
+		}
 		te.renderTemplate(ctx, w, id.DetailTemplateZid, &base, struct {
-			HTMLTitle    string
-			CanWrite     bool
-			EditURL      string
-			Zid          string
-			InfoURL      string
-			RoleText     string
-			RoleURL      string
-			HasTags      bool
-			Tags         []simpleLink
-			CanCopy      bool
-			CopyURL      string
-			CanNew       bool
-			NewURL       string
-			CanFolge     bool
-			FolgeURL     string
-			HasExtURL    bool
-			ExtURL       string
-			ExtNewWindow string
-			Content      string
+			HasBreadcrumbs bool
+			Breadcrumbs    []metaInfo
+			HTMLTitle      string
+			CanWrite       bool
+			EditURL        string
+			HasLock        bool
+			Lock           *lockData
+			HasQuota       bool
+			Quota          *quotaData
+			Zid            string
+			InfoURL        string
+			RoleText       string
+			RoleURL        string
+			HasTags        bool
+			Tags           []simpleLink
+			CanCopy        bool
+			CopyURL        string
+			CanNew         bool
+			NewURL         string
+			CanFolge       bool
+			FolgeURL       string
+			CanSuggest     bool
+			SuggestURL     string
+			HasFlags       bool
+			Flags          *flagData
+			HasStatus      bool
+			Status         *statusData
+			HasExtURL      bool
+			ExtURL         string
+			ExtNewWindow   string
+			Content        string
+
+			HasAttribution bool
+			Copyright      string
+			License        string
+
+			HasRecent bool
+			Recent    *recentData
+
+			HasComments bool
+			Comments    string
+			CanComment  bool
+			CommentURL  string
 		}{
-			HTMLTitle:    htmlTitle,
-			CanWrite:     te.canWrite(ctx, user, zn.Zettel),
-			EditURL:      adapter.NewURLBuilder('e').SetZid(zid).String(),
-			Zid:          zid.String(),
-			InfoURL:      adapter.NewURLBuilder('i').SetZid(zid).String(),
-			RoleText:     roleText,
-			RoleURL:      adapter.NewURLBuilder('h').AppendQuery("role", roleText).String(),
-			HasTags:      len(tags) > 0,
-			Tags:         tags,
-			CanCopy:      canCopy,
-			CopyURL:      adapter.NewURLBuilder('c').SetZid(zid).String(),
-			CanNew:       canCopy && roleText == meta.ValueRoleNewTemplate,
-			NewURL:       adapter.NewURLBuilder('n').SetZid(zid).String(),
-			CanFolge:     base.CanCreate && !zn.Zettel.Content.IsBinary(),
-			FolgeURL:     adapter.NewURLBuilder('f').SetZid(zid).String(),
-			ExtURL:       extURL,
-			HasExtURL:    hasExtURL,
-			ExtNewWindow: htmlAttrNewWindow(newWindow && hasExtURL),
-			Content:      htmlContent,
+			HasBreadcrumbs: len(breadcrumbs) > 0,
+			Breadcrumbs:    breadcrumbs,
+			HTMLTitle:      htmlTitle,
+			CanWrite:       canWrite,
+			EditURL:        adapter.NewURLBuilder(ctx, 'e').SetZid(zid).String(),
+			HasLock:        lockInfo != nil,
+			Lock:           lockInfo,
+			HasQuota:       quotaInfo != nil,
+			Quota:          quotaInfo,
+			Zid:            zid.String(),
+			InfoURL:        adapter.NewURLBuilder(ctx, 'i').SetZid(zid).String(),
+			RoleText:       roleText,
+			RoleURL:        adapter.NewURLBuilder(ctx, 'h').AppendQuery("role", roleText).String(),
+			HasTags:        len(tags) > 0,
+			Tags:           tags,
+			CanCopy:        canCopy,
+			CopyURL:        adapter.NewURLBuilder(ctx, 'c').SetZid(zid).String(),
+			CanNew:         canCopy && roleText == meta.ValueRoleNewTemplate,
+			NewURL:         adapter.NewURLBuilder(ctx, 'n').SetZid(zid).String(),
+			CanFolge:       base.CanCreate && !zn.Zettel.Content.IsBinary(),
+			FolgeURL:       adapter.NewURLBuilder(ctx, 'f').SetZid(zid).String(),
+			CanSuggest:     canSuggest,
+			SuggestURL:     adapter.NewURLBuilder(ctx, 'b').SetZid(zid).String(),
+			HasFlags:       flags != nil,
+			Flags:          flags,
+			HasStatus:      status != nil,
+			Status:         status,
+			ExtURL:         extURL,
+			HasExtURL:      hasExtURL,
+			ExtNewWindow:   htmlAttrNewWindow(newWindow && hasExtURL),
+			Content:        htmlContent,
+
+			HasAttribution: copyright != "" || license != "",
+			Copyright:      copyright,
+			License:        license,
+
+			HasRecent: recent != nil,
+			Recent:    recent,
+
+			HasComments: commentsHTML != "",
+			Comments:    commentsHTML,
+			CanComment:  te.canComment(ctx, user, zn.Zettel.Meta),
+			CommentURL:  adapter.NewURLBuilder(ctx, 'm').SetZid(zid).String(),
 		})
 	}
 }
 
+// getGlossaryOption returns the glossary encoding option. It is empty, if the
+// zettel does not want to mark its abbreviations.
+func getGlossaryOption(
+	ctx context.Context,
+	m *meta.Meta,
+	getGlossary usecase.GetGlossary,
+) (*encoder.GlossaryOption, error) {
+	val, ok := m.Get(meta.KeyGlossary)
+	if !ok || (val != meta.ValueGlossaryAbbr && val != meta.ValueGlossarySection) {
+		return &encoder.GlossaryOption{}, nil
+	}
+	glossary, err := getGlossary.Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &encoder.GlossaryOption{
+		Glossary: glossary,
+		Section:  val == meta.ValueGlossarySection,
+	}, nil
+}
+
 func formatBlocks(
 	bs ast.BlockSlice, format string, options ...encoder.Option) (string, error) {
 	enc := encoder.Create(format, options...)
@@ -167,11 +295,11 @@ func formatMeta(m *meta.Meta, format string, options ...encoder.Option) (string,
 	return content.String(), nil
 }
 
-func buildTagInfos(m *meta.Meta) []simpleLink {
+func buildTagInfos(ctx context.Context, m *meta.Meta) []simpleLink {
 	var tagInfos []simpleLink
 	if tags, ok := m.GetList(meta.KeyTags); ok {
 		tagInfos = make([]simpleLink, 0, len(tags))
-		ub := adapter.NewURLBuilder('h')
+		ub := adapter.NewURLBuilder(ctx, 'h')
 		for _, t := range tags {
 			// Cast to template.HTML is ok, because "t" is a tag name
 			// and contains only legal characters by construction.
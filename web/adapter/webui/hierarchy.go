@@ -0,0 +1,152 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/place"
+	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/adapter"
+	"zettelstore.de/z/web/session"
+)
+
+// maxBreadcrumbs is the maximum number of zettel in a breadcrumb trail.
+const maxBreadcrumbs = 32
+
+// buildBreadcrumbs returns the trail of zettel from the top of the hierarchy
+// down to the parent of the given zettel. It follows the "up" key as long as
+// the referenced zettel is readable and was not visited before.
+func buildBreadcrumbs(
+	ctx context.Context, m *meta.Meta, getMeta usecase.GetMeta) ([]metaInfo, error) {
+	visited := map[id.Zid]bool{m.Zid: true}
+	var trail []*meta.Meta
+	for len(trail) < maxBreadcrumbs {
+		zid, ok := upZid(m)
+		if !ok || visited[zid] {
+			break
+		}
+		up, err := getMeta.Run(ctx, zid)
+		if err != nil {
+			break
+		}
+		visited[zid] = true
+		trail = append(trail, up)
+		m = up
+	}
+	for i, j := 0, len(trail)-1; i < j; i, j = i+1, j-1 {
+		trail[i], trail[j] = trail[j], trail[i]
+	}
+	return buildHTMLMetaList(ctx, trail)
+}
+
+func upZid(m *meta.Meta) (id.Zid, bool) {
+	val, ok := m.Get(meta.KeyUp)
+	if !ok {
+		return id.Invalid, false
+	}
+	zid, err := id.Parse(val)
+	if err != nil {
+		return id.Invalid, false
+	}
+	return zid, true
+}
+
+var hierarchySorter = &place.Sorter{Order: meta.KeyTitle}
+
+// renderWebUIHierarchy renders all zettel that are part of a hierarchy as a
+// tree. The roots of the tree are all zettel that have children, but no
+// readable parent.
+func renderWebUIHierarchy(
+	w http.ResponseWriter, r *http.Request, te *TemplateEngine, listMeta usecase.ListMeta) {
+	ctx := r.Context()
+	metaList, err := listMeta.Run(ctx, nil, hierarchySorter)
+	if err != nil {
+		adapter.ReportUsecaseError(w, r, err)
+		return
+	}
+	metas, err := buildHTMLMetaList(ctx, metaList)
+	if err != nil {
+		adapter.InternalServerError(w, r, "Build HTML meta list", err)
+		return
+	}
+	known := make(map[id.Zid]bool, len(metaList))
+	for _, m := range metaList {
+		known[m.Zid] = true
+	}
+	children := make(map[id.Zid][]int)
+	for i, m := range metaList {
+		if zid, ok := upZid(m); ok && known[zid] && zid != m.Zid {
+			children[zid] = append(children[zid], i)
+		}
+	}
+	var roots []int
+	for i, m := range metaList {
+		if len(children[m.Zid]) == 0 {
+			continue
+		}
+		if zid, ok := upZid(m); !ok || !known[zid] {
+			roots = append(roots, i)
+		}
+	}
+
+	var sb strings.Builder
+	ht := hierarchyTree{metaList: metaList, metas: metas, children: children, sb: &sb}
+	ht.write(roots, make(map[id.Zid]bool))
+
+	user := session.GetUser(ctx)
+	var base baseData
+	te.makeBaseData(ctx, runtime.GetDefaultLang(), runtime.GetSiteName(), user, &base)
+	te.renderTemplate(ctx, w, id.HierarchyTemplateZid, &base, struct {
+		HasTree bool
+		Tree    string
+	}{
+		HasTree: len(roots) > 0,
+		Tree:    sb.String(),
+	})
+}
+
+type hierarchyTree struct {
+	metaList []*meta.Meta
+	metas    []metaInfo
+	children map[id.Zid][]int
+	sb       *strings.Builder
+}
+
+// write writes the given zettel and their descendants as nested lists. A
+// zettel is written at most once, to protect against loops.
+func (ht *hierarchyTree) write(nodes []int, visited map[id.Zid]bool) {
+	ht.sb.WriteString("<ul class=\"zs-tree\">\n")
+	for _, i := range nodes {
+		zid := ht.metaList[i].Zid
+		if visited[zid] {
+			continue
+		}
+		visited[zid] = true
+		ht.sb.WriteString("<li><a href=\"")
+		ht.sb.WriteString(ht.metas[i].URL)
+		ht.sb.WriteString("\">")
+		ht.sb.WriteString(ht.metas[i].Title)
+		ht.sb.WriteString("</a>")
+		if children := ht.children[zid]; len(children) > 0 {
+			ht.sb.WriteByte('\n')
+			ht.write(children, visited)
+		}
+		ht.sb.WriteString("</li>\n")
+	}
+	ht.sb.WriteString("</ul>\n")
+}
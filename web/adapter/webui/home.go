@@ -18,6 +18,7 @@ import (
 	"zettelstore.de/z/config/runtime"
 	"zettelstore.de/z/domain/id"
 	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/web/adapter"
 )
 
 type getRootStore interface {
@@ -30,7 +31,7 @@ func MakeGetRootHandler(
 	s getRootStore, startNotFound, startFound http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/" {
-			http.NotFound(w, r)
+			adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
 			return
 		}
 		startID := runtime.GetStart()
@@ -12,6 +12,7 @@
 package webui
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/url"
@@ -26,26 +27,26 @@ import (
 )
 
 func writeHTMLMetaValue(
-	w io.Writer, m *meta.Meta, key string,
+	ctx context.Context, w io.Writer, m *meta.Meta, key string,
 	getTitle func(id.Zid, string) (string, int),
 	option encoder.Option) {
 
 	switch kt := m.Type(key); kt {
 	case meta.TypeBool:
-		writeHTMLBool(w, key, m.GetBool(key))
+		writeHTMLBool(ctx, w, key, m.GetBool(key))
 	case meta.TypeCredential:
 		writeCredential(w, m.GetDefault(key, "???c"))
 	case meta.TypeEmpty:
 		writeEmpty(w, m.GetDefault(key, "???e"))
 	case meta.TypeID:
-		writeIdentifier(w, m.GetDefault(key, "???i"), getTitle)
+		writeIdentifier(ctx, w, m.GetDefault(key, "???i"), getTitle)
 	case meta.TypeNumber:
 		writeNumber(w, m.GetDefault(key, "???n"))
 	case meta.TypeString:
 		writeString(w, m.GetDefault(key, "???s"))
 	case meta.TypeTagSet:
 		if l, ok := m.GetList(key); ok {
-			writeTagSet(w, key, l)
+			writeTagSet(ctx, w, key, l)
 		}
 	case meta.TypeTimestamp:
 		if ts, ok := m.GetTime(key); ok {
@@ -54,10 +55,10 @@ func writeHTMLMetaValue(
 	case meta.TypeURL:
 		writeURL(w, m.GetDefault(key, "???u"))
 	case meta.TypeWord:
-		writeWord(w, key, m.GetDefault(key, "???w"))
+		writeWord(ctx, w, key, m.GetDefault(key, "???w"))
 	case meta.TypeWordSet:
 		if l, ok := m.GetList(key); ok {
-			writeWordSet(w, key, l)
+			writeWordSet(ctx, w, key, l)
 		}
 	case meta.TypeZettelmarkup:
 		writeZettelmarkup(w, m.GetDefault(key, "???z"), option)
@@ -67,11 +68,11 @@ func writeHTMLMetaValue(
 	}
 }
 
-func writeHTMLBool(w io.Writer, key string, val bool) {
+func writeHTMLBool(ctx context.Context, w io.Writer, key string, val bool) {
 	if val {
-		writeLink(w, key, "True")
+		writeLink(ctx, w, key, "True")
 	} else {
-		writeLink(w, key, "False")
+		writeLink(ctx, w, key, "False")
 	}
 }
 
@@ -83,7 +84,7 @@ func writeEmpty(w io.Writer, val string) {
 	strfun.HTMLEscape(w, val, false)
 }
 
-func writeIdentifier(w io.Writer, val string, getTitle func(id.Zid, string) (string, int)) {
+func writeIdentifier(ctx context.Context, w io.Writer, val string, getTitle func(id.Zid, string) (string, int)) {
 	zid, err := id.Parse(val)
 	if err != nil {
 		strfun.HTMLEscape(w, val, false)
@@ -95,12 +96,12 @@ func writeIdentifier(w io.Writer, val string, getTitle func(id.Zid, string) (str
 		if title == "" {
 			fmt.Fprintf(
 				w, "<a href=\"%v\">%v</a>",
-				adapter.NewURLBuilder('h').SetZid(zid), zid,
+				adapter.NewURLBuilder(ctx, 'h').SetZid(zid), zid,
 			)
 		} else {
 			fmt.Fprintf(
 				w, "<a href=\"%v\" title=\"%v\">%v</a>",
-				adapter.NewURLBuilder('h').SetZid(zid), title, zid,
+				adapter.NewURLBuilder(ctx, 'h').SetZid(zid), title, zid,
 			)
 		}
 	case found == 0:
@@ -119,12 +120,12 @@ func writeString(w io.Writer, val string) {
 	strfun.HTMLEscape(w, val, false)
 }
 
-func writeTagSet(w io.Writer, key string, tags []string) {
+func writeTagSet(ctx context.Context, w io.Writer, key string, tags []string) {
 	for i, tag := range tags {
 		if i > 0 {
 			io.WriteString(w, ", ")
 		}
-		writeLink(w, key, tag)
+		writeLink(ctx, w, key, tag)
 	}
 }
 
@@ -143,16 +144,16 @@ func writeURL(w io.Writer, val string) {
 	io.WriteString(w, "</a>")
 }
 
-func writeWord(w io.Writer, key, word string) {
-	writeLink(w, key, word)
+func writeWord(ctx context.Context, w io.Writer, key, word string) {
+	writeLink(ctx, w, key, word)
 }
 
-func writeWordSet(w io.Writer, key string, words []string) {
+func writeWordSet(ctx context.Context, w io.Writer, key string, words []string) {
 	for i, word := range words {
 		if i > 0 {
 			io.WriteString(w, ", ")
 		}
-		writeWord(w, key, word)
+		writeWord(ctx, w, key, word)
 	}
 }
 func writeZettelmarkup(w io.Writer, val string, option encoder.Option) {
@@ -165,10 +166,10 @@ func writeZettelmarkup(w io.Writer, val string, option encoder.Option) {
 	io.WriteString(w, title)
 }
 
-func writeLink(w io.Writer, key, value string) {
+func writeLink(ctx context.Context, w io.Writer, key, value string) {
 	fmt.Fprintf(
 		w, "<a href=\"%v?%v=%v\">",
-		adapter.NewURLBuilder('h'), url.QueryEscape(key), url.QueryEscape(value))
+		adapter.NewURLBuilder(ctx, 'h'), url.QueryEscape(key), url.QueryEscape(value))
 	strfun.HTMLEscape(w, value, false)
 	io.WriteString(w, "</a>")
 }
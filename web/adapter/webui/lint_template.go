@@ -0,0 +1,100 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/template"
+)
+
+// templateData is the registry of the data types that are used to render
+// a template. Most data types are anonymous structs, they are registered
+// when the template is rendered for the first time. A template may be
+// rendered with different data types.
+var templateData = struct {
+	mx    sync.RWMutex
+	types map[id.Zid][]reflect.Type
+}{
+	types: map[id.Zid][]reflect.Type{
+		id.BaseTemplateZid: {reflect.TypeOf(&baseData{})},
+		id.FormTemplateZid: {reflect.TypeOf(formZettelData{})},
+	},
+}
+
+func registerTemplateData(zid id.Zid, data interface{}) {
+	typ := reflect.TypeOf(data)
+	templateData.mx.RLock()
+	types := templateData.types[zid]
+	templateData.mx.RUnlock()
+	for _, t := range types {
+		if t == typ {
+			return
+		}
+	}
+
+	templateData.mx.Lock()
+	defer templateData.mx.Unlock()
+	for _, t := range templateData.types[zid] {
+		if t == typ {
+			return
+		}
+	}
+	templateData.types[zid] = append(templateData.types[zid], typ)
+}
+
+func getTemplateData(zid id.Zid) []reflect.Type {
+	templateData.mx.RLock()
+	defer templateData.mx.RUnlock()
+	return templateData.types[zid]
+}
+
+// LintTemplate checks the syntax of the given template. If the data types
+// used to render the template are known, it also checks that all referenced
+// names exist in at least one of them.
+func (te *TemplateEngine) LintTemplate(zid id.Zid, content string) error {
+	t, err := template.ParseString(content, nil)
+	if err != nil {
+		return err
+	}
+	types := getTemplateData(zid)
+	if len(types) == 0 {
+		return nil
+	}
+	var missing []string
+	for i, typ := range types {
+		names := t.MissingNames(typ)
+		if i == 0 {
+			missing = names
+		} else {
+			missing = intersectStrings(missing, names)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("unknown name(s): %v", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func intersectStrings(s1, s2 []string) []string {
+	result := s1[:0]
+	for _, s := range s1 {
+		if containsString(s2, s) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
@@ -18,7 +18,9 @@ import (
 	"sort"
 	"strconv"
 
+	"zettelstore.de/z/ast"
 	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/domain"
 	"zettelstore.de/z/domain/id"
 	"zettelstore.de/z/domain/meta"
 	"zettelstore.de/z/encoder"
@@ -26,14 +28,19 @@ import (
 	"zettelstore.de/z/place"
 	"zettelstore.de/z/usecase"
 	"zettelstore.de/z/web/adapter"
+	"zettelstore.de/z/web/router"
 	"zettelstore.de/z/web/session"
 )
 
 // MakeListHTMLMetaHandler creates a HTTP handler for rendering the list of zettel as HTML.
 func MakeListHTMLMetaHandler(
-	te *TemplateEngine, listMeta usecase.ListMeta) http.HandlerFunc {
+	te *TemplateEngine,
+	listMeta usecase.ListMeta,
+	listFacets usecase.ListFacets,
+	parseZettel usecase.ParseZettel,
+) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		renderWebUIZettelList(w, r, te, listMeta)
+		renderWebUIZettelList(w, r, te, listMeta, listFacets, parseZettel)
 	}
 }
 
@@ -41,38 +48,105 @@ func MakeListHTMLMetaHandler(
 func MakeWebUIListsHandler(
 	te *TemplateEngine,
 	listMeta usecase.ListMeta,
+	listFacets usecase.ListFacets,
 	listRole usecase.ListRole,
 	listTags usecase.ListTags,
+	listTasks usecase.ListTasks,
+	listRecent usecase.ListRecent,
+	listCustomized usecase.ListCustomized,
+	listDuplicates usecase.ListDuplicates,
+	listCitations usecase.ListCitations,
+	listReview usecase.ListReview,
+	parseZettel usecase.ParseZettel,
+	listRoutes func() []router.RouteInfo,
 ) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		zid, err := id.Parse(r.URL.Path[1:])
 		if err != nil {
-			http.NotFound(w, r)
+			adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
 			return
 		}
 		switch zid {
 		case 1:
-			renderWebUIZettelList(w, r, te, listMeta)
+			renderWebUIZettelList(w, r, te, listMeta, listFacets, parseZettel)
 		case 2:
 			renderWebUIRolesList(w, r, te, listRole)
 		case 3:
-			renderWebUITagsList(w, r, te, listTags)
+			renderWebUITagsList(w, r, te, listTags, listMeta, parseZettel)
+		case 4:
+			renderWebUITasksList(w, r, te, listTasks)
+		case 5:
+			renderWebUIHierarchy(w, r, te, listMeta)
+		case 6:
+			renderWebUIFlaggedList(w, r, te, listMeta, zid, usecase.FlagFavorite)
+		case 7:
+			renderWebUIFlaggedList(w, r, te, listMeta, zid, usecase.FlagReadLater)
+		case recentVisitedZid:
+			renderWebUIRecentList(w, r, te, listRecent, true)
+		case recentModifiedZid:
+			renderWebUIRecentList(w, r, te, listRecent, false)
+		case customizedZid:
+			renderWebUICustomizedList(w, r, te, listCustomized)
+		case duplicatesZid:
+			renderWebUIDuplicatesList(w, r, te, listDuplicates)
+		case citationsZid:
+			renderWebUICitationsList(w, r, te, listCitations)
+		case reviewZid:
+			renderWebUIReviewList(w, r, te, listReview)
+		case routesZid:
+			renderWebUIRoutesList(w, r, te, listRoutes)
+		default:
+			adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
 		}
 	}
 }
 
 func renderWebUIZettelList(
-	w http.ResponseWriter, r *http.Request, te *TemplateEngine, listMeta usecase.ListMeta) {
+	w http.ResponseWriter,
+	r *http.Request,
+	te *TemplateEngine,
+	listMeta usecase.ListMeta,
+	listFacets usecase.ListFacets,
+	parseZettel usecase.ParseZettel,
+) {
 	query := r.URL.Query()
 	filter, sorter := adapter.GetFilterSorter(query, false)
 	ctx := r.Context()
+	count, facets, err := listFacets.Run(ctx, filter, getFacetKeys())
+	if err != nil {
+		adapter.ReportUsecaseError(w, r, err)
+		return
+	}
+	var tagDescr *tagDescription
+	if tags := query[meta.KeyTags]; len(tags) == 1 {
+		tagZettel, err := getTagZettel(ctx, listMeta)
+		if err != nil {
+			adapter.ReportUsecaseError(w, r, err)
+			return
+		}
+		if m, ok := tagZettel[tagZettelKey(tags[0])]; ok {
+			tagDescr, err = buildTagDescription(ctx, m, parseZettel)
+			if err != nil {
+				adapter.ReportUsecaseError(w, r, err)
+				return
+			}
+		}
+	}
 	renderWebUIMetaList(
-		ctx, w, te, sorter,
+		w, r, te, sorter, adapter.GetListColumns(query, "_columns"),
+		buildFacetData(ctx, query, count, facets), tagDescr, newCSVURL(ctx, query, false),
+		newBulkEditURL(ctx, query), nil,
 		func(sorter *place.Sorter) ([]*meta.Meta, error) {
 			return listMeta.Run(ctx, filter, sorter)
 		},
 		func(offset int) string {
-			return newPageURL('h', query, offset, "_offset", "_limit")
+			return newPageURL(ctx, 'h', query, offset, "_offset", "_limit")
+		},
+		func(after id.Zid) string {
+			return newCursorURL(ctx, 'h', query, after, "_after", "_offset")
+		},
+		func(order string) string {
+			return newSortURL(ctx, 'h', query, order, "_sort", "_order", "_after", "_offset")
 		})
 }
 
@@ -90,7 +164,7 @@ func renderWebUIRolesList(
 	ctx := r.Context()
 	roleList, err := listRole.Run(ctx)
 	if err != nil {
-		adapter.ReportUsecaseError(w, err)
+		adapter.ReportUsecaseError(w, r, err)
 		return
 	}
 
@@ -98,7 +172,7 @@ func renderWebUIRolesList(
 	for _, r := range roleList {
 		roleInfos = append(
 			roleInfos,
-			roleInfo{r, adapter.NewURLBuilder('h').AppendQuery("role", r).String()})
+			roleInfo{r, adapter.NewURLBuilder(ctx, 'h').AppendQuery("role", r).String()})
 	}
 
 	user := session.GetUser(ctx)
@@ -117,11 +191,13 @@ type countInfo struct {
 }
 
 type tagInfo struct {
-	Name  string
-	URL   string
-	count int
-	Count string
-	Size  string
+	Name     string
+	URL      string
+	count    int
+	Count    string
+	Size     string
+	HasDescr bool
+	Descr    *tagDescription
 }
 
 var fontSizes = [...]int{75, 83, 100, 117, 150, 200}
@@ -131,26 +207,46 @@ func renderWebUITagsList(
 	r *http.Request,
 	te *TemplateEngine,
 	listTags usecase.ListTags,
+	listMeta usecase.ListMeta,
+	parseZettel usecase.ParseZettel,
 ) {
 	ctx := r.Context()
 	iMinCount, _ := strconv.Atoi(r.URL.Query().Get("min"))
 	tagData, err := listTags.Run(ctx, iMinCount)
 	if err != nil {
-		adapter.ReportUsecaseError(w, err)
+		adapter.ReportUsecaseError(w, r, err)
+		return
+	}
+	tagZettel, err := getTagZettel(ctx, listMeta)
+	if err != nil {
+		adapter.ReportUsecaseError(w, r, err)
 		return
 	}
 
 	user := session.GetUser(ctx)
 	tagsList := make([]tagInfo, 0, len(tagData))
 	countMap := make(map[int]int)
-	baseTagListURL := adapter.NewURLBuilder('h')
+	hasDescriptions := false
+	baseTagListURL := adapter.NewURLBuilder(ctx, 'h')
 	for tag, ml := range tagData {
 		count := len(ml)
 		countMap[count]++
-		tagsList = append(
-			tagsList,
-			tagInfo{tag, baseTagListURL.AppendQuery("tags", tag).String(), count, "", ""})
+		ti := tagInfo{
+			Name:  tag,
+			URL:   baseTagListURL.AppendQuery("tags", tag).String(),
+			count: count,
+		}
 		baseTagListURL.ClearQuery()
+		if m, ok := tagZettel[tagZettelKey(tag)]; ok {
+			ti.Descr, err = buildTagDescription(ctx, m, parseZettel)
+			if err != nil {
+				adapter.ReportUsecaseError(w, r, err)
+				return
+			}
+			ti.HasDescr = true
+		}
+		hasDescriptions = hasDescriptions || ti.HasDescr
+		tagsList = append(tagsList, ti)
 	}
 	sort.Slice(tagsList, func(i, j int) bool { return tagsList[i].Name < tagsList[j].Name })
 
@@ -177,18 +273,95 @@ func renderWebUITagsList(
 	}
 
 	te.renderTemplate(ctx, w, id.TagsTemplateZid, &base, struct {
-		MinCounts []countInfo
-		Tags      []tagInfo
+		MinCounts       []countInfo
+		Tags            []tagInfo
+		HasDescriptions bool
 	}{
-		MinCounts: minCounts,
-		Tags:      tagsList,
+		MinCounts:       minCounts,
+		Tags:            tagsList,
+		HasDescriptions: hasDescriptions,
 	})
 }
 
+type taskInfo struct {
+	Text string
+	Line int
+}
+
+type taskZettelInfo struct {
+	Title string
+	URL   string
+	Tasks []taskInfo
+}
+
+func renderWebUITasksList(
+	w http.ResponseWriter,
+	r *http.Request,
+	te *TemplateEngine,
+	listTasks usecase.ListTasks,
+) {
+	ctx := r.Context()
+	filter, sorter := adapter.GetFilterSorter(r.URL.Query(), false)
+	tasks, err := listTasks.Run(ctx, filter, sorter)
+	if err != nil {
+		adapter.ReportUsecaseError(w, r, err)
+		return
+	}
+
+	var metaList []*meta.Meta
+	var taskLists [][]taskInfo
+	for _, task := range tasks {
+		if n := len(metaList); n == 0 || metaList[n-1].Zid != task.Meta.Zid {
+			metaList = append(metaList, task.Meta)
+			taskLists = append(taskLists, nil)
+		}
+		last := len(taskLists) - 1
+		taskLists[last] = append(taskLists[last], taskInfo{
+			Text: taskText(task.Item),
+			Line: task.Line,
+		})
+	}
+	metas, err := buildHTMLMetaList(ctx, metaList)
+	if err != nil {
+		adapter.InternalServerError(w, r, "Build HTML meta list", err)
+		return
+	}
+	zettelInfos := make([]taskZettelInfo, 0, len(metas))
+	for i, mi := range metas {
+		zettelInfos = append(zettelInfos, taskZettelInfo{
+			Title: mi.Title,
+			URL:   mi.URL,
+			Tasks: taskLists[i],
+		})
+	}
+
+	user := session.GetUser(ctx)
+	var base baseData
+	te.makeBaseData(ctx, runtime.GetDefaultLang(), runtime.GetSiteName(), user, &base)
+	te.renderTemplate(ctx, w, id.TasksTemplateZid, &base, struct {
+		Zettel []taskZettelInfo
+	}{
+		Zettel: zettelInfos,
+	})
+}
+
+// taskText returns the text of the first paragraph of a task item.
+func taskText(item ast.ItemSlice) string {
+	if len(item) > 0 {
+		if pn, ok := item[0].(*ast.ParaNode); ok {
+			if text, err := adapter.FormatInlines(pn.Inlines, "text"); err == nil {
+				return text
+			}
+		}
+	}
+	return ""
+}
+
 // MakeSearchHandler creates a new HTTP handler for the use case "search".
 func MakeSearchHandler(
 	te *TemplateEngine,
 	search usecase.Search,
+	matchedPages usecase.MatchedPages,
 	getMeta usecase.GetMeta,
 	getZettel usecase.GetZettel,
 ) http.HandlerFunc {
@@ -196,28 +369,46 @@ func MakeSearchHandler(
 		query := r.URL.Query()
 		filter, sorter := adapter.GetFilterSorter(query, true)
 		if filter == nil || len(filter.Expr) == 0 {
-			http.Redirect(w, r, adapter.NewURLBuilder('h').String(), http.StatusFound)
+			http.Redirect(w, r, adapter.NewURLBuilder(r.Context(), 'h').String(), http.StatusFound)
 			return
 		}
 
 		ctx := r.Context()
+		terms := filter.Expr[""]
 		renderWebUIMetaList(
-			ctx, w, te, sorter,
+			w, r, te, sorter, adapter.GetListColumns(query, "columns"), nil, nil,
+			newCSVURL(ctx, query, true), "",
+			func(zid id.Zid) []int { return matchedPages.Run(zid, terms) },
 			func(sorter *place.Sorter) ([]*meta.Meta, error) {
 				return search.Run(ctx, filter, sorter)
 			},
 			func(offset int) string {
-				return newPageURL('s', query, offset, "offset", "limit")
+				return newPageURL(ctx, 's', query, offset, "offset", "limit")
+			},
+			func(after id.Zid) string {
+				return newCursorURL(ctx, 's', query, after, "after", "offset")
+			},
+			func(order string) string {
+				return newSortURL(ctx, 's', query, order, "sort", "order", "after", "offset")
 			})
 	}
 }
 
 func renderWebUIMetaList(
-	ctx context.Context, w http.ResponseWriter, te *TemplateEngine,
+	w http.ResponseWriter, r *http.Request, te *TemplateEngine,
 	sorter *place.Sorter,
+	columns []string,
+	facets *facetData,
+	tagDescr *tagDescription,
+	csvURL string,
+	bulkURL string,
+	searchPages func(id.Zid) []int,
 	ucMetaList func(sorter *place.Sorter) ([]*meta.Meta, error),
-	pageURL func(int) string) {
+	pageURL func(int) string,
+	cursorURL func(id.Zid) string,
+	sortURL func(string) string) {
 
+	ctx := r.Context()
 	var metaList []*meta.Meta
 	var err error
 	var prevURL, nextURL string
@@ -229,10 +420,11 @@ func renderWebUIMetaList(
 
 		metaList, err = ucMetaList(sorter)
 		if err != nil {
-			adapter.ReportUsecaseError(w, err)
+			adapter.ReportUsecaseError(w, r, err)
 			return
 		}
-		if offset := sorter.Offset; offset > 0 {
+		// A list that was continued after a zettel has no previous page.
+		if offset := sorter.Offset; offset > 0 && !sorter.After.IsValid() {
 			offset -= lps
 			if offset < 0 {
 				offset = 0
@@ -240,46 +432,90 @@ func renderWebUIMetaList(
 			prevURL = pageURL(offset)
 		}
 		if len(metaList) >= sorter.Limit {
-			nextURL = pageURL(sorter.Offset + lps)
 			metaList = metaList[:len(metaList)-1]
+			if sorter.Order == place.RandomOrder {
+				nextURL = pageURL(sorter.Offset + lps)
+			} else {
+				nextURL = cursorURL(metaList[len(metaList)-1].Zid)
+			}
 		}
 	} else {
 		metaList, err = ucMetaList(sorter)
 		if err != nil {
-			adapter.ReportUsecaseError(w, err)
+			adapter.ReportUsecaseError(w, r, err)
 			return
 		}
 	}
 	user := session.GetUser(ctx)
-	metas, err := buildHTMLMetaList(metaList)
+	metas, err := buildHTMLMetaList(ctx, metaList)
 	if err != nil {
-		adapter.InternalServerError(w, "Build HTML meta list", err)
+		adapter.InternalServerError(w, r, "Build HTML meta list", err)
 		return
 	}
+	titleColumn, columnInfos := buildListColumns(columns, sorter, sortURL)
+	if len(columns) > 0 {
+		for i, m := range metaList {
+			metas[i].Cells = buildListCells(ctx, m, columns)
+		}
+	}
+	if searchPages != nil {
+		for i, m := range metaList {
+			metas[i].Pages = buildPageLinks(ctx, m.Zid, searchPages(m.Zid))
+			metas[i].HasPages = len(metas[i].Pages) > 0
+		}
+	}
+	for i, m := range metaList {
+		if _, ok := m.Get(meta.KeyStatus); ok {
+			metas[i].Status = te.buildStatusData(ctx, user, domain.Zettel{Meta: m})
+			metas[i].HasStatus = metas[i].Status != nil
+		}
+	}
 	var base baseData
 	te.makeBaseData(ctx, runtime.GetDefaultLang(), runtime.GetSiteName(), user, &base)
 	te.renderTemplate(ctx, w, id.ListTemplateZid, &base, struct {
-		Title       string
-		Metas       []metaInfo
-		HasPrevNext bool
-		HasPrev     bool
-		PrevURL     string
-		HasNext     bool
-		NextURL     string
+		Title          string
+		HasTagDescr    bool
+		TagDescription *tagDescription
+		Metas          []metaInfo
+		HasColumns     bool
+		TitleColumn    columnInfo
+		Columns        []columnInfo
+		HasFacets      bool
+		Sidebar        *facetData
+		HasPrevNext    bool
+		HasPrev        bool
+		PrevURL        string
+		HasNext        bool
+		NextURL        string
+		HasCSV         bool
+		CSVURL         string
+		CanBulkEdit    bool
+		BulkURL        string
 	}{
-		Title:       base.Title,
-		Metas:       metas,
-		HasPrevNext: len(prevURL) > 0 || len(nextURL) > 0,
-		HasPrev:     len(prevURL) > 0,
-		PrevURL:     prevURL,
-		HasNext:     len(nextURL) > 0,
-		NextURL:     nextURL,
+		Title:          base.Title,
+		HasTagDescr:    tagDescr != nil,
+		TagDescription: tagDescr,
+		Metas:          metas,
+		HasColumns:     len(columns) > 0,
+		TitleColumn:    titleColumn,
+		Columns:        columnInfos,
+		HasFacets:      facets != nil,
+		Sidebar:        facets,
+		HasPrevNext:    len(prevURL) > 0 || len(nextURL) > 0,
+		HasPrev:        len(prevURL) > 0,
+		PrevURL:        prevURL,
+		HasNext:        len(nextURL) > 0,
+		NextURL:        nextURL,
+		HasCSV:         csvURL != "",
+		CSVURL:         csvURL,
+		CanBulkEdit:    bulkURL != "" && base.CanCreate && len(metas) > 0,
+		BulkURL:        bulkURL,
 	})
 }
 
 func newPageURL(
-	key byte, query url.Values, offset int, offsetKey, limitKey string) string {
-	urlBuilder := adapter.NewURLBuilder(key)
+	ctx context.Context, key byte, query url.Values, offset int, offsetKey, limitKey string) string {
+	urlBuilder := adapter.NewURLBuilder(ctx, key)
 	for key, values := range query {
 		if key != offsetKey && key != limitKey {
 			for _, val := range values {
@@ -293,13 +529,75 @@ func newPageURL(
 	return urlBuilder.String()
 }
 
+// newCursorURL returns the URL of the page that continues a list after the
+// given zettel.
+func newCursorURL(
+	ctx context.Context, key byte, query url.Values, after id.Zid, afterKey, offsetKey string) string {
+	urlBuilder := adapter.NewURLBuilder(ctx, key)
+	for key, values := range query {
+		if key != afterKey && key != offsetKey {
+			for _, val := range values {
+				urlBuilder.AppendQuery(key, val)
+			}
+		}
+	}
+	urlBuilder.AppendQuery(afterKey, after.String())
+	return urlBuilder.String()
+}
+
+// newCSVURL returns the URL of the API that exports all zettel of the list
+// as comma separated values. The query keys of a search lack the leading
+// underscore of the list query keys.
+func newCSVURL(ctx context.Context, query url.Values, forSearch bool) string {
+	urlBuilder := adapter.NewURLBuilder(ctx, 'z')
+	for key, values := range query {
+		if forSearch {
+			key = "_" + key
+		}
+		switch key {
+		case "_after", "_offset", "_limit", "_format":
+			continue
+		}
+		for _, val := range values {
+			urlBuilder.AppendQuery(key, val)
+		}
+	}
+	urlBuilder.AppendQuery("_format", "csv")
+	return urlBuilder.String()
+}
+
 type metaInfo struct {
-	Title string
-	URL   string
+	Zid       string
+	Title     string
+	URL       string
+	Abstract  string
+	Cells     []cellInfo
+	HasStatus bool
+	Status    *statusData
+	HasPages  bool
+	Pages     []pageLink
+}
+
+// pageLink refers to a page of a document, where search terms were found.
+type pageLink struct {
+	Number int
+	URL    string
+}
+
+func buildPageLinks(ctx context.Context, zid id.Zid, numbers []int) []pageLink {
+	if len(numbers) == 0 {
+		return nil
+	}
+	contentURL := rawContentURL(ctx, zid)
+	result := make([]pageLink, 0, len(numbers))
+	for _, n := range numbers {
+		result = append(result, pageLink{n, contentURL + "#page=" + strconv.Itoa(n)})
+	}
+	return result
 }
 
 // buildHTMLMetaList builds a zettel list based on a meta list for HTML rendering.
-func buildHTMLMetaList(metaList []*meta.Meta) ([]metaInfo, error) {
+func buildHTMLMetaList(ctx context.Context, metaList []*meta.Meta) ([]metaInfo, error) {
 	defaultLang := runtime.GetDefaultLang()
 	langOption := encoder.StringOption{Key: "lang", Value: ""}
 	metas := make([]metaInfo, 0, len(metaList))
@@ -316,8 +614,10 @@ func buildHTMLMetaList(metaList []*meta.Meta) ([]metaInfo, error) {
 			return nil, err
 		}
 		metas = append(metas, metaInfo{
-			Title: htmlTitle,
-			URL:   adapter.NewURLBuilder('h').SetZid(m.Zid).String(),
+			Zid:      m.Zid.String(),
+			Title:    htmlTitle,
+			URL:      adapter.NewURLBuilder(ctx, 'h').SetZid(m.Zid).String(),
+			Abstract: m.GetDefault(meta.KeyAbstract, ""),
 		})
 	}
 	return metas, nil
@@ -0,0 +1,78 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"context"
+	"net/http"
+
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/adapter"
+	"zettelstore.de/z/web/session"
+)
+
+// lockData contains the data about the advisory lock of a zettel.
+type lockData struct {
+	URL       string
+	IsLocked  bool
+	User      string
+	Expires   string
+	CanLock   bool // The current user may acquire the lock
+	CanUnlock bool // The current user holds the lock
+}
+
+// buildLockData returns the data about the lock of the given zettel, as seen
+// by the given user.
+func buildLockData(
+	ctx context.Context, user *meta.Meta, zid id.Zid, getLock usecase.GetLock) *lockData {
+	result := &lockData{URL: adapter.NewURLBuilder(ctx, 'g').SetZid(zid).String()}
+	l, ok := getLock.Run(zid)
+	if ok {
+		result.IsLocked = true
+		result.User = l.User
+		result.Expires = l.Expires.Format("2006-01-02 15:04")
+	}
+	if user != nil {
+		own := ok && l.User == user.GetDefault(meta.KeyUserID, user.Zid.String())
+		result.CanLock = !ok || own
+		result.CanUnlock = own
+	}
+	return result
+}
+
+// isForeignLock returns true, if the zettel is locked by another user.
+func (ld *lockData) isForeignLock() bool { return ld.IsLocked && !ld.CanUnlock }
+
+// MakePostLockZettelHandler creates a new HTTP handler to lock or to unlock
+// a zettel. A lock is acquired again to extend it.
+func MakePostLockZettelHandler(lockZettel usecase.LockZettel) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		zid, err := id.Parse(r.URL.Path[1:])
+		if err != nil {
+			adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
+			return
+		}
+		if err = r.ParseForm(); err != nil {
+			adapter.BadRequest(w, r, "Unable to read lock form")
+			return
+		}
+		ctx := r.Context()
+		acquire := r.PostFormValue("action") != "unlock"
+		if _, err = lockZettel.Run(ctx, session.GetUser(ctx), zid, acquire); err != nil {
+			adapter.ReportUsecaseError(w, r, err)
+			return
+		}
+		http.Redirect(w, r, adapter.NewURLBuilder(ctx, 'h').SetZid(zid).String(), http.StatusFound)
+	}
+}
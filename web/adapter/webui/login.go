@@ -45,11 +45,19 @@ func renderLoginForm(ctx context.Context, w http.ResponseWriter, te *TemplateEng
 	})
 }
 
+// MakeRedirectLoginHandler creates a new HTTP handler that redirects to the
+// login view. It is used for pages that need an authenticated user.
+func MakeRedirectLoginHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, adapter.NewURLBuilder(r.Context(), 'a').String(), http.StatusFound)
+	}
+}
+
 // MakePostLoginHandlerHTML creates a new HTTP handler to authenticate the given user.
 func MakePostLoginHandlerHTML(te *TemplateEngine, auth usecase.Authenticate) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if !startup.WithAuth() {
-			http.Redirect(w, r, adapter.NewURLBuilder('/').String(), http.StatusFound)
+			http.Redirect(w, r, adapter.NewURLBuilder(r.Context(), '/').String(), http.StatusFound)
 			return
 		}
 		htmlDur, _ := startup.TokenLifetime()
@@ -66,13 +74,13 @@ func authenticateViaHTML(
 ) {
 	ident, cred, ok := adapter.GetCredentialsViaForm(r)
 	if !ok {
-		adapter.BadRequest(w, "Unable to read login form")
+		adapter.BadRequest(w, r, "Unable to read login form")
 		return
 	}
 	ctx := r.Context()
-	token, err := auth.Run(ctx, ident, cred, authDuration, token.KindHTML)
+	token, err := auth.Run(ctx, ident, cred, authDuration, token.KindHTML, token.Scope{})
 	if err != nil {
-		adapter.ReportUsecaseError(w, err)
+		adapter.ReportUsecaseError(w, r, err)
 		return
 	}
 	if token == nil {
@@ -80,19 +88,19 @@ func authenticateViaHTML(
 		return
 	}
 
-	session.SetToken(w, token, authDuration)
-	http.Redirect(w, r, adapter.NewURLBuilder('/').String(), http.StatusFound)
+	session.SetToken(ctx, w, token, authDuration)
+	http.Redirect(w, r, adapter.NewURLBuilder(ctx, '/').String(), http.StatusFound)
 }
 
 // MakeGetLogoutHandler creates a new HTTP handler to log out the current user
 func MakeGetLogoutHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if format := adapter.GetFormat(r, r.URL.Query(), "html"); format != "html" {
-			adapter.BadRequest(w, fmt.Sprintf("Logout not possible in format %q", format))
+			adapter.BadRequest(w, r, fmt.Sprintf("Logout not possible in format %q", format))
 			return
 		}
 
 		session.ClearToken(r.Context(), w)
-		http.Redirect(w, r, adapter.NewURLBuilder('/').String(), http.StatusFound)
+		http.Redirect(w, r, adapter.NewURLBuilder(r.Context(), '/').String(), http.StatusFound)
 	}
 }
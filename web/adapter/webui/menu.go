@@ -0,0 +1,179 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"context"
+	"strings"
+
+	"zettelstore.de/z/ast"
+	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/input"
+	"zettelstore.de/z/parser"
+	"zettelstore.de/z/strfun"
+	"zettelstore.de/z/web/adapter"
+)
+
+// menuEntry is one entry of the user-defined menu. An entry with children is
+// shown as a drop-down menu.
+type menuEntry struct {
+	link     simpleLink
+	children []simpleLink
+}
+
+// buildMenu returns the HTML of the user-defined menu entries. They are
+// defined by the top-level unordered lists of the menu zettel: an item with a
+// link is a menu entry, an item with some text and a nested list is a
+// drop-down menu. Links to zettel that the user is not allowed to read are
+// ignored.
+func (te *TemplateEngine) buildMenu(ctx context.Context, user *meta.Meta) string {
+	zettel, err := te.place.GetZettel(ctx, id.MenuZid)
+	if err != nil {
+		return ""
+	}
+	bs := parser.ParseBlocks(
+		input.NewInput(zettel.Content.AsString()), zettel.Meta, runtime.GetSyntax(zettel.Meta))
+	var entries []menuEntry
+	for _, bn := range bs {
+		if ln, ok := bn.(*ast.NestedListNode); ok && ln.Code == ast.NestedListUnordered {
+			entries = te.appendMenuEntries(ctx, user, entries, ln)
+		}
+	}
+	var sb strings.Builder
+	for _, entry := range entries {
+		if len(entry.children) == 0 {
+			writeMenuLink(&sb, entry.link)
+			continue
+		}
+		sb.WriteString("<div class=\"zs-dropdown\">\n<button>")
+		strfun.HTMLEscape(&sb, entry.link.Text, false)
+		sb.WriteString("</button>\n<nav class=\"zs-dropdown-content\">\n")
+		for _, child := range entry.children {
+			writeMenuLink(&sb, child)
+		}
+		sb.WriteString("</nav>\n</div>\n")
+	}
+	return sb.String()
+}
+
+func writeMenuLink(sb *strings.Builder, link simpleLink) {
+	sb.WriteString("<a href=\"")
+	strfun.HTMLAttrEscape(sb, link.URL)
+	sb.WriteString("\">")
+	strfun.HTMLEscape(sb, link.Text, false)
+	sb.WriteString("</a>\n")
+}
+
+func (te *TemplateEngine) appendMenuEntries(
+	ctx context.Context,
+	user *meta.Meta,
+	entries []menuEntry,
+	ln *ast.NestedListNode,
+) []menuEntry {
+	for _, item := range ln.Items {
+		var entry menuEntry
+		for _, in := range item {
+			switch n := in.(type) {
+			case *ast.ParaNode:
+				if entry.link.Text == "" {
+					entry.link, _ = te.menuLink(ctx, user, n.Inlines)
+				}
+			case *ast.NestedListNode:
+				entry.children = te.appendMenuChildren(ctx, user, entry.children, n)
+			}
+		}
+		if entry.link.Text == "" || (entry.link.URL == "" && len(entry.children) == 0) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// appendMenuChildren collects all links of a nested list. Deeper nested lists
+// are flattened.
+func (te *TemplateEngine) appendMenuChildren(
+	ctx context.Context,
+	user *meta.Meta,
+	children []simpleLink,
+	ln *ast.NestedListNode,
+) []simpleLink {
+	for _, item := range ln.Items {
+		for _, in := range item {
+			switch n := in.(type) {
+			case *ast.ParaNode:
+				if link, ok := te.menuLink(ctx, user, n.Inlines); ok && link.URL != "" {
+					children = append(children, link)
+				}
+			case *ast.NestedListNode:
+				children = te.appendMenuChildren(ctx, user, children, n)
+			}
+		}
+	}
+	return children
+}
+
+// menuLink returns the text and the URL of the first link in the given
+// inline slice. If there is no link, only the text is returned. If the user
+// is not allowed to read a linked zettel, false is returned.
+func (te *TemplateEngine) menuLink(
+	ctx context.Context, user *meta.Meta, ins ast.InlineSlice) (simpleLink, bool) {
+	for _, in := range ins {
+		ln, ok := in.(*ast.LinkNode)
+		if !ok {
+			continue
+		}
+		u, ok := te.menuURL(ctx, user, ln.Ref)
+		if !ok {
+			return simpleLink{}, false
+		}
+		text := ln.Ref.Value
+		if !ln.OnlyRef {
+			text = menuText(ln.Inlines)
+		}
+		return simpleLink{Text: text, URL: u}, true
+	}
+	return simpleLink{Text: menuText(ins)}, true
+}
+
+func (te *TemplateEngine) menuURL(
+	ctx context.Context, user *meta.Meta, ref *ast.Reference) (string, bool) {
+	switch ref.State {
+	case ast.RefStateZettel:
+		zid, err := id.Parse(ref.URL.Path)
+		if err != nil {
+			return "", false
+		}
+		m, err := te.place.GetMeta(ctx, zid)
+		if err != nil || !te.policy.CanRead(user, m) {
+			return "", false
+		}
+		u := adapter.NewURLBuilder(ctx, 'h').SetZid(zid)
+		if fragment := ref.URL.EscapedFragment(); fragment != "" {
+			u.SetFragment(fragment)
+		}
+		return u.String(), true
+	case ast.RefStateLocal, ast.RefStateExternal:
+		return ref.Value, true
+	}
+	return "", false
+}
+
+func menuText(ins ast.InlineSlice) string {
+	text, err := adapter.FormatInlines(ins, "text")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(text)
+}
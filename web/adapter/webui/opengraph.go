@@ -0,0 +1,94 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"context"
+	"strings"
+
+	"zettelstore.de/z/abstract"
+	"zettelstore.de/z/ast"
+	"zettelstore.de/z/collect"
+	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/strfun"
+	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/adapter"
+)
+
+// openGraphHeader returns the Open Graph and Twitter card meta tags of a
+// zettel, so that links to it can be previewed. Only public zettel get these
+// tags, and only a public image is referenced.
+func openGraphHeader(
+	ctx context.Context, zn *ast.ZettelNode, title string, getMeta usecase.GetMeta) string {
+	if runtime.GetVisibility(zn.Zettel.Meta) != meta.VisibilityPublic {
+		return ""
+	}
+	var sb strings.Builder
+	writeOpenGraph(&sb, "property", "og:type", "article")
+	writeOpenGraph(&sb, "property", "og:title", title)
+	if descr := openGraphDescription(zn); descr != "" {
+		writeOpenGraph(&sb, "property", "og:description", descr)
+	}
+	writeOpenGraph(&sb, "property", "og:url",
+		adapter.NewCanonicalURLBuilder('h').SetZid(zn.Zid).String())
+	card := "summary"
+	if zid, ok := firstPublicImageZid(ctx, zn, getMeta); ok {
+		writeOpenGraph(&sb, "property", "og:image",
+			adapter.NewCanonicalURLBuilder('z').SetZid(zid).AppendQuery(
+				"_part", "content").AppendQuery("_format", "raw").String())
+		card = "summary_large_image"
+	}
+	writeOpenGraph(&sb, "name", "twitter:card", card)
+	return sb.String()
+}
+
+func writeOpenGraph(sb *strings.Builder, attr, key, value string) {
+	sb.WriteString("\n<meta ")
+	sb.WriteString(attr)
+	sb.WriteString("=\"")
+	sb.WriteString(key)
+	sb.WriteString("\" content=\"")
+	strfun.HTMLAttrEscape(sb, value)
+	sb.WriteString("\">")
+}
+
+// openGraphDescription returns the abstract of the zettel. If it was not
+// computed, the text of the first paragraph is used.
+func openGraphDescription(zn *ast.ZettelNode) string {
+	if descr, ok := zn.Zettel.Meta.Get(meta.KeyAbstract); ok && descr != "" {
+		return descr
+	}
+	return abstract.FromBlocks(zn.Ast)
+}
+
+// firstPublicImageZid returns the zettel identifier of the first image that
+// is stored as a public zettel. Other images must not be announced to
+// anonymous clients.
+func firstPublicImageZid(
+	ctx context.Context, zn *ast.ZettelNode, getMeta usecase.GetMeta) (id.Zid, bool) {
+	for _, ref := range collect.References(zn).Images {
+		if ref.State != ast.RefStateZettel {
+			continue
+		}
+		zid, err := id.Parse(ref.Value)
+		if err != nil {
+			continue
+		}
+		if m, err := getMeta.Run(ctx, zid); err == nil &&
+			runtime.GetVisibility(m) == meta.VisibilityPublic {
+			return zid, true
+		}
+	}
+	return id.Invalid, false
+}
@@ -0,0 +1,60 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/encoder"
+	"zettelstore.de/z/strfun"
+	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/adapter"
+)
+
+// MakeGetPreviewHandler creates a new HTTP handler that returns a small HTML
+// fragment with the title and the abstract of a zettel. It is shown as a
+// tooltip, when the mouse hovers over a link to the zettel.
+func MakeGetPreviewHandler(getMeta usecase.GetMeta) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		zid, err := id.Parse(r.URL.Path[1:])
+		if err != nil {
+			adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
+			return
+		}
+		m, err := getMeta.Run(r.Context(), zid)
+		if err != nil {
+			adapter.ReportUsecaseError(w, r, err)
+			return
+		}
+		lang := m.GetDefault(meta.KeyLang, runtime.GetDefaultLang())
+
+		var sb strings.Builder
+		sb.WriteString("<div class=\"zs-preview-title\">")
+		writeZettelmarkup(
+			&sb, m.GetDefault(meta.KeyTitle, zid.String()),
+			&encoder.StringOption{Key: "lang", Value: lang})
+		sb.WriteString("</div>")
+		if abstract := m.GetDefault(meta.KeyAbstract, ""); abstract != "" {
+			sb.WriteString("\n<p>")
+			strfun.HTMLEscape(&sb, abstract, false)
+			sb.WriteString("</p>")
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "private, max-age=60")
+		io.WriteString(w, sb.String())
+	}
+}
@@ -0,0 +1,144 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"zettelstore.de/z/ast"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/parser"
+	"zettelstore.de/z/place"
+	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/adapter"
+	"zettelstore.de/z/web/session"
+)
+
+// queryRegion is the generic attribute value of a region, whose content is a
+// query. Example:
+//
+//	:::query
+//	tags:#project sort:-modified
+//	:::
+const queryRegion = "query"
+
+// expandQueries replaces the content of all query regions with a list of
+// links to the zettel that match the query.
+func (te *TemplateEngine) expandQueries(
+	ctx context.Context, bs ast.BlockSlice, listMeta usecase.ListMeta) {
+	for _, bn := range bs {
+		te.expandQueryNode(ctx, bn, listMeta)
+	}
+}
+
+func (te *TemplateEngine) expandQueryNode(
+	ctx context.Context, n ast.Node, listMeta usecase.ListMeta) {
+	switch n := n.(type) {
+	case *ast.RegionNode:
+		if val, ok := n.Attrs.Get(""); n.Code == ast.RegionSpan && ok &&
+			strings.ToLower(val) == queryRegion {
+			te.expandQueryRegion(ctx, n, listMeta)
+			return
+		}
+		te.expandQueries(ctx, n.Blocks, listMeta)
+	case *ast.NestedListNode:
+		for _, item := range n.Items {
+			for _, in := range item {
+				te.expandQueryNode(ctx, in, listMeta)
+			}
+		}
+	}
+}
+
+func (te *TemplateEngine) expandQueryRegion(
+	ctx context.Context, rn *ast.RegionNode, listMeta usecase.ListMeta) {
+	query, err := formatBlocks(rn.Blocks, "text")
+	if err != nil {
+		return
+	}
+	query = strings.Join(strings.Fields(query), " ")
+	metaList, err := te.runQuery(ctx, query, listMeta)
+	if err != nil {
+		return
+	}
+	rn.Attrs = rn.Attrs.Clone().AddClass("zs-query")
+	if len(metaList) == 0 {
+		rn.Blocks = nil
+		return
+	}
+	items := make([]ast.ItemSlice, 0, len(metaList))
+	for _, m := range metaList {
+		ref := ast.ParseReference(m.Zid.String())
+		items = append(items, ast.ItemSlice{&ast.ParaNode{
+			Inlines: ast.InlineSlice{&ast.LinkNode{
+				Ref:     ref,
+				Inlines: parser.ParseTitle(m.GetDefault(meta.KeyTitle, m.Zid.String())),
+			}},
+		}})
+	}
+	rn.Blocks = ast.BlockSlice{&ast.NestedListNode{
+		Code:  ast.NestedListUnordered,
+		Items: items,
+	}}
+}
+
+// runQuery returns the meta data of all zettel that match the query. Results
+// are cached per user until a zettel changes.
+func (te *TemplateEngine) runQuery(
+	ctx context.Context, query string, listMeta usecase.ListMeta) ([]*meta.Meta, error) {
+	key := query
+	if user := session.GetUser(ctx); user != nil {
+		key = user.Zid.String() + " " + query
+	}
+	te.mxQuery.Lock()
+	metaList, ok := te.queryCache[key]
+	te.mxQuery.Unlock()
+	if ok {
+		return metaList, nil
+	}
+
+	filter, sorter := parseQuery(query)
+	metaList, err := listMeta.Run(ctx, filter, sorter)
+	if err != nil {
+		return nil, err
+	}
+	te.mxQuery.Lock()
+	te.queryCache[key] = metaList
+	te.mxQuery.Unlock()
+	return metaList, nil
+}
+
+// parseQuery translates the text of a query region into a filter and a
+// sorter. The query is a sequence of terms "key:value" or "key=value".
+// The keys "sort", "order", "offset", "limit", "negate", and "s" have the
+// same meaning as the corresponding query parameters of a zettel list, all
+// other keys select by metadata. A term without a key searches in all
+// metadata values.
+func parseQuery(query string) (*place.Filter, *place.Sorter) {
+	q := url.Values{}
+	for _, term := range strings.Fields(query) {
+		pos := strings.IndexAny(term, ":=")
+		if pos < 0 {
+			q.Add("_s", term)
+			continue
+		}
+		key, val := term[:pos], term[pos+1:]
+		switch key {
+		case "sort", "order", "offset", "limit", "negate", "s":
+			key = "_" + key
+		}
+		q.Add(key, val)
+	}
+	return adapter.GetFilterSorter(q, false)
+}
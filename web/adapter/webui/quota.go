@@ -0,0 +1,54 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"context"
+	"strconv"
+
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/usecase"
+)
+
+// quotaData contains the usage of a user, shown on the own user zettel.
+// Empty maximum values mean no limit.
+type quotaData struct {
+	Zettel    string
+	MaxZettel string
+	Size      string
+	MaxSize   string
+}
+
+// buildQuotaData returns the usage of the user, if the user views the own
+// user zettel. Otherwise it returns nil.
+func buildQuotaData(
+	ctx context.Context, user *meta.Meta, zid id.Zid, quota usecase.Quota) (*quotaData, error) {
+	if user == nil || user.Zid != zid {
+		return nil, nil
+	}
+	usage, err := quota.Run(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	data := &quotaData{
+		Zettel: strconv.Itoa(usage.Zettel),
+		Size:   usecase.FormatSize(usage.Size),
+	}
+	if usage.MaxZettel > 0 {
+		data.MaxZettel = strconv.Itoa(usage.MaxZettel)
+	}
+	if usage.MaxSize > 0 {
+		data.MaxSize = usecase.FormatSize(usage.MaxSize)
+	}
+	return data, nil
+}
@@ -0,0 +1,116 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"context"
+	"net/http"
+
+	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/adapter"
+	"zettelstore.de/z/web/session"
+)
+
+// Identifier of the lists of recent zettel, below the 'k' route.
+const (
+	recentVisitedZid  = id.Zid(8)
+	recentModifiedZid = id.Zid(9)
+)
+
+// recentHomeSize is the number of recent zettel shown on the home zettel.
+const recentHomeSize = 5
+
+// recentData contains the recent zettel shown on the home zettel.
+type recentData struct {
+	HasVisited  bool
+	Visited     []metaInfo
+	VisitedURL  string
+	HasModified bool
+	Modified    []metaInfo
+	ModifiedURL string
+}
+
+// buildRecentData returns the recent zettel for the home zettel. It returns
+// nil, if there is nothing to show.
+func buildRecentData(
+	ctx context.Context, user *meta.Meta, listRecent usecase.ListRecent) (*recentData, error) {
+	if user == nil {
+		return nil, nil
+	}
+	visited, err := buildHTMLMetaList(ctx, limitMetaList(listRecent.RunVisited(ctx, user)))
+	if err != nil {
+		return nil, err
+	}
+	modified, err := buildHTMLMetaList(ctx, limitMetaList(listRecent.RunModified(ctx)))
+	if err != nil {
+		return nil, err
+	}
+	if len(visited) == 0 && len(modified) == 0 {
+		return nil, nil
+	}
+	return &recentData{
+		HasVisited:  len(visited) > 0,
+		Visited:     visited,
+		VisitedURL:  adapter.NewURLBuilder(ctx, 'k').SetZid(recentVisitedZid).String(),
+		HasModified: len(modified) > 0,
+		Modified:    modified,
+		ModifiedURL: adapter.NewURLBuilder(ctx, 'k').SetZid(recentModifiedZid).String(),
+	}, nil
+}
+
+func limitMetaList(metaList []*meta.Meta) []*meta.Meta {
+	if len(metaList) > recentHomeSize {
+		return metaList[:recentHomeSize]
+	}
+	return metaList
+}
+
+// renderWebUIRecentList renders the recently visited or the recently
+// modified zettel, the most recent first.
+func renderWebUIRecentList(
+	w http.ResponseWriter,
+	r *http.Request,
+	te *TemplateEngine,
+	listRecent usecase.ListRecent,
+	visited bool,
+) {
+	ctx := r.Context()
+	user := session.GetUser(ctx)
+	var metaList []*meta.Meta
+	var title string
+	if visited {
+		if user == nil {
+			adapter.Forbidden(w, r, "Visited zettel are only tracked for authenticated users")
+			return
+		}
+		metaList, title = listRecent.RunVisited(ctx, user), "Recently Visited"
+	} else {
+		metaList, title = listRecent.RunModified(ctx), "Recently Modified"
+	}
+	metas, err := buildHTMLMetaList(ctx, metaList)
+	if err != nil {
+		adapter.InternalServerError(w, r, "Build HTML meta list", err)
+		return
+	}
+	var base baseData
+	te.makeBaseData(ctx, runtime.GetDefaultLang(), title, user, &base)
+	te.renderTemplate(ctx, w, id.ListTemplateZid, &base, struct {
+		Title string
+		Metas []metaInfo
+	}{
+		Title: title,
+		Metas: metas,
+	})
+}
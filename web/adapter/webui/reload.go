@@ -19,5 +19,10 @@ import (
 
 // ReloadHandlerHTML creates a new HTTP handler for the use case "reload".
 func ReloadHandlerHTML(w http.ResponseWriter, r *http.Request) {
-	http.Redirect(w, r, adapter.NewURLBuilder('/').String(), http.StatusFound)
+	http.Redirect(w, r, adapter.NewURLBuilder(r.Context(), '/').String(), http.StatusFound)
+}
+
+// ReloadZettelHandlerHTML creates a new HTTP handler for the use case "reload zettel".
+func ReloadZettelHandlerHTML(w http.ResponseWriter, r *http.Request) {
+	http.Redirect(w, r, adapter.NewURLBuilder(r.Context(), 'i').AppendPath(r.URL.Path[1:]).String(), http.StatusFound)
 }
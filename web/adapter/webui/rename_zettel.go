@@ -31,19 +31,19 @@ func MakeGetRenameZettelHandler(
 	return func(w http.ResponseWriter, r *http.Request) {
 		zid, err := id.Parse(r.URL.Path[1:])
 		if err != nil {
-			http.NotFound(w, r)
+			adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
 			return
 		}
 
 		ctx := r.Context()
 		m, err := getMeta.Run(ctx, zid)
 		if err != nil {
-			adapter.ReportUsecaseError(w, err)
+			adapter.ReportUsecaseError(w, r, err)
 			return
 		}
 
 		if format := adapter.GetFormat(r, r.URL.Query(), "html"); format != "html" {
-			adapter.BadRequest(w, fmt.Sprintf("Rename zettel %q not possible in format %q", zid.String(), format))
+			adapter.BadRequest(w, r, fmt.Sprintf("Rename zettel %q not possible in format %q", zid.String(), format))
 			return
 		}
 
@@ -65,29 +65,29 @@ func MakePostRenameZettelHandler(renameZettel usecase.RenameZettel) http.Handler
 	return func(w http.ResponseWriter, r *http.Request) {
 		curZid, err := id.Parse(r.URL.Path[1:])
 		if err != nil {
-			http.NotFound(w, r)
+			adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
 			return
 		}
 		if err := r.ParseForm(); err != nil {
-			adapter.BadRequest(w, "Unable to read rename zettel form")
+			adapter.BadRequest(w, r, "Unable to read rename zettel form")
 			return
 		}
 		if formCurZid, err := id.Parse(
 			r.PostFormValue("curzid")); err != nil || formCurZid != curZid {
-			adapter.BadRequest(w, "Invalid value for current zettel id in form")
+			adapter.BadRequest(w, r, "Invalid value for current zettel id in form")
 			return
 		}
 		newZid, err := id.Parse(strings.TrimSpace(r.PostFormValue("newzid")))
 		if err != nil {
-			adapter.BadRequest(w, fmt.Sprintf("Invalid new zettel id %q", newZid.String()))
+			adapter.BadRequest(w, r, fmt.Sprintf("Invalid new zettel id %q", newZid.String()))
 			return
 		}
 
 		if err := renameZettel.Run(r.Context(), curZid, newZid); err != nil {
-			adapter.ReportUsecaseError(w, err)
+			adapter.ReportUsecaseError(w, r, err)
 			return
 		}
 		http.Redirect(
-			w, r, adapter.NewURLBuilder('h').SetZid(newZid).String(), http.StatusFound)
+			w, r, adapter.NewURLBuilder(r.Context(), 'h').SetZid(newZid).String(), http.StatusFound)
 	}
 }
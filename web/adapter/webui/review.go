@@ -0,0 +1,133 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/adapter"
+	"zettelstore.de/z/web/session"
+)
+
+// Identifier of the list of zettel that are due for a review, below the 'k'
+// route.
+const reviewZid = id.Zid(14)
+
+type reviewInfo struct {
+	Title     string
+	URL       string
+	Interval  string
+	Due       string
+	CanReview bool
+	ReviewURL string
+}
+
+func renderWebUIReviewList(
+	w http.ResponseWriter, r *http.Request, te *TemplateEngine,
+	listReview usecase.ListReview) {
+	ctx := r.Context()
+	review, err := listReview.Run(ctx, time.Now())
+	if err != nil {
+		adapter.ReportUsecaseError(w, r, err)
+		return
+	}
+	user := session.GetUser(ctx)
+	due := make([]reviewInfo, 0, len(review.Due))
+	for _, m := range review.Due {
+		due = append(due, te.makeReviewInfo(ctx, user, m))
+	}
+	var daily []reviewInfo
+	if review.Daily != nil {
+		daily = append(daily, te.makeReviewInfo(ctx, user, review.Daily))
+	}
+
+	var base baseData
+	te.makeBaseData(ctx, runtime.GetDefaultLang(), "Review", user, &base)
+	te.renderTemplate(ctx, w, id.ReviewTemplateZid, &base, struct {
+		Daily  []reviewInfo
+		HasDue bool
+		Due    []reviewInfo
+	}{
+		Daily:  daily,
+		HasDue: len(due) > 0,
+		Due:    due,
+	})
+}
+
+func (te *TemplateEngine) makeReviewInfo(
+	ctx context.Context, user *meta.Meta, m *meta.Meta) reviewInfo {
+	due := "never reviewed"
+	if t, ok := m.GetTime(meta.KeyReviewDue); ok {
+		due = t.Format("2006-01-02")
+	}
+	return reviewInfo{
+		Title:     m.GetDefault(meta.KeyTitle, m.Zid.String()),
+		URL:       adapter.NewURLBuilder(ctx, 'h').SetZid(m.Zid).String(),
+		Interval:  reviewIntervalText(usecase.ReviewInterval(m)),
+		Due:       due,
+		CanReview: te.policy.CanWrite(user, m, m),
+		ReviewURL: adapter.NewURLBuilder(ctx, 't').SetZid(m.Zid).String(),
+	}
+}
+
+// MakePostReviewZettelHandler creates a new HTTP handler to mark a zettel as
+// reviewed, optionally with a grade. It returns to the referring page, which
+// is the list of zettel due for a review or the study page.
+func MakePostReviewZettelHandler(reviewZettel usecase.ReviewZettel) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		zid, err := id.Parse(r.URL.Path[1:])
+		if err != nil {
+			adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
+			return
+		}
+		if err = r.ParseForm(); err != nil {
+			adapter.BadRequest(w, r, "Unable to read review form")
+			return
+		}
+		grade := usecase.GradeGood
+		if name := r.PostFormValue("grade"); name != "" {
+			var ok bool
+			if grade, ok = usecase.ParseGrade(name); !ok {
+				adapter.BadRequest(w, r, "Unknown grade "+strconv.Quote(name))
+				return
+			}
+		}
+		ctx := r.Context()
+		if err = reviewZettel.Run(ctx, zid, grade, time.Now()); err != nil {
+			adapter.ReportUsecaseError(w, r, err)
+			return
+		}
+		target := adapter.NewURLBuilder(ctx, 'k').SetZid(reviewZid).String()
+		if ref, err1 := url.Parse(r.Referer()); err1 == nil && ref.Host == r.Host &&
+			strings.HasPrefix(ref.Path, "/") {
+			target = ref.RequestURI()
+		}
+		http.Redirect(w, r, target, http.StatusFound)
+	}
+}
+
+// reviewIntervalText returns a human readable form of a review interval.
+func reviewIntervalText(days int) string {
+	if days == 1 {
+		return "every day"
+	}
+	return "every " + strconv.Itoa(days) + " days"
+}
@@ -0,0 +1,79 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"net/http"
+	"strings"
+
+	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/web/adapter"
+	"zettelstore.de/z/web/router"
+	"zettelstore.de/z/web/session"
+)
+
+// Identifier of the list of all routes, below the 'k' route.
+const routesZid = id.Zid(13)
+
+type routeInfo struct {
+	Method       string
+	URL          string
+	Requirements string
+	Description  string
+}
+
+func renderWebUIRoutesList(
+	w http.ResponseWriter, r *http.Request, te *TemplateEngine,
+	listRoutes func() []router.RouteInfo) {
+	if listRoutes == nil {
+		adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
+		return
+	}
+	if !runtime.GetExpertMode() {
+		adapter.Forbidden(w, r, "The list of routes is only shown in expert mode")
+		return
+	}
+	ctx := r.Context()
+	prefix := adapter.NewURLBuilder(ctx, '/').String()
+	infos := listRoutes()
+	routes := make([]routeInfo, 0, len(infos))
+	for _, ri := range infos {
+		var reqs []string
+		if ri.Auth == router.AuthUser {
+			reqs = append(reqs, "login")
+		}
+		if ri.Write {
+			reqs = append(reqs, "write")
+		}
+		if ri.API {
+			reqs = append(reqs, "API")
+		}
+		if ri.Admin {
+			reqs = append(reqs, "admin")
+		}
+		routes = append(routes, routeInfo{
+			Method:       ri.Method,
+			URL:          prefix + ri.Path[1:],
+			Requirements: strings.Join(reqs, ", "),
+			Description:  ri.Description,
+		})
+	}
+
+	var base baseData
+	te.makeBaseData(ctx, runtime.GetDefaultLang(), "Routes", session.GetUser(ctx), &base)
+	te.renderTemplate(ctx, w, id.RoutesTemplateZid, &base, struct {
+		Routes []routeInfo
+	}{
+		Routes: routes,
+	})
+}
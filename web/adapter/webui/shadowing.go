@@ -0,0 +1,59 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"fmt"
+	"net/http"
+
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/adapter"
+)
+
+// MakePostShadowingHandler creates a new HTTP handler to customize a built-in
+// zettel, or to revert a zettel to the one it shadows. The form field
+// "action" must be "customize" or "revert". API clients get no content as a
+// response, all others are redirected to the info page.
+func MakePostShadowingHandler(
+	customizeZettel usecase.CustomizeZettel, revertZettel usecase.RevertZettel) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		zid, err := id.Parse(r.URL.Path[1:])
+		if err != nil {
+			adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
+			return
+		}
+		if err = r.ParseForm(); err != nil {
+			adapter.BadRequest(w, r, "Unable to read shadowing form")
+			return
+		}
+		ctx := r.Context()
+		switch action := r.PostFormValue("action"); action {
+		case "customize":
+			err = customizeZettel.Run(ctx, zid)
+		case "revert":
+			err = revertZettel.Run(ctx, zid)
+		default:
+			adapter.BadRequest(w, r, fmt.Sprintf("Unknown action %q", action))
+			return
+		}
+		if err != nil {
+			adapter.ReportUsecaseError(w, r, err)
+			return
+		}
+		if adapter.GetFormat(r, r.URL.Query(), "html") == "json" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		http.Redirect(w, r, adapter.NewURLBuilder(ctx, 'i').SetZid(zid).String(), http.StatusFound)
+	}
+}
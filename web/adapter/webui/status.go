@@ -0,0 +1,102 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/adapter"
+)
+
+// statusData contains the workflow state of a zettel, together with all
+// states it may be changed to.
+type statusData struct {
+	URL        string
+	Status     string
+	HasCurrent bool
+	States     []statusState
+}
+
+type statusState struct {
+	Name      string
+	IsCurrent bool
+}
+
+// buildStatusData returns the workflow state of the given zettel. It returns
+// nil, if the user is not allowed to change the state.
+func (te *TemplateEngine) buildStatusData(
+	ctx context.Context, user *meta.Meta, zettel domain.Zettel) *statusData {
+	if !te.canWrite(ctx, user, zettel) {
+		return nil
+	}
+	status := zettel.Meta.GetDefault(meta.KeyStatus, "")
+	states := runtime.GetStatusStates()
+	result := &statusData{
+		URL:        adapter.NewURLBuilder(ctx, 's').SetZid(zettel.Meta.Zid).String(),
+		Status:     status,
+		HasCurrent: status != "",
+		States:     make([]statusState, 0, len(states)),
+	}
+	for _, state := range states {
+		result.States = append(result.States, statusState{Name: state, IsCurrent: state == status})
+	}
+	return result
+}
+
+// statusLinks returns links to the lists of zettel per workflow state.
+func statusLinks(ctx context.Context) []simpleLink {
+	states := runtime.GetStatusStates()
+	result := make([]simpleLink, 0, len(states))
+	for _, state := range states {
+		result = append(result, simpleLink{
+			Text: "Status: " + state,
+			URL:  adapter.NewURLBuilder(ctx, 'h').AppendQuery(meta.KeyStatus, state).String(),
+		})
+	}
+	return result
+}
+
+// MakePostStatusZettelHandler creates a new HTTP handler to change the
+// workflow state of a zettel. It returns to the referring page, which is a
+// zettel list or the zettel itself.
+func MakePostStatusZettelHandler(setStatus usecase.SetStatus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		zid, err := id.Parse(r.URL.Path[1:])
+		if err != nil {
+			adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
+			return
+		}
+		if err = r.ParseForm(); err != nil {
+			adapter.BadRequest(w, r, "Unable to read status form")
+			return
+		}
+		ctx := r.Context()
+		if err = setStatus.Run(ctx, zid, r.PostFormValue("status")); err != nil {
+			adapter.ReportUsecaseError(w, r, err)
+			return
+		}
+		target := adapter.NewURLBuilder(ctx, 'h').SetZid(zid).String()
+		if ref, err1 := url.Parse(r.Referer()); err1 == nil && ref.Host == r.Host &&
+			strings.HasPrefix(ref.Path, "/") {
+			target = ref.RequestURI()
+		}
+		http.Redirect(w, r, target, http.StatusFound)
+	}
+}
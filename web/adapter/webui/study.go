@@ -0,0 +1,111 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"net/http"
+	"time"
+
+	"zettelstore.de/z/ast"
+	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/encoder"
+	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/adapter"
+	"zettelstore.de/z/web/session"
+)
+
+// MakeGetStudyHandler creates a new HTTP handler to study the cards that are
+// due. It presents the most overdue card: its question, its hidden answer,
+// and a form to grade it.
+func MakeGetStudyHandler(
+	te *TemplateEngine,
+	listReview usecase.ListReview,
+	parseZettel usecase.ParseZettel,
+	getMeta usecase.GetMeta,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		cards, err := listReview.RunCards(ctx, time.Now())
+		if err != nil {
+			adapter.ReportUsecaseError(w, r, err)
+			return
+		}
+		user := session.GetUser(ctx)
+		var base baseData
+		te.makeBaseData(ctx, runtime.GetDefaultLang(), "Study", user, &base)
+		if len(cards) == 0 {
+			te.renderTemplate(ctx, w, id.StudyTemplateZid, &base, struct {
+				HasCard bool
+			}{})
+			return
+		}
+
+		m := cards[0]
+		zn, err := parseZettel.Run(ctx, m.Zid, "")
+		if err != nil {
+			adapter.ReportUsecaseError(w, r, err)
+			return
+		}
+		langOption := encoder.StringOption{Key: "lang", Value: runtime.GetLang(zn.InhMeta)}
+		question, answer := splitCard(zn)
+		htmlQuestion, err := adapter.FormatInlines(question, "html", &langOption)
+		if err != nil {
+			adapter.InternalServerError(w, r, "Format HTML inlines", err)
+			return
+		}
+		htmlAnswer, err := formatBlocks(
+			answer,
+			"html",
+			&langOption,
+			&encoder.AdaptLinkOption{
+				Adapter: adapter.MakeLinkAdapter(ctx, 'h', getMeta, "", ""),
+			},
+			&encoder.AdaptImageOption{Adapter: adapter.MakeImageAdapter(ctx)},
+		)
+		if err != nil {
+			adapter.InternalServerError(w, r, "Format blocks", err)
+			return
+		}
+		te.renderTemplate(ctx, w, id.StudyTemplateZid, &base, struct {
+			HasCard   bool
+			Remaining int
+			URL       string
+			Question  string
+			Answer    string
+			CanGrade  bool
+			GradeURL  string
+		}{
+			HasCard:   true,
+			Remaining: len(cards),
+			URL:       adapter.NewURLBuilder(ctx, 'h').SetZid(m.Zid).String(),
+			Question:  htmlQuestion,
+			Answer:    htmlAnswer,
+			CanGrade:  te.policy.CanWrite(user, m, m),
+			GradeURL:  adapter.NewURLBuilder(ctx, 't').SetZid(m.Zid).String(),
+		})
+	}
+}
+
+// splitCard returns the question and the answer of a card. The question is
+// the first heading, the answer is the rest of the content. If there is no
+// heading, the title is the question.
+func splitCard(zn *ast.ZettelNode) (ast.InlineSlice, ast.BlockSlice) {
+	for i, bn := range zn.Ast {
+		if hn, ok := bn.(*ast.HeadingNode); ok {
+			answer := make(ast.BlockSlice, 0, len(zn.Ast)-1)
+			answer = append(answer, zn.Ast[:i]...)
+			return hn.Inlines, append(answer, zn.Ast[i+1:]...)
+		}
+	}
+	return zn.Title, zn.Ast
+}
@@ -0,0 +1,131 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"fmt"
+	"net/http"
+
+	"zettelstore.de/z/captcha"
+	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/domain"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/adapter"
+	"zettelstore.de/z/web/router"
+	"zettelstore.de/z/web/session"
+)
+
+// MakeGetSuggestZettelHandler creates a new HTTP handler to display the form
+// for suggesting a change of a public zettel.
+func MakeGetSuggestZettelHandler(
+	te *TemplateEngine, getZettel usecase.GetZettel, guard *captcha.Guard) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		zettel, ok := getSuggestZettel(w, r, getZettel)
+		if ok {
+			renderSuggestZettel(w, r, te, guard, zettel, zettel.Content.AsString(), "", "", false)
+		}
+	}
+}
+
+// MakePostSuggestZettelHandler creates a new HTTP handler to store the
+// suggested change of a public zettel.
+func MakePostSuggestZettelHandler(
+	te *TemplateEngine,
+	getZettel usecase.GetZettel,
+	suggestZettel usecase.SuggestZettel,
+	guard *captcha.Guard,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		zettel, ok := getSuggestZettel(w, r, getZettel)
+		if !ok {
+			return
+		}
+		// Some room for the other form values and the URL encoding.
+		r.Body = http.MaxBytesReader(w, r.Body, 2*usecase.MaxSuggestionSize)
+		if err := r.ParseForm(); err != nil {
+			adapter.BadRequest(w, r, "Unable to read suggestion form")
+			return
+		}
+		content, note := r.PostFormValue("content"), r.PostFormValue("note")
+		err := guard.Verify(r.PostFormValue("token"), r.PostFormValue("answer"))
+		if err != nil {
+			renderSuggestZettel(w, r, te, guard, zettel, content, note, err.Error(), false)
+			return
+		}
+		client := router.ClientIP(r).String()
+		if _, err = suggestZettel.Run(r.Context(), client, zettel.Meta.Zid, content, note); err != nil {
+			adapter.ReportUsecaseError(w, r, err)
+			return
+		}
+		renderSuggestZettel(w, r, te, guard, zettel, "", "", "", true)
+	}
+}
+
+func getSuggestZettel(
+	w http.ResponseWriter, r *http.Request, getZettel usecase.GetZettel) (domain.Zettel, bool) {
+	if format := adapter.GetFormat(r, r.URL.Query(), "html"); format != "html" {
+		adapter.BadRequest(w, r, fmt.Sprintf("Suggestion not possible in format %q", format))
+		return domain.Zettel{}, false
+	}
+	zid, err := id.Parse(r.URL.Path[1:])
+	if err != nil {
+		adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
+		return domain.Zettel{}, false
+	}
+	zettel, err := getZettel.Run(r.Context(), zid)
+	if err != nil {
+		adapter.ReportUsecaseError(w, r, err)
+		return domain.Zettel{}, false
+	}
+	if !usecase.CanSuggest(zettel.Meta) || zettel.Content.IsBinary() {
+		adapter.Forbidden(w, r, fmt.Sprintf("Suggestions for zettel %v are not allowed", zid))
+		return domain.Zettel{}, false
+	}
+	return zettel, true
+}
+
+func renderSuggestZettel(
+	w http.ResponseWriter, r *http.Request, te *TemplateEngine, guard *captcha.Guard,
+	zettel domain.Zettel, content, note, errMsg string, sent bool) {
+	ctx := r.Context()
+	m := zettel.Meta
+	question, token := guard.Challenge()
+	var base baseData
+	te.makeBaseData(
+		ctx, runtime.GetLang(m), "Suggest a Change of Zettel "+m.Zid.String(),
+		session.GetUser(ctx), &base)
+	te.renderTemplate(ctx, w, id.SuggestTemplateZid, &base, struct {
+		Zid      string
+		Title    string
+		WebURL   string
+		HasSent  bool
+		HasError bool
+		Error    string
+		Content  string
+		Note     string
+		Question string
+		Token    string
+	}{
+		Zid:      m.Zid.String(),
+		Title:    m.GetDefault(meta.KeyTitle, ""),
+		WebURL:   adapter.NewURLBuilder(ctx, 'h').SetZid(m.Zid).String(),
+		HasSent:  sent,
+		HasError: errMsg != "",
+		Error:    errMsg,
+		Content:  content,
+		Note:     note,
+		Question: question,
+		Token:    token,
+	})
+}
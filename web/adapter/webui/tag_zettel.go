@@ -0,0 +1,85 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"context"
+	"strings"
+
+	"zettelstore.de/z/abstract"
+	"zettelstore.de/z/domain/meta"
+	"zettelstore.de/z/place"
+	"zettelstore.de/z/usecase"
+)
+
+// tagDescription contains the data of a zettel that describes a tag.
+type tagDescription struct {
+	Title string
+	URL   string
+	Text  string
+}
+
+// tagZettelKey returns the tag that is described by a zettel with the given
+// title. The leading "#" of the tag is optional within the title.
+func tagZettelKey(title string) string {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return ""
+	}
+	if title[0] != '#' {
+		title = "#" + title
+	}
+	return strings.ToLower(title)
+}
+
+var tagZettelFilter = &place.Filter{
+	Expr: place.FilterExpr{meta.KeyRole: []string{meta.ValueRoleTag}},
+}
+
+// getTagZettel returns all readable zettel with role "tag", indexed by the
+// tag they describe. If there are more zettel for one tag, the newest wins.
+func getTagZettel(ctx context.Context, listMeta usecase.ListMeta) (map[string]*meta.Meta, error) {
+	metaList, err := listMeta.Run(ctx, tagZettelFilter, nil)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]*meta.Meta, len(metaList))
+	for _, m := range metaList {
+		if m.GetDefault(meta.KeyRole, "") != meta.ValueRoleTag {
+			continue
+		}
+		key := tagZettelKey(m.GetDefault(meta.KeyTitle, ""))
+		if _, ok := result[key]; key != "" && !ok {
+			result[key] = m
+		}
+	}
+	return result, nil
+}
+
+// buildTagDescription returns the description of a tag, i.e. the title of
+// the tag zettel, a link to it, and the text of its first paragraph.
+func buildTagDescription(
+	ctx context.Context, m *meta.Meta, parseZettel usecase.ParseZettel) (*tagDescription, error) {
+	zn, err := parseZettel.Run(ctx, m.Zid, "")
+	if err != nil {
+		return nil, err
+	}
+	metas, err := buildHTMLMetaList(ctx, []*meta.Meta{m})
+	if err != nil {
+		return nil, err
+	}
+	return &tagDescription{
+		Title: metas[0].Title,
+		URL:   metas[0].URL,
+		Text:  abstract.FromBlocks(zn.Ast),
+	}, nil
+}
@@ -14,6 +14,8 @@ package webui
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"log"
 	"net/http"
 	"sync"
 
@@ -28,7 +30,9 @@ import (
 	"zettelstore.de/z/input"
 	"zettelstore.de/z/parser"
 	"zettelstore.de/z/place"
+	"zettelstore.de/z/place/constplace"
 	"zettelstore.de/z/template"
+	"zettelstore.de/z/usecase"
 	"zettelstore.de/z/web/adapter"
 	"zettelstore.de/z/web/session"
 )
@@ -41,6 +45,9 @@ type templatePlace interface {
 	CanUpdateZettel(ctx context.Context, zettel domain.Zettel) bool
 	AllowRenameZettel(ctx context.Context, zid id.Zid) bool
 	CanDeleteZettel(ctx context.Context, zid id.Zid) bool
+	CanUndoZettel(ctx context.Context, zid id.Zid) bool
+	GetUndoMeta(ctx context.Context, zid id.Zid) (*meta.Meta, error)
+	IsDegraded() bool
 }
 
 // TemplateEngine is the way to render HTML templates.
@@ -48,42 +55,32 @@ type TemplateEngine struct {
 	place         templatePlace
 	templateCache map[id.Zid]*template.Template
 	mxCache       sync.RWMutex
+	queryCache    map[string][]*meta.Meta
+	mxQuery       sync.Mutex
 	policy        policy.Policy
-
-	stylesheetURL string
-	homeURL       string
-	listZettelURL string
-	listRolesURL  string
-	listTagsURL   string
 	withAuth      bool
-	loginURL      string
-	reloadURL     string
-	searchURL     string
 }
 
 // NewTemplateEngine creates a new TemplateEngine.
-func NewTemplateEngine(p place.Place, pol policy.Policy) *TemplateEngine {
+func NewTemplateEngine(p place.Manager, pol policy.Policy) *TemplateEngine {
 	te := &TemplateEngine{
-		place:  p,
-		policy: pol,
-
-		stylesheetURL: adapter.NewURLBuilder('z').SetZid(
-			id.BaseCSSZid).AppendQuery("_format", "raw").AppendQuery(
-			"_part", "content").String(),
-		homeURL:       adapter.NewURLBuilder('/').String(),
-		listZettelURL: adapter.NewURLBuilder('h').String(),
-		listRolesURL:  adapter.NewURLBuilder('k').SetZid(2).String(),
-		listTagsURL:   adapter.NewURLBuilder('k').SetZid(3).String(),
-		withAuth:      startup.WithAuth(),
-		loginURL:      adapter.NewURLBuilder('a').String(),
-		reloadURL:     adapter.NewURLBuilder('c').AppendQuery("_format", "html").String(),
-		searchURL:     adapter.NewURLBuilder('s').String(),
+		place:    p,
+		policy:   pol,
+		withAuth: startup.WithAuth(),
 	}
 	te.observe(place.OnReload, id.Invalid)
 	p.RegisterChangeObserver(te.observe)
 	return te
 }
 
+// NewPublicTemplateEngine creates a new TemplateEngine for the public mirror.
+// It never shows a login or user menu.
+func NewPublicTemplateEngine(p place.Manager, pol policy.Policy) *TemplateEngine {
+	te := NewTemplateEngine(p, pol)
+	te.withAuth = false
+	return te
+}
+
 func (te *TemplateEngine) observe(reason place.ChangeReason, zid id.Zid) {
 	te.mxCache.Lock()
 	if reason == place.OnReload || zid == id.BaseTemplateZid {
@@ -93,6 +90,11 @@ func (te *TemplateEngine) observe(reason place.ChangeReason, zid id.Zid) {
 		delete(te.templateCache, zid)
 	}
 	te.mxCache.Unlock()
+
+	// Any change may alter the result of any query.
+	te.mxQuery.Lock()
+	te.queryCache = make(map[string][]*meta.Meta)
+	te.mxQuery.Unlock()
 }
 
 func (te *TemplateEngine) cacheSetTemplate(zid id.Zid, t *template.Template) {
@@ -129,6 +131,21 @@ func (te *TemplateEngine) canDelete(
 	return te.policy.CanDelete(user, m) && te.place.CanDeleteZettel(ctx, m.Zid)
 }
 
+func (te *TemplateEngine) canUndo(
+	ctx context.Context, user *meta.Meta, m *meta.Meta) bool {
+	if !te.place.CanUndoZettel(ctx, m.Zid) {
+		return false
+	}
+	prevMeta, err := te.place.GetUndoMeta(ctx, m.Zid)
+	return err == nil && te.policy.CanWrite(user, m, prevMeta)
+}
+
+func (te *TemplateEngine) canComment(
+	ctx context.Context, user *meta.Meta, m *meta.Meta) bool {
+	return user != nil && te.policy.CanCreate(user, usecase.NewCommentMeta(m, user)) &&
+		te.place.CanCreateZettel(ctx)
+}
+
 func (te *TemplateEngine) getTemplate(
 	ctx context.Context, templateID id.Zid) (*template.Template, error) {
 	if t, ok := te.cacheGetTemplate(templateID); ok {
@@ -151,28 +168,54 @@ type simpleLink struct {
 }
 
 type baseData struct {
-	Lang           string
-	MetaHeader     string
-	StylesheetURL  string
-	Title          string
-	HomeURL        string
-	ListZettelURL  string
-	ListRolesURL   string
-	ListTagsURL    string
-	CanCreate      bool
-	NewZettelURL   string
-	NewZettelLinks []simpleLink
-	WithAuth       bool
-	UserIsValid    bool
-	UserZettelURL  string
-	UserIdent      string
-	UserLogoutURL  string
-	LoginURL       string
-	CanReload      bool
-	ReloadURL      string
-	SearchURL      string
-	Content        string
-	FooterHTML     string
+	Lang              string
+	MetaHeader        string
+	StylesheetURL     string
+	ManifestURL       string
+	IconURL           string
+	AppScriptURL      string
+	ServiceWorkerURL  string
+	ZettelAssets      string
+	Title             string
+	HomeURL           string
+	ListZettelURL     string
+	ListRolesURL      string
+	ListTagsURL       string
+	ListTasksURL      string
+	ReviewURL         string
+	StudyURL          string
+	ListHierarchyURL  string
+	ModifiedURL       string
+	StatusLinks       []simpleLink
+	DuplicatesURL     string
+	CitationsURL      string
+	ShowRoutes        bool
+	RoutesURL         string
+	CanCreate         bool
+	NewZettelURL      string
+	NewZettelLinks    []simpleLink
+	HasPinned         bool
+	PinnedLinks       []simpleLink
+	WithAuth          bool
+	UserIsValid       bool
+	UserZettelURL     string
+	FavoritesURL      string
+	ReadLaterURL      string
+	VisitedURL        string
+	UserIdent         string
+	UserLogoutURL     string
+	LoginURL          string
+	CanReload         bool
+	ReloadURL         string
+	CanCustomize      bool
+	CustomizeURL      string
+	SearchURL         string
+	Menu              string
+	Degraded          bool
+	HasTemplateErrors bool
+	TemplateErrors    []string
+	Content           string
+	FooterHTML        string
 }
 
 func (te *TemplateEngine) makeBaseData(
@@ -189,29 +232,52 @@ func (te *TemplateEngine) makeBaseData(
 	}
 	userIsValid := user != nil
 	if userIsValid {
-		userZettelURL = adapter.NewURLBuilder('h').SetZid(user.Zid).String()
+		userZettelURL = adapter.NewURLBuilder(ctx, 'h').SetZid(user.Zid).String()
 		userIdent = user.GetDefault(meta.KeyUserID, "")
-		userLogoutURL = adapter.NewURLBuilder('a').SetZid(user.Zid).String()
+		userLogoutURL = adapter.NewURLBuilder(ctx, 'a').SetZid(user.Zid).String()
 	}
 
 	data.Lang = lang
-	data.StylesheetURL = te.stylesheetURL
+	data.StylesheetURL = rawContentURL(ctx, id.BaseCSSZid)
+	data.ManifestURL = adapter.NewURLBuilder(ctx, '/').String() + ManifestPath
+	data.IconURL = rawContentURL(ctx, id.AppIconZid)
+	data.AppScriptURL = rawContentURL(ctx, id.AppScriptZid)
+	data.ServiceWorkerURL = adapter.NewURLBuilder(ctx, '/').String() + ServiceWorkerPath
 	data.Title = title
-	data.HomeURL = te.homeURL
-	data.ListZettelURL = te.listZettelURL
-	data.ListRolesURL = te.listRolesURL
-	data.ListTagsURL = te.listTagsURL
+	data.HomeURL = adapter.NewURLBuilder(ctx, '/').String()
+	data.ListZettelURL = adapter.NewURLBuilder(ctx, 'h').String()
+	data.ListRolesURL = adapter.NewURLBuilder(ctx, 'k').SetZid(2).String()
+	data.ListTagsURL = adapter.NewURLBuilder(ctx, 'k').SetZid(3).String()
+	data.ListTasksURL = adapter.NewURLBuilder(ctx, 'k').SetZid(4).String()
+	data.ReviewURL = adapter.NewURLBuilder(ctx, 'k').SetZid(reviewZid).String()
+	data.StudyURL = adapter.NewURLBuilder(ctx, 'l').String()
+	data.ListHierarchyURL = adapter.NewURLBuilder(ctx, 'k').SetZid(5).String()
+	data.ModifiedURL = adapter.NewURLBuilder(ctx, 'k').SetZid(recentModifiedZid).String()
+	data.StatusLinks = statusLinks(ctx)
+	data.DuplicatesURL = adapter.NewURLBuilder(ctx, 'k').SetZid(duplicatesZid).String()
+	data.CitationsURL = adapter.NewURLBuilder(ctx, 'k').SetZid(citationsZid).String()
+	data.ShowRoutes = runtime.GetExpertMode()
+	data.RoutesURL = adapter.NewURLBuilder(ctx, 'k').SetZid(routesZid).String()
 	data.CanCreate = canCreate
 	data.NewZettelLinks = newZettelLinks
+	data.PinnedLinks = te.fetchPinned(ctx, user)
+	data.HasPinned = len(data.PinnedLinks) > 0
 	data.WithAuth = te.withAuth
 	data.UserIsValid = userIsValid
 	data.UserZettelURL = userZettelURL
+	data.FavoritesURL = adapter.NewURLBuilder(ctx, 'k').SetZid(6).String()
+	data.ReadLaterURL = adapter.NewURLBuilder(ctx, 'k').SetZid(7).String()
+	data.VisitedURL = adapter.NewURLBuilder(ctx, 'k').SetZid(recentVisitedZid).String()
 	data.UserIdent = userIdent
 	data.UserLogoutURL = userLogoutURL
-	data.LoginURL = te.loginURL
+	data.LoginURL = adapter.NewURLBuilder(ctx, 'a').String()
 	data.CanReload = te.policy.CanReload(user)
-	data.ReloadURL = te.reloadURL
-	data.SearchURL = te.searchURL
+	data.ReloadURL = adapter.NewURLBuilder(ctx, 'c').AppendQuery("_format", "html").String()
+	data.CanCustomize = data.CanReload && runtime.GetExpertMode()
+	data.CustomizeURL = adapter.NewURLBuilder(ctx, 'k').SetZid(customizedZid).String()
+	data.SearchURL = adapter.NewURLBuilder(ctx, 's').String()
+	data.Menu = te.buildMenu(ctx, user)
+	data.Degraded = te.place.IsDegraded()
 	data.FooterHTML = runtime.GetFooterHTML()
 }
 
@@ -239,12 +305,38 @@ var templatePlaceSorter = &place.Sorter{
 
 func (te *TemplateEngine) fetchNewTemplates(
 	ctx context.Context, user *meta.Meta) []simpleLink {
-	templateList, err := te.place.SelectMeta(ctx, templatePlaceFilter, templatePlaceSorter)
+	return te.fetchMenuLinks(ctx, user, 'n', templatePlaceFilter, templatePlaceSorter)
+}
+
+var pinnedPlaceFilter = &place.Filter{
+	Select: func(m *meta.Meta) bool { return m.GetBool(meta.KeyPinned) },
+}
+
+var pinnedPlaceSorter = &place.Sorter{
+	Order:      meta.KeyTitle,
+	Descending: false,
+	Offset:     -1,
+	Limit:      31,
+}
+
+// fetchPinned returns the links to all pinned zettel the user may read.
+func (te *TemplateEngine) fetchPinned(ctx context.Context, user *meta.Meta) []simpleLink {
+	return te.fetchMenuLinks(ctx, user, 'h', pinnedPlaceFilter, pinnedPlaceSorter)
+}
+
+func (te *TemplateEngine) fetchMenuLinks(
+	ctx context.Context,
+	user *meta.Meta,
+	key byte,
+	filter *place.Filter,
+	sorter *place.Sorter,
+) []simpleLink {
+	metaList, err := te.place.SelectMeta(ctx, filter, sorter)
 	if err != nil {
 		return nil
 	}
-	result := make([]simpleLink, 0, len(templateList))
-	for _, m := range templateList {
+	result := make([]simpleLink, 0, len(metaList))
+	for _, m := range metaList {
 		if te.policy.CanRead(user, m) {
 			title := runtime.GetTitle(m)
 			langOption := encoder.StringOption{Key: "lang", Value: runtime.GetLang(m)}
@@ -259,7 +351,7 @@ func (te *TemplateEngine) fetchNewTemplates(
 			}
 			result = append(result, simpleLink{
 				Text: menuTitle,
-				URL:  adapter.NewURLBuilder('n').SetZid(m.Zid).String(),
+				URL:  adapter.NewURLBuilder(ctx, key).SetZid(m.Zid).String(),
 			})
 		}
 	}
@@ -272,30 +364,86 @@ func (te *TemplateEngine) renderTemplate(
 	templateID id.Zid,
 	base *baseData,
 	data interface{}) {
+	te.renderTemplateStatus(ctx, w, http.StatusOK, templateID, base, data)
+}
+
+// renderTemplateStatus renders the template with the given HTTP status code.
+// Errors are written as plain text, because they may occur while an error is
+// rendered.
+func (te *TemplateEngine) renderTemplateStatus(
+	ctx context.Context,
+	w http.ResponseWriter,
+	code int,
+	templateID id.Zid,
+	base *baseData,
+	data interface{}) {
 
-	bt, err := te.getTemplate(ctx, id.BaseTemplateZid)
+	if user := session.GetUser(ctx); user != nil {
+		htmlLifetime, _ := startup.TokenLifetime()
+		t, err := token.GetToken(user, htmlLifetime, token.KindHTML)
+		if err == nil {
+			session.SetToken(ctx, w, t, htmlLifetime)
+		}
+	}
+	content, err := te.executeTemplate(ctx, templateID, data, base.addTemplateError)
 	if err != nil {
-		adapter.InternalServerError(w, "Unable to get base template", err)
+		reportTemplateError(w, err)
 		return
 	}
-	t, err := te.getTemplate(ctx, templateID)
+	base.Content = string(content)
+	page, err := te.executeTemplate(ctx, id.BaseTemplateZid, base, base.addTemplateError)
 	if err != nil {
-		adapter.InternalServerError(w, "Unable to get template", err)
+		reportTemplateError(w, err)
 		return
 	}
-	if user := session.GetUser(ctx); user != nil {
-		htmlLifetime, _ := startup.TokenLifetime()
-		t, err := token.GetToken(user, htmlLifetime, token.KindHTML)
-		if err == nil {
-			session.SetToken(w, t, htmlLifetime)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	w.Write(page)
+}
+
+func reportTemplateError(w http.ResponseWriter, err error) {
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	log.Printf("Unable to render template: %v", err)
+}
+
+func (base *baseData) addTemplateError(msg string) {
+	base.HasTemplateErrors = true
+	base.TemplateErrors = append(base.TemplateErrors, msg)
+}
+
+// executeTemplate renders the template with the given data. If the template
+// cannot be parsed or rendered, e.g. because a user changed it, the template
+// built into the executable is used instead. In this case, onFallback is
+// called with a message that describes the problem, before the built-in
+// template is rendered.
+func (te *TemplateEngine) executeTemplate(
+	ctx context.Context, templateID id.Zid, data interface{},
+	onFallback func(msg string)) ([]byte, error) {
+
+	registerTemplateData(templateID, data)
+	var buf bytes.Buffer
+	t, err := te.getTemplate(ctx, templateID)
+	if err == nil {
+		if err = t.Render(&buf, data); err == nil {
+			return buf.Bytes(), nil
 		}
 	}
-	var content bytes.Buffer
-	err = t.Render(&content, data)
-	base.Content = content.String()
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	err = bt.Render(w, base)
-	if err != nil {
-		adapter.InternalServerError(w, "Unable to render template", err)
+	content, ok := constplace.BuiltinContent(templateID)
+	if !ok {
+		return nil, err
+	}
+	bt, berr := template.ParseString(content.AsString(), nil)
+	if berr != nil {
+		return nil, err
+	}
+	if onFallback != nil {
+		onFallback(fmt.Sprintf(
+			"Template %v is not usable, the built-in template is used instead: %v",
+			templateID, err))
+	}
+	buf.Reset()
+	if berr = bt.Render(&buf, data); berr != nil {
+		return nil, err
 	}
+	return buf.Bytes(), nil
 }
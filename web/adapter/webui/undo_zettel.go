@@ -0,0 +1,75 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package webui provides wet-UI handlers for web requests.
+package webui
+
+import (
+	"fmt"
+	"net/http"
+
+	"zettelstore.de/z/config/runtime"
+	"zettelstore.de/z/domain/id"
+	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/adapter"
+	"zettelstore.de/z/web/session"
+)
+
+// MakeGetUndoZettelHandler creates a new HTTP handler to display the
+// HTML undo view of a zettel.
+func MakeGetUndoZettelHandler(te *TemplateEngine, getMeta usecase.GetMeta) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if format := adapter.GetFormat(r, r.URL.Query(), "html"); format != "html" {
+			adapter.BadRequest(w, r, fmt.Sprintf("Undo zettel not possible in format %q", format))
+			return
+		}
+
+		zid, err := id.Parse(r.URL.Path[1:])
+		if err != nil {
+			adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
+			return
+		}
+
+		ctx := r.Context()
+		m, err := getMeta.Run(ctx, zid)
+		if err != nil {
+			adapter.ReportUsecaseError(w, r, err)
+			return
+		}
+
+		var base baseData
+		te.makeBaseData(ctx, runtime.GetLang(m), "Undo Zettel "+zid.String(), session.GetUser(ctx), &base)
+		te.renderTemplate(ctx, w, id.UndoTemplateZid, &base, struct {
+			Zid   string
+			Title string
+		}{
+			Zid:   zid.String(),
+			Title: runtime.GetTitle(m),
+		})
+	}
+}
+
+// MakePostUndoZettelHandler creates a new HTTP handler to restore the
+// previous version of a zettel.
+func MakePostUndoZettelHandler(undoZettel usecase.UndoZettel) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		zid, err := id.Parse(r.URL.Path[1:])
+		if err != nil {
+			adapter.NotFound(w, r, http.StatusText(http.StatusNotFound))
+			return
+		}
+
+		if err := undoZettel.Run(r.Context(), zid); err != nil {
+			adapter.ReportUsecaseError(w, r, err)
+			return
+		}
+		http.Redirect(w, r, adapter.NewURLBuilder(r.Context(), 'h').SetZid(zid).String(), http.StatusFound)
+	}
+}
@@ -0,0 +1,138 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package router provides a router for web requests.
+package router
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// IPRule restricts the access to routes by the IP address of the client.
+type IPRule struct {
+	Allow []*net.IPNet // If not empty, only clients of these networks are allowed
+	Deny  []*net.IPNet // Clients of these networks are never allowed
+}
+
+// Allows returns true, if a client with the given IP address may access the
+// routes.
+func (rule IPRule) Allows(ip net.IP) bool {
+	if ip == nil {
+		return len(rule.Allow) == 0 && len(rule.Deny) == 0
+	}
+	if containsIP(rule.Deny, ip) {
+		return false
+	}
+	return len(rule.Allow) == 0 || containsIP(rule.Allow, ip)
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// routeClass specifies the kind of a route, to apply an IP rule.
+type routeClass int
+
+// Constants for routeClass
+const (
+	classWebUI routeClass = iota // Route is used by the web user interface
+	classAPI                     // Route is used by API clients
+	classAdmin                   // Route administrates the Zettelstore
+	numClasses
+)
+
+// API marks a route that is used by API clients.
+func API() Option {
+	return func(r *route) { r.api = true }
+}
+
+// Admin marks a route that administrates the Zettelstore.
+func Admin() Option {
+	return func(r *route) { r.admin = true }
+}
+
+// requestClass returns the class of the route for the given request. Raw
+// content is part of the web user interface, because it embeds images, style
+// sheets, and scripts that are retrieved by an API route.
+func (rte *route) requestClass(r *http.Request) routeClass {
+	if rte.admin {
+		return classAdmin
+	}
+	if rte.api && (r.Method != http.MethodGet || r.URL.Query().Get("_format") != "raw") {
+		return classAPI
+	}
+	return classWebUI
+}
+
+// SetIPRules sets the rules for the web user interface, for the API, and for
+// the administrative routes. All routes without a specific class, including
+// handlers for static patterns, are part of the web user interface.
+func (rt *Router) SetIPRules(webui, api, admin IPRule) {
+	rt.ipRules = [numClasses]IPRule{webui, api, admin}
+}
+
+// allowsClient returns true, if the client of the request may access routes
+// of the given class. It writes an error response otherwise.
+func (rt *Router) allowsClient(w http.ResponseWriter, r *http.Request, class routeClass) bool {
+	if rt.ipRules[class].Allows(ClientIP(r)) {
+		return true
+	}
+	rt.reportError(w, r, http.StatusForbidden, "Access from your address is not allowed")
+	return false
+}
+
+// ClientIP returns the IP address of the client of the request. If the
+// request was forwarded by a trusted reverse proxy, the address is taken from
+// the header X-Forwarded-For.
+func ClientIP(r *http.Request) net.IP {
+	if fwd := GetForwarded(r.Context()); fwd != nil && fwd.ClientIP != nil {
+		return fwd.ClientIP
+	}
+	return remoteIP(r)
+}
+
+func remoteIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+// forwardedClientIP returns the address of the client, as reported by a chain
+// of reverse proxies. The list of addresses is read from right to left,
+// skipping all trusted proxies. If proxies is empty, only the proxy that sent
+// the request is trusted, i.e. the rightmost address is used. All addresses
+// to the left of it may be set by the client.
+func forwardedClientIP(r *http.Request, proxies []*net.IPNet) net.IP {
+	var addrs []string
+	for _, val := range r.Header.Values("X-Forwarded-For") {
+		addrs = append(addrs, strings.Split(val, ",")...)
+	}
+	var result net.IP
+	for i := len(addrs) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(addrs[i]))
+		if ip == nil {
+			break
+		}
+		result = ip
+		if len(proxies) == 0 || !containsIP(proxies, ip) {
+			break
+		}
+	}
+	return result
+}
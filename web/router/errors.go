@@ -0,0 +1,48 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package router provides a router for web requests.
+package router
+
+import (
+	"context"
+	"net/http"
+)
+
+// ErrorFunc writes an error response with the given HTTP status code.
+type ErrorFunc func(w http.ResponseWriter, r *http.Request, code int, text string)
+
+// SetErrorFunc sets the function that writes the error responses of the
+// router itself, e.g. if a method is not allowed. By default, the text is
+// written as plain text.
+func (rt *Router) SetErrorFunc(f ErrorFunc) {
+	rt.errorFunc = f
+}
+
+func (rt *Router) reportError(w http.ResponseWriter, r *http.Request, code int, text string) {
+	if rt.errorFunc != nil {
+		rt.errorFunc(w, r, code, text)
+		return
+	}
+	http.Error(w, text, code)
+}
+
+type ctxKeyAPI struct{}
+
+func withAPI(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), ctxKeyAPI{}, true))
+}
+
+// IsAPIRequest returns true, if the request is handled by a route that is
+// used by API clients.
+func IsAPIRequest(r *http.Request) bool {
+	val, ok := r.Context().Value(ctxKeyAPI{}).(bool)
+	return ok && val
+}
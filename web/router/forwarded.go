@@ -0,0 +1,118 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package router provides a router for web requests.
+package router
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Forwarded describes the URL of the service as seen by the client. It may
+// differ from the URL seen by the server, if a reverse proxy forwards the
+// request.
+type Forwarded struct {
+	Scheme string // "http" or "https"
+	Host   string // Host name, optionally with a port number
+	Prefix string // URL path prefix, starts and ends with a "/"
+
+	ClientIP net.IP // Address of the client, nil if unknown
+}
+
+// BaseURL returns the absolute URL of the service.
+func (fwd *Forwarded) BaseURL() string {
+	return fwd.Scheme + "://" + fwd.Host + fwd.Prefix
+}
+
+type ctxForwardedKey struct{}
+
+// GetForwarded returns the URL data of the service for the current request.
+// If it is not available, nil is returned.
+func GetForwarded(ctx context.Context) *Forwarded {
+	if fwd, ok := ctx.Value(ctxForwardedKey{}).(*Forwarded); ok {
+		return fwd
+	}
+	return nil
+}
+
+// ForwardedMiddleware returns a middleware that determines the URL data of
+// the service for every request. The given URL prefix is removed from the
+// request path, if the reverse proxy did not remove it. If trustProxy is
+// true, the headers X-Forwarded-Proto, X-Forwarded-Host, and
+// X-Forwarded-Prefix override the values of the request and the given
+// prefix, and the address of the client is taken from X-Forwarded-For. If
+// proxies is not empty, only requests of these networks are trusted.
+func ForwardedMiddleware(prefix string, trustProxy bool, proxies []*net.IPNet) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fwd := &Forwarded{Scheme: "http", Host: r.Host, Prefix: prefix}
+			if r.TLS != nil {
+				fwd.Scheme = "https"
+			}
+			fwd.ClientIP = remoteIP(r)
+			if trustProxy && (len(proxies) == 0 || containsIP(proxies, fwd.ClientIP)) {
+				if ip := forwardedClientIP(r, proxies); ip != nil {
+					fwd.ClientIP = ip
+				}
+				if proto := firstHeaderValue(r, "X-Forwarded-Proto"); proto == "http" || proto == "https" {
+					fwd.Scheme = proto
+				}
+				if host := firstHeaderValue(r, "X-Forwarded-Host"); host != "" {
+					fwd.Host = host
+				}
+				if p := firstHeaderValue(r, "X-Forwarded-Prefix"); p != "" {
+					fwd.Prefix = cleanPrefix(p)
+				}
+			}
+			stripPrefix(r, fwd.Prefix)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxForwardedKey{}, fwd)))
+		})
+	}
+}
+
+func firstHeaderValue(r *http.Request, key string) string {
+	value := r.Header.Get(key)
+	if pos := strings.IndexByte(value, ','); pos >= 0 {
+		value = value[:pos]
+	}
+	return strings.TrimSpace(value)
+}
+
+// cleanPrefix returns a valid URL prefix, which starts and ends with a "/".
+func cleanPrefix(prefix string) string {
+	prefix = path.Clean("/" + prefix)
+	if prefix == "/" {
+		return prefix
+	}
+	return prefix + "/"
+}
+
+// stripPrefix removes the URL prefix from the request path, if it is
+// contained there.
+func stripPrefix(r *http.Request, prefix string) {
+	if prefix == "/" {
+		return
+	}
+	p := r.URL.Path
+	switch {
+	case p == prefix[:len(prefix)-1]:
+		p = "/"
+	case strings.HasPrefix(p, prefix):
+		p = p[len(prefix)-1:]
+	default:
+		return
+	}
+	r.URL.Path = p
+	r.URL.RawPath = ""
+}
@@ -0,0 +1,45 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package router provides a router for web requests.
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// LimitMiddleware returns a middleware that restricts every request. The
+// context of the request is cancelled after the given timeout. The request
+// body must not be larger than maxBody bytes. A value of zero disables the
+// respective limit.
+func LimitMiddleware(timeout time.Duration, maxBody int64) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if maxBody > 0 {
+				if r.ContentLength > maxBody {
+					http.Error(w, fmt.Sprintf(
+						"Request is too large: it has %v bytes, but at most %v bytes are allowed",
+						r.ContentLength, maxBody), http.StatusRequestEntityTooLarge)
+					return
+				}
+				r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+			}
+			if timeout > 0 {
+				ctx, cancel := context.WithTimeout(r.Context(), timeout)
+				defer cancel()
+				r = r.WithContext(ctx)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -14,13 +14,60 @@ package router
 import (
 	"net/http"
 	"regexp"
+	"sort"
+	"strings"
 )
 
 type (
-	methodHandler map[string]http.Handler
+	methodHandler map[string]*route
 	routingTable  map[byte]methodHandler
 )
 
+// route stores the handler of a route together with its meta data.
+type route struct {
+	handler http.Handler
+	auth    AuthLevel
+	write   bool
+	api     bool
+	admin   bool
+	descr   string
+}
+
+// AuthLevel specifies which kind of authentication is needed to use a route.
+type AuthLevel int
+
+// Constants for AuthLevel
+const (
+	AuthNone AuthLevel = iota // Route can be used without authentication
+	AuthUser                  // Route needs an authenticated user
+)
+
+// Option specifies meta data of a route.
+type Option func(*route)
+
+// Auth sets the authentication level that is needed to use the route.
+func Auth(level AuthLevel) Option {
+	return func(r *route) { r.auth = level }
+}
+
+// Write marks a route that changes zettel. It is not available in read-only
+// mode and needs an authenticated user.
+func Write() Option {
+	return func(r *route) {
+		r.write = true
+		r.auth = AuthUser
+	}
+}
+
+// Change marks a route that changes zettel, but that may be used without
+// authentication. It is not available in read-only mode.
+func Change() Option {
+	return func(r *route) { r.write = true }
+}
+
+// Middleware wraps a handler to add some functionality to it.
+type Middleware func(http.Handler) http.Handler
+
 // Router handles all routing for zettelstore.
 type Router struct {
 	minKey byte
@@ -28,6 +75,18 @@ type Router struct {
 	reURL  *regexp.Regexp
 	tables [2]routingTable
 	mux    *http.ServeMux
+
+	middlewares   []Middleware
+	handler       http.Handler
+	readonly      bool
+	authenticated func(*http.Request) bool
+	login         http.Handler
+
+	corsOrigins map[string]bool // allowed origins; key "*" allows all
+	corsMethods map[string]bool // allowed methods; nil allows all
+	ipRules     [numClasses]IPRule
+	errorFunc   ErrorFunc
+	statics     []staticRoute
 }
 
 const (
@@ -45,10 +104,12 @@ func NewRouter() *Router {
 	}
 	router.tables[indexList] = make(routingTable)
 	router.tables[indexZettel] = make(routingTable)
+	router.handler = http.HandlerFunc(router.dispatch)
 	return router
 }
 
-func (rt *Router) addRoute(key byte, httpMethod string, handler http.Handler, index int) {
+func (rt *Router) addRoute(
+	key byte, httpMethod string, handler http.Handler, index int, opts []Option) {
 	// Set minKey and maxKey; re-calculate regexp.
 	if key < rt.minKey || rt.maxKey < key {
 		if key < rt.minKey {
@@ -66,30 +127,95 @@ func (rt *Router) addRoute(key byte, httpMethod string, handler http.Handler, in
 		mh = make(methodHandler)
 		rt.tables[index][key] = mh
 	}
-	mh[httpMethod] = handler
+	rte := &route{handler: handler}
+	for _, opt := range opts {
+		opt(rte)
+	}
+	mh[httpMethod] = rte
 	if httpMethod == http.MethodGet {
-		if _, hasHead := rt.tables[index][key][http.MethodHead]; !hasHead {
-			rt.tables[index][key][http.MethodHead] = handler
+		if _, hasHead := mh[http.MethodHead]; !hasHead {
+			mh[http.MethodHead] = rte
 		}
 	}
 }
 
 // AddListRoute adds a route for the given key and HTTP method to work with a list.
-func (rt *Router) AddListRoute(key byte, httpMethod string, handler http.Handler) {
-	rt.addRoute(key, httpMethod, handler, indexList)
+func (rt *Router) AddListRoute(key byte, httpMethod string, handler http.Handler, opts ...Option) {
+	rt.addRoute(key, httpMethod, handler, indexList, opts)
 }
 
 // AddZettelRoute adds a route for the given key and HTTP method to work with a zettel.
-func (rt *Router) AddZettelRoute(key byte, httpMethod string, handler http.Handler) {
-	rt.addRoute(key, httpMethod, handler, indexZettel)
+func (rt *Router) AddZettelRoute(key byte, httpMethod string, handler http.Handler, opts ...Option) {
+	rt.addRoute(key, httpMethod, handler, indexZettel, opts)
+}
+
+// Use adds middlewares, which are called for every request, before the
+// request is routed. The first middleware is the outermost one.
+func (rt *Router) Use(mws ...Middleware) {
+	rt.middlewares = append(rt.middlewares, mws...)
+	var handler http.Handler = http.HandlerFunc(rt.dispatch)
+	for i := len(rt.middlewares) - 1; i >= 0; i-- {
+		handler = rt.middlewares[i](handler)
+	}
+	rt.handler = handler
+}
+
+// SetReadOnly disables all routes that change zettel, if readonly is true.
+func (rt *Router) SetReadOnly(readonly bool) {
+	rt.readonly = readonly
+}
+
+// SetAuthenticated sets the function that checks whether a request was made
+// by an authenticated user. Without it, all requests are treated as
+// authenticated.
+func (rt *Router) SetAuthenticated(authenticated func(*http.Request) bool) {
+	rt.authenticated = authenticated
+}
+
+// SetLoginHandler sets the handler that is called instead of a route of the
+// web user interface, if the route needs an authenticated user and the
+// request is not authenticated. Typically, it redirects to the login form.
+// Without it, the status 401 is reported.
+func (rt *Router) SetLoginHandler(login http.Handler) {
+	rt.login = login
+}
+
+// SetCORS allows cross-origin resource sharing for the given origins and HTTP
+// methods of all routes that are used by API clients. If methods is empty,
+// all methods are allowed.
+func (rt *Router) SetCORS(origins, methods []string) {
+	rt.corsOrigins = nil
+	if len(origins) > 0 {
+		rt.corsOrigins = make(map[string]bool, len(origins))
+		for _, origin := range origins {
+			rt.corsOrigins[origin] = true
+		}
+	}
+	rt.corsMethods = nil
+	if len(methods) > 0 {
+		rt.corsMethods = make(map[string]bool, len(methods))
+		for _, method := range methods {
+			rt.corsMethods[strings.ToUpper(method)] = true
+		}
+	}
 }
 
 // Handle registers the handler for the given pattern. If a handler already exists for pattern, Handle panics.
-func (rt *Router) Handle(pattern string, handler http.Handler) {
+// Only the option Describe is used for static patterns.
+func (rt *Router) Handle(pattern string, handler http.Handler, opts ...Option) {
 	rt.mux.Handle(pattern, handler)
+	var rte route
+	for _, opt := range opts {
+		opt(&rte)
+	}
+	rt.statics = append(rt.statics, staticRoute{pattern: pattern, descr: rte.descr})
 }
 
 func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt.handler.ServeHTTP(w, r)
+}
+
+func (rt *Router) dispatch(w http.ResponseWriter, r *http.Request) {
 	match := rt.reURL.FindStringSubmatch(r.URL.Path)
 	if len(match) == 3 {
 		key := match[1][0]
@@ -97,15 +223,137 @@ func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		if len(match[2]) == 0 {
 			index = indexList
 		}
-		if mh, ok := rt.tables[index][key]; ok {
-			if handler, ok := mh[r.Method]; ok {
+		if mh := rt.activeMethods(rt.tables[index][key]); len(mh) > 0 {
+			rt.addCORSHeader(w, r, mh)
+			if rte, ok := mh[r.Method]; ok {
+				class := rte.requestClass(r)
+				if rte.api && class != classWebUI {
+					r = withAPI(r)
+				}
+				if !rt.allowsClient(w, r, class) {
+					return
+				}
+				if rte.auth == AuthUser && rt.authenticated != nil && !rt.authenticated(r) {
+					if class == classWebUI && rt.login != nil {
+						rt.login.ServeHTTP(w, r)
+						return
+					}
+					w.Header().Set("WWW-Authenticate", `Bearer realm="Default"`)
+					rt.reportError(w, r, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+					return
+				}
 				r.URL.Path = "/" + match[2]
-				handler.ServeHTTP(w, r)
+				rte.handler.ServeHTTP(w, r)
 				return
 			}
-			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			w.Header().Set("Allow", mh.allow())
+			if r.Method == http.MethodOptions {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			if mh.hasAPI() {
+				r = withAPI(r)
+			}
+			rt.reportError(w, r, http.StatusMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed))
+			return
+		}
+	}
+	if rt.allowsClient(w, r, classWebUI) {
+		rt.mux.ServeHTTP(w, r)
+	}
+}
+
+// activeMethods returns the routes that can be used currently. In read-only
+// mode, all routes that change zettel are removed.
+func (rt *Router) activeMethods(mh methodHandler) methodHandler {
+	if !rt.readonly {
+		return mh
+	}
+	result := make(methodHandler, len(mh))
+	for method, rte := range mh {
+		if !rte.write {
+			result[method] = rte
+		}
+	}
+	return result
+}
+
+// hasAPI returns true, if one of the routes is used by API clients.
+func (mh methodHandler) hasAPI() bool {
+	for _, rte := range mh {
+		if rte.api {
+			return true
+		}
+	}
+	return false
+}
+
+// apiRoutes returns the routes that are used by API clients.
+func (mh methodHandler) apiRoutes() methodHandler {
+	result := make(methodHandler, len(mh))
+	for method, rte := range mh {
+		if rte.api {
+			result[method] = rte
+		}
+	}
+	return result
+}
+
+// allow returns the value of the "Allow" header for the route.
+func (mh methodHandler) allow() string {
+	methods := make([]string, 0, len(mh)+1)
+	for method := range mh {
+		methods = append(methods, method)
+	}
+	if _, ok := mh[http.MethodOptions]; !ok {
+		methods = append(methods, http.MethodOptions)
+	}
+	sort.Strings(methods)
+	return strings.Join(methods, ", ")
+}
+
+// addCORSHeader adds the headers for cross-origin resource sharing, if the
+// request comes from an allowed origin. Only routes that are used by API
+// clients are shared.
+func (rt *Router) addCORSHeader(w http.ResponseWriter, r *http.Request, mh methodHandler) {
+	origin := r.Header.Get("Origin")
+	if origin == "" || len(rt.corsOrigins) == 0 {
+		return
+	}
+	if mh = mh.apiRoutes(); len(mh) == 0 {
+		return
+	}
+	h := w.Header()
+	h.Add("Vary", "Origin")
+	if !rt.corsOrigins["*"] && !rt.corsOrigins[origin] {
+		return
+	}
+	if r.Method == http.MethodOptions {
+		reqMethod := r.Header.Get("Access-Control-Request-Method")
+		if reqMethod == "" {
 			return
 		}
+		if _, ok := mh[reqMethod]; !ok || !rt.corsAllowsMethod(reqMethod) {
+			return
+		}
+		methods := make([]string, 0, len(mh))
+		for method := range mh {
+			if rt.corsAllowsMethod(method) {
+				methods = append(methods, method)
+			}
+		}
+		sort.Strings(methods)
+		h.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+		if reqHeaders := r.Header.Get("Access-Control-Request-Headers"); reqHeaders != "" {
+			h.Set("Access-Control-Allow-Headers", reqHeaders)
+		}
+		h.Set("Access-Control-Max-Age", "600")
+	} else if _, ok := mh[r.Method]; !ok || !rt.corsAllowsMethod(r.Method) {
+		return
 	}
-	rt.mux.ServeHTTP(w, r)
+	h.Set("Access-Control-Allow-Origin", origin)
+}
+
+func (rt *Router) corsAllowsMethod(method string) bool {
+	return rt.corsMethods == nil || rt.corsMethods[method]
 }
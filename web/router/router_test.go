@@ -0,0 +1,355 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package router provides a router for web requests.
+package router
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestRouter() *Router {
+	rt := NewRouter()
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	rt.AddListRoute('z', http.MethodGet, handler)
+	rt.AddZettelRoute('z', http.MethodGet, handler)
+	rt.AddZettelRoute('z', http.MethodPut, handler, Write())
+	rt.AddZettelRoute('e', http.MethodGet, handler, Write())
+	return rt
+}
+
+func TestMethods(t *testing.T) {
+	rt := newTestRouter()
+	testcases := []struct {
+		method string
+		path   string
+		status int
+		allow  string
+	}{
+		{http.MethodGet, "/z", http.StatusOK, ""},
+		{http.MethodHead, "/z/12345678901234", http.StatusOK, ""},
+		{http.MethodPost, "/z", http.StatusMethodNotAllowed, "GET, HEAD, OPTIONS"},
+		{http.MethodOptions, "/z/12345678901234", http.StatusNoContent, "GET, HEAD, OPTIONS, PUT"},
+	}
+	for _, tc := range testcases {
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		if w.Code != tc.status {
+			t.Errorf("%v %v: exp status %v, got %v", tc.method, tc.path, tc.status, w.Code)
+		}
+		if got := w.Header().Get("Allow"); got != tc.allow {
+			t.Errorf("%v %v: exp Allow %q, got %q", tc.method, tc.path, tc.allow, got)
+		}
+	}
+}
+
+func TestCORS(t *testing.T) {
+	rt := newTestRouter()
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	rt.AddZettelRoute('j', http.MethodGet, handler, API())
+	rt.AddZettelRoute('j', http.MethodPut, handler, Write(), API())
+	rt.AddZettelRoute('j', http.MethodPost, handler, Write())
+	rt.SetCORS([]string{"https://example.com"}, []string{"get", "head", "post"})
+	testcases := []struct {
+		method    string
+		path      string
+		origin    string
+		reqMethod string
+		expOrigin string
+		expAllow  string
+	}{
+		{http.MethodGet, "/j/12345678901234", "", "", "", ""},
+		{http.MethodGet, "/j/12345678901234", "https://example.com", "", "https://example.com", ""},
+		{http.MethodGet, "/j/12345678901234", "https://example.org", "", "", ""},
+		{http.MethodPut, "/j/12345678901234", "https://example.com", "", "", ""},
+		{http.MethodPost, "/j/12345678901234", "https://example.com", "", "", ""},
+		{http.MethodOptions, "/j/12345678901234", "https://example.com", "GET", "https://example.com", "GET, HEAD"},
+		{http.MethodOptions, "/j/12345678901234", "https://example.com", "PUT", "", ""},
+		{http.MethodOptions, "/j/12345678901234", "https://example.com", "POST", "", ""},
+		{http.MethodGet, "/z/12345678901234", "https://example.com", "", "", ""},
+		{http.MethodOptions, "/z/12345678901234", "https://example.com", "GET", "", ""},
+	}
+	for _, tc := range testcases {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(tc.method, tc.path, nil)
+		if tc.origin != "" {
+			r.Header.Set("Origin", tc.origin)
+		}
+		if tc.reqMethod != "" {
+			r.Header.Set("Access-Control-Request-Method", tc.reqMethod)
+		}
+		rt.ServeHTTP(w, r)
+		h := w.Header()
+		if got := h.Get("Access-Control-Allow-Origin"); got != tc.expOrigin {
+			t.Errorf("%v %v %q: exp origin %q, got %q", tc.method, tc.path, tc.origin, tc.expOrigin, got)
+		}
+		if got := h.Get("Access-Control-Allow-Methods"); got != tc.expAllow {
+			t.Errorf("%v %v %q: exp methods %q, got %q", tc.method, tc.path, tc.origin, tc.expAllow, got)
+		}
+	}
+}
+
+func TestReadOnlyAuth(t *testing.T) {
+	rt := newTestRouter()
+	authenticated := false
+	rt.SetAuthenticated(func(*http.Request) bool { return authenticated })
+	var trace []string
+	rt.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			trace = append(trace, r.URL.Path)
+			next.ServeHTTP(w, r)
+		})
+	})
+	testcases := []struct {
+		readonly bool
+		auth     bool
+		method   string
+		path     string
+		status   int
+	}{
+		{false, false, http.MethodGet, "/z/12345678901234", http.StatusOK},
+		{false, false, http.MethodPut, "/z/12345678901234", http.StatusUnauthorized},
+		{false, true, http.MethodPut, "/z/12345678901234", http.StatusOK},
+		{true, true, http.MethodPut, "/z/12345678901234", http.StatusMethodNotAllowed},
+		{true, true, http.MethodGet, "/e/12345678901234", http.StatusNotFound},
+	}
+	for _, tc := range testcases {
+		rt.SetReadOnly(tc.readonly)
+		authenticated = tc.auth
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		if w.Code != tc.status {
+			t.Errorf("%v/%v %v %v: exp status %v, got %v",
+				tc.readonly, tc.auth, tc.method, tc.path, tc.status, w.Code)
+		}
+	}
+	if len(trace) != len(testcases) {
+		t.Errorf("middleware called %d times, but expected %d", len(trace), len(testcases))
+	}
+}
+
+func TestLoginHandler(t *testing.T) {
+	rt := newTestRouter()
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	rt.AddZettelRoute('e', http.MethodPut, handler, Write(), API())
+	rt.SetAuthenticated(func(*http.Request) bool { return false })
+	rt.SetLoginHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/a", http.StatusFound)
+	}))
+	testcases := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{http.MethodGet, "/z/12345678901234", http.StatusOK},
+		{http.MethodGet, "/e/12345678901234", http.StatusFound},
+		{http.MethodPut, "/z/12345678901234", http.StatusFound},
+		{http.MethodPut, "/e/12345678901234", http.StatusUnauthorized},
+	}
+	for _, tc := range testcases {
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		if w.Code != tc.status {
+			t.Errorf("%v %v: exp status %v, got %v", tc.method, tc.path, tc.status, w.Code)
+		}
+		if w.Code == http.StatusFound && w.Header().Get("Location") != "/a" {
+			t.Errorf("%v %v: unexpected location %q", tc.method, tc.path, w.Header().Get("Location"))
+		}
+	}
+}
+
+func TestForwarded(t *testing.T) {
+	testcases := []struct {
+		prefix  string
+		trust   bool
+		path    string
+		headers map[string]string
+		expPath string
+		expBase string
+	}{
+		{"/", false, "/h", nil, "/h", "http://example.com/"},
+		{"/zs/", false, "/h", nil, "/h", "http://example.com/zs/"},
+		{"/zs/", false, "/zs/h", nil, "/h", "http://example.com/zs/"},
+		{"/zs/", false, "/zs", nil, "/", "http://example.com/zs/"},
+		{"/", false, "/h", map[string]string{"X-Forwarded-Prefix": "/zs"}, "/h", "http://example.com/"},
+		{"/", true, "/h", map[string]string{
+			"X-Forwarded-Prefix": "/zs",
+			"X-Forwarded-Proto":  "https",
+			"X-Forwarded-Host":   "zettel.example.org, proxy.local",
+		}, "/h", "https://zettel.example.org/zs/"},
+		{"/", true, "/h", map[string]string{"X-Forwarded-Prefix": "/a/../b//"}, "/h", "http://example.com/b/"},
+		{"/", true, "/h", map[string]string{"X-Forwarded-Proto": "ftp"}, "/h", "http://example.com/"},
+	}
+	for i, tc := range testcases {
+		var gotPath, gotBase string
+		handler := ForwardedMiddleware(tc.prefix, tc.trust, nil)(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath = r.URL.Path
+				gotBase = GetForwarded(r.Context()).BaseURL()
+			}))
+		r := httptest.NewRequest(http.MethodGet, "http://example.com"+tc.path, nil)
+		for key, val := range tc.headers {
+			r.Header.Set(key, val)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+		if gotPath != tc.expPath {
+			t.Errorf("%d: exp path %q, got %q", i, tc.expPath, gotPath)
+		}
+		if gotBase != tc.expBase {
+			t.Errorf("%d: exp base URL %q, got %q", i, tc.expBase, gotBase)
+		}
+	}
+}
+
+func TestLimit(t *testing.T) {
+	rt := NewRouter()
+	rt.Use(LimitMiddleware(time.Minute, 10))
+	rt.AddZettelRoute('z', http.MethodPut, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Error("request has no deadline")
+		}
+		if _, err := ioutil.ReadAll(r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	}))
+	testcases := []struct {
+		body    string
+		chunked bool
+		status  int
+	}{
+		{"0123456789", false, http.StatusOK},
+		{"0123456789a", false, http.StatusRequestEntityTooLarge},
+		{"0123456789a", true, http.StatusBadRequest},
+	}
+	for _, tc := range testcases {
+		r := httptest.NewRequest(http.MethodPut, "/z/12345678901234", strings.NewReader(tc.body))
+		if tc.chunked {
+			r.ContentLength = -1
+		}
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, r)
+		if w.Code != tc.status {
+			t.Errorf("%q (chunked=%v): exp status %v, got %v", tc.body, tc.chunked, tc.status, w.Code)
+		}
+	}
+}
+
+func TestIPRules(t *testing.T) {
+	parseNet := func(s string) []*net.IPNet {
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return []*net.IPNet{n}
+	}
+	newRouter := func(proxies []*net.IPNet) *Router {
+		rt := newTestRouter()
+		rt.AddListRoute('y', http.MethodGet, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), API())
+		rt.Use(ForwardedMiddleware("/", true, proxies))
+		rt.SetIPRules(
+			IPRule{Deny: parseNet("192.0.2.0/24")},
+			IPRule{Allow: parseNet("198.51.100.0/24")},
+			IPRule{})
+		return rt
+	}
+	withProxies, withoutProxies := newRouter(parseNet("10.0.0.0/8")), newRouter(nil)
+	testcases := []struct {
+		rt        *Router
+		remote    string
+		forwarded string
+		path      string
+		status    int
+	}{
+		{withProxies, "198.51.100.7:1234", "", "/z", http.StatusOK},
+		{withProxies, "198.51.100.7:1234", "", "/y", http.StatusOK},
+		{withProxies, "192.0.2.7:1234", "", "/z", http.StatusForbidden},
+		{withProxies, "192.0.2.7:1234", "", "/", http.StatusForbidden},
+		{withProxies, "203.0.113.1:1234", "", "/y", http.StatusForbidden},
+		{withProxies, "203.0.113.1:1234", "", "/z?_format=raw", http.StatusOK},
+		{withProxies, "10.0.0.1:1234", "192.0.2.7, 10.0.0.2", "/z", http.StatusForbidden},
+		{withProxies, "10.0.0.1:1234", "192.0.2.7, 198.51.100.7", "/y", http.StatusOK},
+		{withProxies, "203.0.113.1:1234", "198.51.100.7", "/y", http.StatusForbidden},
+		{withoutProxies, "10.0.0.1:1234", "198.51.100.7", "/y", http.StatusOK},
+		{withoutProxies, "10.0.0.1:1234", "198.51.100.7, 203.0.113.1", "/y", http.StatusForbidden},
+		{withoutProxies, "10.0.0.1:1234", "127.0.0.1, 192.0.2.7", "/z", http.StatusForbidden},
+	}
+	for i, tc := range testcases {
+		rt := tc.rt
+		r := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		r.RemoteAddr = tc.remote
+		if tc.forwarded != "" {
+			r.Header.Set("X-Forwarded-For", tc.forwarded)
+		}
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, r)
+		if w.Code != tc.status {
+			t.Errorf("%d: expected status %d, but got %d", i, tc.status, w.Code)
+		}
+	}
+}
+
+func TestErrorFunc(t *testing.T) {
+	rt := newTestRouter()
+	rt.AddListRoute('y', http.MethodGet, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), API())
+	var isAPI bool
+	rt.SetErrorFunc(func(w http.ResponseWriter, r *http.Request, code int, text string) {
+		isAPI = IsAPIRequest(r)
+		w.WriteHeader(code)
+	})
+	testcases := []struct {
+		path  string
+		isAPI bool
+	}{
+		{"/y", true},
+		{"/e/00000000000001", false},
+	}
+	for _, tc := range testcases {
+		isAPI = !tc.isAPI
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, tc.path, nil))
+		if w.Code != http.StatusMethodNotAllowed || isAPI != tc.isAPI {
+			t.Errorf("%v: expected %d/%v, but got %d/%v",
+				tc.path, http.StatusMethodNotAllowed, tc.isAPI, w.Code, isAPI)
+		}
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	rt := newTestRouter()
+	rt.AddListRoute('y', http.MethodGet, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		API(), Describe("test"))
+	rt.Handle("/", http.NotFoundHandler(), Describe("root"))
+	rt.SetReadOnly(true)
+	var got []string
+	for _, ri := range rt.Routes() {
+		got = append(got, ri.Method+" "+ri.Path+" "+ri.Description)
+	}
+	exp := []string{"GET /y test", "GET /z ", "GET /z/{zid} ", " / root"}
+	if len(got) != len(exp) {
+		t.Fatalf("expected %q, but got %q", exp, got)
+	}
+	for i := range exp {
+		if got[i] != exp[i] {
+			t.Errorf("%d: expected %q, but got %q", i, exp[i], got[i])
+		}
+	}
+}
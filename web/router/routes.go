@@ -0,0 +1,83 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package router provides a router for web requests.
+package router
+
+import (
+	"net/http"
+	"sort"
+)
+
+// Describe sets a short description of the route, for the list of all routes.
+func Describe(text string) Option {
+	return func(r *route) { r.descr = text }
+}
+
+// RouteInfo describes a route that is currently available.
+type RouteInfo struct {
+	Path        string    // Path, relative to the URL prefix
+	Key         byte      // Key of the route, zero for a static pattern
+	Zettel      bool      // Route works with a zettel, not with a list
+	Method      string    // HTTP method, empty for a static pattern
+	Auth        AuthLevel // Authentication that is needed
+	Write       bool      // Route changes zettel
+	API         bool      // Route is used by API clients
+	Admin       bool      // Route administrates the Zettelstore
+	Description string
+}
+
+type staticRoute struct {
+	pattern string
+	descr   string
+}
+
+// Routes returns all routes that are currently available. Routes that
+// change zettel are not returned in read-only mode. Routes with a key are
+// ordered by key, list routes before zettel routes, and then by method.
+// Static patterns follow.
+func (rt *Router) Routes() []RouteInfo {
+	var result []RouteInfo
+	for k := int(rt.minKey); k <= int(rt.maxKey); k++ {
+		key := byte(k)
+		for index, zettel := range []bool{false, true} {
+			path := "/" + string(key)
+			if zettel {
+				path += "/{zid}"
+			}
+			mh := rt.activeMethods(rt.tables[index][key])
+			methods := make([]string, 0, len(mh))
+			for method, rte := range mh {
+				if method != http.MethodHead || mh[http.MethodGet] != rte {
+					methods = append(methods, method)
+				}
+			}
+			sort.Strings(methods)
+			for _, method := range methods {
+				rte := mh[method]
+				result = append(result, RouteInfo{
+					Path:        path,
+					Key:         key,
+					Zettel:      zettel,
+					Method:      method,
+					Auth:        rte.auth,
+					Write:       rte.write,
+					API:         rte.api,
+					Admin:       rte.admin,
+					Description: rte.descr,
+				})
+			}
+		}
+	}
+	for _, sr := range rt.statics {
+		result = append(result, RouteInfo{Path: sr.pattern, Description: sr.descr})
+	}
+	return result
+}
@@ -0,0 +1,77 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2021 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+// Package server provides a web server.
+package server
+
+import (
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// unixPrefix is the prefix of a listen address that specifies a Unix domain
+// socket.
+const unixPrefix = "unix:"
+
+// listenFDsStart is the first file descriptor passed by systemd.
+const listenFDsStart = 3
+
+// listen creates the listener for the given address. If the process was
+// started via systemd socket activation, the passed socket is used instead.
+func listen(addr string) (net.Listener, error) {
+	if ln, err := systemdListener(); ln != nil || err != nil {
+		return ln, err
+	}
+	if strings.HasPrefix(addr, unixPrefix) {
+		path := addr[len(unixPrefix):]
+		removeStaleSocket(path)
+		return net.Listen("unix", path)
+	}
+	return net.Listen("tcp", addr)
+}
+
+// systemdListener returns the first socket passed by systemd socket
+// activation, or nil if there is none.
+func systemdListener() (net.Listener, error) {
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	nfds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || nfds < 1 {
+		return nil, nil
+	}
+	// Child processes must not use the sockets.
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	f := os.NewFile(listenFDsStart, "LISTEN_FD_3")
+	ln, err := net.FileListener(f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	if nfds > 1 {
+		log.Printf("Using only the first of %v sockets passed by systemd", nfds)
+	}
+	log.Printf("Listening on socket passed by systemd: %v", ln.Addr())
+	return ln, nil
+}
+
+// removeStaleSocket removes a Unix domain socket that was not removed by a
+// previous run, e.g. because of a crash.
+func removeStaleSocket(path string) {
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+}
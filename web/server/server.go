@@ -14,6 +14,7 @@ package server
 import (
 	"context"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -33,9 +34,11 @@ const (
 type Server struct {
 	*http.Server
 	waitShutdown chan struct{}
+	onStart      func(net.Addr)
 }
 
-// New creates a new HTTP server object.
+// New creates a new HTTP server object. The address is either a TCP address,
+// or the path of a Unix domain socket, prefixed with "unix:".
 func New(addr string, handler http.Handler) *Server {
 	if addr == "" {
 		addr = ":http"
@@ -55,6 +58,14 @@ func New(addr string, handler http.Handler) *Server {
 	return srv
 }
 
+// SetRequestTimeout sets the maximum duration of a request. Reading the
+// request may take half of this time. This method should be called before
+// running the server.
+func (srv *Server) SetRequestTimeout(timeout time.Duration) {
+	srv.ReadTimeout = timeout / 2
+	srv.WriteTimeout = timeout
+}
+
 // SetDebug enables debugging goroutines that are started by the server.
 // Basically, just the timeout values are reset. This method should be called
 // before running the server.
@@ -64,9 +75,19 @@ func (srv *Server) SetDebug() {
 	srv.IdleTimeout = 0
 }
 
+// SetOnStart sets a function that is called when the server accepts
+// requests. The function is called with the address of the listener.
+func (srv *Server) SetOnStart(onStart func(net.Addr)) {
+	srv.onStart = onStart
+}
+
 // Run starts the web server and wait for its completion.
 func (srv *Server) Run() error {
-	waitInterrupt := make(chan os.Signal)
+	ln, err := listen(srv.Addr)
+	if err != nil {
+		return err
+	}
+	waitInterrupt := make(chan os.Signal, 1)
 	waitError := make(chan error)
 	signal.Notify(waitInterrupt, os.Interrupt, syscall.SIGTERM)
 
@@ -86,7 +107,10 @@ func (srv *Server) Run() error {
 		waitError <- nil
 	}()
 
-	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	if srv.onStart != nil {
+		go srv.onStart(ln.Addr())
+	}
+	if err = srv.Serve(ln); err != nil && err != http.ErrServerClosed {
 		return err
 	}
 	return <-waitError
@@ -21,16 +21,21 @@ import (
 	"zettelstore.de/z/config/startup"
 	"zettelstore.de/z/domain/meta"
 	"zettelstore.de/z/usecase"
+	"zettelstore.de/z/web/router"
 )
 
 const sessionName = "zsession"
 
 // SetToken sets the session cookie for later user identification.
-func SetToken(w http.ResponseWriter, token []byte, d time.Duration) {
+func SetToken(ctx context.Context, w http.ResponseWriter, token []byte, d time.Duration) {
+	path := startup.URLPrefix()
+	if fwd := router.GetForwarded(ctx); fwd != nil {
+		path = fwd.Prefix
+	}
 	cookie := http.Cookie{
 		Name:     sessionName,
 		Value:    string(token),
-		Path:     startup.URLPrefix(),
+		Path:     path,
 		Secure:   startup.SecureCookie(),
 		HttpOnly: true,
 		SameSite: http.SameSiteStrictMode,
@@ -44,7 +49,7 @@ func SetToken(w http.ResponseWriter, token []byte, d time.Duration) {
 // ClearToken invalidates the session cookie by sending an empty one.
 func ClearToken(ctx context.Context, w http.ResponseWriter) context.Context {
 	if w != nil {
-		SetToken(w, nil, 0)
+		SetToken(ctx, w, nil, 0)
 	}
 	return updateContext(ctx, nil, nil)
 }
@@ -74,6 +79,7 @@ type AuthData struct {
 	Now     time.Time
 	Issued  time.Time
 	Expires time.Time
+	Scope   token.Scope
 }
 
 // GetAuthData returns the full authentication data from the context.
@@ -94,6 +100,21 @@ func GetUser(ctx context.Context) *meta.Meta {
 	return nil
 }
 
+// GetScope returns the scope of the token that authenticated the user. It is
+// not restricted, if there is no such token.
+func GetScope(ctx context.Context) token.Scope {
+	if data := GetAuthData(ctx); data != nil {
+		return data.Scope
+	}
+	return token.Scope{}
+}
+
+// NewContext returns a context, where the given user is authenticated
+// without a token.
+func NewContext(ctx context.Context, user *meta.Meta) context.Context {
+	return updateContext(ctx, user, nil)
+}
+
 func updateContext(
 	ctx context.Context, user *meta.Meta, data *token.Data) context.Context {
 	if data == nil {
@@ -108,6 +129,7 @@ func updateContext(
 			Now:     data.Now,
 			Issued:  data.Issued,
 			Expires: data.Expires,
+			Scope:   data.Scope,
 		})
 }
 